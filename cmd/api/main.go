@@ -15,37 +15,48 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/alexedwards/argon2id"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/redis/go-redis/extra/redisotel/v9"
 	redis "github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/noah-isme/backend-toko/internal/analytics"
 	"github.com/noah-isme/backend-toko/internal/audit"
 	"github.com/noah-isme/backend-toko/internal/auth"
+	"github.com/noah-isme/backend-toko/internal/banlist"
+	"github.com/noah-isme/backend-toko/internal/bootstrap"
+	"github.com/noah-isme/backend-toko/internal/cache"
 	"github.com/noah-isme/backend-toko/internal/cart"
 	"github.com/noah-isme/backend-toko/internal/catalog"
 	"github.com/noah-isme/backend-toko/internal/checkout"
 	"github.com/noah-isme/backend-toko/internal/common"
 	"github.com/noah-isme/backend-toko/internal/config"
+	"github.com/noah-isme/backend-toko/internal/db"
 	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
 	"github.com/noah-isme/backend-toko/internal/events"
 	"github.com/noah-isme/backend-toko/internal/favorites"
 	"github.com/noah-isme/backend-toko/internal/health"
+	"github.com/noah-isme/backend-toko/internal/inbound"
+	"github.com/noah-isme/backend-toko/internal/maintenance"
+	"github.com/noah-isme/backend-toko/internal/media"
 	"github.com/noah-isme/backend-toko/internal/notify"
 	"github.com/noah-isme/backend-toko/internal/obs"
 	"github.com/noah-isme/backend-toko/internal/order"
 	"github.com/noah-isme/backend-toko/internal/payment"
 	"github.com/noah-isme/backend-toko/internal/queue"
 	"github.com/noah-isme/backend-toko/internal/ratelimit"
+	"github.com/noah-isme/backend-toko/internal/reqcache"
 	"github.com/noah-isme/backend-toko/internal/resilience"
 	"github.com/noah-isme/backend-toko/internal/reviews"
 	"github.com/noah-isme/backend-toko/internal/security"
 	"github.com/noah-isme/backend-toko/internal/shipping"
+	"github.com/noah-isme/backend-toko/internal/storefront"
 	"github.com/noah-isme/backend-toko/internal/tenant"
 	"github.com/noah-isme/backend-toko/internal/user"
 	"github.com/noah-isme/backend-toko/internal/voucher"
@@ -88,16 +99,14 @@ func main() {
 		}
 	}
 
-	mailer := common.NopEmailSender{}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.StartupTimeout)
 	defer cancel()
 
 	poolConfig, err := pgxpool.ParseConfig(cfg.DatabaseURL)
 	if err != nil {
 		logger.Fatal().Err(err).Msg("parse database config")
 	}
-	poolConfig.ConnConfig.Tracer = obs.PGXTracer{}
+	poolConfig.ConnConfig.Tracer = obs.PGXTracer{SlowThreshold: cfg.DBSlowQueryThreshold, Logger: logger}
 	if poolConfig.ConnConfig.RuntimeParams == nil {
 		poolConfig.ConnConfig.RuntimeParams = map[string]string{}
 	}
@@ -134,11 +143,15 @@ func main() {
 	}
 	defer pool.Close()
 
-	if err := pool.Ping(ctx); err != nil {
+	if err := startupRetry(cfg, logger, "postgres").Do(ctx, pool.Ping); err != nil {
 		logger.Fatal().Err(err).Msg("ping database")
 	}
+	bootstrap.CheckSchema(ctx, cfg, logger, pool)
 
-	queries := dbgen.New(pool)
+	queries := dbgen.New(cfg.Chaos().DB(pool))
+	if cfg.ChaosEnabled {
+		logger.Warn().Strs("targets", cfg.ChaosTargets).Float64("failure_rate", cfg.ChaosFailureRate).Float64("latency_rate", cfg.ChaosLatencyRate).Msg("chaos testing enabled; faults are injected")
+	}
 
 	if metricsEnabled {
 		prometheus.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
@@ -178,34 +191,74 @@ func main() {
 		}))
 	}
 
-	redisOpts, err := redis.ParseURL(cfg.RedisURL)
-	if err != nil {
-		logger.Fatal().Err(err).Msg("parse redis url")
-	}
-	redisClient := redis.NewClient(redisOpts)
-	if err := redisotel.InstrumentTracing(redisClient); err != nil {
-		logger.Error().Err(err).Msg("instrument redis tracing")
-	}
-	if metricsEnabled {
-		if err := redisotel.InstrumentMetrics(redisClient); err != nil {
-			logger.Error().Err(err).Msg("instrument redis metrics")
+	// STATE_BACKEND=memory runs without Redis: the queue, caches, ban list,
+	// and Redis readiness probe are disabled.
+	var redisClient *redis.Client
+	if cfg.StateBackend != "memory" {
+		redisOpts, err := redis.ParseURL(cfg.RedisURL)
+		if err != nil {
+			logger.Fatal().Err(err).Msg("parse redis url")
 		}
-	}
-	defer func() {
-		if err := redisClient.Close(); err != nil {
-			logger.Error().Err(err).Msg("close redis")
+		redisClient = redis.NewClient(redisOpts)
+		if err := redisotel.InstrumentTracing(redisClient); err != nil {
+			logger.Error().Err(err).Msg("instrument redis tracing")
+		}
+		if metricsEnabled {
+			if err := redisotel.InstrumentMetrics(redisClient); err != nil {
+				logger.Error().Err(err).Msg("instrument redis metrics")
+			}
+		}
+		defer func() {
+			if err := redisClient.Close(); err != nil {
+				logger.Error().Err(err).Msg("close redis")
+			}
+		}()
+		if err := startupRetry(cfg, logger, "redis").Do(ctx, func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		}); err != nil {
+			logger.Fatal().Err(err).Msg("ping redis")
 		}
-	}()
-	if err := redisClient.Ping(ctx).Err(); err != nil {
-		logger.Fatal().Err(err).Msg("ping redis")
 	}
+	taskQueue := queue.Enqueuer{R: redisClient, Prefix: cfg.QueueRedisPrefix, DedupTTL: cfg.IdempotencyTTL, MaxAttempts: cfg.QueueMaxAttempts}
+	var mailer common.EmailSender = notify.QueuedEmailSender{
+		Queue:       taskQueue,
+		MaxAttempts: cfg.EmailMaxAttempts,
+	}
+	if !cfg.EmailQueueEnabled || redisClient == nil {
+		// Development fallback: send on the request goroutine.
+		mailer = bootstrap.NewEmailSender(cfg, logger)
+	}
+	mediaStorage, localMedia := bootstrap.NewMediaStorage(cfg)
+	// Opt-in: with REDIS_TENANT_ISOLATION catalog cache keys are namespaced
+	// per tenant.
+	redisScope := tenant.KeyScope{Enabled: cfg.RedisTenantIsolation}
 	catalogCache := catalog.NewCache(redisClient, cfg.CatalogCacheTTL, cfg.RedisCachePrefix)
+	catalogCache.SetTenantScope(redisScope)
 	catalogService, err := catalog.NewService(catalog.ServiceConfig{
-		Queries:      queries,
-		Cache:        catalogCache,
-		DefaultPage:  cfg.CatalogDefaultPage,
-		DefaultLimit: cfg.CatalogDefaultLimit,
-		MaxLimit:     cfg.CatalogMaxLimit,
+		Queries:       queries,
+		Cache:         catalogCache,
+		Images:        media.Resolver{Storage: mediaStorage},
+		DefaultPage:   cfg.CatalogDefaultPage,
+		DefaultLimit:  cfg.CatalogDefaultLimit,
+		MaxLimit:      cfg.CatalogMaxLimit,
+		DefaultSort:   cfg.CatalogDefaultSort,
+		DefaultLocale: cfg.CatalogDefaultLocale,
+		Locales:       cfg.CatalogLocales,
+		Badges: catalog.BadgeRules{
+			Sale:        cfg.CatalogBadgeSale,
+			NewWithin:   time.Duration(cfg.CatalogBadgeNewDays) * 24 * time.Hour,
+			LowStock:    cfg.CatalogBadgeLowStock,
+			Bestsellers: cfg.CatalogBadgeBestsellerTop,
+
+			PriceDropWithin:     time.Duration(cfg.CatalogBadgePriceDropDays) * 24 * time.Hour,
+			PriceDropMinPercent: cfg.CatalogBadgePriceDropMinPercent,
+		},
+		HideOutOfStock: cfg.CatalogHideOutOfStock,
+		Recommendations: catalog.RecommendationConfig{
+			DefaultCount: cfg.RecommendationsDefaultCount,
+			MaxCount:     cfg.RecommendationsMaxCount,
+		},
+		PriceTrendPoints: cfg.CatalogPriceTrendPoints,
 	})
 	if err != nil {
 		logger.Fatal().Err(err).Msg("initialise catalog service")
@@ -213,21 +266,35 @@ func main() {
 	catalogHandler := catalog.NewHandler(catalog.HandlerConfig{Service: catalogService})
 
 	authService, err := auth.NewService(auth.Config{
-		Queries:         queries,
-		Secret:          cfg.JWTSecret,
-		AccessTokenTTL:  cfg.AccessTokenTTL,
-		RefreshTokenTTL: cfg.RefreshTokenTTL,
-		ResetTokenTTL:   cfg.PasswordResetTTL,
-		Issuer:          cfg.JWTIssuer,
-		Audience:        cfg.JWTAudience,
-		ClockSkew:       cfg.JWTClockSkew,
+		Queries:               queries,
+		Pool:                  pool,
+		Secret:                cfg.JWTSecret,
+		AccessTokenTTL:        cfg.AccessTokenTTL,
+		RefreshTokenTTL:       cfg.RefreshTokenTTL,
+		ResetTokenTTL:         cfg.PasswordResetTTL,
+		Issuer:                cfg.JWTIssuer,
+		Audience:              cfg.JWTAudience,
+		Audiences:             cfg.JWTAudiences,
+		AcceptedAudiences:     cfg.JWTAcceptedAudiences,
+		ClockSkew:             cfg.JWTClockSkew,
+		TOTPEncryptionKey:     cfg.TOTPEncryptionKey,
+		AccessTokenTTLByRole:  cfg.AccessTokenTTLByRole,
+		RefreshTokenTTLByRole: cfg.RefreshTokenTTLByRole,
+		AccountRecoveryWindow: cfg.AccountRecoveryWindow,
+		PasswordHashParams: &argon2id.Params{
+			Memory:      uint32(cfg.PasswordHashMemoryKiB),
+			Iterations:  uint32(cfg.PasswordHashIterations),
+			Parallelism: uint8(cfg.PasswordHashParallelism),
+			SaltLength:  argon2id.DefaultParams.SaltLength,
+			KeyLength:   argon2id.DefaultParams.KeyLength,
+		},
 	})
 	if err != nil {
 		logger.Fatal().Err(err).Msg("initialise auth service")
 	}
 	authHandler := &auth.Handler{
 		Service:               authService,
-		Mailer:                common.NopEmailSender{},
+		Mailer:                mailer,
 		RefreshCookieName:     cfg.RefreshCookieName,
 		RefreshCookieDomain:   cfg.RefreshCookieDomain,
 		RefreshCookieSecure:   cfg.RefreshCookieSecure,
@@ -238,8 +305,15 @@ func main() {
 
 	addressService := user.NewService(pool)
 	addressHandler := &user.Handler{Service: addressService}
+	userAdmin := &user.AdminHandler{Service: addressService}
 
 	idem := common.Idem{R: redisClient, TTL: cfg.IdempotencyTTL}
+	if cfg.StateBackend == "memory" {
+		if cfg.AppEnv == "production" {
+			logger.Warn().Msg("STATE_BACKEND=memory keeps rate limits and idempotency keys per process")
+		}
+		idem.Store = common.NewMemoryIdemStore()
+	}
 
 	defaultTenantIDStr := envOrDefault("TENANT_DEFAULT_ID", "17c19dca-9a70-4e30-bd34-9af2b1e7b01b")
 	defaultTenantID, err := cart.ToUUID(defaultTenantIDStr)
@@ -256,54 +330,64 @@ func main() {
 
 	cartSvc := &cart.Service{
 		Q:                          queries,
+		Pool:                       pool,
 		TTL:                        cfg.CartTTL,
 		VoucherPerUserLimitDefault: cfg.VoucherPerUserLimit,
+		VoucherReleaseOnCancel:     cfg.VoucherReleaseOnCancel,
 		DefaultTenantID:            defaultTenantID,
+		Rounding:                   cfg.PricingRounding,
+		Limits: cart.Limits{
+			MaxItems:    cfg.CartMaxItems,
+			MaxLineQty:  cfg.CartMaxLineQty,
+			MaxTotalQty: cfg.CartMaxTotalQty,
+		},
+		RequestCache:  slices.Contains(cfg.RequestCacheServices, "cart"),
+		ExpiryWarning: cfg.CartExpiryWarning,
+	}
+	voucherSvc := &voucher.Service{Q: queries, DefaultPerUserLimit: cfg.VoucherPerUserLimit, ReleaseOnCancel: cfg.VoucherReleaseOnCancel, Rounding: cfg.PricingRounding}
+	voucherHandler := &voucher.Handler{Q: queries, Pool: pool, Svc: voucherSvc, DefaultPriority: cfg.VoucherDefaultPriority, CatalogCache: catalogCache, Analytics: nil}
+	parcelCfg := shipping.ParcelConfig{
+		DefaultItemWeightGram: cfg.ShippingDefaultItemWeightGram,
+		VolumetricDivisor:     cfg.ShippingVolumetricDivisor,
 	}
-	voucherSvc := &voucher.Service{Q: queries, DefaultPerUserLimit: cfg.VoucherPerUserLimit}
-	voucherHandler := &voucher.Handler{Q: queries, Svc: voucherSvc, DefaultPriority: cfg.VoucherDefaultPriority, CatalogCache: catalogCache, Analytics: nil}
+	courierPolicy := shipping.CourierPolicy{Prefer: cfg.ShippingPreferredCouriers}
+	courierAdmin := &shipping.CourierAdmin{Q: queries, Defaults: courierPolicy}
 	cartHandler := &cart.Handler{
 		Q:              queries,
 		Svc:            cartSvc,
 		ShippingClient: shipping.MockClient{},
 		ShippingOrigin: cfg.ShippingOriginCode,
+		ShippingRules:  shipping.DefaultRules(cfg.ShippingFreeThreshold),
 		TaxBps:         cfg.PricingTaxRateBPS,
+		Rounding:       cfg.PricingRounding,
 		Currency:       cfg.CurrencyCode,
+		Parcel:         parcelCfg,
+		CourierPolicy:  courierPolicy,
+
+		ProviderTimeout: cfg.OutboundTimeout,
 	}
 
 	notifyStore := notify.NewStore(queries)
-	taskQueue := queue.Enqueuer{R: redisClient, Prefix: cfg.QueueRedisPrefix, DedupTTL: cfg.IdempotencyTTL, MaxAttempts: cfg.QueueMaxAttempts}
-	webhookHTTPClient := notify.HttpClient(int(cfg.WebhookRequestTimeout/time.Millisecond), cfg.WebhookAllowInsecureTLS)
-	dispatcher := &notify.Dispatcher{
-		Store: notifyStore,
-		HTTP: &resilience.HTTPClient{
-			Client:      webhookHTTPClient,
-			Breaker:     resilience.NewBreaker(cfg.CircuitWebhookMinReq, cfg.CircuitWebhookFailureRate, cfg.CircuitWebhookOpenFor),
-			BaseBackoff: cfg.RetryBase,
-			MaxAttempts: cfg.RetryMaxAttempts,
-			Jitter:      cfg.RetryJitterPercent,
-			Timeout:     cfg.OutboundTimeout,
-			Target:      "webhook-delivery",
-			Logger:      &logger,
-		},
-		Queue:              taskQueue,
-		BackoffBaseSec:     cfg.WebhookBackoffBaseSec,
-		DefaultMaxAttempts: cfg.WebhookDefaultMaxAttempts,
-		Enabled:            cfg.WebhookDeliveryEnabled,
-		Replay:             notify.RedisReplayProtector{Client: redisClient},
-		ReplayTTL:          cfg.WebhookReplayTTL,
+	dispatcher := bootstrap.NewWebhookDispatcher(cfg, logger, notifyStore, taskQueue, redisClient)
+	emailPrefs := notify.EmailPreferences{
+		Store:   queries,
+		Routing: notify.EmailRouting{Categories: cfg.NotifyEmailCategories, Required: cfg.NotifyEmailRequiredCategories},
 	}
 	emailNotifier := notify.EmailNotifier{
 		Mail:         mailer,
 		Enabled:      cfg.NotifyEmailEnabled,
 		From:         cfg.NotifyEmailFrom,
 		TopicToggles: cfg.NotifyEmailTopics,
+		Preferences:  emailPrefs,
 	}
+	emailPrefsHandler := notify.PreferenceHandler{Prefs: emailPrefs}
 	bus := &events.Bus{
 		Store:     queries,
 		Scheduler: dispatcher,
 		Notifiers: []events.Notifier{emailNotifier},
+		Logger:    &logger,
 	}
+	dispatcher.Events = bus
 
 	checkoutSvc := &checkout.Service{
 		Q:        queries,
@@ -312,12 +396,31 @@ func main() {
 		TaxBps:   cfg.PricingTaxRateBPS,
 		Currency: cfg.CurrencyCode,
 		Events:   bus,
+
+		Shipping:        shipping.MockClient{},
+		ShippingOrigin:  cfg.ShippingOriginCode,
+		ShippingRules:   shipping.DefaultRules(cfg.ShippingFreeThreshold),
+		ShippingTimeout: cfg.OutboundTimeout,
+		Parcel:          parcelCfg,
+		CourierPolicy:   courierPolicy,
+		Limits:          checkout.OrderLimits{Min: cfg.CheckoutMinOrderTotal, Max: cfg.CheckoutMaxOrderTotal},
+		Rounding:        cfg.PricingRounding,
+
+		PriceToleranceBps: cfg.CheckoutPriceToleranceBps,
+		RequestCache:      slices.Contains(cfg.RequestCacheServices, "checkout"),
 	}
 	checkoutHandler := &checkout.Handler{Svc: checkoutSvc}
 
-	orderHandler := &order.Handler{Q: queries}
-	orderAdmin := &order.AdminHandler{Q: queries}
+	orderHandler := &order.Handler{Q: queries, ReleaseVoucherOnCancel: cfg.VoucherReleaseOnCancel}
+	orderAdmin := &order.AdminHandler{
+		Q:                      queries,
+		ReleaseVoucherOnCancel: cfg.VoucherReleaseOnCancel,
+		ExportFormat:           cfg.OrderExportFormat,
+		ExportStatuses:         cfg.OrderExportStatuses,
+	}
 	notifyAdmin := &notify.AdminHandler{Store: notifyStore, Disp: dispatcher}
+	eventsAdmin := &events.AdminHandler{Store: queries}
+	webhookPayloads := &notify.PayloadHandler{Store: notifyStore}
 	queueAdmin := &queue.AdminHandler{
 		Store:             queue.NewStore(pool),
 		Queue:             taskQueue,
@@ -335,57 +438,84 @@ func main() {
 	}
 	shipSvc := &shipping.Service{
 		Q:                      queries,
-		Provider:               shipProvider,
+		Provider:               shipping.WithChaos(shipProvider, cfg.Chaos()),
 		Mail:                   mailer,
 		NotifyOnShipped:        cfg.NotifyOnShipped,
 		NotifyOnOutForDelivery: cfg.NotifyOnOutForDelivery,
 		NotifyOnDelivered:      cfg.NotifyOnDelivered,
+		EmailAllowed:           emailPrefs.Allowed,
 		Events:                 bus,
+		RequestCache:           slices.Contains(cfg.RequestCacheServices, "shipping"),
 	}
 	shipHandler := &shipping.Handler{Svc: shipSvc, Q: queries}
-	shipWebhook := shipping.Webhook{Svc: shipSvc, Replay: redisClient, ReplayTTL: cfg.ShippingTrackReplayTTL}
+	shipWebhook := shipping.Webhook{Svc: shipSvc, Inbound: newInboundConsumer(queries, cfg, cfg.ShippingTrackReplayTTL)}
 
-	providers := map[string]payment.Provider{
-		"midtrans": payment.Midtrans{
-			ServerKey: cfg.MidtransServerKey,
-			BaseURL:   cfg.MidtransBaseURL,
-			Sandbox:   cfg.PaymentSandbox,
-		},
-		"xendit": payment.Xendit{
-			SecretKey: cfg.XenditSecretKey,
-			BaseURL:   cfg.XenditBaseURL,
-		},
+	providerConfigs := make(map[string]payment.ProviderConfig, len(cfg.PaymentProviders))
+	for name, pc := range cfg.PaymentProviders {
+		providerConfigs[name] = payment.ProviderConfig{SecretKey: pc.SecretKey, BaseURL: pc.BaseURL, Sandbox: cfg.PaymentSandbox}
+	}
+	providers, err := payment.OpenAll(providerConfigs)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("open payment providers")
+	}
+	if fake, ok := providers[payment.FakeProviderName].(*payment.Fake); ok {
+		fake.Callbacks = taskQueue
+		fake.CallbackDelay = cfg.PaymentFakeCallbackDelay
+		fake.CallbackBaseURL = cfg.PaymentCallbackBaseURL
+		logger.Warn().Msg("fake payment provider enabled; payments settle without a real provider")
 	}
-	activeProvider := providers[cfg.PaymentProvider]
-	if activeProvider == nil {
-		activeProvider = providers["midtrans"]
+	for name, provider := range providers {
+		providers[name] = payment.WithChaos(provider, cfg.Chaos())
 	}
 	paymentSvc := &payment.Service{
 		Q:               queries,
-		Provider:        activeProvider,
+		Provider:        providers[cfg.PaymentProvider],
 		IntentTTL:       cfg.PaymentIntentTTL,
 		CallbackBaseURL: cfg.PaymentCallbackBaseURL,
+		Timeout:         cfg.OutboundTimeout,
+		Providers:       providers,
+		Currency:        cfg.CurrencyCode,
 	}
 	paymentHandler := &payment.Handler{Svc: paymentSvc, Q: queries}
 	webhookHandler := payment.Webhook{
 		Q:            queries,
 		Pool:         pool,
 		Providers:    providers,
-		Replay:       redisClient,
-		ReplayTTL:    cfg.WebhookReplayTTL,
+		Inbound:      newInboundConsumer(queries, cfg, cfg.WebhookReplayTTL),
 		Voucher:      voucherSvc,
 		Events:       bus,
 		CatalogCache: catalogCache,
 		Analytics:    nil,
+
+		ReleaseVoucherOnCancel: cfg.VoucherReleaseOnCancel,
 	}
 
-	analyticsSvc := &analytics.Service{Q: queries, R: redisClient, TTL: cfg.AnalyticsCacheTTL, DefaultRange: cfg.AnalyticsDefaultRange, Prefix: cfg.RedisCachePrefix}
+	analyticsSvc := &analytics.Service{
+		Q:            queries,
+		R:            redisClient,
+		TTL:          cfg.AnalyticsCacheTTL,
+		DefaultRange: cfg.AnalyticsDefaultRange,
+		Prefix:       cfg.RedisCachePrefix,
+		Limits: analytics.RangeLimits{
+			MaxDays:          cfg.AnalyticsMaxRangeDays,
+			MaxDaysByReport:  cfg.AnalyticsMaxRangeDaysByReport,
+			WeeklyAfterDays:  cfg.AnalyticsWeeklyAfterDays,
+			MonthlyAfterDays: cfg.AnalyticsMonthlyAfterDays,
+		},
+	}
 	voucherHandler.Analytics = analyticsSvc
 	webhookHandler.Analytics = analyticsSvc
 	analyticsHandler := &analytics.Handler{Svc: analyticsSvc}
 
 	reviewsSvc := &reviews.Service{Q: queries}
 	reviewsHandler := &reviews.Handler{Svc: reviewsSvc}
+	storefrontHandler := &storefront.Handler{Catalog: catalogService, Reviews: reviewsSvc}
+	catalogAdmin := &catalog.AdminHandler{Q: queries, Cache: catalogCache, Service: catalogService}
+	mediaAdmin := &media.AdminHandler{
+		Uploader:     &media.Uploader{Storage: mediaStorage, MaxBytes: cfg.MediaMaxUploadBytes},
+		Q:            queries,
+		CatalogCache: catalogCache,
+	}
 
 	favoritesSvc := &favorites.Service{Q: queries}
 	favoritesHandler := &favorites.Handler{Svc: favoritesSvc}
@@ -398,7 +528,8 @@ func main() {
 		auditSample = 1
 	}
 	auditEnabled := envBool("AUDIT_ENABLED", true) && auditSample > 0
-	auditSvc := &audit.Service{Store: queries, Enabled: auditEnabled, SamplingRate: auditSample}
+	redactor := common.Redactor{Fields: cfg.RedactFields, Headers: cfg.RedactHeaders}
+	auditSvc := &audit.Service{Store: queries, Enabled: auditEnabled, SamplingRate: auditSample, Redactor: redactor}
 	auditHandler := audit.Handler{Store: auditSvc.Store}
 	auditRecorder := audit.HTTPRecorder{
 		Service: auditSvc,
@@ -409,6 +540,22 @@ func main() {
 		},
 	}
 
+	var banList *banlist.List
+	if redisClient != nil {
+		banList = &banlist.List{
+			R:           redisClient,
+			Prefix:      envOrDefault("BAN_REDIS_PREFIX", "ban:"),
+			NegativeTTL: envDurationMillis("BAN_NEGATIVE_CACHE_MS", 5000),
+		}
+	}
+	banGuard := banlist.Guard{
+		List: banList,
+		OnError: func(err error) {
+			logger.Error().Err(err).Msg("ban list lookup failed")
+		},
+	}
+	banAdmin := &banlist.AdminHandler{List: banList, Audit: auditSvc, OnError: auditRecorder.OnError}
+
 	securityHeaders := security.Headers{
 		Enable:                envBool("SECURITY_ENABLE_HEADERS", true),
 		EnableHSTS:            envBool("SECURITY_ENABLE_HSTS", true),
@@ -422,15 +569,68 @@ func main() {
 	if strings.TrimSpace(corsOrigins) == "" {
 		corsOrigins = "http://localhost:3000"
 	}
+	publicOrigins := strings.Split(corsOrigins, ",")
+	corsMaxAge := time.Duration(envInt("CORS_MAX_AGE_SEC", 600)) * time.Second
+	publicCORS, err := security.NewCORS(security.CORSPolicy{
+		AllowedOrigins:   publicOrigins,
+		MaxAge:           corsMaxAge,
+		AllowCredentials: envBool("CORS_ALLOW_CREDENTIALS", !slices.Contains(publicOrigins, "*")),
+		// The admin and analytics groups mount adminCORS themselves.
+		Except: []string{"/api/v1/admin", "/api/v1/analytics"},
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid public CORS policy")
+	}
+	adminOrigins := slices.DeleteFunc(strings.Split(envOrDefault("CORS_ADMIN_ALLOWED_ORIGINS", corsOrigins), ","), func(o string) bool {
+		return strings.TrimSpace(o) == "*"
+	})
+	adminCORS, err := security.NewCORS(security.CORSPolicy{
+		AllowedOrigins:   adminOrigins,
+		MaxAge:           corsMaxAge,
+		AllowCredentials: envBool("CORS_ADMIN_ALLOW_CREDENTIALS", true),
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("invalid admin CORS policy")
+	}
 	bodyLimitBytes := envInt("SECURITY_BODY_LIMIT_BYTES", 1_048_576)
 	if bodyLimitBytes <= 0 {
 		bodyLimitBytes = 1_048_576
 	}
+	gzipRequests := envBool("HTTP_GZIP_REQUESTS_ENABLED", true)
+	gzipResponses := envBool("HTTP_GZIP_RESPONSES_ENABLED", true)
+	gzipMinBytes := envInt("HTTP_GZIP_MIN_BYTES", 1024)
 	csrfEnabled := envBool("SECURITY_CSRF_ENABLED", true)
 	csrfHeader := envOrDefault("SECURITY_CSRF_HEADER", "X-CSRF-Token")
 
+	common.DefaultListFormat = cfg.ListEnvelope
+	common.TrustedProxies = cfg.TrustedProxies
+	common.Int64AsString = cfg.JSONInt64AsString
+
+	var maintenanceStore maintenance.Store = maintenance.RedisStore{R: redisClient}
+	if cfg.StateBackend == "memory" {
+		maintenanceStore = &maintenance.MemoryStore{}
+	}
+	maintenanceGuard := &maintenance.Guard{
+		Store: maintenanceStore,
+		Static: maintenance.State{
+			Mode:    cfg.MaintenanceMode,
+			Message: cfg.MaintenanceMessage,
+		},
+		BypassToken:       cfg.MaintenanceBypassToken,
+		Exempt:            []string{maintenanceAdminPath},
+		DefaultRetryAfter: cfg.MaintenanceRetryAfter,
+		Logger:            &logger,
+	}
+	maintenanceAdmin := &maintenance.AdminHandler{Guard: maintenanceGuard}
+	redisCacheAdmin := cache.AdminHandler{Client: redisClient, Scope: redisScope}
+
 	rateLimitPrefix := envOrDefault("RATE_LIMIT_REDIS_PREFIX", "rl:")
-	limiter := ratelimit.Limiter{Client: redisClient, Prefix: rateLimitPrefix}
+	var limiter ratelimit.Store = ratelimit.Limiter{Client: redisClient, Prefix: rateLimitPrefix}
+	if cfg.StateBackend == "memory" {
+		limiter = ratelimit.NewMemoryLimiter()
+	}
+	// Allowed responses warn clients once they have used this share of a limit.
+	rateLimitWarnAt := float64(envInt("RATE_LIMIT_WARN_PCT", 80)) / 100
 	rateLimitErr := func(err error) {
 		if err != nil {
 			logger.Error().Err(err).Msg("rate limiter failure")
@@ -442,6 +642,7 @@ func main() {
 			Key:    func(*http.Request) string { return "global" },
 			Window: time.Duration(envInt("RATE_LIMIT_GLOBAL_WINDOW_SEC", 60)) * time.Second,
 			Max:    envInt("RATE_LIMIT_GLOBAL_MAX", 1200),
+			WarnAt: rateLimitWarnAt,
 		},
 		OnError: rateLimitErr,
 	}.Middleware
@@ -457,6 +658,7 @@ func main() {
 			},
 			Window: time.Duration(envInt("RATE_LIMIT_IP_WINDOW_SEC", 60)) * time.Second,
 			Max:    envInt("RATE_LIMIT_IP_MAX", 240),
+			WarnAt: rateLimitWarnAt,
 		},
 		OnError: rateLimitErr,
 	}.Middleware
@@ -475,6 +677,7 @@ func main() {
 			},
 			Window: time.Duration(envInt("RATE_LIMIT_USER_WINDOW_SEC", 60)) * time.Second,
 			Max:    envInt("RATE_LIMIT_USER_MAX", 120),
+			WarnAt: rateLimitWarnAt,
 		},
 		OnError: rateLimitErr,
 	}.Middleware
@@ -490,6 +693,46 @@ func main() {
 			},
 			Window: time.Duration(envInt("RATE_LIMIT_LOGIN_WINDOW_SEC", 300)) * time.Second,
 			Max:    envInt("RATE_LIMIT_LOGIN_MAX", 10),
+			WarnAt: rateLimitWarnAt,
+		},
+		OnError: rateLimitErr,
+	}.Middleware
+	// Two-factor codes only have a million values, so attempts are limited per
+	// user: the signed-in user, or the one a login challenge was issued to.
+	// Requests without a valid challenge fall back to the client IP.
+	twoFactorLimiter := ratelimit.Handler{
+		Limiter: limiter,
+		Config: ratelimit.Config{
+			Key: func(r *http.Request) string {
+				if userID, ok := common.UserID(r.Context()); ok {
+					return "2fa:user:" + userID
+				}
+				if userID, ok := authHandler.TwoFactorLoginUser(r); ok {
+					return "2fa:user:" + userID
+				}
+				ip := common.ClientIP(r)
+				if ip == "" {
+					ip = "unknown"
+				}
+				return "2fa:ip:" + ip
+			},
+			Window: time.Duration(envInt("RATE_LIMIT_2FA_WINDOW_SEC", 300)) * time.Second,
+			Max:    envInt("RATE_LIMIT_2FA_MAX", 5),
+			WarnAt: rateLimitWarnAt,
+		},
+		OnError: rateLimitErr,
+	}.Middleware
+	// Exports read a user's whole history, so each user gets only a few an hour.
+	exportLimiter := ratelimit.Handler{
+		Limiter: limiter,
+		Config: ratelimit.Config{
+			Key: func(r *http.Request) string {
+				userID, _ := common.UserID(r.Context())
+				return "export:user:" + userID
+			},
+			Window: time.Duration(envInt("RATE_LIMIT_EXPORT_WINDOW_SEC", 3600)) * time.Second,
+			Max:    envInt("RATE_LIMIT_EXPORT_MAX", 3),
+			WarnAt: rateLimitWarnAt,
 		},
 		OnError: rateLimitErr,
 	}.Middleware
@@ -508,9 +751,13 @@ func main() {
 
 	r := chi.NewRouter()
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	r.Use(common.EchoRequestID)
+	r.Use(common.RealIP)
 	r.Use(middleware.Recoverer)
 	r.Use(obs.RoutePatternMiddleware)
+	if len(cfg.RequestCacheServices) > 0 {
+		r.Use(reqcache.Middleware)
+	}
 	r.Use(func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 			select {
@@ -518,7 +765,7 @@ func main() {
 				defer func() { <-inflightSem }()
 				next.ServeHTTP(w, req)
 			case <-req.Context().Done():
-				http.Error(w, "request cancelled", http.StatusRequestTimeout)
+				common.JSONError(w, http.StatusRequestTimeout, common.CodeRequestCancelled, "request cancelled", nil)
 			}
 		})
 	})
@@ -528,14 +775,24 @@ func main() {
 	if metricsEnabled && httpMetrics != nil {
 		r.Use(obs.HTTPObs{Metrics: httpMetrics}.Middleware)
 	}
-	r.Use(obs.RequestLogger{Logger: logger}.Middleware)
+	r.Use(obs.RequestLogger{
+		Logger:        logger,
+		Fields:        cfg.AccessLogFields,
+		SampleRates:   cfg.AccessLogSampleRates,
+		SlowThreshold: cfg.AccessLogSlowThreshold,
+		Redactor:      redactor,
+	}.Middleware)
 	r.Use(securityHeaders.Middleware)
-	if strings.TrimSpace(corsOrigins) != "" {
-		r.Use(security.AllowCORS(corsOrigins))
+	r.Use(publicCORS.Middleware)
+	if gzipResponses {
+		r.Use(security.Compress{MinSize: gzipMinBytes}.Middleware)
 	}
-	r.Use(security.BodyLimit{Max: int64(bodyLimitBytes)}.Middleware)
+	if gzipRequests {
+		r.Use(security.Decompress{Max: int64(bodyLimitBytes), Skip: []string{mediaUploadPath}}.Middleware)
+	}
+	r.Use(security.BodyLimit{Max: int64(bodyLimitBytes), Skip: []string{mediaUploadPath}}.Middleware)
 	if csrfEnabled {
-		r.Use(security.CSRF{Header: csrfHeader}.Middleware)
+		r.Use(security.CSRF{Header: csrfHeader, Exempt: []string{"/api/v1/batch"}}.Middleware)
 	}
 
 	if metricsEnabled {
@@ -550,23 +807,46 @@ func main() {
 
 	healthHandler := health.Handler{
 		Checker:      readinessChecker{db: pool, redis: redisClient},
+		NoRedis:      redisClient == nil,
 		DBTimeout:    envDurationMillis("HEALTH_READY_DB_TIMEOUT_MS", 500),
 		RedisTimeout: envDurationMillis("HEALTH_READY_REDIS_TIMEOUT_MS", 300),
 	}
+	if redisClient != nil {
+		workerProbe := health.RedisWorkerProbe{R: redisClient, Key: health.WorkerHeartbeatKey(cfg.QueueRedisPrefix)}
+		healthHandler.Heartbeat = workerProbe
+		healthHandler.HeartbeatMaxAge = cfg.WorkerLivenessTTL
+		if metricsEnabled {
+			prometheus.MustRegister(health.NewWorkerHeartbeatAge(metricsNamespace, workerProbe, healthHandler.RedisTimeout))
+		}
+	}
+	if cfg.SchemaCheck != db.SchemaCheckOff {
+		healthHandler.Schema = schemaChecker{db: pool}
+		healthHandler.SchemaRequired = cfg.SchemaCheck == db.SchemaCheckFail
+	}
+	if localMedia != nil {
+		r.Handle("/media/*", http.StripPrefix("/media", localMedia.Handler()))
+	}
 	r.Get("/health/live", healthHandler.Live)
 	r.Get("/health/ready", healthHandler.Ready)
+	r.Get("/health/worker", healthHandler.Worker)
 
 	r.Route("/api/v1", func(v chi.Router) {
+		v.Use(maintenanceGuard.Middleware)
+		v.Use(authMiddleware.Authenticate)
+		v.Use(banGuard.Middleware)
 		v.Use(globalLimiter)
 		v.Use(ipLimiter)
 		v.Use(userLimiter)
 		v.Use(tenantResolver.Middleware)
+		v.Use(obs.TenantAccessLog)
 
 		v.Get("/categories", catalogHandler.Categories)
 		v.Get("/brands", catalogHandler.Brands)
 		v.Get("/products", catalogHandler.Products)
 		v.Get("/products/{slug}", catalogHandler.ProductDetail)
 		v.Get("/products/{slug}/related", catalogHandler.Related)
+		v.Get("/products/{slug}/recommendations", catalogHandler.Recommendations)
+		v.Post("/batch", storefrontHandler.Batch)
 
 		// Reviews
 		v.Get("/products/{id}/reviews", reviewsHandler.List)
@@ -583,20 +863,36 @@ func main() {
 		})
 
 		v.Route("/auth", func(a chi.Router) {
-			a.Use(auditRecorder.Middleware(audit.HTTPConfig{ResourceType: "auth"}))
+			a.Use(auditRecorder.Middleware(audit.HTTPConfig{ResourceType: "auth", CaptureBody: true}))
 			a.Post("/register", authHandler.Register)
 			a.With(loginLimiter).Post("/login", authHandler.Login)
+			a.With(loginLimiter).Post("/reactivate", authHandler.Reactivate)
 			a.Post("/refresh", authHandler.Refresh)
 			a.Post("/logout", authHandler.Logout)
 			a.With(loginLimiter).Post("/password/forgot", authHandler.Forgot)
 			a.With(loginLimiter).Post("/password/reset", authHandler.Reset)
+			a.With(twoFactorLimiter).Post("/2fa/login", authHandler.TwoFactorLogin)
 
 			a.Group(func(protected chi.Router) {
 				protected.Use(authMiddleware.RequireAuth)
 				protected.Get("/me", authHandler.Me)
+				protected.Post("/2fa/enroll", authHandler.TwoFactorEnroll)
+				protected.With(twoFactorLimiter).Post("/2fa/verify", authHandler.TwoFactorVerify)
+				protected.With(twoFactorLimiter).Post("/2fa/disable", authHandler.TwoFactorDisable)
 			})
 		})
 
+		v.With(
+			authMiddleware.RequireAuth,
+			auditRecorder.Middleware(audit.HTTPConfig{ResourceType: "user", Action: "deactivate"}),
+		).Delete("/users/me", authHandler.Deactivate)
+		v.With(authMiddleware.RequireAuth, exportLimiter).Get("/users/me/export", addressHandler.Export)
+
+		v.Route("/users/me/email-preferences", func(p chi.Router) {
+			p.Use(authMiddleware.RequireAuth)
+			p.Get("/", emailPrefsHandler.Get)
+			p.Put("/", emailPrefsHandler.Update)
+		})
 		v.Route("/users/me/addresses", func(a chi.Router) {
 			a.Use(authMiddleware.RequireAuth)
 			a.Get("/", addressHandler.List)
@@ -620,11 +916,13 @@ func main() {
 				g.Delete("/{id}/voucher", cartHandler.RemoveVoucher)
 				g.Post("/{id}/quote/shipping", cartHandler.QuoteShipping)
 				g.Post("/{id}/quote/tax", cartHandler.QuoteTax)
+				g.Post("/{id}/touch", cartHandler.Touch)
 				g.With(authMiddleware.RequireAuth).Post("/merge", cartHandler.Merge)
 			})
 		})
 
 		v.With(idem.Middleware, authMiddleware.RequireAuth).Post("/checkout", checkoutHandler.Checkout)
+		v.With(authMiddleware.RequireAuth).Post("/checkout/preview", checkoutHandler.Preview)
 
 		v.Group(func(authR chi.Router) {
 			authR.Use(authMiddleware.RequireAuth)
@@ -635,31 +933,68 @@ func main() {
 		})
 
 		v.Route("/admin", func(admin chi.Router) {
+			admin.Use(adminCORS.Middleware)
 			admin.Use(authMiddleware.RequireAuth)
 			admin.Use(requireRole(queries, "admin"))
-			admin.Use(auditRecorder.Middleware(audit.HTTPConfig{ResourceType: "admin"}))
+			admin.Use(auditRecorder.Middleware(audit.HTTPConfig{ResourceType: "admin", RoutePrefix: "/api/v1/admin", Routes: adminAuditRoutes, CaptureBody: true}))
 			admin.Post("/vouchers", voucherHandler.Create)
+			admin.Post("/vouchers/bulk", voucherHandler.Bulk)
 			admin.Put("/vouchers/{code}", voucherHandler.Update)
 			admin.Post("/vouchers/preview", voucherHandler.Preview)
 			admin.Post("/orders/{id}/shipment", shipHandler.AdminCreate)
+			admin.Get("/orders", orderAdmin.List)
+			admin.Get("/orders/export", orderAdmin.Export)
 			admin.Patch("/orders/{id}/status", orderAdmin.PatchStatus)
+			admin.Post("/orders/{id}/refund", paymentHandler.Refund)
 			admin.Post("/webhooks", notifyAdmin.CreateEndpoint)
 			admin.Put("/webhooks/{id}", notifyAdmin.UpdateEndpoint)
 			admin.Get("/webhooks", notifyAdmin.ListEndpoints)
 			admin.Delete("/webhooks/{id}", notifyAdmin.DeleteEndpoint)
+			admin.Post("/webhooks/{id}/enable", notifyAdmin.EnableEndpoint)
+			admin.Post("/webhooks/{id}/disable", notifyAdmin.DisableEndpoint)
+			admin.Post("/webhooks/{id}/test", notifyAdmin.TestEndpoint)
 			admin.Get("/webhook-deliveries", notifyAdmin.ListDeliveries)
 			admin.Post("/webhook-deliveries/{id}/replay", notifyAdmin.ReplayDelivery)
+			admin.Get("/webhook-deliveries/{id}/attempts", notifyAdmin.ListAttempts)
+			admin.Get("/domain-events", eventsAdmin.List)
+			admin.Get("/domain-events/schemas", eventsAdmin.Schemas)
 			admin.Get("/queue/dlq", queueAdmin.ListDLQ)
+			admin.Get("/queue/dlq/summary", queueAdmin.DLQSummary)
 			admin.Post("/queue/dlq/replay", queueAdmin.ReplayDLQ)
 			admin.Get("/queue/stats", queueAdmin.Stats)
+			admin.Get("/breakers", resilience.AdminHandler{}.Breakers)
 			admin.Get("/audit-logs", auditHandler.List)
+			admin.Post("/media/images", mediaAdmin.UploadImage)
+			admin.Post("/catalog/warm", catalogAdmin.Warm)
+			admin.Post("/analytics/refresh", analyticsHandler.Refresh)
+			admin.With(catalog.StockOverride).Get("/products", catalogHandler.Products)
+			admin.Put("/products/{id}/options", catalogAdmin.PutOptions)
+			admin.Put("/products/{id}/availability", catalogAdmin.PutAvailability)
+			admin.Get("/products/{id}/price-history", catalogAdmin.PriceHistory)
+			admin.Post("/products/{id}/variants", catalogAdmin.CreateVariant)
+			admin.Put("/products/{id}/variants/{variantId}", catalogAdmin.UpdateVariant)
+			admin.Put("/products/{id}/variants/{variantId}/bundle", catalogAdmin.PutBundle)
+			admin.Delete("/products/{id}/variants/{variantId}/bundle", catalogAdmin.DeleteBundle)
+			admin.Get("/maintenance", maintenanceAdmin.Get)
+			admin.Put("/maintenance", maintenanceAdmin.Put)
+			admin.Delete("/maintenance", maintenanceAdmin.Delete)
+			admin.Get("/bans", banAdmin.ListBans)
+			admin.Post("/bans", banAdmin.CreateBan)
+			admin.Delete("/bans/{kind}/{value}", banAdmin.DeleteBan)
+			admin.Post("/tenants/{tenant}/cache/flush", redisCacheAdmin.FlushTenant)
+			admin.Get("/tenants/{tenant}/shipping/couriers", courierAdmin.Get)
+			admin.Put("/tenants/{tenant}/shipping/couriers", courierAdmin.Put)
+			admin.Delete("/tenants/{tenant}/shipping/couriers", courierAdmin.Delete)
+			admin.Post("/users/{id}/purge", userAdmin.Purge)
 		})
 
 		v.Route("/analytics", func(an chi.Router) {
+			an.Use(adminCORS.Middleware)
 			an.Use(authMiddleware.RequireAuth)
 			an.Use(requireRole(queries, "admin"))
 			an.Get("/sales", analyticsHandler.Sales)
 			an.Get("/top-products", analyticsHandler.TopProducts)
+			an.Get("/vouchers", analyticsHandler.Vouchers)
 			an.Get("/overview", analyticsHandler.Overview)
 		})
 
@@ -674,6 +1009,7 @@ func main() {
 
 		v.Post("/webhooks/shipping/{courier}", shipWebhook.Handle)
 		v.Post("/webhooks/payment/{provider}", webhookHandler.Handle)
+		v.Get("/webhooks/events/{id}", webhookPayloads.Fetch)
 	})
 
 	srv := &http.Server{
@@ -735,6 +1071,19 @@ func requireRole(q dbgen.Querier, role string) func(http.Handler) http.Handler {
 	}
 }
 
+// schemaChecker reports the migration version for readiness.
+type schemaChecker struct {
+	db *pgxpool.Pool
+}
+
+func (c schemaChecker) SchemaVersion(ctx context.Context) (uint, error) {
+	status, err := db.CheckSchema(ctx, c.db)
+	if err != nil {
+		return 0, err
+	}
+	return status.Current, status.Err()
+}
+
 type readinessChecker struct {
 	db    *pgxpool.Pool
 	redis *redis.Client
@@ -758,6 +1107,36 @@ func (c readinessChecker) PingRedis(ctx context.Context, timeout time.Duration)
 	return c.redis.Ping(ctx).Err()
 }
 
+// adminAuditRoutes names admin routes whose pattern does not say what they
+// change; the rest are derived from the pattern and method.
+var adminAuditRoutes = map[string]audit.RouteAudit{
+	"POST /orders/{id}/shipment":                 {ResourceType: "shipment", Action: "create"},
+	"POST /orders/{id}/refund":                   {ResourceType: "payment", Action: "refund"},
+	"POST /media/images":                         {ResourceType: "media", Action: "upload"},
+	"POST /queue/dlq/replay":                     {ResourceType: "dlq", Action: "replay"},
+	"POST /tenants/{tenant}/cache/flush":         {ResourceType: "cache", Action: "flush"},
+	"PUT /tenants/{tenant}/shipping/couriers":    {ResourceType: "courier_policy", Action: "update"},
+	"DELETE /tenants/{tenant}/shipping/couriers": {ResourceType: "courier_policy", Action: "delete"},
+}
+
+// maintenanceAdminPath stays reachable during maintenance so admins can end it.
+const maintenanceAdminPath = "/api/v1/admin/maintenance"
+
+// mediaUploadPath enforces its own upload limit instead of the global body limit.
+const mediaUploadPath = "/api/v1/admin/media/images"
+
+// startupRetry retries a dependency check during startup so the process
+// tolerates the database or Redis becoming ready a little after it does.
+func startupRetry(cfg *config.Config, logger zerolog.Logger, dependency string) resilience.Retry {
+	return resilience.Retry{
+		Attempts: cfg.StartupConnectAttempts,
+		MaxWait:  cfg.StartupConnectMaxWait,
+		OnRetry: func(attempt int, wait time.Duration, err error) {
+			logger.Warn().Err(err).Str("dependency", dependency).Int("attempt", attempt).Dur("retryIn", wait).Msg("dependency not ready")
+		},
+	}
+}
+
 func envOrDefault(key, fallback string) string {
 	if val, ok := os.LookupEnv(key); ok {
 		trimmed := strings.TrimSpace(val)
@@ -834,3 +1213,26 @@ func protectPprof(handler http.Handler, user, pass string) http.Handler {
 		handler.ServeHTTP(w, r)
 	})
 }
+
+// newInboundConsumer builds the callback deduplicator with the configured
+// timestamp window and any per-source overrides.
+func newInboundConsumer(q inbound.Queries, cfg *config.Config, claimTTL time.Duration) *inbound.Consumer {
+	sources := make(map[string]inbound.Policy)
+	for source, age := range cfg.InboundWebhookMaxAgeBySource {
+		p := sources[source]
+		p.MaxAge = age
+		sources[source] = p
+	}
+	for source, ttl := range cfg.InboundWebhookClaimTTLBySource {
+		p := sources[source]
+		p.ClaimTTL = ttl
+		sources[source] = p
+	}
+	return &inbound.Consumer{
+		Q:        q,
+		ClaimTTL: claimTTL,
+		MaxAge:   cfg.InboundWebhookMaxAge,
+		MaxSkew:  cfg.InboundWebhookMaxSkew,
+		Sources:  sources,
+	}
+}
@@ -1,13 +1,18 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"log"
+	"os"
+	"os/signal"
 	"sort"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -23,10 +28,18 @@ func main() {
 		tenantStatus = flag.String("tenant-status", "active", "status to set for the tenant record")
 		tablesList   = flag.String("tables", "", "comma separated list of tables to update; defaults to all tables with tenant_id column")
 		dryRun       = flag.Bool("dry-run", false, "print the operations without mutating data")
+		batchSize    = flag.Int("batch-size", 5000, "rows updated per statement; each batch commits on its own")
+		sleep        = flag.Duration("sleep", 0, "pause between batches to let replicas and other writers catch up")
+		yes          = flag.Bool("yes", false, "skip the confirmation prompt")
 	)
 	flag.Parse()
+	if *batchSize <= 0 {
+		log.Fatal("-batch-size must be positive")
+	}
 
-	baseCtx := context.Background()
+	// Interrupting stops between batches; rerunning resumes where it stopped.
+	baseCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 	connectCtx, cancel := context.WithTimeout(baseCtx, 10*time.Second)
 	defer cancel()
 
@@ -48,11 +61,6 @@ func main() {
 		log.Fatalf("ping database: %v", err)
 	}
 
-	tenantID, err := ensureTenant(baseCtx, pool, *tenantSlug, *tenantName, *tenantStatus, *dryRun)
-	if err != nil {
-		log.Fatalf("ensure tenant: %v", err)
-	}
-
 	tables, err := resolveTables(baseCtx, pool, *tablesList)
 	if err != nil {
 		log.Fatalf("resolve tables: %v", err)
@@ -63,19 +71,54 @@ func main() {
 		return
 	}
 
-	if *dryRun {
-		log.Printf("would update %d tables with tenant %s (%s)\n", len(tables), tenantID, *tenantSlug)
-		for _, tbl := range tables {
-			log.Printf("would backfill table %s.%s\n", tbl.Schema, tbl.Name)
+	existingID, err := lookupTenant(baseCtx, pool, *tenantSlug)
+	if err != nil {
+		log.Fatalf("look up tenant: %v", err)
+	}
+	plans, err := planTables(baseCtx, pool, tables, existingID)
+	if err != nil {
+		log.Fatalf("count rows: %v", err)
+	}
+	var pendingRows int64
+	pendingTables := 0
+	for _, plan := range plans {
+		if plan.done() {
+			log.Printf("%s.%s: already backfilled, skipping", plan.Schema, plan.Name)
+			continue
 		}
+		pendingTables++
+		pendingRows += plan.Pending
+		log.Printf("%s.%s: %d rows without tenant_id (%d batches)", plan.Schema, plan.Name, plan.Pending, batches(plan.Pending, *batchSize))
+	}
+	if pendingTables == 0 {
+		log.Println("every table is already backfilled; nothing to do")
 		return
 	}
 
-	for _, tbl := range tables {
-		if err := backfillTable(baseCtx, pool, tbl, tenantID); err != nil {
-			log.Fatalf("backfill %s.%s: %v", tbl.Schema, tbl.Name, err)
+	if *dryRun {
+		log.Printf("would backfill %d rows in %d tables with tenant %s in batches of %d\n", pendingRows, pendingTables, *tenantSlug, *batchSize)
+		return
+	}
+
+	if !*yes && !confirm(os.Stdin, fmt.Sprintf("Backfill %d rows in %d tables with tenant %q?", pendingRows, pendingTables, *tenantSlug)) {
+		log.Println("aborted")
+		return
+	}
+
+	tenantID, err := ensureTenant(baseCtx, pool, *tenantSlug, *tenantName, *tenantStatus)
+	if err != nil {
+		log.Fatalf("ensure tenant: %v", err)
+	}
+
+	for _, plan := range plans {
+		if plan.done() {
+			continue
+		}
+		updated, err := backfillTable(baseCtx, pool, plan, tenantID, *batchSize, *sleep)
+		if err != nil {
+			log.Fatalf("backfill %s.%s after %d rows: %v", plan.Schema, plan.Name, updated, err)
 		}
-		log.Printf("backfilled %s.%s", tbl.Schema, tbl.Name)
+		log.Printf("backfilled %s.%s (%d rows)", plan.Schema, plan.Name, updated)
 	}
 }
 
@@ -84,7 +127,69 @@ type tableRef struct {
 	Name   string
 }
 
-func ensureTenant(ctx context.Context, pool *pgxpool.Pool, slug, name, status string, dryRun bool) (string, error) {
+// tablePlan is the work left on a table: rows still missing tenant_id and
+// whether the column default already points at the tenant.
+type tablePlan struct {
+	tableRef
+	Pending    int64
+	DefaultSet bool
+}
+
+func (p tablePlan) done() bool {
+	return p.Pending == 0 && p.DefaultSet
+}
+
+func lookupTenant(ctx context.Context, pool *pgxpool.Pool, slug string) (string, error) {
+	var tenantID string
+	err := pool.QueryRow(ctx, `SELECT id::text FROM tenants WHERE slug = $1`, strings.TrimSpace(slug)).Scan(&tenantID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return tenantID, err
+}
+
+func planTables(ctx context.Context, pool *pgxpool.Pool, tables []tableRef, tenantID string) ([]tablePlan, error) {
+	plans := make([]tablePlan, 0, len(tables))
+	for _, tbl := range tables {
+		plan := tablePlan{tableRef: tbl}
+		identifier := pgx.Identifier{tbl.Schema, tbl.Name}.Sanitize()
+		countSQL := fmt.Sprintf("SELECT count(*) FROM %s WHERE tenant_id IS NULL", identifier)
+		if err := pool.QueryRow(ctx, countSQL).Scan(&plan.Pending); err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", tbl.Schema, tbl.Name, err)
+		}
+		var columnDefault *string
+		err := pool.QueryRow(ctx, `
+            SELECT column_default
+            FROM information_schema.columns
+            WHERE table_schema = $1 AND table_name = $2 AND column_name = 'tenant_id'
+        `, tbl.Schema, tbl.Name).Scan(&columnDefault)
+		if err != nil {
+			return nil, fmt.Errorf("%s.%s: %w", tbl.Schema, tbl.Name, err)
+		}
+		plan.DefaultSet = tenantID != "" && columnDefault != nil && strings.Contains(*columnDefault, tenantID)
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
+
+func batches(rows int64, size int) int64 {
+	return (rows + int64(size) - 1) / int64(size)
+}
+
+// confirm asks question on stdout and reports whether the answer read from in
+// is yes. No input counts as no.
+func confirm(in io.Reader, question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
+
+func ensureTenant(ctx context.Context, pool *pgxpool.Pool, slug, name, status string) (string, error) {
 	slug = strings.TrimSpace(slug)
 	if slug == "" {
 		return "", errors.New("tenant slug cannot be empty")
@@ -98,10 +203,6 @@ func ensureTenant(ctx context.Context, pool *pgxpool.Pool, slug, name, status st
 		status = "active"
 	}
 
-	if dryRun {
-		return fmt.Sprintf("dry-run-%s", slug), nil
-	}
-
 	var tenantID string
 	err := pool.QueryRow(ctx,
 		`INSERT INTO tenants (slug, name, status)
@@ -177,16 +278,42 @@ func sortTables(tables []tableRef) {
 	})
 }
 
-func backfillTable(ctx context.Context, pool *pgxpool.Pool, tbl tableRef, tenantID string) error {
+// backfillTable points the tenant_id default at the tenant unless it already
+// does, then fills the
+// rows still missing it batchSize rows per statement. Each batch commits on
+// its own, so row locks are held briefly and an interrupted run resumes from
+// the rows that are still NULL.
+func backfillTable(ctx context.Context, pool *pgxpool.Pool, tbl tablePlan, tenantID string, batchSize int, sleep time.Duration) (int64, error) {
 	identifier := pgx.Identifier{tbl.Schema, tbl.Name}.Sanitize()
-	updateSQL := fmt.Sprintf("UPDATE %s SET tenant_id = $1 WHERE tenant_id IS NULL", identifier)
-	alterSQL := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN tenant_id SET DEFAULT $1", identifier)
+	// DDL takes no bind parameters, so the id is inlined as a quoted literal.
+	alterSQL := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN tenant_id SET DEFAULT '%s'", identifier, strings.ReplaceAll(tenantID, "'", "''"))
+	updateSQL := fmt.Sprintf(`UPDATE %[1]s SET tenant_id = $1
+        WHERE ctid IN (SELECT ctid FROM %[1]s WHERE tenant_id IS NULL LIMIT $2)`, identifier)
 
-	if _, err := pool.Exec(ctx, alterSQL, tenantID); err != nil {
-		return fmt.Errorf("set default: %w", err)
+	if !tbl.DefaultSet {
+		if _, err := pool.Exec(ctx, alterSQL); err != nil {
+			return 0, fmt.Errorf("set default: %w", err)
+		}
 	}
-	if _, err := pool.Exec(ctx, updateSQL, tenantID); err != nil {
-		return fmt.Errorf("update rows: %w", err)
+	var total int64
+	for {
+		tag, err := pool.Exec(ctx, updateSQL, tenantID, batchSize)
+		if err != nil {
+			return total, fmt.Errorf("update rows: %w", err)
+		}
+		// A row updated concurrently can drop out of a batch, so only an
+		// empty batch proves the table is done.
+		if tag.RowsAffected() == 0 {
+			return total, nil
+		}
+		total += tag.RowsAffected()
+		log.Printf("%s.%s: %d rows so far", tbl.Schema, tbl.Name, total)
+		if sleep > 0 {
+			select {
+			case <-ctx.Done():
+				return total, ctx.Err()
+			case <-time.After(sleep):
+			}
+		}
 	}
-	return nil
 }
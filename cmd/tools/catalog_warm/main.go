@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	redis "github.com/redis/go-redis/v9"
+
+	"github.com/noah-isme/backend-toko/internal/bootstrap"
+	"github.com/noah-isme/backend-toko/internal/catalog"
+	"github.com/noah-isme/backend-toko/internal/config"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/media"
+)
+
+func main() {
+	var (
+		top         = flag.Int("top", 50, "number of best-selling products whose detail is cached")
+		concurrency = flag.Int("concurrency", 4, "maximum parallel detail loads")
+		timeout     = flag.Duration("timeout", 5*time.Minute, "abort the run after this long")
+	)
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("load config: %v", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
+	if err != nil {
+		log.Fatalf("connect database: %v", err)
+	}
+	defer pool.Close()
+	if err := pool.Ping(ctx); err != nil {
+		log.Fatalf("ping database: %v", err)
+	}
+
+	redisOpts, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		log.Fatalf("parse redis url: %v", err)
+	}
+	redisClient := redis.NewClient(redisOpts)
+	defer func() { _ = redisClient.Close() }()
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		log.Fatalf("ping redis: %v", err)
+	}
+
+	// Mirror the API's catalog settings so warmed keys match the ones it reads.
+	storage, _ := bootstrap.NewMediaStorage(cfg)
+	svc, err := catalog.NewService(catalog.ServiceConfig{
+		Queries:       dbgen.New(pool),
+		Cache:         catalog.NewCache(redisClient, cfg.CatalogCacheTTL, cfg.RedisCachePrefix),
+		Images:        media.Resolver{Storage: storage},
+		DefaultPage:   cfg.CatalogDefaultPage,
+		DefaultLimit:  cfg.CatalogDefaultLimit,
+		MaxLimit:      cfg.CatalogMaxLimit,
+		DefaultSort:   cfg.CatalogDefaultSort,
+		DefaultLocale: cfg.CatalogDefaultLocale,
+		Locales:       cfg.CatalogLocales,
+
+		PriceTrendPoints: cfg.CatalogPriceTrendPoints,
+	})
+	if err != nil {
+		log.Fatalf("initialise catalog service: %v", err)
+	}
+
+	start := time.Now()
+	result, err := svc.Warm(ctx, catalog.WarmOptions{Top: *top, Concurrency: *concurrency})
+	log.Printf("warmed %d listings and %d product details (%d failed) in %s", result.Lists, result.Details, result.Failed, time.Since(start).Round(time.Millisecond))
+	if err != nil {
+		log.Fatalf("warm catalog cache: %v", err)
+	}
+}
@@ -1,14 +1,22 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"database/sql"
 	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
 	"log"
 	"os"
 	"strings"
 
 	"github.com/joho/godotenv"
 	_ "github.com/lib/pq"
+
+	"github.com/noah-isme/backend-toko/internal/media"
 )
 
 func main() {
@@ -172,24 +180,26 @@ func seedCatalog(db *sql.DB, tenantID string) {
 		Brand    string
 		Category string
 		Price    int64
-		Image    string
+		Color    string
 		Stock    int
 	}{
-		{"MacBook Pro 14 M3", "macbook-pro-14-m3", "apple", "electronics", 25000000, "https://images.unsplash.com/photo-1517336714731-489689fd1ca8?w=800", 50},
-		{"iPhone 15 Pro", "iphone-15-pro", "apple", "electronics", 20000000, "https://images.unsplash.com/photo-1695048133142-1a20484d2569?w=800", 100},
-		{"Samsung Galaxy S24 Ultra", "samsung-galaxy-s24", "samsung", "electronics", 19000000, "https://images.unsplash.com/photo-1610945415295-d9bbf067e59c?w=800", 80},
-		{"Sony WH-1000XM5", "sony-wh-1000xm5", "sony", "electronics", 5000000, "https://images.unsplash.com/photo-1618366712010-f4ae9c647dcb?w=800", 150},
-		{"Dell XPS 13", "dell-xps-13", "dell", "electronics", 18000000, "https://images.unsplash.com/photo-1593642632823-8f785667771b?w=800", 40},
-		{"Canon EOS R5", "canon-eos-r5", "canon", "electronics", 45000000, "https://images.unsplash.com/photo-1516035069371-29a1b244cc32?w=800", 20},
-		{"Nike Air Force 1", "nike-air-force-1", "nike", "fashion", 1500000, "https://images.unsplash.com/photo-1542291026-7eec264c27ff?w=800", 200},
-		{"Adidas Ultraboost", "adidas-ultraboost", "adidas", "fashion", 2000000, "https://images.unsplash.com/photo-1608231387042-66d1773070a5?w=800", 180},
-		{"IKEAS LANDSKRONA Sofa", "ikea-landskrona", "ikea", "home-living", 8000000, "https://images.unsplash.com/photo-1555041469-a586c61ea9bc?w=800", 10},
-		{"Dyson V15 Detect", "dyson-v15", "dyson", "home-living", 12000000, "https://images.unsplash.com/photo-1556911220-e15b29be8c8f?w=800", 30},
-		{"LEGO Star Wars Millenium Falcon", "lego-millenium-falcon", "lego", "toys", 13000000, "https://images.unsplash.com/photo-1585366119957-e9730b6d0f60?w=800", 25},
-		{"Sony PlayStation 5", "sony-ps5", "sony", "electronics", 9000000, "https://images.unsplash.com/photo-1606813907291-d86efa9b94db?w=800", 60},
-		{"Kaos Hitam Polos", "kaos-hitam-polos", "nike", "fashion", 100000, "https://images.unsplash.com/photo-1583743814966-8936f5b7be1a?w=800", 500},
+		{"MacBook Pro 14 M3", "macbook-pro-14-m3", "apple", "electronics", 25000000, "#4b5563", 50},
+		{"iPhone 15 Pro", "iphone-15-pro", "apple", "electronics", 20000000, "#1f2937", 100},
+		{"Samsung Galaxy S24 Ultra", "samsung-galaxy-s24", "samsung", "electronics", 19000000, "#6d28d9", 80},
+		{"Sony WH-1000XM5", "sony-wh-1000xm5", "sony", "electronics", 5000000, "#111827", 150},
+		{"Dell XPS 13", "dell-xps-13", "dell", "electronics", 18000000, "#2563eb", 40},
+		{"Canon EOS R5", "canon-eos-r5", "canon", "electronics", 45000000, "#b91c1c", 20},
+		{"Nike Air Force 1", "nike-air-force-1", "nike", "fashion", 1500000, "#f97316", 200},
+		{"Adidas Ultraboost", "adidas-ultraboost", "adidas", "fashion", 2000000, "#0ea5e9", 180},
+		{"IKEAS LANDSKRONA Sofa", "ikea-landskrona", "ikea", "home-living", 8000000, "#a16207", 10},
+		{"Dyson V15 Detect", "dyson-v15", "dyson", "home-living", 12000000, "#7c3aed", 30},
+		{"LEGO Star Wars Millenium Falcon", "lego-millenium-falcon", "lego", "toys", 13000000, "#facc15", 25},
+		{"Sony PlayStation 5", "sony-ps5", "sony", "electronics", 9000000, "#1d4ed8", 60},
+		{"Kaos Hitam Polos", "kaos-hitam-polos", "nike", "fashion", 100000, "#000000", 500},
 	}
 
+	uploader := seedUploader()
+
 	fmt.Println("Seeding Products...")
 	for _, p := range products {
 		catID, ok1 := catIDs[p.Category]
@@ -206,9 +216,15 @@ func seedCatalog(db *sql.DB, tenantID string) {
 			continue
 		}
 
+		thumbnail, err := seedImage(uploader, p.Color)
+		if err != nil {
+			log.Printf("Failed to store image for %s: %v", p.Title, err)
+			continue
+		}
+
 		// Insert Product
 		var prodID string
-		err := db.QueryRow(`
+		err = db.QueryRow(`
 			INSERT INTO products (title, slug, brand_id, category_id, price, in_stock, thumbnail, tenant_id)
 			VALUES ($1, $2, $3, $4, $5, true, $6, $7)
 			ON CONFLICT (slug) DO UPDATE SET 
@@ -218,7 +234,7 @@ func seedCatalog(db *sql.DB, tenantID string) {
 				thumbnail = EXCLUDED.thumbnail,
 				tenant_id = EXCLUDED.tenant_id
 			RETURNING id;
-		`, p.Title, p.Slug, brandID, catID, p.Price, p.Image, tenantID).Scan(&prodID)
+		`, p.Title, p.Slug, brandID, catID, p.Price, thumbnail, tenantID).Scan(&prodID)
 
 		if err != nil {
 			log.Printf("Failed to seed product %s: %v", p.Title, err)
@@ -241,6 +257,40 @@ func seedCatalog(db *sql.DB, tenantID string) {
 	}
 }
 
+// seedUploader stores placeholder product images through the same media
+// storage the API serves, so seeded data does not depend on external hosts.
+func seedUploader() *media.Uploader {
+	baseURL := strings.TrimRight(os.Getenv("MEDIA_PUBLIC_BASE_URL"), "/")
+	if baseURL == "" {
+		baseURL = "http://localhost:8080/media"
+	}
+	root := os.Getenv("MEDIA_LOCAL_DIR")
+	if root == "" {
+		root = "./var/media"
+	}
+	return &media.Uploader{Storage: &media.LocalStorage{Root: root, BaseURL: baseURL}}
+}
+
+// seedImage renders a solid placeholder in the given hex colour and uploads it.
+func seedImage(u *media.Uploader, hex string) (string, error) {
+	var c color.RGBA
+	if _, err := fmt.Sscanf(hex, "#%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return "", err
+	}
+	c.A = 0xff
+	img := image.NewRGBA(image.Rect(0, 0, 800, 800))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: c}, image.Point{}, draw.Src)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	stored, err := u.Upload(context.Background(), buf.Bytes())
+	if err != nil {
+		return "", err
+	}
+	return stored.Ref, nil
+}
+
 func seedVouchers(db *sql.DB, tenantID string) {
 	vouchers := []struct {
 		Code   string
@@ -1,19 +1,29 @@
 package main
 
 import (
-	"bufio"
+	"flag"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 )
 
-// tenantGuard scans .sql query files and ensures SELECT/UPDATE/DELETE contain a tenant_id filter.
+// tenantGuard scans .sql query files and ensures every statement that reads
+// or writes a table filters on tenant_id. Statements are split on sqlc
+// "-- name:" markers and top-level semicolons, and each CTE of a statement is
+// checked on its own, so a filter in one query or CTE never covers another.
+// A statement that is tenant-agnostic on purpose is exempted with a comment
+// inside it:
+//
+//	-- tenant_guard:ignore <reason>
+//
 // Exit code 0 = ok, 1 = violation, 2 = other error.
 func main() {
-	root := "internal/db/queries"
-	deny, err := scan(root)
+	root := flag.String("root", "internal/db/queries", "directory holding the .sql query files")
+	flag.Parse()
+	deny, err := scan(*root)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "tenant_guard error: %v\n", err)
 		os.Exit(2)
@@ -27,13 +37,24 @@ func main() {
 	fmt.Println("tenant_guard: OK")
 }
 
+const ignoreMarker = "tenant_guard:ignore"
+
 var (
-	reSelect = regexp.MustCompile(`(?i)^\s*select\b`)
-	reUpdate = regexp.MustCompile(`(?i)^\s*update\b`)
-	reDelete = regexp.MustCompile(`(?i)^\s*delete\b`)
-	reTenant = regexp.MustCompile(`(?i)tenant_id\s*=\s*\$?[0-9a-z_]+`)
+	reName   = regexp.MustCompile(`^\s*name:\s*(\S+)`)
+	reTenant = regexp.MustCompile(`(?i)\btenant_id\s*(=|\bin\b)`)
+	// reTable captures the relation after FROM, JOIN, and UPDATE; a following
+	// "(" marks a function call rather than a table.
+	reTable = regexp.MustCompile(`(?i)\b(?:from|join|update)\s+(?:only\s+)?([a-z_][a-z0-9_]*(?:\.[a-z_][a-z0-9_]*)?)(\s*\()?`)
+	// reFromFunc finds functions whose arguments use FROM as a keyword.
+	reFromFunc = regexp.MustCompile(`(?i)\b(?:extract|substring|trim|overlay|position)\s*\(`)
+	reCTE      = regexp.MustCompile(`(?i)^\s*,?\s*([a-z_][a-z0-9_]*)\s*(\([^)]*\))?\s+as\s+(?:not\s+)?(?:materialized\s+)?\(`)
+	reWith     = regexp.MustCompile(`(?i)^\s*with\s+(?:recursive\s+)?`)
 )
 
+// Words that can follow FROM/UPDATE without naming a table, as in
+// "ON CONFLICT DO UPDATE SET" or "FOR UPDATE SKIP LOCKED".
+var notTables = map[string]bool{"set": true, "skip": true, "nowait": true, "of": true, "lateral": true}
+
 func scan(dir string) ([]string, error) {
 	var violations []string
 	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
@@ -46,46 +67,228 @@ func scan(dir string) ([]string, error) {
 		if filepath.Ext(path) != ".sql" {
 			return nil
 		}
-		ok, err := checkFile(path)
+		found, err := checkFile(path)
 		if err != nil {
 			return err
 		}
-		if !ok {
-			violations = append(violations, path)
-		}
+		violations = append(violations, found...)
 		return nil
 	})
 	return violations, err
 }
 
-func checkFile(path string) (bool, error) {
-	f, err := os.Open(path)
+func checkFile(path string) ([]string, error) {
+	src, err := os.ReadFile(path)
 	if err != nil {
-		return false, err
+		return nil, err
 	}
-	defer func() {
-		_ = f.Close()
-	}()
-	s := bufio.NewScanner(f)
-	foundStmt := false
-	foundTenant := false
-	for s.Scan() {
-		line := s.Text()
-		if reSelect.MatchString(line) || reUpdate.MatchString(line) || reDelete.MatchString(line) {
-			foundStmt = true
-		}
-		if reTenant.MatchString(line) {
-			foundTenant = true
+	var violations []string
+	for _, stmt := range splitStatements(string(src)) {
+		if problem := stmt.check(); problem != "" {
+			violations = append(violations, fmt.Sprintf("%s:%d: %s: %s", path, stmt.Line, stmt.label(), problem))
 		}
 	}
-	if err := s.Err(); err != nil {
-		return false, err
+	return violations, nil
+}
+
+// statement is one SQL statement of a query file with its comments removed.
+type statement struct {
+	// Name is the sqlc query name, empty outside "-- name:" blocks.
+	Name string
+	// Line is where the statement's SQL starts.
+	Line int
+	SQL  string
+	// Ignored is set by a tenant_guard:ignore comment; Reason is its text.
+	Ignored bool
+	Reason  string
+}
+
+func (s statement) label() string {
+	if s.Name != "" {
+		return "query " + s.Name
 	}
-	if !foundStmt {
-		return true, nil
+	return "statement"
+}
+
+// check returns why the statement violates the guard, or "" when it passes.
+func (s statement) check() string {
+	if s.Ignored {
+		if s.Reason == "" {
+			return ignoreMarker + " needs a reason"
+		}
+		return ""
 	}
-	if !foundTenant {
-		return false, nil
+	ctes, parts := splitCTEs(s.SQL)
+	for i, part := range parts {
+		if !needsFilter(part, ctes) || reTenant.MatchString(part) {
+			continue
+		}
+		if i < len(ctes) {
+			return fmt.Sprintf("CTE %s has no tenant_id filter", ctes[i])
+		}
+		return "no tenant_id filter"
+	}
+	return ""
+}
+
+// splitStatements splits a query file on sqlc "-- name:" markers and
+// top-level semicolons, dropping comments and keeping string literals intact.
+func splitStatements(src string) []statement {
+	var (
+		out     []statement
+		current statement
+		sql     strings.Builder
+		line    = 1
+	)
+	flush := func() {
+		current.SQL = strings.TrimSpace(sql.String())
+		if current.SQL != "" {
+			out = append(out, current)
+		}
+		sql.Reset()
+		current = statement{Name: current.Name}
+	}
+	for i := 0; i < len(src); i++ {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			sql.WriteByte(c)
+		case c == '-' && i+1 < len(src) && src[i+1] == '-':
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src) - i
+			}
+			comment := src[i+2 : i+end]
+			if m := reName.FindStringSubmatch(comment); m != nil {
+				flush()
+				current.Name = m[1]
+			} else if idx := strings.Index(comment, ignoreMarker); idx >= 0 {
+				current.Ignored = true
+				current.Reason = strings.TrimSpace(comment[idx+len(ignoreMarker):])
+			}
+			i += end - 1
+		case c == '/' && i+1 < len(src) && src[i+1] == '*':
+			end := strings.Index(src[i+2:], "*/")
+			if end < 0 {
+				end = len(src) - i - 2
+			}
+			comment := src[i : i+2+end]
+			line += strings.Count(comment, "\n")
+			i += 2 + end + 1
+		case c == '\'' || c == '"':
+			end := i + 1
+			for end < len(src) {
+				if src[end] == c {
+					if end+1 < len(src) && src[end+1] == c {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			end = min(end, len(src)-1)
+			literal := src[i : end+1]
+			if strings.TrimSpace(sql.String()) == "" {
+				current.Line = line
+			}
+			line += strings.Count(literal, "\n")
+			sql.WriteString(literal)
+			i = end
+		case c == ';':
+			flush()
+		default:
+			if strings.TrimSpace(sql.String()) == "" && c != ' ' && c != '\t' && c != '\r' {
+				current.Line = line
+			}
+			sql.WriteByte(c)
+		}
+	}
+	flush()
+	return out
+}
+
+// splitCTEs returns the names of the statement's CTEs and its parts: each CTE
+// body in order, followed by the main query.
+func splitCTEs(sql string) ([]string, []string) {
+	loc := reWith.FindStringIndex(sql)
+	if loc == nil {
+		return nil, []string{sql}
+	}
+	var names, parts []string
+	rest := sql[loc[1]:]
+	for {
+		m := reCTE.FindStringSubmatchIndex(rest)
+		if m == nil {
+			break
+		}
+		open := m[1] - 1
+		close := matchParen(rest, open)
+		if close < 0 {
+			return nil, []string{sql}
+		}
+		names = append(names, strings.ToLower(rest[m[2]:m[3]]))
+		parts = append(parts, rest[open+1:close])
+		rest = rest[close+1:]
+	}
+	if len(names) == 0 {
+		return nil, []string{sql}
+	}
+	return names, append(parts, rest)
+}
+
+// needsFilter reports whether part touches a table other than the given CTEs.
+func needsFilter(part string, ctes []string) bool {
+	for _, m := range reTable.FindAllStringSubmatch(stripFromFuncs(part), -1) {
+		name := strings.ToLower(m[1])
+		if m[2] != "" || notTables[name] {
+			continue
+		}
+		isCTE := false
+		for _, cte := range ctes {
+			if name == cte {
+				isCTE = true
+				break
+			}
+		}
+		if !isCTE {
+			return true
+		}
+	}
+	return false
+}
+
+// stripFromFuncs blanks the arguments of functions like EXTRACT(x FROM y) so
+// their FROM is not read as a table reference.
+func stripFromFuncs(sql string) string {
+	for {
+		loc := reFromFunc.FindStringIndex(sql)
+		if loc == nil {
+			return sql
+		}
+		close := matchParen(sql, loc[1]-1)
+		if close < 0 {
+			return sql
+		}
+		sql = sql[:loc[0]] + "NULL" + sql[close+1:]
+	}
+}
+
+// matchParen returns the index of the parenthesis closing the one at open,
+// or -1 when it is unbalanced.
+func matchParen(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
 	}
-	return true, nil
+	return -1
 }
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckFileChecksEachStatement(t *testing.T) {
+	src := `-- name: ListOrders :many
+SELECT * FROM orders WHERE tenant_id = sqlc.arg(tenant_id);
+
+-- name: ListAllOrders :many
+-- tenant_id = $1 in a comment does not count
+SELECT * FROM orders;
+
+-- name: ListCountries :many
+-- tenant_guard:ignore countries are shared reference data
+SELECT * FROM countries;
+
+-- name: ListCities :many
+-- tenant_guard:ignore
+SELECT * FROM cities;
+
+-- name: LeakyCTE :many
+WITH mine AS (
+  SELECT id FROM orders WHERE tenant_id = $1
+), theirs AS (
+  SELECT id FROM payments
+)
+SELECT * FROM mine JOIN theirs USING (id);
+
+-- name: FilteredCTE :many
+WITH recent AS (
+  SELECT id, EXTRACT(EPOCH FROM created_at) AS ts FROM orders WHERE tenant_id = $1
+)
+SELECT * FROM recent;
+
+-- name: UpsertSetting :exec
+INSERT INTO settings (key, value) VALUES ($1, $2)
+ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value;
+
+-- name: Now :one
+SELECT now();
+
+-- name: ResetCarts :exec
+UPDATE carts SET total = 0 WHERE id = ';'; DELETE FROM carts WHERE tenant_id = $1;
+`
+	path := filepath.Join(t.TempDir(), "queries.sql")
+	require.NoError(t, os.WriteFile(path, []byte(src), 0o600))
+
+	violations, err := checkFile(path)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		path + ":6: query ListAllOrders: no tenant_id filter",
+		path + ":14: query ListCities: tenant_guard:ignore needs a reason",
+		path + ":17: query LeakyCTE: CTE theirs has no tenant_id filter",
+		// The quoted semicolon does not split; the DELETE after it is filtered.
+		path + ":38: query ResetCarts: no tenant_id filter",
+	}, violations)
+}
@@ -3,24 +3,33 @@ package main
 import (
 	"context"
 	"errors"
+	"net/http"
 	"os"
 	"os/signal"
 	"strings"
+	"sync"
 	"syscall"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/redis/go-redis/extra/redisotel/v9"
 	redis "github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 
+	"github.com/noah-isme/backend-toko/internal/analytics"
+	"github.com/noah-isme/backend-toko/internal/bootstrap"
 	"github.com/noah-isme/backend-toko/internal/config"
 	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/events"
+	"github.com/noah-isme/backend-toko/internal/health"
 	"github.com/noah-isme/backend-toko/internal/lock"
 	"github.com/noah-isme/backend-toko/internal/notify"
 	"github.com/noah-isme/backend-toko/internal/obs"
+	"github.com/noah-isme/backend-toko/internal/payment"
 	"github.com/noah-isme/backend-toko/internal/queue"
 	"github.com/noah-isme/backend-toko/internal/resilience"
+	"github.com/noah-isme/backend-toko/internal/user"
 )
 
 func main() {
@@ -32,14 +41,17 @@ func main() {
 	logFormat := envOrDefault("OBS_LOG_FORMAT", "json")
 	logLevel := envOrDefault("OBS_LOG_LEVEL", "info")
 	logger := obs.NewLogger(logFormat, logLevel).With().Str("component", "worker").Logger()
+	obs.MustRegisterDomainMetrics(envOrDefault("OBS_METRICS_NAMESPACE", "toko"), nil)
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
-	pool, queries := mustInitDatabase(ctx, cfg, logger)
+	startupCtx, cancelStartup := context.WithTimeout(ctx, cfg.StartupTimeout)
+	pool, queries := mustInitDatabase(startupCtx, cfg, logger)
 	defer pool.Close()
 
-	redisClient := mustInitRedis(ctx, cfg, logger)
+	redisClient := mustInitRedis(startupCtx, cfg, logger)
+	cancelStartup()
 	defer func() {
 		if err := redisClient.Close(); err != nil {
 			logger.Error().Err(err).Msg("close redis")
@@ -48,31 +60,14 @@ func main() {
 
 	notifyStore := notify.NewStore(queries)
 	taskQueue := queue.Enqueuer{R: redisClient, Prefix: cfg.QueueRedisPrefix, DedupTTL: cfg.IdempotencyTTL, MaxAttempts: cfg.QueueMaxAttempts}
-	webhookHTTPClient := notify.HttpClient(int(cfg.WebhookRequestTimeout/time.Millisecond), cfg.WebhookAllowInsecureTLS)
-	dispatcher := &notify.Dispatcher{
-		Store: notifyStore,
-		HTTP: &resilience.HTTPClient{
-			Client:      webhookHTTPClient,
-			Breaker:     resilience.NewBreaker(cfg.CircuitWebhookMinReq, cfg.CircuitWebhookFailureRate, cfg.CircuitWebhookOpenFor),
-			BaseBackoff: cfg.RetryBase,
-			MaxAttempts: cfg.RetryMaxAttempts,
-			Jitter:      cfg.RetryJitterPercent,
-			Timeout:     cfg.OutboundTimeout,
-			Target:      "webhook-delivery",
-			Logger:      &logger,
-		},
-		Queue:              taskQueue,
-		BackoffBaseSec:     cfg.WebhookBackoffBaseSec,
-		DefaultMaxAttempts: cfg.WebhookDefaultMaxAttempts,
-		Enabled:            cfg.WebhookDeliveryEnabled,
-		Replay:             notify.RedisReplayProtector{Client: redisClient},
-		ReplayTTL:          cfg.WebhookReplayTTL,
-	}
+	dispatcher := bootstrap.NewWebhookDispatcher(cfg, logger, notifyStore, taskQueue, redisClient)
+	dispatcher.Events = &events.Bus{Store: queries, Scheduler: dispatcher, Logger: &logger}
 
 	deliveryWorker := notify.DeliveryWorker{
-		Dispatcher: dispatcher,
-		Locker:     lock.Locker{R: redisClient, RetryBackoff: cfg.LockRetryBackoff},
-		LockTTL:    cfg.LockTTL,
+		Dispatcher:     dispatcher,
+		Locker:         lock.Locker{R: redisClient, RetryBackoff: cfg.LockRetryBackoff},
+		LockTTL:        cfg.LockTTL,
+		LockRenewEvery: cfg.LockRenewInterval,
 	}
 
 	webhookQueueWorker := queue.Worker{
@@ -86,18 +81,154 @@ func main() {
 		Store:             queue.NewStore(pool),
 		HeartbeatInterval: cfg.WorkerHeartbeatInterval,
 		SoftDeadline:      cfg.WorkerJobSoftDeadline,
+		ShutdownGrace:     cfg.WorkerShutdownGrace,
 		Logger:            &logger,
 		Handler: func(jobCtx context.Context, task queue.Task) error {
 			return deliveryWorker.Handle(jobCtx, task.Payload)
 		},
 	}
 
+	if cfg.QueueAdaptiveConcurrency {
+		webhookQueueWorker.Adaptive = &queue.AdaptiveConfig{
+			Min:           cfg.QueueAdaptiveMin,
+			Max:           cfg.QueueConcurrencyWebhook,
+			LatencyTarget: cfg.QueueAdaptiveLatencyTarget,
+		}
+	}
+
+	emailWorker := notify.EmailWorker{Mail: bootstrap.NewEmailSender(cfg, logger), Timeout: cfg.EmailSendTimeout}
+	emailQueueWorker := queue.Worker{
+		R:                 redisClient,
+		Prefix:            cfg.QueueRedisPrefix,
+		Kind:              notify.EmailSendTask(),
+		Concurrency:       cfg.QueueConcurrencyEmail,
+		VisibilityTimeout: cfg.QueueVisibilityTimeout,
+		RetryBase:         cfg.QueueBackoffBase,
+		RetryJitter:       cfg.QueueBackoffJitter,
+		Store:             queue.NewStore(pool),
+		HeartbeatInterval: cfg.WorkerHeartbeatInterval,
+		SoftDeadline:      cfg.WorkerJobSoftDeadline,
+		ShutdownGrace:     cfg.WorkerShutdownGrace,
+		Logger:            &logger,
+		Handler: func(jobCtx context.Context, task queue.Task) error {
+			return emailWorker.Handle(jobCtx, task.Payload)
+		},
+	}
+
+	queueWorkers := []queue.Worker{webhookQueueWorker, emailQueueWorker}
+	if _, ok := cfg.PaymentProviders[payment.FakeProviderName]; ok {
+		fakeCallbackWorker := payment.FakeCallbackWorker{HTTP: &http.Client{Timeout: cfg.OutboundTimeout}}
+		queueWorkers = append(queueWorkers, queue.Worker{
+			R:                 redisClient,
+			Prefix:            cfg.QueueRedisPrefix,
+			Kind:              payment.FakeCallbackTask(),
+			Concurrency:       1,
+			VisibilityTimeout: cfg.QueueVisibilityTimeout,
+			RetryBase:         cfg.QueueBackoffBase,
+			RetryJitter:       cfg.QueueBackoffJitter,
+			Store:             queue.NewStore(pool),
+			HeartbeatInterval: cfg.WorkerHeartbeatInterval,
+			SoftDeadline:      cfg.WorkerJobSoftDeadline,
+			ShutdownGrace:     cfg.WorkerShutdownGrace,
+			Logger:            &logger,
+			Handler: func(jobCtx context.Context, task queue.Task) error {
+				return fakeCallbackWorker.Handle(jobCtx, task.Payload)
+			},
+		})
+	}
+
+	heartbeat := &health.WorkerHeartbeat{
+		R:        redisClient,
+		Key:      health.WorkerHeartbeatKey(cfg.QueueRedisPrefix),
+		Interval: cfg.WorkerHeartbeatInterval,
+		TTL:      cfg.WorkerLivenessTTL,
+		Logger:   logger.With().Str("job", "heartbeat").Logger(),
+	}
+	for i := range queueWorkers {
+		queueWorkers[i].Beat = heartbeat.Loop(queueWorkers[i].Kind)
+	}
+
 	logger.Info().Msg("worker starting")
-	if err := webhookQueueWorker.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
-		logger.Error().Err(err).Msg("worker stopped with error")
-	} else {
-		logger.Info().Msg("worker shutdown complete")
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_ = heartbeat.Run(ctx)
+	}()
+	for _, w := range queueWorkers {
+		wg.Add(1)
+		go func(w queue.Worker) {
+			defer wg.Done()
+			if err := w.Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				logger.Error().Err(err).Str("queue_kind", w.Kind).Msg("worker stopped with error")
+				// One queue failing takes the process down so it gets restarted.
+				stop()
+			}
+		}(w)
 	}
+	if cfg.RetentionEnabled {
+		purger := &notify.Purger{
+			Store: queries,
+			Retention: notify.Retention{
+				Delivered:  days(cfg.RetentionDeliveredDays),
+				DeadLetter: days(cfg.RetentionDLQDays),
+				Attempts:   days(cfg.RetentionAttemptsDays),
+				Events:     days(cfg.RetentionEventsDays),
+				Inbound:    days(cfg.RetentionInboundDays),
+			},
+			BatchSize: cfg.RetentionBatchSize,
+			Interval:  cfg.RetentionInterval,
+			Logger:    logger.With().Str("job", "retention").Logger(),
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = purger.Run(ctx)
+		}()
+	}
+	if cfg.AccountPurgeInterval > 0 {
+		accountPurger := &user.Purger{
+			Svc:      user.NewService(pool),
+			After:    cfg.AccountRecoveryWindow,
+			Interval: cfg.AccountPurgeInterval,
+			Logger:   logger.With().Str("job", "account_purge").Logger(),
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = accountPurger.Run(ctx)
+		}()
+	}
+	if cfg.AnalyticsRefreshInterval > 0 {
+		refresher := &analytics.Refresher{
+			Svc: &analytics.Service{
+				Q:            queries,
+				R:            redisClient,
+				TTL:          cfg.AnalyticsCacheTTL,
+				DefaultRange: cfg.AnalyticsDefaultRange,
+				Prefix:       cfg.RedisCachePrefix,
+			},
+			Interval:        cfg.AnalyticsRefreshInterval,
+			WarmConcurrency: cfg.AnalyticsWarmConcurrency,
+			Logger:          logger.With().Str("job", "analytics_refresh").Logger(),
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = refresher.Run(ctx)
+		}()
+	}
+	if addr := envOrDefault("WORKER_METRICS_ADDR", ""); addr != "" {
+		metricsServer := &http.Server{Addr: addr, Handler: promhttp.Handler(), ReadHeaderTimeout: 5 * time.Second}
+		go func() {
+			if err := metricsServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error().Err(err).Msg("worker metrics server stopped")
+			}
+		}()
+		defer func() { _ = metricsServer.Close() }()
+	}
+	wg.Wait()
+	logger.Info().Msg("worker shutdown complete")
 }
 
 func mustInitDatabase(ctx context.Context, cfg *config.Config, logger zerolog.Logger) (*pgxpool.Pool, *dbgen.Queries) {
@@ -105,7 +236,7 @@ func mustInitDatabase(ctx context.Context, cfg *config.Config, logger zerolog.Lo
 	if err != nil {
 		logger.Fatal().Err(err).Msg("parse database config")
 	}
-	poolConfig.ConnConfig.Tracer = obs.PGXTracer{}
+	poolConfig.ConnConfig.Tracer = obs.PGXTracer{SlowThreshold: cfg.DBSlowQueryThreshold, Logger: logger}
 	if cfg.DBStatementCacheCapacity >= 0 {
 		poolConfig.ConnConfig.StatementCacheCapacity = cfg.DBStatementCacheCapacity
 	}
@@ -119,10 +250,14 @@ func mustInitDatabase(ctx context.Context, cfg *config.Config, logger zerolog.Lo
 	if err != nil {
 		logger.Fatal().Err(err).Msg("connect database")
 	}
-	if err := pool.Ping(ctx); err != nil {
+	if err := startupRetry(cfg, logger, "postgres").Do(ctx, pool.Ping); err != nil {
 		logger.Fatal().Err(err).Msg("ping database")
 	}
-	return pool, dbgen.New(pool)
+	bootstrap.CheckSchema(ctx, cfg, logger, pool)
+	if cfg.ChaosEnabled {
+		logger.Warn().Strs("targets", cfg.ChaosTargets).Float64("failure_rate", cfg.ChaosFailureRate).Float64("latency_rate", cfg.ChaosLatencyRate).Msg("chaos testing enabled; faults are injected")
+	}
+	return pool, dbgen.New(cfg.Chaos().DB(pool))
 }
 
 func mustInitRedis(ctx context.Context, cfg *config.Config, logger zerolog.Logger) *redis.Client {
@@ -134,12 +269,30 @@ func mustInitRedis(ctx context.Context, cfg *config.Config, logger zerolog.Logge
 	if err := redisotel.InstrumentTracing(redisClient); err != nil {
 		logger.Error().Err(err).Msg("instrument redis tracing")
 	}
-	if err := redisClient.Ping(ctx).Err(); err != nil {
+	if err := startupRetry(cfg, logger, "redis").Do(ctx, func(ctx context.Context) error {
+		return redisClient.Ping(ctx).Err()
+	}); err != nil {
 		logger.Fatal().Err(err).Msg("ping redis")
 	}
 	return redisClient
 }
 
+// startupRetry retries a dependency check during startup so the process
+// tolerates the database or Redis becoming ready a little after it does.
+func startupRetry(cfg *config.Config, logger zerolog.Logger, dependency string) resilience.Retry {
+	return resilience.Retry{
+		Attempts: cfg.StartupConnectAttempts,
+		MaxWait:  cfg.StartupConnectMaxWait,
+		OnRetry: func(attempt int, wait time.Duration, err error) {
+			logger.Warn().Err(err).Str("dependency", dependency).Int("attempt", attempt).Dur("retryIn", wait).Msg("dependency not ready")
+		},
+	}
+}
+
+func days(n int) time.Duration {
+	return time.Duration(n) * 24 * time.Hour
+}
+
 func envOrDefault(key, fallback string) string {
 	if val, ok := os.LookupEnv(key); ok {
 		trimmed := strings.TrimSpace(val)
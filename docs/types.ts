@@ -149,6 +149,11 @@ export interface ProductVariant {
   attributes: Record<string, string>;
 }
 
+export interface ProductOption {
+  name: string;
+  values: string[];
+}
+
 export interface Product {
   id: string;
   title: string;
@@ -184,6 +189,7 @@ export interface ProductDetail extends Product {
     slug: string;
   };
   images: ProductImage[];
+  options: ProductOption[];
   variants?: ProductVariant[];
   specifications?: Record<string, string>;
   weight?: number;
@@ -1,12 +1,78 @@
 package analytics
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
+	"github.com/jackc/pgx/v5/pgtype"
+
 	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
 )
 
+// salesDay is the response shape of a sales row. Counts and revenue use
+// common.Int64 so they follow the configured integer encoding.
+type salesDay struct {
+	Day        pgtype.Timestamptz `json:"day"`
+	PaidOrders common.Int64       `json:"paid_orders"`
+	AllOrders  common.Int64       `json:"all_orders"`
+	Revenue    common.Int64       `json:"revenue"`
+}
+
+// topProduct is the response shape of a top products row.
+type topProduct struct {
+	ProductID pgtype.UUID  `json:"product_id"`
+	QtySold   common.Int64 `json:"qty_sold"`
+	Gross     common.Int64 `json:"gross"`
+}
+
+// voucherPerformance is the response shape of a voucher report row.
+type voucherPerformance struct {
+	Code        string       `json:"code"`
+	Redemptions common.Int64 `json:"redemptions"`
+	Discount    common.Int64 `json:"discount"`
+	Revenue     common.Int64 `json:"revenue"`
+	UniqueUsers common.Int64 `json:"unique_users"`
+}
+
+func salesDays(rows []dbgen.GetSalesDailyRangeRow) []salesDay {
+	out := make([]salesDay, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, salesDay{
+			Day:        row.Day,
+			PaidOrders: common.Int64(row.PaidOrders),
+			AllOrders:  common.Int64(row.AllOrders),
+			Revenue:    common.Int64(row.Revenue),
+		})
+	}
+	return out
+}
+
+func topProducts(rows []dbgen.MvTopProduct) []topProduct {
+	out := make([]topProduct, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, topProduct{ProductID: row.ProductID, QtySold: common.Int64(row.QtySold), Gross: common.Int64(row.Gross)})
+	}
+	return out
+}
+
+func voucherRows(rows []dbgen.GetVoucherPerformanceRow) []voucherPerformance {
+	out := make([]voucherPerformance, 0, len(rows))
+	for _, row := range rows {
+		out = append(out, voucherPerformance{
+			Code:        row.Code,
+			Redemptions: common.Int64(row.Redemptions),
+			Discount:    common.Int64(row.Discount),
+			Revenue:     common.Int64(row.Revenue),
+			UniqueUsers: common.Int64(row.UniqueUsers),
+		})
+	}
+	return out
+}
+
 // Handler exposes analytics read endpoints.
 type Handler struct {
 	Svc *Service
@@ -18,50 +84,117 @@ func (h *Handler) Sales(w http.ResponseWriter, r *http.Request) {
 		common.JSONError(w, http.StatusInternalServerError, "ANALYTICS_NOT_CONFIGURED", "analytics service not configured", nil)
 		return
 	}
+	from, to, ok := h.dateRange(w, r, ReportSales)
+	if !ok {
+		return
+	}
+	compare, ok := compareParam(w, r)
+	if !ok {
+		return
+	}
+	requested := r.URL.Query().Get("granularity")
+	switch requested {
+	case "", GranularityDay, GranularityWeek, GranularityMonth:
+	default:
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "granularity must be day, week, or month", map[string]any{"field": "granularity"})
+		return
+	}
+	rows, fresh, err := h.Svc.SalesRange(r.Context(), from, to)
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, "ANALYTICS_ERROR", err.Error(), nil)
+		return
+	}
+	granularity := h.Svc.Limits.Granularity(requested, to.Sub(from))
+	payload := map[string]any{
+		"data": salesDays(BucketSales(rows, granularity)),
+		"meta": salesMeta{Freshness: fresh, Granularity: granularity},
+	}
+	if compare {
+		overview, _, err := h.Svc.Overview(r.Context(), from, to, true)
+		if err != nil {
+			common.JSONError(w, http.StatusInternalServerError, "ANALYTICS_ERROR", err.Error(), nil)
+			return
+		}
+		payload["compare"] = overview
+	}
+	common.JSON(w, http.StatusOK, payload)
+}
+
+// salesMeta adds the bucket size actually used to the sales freshness meta.
+type salesMeta struct {
+	Freshness
+	Granularity string `json:"granularity"`
+}
+
+// Vouchers reports how each voucher code performed over the requested range,
+// sorted by redemptions (default) or discount given.
+func (h *Handler) Vouchers(w http.ResponseWriter, r *http.Request) {
+	if h.Svc == nil {
+		common.JSONError(w, http.StatusInternalServerError, "ANALYTICS_NOT_CONFIGURED", "analytics service not configured", nil)
+		return
+	}
+	from, to, ok := h.dateRange(w, r, ReportVouchers)
+	if !ok {
+		return
+	}
+	q := r.URL.Query()
+	sortBy := q.Get("sort")
+	switch sortBy {
+	case "":
+		sortBy = VoucherSortRedemptions
+	case VoucherSortRedemptions, VoucherSortDiscount:
+	default:
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "sort must be redemptions or discount", nil)
+		return
+	}
+	limit := common.AtoiDefault(q.Get("limit"), 20)
+	offset := common.AtoiDefault(q.Get("offset"), 0)
+	rows, fresh, err := h.Svc.VoucherPerformance(r.Context(), from, to, sortBy, int32(limit), int32(offset))
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, "ANALYTICS_ERROR", err.Error(), nil)
+		return
+	}
+	common.JSON(w, http.StatusOK, map[string]any{"data": voucherRows(rows), "meta": fresh})
+}
+
+// dateRange reads from/to (RFC 3339) or the last days (default
+// DefaultRange) from the query, writing a 400 and returning false when the
+// range is invalid. An explicit range longer than the report's maximum is
+// rejected; days is clamped to it.
+func (h *Handler) dateRange(w http.ResponseWriter, r *http.Request, report string) (time.Time, time.Time, bool) {
 	query := r.URL.Query()
 	fromStr := query.Get("from")
 	toStr := query.Get("to")
-	now := h.Svc.now()
 	var (
 		from time.Time
 		to   time.Time
 		err  error
 	)
+	maxDays := h.Svc.Limits.MaxRangeDays(report)
 	if fromStr != "" && toStr != "" {
 		from, err = time.Parse(time.RFC3339, fromStr)
 		if err != nil {
 			common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid from date", nil)
-			return
+			return from, to, false
 		}
 		to, err = time.Parse(time.RFC3339, toStr)
 		if err != nil {
 			common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid to date", nil)
-			return
+			return from, to, false
 		}
 	} else {
-		days := h.Svc.DefaultRange
-		if days <= 0 {
-			days = 30
-		}
-		if raw := query.Get("days"); raw != "" {
-			parsed := common.AtoiDefault(raw, days)
-			if parsed > 0 {
-				days = parsed
-			}
-		}
-		to = now
-		from = to.AddDate(0, 0, -days)
+		days := common.AtoiDefault(query.Get("days"), 0)
+		from, to = h.Svc.lastDays(min(days, maxDays))
 	}
 	if !from.Before(to) {
 		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "from must be before to", nil)
-		return
+		return from, to, false
 	}
-	rows, err := h.Svc.SalesRange(r.Context(), from, to)
-	if err != nil {
-		common.JSONError(w, http.StatusInternalServerError, "ANALYTICS_ERROR", err.Error(), nil)
-		return
+	if to.Sub(from) > time.Duration(maxDays)*24*time.Hour {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", fmt.Sprintf("range must not exceed %d days", maxDays), map[string]any{"field": "from", "max_days": maxDays})
+		return from, to, false
 	}
-	common.JSON(w, http.StatusOK, map[string]any{"data": rows})
+	return from, to, true
 }
 
 // TopProducts returns the top selling products within the analytics view.
@@ -79,15 +212,77 @@ func (h *Handler) TopProducts(w http.ResponseWriter, r *http.Request) {
 	if offset < 0 {
 		offset = 0
 	}
-	rows, err := h.Svc.TopProducts(r.Context(), int32(limit), int32(offset))
+	rows, fresh, err := h.Svc.TopProducts(r.Context(), int32(limit), int32(offset))
 	if err != nil {
 		common.JSONError(w, http.StatusInternalServerError, "ANALYTICS_ERROR", err.Error(), nil)
 		return
 	}
-	common.JSON(w, http.StatusOK, map[string]any{"data": rows})
+	common.JSON(w, http.StatusOK, map[string]any{"data": topProducts(rows), "meta": fresh})
+}
+
+// Refresh rebuilds the analytics materialized views, or only the one named by
+// the view query parameter, and reports how long each took.
+func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
+	if h.Svc == nil {
+		common.JSONError(w, http.StatusInternalServerError, "ANALYTICS_NOT_CONFIGURED", "analytics service not configured", nil)
+		return
+	}
+	var views []string
+	if view := strings.TrimSpace(r.URL.Query().Get("view")); view != "" {
+		views = []string{view}
+	}
+	start := time.Now()
+	results, err := h.Svc.Refresh(r.Context(), views...)
+	switch {
+	case errors.Is(err, ErrUnknownView):
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "view must be one of "+strings.Join(Views, ", "), map[string]any{"field": "view"})
+		return
+	case errors.Is(err, ErrRefreshInProgress):
+		common.JSONError(w, http.StatusConflict, "CONFLICT", "an analytics refresh is already running", nil)
+		return
+	case err != nil:
+		common.JSONError(w, http.StatusInternalServerError, "ANALYTICS_ERROR", err.Error(), map[string]any{"refreshed": results})
+		return
+	}
+	common.JSON(w, http.StatusOK, map[string]any{"data": map[string]any{
+		"views":       results,
+		"duration_ms": time.Since(start).Milliseconds(),
+	}})
 }
 
-// Overview aggregates key analytics metrics for dashboards.
+// Overview totals revenue, orders, and AOV over the requested range and,
+// with compare=previous, the change against the period before it.
 func (h *Handler) Overview(w http.ResponseWriter, r *http.Request) {
-	common.JSONError(w, http.StatusNotImplemented, "NOT_IMPLEMENTED", "overview will be available soon", nil)
+	if h.Svc == nil {
+		common.JSONError(w, http.StatusInternalServerError, "ANALYTICS_NOT_CONFIGURED", "analytics service not configured", nil)
+		return
+	}
+	from, to, ok := h.dateRange(w, r, ReportSales)
+	if !ok {
+		return
+	}
+	compare, ok := compareParam(w, r)
+	if !ok {
+		return
+	}
+	overview, fresh, err := h.Svc.Overview(r.Context(), from, to, compare)
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, "ANALYTICS_ERROR", err.Error(), nil)
+		return
+	}
+	common.JSON(w, http.StatusOK, map[string]any{"data": overview, "meta": fresh})
+}
+
+// compareParam reads compare, which is empty or "previous", writing a 400
+// and returning false for anything else.
+func compareParam(w http.ResponseWriter, r *http.Request) (bool, bool) {
+	switch r.URL.Query().Get("compare") {
+	case "":
+		return false, true
+	case "previous":
+		return true, true
+	default:
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "compare must be previous", map[string]any{"field": "compare"})
+		return false, false
+	}
 }
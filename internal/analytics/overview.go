@@ -0,0 +1,111 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+// Totals sums sales over a period. AOV is revenue per paid order.
+type Totals struct {
+	From       time.Time    `json:"from"`
+	To         time.Time    `json:"to"`
+	Revenue    common.Int64 `json:"revenue"`
+	PaidOrders common.Int64 `json:"paid_orders"`
+	AllOrders  common.Int64 `json:"all_orders"`
+	AOV        common.Int64 `json:"aov"`
+}
+
+// Change compares two periods. The percentages are nil when the previous
+// value is zero, since there is no meaningful ratio.
+type Change struct {
+	Revenue       common.Int64 `json:"revenue"`
+	RevenuePct    *float64     `json:"revenue_pct"`
+	PaidOrders    common.Int64 `json:"paid_orders"`
+	PaidOrdersPct *float64     `json:"paid_orders_pct"`
+	AOV           common.Int64 `json:"aov"`
+	AOVPct        *float64     `json:"aov_pct"`
+}
+
+// Overview is the dashboard summary for a period, optionally compared with
+// the period of the same length right before it.
+type Overview struct {
+	Current  Totals  `json:"current"`
+	Previous *Totals `json:"previous,omitempty"`
+	Change   *Change `json:"change,omitempty"`
+}
+
+// PreviousPeriod returns the window of the same length ending at from.
+func PreviousPeriod(from, to time.Time) (time.Time, time.Time) {
+	return from.Add(-to.Sub(from)), from
+}
+
+// SumSales totals daily sales rows for [from, to).
+func SumSales(rows []dbgen.GetSalesDailyRangeRow, from, to time.Time) Totals {
+	totals := Totals{From: from, To: to}
+	for _, row := range rows {
+		totals.Revenue += common.Int64(row.Revenue)
+		totals.PaidOrders += common.Int64(row.PaidOrders)
+		totals.AllOrders += common.Int64(row.AllOrders)
+	}
+	if totals.PaidOrders > 0 {
+		totals.AOV = totals.Revenue / totals.PaidOrders
+	}
+	return totals
+}
+
+// Compare returns how current moved against previous.
+func Compare(current, previous Totals) Change {
+	return Change{
+		Revenue:       current.Revenue - previous.Revenue,
+		RevenuePct:    percentChange(current.Revenue, previous.Revenue),
+		PaidOrders:    current.PaidOrders - previous.PaidOrders,
+		PaidOrdersPct: percentChange(current.PaidOrders, previous.PaidOrders),
+		AOV:           current.AOV - previous.AOV,
+		AOVPct:        percentChange(current.AOV, previous.AOV),
+	}
+}
+
+func percentChange(current, previous common.Int64) *float64 {
+	if previous == 0 {
+		return nil
+	}
+	pct := math.Round(float64(current-previous)/float64(previous)*10000) / 100
+	return &pct
+}
+
+// Overview totals sales over [from, to) and, with compare, the previous
+// period of the same length. The combined result is cached per range and
+// compare mode; its freshness is that of the current period.
+func (s *Service) Overview(ctx context.Context, from, to time.Time, compare bool) (Overview, Freshness, error) {
+	if s == nil || s.Q == nil {
+		return Overview{}, Freshness{}, fmt.Errorf("analytics service not configured")
+	}
+	key := s.key("analytics", "overview", from.Format(time.DateOnly), to.Format(time.DateOnly), compare)
+	var overview Overview
+	if fresh, ok := s.load(ctx, key, &overview); ok {
+		return overview, fresh, nil
+	}
+	rows, fresh, err := s.SalesRange(ctx, from, to)
+	if err != nil {
+		return Overview{}, Freshness{}, err
+	}
+	overview.Current = SumSales(rows, from, to)
+	if compare {
+		prevFrom, prevTo := PreviousPeriod(from, to)
+		prevRows, _, err := s.SalesRange(ctx, prevFrom, prevTo)
+		if err != nil {
+			return Overview{}, Freshness{}, err
+		}
+		previous := SumSales(prevRows, prevFrom, prevTo)
+		change := Compare(overview.Current, previous)
+		overview.Previous = &previous
+		overview.Change = &change
+	}
+	s.store(ctx, key, overview, fresh)
+	return overview, fresh, nil
+}
@@ -0,0 +1,120 @@
+package analytics
+
+import (
+	"time"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+// Reports whose date range is limited by MaxRangeDays.
+const (
+	ReportSales    = "sales"
+	ReportVouchers = "vouchers"
+)
+
+// Sales bucket sizes.
+const (
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
+const (
+	defaultMaxRangeDays     = 366
+	defaultWeeklyAfterDays  = 92
+	defaultMonthlyAfterDays = 366
+)
+
+// RangeLimits guards the expensive range reports. Zero values use the
+// defaults: 366 days at most, weekly buckets past 92 days, and monthly
+// buckets past 366 days.
+type RangeLimits struct {
+	// MaxDays is the longest range any report accepts, and MaxDaysByReport
+	// overrides it per report name.
+	MaxDays         int
+	MaxDaysByReport map[string]int
+	// WeeklyAfterDays and MonthlyAfterDays set the finest sales granularity
+	// allowed for ranges longer than that many days.
+	WeeklyAfterDays  int
+	MonthlyAfterDays int
+}
+
+// MaxRangeDays returns the longest range, in days, report accepts.
+func (l RangeLimits) MaxRangeDays(report string) int {
+	if days := l.MaxDaysByReport[report]; days > 0 {
+		return days
+	}
+	if l.MaxDays > 0 {
+		return l.MaxDays
+	}
+	return defaultMaxRangeDays
+}
+
+// Granularity returns requested, coarsened as needed for a range of the
+// given length. An empty or unknown request counts as daily.
+func (l RangeLimits) Granularity(requested string, span time.Duration) string {
+	weekly := l.WeeklyAfterDays
+	if weekly <= 0 {
+		weekly = defaultWeeklyAfterDays
+	}
+	monthly := l.MonthlyAfterDays
+	if monthly <= 0 {
+		monthly = defaultMonthlyAfterDays
+	}
+	days := int(span / (24 * time.Hour))
+	minimum := GranularityDay
+	switch {
+	case days > monthly:
+		minimum = GranularityMonth
+	case days > weekly:
+		minimum = GranularityWeek
+	}
+	if granularityRank(requested) > granularityRank(minimum) {
+		return requested
+	}
+	return minimum
+}
+
+func granularityRank(g string) int {
+	switch g {
+	case GranularityWeek:
+		return 1
+	case GranularityMonth:
+		return 2
+	default:
+		return 0
+	}
+}
+
+// BucketSales sums daily sales rows into weekly (starting Monday) or monthly
+// buckets in UTC. Daily rows are returned unchanged.
+func BucketSales(rows []dbgen.GetSalesDailyRangeRow, granularity string) []dbgen.GetSalesDailyRangeRow {
+	if granularity != GranularityWeek && granularity != GranularityMonth {
+		return rows
+	}
+	out := make([]dbgen.GetSalesDailyRangeRow, 0, len(rows))
+	index := make(map[time.Time]int, len(rows))
+	for _, row := range rows {
+		start := bucketStart(row.Day.Time.UTC(), granularity)
+		i, ok := index[start]
+		if !ok {
+			i = len(out)
+			index[start] = i
+			out = append(out, dbgen.GetSalesDailyRangeRow{Day: row.Day})
+			out[i].Day.Time = start
+		}
+		out[i].PaidOrders += row.PaidOrders
+		out[i].AllOrders += row.AllOrders
+		out[i].Revenue += row.Revenue
+	}
+	return out
+}
+
+func bucketStart(day time.Time, granularity string) time.Time {
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	if granularity == GranularityMonth {
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	// Weekday counts from Sunday; weeks start on Monday.
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
@@ -0,0 +1,189 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/lock"
+)
+
+// Materialized views that can be refreshed on demand.
+const (
+	ViewSalesDaily  = "sales_daily"
+	ViewTopProducts = "top_products"
+	// ViewProductCopurchase feeds "frequently bought together"
+	// recommendations.
+	ViewProductCopurchase = "product_copurchase"
+)
+
+// Views lists the refreshable views in the order Refresh runs them.
+var Views = []string{ViewSalesDaily, ViewTopProducts, ViewProductCopurchase}
+
+// refreshLockTTL bounds how long a crashed refresh blocks the next one; the
+// lease is renewed while the refresh is running.
+const refreshLockTTL = time.Minute
+
+var (
+	// ErrUnknownView is returned by Refresh for a view not in Views.
+	ErrUnknownView = errors.New("analytics: unknown view")
+	// ErrRefreshInProgress is returned by Refresh while another refresh,
+	// possibly on another instance, is running.
+	ErrRefreshInProgress = errors.New("analytics: refresh already in progress")
+)
+
+// RefreshResult reports one refreshed view.
+type RefreshResult struct {
+	View        string    `json:"view"`
+	RefreshedAt time.Time `json:"refreshed_at"`
+	DurationMs  int64     `json:"duration_ms"`
+}
+
+// Refresh rebuilds the given materialized views, or all of them when none are
+// given, records when each finished, and evicts the cached reports. Only one
+// refresh runs at a time across instances. When a view fails, the results of
+// the views refreshed before it are returned with the error.
+func (s *Service) Refresh(ctx context.Context, views ...string) ([]RefreshResult, error) {
+	if s == nil || s.Q == nil {
+		return nil, fmt.Errorf("analytics service not configured")
+	}
+	if len(views) == 0 {
+		views = Views
+	}
+	for _, view := range views {
+		if s.refresher(view) == nil {
+			return nil, fmt.Errorf("%w: %q", ErrUnknownView, view)
+		}
+	}
+	var results []RefreshResult
+	run := func(ctx context.Context) error {
+		for _, view := range views {
+			start := time.Now()
+			if err := s.refresher(view)(ctx); err != nil {
+				return fmt.Errorf("refresh %s: %w", view, err)
+			}
+			result := RefreshResult{View: view, RefreshedAt: s.now().UTC(), DurationMs: time.Since(start).Milliseconds()}
+			if err := s.Q.MarkAnalyticsViewRefreshed(ctx, dbgen.MarkAnalyticsViewRefreshedParams{
+				ViewName:    view,
+				RefreshedAt: pgtype.Timestamptz{Time: result.RefreshedAt, Valid: true},
+				DurationMs:  result.DurationMs,
+			}); err != nil {
+				return fmt.Errorf("record %s refresh: %w", view, err)
+			}
+			results = append(results, result)
+		}
+		return nil
+	}
+	var err error
+	if s.R == nil {
+		err = run(ctx)
+	} else {
+		// Kept outside the analytics:* namespace so Clear cannot drop it.
+		key := s.key("lock", "analytics", "refresh")
+		err = lock.Locker{R: s.R}.TryWithRenewingLock(ctx, key, refreshLockTTL, 0, run)
+		if errors.Is(err, lock.ErrLocked) {
+			return nil, ErrRefreshInProgress
+		}
+	}
+	if len(results) > 0 {
+		s.Clear(ctx)
+	}
+	return results, err
+}
+
+// RefreshedAt reports when view was last refreshed through Refresh, or nil
+// when it never was.
+func (s *Service) RefreshedAt(ctx context.Context, view string) (*time.Time, error) {
+	if s == nil || s.Q == nil {
+		return nil, fmt.Errorf("analytics service not configured")
+	}
+	at, err := s.Q.GetAnalyticsViewRefreshedAt(ctx, view)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !at.Valid {
+		return nil, nil
+	}
+	t := at.Time.UTC()
+	return &t, nil
+}
+
+func (s *Service) refresher(view string) func(context.Context) error {
+	switch view {
+	case ViewSalesDaily:
+		return s.Q.RefreshSalesDaily
+	case ViewTopProducts:
+		return s.Q.RefreshTopProducts
+	case ViewProductCopurchase:
+		return s.Q.RefreshProductCopurchase
+	default:
+		return nil
+	}
+}
+
+// Refresher refreshes every view on a schedule so the reports and the
+// recommendations built on them stay current without an admin refresh.
+type Refresher struct {
+	Svc *Service
+	// Interval between refreshes; zero runs hourly.
+	Interval time.Duration
+	// WarmConcurrency caps parallel report loads when re-warming the cache
+	// after a refresh; zero skips warming.
+	WarmConcurrency int
+	Logger          zerolog.Logger
+}
+
+// Run refreshes all views every Interval until ctx is done. A pass is skipped
+// while another refresh, e.g. an admin one, is running.
+func (r *Refresher) Run(ctx context.Context) error {
+	interval := r.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		results, err := r.Svc.Refresh(ctx)
+		switch {
+		case errors.Is(err, ErrRefreshInProgress):
+			r.Logger.Debug().Msg("analytics refresh skipped; another is running")
+		case err != nil && ctx.Err() == nil:
+			r.Logger.Error().Err(err).Msg("analytics refresh failed")
+		case err == nil:
+			event := r.Logger.Info()
+			for _, result := range results {
+				event = event.Int64(result.View+"_ms", result.DurationMs)
+			}
+			event.Msg("analytics refresh complete")
+			r.warm(ctx)
+		}
+	}
+}
+
+// warm re-caches the dashboard reports the refresh just evicted, so the
+// first admin after a refresh is not served from cold views.
+func (r *Refresher) warm(ctx context.Context) {
+	if r.WarmConcurrency <= 0 {
+		return
+	}
+	result, err := r.Svc.Warm(ctx, r.WarmConcurrency)
+	if err != nil && ctx.Err() == nil {
+		r.Logger.Error().Err(err).Msg("analytics cache warm failed")
+		return
+	}
+	r.Logger.Info().Int("reports", result.Reports).Int("failed", result.Failed).Msg("analytics cache warmed")
+}
@@ -17,8 +17,23 @@ import (
 type Querier interface {
 	GetSalesDailyRange(ctx context.Context, arg dbgen.GetSalesDailyRangeParams) ([]dbgen.GetSalesDailyRangeRow, error)
 	GetTopProducts(ctx context.Context, arg dbgen.GetTopProductsParams) ([]dbgen.MvTopProduct, error)
+	GetVoucherPerformance(ctx context.Context, arg dbgen.GetVoucherPerformanceParams) ([]dbgen.GetVoucherPerformanceRow, error)
+	RefreshSalesDaily(ctx context.Context) error
+	RefreshTopProducts(ctx context.Context) error
+	RefreshProductCopurchase(ctx context.Context) error
+	MarkAnalyticsViewRefreshed(ctx context.Context, arg dbgen.MarkAnalyticsViewRefreshedParams) error
+	GetAnalyticsViewRefreshedAt(ctx context.Context, viewName string) (pgtype.Timestamptz, error)
 }
 
+// Voucher report sort orders.
+const (
+	VoucherSortRedemptions = "redemptions"
+	VoucherSortDiscount    = "discount"
+)
+
+// MaxVoucherLimit caps the rows returned by one voucher report page.
+const MaxVoucherLimit = 100
+
 // Service provides cached access to analytics materialized views.
 type Service struct {
 	Q            Querier
@@ -27,6 +42,7 @@ type Service struct {
 	DefaultRange int
 	Now          func() time.Time
 	Prefix       string
+	Limits       RangeLimits
 }
 
 func (s *Service) now() time.Time {
@@ -36,6 +52,19 @@ func (s *Service) now() time.Time {
 	return time.Now()
 }
 
+// lastDays returns the range covering the last days up to now, or the last
+// DefaultRange days (30 when unset) when days is not positive.
+func (s *Service) lastDays(days int) (time.Time, time.Time) {
+	if days <= 0 {
+		days = s.DefaultRange
+	}
+	if days <= 0 {
+		days = 30
+	}
+	to := s.now()
+	return to.AddDate(0, 0, -days), to
+}
+
 func (s *Service) key(parts ...any) string {
 	formatted := make([]string, 0, len(parts))
 	prefix := strings.Trim(s.Prefix, ": ")
@@ -48,31 +77,50 @@ func (s *Service) key(parts ...any) string {
 	return strings.Join(formatted, ":")
 }
 
+// Freshness tells consumers how current a report is.
+type Freshness struct {
+	// DataAsOf is when the data behind the report was captured: the last
+	// refresh of its materialized view, or GeneratedAt for live queries. Nil
+	// when the view was never refreshed through Refresh.
+	DataAsOf *time.Time `json:"data_as_of"`
+	// GeneratedAt is when the report was read from the database.
+	GeneratedAt time.Time `json:"generated_at"`
+	// Cached reports whether the report was served from Redis, and
+	// CacheAgeSec how long ago it was stored there.
+	Cached      bool  `json:"cached"`
+	CacheAgeSec int64 `json:"cache_age_sec"`
+}
+
 // SalesRange returns sales summary between the provided bounds inclusive of from and exclusive of to.
-func (s *Service) SalesRange(ctx context.Context, from, to time.Time) ([]dbgen.GetSalesDailyRangeRow, error) {
+func (s *Service) SalesRange(ctx context.Context, from, to time.Time) ([]dbgen.GetSalesDailyRangeRow, Freshness, error) {
 	if s == nil || s.Q == nil {
-		return nil, fmt.Errorf("analytics service not configured")
+		return nil, Freshness{}, fmt.Errorf("analytics service not configured")
 	}
 	key := s.key("analytics", "sales", from.Format(time.DateOnly), to.Format(time.DateOnly))
-	if rows, ok := s.getSalesFromCache(ctx, key); ok {
-		return rows, nil
+	var rows []dbgen.GetSalesDailyRangeRow
+	if fresh, ok := s.load(ctx, key, &rows); ok {
+		return rows, fresh, nil
+	}
+	fresh, err := s.viewFreshness(ctx, ViewSalesDaily)
+	if err != nil {
+		return nil, Freshness{}, err
 	}
 	params := dbgen.GetSalesDailyRangeParams{
 		StartDate: pgtype.Timestamptz{Time: from, Valid: true},
 		EndDate:   pgtype.Timestamptz{Time: to, Valid: true},
 	}
-	rows, err := s.Q.GetSalesDailyRange(ctx, params)
+	rows, err = s.Q.GetSalesDailyRange(ctx, params)
 	if err != nil {
-		return nil, err
+		return nil, Freshness{}, err
 	}
-	s.store(ctx, key, rows)
-	return rows, nil
+	s.store(ctx, key, rows, fresh)
+	return rows, fresh, nil
 }
 
 // TopProducts returns paginated top-selling products ordered by quantity sold.
-func (s *Service) TopProducts(ctx context.Context, limit, offset int32) ([]dbgen.MvTopProduct, error) {
+func (s *Service) TopProducts(ctx context.Context, limit, offset int32) ([]dbgen.MvTopProduct, Freshness, error) {
 	if s == nil || s.Q == nil {
-		return nil, fmt.Errorf("analytics service not configured")
+		return nil, Freshness{}, fmt.Errorf("analytics service not configured")
 	}
 	if limit <= 0 {
 		limit = 10
@@ -81,52 +129,115 @@ func (s *Service) TopProducts(ctx context.Context, limit, offset int32) ([]dbgen
 		offset = 0
 	}
 	key := s.key("analytics", "top", limit, offset)
-	if rows, ok := s.getTopFromCache(ctx, key); ok {
-		return rows, nil
+	var rows []dbgen.MvTopProduct
+	if fresh, ok := s.load(ctx, key, &rows); ok {
+		return rows, fresh, nil
 	}
-	rows, err := s.Q.GetTopProducts(ctx, dbgen.GetTopProductsParams{OffsetRows: offset, LimitCount: limit})
+	fresh, err := s.viewFreshness(ctx, ViewTopProducts)
 	if err != nil {
-		return nil, err
+		return nil, Freshness{}, err
 	}
-	s.store(ctx, key, rows)
-	return rows, nil
+	rows, err = s.Q.GetTopProducts(ctx, dbgen.GetTopProductsParams{OffsetRows: offset, LimitCount: limit})
+	if err != nil {
+		return nil, Freshness{}, err
+	}
+	s.store(ctx, key, rows, fresh)
+	return rows, fresh, nil
 }
 
-func (s *Service) getSalesFromCache(ctx context.Context, key string) ([]dbgen.GetSalesDailyRangeRow, bool) {
-	if s.R == nil || s.TTL <= 0 || strings.TrimSpace(key) == "" {
-		return nil, false
+// VoucherPerformance returns per-code redemption counts, discount given,
+// attributed revenue, and unique users for redemptions within [from, to).
+// Revenue excludes canceled orders.
+func (s *Service) VoucherPerformance(ctx context.Context, from, to time.Time, sortBy string, limit, offset int32) ([]dbgen.GetVoucherPerformanceRow, Freshness, error) {
+	if s == nil || s.Q == nil {
+		return nil, Freshness{}, fmt.Errorf("analytics service not configured")
 	}
-	data, err := s.R.Get(ctx, key).Bytes()
+	if sortBy != VoucherSortDiscount {
+		sortBy = VoucherSortRedemptions
+	}
+	if limit <= 0 {
+		limit = 20
+	}
+	limit = min(limit, MaxVoucherLimit)
+	if offset < 0 {
+		offset = 0
+	}
+	// Explicit ranges are arbitrary instants, so both bounds key at full
+	// precision; day granularity would serve one range's rows for another.
+	key := s.key("analytics", "vouchers", from.UTC().Format(time.RFC3339Nano), to.UTC().Format(time.RFC3339Nano), sortBy, limit, offset)
+	var rows []dbgen.GetVoucherPerformanceRow
+	if fresh, ok := s.load(ctx, key, &rows); ok {
+		return rows, fresh, nil
+	}
+	rows, err := s.Q.GetVoucherPerformance(ctx, dbgen.GetVoucherPerformanceParams{
+		StartDate:  pgtype.Timestamptz{Time: from, Valid: true},
+		EndDate:    pgtype.Timestamptz{Time: to, Valid: true},
+		SortBy:     sortBy,
+		OffsetRows: offset,
+		LimitCount: limit,
+	})
 	if err != nil {
-		return nil, false
+		return nil, Freshness{}, err
 	}
-	var rows []dbgen.GetSalesDailyRangeRow
-	if err := json.Unmarshal(data, &rows); err != nil {
-		return nil, false
+	// Read live from the tables, so the data is as of now.
+	generated := s.now().UTC()
+	fresh := Freshness{DataAsOf: &generated, GeneratedAt: generated}
+	s.store(ctx, key, rows, fresh)
+	return rows, fresh, nil
+}
+
+// viewFreshness stamps a report about to be read from view.
+func (s *Service) viewFreshness(ctx context.Context, view string) (Freshness, error) {
+	asOf, err := s.RefreshedAt(ctx, view)
+	if err != nil {
+		return Freshness{}, fmt.Errorf("load %s refresh time: %w", view, err)
 	}
-	return rows, true
+	return Freshness{DataAsOf: asOf, GeneratedAt: s.now().UTC()}, nil
 }
 
-func (s *Service) getTopFromCache(ctx context.Context, key string) ([]dbgen.MvTopProduct, bool) {
+// cachedReport is the cached form of a report: its rows plus the freshness
+// they were generated with.
+type cachedReport struct {
+	Rows        json.RawMessage `json:"rows"`
+	GeneratedAt time.Time       `json:"generated_at"`
+	DataAsOf    *time.Time      `json:"data_as_of"`
+}
+
+// load decodes the cached report under key into rows. Entries in an older
+// format do not decode and count as misses.
+func (s *Service) load(ctx context.Context, key string, rows any) (Freshness, bool) {
 	if s.R == nil || s.TTL <= 0 || strings.TrimSpace(key) == "" {
-		return nil, false
+		return Freshness{}, false
 	}
 	data, err := s.R.Get(ctx, key).Bytes()
 	if err != nil {
-		return nil, false
+		return Freshness{}, false
 	}
-	var rows []dbgen.MvTopProduct
-	if err := json.Unmarshal(data, &rows); err != nil {
-		return nil, false
+	var cached cachedReport
+	if err := json.Unmarshal(data, &cached); err != nil || cached.GeneratedAt.IsZero() {
+		return Freshness{}, false
 	}
-	return rows, true
+	if err := json.Unmarshal(cached.Rows, rows); err != nil {
+		return Freshness{}, false
+	}
+	age := max(s.now().Sub(cached.GeneratedAt), 0)
+	return Freshness{
+		DataAsOf:    cached.DataAsOf,
+		GeneratedAt: cached.GeneratedAt,
+		Cached:      true,
+		CacheAgeSec: int64(age / time.Second),
+	}, true
 }
 
-func (s *Service) store(ctx context.Context, key string, value any) {
+func (s *Service) store(ctx context.Context, key string, rows any, fresh Freshness) {
 	if s.R == nil || s.TTL <= 0 || strings.TrimSpace(key) == "" {
 		return
 	}
-	data, err := json.Marshal(value)
+	encoded, err := json.Marshal(rows)
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(cachedReport{Rows: encoded, GeneratedAt: fresh.GeneratedAt, DataAsOf: fresh.DataAsOf})
 	if err != nil {
 		return
 	}
@@ -2,10 +2,12 @@ package analytics_test
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/alicebob/miniredis/v2"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/redis/go-redis/v9"
 
@@ -14,7 +16,47 @@ import (
 )
 
 type stubQueries struct {
-	salesCalls int
+	salesCalls   int
+	voucherCalls int
+	voucherSort  string
+	voucherLimit int32
+	refreshed    []string
+	refreshedAt  map[string]pgtype.Timestamptz
+	refreshGate  chan struct{}
+}
+
+func (s *stubQueries) RefreshSalesDaily(ctx context.Context) error {
+	if s.refreshGate != nil {
+		<-s.refreshGate
+	}
+	s.refreshed = append(s.refreshed, analytics.ViewSalesDaily)
+	return nil
+}
+
+func (s *stubQueries) RefreshTopProducts(ctx context.Context) error {
+	s.refreshed = append(s.refreshed, analytics.ViewTopProducts)
+	return nil
+}
+
+func (s *stubQueries) RefreshProductCopurchase(ctx context.Context) error {
+	s.refreshed = append(s.refreshed, analytics.ViewProductCopurchase)
+	return nil
+}
+
+func (s *stubQueries) MarkAnalyticsViewRefreshed(ctx context.Context, arg dbgen.MarkAnalyticsViewRefreshedParams) error {
+	if s.refreshedAt == nil {
+		s.refreshedAt = map[string]pgtype.Timestamptz{}
+	}
+	s.refreshedAt[arg.ViewName] = arg.RefreshedAt
+	return nil
+}
+
+func (s *stubQueries) GetAnalyticsViewRefreshedAt(ctx context.Context, viewName string) (pgtype.Timestamptz, error) {
+	at, ok := s.refreshedAt[viewName]
+	if !ok {
+		return pgtype.Timestamptz{}, pgx.ErrNoRows
+	}
+	return at, nil
 }
 
 func (s *stubQueries) GetSalesDailyRange(ctx context.Context, arg dbgen.GetSalesDailyRangeParams) ([]dbgen.GetSalesDailyRangeRow, error) {
@@ -26,6 +68,13 @@ func (s *stubQueries) GetTopProducts(ctx context.Context, arg dbgen.GetTopProduc
 	return nil, nil
 }
 
+func (s *stubQueries) GetVoucherPerformance(ctx context.Context, arg dbgen.GetVoucherPerformanceParams) ([]dbgen.GetVoucherPerformanceRow, error) {
+	s.voucherCalls++
+	s.voucherSort = arg.SortBy
+	s.voucherLimit = arg.LimitCount
+	return []dbgen.GetVoucherPerformanceRow{{Code: "HEMAT10", Redemptions: 4, Discount: 40000, Revenue: 900000, UniqueUsers: 3}}, nil
+}
+
 func TestSalesRangeCached(t *testing.T) {
 	mr, err := miniredis.Run()
 	if err != nil {
@@ -37,13 +86,269 @@ func TestSalesRangeCached(t *testing.T) {
 	svc := &analytics.Service{Q: queries, R: rdb, TTL: time.Minute, DefaultRange: 30, Prefix: "test"}
 	from := time.Now().Add(-24 * time.Hour).Truncate(24 * time.Hour)
 	to := time.Now().Truncate(24 * time.Hour)
-	if _, err := svc.SalesRange(context.Background(), from, to); err != nil {
+	if _, _, err := svc.SalesRange(context.Background(), from, to); err != nil {
 		t.Fatalf("first call: %v", err)
 	}
-	if _, err := svc.SalesRange(context.Background(), from, to); err != nil {
+	if _, _, err := svc.SalesRange(context.Background(), from, to); err != nil {
 		t.Fatalf("second call: %v", err)
 	}
 	if queries.salesCalls != 1 {
 		t.Fatalf("expected 1 DB call, got %d", queries.salesCalls)
 	}
 }
+
+func TestVoucherPerformanceCachedPerSort(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	queries := &stubQueries{}
+	svc := &analytics.Service{Q: queries, R: rdb, TTL: time.Minute, Prefix: "test"}
+	to := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	from := to.AddDate(0, 0, -30)
+
+	rows, _, err := svc.VoucherPerformance(context.Background(), from, to, analytics.VoucherSortDiscount, 10, 0)
+	if err != nil {
+		t.Fatalf("first call: %v", err)
+	}
+	if len(rows) != 1 || rows[0].Discount != 40000 || rows[0].UniqueUsers != 3 {
+		t.Fatalf("unexpected rows: %+v", rows)
+	}
+	if _, _, err := svc.VoucherPerformance(context.Background(), from, to, analytics.VoucherSortDiscount, 10, 0); err != nil {
+		t.Fatalf("cached call: %v", err)
+	}
+	if queries.voucherCalls != 1 || queries.voucherSort != analytics.VoucherSortDiscount {
+		t.Fatalf("expected one discount-sorted query, got %d (%s)", queries.voucherCalls, queries.voucherSort)
+	}
+	if _, _, err := svc.VoucherPerformance(context.Background(), from, to, "bogus", 10, 0); err != nil {
+		t.Fatalf("default sort: %v", err)
+	}
+	if queries.voucherCalls != 2 || queries.voucherSort != analytics.VoucherSortRedemptions {
+		t.Fatalf("expected a redemption-sorted query, got %d (%s)", queries.voucherCalls, queries.voucherSort)
+	}
+}
+
+func TestVoucherPerformanceKeysFullRangeAndClampsLimit(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	queries := &stubQueries{}
+	svc := &analytics.Service{Q: queries, R: rdb, TTL: time.Minute, Prefix: "test"}
+	from := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 6, 2, 6, 0, 0, 0, time.UTC)
+
+	if _, _, err := svc.VoucherPerformance(context.Background(), from, to, analytics.VoucherSortRedemptions, 10000, 0); err != nil {
+		t.Fatalf("first range: %v", err)
+	}
+	if queries.voucherLimit != analytics.MaxVoucherLimit {
+		t.Fatalf("expected limit clamped to %d, got %d", analytics.MaxVoucherLimit, queries.voucherLimit)
+	}
+	// Same calendar days, different instants: must not share a cache entry.
+	if _, _, err := svc.VoucherPerformance(context.Background(), from.Add(12*time.Hour), to.Add(12*time.Hour), analytics.VoucherSortRedemptions, 10000, 0); err != nil {
+		t.Fatalf("second range: %v", err)
+	}
+	if queries.voucherCalls != 2 {
+		t.Fatalf("expected each range to be queried, got %d queries", queries.voucherCalls)
+	}
+}
+
+func TestRefreshRecordsFreshnessAndClearsCache(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	queries := &stubQueries{}
+	now := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	svc := &analytics.Service{Q: queries, R: rdb, TTL: time.Minute, Prefix: "test", Now: func() time.Time { return now }}
+	ctx := context.Background()
+	from, to := now.AddDate(0, 0, -7), now
+
+	if at, err := svc.RefreshedAt(ctx, analytics.ViewSalesDaily); err != nil || at != nil {
+		t.Fatalf("expected no refresh yet, got %v (%v)", at, err)
+	}
+	if _, _, err := svc.SalesRange(ctx, from, to); err != nil {
+		t.Fatalf("warm cache: %v", err)
+	}
+
+	results, err := svc.Refresh(ctx, analytics.ViewSalesDaily)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if len(results) != 1 || results[0].View != analytics.ViewSalesDaily || !results[0].RefreshedAt.Equal(now) {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+	if len(queries.refreshed) != 1 {
+		t.Fatalf("expected only sales_daily refreshed, got %v", queries.refreshed)
+	}
+	at, err := svc.RefreshedAt(ctx, analytics.ViewSalesDaily)
+	if err != nil || at == nil || !at.Equal(now) {
+		t.Fatalf("expected refreshed_at %v, got %v (%v)", now, at, err)
+	}
+	if _, _, err := svc.SalesRange(ctx, from, to); err != nil {
+		t.Fatalf("reload: %v", err)
+	}
+	if queries.salesCalls != 2 {
+		t.Fatalf("expected the refresh to evict the cached report, got %d DB calls", queries.salesCalls)
+	}
+
+	if _, err := svc.Refresh(ctx); err != nil {
+		t.Fatalf("refresh all: %v", err)
+	}
+	if len(queries.refreshed) != 1+len(analytics.Views) || queries.refreshed[2] != analytics.ViewTopProducts || queries.refreshed[3] != analytics.ViewProductCopurchase {
+		t.Fatalf("expected every view refreshed, got %v", queries.refreshed)
+	}
+	if _, err := svc.Refresh(ctx, "mv_orders"); !errors.Is(err, analytics.ErrUnknownView) {
+		t.Fatalf("expected ErrUnknownView, got %v", err)
+	}
+}
+
+func TestRefreshDoesNotOverlap(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	queries := &stubQueries{refreshGate: make(chan struct{})}
+	svc := &analytics.Service{Q: queries, R: rdb, Prefix: "test"}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := svc.Refresh(context.Background(), analytics.ViewSalesDaily)
+		done <- err
+	}()
+	// Wait until the first refresh holds the lock.
+	deadline := time.Now().Add(time.Second)
+	for len(mr.Keys()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if _, err := svc.Refresh(context.Background(), analytics.ViewTopProducts); !errors.Is(err, analytics.ErrRefreshInProgress) {
+		t.Fatalf("expected ErrRefreshInProgress, got %v", err)
+	}
+	close(queries.refreshGate)
+	if err := <-done; err != nil {
+		t.Fatalf("first refresh: %v", err)
+	}
+	if _, err := svc.Refresh(context.Background(), analytics.ViewTopProducts); err != nil {
+		t.Fatalf("refresh after release: %v", err)
+	}
+}
+
+func TestReportsCarryFreshness(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	refreshed := time.Date(2025, 6, 1, 6, 0, 0, 0, time.UTC)
+	queries := &stubQueries{refreshedAt: map[string]pgtype.Timestamptz{
+		analytics.ViewSalesDaily: {Time: refreshed, Valid: true},
+	}}
+	now := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	svc := &analytics.Service{Q: queries, R: rdb, TTL: time.Minute, Prefix: "test", Now: func() time.Time { return now }}
+	ctx := context.Background()
+	from, to := now.AddDate(0, 0, -7), now
+
+	_, fresh, err := svc.SalesRange(ctx, from, to)
+	if err != nil {
+		t.Fatalf("sales: %v", err)
+	}
+	if fresh.Cached || fresh.DataAsOf == nil || !fresh.DataAsOf.Equal(refreshed) || !fresh.GeneratedAt.Equal(now) {
+		t.Fatalf("unexpected fresh report: %+v", fresh)
+	}
+
+	now = now.Add(45 * time.Second)
+	_, fresh, err = svc.SalesRange(ctx, from, to)
+	if err != nil {
+		t.Fatalf("cached sales: %v", err)
+	}
+	if !fresh.Cached || fresh.CacheAgeSec != 45 || !fresh.DataAsOf.Equal(refreshed) {
+		t.Fatalf("unexpected cached report: %+v", fresh)
+	}
+
+	_, fresh, err = svc.TopProducts(ctx, 10, 0)
+	if err != nil {
+		t.Fatalf("top products: %v", err)
+	}
+	if fresh.DataAsOf != nil {
+		t.Fatalf("expected unknown freshness for a never refreshed view, got %v", fresh.DataAsOf)
+	}
+
+	_, fresh, err = svc.VoucherPerformance(ctx, from, to, analytics.VoucherSortRedemptions, 10, 0)
+	if err != nil {
+		t.Fatalf("vouchers: %v", err)
+	}
+	if fresh.DataAsOf == nil || !fresh.DataAsOf.Equal(now) {
+		t.Fatalf("expected live report data as of now, got %+v", fresh)
+	}
+}
+
+func TestRangeLimitsAndSalesBuckets(t *testing.T) {
+	limits := analytics.RangeLimits{MaxDaysByReport: map[string]int{analytics.ReportSales: 1095}}
+	if got := limits.MaxRangeDays(analytics.ReportSales); got != 1095 {
+		t.Fatalf("expected sales override, got %d", got)
+	}
+	if got := limits.MaxRangeDays(analytics.ReportVouchers); got != 366 {
+		t.Fatalf("expected default limit, got %d", got)
+	}
+	day := 24 * time.Hour
+	cases := []struct {
+		requested string
+		span      time.Duration
+		want      string
+	}{
+		{"", 30 * day, analytics.GranularityDay},
+		{analytics.GranularityMonth, 30 * day, analytics.GranularityMonth},
+		{analytics.GranularityDay, 120 * day, analytics.GranularityWeek},
+		{analytics.GranularityWeek, 400 * day, analytics.GranularityMonth},
+	}
+	for _, tc := range cases {
+		if got := limits.Granularity(tc.requested, tc.span); got != tc.want {
+			t.Fatalf("Granularity(%q, %v) = %s, want %s", tc.requested, tc.span, got, tc.want)
+		}
+	}
+
+	row := func(y int, m time.Month, d int, revenue int64) dbgen.GetSalesDailyRangeRow {
+		return dbgen.GetSalesDailyRangeRow{Day: pgtype.Timestamptz{Time: time.Date(y, m, d, 0, 0, 0, 0, time.UTC), Valid: true}, PaidOrders: 1, AllOrders: 2, Revenue: revenue}
+	}
+	// 2025-06-01 is a Sunday, so it closes the week starting 2025-05-26.
+	rows := []dbgen.GetSalesDailyRangeRow{row(2025, 5, 31, 100), row(2025, 6, 1, 200), row(2025, 6, 2, 400)}
+	weeks := analytics.BucketSales(rows, analytics.GranularityWeek)
+	if len(weeks) != 2 || !weeks[0].Day.Time.Equal(time.Date(2025, 5, 26, 0, 0, 0, 0, time.UTC)) || weeks[0].Revenue != 300 || weeks[0].PaidOrders != 2 || weeks[1].Revenue != 400 {
+		t.Fatalf("unexpected weekly buckets: %+v", weeks)
+	}
+	months := analytics.BucketSales(rows, analytics.GranularityMonth)
+	if len(months) != 2 || !months[1].Day.Time.Equal(time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)) || months[1].Revenue != 600 || months[1].AllOrders != 4 {
+		t.Fatalf("unexpected monthly buckets: %+v", months)
+	}
+}
+
+func TestOverviewComparesWithPreviousPeriod(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	queries := &stubQueries{}
+	now := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	svc := &analytics.Service{Q: queries, R: rdb, TTL: time.Minute, Prefix: "test", Now: func() time.Time { return now }}
+	ctx := context.Background()
+	from, to := now.AddDate(0, 0, -7), now
+
+	overview, _, err := svc.Overview(ctx, from, to, true)
+	if err != nil {
+		t.Fatalf("overview: %v", err)
+	}
+	if overview.Current.Revenue != 1000 || overview.Current.AOV != 500 || overview.Previous == nil || !overview.Previous.To.Equal(from) || !overview.Previous.From.Equal(from.AddDate(0, 0, -7)) {
+		t.Fatalf("unexpected overview: %+v", overview)
+	}
+	if overview.Change == nil || overview.Change.Revenue != 0 || overview.Change.RevenuePct == nil || *overview.Change.RevenuePct != 0 {
+		t.Fatalf("unexpected change: %+v", overview.Change)
+	}
+	if queries.salesCalls != 2 {
+		t.Fatalf("expected current and previous sales queries, got %d", queries.salesCalls)
+	}
+
+	if _, fresh, err := svc.Overview(ctx, from, to, true); err != nil || !fresh.Cached {
+		t.Fatalf("expected cached overview, got %+v (%v)", fresh, err)
+	}
+	plain, _, err := svc.Overview(ctx, from, to, false)
+	if err != nil || plain.Previous != nil || plain.Change != nil {
+		t.Fatalf("expected overview without comparison, got %+v (%v)", plain, err)
+	}
+	if queries.salesCalls != 2 {
+		t.Fatalf("expected sales reports served from cache, got %d DB calls", queries.salesCalls)
+	}
+
+	if pct := analytics.Compare(analytics.Totals{Revenue: 150}, analytics.Totals{Revenue: 120}).RevenuePct; pct == nil || *pct != 25 {
+		t.Fatalf("expected 25%% growth, got %v", pct)
+	}
+	if pct := analytics.Compare(analytics.Totals{Revenue: 150}, analytics.Totals{}).RevenuePct; pct != nil {
+		t.Fatalf("expected no percentage against zero, got %v", *pct)
+	}
+}
@@ -0,0 +1,80 @@
+package analytics
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	defaultWarmConcurrency = 2
+	maxWarmConcurrency     = 8
+	// warmTopLimit and warmVoucherLimit match the handlers' default page
+	// sizes, so the first dashboard load hits the warmed keys.
+	warmTopLimit     = 10
+	warmVoucherLimit = 20
+)
+
+// WarmResult counts the reports a warm run cached.
+type WarmResult struct {
+	Reports int `json:"reports"`
+	Failed  int `json:"failed"`
+}
+
+// Warm caches the reports the dashboard opens with: sales and voucher
+// performance over the default range and the first page of top products. It
+// goes through the regular read path, so reports already cached are left as
+// they are. At most concurrency reports load at once; zero uses the default.
+func (s *Service) Warm(ctx context.Context, concurrency int) (WarmResult, error) {
+	if s == nil || s.Q == nil || s.R == nil || s.TTL <= 0 {
+		return WarmResult{}, nil
+	}
+	if concurrency <= 0 {
+		concurrency = defaultWarmConcurrency
+	}
+	concurrency = min(concurrency, maxWarmConcurrency)
+
+	from, to := s.lastDays(0)
+	reports := []func(context.Context) error{
+		func(ctx context.Context) error {
+			_, _, err := s.SalesRange(ctx, from, to)
+			return err
+		},
+		func(ctx context.Context) error {
+			_, _, err := s.TopProducts(ctx, warmTopLimit, 0)
+			return err
+		},
+		func(ctx context.Context) error {
+			_, _, err := s.VoucherPerformance(ctx, from, to, VoucherSortRedemptions, warmVoucherLimit, 0)
+			return err
+		},
+		func(ctx context.Context) error {
+			_, _, err := s.VoucherPerformance(ctx, from, to, VoucherSortDiscount, warmVoucherLimit, 0)
+			return err
+		},
+	}
+
+	var warmed, failed atomic.Int64
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+schedule:
+	for _, report := range reports {
+		select {
+		case <-ctx.Done():
+			break schedule
+		case sem <- struct{}{}:
+		}
+		wg.Add(1)
+		go func(report func(context.Context) error) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if err := report(ctx); err != nil {
+				failed.Add(1)
+				return
+			}
+			warmed.Add(1)
+		}(report)
+	}
+	wg.Wait()
+	return WarmResult{Reports: int(warmed.Load()), Failed: int(failed.Load())}, ctx.Err()
+}
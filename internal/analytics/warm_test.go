@@ -0,0 +1,53 @@
+package analytics_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/noah-isme/backend-toko/internal/analytics"
+)
+
+func TestWarmCachesDefaultReports(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	queries := &stubQueries{}
+	now := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	svc := &analytics.Service{Q: queries, R: rdb, TTL: time.Minute, DefaultRange: 7, Prefix: "test", Now: func() time.Time { return now }}
+	ctx := context.Background()
+
+	// One at a time: the stub counters are not synchronized.
+	result, err := svc.Warm(ctx, 1)
+	if err != nil {
+		t.Fatalf("warm: %v", err)
+	}
+	if result.Reports != 4 || result.Failed != 0 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if queries.salesCalls != 1 || queries.voucherCalls != 2 {
+		t.Fatalf("expected one sales and two voucher queries, got %d and %d", queries.salesCalls, queries.voucherCalls)
+	}
+
+	_, fresh, err := svc.SalesRange(ctx, now.AddDate(0, 0, -7), now)
+	if err != nil {
+		t.Fatalf("sales: %v", err)
+	}
+	if !fresh.Cached || queries.salesCalls != 1 {
+		t.Fatalf("expected the default range to be served from the warmed cache, calls=%d", queries.salesCalls)
+	}
+	if _, fresh, err := svc.TopProducts(ctx, 10, 0); err != nil || !fresh.Cached {
+		t.Fatalf("expected warmed top products, cached=%v err=%v", fresh.Cached, err)
+	}
+}
+
+func TestWarmSkipsWithoutCache(t *testing.T) {
+	queries := &stubQueries{}
+	svc := &analytics.Service{Q: queries}
+	result, err := svc.Warm(context.Background(), 2)
+	if err != nil || result.Reports != 0 || queries.salesCalls != 0 {
+		t.Fatalf("expected no work without a cache, got %+v (%v)", result, err)
+	}
+}
@@ -18,19 +18,17 @@ func (h Handler) List(w http.ResponseWriter, r *http.Request) {
 		common.JSONError(w, http.StatusInternalServerError, "AUDIT_NOT_CONFIGURED", "audit store not configured", nil)
 		return
 	}
-	limit := common.AtoiDefault(r.URL.Query().Get("limit"), 50)
-	if limit <= 0 || limit > 200 {
-		limit = 50
-	}
-	offset := common.AtoiDefault(r.URL.Query().Get("offset"), 0)
-	if offset < 0 {
-		offset = 0
-	}
+	limit, offset := common.ParseOffsetPagination(r, 50, 200)
 
 	rows, err := h.Store.ListAuditLogs(r.Context(), dbgen.ListAuditLogsParams{Limit: int32(limit), Offset: int32(offset)})
 	if err != nil {
 		common.JSONError(w, http.StatusInternalServerError, "AUDIT_QUERY_FAILED", "unable to fetch audit logs", nil)
 		return
 	}
-	common.JSON(w, http.StatusOK, rows)
+	total, err := h.Store.CountAuditLogs(r.Context())
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, "AUDIT_QUERY_FAILED", "unable to count audit logs", nil)
+		return
+	}
+	common.WritePage(w, r, "audit-logs", rows, limit, offset, total, nil)
 }
@@ -7,6 +7,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"github.com/noah-isme/backend-toko/internal/common"
 	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
 )
 
@@ -22,6 +23,10 @@ func (l *listStore) ListAuditLogs(_ context.Context, arg dbgen.ListAuditLogsPara
 	return []dbgen.AuditLog{{Action: "TEST", Method: "GET"}}, nil
 }
 
+func (l *listStore) CountAuditLogs(context.Context) (int64, error) {
+	return 40, nil
+}
+
 func TestHandlerList(t *testing.T) {
 	store := &listStore{}
 	h := Handler{Store: store}
@@ -34,11 +39,19 @@ func TestHandlerList(t *testing.T) {
 	if store.receivedLimit != 25 || store.receivedOffset != 10 {
 		t.Fatalf("unexpected pagination params: %d/%d", store.receivedLimit, store.receivedOffset)
 	}
-	var payload []map[string]any
+	var payload struct {
+		Data       []map[string]any `json:"data"`
+		Pagination common.PageInfo  `json:"pagination"`
+		Total      int64            `json:"total"`
+	}
 	if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil {
 		t.Fatalf("decode response: %v", err)
 	}
-	if len(payload) != 1 {
-		t.Fatalf("expected one log entry, got %d", len(payload))
+	if len(payload.Data) != 1 {
+		t.Fatalf("expected one log entry, got %d", len(payload.Data))
+	}
+	want := common.PageInfo{Page: 1, Limit: 25, Offset: 10, Total: 40, HasMore: true}
+	if payload.Pagination != want || payload.Total != 40 {
+		t.Fatalf("unexpected pagination: %+v (total %d)", payload.Pagination, payload.Total)
 	}
 }
@@ -3,6 +3,7 @@ package audit
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 
@@ -14,6 +15,9 @@ type HTTPRecorder struct {
 	Service   *Service
 	OnError   func(error)
 	ActorFunc func(*http.Request) Actor
+	// BodyLimit skips capturing bodies longer than this many bytes; zero
+	// means 8192.
+	BodyLimit int
 }
 
 // HTTPConfig customises how the audit entry is produced for a route.
@@ -23,6 +27,24 @@ type HTTPConfig struct {
 	ResourceIDParam string
 	MetadataFunc    func(*http.Request, int) map[string]any
 	ActorFunc       func(*http.Request) Actor
+	// RoutePrefix, when set, derives the resource type, action, and resource
+	// ID from the matched route pattern below it and the HTTP method, so PUT
+	// {prefix}/vouchers/{code} is recorded as resource voucher, action update.
+	// ResourceType and Action remain the fallback for unmatched routes.
+	RoutePrefix string
+	// Routes overrides the derived values per "METHOD /pattern", with the
+	// pattern relative to RoutePrefix.
+	Routes map[string]RouteAudit
+	// CaptureBody adds the JSON or form request body to the metadata as
+	// "body", with sensitive fields redacted by the Service's Redactor.
+	CaptureBody bool
+}
+
+// RouteAudit names the resource and action recorded for a route; empty
+// fields keep the derived value.
+type RouteAudit struct {
+	ResourceType string
+	Action       string
 }
 
 // Middleware returns a chi-compatible middleware that records audit entries.
@@ -34,6 +56,16 @@ func (r HTTPRecorder) Middleware(cfg HTTPConfig) func(http.Handler) http.Handler
 				return
 			}
 
+			var body any
+			var hasBody bool
+			if cfg.CaptureBody {
+				limit := r.BodyLimit
+				if limit <= 0 {
+					limit = 8192
+				}
+				body, hasBody = r.Service.Redactor.Body(req, limit)
+			}
+
 			recorder := &statusRecorder{ResponseWriter: w, status: 0}
 			next.ServeHTTP(recorder, req)
 
@@ -42,27 +74,142 @@ func (r HTTPRecorder) Middleware(cfg HTTPConfig) func(http.Handler) http.Handler
 				actor = cfg.ActorFunc(req)
 			}
 
+			action, resourceType := cfg.Action, cfg.ResourceType
 			resourceID := ""
+			if cfg.RoutePrefix != "" {
+				if derived, ok := cfg.derive(req); ok {
+					action, resourceType, resourceID = derived.Action, derived.ResourceType, derived.resourceID
+				}
+			}
 			if cfg.ResourceIDParam != "" {
 				resourceID = chi.URLParam(req, cfg.ResourceIDParam)
 			}
 
-			var metadata []byte
+			var payload map[string]any
 			if cfg.MetadataFunc != nil {
-				if payload := cfg.MetadataFunc(req, recorder.Status()); payload != nil {
-					if data, err := json.Marshal(payload); err == nil {
-						metadata = data
-					}
+				payload = cfg.MetadataFunc(req, recorder.Status())
+			}
+			if hasBody {
+				if payload == nil {
+					payload = map[string]any{}
+				}
+				payload["body"] = body
+				if req.URL.RawQuery != "" {
+					payload["query"] = req.URL.RawQuery
+				}
+			}
+			var metadata []byte
+			if payload != nil {
+				if data, err := json.Marshal(payload); err == nil {
+					metadata = data
 				}
 			}
 
-			if err := r.Service.Record(req.Context(), actor, cfg.Action, cfg.ResourceType, resourceID, req, recorder.Status(), metadata); err != nil && r.OnError != nil {
+			if err := r.Service.Record(req.Context(), actor, action, resourceType, resourceID, req, recorder.Status(), metadata); err != nil && r.OnError != nil {
 				r.OnError(err)
 			}
 		})
 	}
 }
 
+type derivedRoute struct {
+	RouteAudit
+	resourceID string
+}
+
+// derive maps the matched route pattern below RoutePrefix to a resource and
+// action. The resource is the last collection followed by an ID parameter,
+// or the first segment when none is; literal segments after it name the
+// operation, e.g. POST /orders/{id}/refund is order refund and PATCH
+// /orders/{id}/status is order update_status.
+func (cfg HTTPConfig) derive(req *http.Request) (derivedRoute, bool) {
+	rc := chi.RouteContext(req.Context())
+	if rc == nil {
+		return derivedRoute{}, false
+	}
+	rest, ok := strings.CutPrefix(rc.RoutePattern(), strings.TrimSuffix(cfg.RoutePrefix, "/"))
+	if !ok {
+		return derivedRoute{}, false
+	}
+	rest = "/" + strings.Trim(rest, "/")
+	segments := strings.Split(strings.Trim(rest, "/"), "/")
+	if segments[0] == "" || isParam(segments[0]) {
+		return derivedRoute{}, false
+	}
+
+	resource, idParam := 0, ""
+	for i := 0; i+1 < len(segments); i++ {
+		if !isParam(segments[i]) && isParam(segments[i+1]) {
+			resource, idParam = i, strings.Trim(segments[i+1], "{}")
+		}
+	}
+	var operation []string
+	for _, segment := range segments[resource+1:] {
+		if !isParam(segment) {
+			operation = append(operation, segment)
+		}
+	}
+
+	var verb string
+	switch req.Method {
+	case http.MethodPost:
+		verb = "create"
+	case http.MethodPut, http.MethodPatch:
+		verb = "update"
+	case http.MethodDelete:
+		verb = "delete"
+	default:
+		verb = "read"
+		if idParam == "" && len(operation) == 0 {
+			verb = "list"
+		}
+	}
+	action := verb
+	if len(operation) > 0 {
+		action = strings.ReplaceAll(strings.Join(operation, "_"), "-", "_")
+		if req.Method != http.MethodPost {
+			action = verb + "_" + action
+		}
+	}
+
+	out := derivedRoute{
+		RouteAudit: RouteAudit{ResourceType: singular(segments[resource]), Action: action},
+	}
+	if idParam != "" {
+		out.resourceID = chi.URLParam(req, idParam)
+	}
+	if override, ok := cfg.Routes[req.Method+" "+rest]; ok {
+		if override.ResourceType != "" {
+			out.ResourceType = override.ResourceType
+		}
+		if override.Action != "" {
+			out.Action = override.Action
+		}
+	}
+	return out, true
+}
+
+func isParam(segment string) bool {
+	return strings.HasPrefix(segment, "{")
+}
+
+// singular turns a collection segment such as webhook-deliveries into the
+// resource name webhook_delivery.
+func singular(segment string) string {
+	name := strings.ReplaceAll(segment, "-", "_")
+	switch {
+	case strings.HasSuffix(name, "ies"):
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "ches"), strings.HasSuffix(name, "shes"), strings.HasSuffix(name, "xes"):
+		return strings.TrimSuffix(name, "es")
+	case strings.HasSuffix(name, "ss"), strings.HasSuffix(name, "us"), strings.HasSuffix(name, "ics"):
+		return name
+	case strings.HasSuffix(name, "s"):
+		return strings.TrimSuffix(name, "s")
+	}
+	return name
+}
+
 func (r HTTPRecorder) actor(req *http.Request) Actor {
 	if r.ActorFunc != nil {
 		return r.ActorFunc(req)
@@ -0,0 +1,126 @@
+package audit
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestMiddlewareDerivesResourceAndActionFromRoute(t *testing.T) {
+	store := &stubStore{}
+	recorder := HTTPRecorder{Service: &Service{Store: store, Enabled: true}}
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+
+	r := chi.NewRouter()
+	r.Route("/api/v1/admin", func(admin chi.Router) {
+		admin.Use(recorder.Middleware(HTTPConfig{
+			ResourceType: "admin",
+			RoutePrefix:  "/api/v1/admin",
+			Routes: map[string]RouteAudit{
+				"POST /orders/{id}/refund": {ResourceType: "payment"},
+			},
+		}))
+		admin.Get("/orders", ok)
+		admin.Put("/vouchers/{code}", ok)
+		admin.Post("/vouchers/bulk", ok)
+		admin.Patch("/orders/{id}/status", ok)
+		admin.Post("/orders/{id}/refund", ok)
+		admin.Get("/webhook-deliveries/{id}/attempts", ok)
+		admin.Put("/products/{id}/variants/{variantId}/bundle", ok)
+	})
+
+	cases := []struct {
+		method, path                 string
+		resource, action, resourceID string
+	}{
+		{http.MethodGet, "/api/v1/admin/orders", "order", "list", ""},
+		{http.MethodPut, "/api/v1/admin/vouchers/HEMAT10", "voucher", "update", "HEMAT10"},
+		{http.MethodPost, "/api/v1/admin/vouchers/bulk", "voucher", "bulk", ""},
+		{http.MethodPatch, "/api/v1/admin/orders/o-1/status", "order", "update_status", "o-1"},
+		{http.MethodPost, "/api/v1/admin/orders/o-1/refund", "payment", "refund", "o-1"},
+		{http.MethodGet, "/api/v1/admin/webhook-deliveries/d-1/attempts", "webhook_delivery", "read_attempts", "d-1"},
+		{http.MethodPut, "/api/v1/admin/products/p-1/variants/v-1/bundle", "variant", "update_bundle", "v-1"},
+	}
+	for _, tc := range cases {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(tc.method, tc.path, nil))
+		got := store.lastInsert
+		if got.ResourceType != tc.resource || got.Action != tc.action {
+			t.Fatalf("%s %s: got resource=%s action=%s, want %s %s", tc.method, tc.path, got.ResourceType, got.Action, tc.resource, tc.action)
+		}
+		id := ""
+		if got.ResourceID.Valid {
+			id = got.ResourceID.String
+		}
+		if id != tc.resourceID {
+			t.Fatalf("%s %s: got resource id %q, want %q", tc.method, tc.path, id, tc.resourceID)
+		}
+		if !got.Route.Valid || tc.resourceID != "" && !strings.Contains(got.Route.String, "{") {
+			t.Fatalf("%s %s: expected route pattern, got %q", tc.method, tc.path, got.Route.String)
+		}
+	}
+}
+
+func TestMiddlewareKeepsStaticResourceWithoutPrefix(t *testing.T) {
+	store := &stubStore{}
+	recorder := HTTPRecorder{Service: &Service{Store: store, Enabled: true}}
+	r := chi.NewRouter()
+	r.With(recorder.Middleware(HTTPConfig{ResourceType: "auth"})).Post("/api/v1/auth/login", func(w http.ResponseWriter, r *http.Request) {})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", nil))
+	if store.lastInsert.ResourceType != "auth" || store.lastInsert.Action != "POST /api/v1/auth/login" {
+		t.Fatalf("unexpected entry: %+v", store.lastInsert)
+	}
+}
+
+func TestMiddlewareRedactsCapturedLoginBody(t *testing.T) {
+	store := &stubStore{}
+	recorder := HTTPRecorder{Service: &Service{Store: store, Enabled: true}}
+	var seen string
+	r := chi.NewRouter()
+	r.With(recorder.Middleware(HTTPConfig{ResourceType: "auth", CaptureBody: true})).Post("/api/v1/auth/login", func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		seen = string(data)
+	})
+
+	body := `{"email":"a@example.com","password":"hunter2-secret"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if seen != body {
+		t.Fatalf("handler saw %q, want the original body", seen)
+	}
+	meta := string(store.lastInsert.Metadata)
+	if strings.Contains(meta, "hunter2-secret") {
+		t.Fatalf("password leaked into audit metadata: %s", meta)
+	}
+	if !strings.Contains(meta, "a@example.com") || !strings.Contains(meta, "[REDACTED]") {
+		t.Fatalf("expected redacted body in metadata, got %s", meta)
+	}
+}
+
+func TestMiddlewareRedactsTwoFactorLoginBody(t *testing.T) {
+	store := &stubStore{}
+	recorder := HTTPRecorder{Service: &Service{Store: store, Enabled: true}}
+	r := chi.NewRouter()
+	r.With(recorder.Middleware(HTTPConfig{ResourceType: "auth", CaptureBody: true})).Post("/api/v1/auth/2fa/login", func(w http.ResponseWriter, r *http.Request) {})
+
+	body := `{"challenge":"chal-8f2a91","code":"RCVR-7731-XQ"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/2fa/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	meta := string(store.lastInsert.Metadata)
+	for _, leaked := range []string{"chal-8f2a91", "RCVR-7731-XQ"} {
+		if strings.Contains(meta, leaked) {
+			t.Fatalf("%q leaked into audit metadata: %s", leaked, meta)
+		}
+	}
+	if !strings.Contains(meta, `"challenge":"[REDACTED]"`) || !strings.Contains(meta, `"code":"[REDACTED]"`) {
+		t.Fatalf("expected redacted body in metadata, got %s", meta)
+	}
+}
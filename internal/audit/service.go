@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
 
@@ -38,6 +39,7 @@ type Actor struct {
 type Store interface {
 	InsertAuditLog(ctx context.Context, arg dbgen.InsertAuditLogParams) (dbgen.InsertAuditLogRow, error)
 	ListAuditLogs(ctx context.Context, arg dbgen.ListAuditLogsParams) ([]dbgen.AuditLog, error)
+	CountAuditLogs(ctx context.Context) (int64, error)
 }
 
 // Service persists audit logs for critical application flows.
@@ -45,6 +47,9 @@ type Service struct {
 	Store        Store
 	Enabled      bool
 	SamplingRate float64
+	// Redactor masks sensitive fields in the recorded query string and
+	// metadata.
+	Redactor common.Redactor
 }
 
 // Record persists an audit log entry when auditing is enabled.
@@ -66,6 +71,9 @@ func (s Service) Record(ctx context.Context, actor Actor, action, resourceType,
 
 	method := req.Method
 	route := obs.RoutePatternFromContext(req.Context())
+	if rc := chi.RouteContext(req.Context()); route == "" && rc != nil {
+		route = rc.RoutePattern()
+	}
 	if route == "" {
 		route = strings.TrimSpace(req.URL.Path)
 	}
@@ -79,7 +87,7 @@ func (s Service) Record(ctx context.Context, actor Actor, action, resourceType,
 	requestID := sanitizeString(pointerOf(req.Header.Get("X-Request-ID")))
 	resID := sanitizeString(pointerOf(resourceID))
 
-	jsonb := toJSONB(metadata, req.URL.RawQuery)
+	jsonb := s.toJSONB(metadata, req.URL.RawQuery)
 
 	finalStatus := status
 	if finalStatus == 0 {
@@ -179,14 +187,18 @@ func toNullText(value *string) pgtype.Text {
 	return pgtype.Text{String: *value, Valid: true}
 }
 
-func toJSONB(metadata []byte, query string) []byte {
+func (s Service) toJSONB(metadata []byte, query string) []byte {
 	if len(metadata) > 0 {
-		return metadata
+		redacted, ok := s.Redactor.JSON(metadata)
+		if !ok {
+			return nil
+		}
+		return redacted
 	}
 	if strings.TrimSpace(query) == "" {
 		return nil
 	}
-	payload := map[string]string{"query": query}
+	payload := map[string]string{"query": s.Redactor.Query(query)}
 	data, err := json.Marshal(payload)
 	if err != nil {
 		return nil
@@ -29,6 +29,10 @@ func (s *stubStore) ListAuditLogs(ctx context.Context, arg dbgen.ListAuditLogsPa
 	return nil, nil
 }
 
+func (s *stubStore) CountAuditLogs(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
 func TestServiceRecord(t *testing.T) {
 	store := &stubStore{}
 	svc := Service{Store: store, Enabled: true, SamplingRate: 1}
@@ -25,7 +25,7 @@ func TestServiceParseAccessTokenSuccess(t *testing.T) {
 	fixed := time.Now()
 	svc.WithNow(func() time.Time { return fixed })
 
-	token, _, err := svc.signAccessToken("user-id")
+	token, _, err := svc.signAccessToken("user-id", "", nil)
 	if err != nil {
 		t.Fatalf("sign access token: %v", err)
 	}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	db "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+// Deactivation describes a closed account and how long it can be recovered.
+type Deactivation struct {
+	DeactivatedAt time.Time `json:"deactivatedAt"`
+	// RecoverableUntil is zero when closed accounts cannot be recovered.
+	RecoverableUntil time.Time `json:"recoverableUntil,omitzero"`
+}
+
+// Deactivate closes the account of userID after confirming its password. The
+// user can no longer log in, every session is revoked, and carts are deleted;
+// orders are kept. Closing an already closed account is a no-op.
+func (s *Service) Deactivate(ctx context.Context, userID, password string) (Deactivation, error) {
+	id, err := pgUUIDFromString(userID)
+	if err != nil {
+		return Deactivation{}, common.NewAppError("UNAUTHORIZED", "unauthorized", httpStatusUnauthorized, nil)
+	}
+	user, err := s.queries.GetUserByID(ctx, id)
+	if err != nil {
+		return Deactivation{}, common.NewAppError("UNAUTHORIZED", "unauthorized", httpStatusUnauthorized, nil)
+	}
+	creds, err := s.queries.GetUserByEmail(ctx, user.Email)
+	if err != nil {
+		return Deactivation{}, err
+	}
+	if ok, _, err := argon2id.CheckHash(password, creds.PasswordHash); err != nil || !ok {
+		return Deactivation{}, common.NewAppError("INVALID_CREDENTIALS", "invalid password", httpStatusUnauthorized, nil)
+	}
+
+	var at pgtype.Timestamptz
+	err = s.inTx(ctx, func(q db.Querier) error {
+		var err error
+		if at, err = q.DeactivateUser(ctx, id); err != nil {
+			return err
+		}
+		if err := q.DeleteSessionsByUser(ctx, id); err != nil {
+			return err
+		}
+		return q.DeleteCartsByUser(ctx, id)
+	})
+	if err != nil {
+		return Deactivation{}, err
+	}
+	return s.deactivation(at), nil
+}
+
+// CheckActive rejects access tokens of accounts closed after the token was
+// issued, which stay cryptographically valid until they expire.
+func (s *Service) CheckActive(ctx context.Context, userID string) error {
+	id, err := pgUUIDFromString(userID)
+	if err != nil {
+		return common.NewAppError("UNAUTHORIZED", "unauthorized", httpStatusUnauthorized, nil)
+	}
+	user, err := s.queries.GetUserByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return common.NewAppError("UNAUTHORIZED", "unauthorized", httpStatusUnauthorized, nil)
+		}
+		return err
+	}
+	if user.DeactivatedAt.Valid {
+		return common.NewAppError(common.CodeAccountDeactivated, "account is deactivated", httpStatusForbidden, nil)
+	}
+	return nil
+}
+
+// inTx runs fn on queries bound to one transaction when a pool is
+// configured, and directly on the service queries otherwise.
+func (s *Service) inTx(ctx context.Context, fn func(db.Querier) error) error {
+	queries, ok := s.queries.(*db.Queries)
+	if s.pool == nil || !ok {
+		return fn(s.queries)
+	}
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+	if err := fn(queries.WithTx(tx)); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Reactivate recovers an account closed within the recovery window and logs
+// it in as Login would.
+func (s *Service) Reactivate(ctx context.Context, email, password, audience, userAgent, ip string) (LoginResult, error) {
+	dbUser, err := s.queries.GetUserByEmail(ctx, strings.TrimSpace(strings.ToLower(email)))
+	if err != nil {
+		return LoginResult{}, common.NewAppError("INVALID_CREDENTIALS", "invalid email or password", httpStatusUnauthorized, nil)
+	}
+	if ok, _, err := argon2id.CheckHash(password, dbUser.PasswordHash); err != nil || !ok {
+		return LoginResult{}, common.NewAppError("INVALID_CREDENTIALS", "invalid email or password", httpStatusUnauthorized, nil)
+	}
+	if dbUser.DeactivatedAt.Valid {
+		if !s.recoverable(dbUser.DeactivatedAt) {
+			return LoginResult{}, common.NewAppError(common.CodeAccountDeactivated, "account can no longer be recovered", httpStatusForbidden, nil)
+		}
+		if err := s.queries.ReactivateUser(ctx, dbUser.ID); err != nil {
+			return LoginResult{}, err
+		}
+	}
+	return s.Login(ctx, email, password, audience, userAgent, ip)
+}
+
+// deactivatedError is returned to a closed account that presents valid
+// credentials, telling it whether it can still be recovered.
+func (s *Service) deactivatedError(at pgtype.Timestamptz) error {
+	err := common.NewAppError(common.CodeAccountDeactivated, "account is deactivated", httpStatusForbidden, nil)
+	if s.recoverable(at) {
+		err.Details = map[string]any{"recoverableUntil": s.deactivation(at).RecoverableUntil}
+	}
+	return err
+}
+
+func (s *Service) recoverable(at pgtype.Timestamptz) bool {
+	return s.recoveryWindow > 0 && at.Valid && s.now().Before(at.Time.Add(s.recoveryWindow))
+}
+
+func (s *Service) deactivation(at pgtype.Timestamptz) Deactivation {
+	out := Deactivation{DeactivatedAt: toTime(at)}
+	if s.recoveryWindow > 0 {
+		out.RecoverableUntil = out.DeactivatedAt.Add(s.recoveryWindow)
+	}
+	return out
+}
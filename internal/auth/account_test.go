@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/google/uuid"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+func newAccountTestService(t *testing.T, window time.Duration) (*Service, *fakeQueries, string) {
+	t.Helper()
+	queries := newFakeQueries()
+	userID := uuid.New()
+	pgID, _ := pgUUIDFromString(userID.String())
+	hash, err := argon2id.CreateHash("password123", argon2id.DefaultParams)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	user := dbgen.User{
+		ID:           pgID,
+		Name:         "Test User",
+		Email:        "user@example.com",
+		PasswordHash: hash,
+		Roles:        []string{"user"},
+		CreatedAt:    pgTimestamp(time.Now()),
+		UpdatedAt:    pgTimestamp(time.Now()),
+	}
+	queries.usersByEmail["user@example.com"] = user
+	queries.usersByID[userID.String()] = user
+
+	svc, err := NewService(Config{
+		Queries:               queries,
+		Secret:                "test-secret",
+		AccountRecoveryWindow: window,
+	})
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+	return svc, queries, userID.String()
+}
+
+func appErrorCode(err error) string {
+	var appErr *common.AppError
+	if errors.As(err, &appErr) {
+		return appErr.Code
+	}
+	return ""
+}
+
+func TestDeactivateBlocksLoginAndRevokesSessions(t *testing.T) {
+	ctx := context.Background()
+	svc, queries, userID := newAccountTestService(t, 30*24*time.Hour)
+
+	login, err := svc.Login(ctx, "user@example.com", "password123", "", "", "")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+
+	if _, err := svc.Deactivate(ctx, userID, "wrong-password"); appErrorCode(err) != "INVALID_CREDENTIALS" {
+		t.Fatalf("expected wrong password to be rejected, got %v", err)
+	}
+	result, err := svc.Deactivate(ctx, userID, "password123")
+	if err != nil {
+		t.Fatalf("deactivate: %v", err)
+	}
+	if result.RecoverableUntil.Sub(result.DeactivatedAt) != 30*24*time.Hour {
+		t.Fatalf("unexpected recovery window: %+v", result)
+	}
+	if len(queries.sessionsByToken) != 0 {
+		t.Fatalf("expected sessions to be revoked, %d left", len(queries.sessionsByToken))
+	}
+
+	_, err = svc.Login(ctx, "user@example.com", "password123", "", "", "")
+	if appErrorCode(err) != common.CodeAccountDeactivated {
+		t.Fatalf("expected %s, got %v", common.CodeAccountDeactivated, err)
+	}
+	var appErr *common.AppError
+	errors.As(err, &appErr)
+	if appErr.HTTPStatus != 403 || appErr.Details == nil {
+		t.Fatalf("expected 403 with recovery details, got %+v", appErr)
+	}
+	// Wrong credentials must not reveal that the account is closed.
+	if _, err := svc.Login(ctx, "user@example.com", "nope", "", "", ""); appErrorCode(err) != "INVALID_CREDENTIALS" {
+		t.Fatalf("expected INVALID_CREDENTIALS, got %v", err)
+	}
+	if _, err := svc.Refresh(ctx, login.RefreshToken); appErrorCode(err) != "UNAUTHORIZED" {
+		t.Fatalf("expected revoked refresh token, got %v", err)
+	}
+}
+
+func TestReactivateWithinRecoveryWindow(t *testing.T) {
+	ctx := context.Background()
+	svc, _, userID := newAccountTestService(t, time.Hour)
+	if _, err := svc.Deactivate(ctx, userID, "password123"); err != nil {
+		t.Fatalf("deactivate: %v", err)
+	}
+
+	svc.WithNow(func() time.Time { return time.Now().Add(2 * time.Hour) })
+	if _, err := svc.Reactivate(ctx, "user@example.com", "password123", "", "", ""); appErrorCode(err) != common.CodeAccountDeactivated {
+		t.Fatalf("expected recovery window to have passed, got %v", err)
+	}
+
+	svc.WithNow(time.Now)
+	result, err := svc.Reactivate(ctx, "user@example.com", "password123", "", "", "")
+	if err != nil {
+		t.Fatalf("reactivate: %v", err)
+	}
+	if result.AccessToken == "" || result.RefreshToken == "" {
+		t.Fatalf("expected tokens after reactivation, got %+v", result)
+	}
+	if _, err := svc.Me(ctx, userID); err != nil {
+		t.Fatalf("expected reactivated account to be usable: %v", err)
+	}
+}
+
+func TestRequireAuthRejectsTokensOfDeactivatedAccount(t *testing.T) {
+	ctx := context.Background()
+	svc, _, userID := newAccountTestService(t, 30*24*time.Hour)
+	login, err := svc.Login(ctx, "user@example.com", "password123", "", "", "")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	handler := Middleware{Service: svc}.RequireAuth(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	call := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/me", nil)
+		req.Header.Set("Authorization", "Bearer "+login.AccessToken)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := call(); code != http.StatusNoContent {
+		t.Fatalf("expected active account to pass, got %d", code)
+	}
+	if _, err := svc.Deactivate(ctx, userID, "password123"); err != nil {
+		t.Fatalf("deactivate: %v", err)
+	}
+	if code := call(); code != http.StatusForbidden {
+		t.Fatalf("expected unexpired token of closed account to be rejected, got %d", code)
+	}
+}
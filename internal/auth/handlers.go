@@ -35,6 +35,16 @@ type registerRequest struct {
 type loginRequest struct {
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	Audience string `json:"audience"`
+}
+
+type twoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+type twoFactorLoginRequest struct {
+	Challenge string `json:"challenge"`
+	Code      string `json:"code"`
 }
 
 type forgotRequest struct {
@@ -46,6 +56,10 @@ type resetRequest struct {
 	NewPassword string `json:"newPassword"`
 }
 
+type deactivateRequest struct {
+	Password string `json:"password"`
+}
+
 type refreshResponse struct {
 	AccessToken string `json:"accessToken"`
 }
@@ -80,11 +94,25 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request payload", nil)
 		return
 	}
-	result, err := h.Service.Login(r.Context(), req.Email, req.Password, r.UserAgent(), common.ClientIP(r))
+	result, err := h.Service.Login(r.Context(), req.Email, req.Password, req.Audience, r.UserAgent(), common.ClientIP(r))
 	if err != nil {
 		h.writeError(w, err)
 		return
 	}
+	if result.TwoFactorRequired {
+		common.JSON(w, http.StatusOK, map[string]any{
+			"data": map[string]any{
+				"twoFactorRequired":  true,
+				"challenge":          result.Challenge,
+				"challengeExpiresAt": result.ChallengeExpiry,
+			},
+		})
+		return
+	}
+	h.writeLogin(w, result)
+}
+
+func (h *Handler) writeLogin(w http.ResponseWriter, result LoginResult) {
 	h.setRefreshCookie(w, result.RefreshToken, result.RefreshExpiry)
 	common.JSON(w, http.StatusOK, map[string]any{
 		"data": map[string]any{
@@ -96,6 +124,62 @@ func (h *Handler) Login(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// Reactivate handles POST /api/v1/auth/reactivate, recovering a closed
+// account within the recovery window and logging it in.
+func (h *Handler) Reactivate(w http.ResponseWriter, r *http.Request) {
+	if h.Service == nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "auth service not configured", nil)
+		return
+	}
+	var req loginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request payload", nil)
+		return
+	}
+	result, err := h.Service.Reactivate(r.Context(), req.Email, req.Password, req.Audience, r.UserAgent(), common.ClientIP(r))
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	if result.TwoFactorRequired {
+		common.JSON(w, http.StatusOK, map[string]any{
+			"data": map[string]any{
+				"twoFactorRequired":  true,
+				"challenge":          result.Challenge,
+				"challengeExpiresAt": result.ChallengeExpiry,
+			},
+		})
+		return
+	}
+	h.writeLogin(w, result)
+}
+
+// Deactivate handles DELETE /api/v1/users/me. The account is closed and
+// the refresh cookie cleared; it can be recovered until the purge runs.
+func (h *Handler) Deactivate(w http.ResponseWriter, r *http.Request) {
+	if h.Service == nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "auth service not configured", nil)
+		return
+	}
+	userID, ok := common.UserID(r.Context())
+	if !ok {
+		common.JSONError(w, http.StatusUnauthorized, "UNAUTHORIZED", "missing or invalid token", nil)
+		return
+	}
+	var req deactivateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request payload", nil)
+		return
+	}
+	result, err := h.Service.Deactivate(r.Context(), userID, req.Password)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.clearRefreshCookie(w)
+	common.JSON(w, http.StatusOK, map[string]any{"data": result})
+}
+
 // Refresh handles POST /api/v1/auth/refresh.
 func (h *Handler) Refresh(w http.ResponseWriter, r *http.Request) {
 	if h.Service == nil {
@@ -148,6 +232,110 @@ func (h *Handler) Me(w http.ResponseWriter, r *http.Request) {
 	common.JSON(w, http.StatusOK, map[string]any{"data": user})
 }
 
+// TwoFactorEnroll handles POST /api/v1/auth/2fa/enroll.
+func (h *Handler) TwoFactorEnroll(w http.ResponseWriter, r *http.Request) {
+	if h.Service == nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "auth service not configured", nil)
+		return
+	}
+	userID, ok := common.UserID(r.Context())
+	if !ok {
+		common.JSONError(w, http.StatusUnauthorized, "UNAUTHORIZED", "missing or invalid token", nil)
+		return
+	}
+	enrollment, err := h.Service.EnrollTwoFactor(r.Context(), userID)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	common.JSON(w, http.StatusOK, map[string]any{"data": enrollment})
+}
+
+// TwoFactorVerify handles POST /api/v1/auth/2fa/verify.
+func (h *Handler) TwoFactorVerify(w http.ResponseWriter, r *http.Request) {
+	if h.Service == nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "auth service not configured", nil)
+		return
+	}
+	userID, ok := common.UserID(r.Context())
+	if !ok {
+		common.JSONError(w, http.StatusUnauthorized, "UNAUTHORIZED", "missing or invalid token", nil)
+		return
+	}
+	var req twoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request payload", nil)
+		return
+	}
+	codes, err := h.Service.ConfirmTwoFactor(r.Context(), userID, req.Code)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	common.JSON(w, http.StatusOK, map[string]any{"data": map[string]any{"enabled": true, "backupCodes": codes}})
+}
+
+// TwoFactorDisable handles POST /api/v1/auth/2fa/disable.
+func (h *Handler) TwoFactorDisable(w http.ResponseWriter, r *http.Request) {
+	if h.Service == nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "auth service not configured", nil)
+		return
+	}
+	userID, ok := common.UserID(r.Context())
+	if !ok {
+		common.JSONError(w, http.StatusUnauthorized, "UNAUTHORIZED", "missing or invalid token", nil)
+		return
+	}
+	var req twoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request payload", nil)
+		return
+	}
+	if err := h.Service.DisableTwoFactor(r.Context(), userID, req.Code); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TwoFactorLoginUser returns the user whose challenge r carries, leaving the
+// body for the handler. Rate limits key on it so rotating client IPs does not
+// buy more guesses against one challenge.
+func (h *Handler) TwoFactorLoginUser(r *http.Request) (string, bool) {
+	if h.Service == nil {
+		return "", false
+	}
+	body, complete := common.PeekBody(r, 8<<10)
+	if !complete {
+		return "", false
+	}
+	var req twoFactorLoginRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return "", false
+	}
+	return h.Service.ChallengeUser(req.Challenge)
+}
+
+// TwoFactorLogin handles POST /api/v1/auth/2fa/login, the second step of a
+// login for users with two-factor enabled.
+func (h *Handler) TwoFactorLogin(w http.ResponseWriter, r *http.Request) {
+	if h.Service == nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "auth service not configured", nil)
+		return
+	}
+	var req twoFactorLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid request payload", nil)
+		return
+	}
+	result, err := h.Service.CompleteTwoFactorLogin(r.Context(), req.Challenge, req.Code, r.UserAgent(), common.ClientIP(r))
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.writeLogin(w, result)
+}
+
 // Forgot handles POST /api/v1/auth/password/forgot.
 func (h *Handler) Forgot(w http.ResponseWriter, r *http.Request) {
 	if h.Service == nil {
@@ -1,41 +1,86 @@
 package auth
 
 import (
+	"io"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/noah-isme/backend-toko/internal/common"
 )
 
 func TestClientIP(t *testing.T) {
+	proxies, err := common.ParseTrustedProxies([]string{"192.0.2.0/24", "10.0.0.5"})
+	if err != nil {
+		t.Fatalf("parse trusted proxies: %v", err)
+	}
+
 	tests := []struct {
 		name       string
+		trusted    bool
 		headers    map[string]string
 		remoteAddr string
 		want       string
 	}{
 		{
 			name:       "forwarded",
-			headers:    map[string]string{"X-Forwarded-For": "203.0.113.1, 70.41.3.18"},
+			trusted:    true,
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.1"},
 			remoteAddr: "192.0.2.1:1234",
 			want:       "203.0.113.1",
 		},
+		{
+			name:       "forwarded through proxy chain",
+			trusted:    true,
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.1, 10.0.0.5"},
+			remoteAddr: "192.0.2.1:1234",
+			want:       "203.0.113.1",
+		},
+		{
+			name:       "spoofed entry before untrusted hop",
+			trusted:    true,
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4, 70.41.3.18"},
+			remoteAddr: "192.0.2.1:1234",
+			want:       "70.41.3.18",
+		},
 		{
 			name:       "real ip",
+			trusted:    true,
 			headers:    map[string]string{"X-Real-IP": "198.51.100.2"},
 			remoteAddr: "192.0.2.1:1234",
 			want:       "198.51.100.2",
 		},
+		{
+			name:       "forwarded from untrusted peer",
+			trusted:    true,
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.1"},
+			remoteAddr: "198.51.100.9:1234",
+			want:       "198.51.100.9",
+		},
+		{
+			name:       "no trusted proxies",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.1", "X-Real-IP": "198.51.100.2"},
+			remoteAddr: "192.0.2.1:1234",
+			want:       "192.0.2.1",
+		},
 		{
 			name:       "remote addr fallback",
+			trusted:    true,
 			headers:    map[string]string{},
 			remoteAddr: "198.51.100.3:8080",
 			want:       "198.51.100.3",
 		},
 	}
 
+	previous := common.TrustedProxies
+	t.Cleanup(func() { common.TrustedProxies = previous })
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
+			common.TrustedProxies = nil
+			if tt.trusted {
+				common.TrustedProxies = proxies
+			}
 			req, _ := http.NewRequest(http.MethodGet, "http://example.com", nil)
 			for k, v := range tt.headers {
 				req.Header.Set(k, v)
@@ -47,3 +92,33 @@ func TestClientIP(t *testing.T) {
 		})
 	}
 }
+
+func TestParseTrustedProxiesRejectsInvalid(t *testing.T) {
+	if _, err := common.ParseTrustedProxies([]string{"not-an-ip"}); err == nil {
+		t.Fatal("expected an error for an invalid proxy")
+	}
+}
+
+func TestTwoFactorLoginUserReadsChallengeAndKeepsBody(t *testing.T) {
+	svc, _, userID := newAccountTestService(t, 0)
+	challenge, _, err := svc.signChallenge(userID, svc.audience)
+	if err != nil {
+		t.Fatalf("sign challenge: %v", err)
+	}
+	h := &Handler{Service: svc}
+
+	body := `{"challenge":"` + challenge + `","code":"123456"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/2fa/login", strings.NewReader(body))
+	got, ok := h.TwoFactorLoginUser(req)
+	if !ok || got != userID {
+		t.Fatalf("got user %q ok=%v, want %q", got, ok, userID)
+	}
+	if rest, _ := io.ReadAll(req.Body); string(rest) != body {
+		t.Fatalf("body consumed: %q", rest)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/auth/2fa/login", strings.NewReader(`{"challenge":"forged","code":"123456"}`))
+	if _, ok := h.TwoFactorLoginUser(req); ok {
+		t.Fatalf("expected forged challenge to be rejected")
+	}
+}
@@ -3,6 +3,7 @@ package auth
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/lestrrat-go/jwx/v2/jwa"
@@ -11,8 +12,11 @@ import (
 
 // TokenValidator validates structural and contextual properties of JWT tokens.
 type TokenValidator struct {
-	Issuer    string
-	Audience  string
+	Issuer   string
+	Audience string
+	// Audiences, when set, replaces Audience with a list of accepted audiences;
+	// a token is valid when its aud claim contains any of them.
+	Audiences []string
 	ClockSkew time.Duration
 	Algorithm jwa.SignatureAlgorithm
 }
@@ -39,9 +43,17 @@ func (v TokenValidator) Validate(tok jwt.Token, algorithm jwa.SignatureAlgorithm
 	if v.Issuer != "" {
 		options = append(options, jwt.WithIssuer(v.Issuer))
 	}
-	if v.Audience != "" {
+	if v.Audience != "" && len(v.Audiences) == 0 {
 		options = append(options, jwt.WithAudience(v.Audience))
 	}
 
-	return jwt.Validate(tok, options...)
+	if err := jwt.Validate(tok, options...); err != nil {
+		return err
+	}
+	if len(v.Audiences) > 0 && !slices.ContainsFunc(tok.Audience(), func(aud string) bool {
+		return slices.Contains(v.Audiences, aud)
+	}) {
+		return errors.New("auth: token audience not accepted")
+	}
+	return nil
 }
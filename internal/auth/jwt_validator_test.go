@@ -92,3 +92,25 @@ func TestTokenValidatorAlgorithmMismatch(t *testing.T) {
 		t.Fatal("expected algorithm mismatch error")
 	}
 }
+
+func TestTokenValidatorAcceptedAudiences(t *testing.T) {
+	now := time.Now()
+	token, _ := jwt.NewBuilder().
+		Issuer("issuer").
+		Audience([]string{"toko-partner"}).
+		Subject("sub").
+		IssuedAt(now).
+		NotBefore(now).
+		Expiration(now.Add(time.Minute)).
+		Build()
+
+	validator := TokenValidator{Issuer: "issuer", Audience: "toko-frontend", Audiences: []string{"toko-frontend", "toko-mobile"}, Algorithm: jwa.HS256}
+	if err := validator.Validate(token, jwa.HS256, now); err == nil {
+		t.Fatal("expected token for another client to be rejected")
+	}
+
+	validator.Audiences = append(validator.Audiences, "toko-partner")
+	if err := validator.Validate(token, jwa.HS256, now); err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/noah-isme/backend-toko/internal/common"
+	"github.com/noah-isme/backend-toko/internal/obs"
 )
 
 var errNoToken = errors.New("auth: token missing")
@@ -66,6 +67,10 @@ func (m Middleware) authenticateRequest(r *http.Request) (context.Context, error
 	if err != nil {
 		return r.Context(), err
 	}
+	if err := m.Service.CheckActive(r.Context(), userID); err != nil {
+		return r.Context(), err
+	}
+	obs.SetAccessLogField(r.Context(), obs.FieldUserID, userID)
 	return common.WithUserID(r.Context(), userID), nil
 }
 
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/google/uuid"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+func TestLoginUpgradesWeakPasswordHash(t *testing.T) {
+	queries := newFakeQueries()
+	userID := uuid.New()
+	pgID, _ := pgUUIDFromString(userID.String())
+	weak := &argon2id.Params{Memory: 8 * 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	hash, err := argon2id.CreateHash("password123", weak)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	user := dbgen.User{ID: pgID, Name: "Old User", Email: "old@example.com", PasswordHash: hash, Roles: []string{"user"}}
+	queries.usersByEmail["old@example.com"] = user
+	queries.usersByID[userID.String()] = user
+
+	target := &argon2id.Params{Memory: 16 * 1024, Iterations: 2, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+	svc, err := NewService(Config{
+		Queries:            queries,
+		Secret:             "test-secret",
+		AccessTokenTTL:     time.Minute,
+		RefreshTokenTTL:    time.Hour,
+		PasswordHashParams: target,
+	})
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+
+	if _, err := svc.Login(context.Background(), "old@example.com", "password123", "", "test", "127.0.0.1"); err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	upgraded := queries.usersByID[userID.String()].PasswordHash
+	if upgraded == hash {
+		t.Fatal("expected weak hash to be replaced after login")
+	}
+	ok, params, err := argon2id.CheckHash("password123", upgraded)
+	if err != nil || !ok {
+		t.Fatalf("upgraded hash does not verify: %v", err)
+	}
+	if params.Memory != target.Memory || params.Iterations != target.Iterations {
+		t.Fatalf("unexpected params after upgrade: %+v", params)
+	}
+
+	// A hash already at the target is left alone.
+	if _, err := svc.Login(context.Background(), "old@example.com", "password123", "", "test", "127.0.0.1"); err != nil {
+		t.Fatalf("second login: %v", err)
+	}
+	if queries.usersByID[userID.String()].PasswordHash != upgraded {
+		t.Fatal("hash at target params should not be rehashed")
+	}
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alexedwards/argon2id"
+	"github.com/google/uuid"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+func TestRoleTTLShortestOverrideWins(t *testing.T) {
+	overrides := map[string]time.Duration{"admin": 5 * time.Minute, "staff": 10 * time.Minute, "customer": time.Hour}
+	cases := []struct {
+		roles []string
+		want  time.Duration
+	}{
+		{nil, 15 * time.Minute},
+		{[]string{"user"}, 15 * time.Minute},
+		{[]string{"customer"}, time.Hour},
+		{[]string{"customer", "staff"}, 10 * time.Minute},
+		{[]string{"staff", "admin", "customer"}, 5 * time.Minute},
+	}
+	for _, tc := range cases {
+		if got := roleTTL(overrides, tc.roles, 15*time.Minute); got != tc.want {
+			t.Fatalf("roles %v: got %s, want %s", tc.roles, got, tc.want)
+		}
+	}
+}
+
+func TestLoginAndRefreshHonorRoleTTL(t *testing.T) {
+	queries := newFakeQueries()
+	userID := uuid.New()
+	pgID, _ := pgUUIDFromString(userID.String())
+	hash, err := argon2id.CreateHash("password123", argon2id.DefaultParams)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	user := dbgen.User{ID: pgID, Name: "Admin", Email: "admin@example.com", PasswordHash: hash, Roles: []string{"customer", "admin"}}
+	queries.usersByEmail["admin@example.com"] = user
+	queries.usersByID[userID.String()] = user
+
+	svc, err := NewService(Config{
+		Queries:               queries,
+		Secret:                "test-secret",
+		AccessTokenTTL:        15 * time.Minute,
+		RefreshTokenTTL:       720 * time.Hour,
+		AccessTokenTTLByRole:  map[string]time.Duration{"admin": 5 * time.Minute},
+		RefreshTokenTTLByRole: map[string]time.Duration{"admin": 8 * time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("new service: %v", err)
+	}
+	now := time.Now().Truncate(time.Second)
+	svc.WithNow(func() time.Time { return now })
+
+	login, err := svc.Login(context.Background(), "admin@example.com", "password123", "", "test", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("login: %v", err)
+	}
+	if !login.AccessExpiry.Equal(now.Add(5*time.Minute)) || !login.RefreshExpiry.Equal(now.Add(8*time.Hour)) {
+		t.Fatalf("unexpected login expiries: %s, %s", login.AccessExpiry, login.RefreshExpiry)
+	}
+
+	now = now.Add(time.Hour)
+	refreshed, err := svc.Refresh(context.Background(), login.RefreshToken)
+	if err != nil {
+		t.Fatalf("refresh: %v", err)
+	}
+	if !refreshed.AccessExpiry.Equal(now.Add(5*time.Minute)) || !refreshed.RefreshExpiry.Equal(now.Add(8*time.Hour)) {
+		t.Fatalf("unexpected refresh expiries: %s, %s", refreshed.AccessExpiry, refreshed.RefreshExpiry)
+	}
+}
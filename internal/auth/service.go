@@ -8,6 +8,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
@@ -15,6 +16,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/lestrrat-go/jwx/v2/jwa"
 	"github.com/lestrrat-go/jwx/v2/jws"
 	"github.com/lestrrat-go/jwx/v2/jwt"
@@ -27,11 +29,15 @@ const (
 	defaultAccessTTL  = 15 * time.Minute
 	defaultRefreshTTL = 24 * time.Hour
 	defaultResetTTL   = 24 * time.Hour
+	// defaultChallengeTTL bounds how long a user has to enter their
+	// two-factor code after a successful password check.
+	defaultChallengeTTL = 5 * time.Minute
 )
 
 // Service coordinates authentication, password management, and session persistence.
 type Service struct {
 	queries    db.Querier
+	pool       *pgxpool.Pool
 	secret     []byte
 	accessTTL  time.Duration
 	refreshTTL time.Duration
@@ -41,7 +47,19 @@ type Service struct {
 	validator  TokenValidator
 	issuer     string
 	audience   string
+	audiences  []string
 	clockSkew  time.Duration
+
+	totpKey      []byte
+	challengeKey []byte
+	challengeTTL time.Duration
+
+	hashParams *argon2id.Params
+
+	accessTTLByRole  map[string]time.Duration
+	refreshTTLByRole map[string]time.Duration
+
+	recoveryWindow time.Duration
 }
 
 // Config configures the auth service.
@@ -52,8 +70,33 @@ type Config struct {
 	RefreshTokenTTL time.Duration
 	ResetTokenTTL   time.Duration
 	Issuer          string
-	Audience        string
-	ClockSkew       time.Duration
+	// Audience is the aud claim for clients that do not request one.
+	Audience string
+	// Audiences registers further client audiences that may be requested at
+	// login, e.g. a mobile app or partner portal.
+	Audiences []string
+	// AcceptedAudiences limits which audiences this API accepts on incoming
+	// access tokens. Empty accepts every registered audience.
+	AcceptedAudiences []string
+	ClockSkew         time.Duration
+	// TOTPEncryptionKey is a base64 AES key (16, 24, or 32 bytes) that
+	// encrypts stored TOTP secrets. Empty derives a key from Secret.
+	TOTPEncryptionKey string
+	// PasswordHashParams are the argon2id parameters for new hashes. Stored
+	// hashes below them are rehashed on the next successful login. Nil uses
+	// argon2id.DefaultParams.
+	PasswordHashParams *argon2id.Params
+	// AccessTokenTTLByRole and RefreshTokenTTLByRole override the global TTLs
+	// for users holding a role. With several matching roles the shortest
+	// override wins; users without a matching role get the global TTL.
+	AccessTokenTTLByRole  map[string]time.Duration
+	RefreshTokenTTLByRole map[string]time.Duration
+	// AccountRecoveryWindow is how long a closed account can be reactivated
+	// with its credentials; zero makes closing final.
+	AccountRecoveryWindow time.Duration
+	// Pool runs multi-step writes such as account deactivation in one
+	// transaction; nil runs them directly on Queries.
+	Pool *pgxpool.Pool
 }
 
 // User represents a safe subset of the user model returned to clients.
@@ -66,13 +109,19 @@ type User struct {
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
-// LoginResult bundles token material returned after a successful login.
+// LoginResult bundles token material returned after a successful login. When
+// TwoFactorRequired is set no tokens are issued; the client must exchange
+// Challenge and a TOTP or backup code via CompleteTwoFactorLogin.
 type LoginResult struct {
 	User          User      `json:"user"`
 	AccessToken   string    `json:"access_token"`
 	RefreshToken  string    `json:"refresh_token"`
 	AccessExpiry  time.Time `json:"access_expires_at"`
 	RefreshExpiry time.Time `json:"refresh_expires_at"`
+
+	TwoFactorRequired bool      `json:"two_factor_required,omitempty"`
+	Challenge         string    `json:"challenge,omitempty"`
+	ChallengeExpiry   time.Time `json:"challenge_expires_at,omitempty"`
 }
 
 // RefreshResult represents the outcome of a refresh operation.
@@ -113,13 +162,43 @@ func NewService(cfg Config) (*Service, error) {
 	if audience == "" {
 		audience = "toko-frontend"
 	}
+	audiences := []string{audience}
+	for _, aud := range cfg.Audiences {
+		if aud = strings.TrimSpace(aud); aud != "" && !slices.Contains(audiences, aud) {
+			audiences = append(audiences, aud)
+		}
+	}
+	accepted := audiences
+	if len(cfg.AcceptedAudiences) > 0 {
+		accepted = nil
+		for _, aud := range cfg.AcceptedAudiences {
+			aud = strings.TrimSpace(aud)
+			if aud == "" {
+				continue
+			}
+			if !slices.Contains(audiences, aud) {
+				return nil, fmt.Errorf("auth: accepted audience %q is not registered", aud)
+			}
+			accepted = append(accepted, aud)
+		}
+	}
 	clockSkew := cfg.ClockSkew
 	if clockSkew < 0 {
 		clockSkew = 0
 	}
+	totpKey, err := totpEncryptionKey(cfg.TOTPEncryptionKey, secret)
+	if err != nil {
+		return nil, err
+	}
+	challengeKey := sha256.Sum256([]byte("2fa-challenge:" + secret))
+	hashParams := argon2id.DefaultParams
+	if cfg.PasswordHashParams != nil {
+		hashParams = cfg.PasswordHashParams
+	}
 
 	return &Service{
 		queries:    cfg.Queries,
+		pool:       cfg.Pool,
 		secret:     []byte(secret),
 		accessTTL:  accessTTL,
 		refreshTTL: refreshTTL,
@@ -129,12 +208,25 @@ func NewService(cfg Config) (*Service, error) {
 		validator: TokenValidator{
 			Issuer:    issuer,
 			Audience:  audience,
+			Audiences: accepted,
 			ClockSkew: clockSkew,
 			Algorithm: jwa.HS256,
 		},
 		issuer:    issuer,
 		audience:  audience,
+		audiences: audiences,
 		clockSkew: clockSkew,
+
+		totpKey:      totpKey,
+		challengeKey: challengeKey[:],
+		challengeTTL: defaultChallengeTTL,
+
+		hashParams: hashParams,
+
+		accessTTLByRole:  cfg.AccessTokenTTLByRole,
+		refreshTTLByRole: cfg.RefreshTokenTTLByRole,
+
+		recoveryWindow: cfg.AccountRecoveryWindow,
 	}, nil
 }
 
@@ -158,7 +250,7 @@ func (s *Service) Register(ctx context.Context, name, email, password string) (U
 		return User{}, common.NewAppError("VALIDATION_ERROR", "password must be at least 8 characters", httpStatusBadRequest, nil)
 	}
 
-	hash, err := argon2id.CreateHash(password, argon2id.DefaultParams)
+	hash, err := argon2id.CreateHash(password, s.hashParams)
 	if err != nil {
 		return User{}, fmt.Errorf("hash password: %w", err)
 	}
@@ -179,40 +271,102 @@ func (s *Service) Register(ctx context.Context, name, email, password string) (U
 	return convertCreateUserRow(created), nil
 }
 
-// Login verifies credentials and issues new JWT/refresh token pair.
-func (s *Service) Login(ctx context.Context, email, password, userAgent, ip string) (LoginResult, error) {
+// Login verifies credentials and issues new JWT/refresh token pair. The
+// access token is minted for audience, which must be registered; empty uses
+// the default audience.
+func (s *Service) Login(ctx context.Context, email, password, audience, userAgent, ip string) (LoginResult, error) {
 	normalizedEmail := strings.TrimSpace(strings.ToLower(email))
 	if normalizedEmail == "" || password == "" {
 		return LoginResult{}, common.NewAppError("INVALID_CREDENTIALS", "invalid email or password", httpStatusUnauthorized, nil)
 	}
+	audience = strings.TrimSpace(audience)
+	if audience == "" {
+		audience = s.audience
+	}
+	if !slices.Contains(s.audiences, audience) {
+		return LoginResult{}, common.NewAppError("VALIDATION_ERROR", "audience is not registered", httpStatusBadRequest, nil)
+	}
 
 	dbUser, err := s.queries.GetUserByEmail(ctx, normalizedEmail)
 	if err != nil {
 		return LoginResult{}, common.NewAppError("INVALID_CREDENTIALS", "invalid email or password", httpStatusUnauthorized, nil)
 	}
 
-	ok, err := argon2id.ComparePasswordAndHash(password, dbUser.PasswordHash)
+	ok, params, err := argon2id.CheckHash(password, dbUser.PasswordHash)
 	if err != nil || !ok {
 		return LoginResult{}, common.NewAppError("INVALID_CREDENTIALS", "invalid email or password", httpStatusUnauthorized, nil)
 	}
+	if dbUser.DeactivatedAt.Valid {
+		return LoginResult{}, s.deactivatedError(dbUser.DeactivatedAt)
+	}
+	if s.needsRehash(params) {
+		s.rehashPassword(ctx, dbUser.ID, password)
+	}
 
 	userID := uuidString(dbUser.ID)
 	if userID == "" {
 		return LoginResult{}, errors.New("auth: invalid user identifier")
 	}
 
-	accessToken, accessExpiry, err := s.signAccessToken(userID)
+	enabled, err := s.twoFactorEnabled(ctx, dbUser.ID)
+	if err != nil {
+		return LoginResult{}, err
+	}
+	if enabled {
+		challenge, expiry, err := s.signChallenge(userID, audience)
+		if err != nil {
+			return LoginResult{}, fmt.Errorf("sign challenge: %w", err)
+		}
+		return LoginResult{
+			User:              convertUserModel(dbUser),
+			TwoFactorRequired: true,
+			Challenge:         challenge,
+			ChallengeExpiry:   expiry,
+		}, nil
+	}
+
+	return s.issueLogin(ctx, convertUserModel(dbUser), dbUser.ID, audience, userAgent, ip)
+}
+
+// needsRehash reports whether a stored hash is cheaper than the target
+// parameters. Parallelism is ignored: it changes how work is split across
+// lanes, not how much work an attacker must do, and DefaultParams derives it
+// from the CPU count, which would otherwise rehash on every host change.
+func (s *Service) needsRehash(params *argon2id.Params) bool {
+	if params == nil {
+		return false
+	}
+	target := s.hashParams
+	return params.Memory < target.Memory ||
+		params.Iterations < target.Iterations ||
+		params.SaltLength < target.SaltLength ||
+		params.KeyLength < target.KeyLength
+}
+
+// rehashPassword upgrades a stored hash to the target parameters. It is best
+// effort: the login already succeeded, and a failure leaves the old hash to be
+// upgraded on a later login.
+func (s *Service) rehashPassword(ctx context.Context, userID pgtype.UUID, password string) {
+	hash, err := argon2id.CreateHash(password, s.hashParams)
+	if err != nil {
+		return
+	}
+	_, _ = s.queries.UpdateUserPassword(ctx, db.UpdateUserPasswordParams{ID: userID, PasswordHash: hash})
+}
+
+func (s *Service) issueLogin(ctx context.Context, user User, userID pgtype.UUID, audience, userAgent, ip string) (LoginResult, error) {
+	accessToken, accessExpiry, err := s.signAccessToken(user.ID, audience, user.Roles)
 	if err != nil {
 		return LoginResult{}, fmt.Errorf("sign access token: %w", err)
 	}
 
-	refreshToken, refreshExpiry, err := s.generateRefreshToken(ctx, dbUser.ID, userAgent, ip)
+	refreshToken, refreshExpiry, err := s.generateRefreshToken(ctx, userID, audience, user.Roles, userAgent, ip)
 	if err != nil {
 		return LoginResult{}, fmt.Errorf("generate refresh token: %w", err)
 	}
 
 	return LoginResult{
-		User:          convertUserModel(dbUser),
+		User:          user,
 		AccessToken:   accessToken,
 		RefreshToken:  refreshToken,
 		AccessExpiry:  accessExpiry,
@@ -252,12 +406,31 @@ func (s *Service) Refresh(ctx context.Context, refreshToken string) (RefreshResu
 		return RefreshResult{}, common.NewAppError("UNAUTHORIZED", "invalid refresh token", httpStatusUnauthorized, nil)
 	}
 
-	accessToken, accessExpiry, err := s.signAccessToken(userID)
+	// Sessions keep the audience they were opened with; one whose audience was
+	// unregistered since can no longer mint tokens.
+	audience := s.audience
+	if session.Audience.Valid {
+		audience = session.Audience.String
+	}
+	if !slices.Contains(s.audiences, audience) {
+		_ = s.queries.DeleteSessionByToken(ctx, hashed)
+		return RefreshResult{}, common.NewAppError("UNAUTHORIZED", "invalid refresh token", httpStatusUnauthorized, nil)
+	}
+
+	// Roles are read again so a promotion or demotion since login takes effect
+	// on the next rotation.
+	user, err := s.queries.GetUserByID(ctx, session.UserID)
+	if err != nil || user.DeactivatedAt.Valid {
+		_ = s.queries.DeleteSessionByToken(ctx, hashed)
+		return RefreshResult{}, common.NewAppError("UNAUTHORIZED", "invalid refresh token", httpStatusUnauthorized, nil)
+	}
+
+	accessToken, accessExpiry, err := s.signAccessToken(userID, audience, user.Roles)
 	if err != nil {
 		return RefreshResult{}, fmt.Errorf("sign access token: %w", err)
 	}
 
-	newRefresh, refreshExpiry, err := s.rotateSessionToken(ctx, session.ID)
+	newRefresh, refreshExpiry, err := s.rotateSessionToken(ctx, session.ID, user.Roles)
 	if err != nil {
 		_ = s.queries.DeleteSessionByToken(ctx, hashed)
 		return RefreshResult{}, fmt.Errorf("rotate session token: %w", err)
@@ -284,6 +457,9 @@ func (s *Service) Me(ctx context.Context, userID string) (User, error) {
 	if err != nil {
 		return User{}, common.NewAppError("UNAUTHORIZED", "unauthorized", httpStatusUnauthorized, nil)
 	}
+	if dbUser.DeactivatedAt.Valid {
+		return User{}, s.deactivatedError(dbUser.DeactivatedAt)
+	}
 	return convertUserFromGet(dbUser), nil
 }
 
@@ -349,7 +525,7 @@ func (s *Service) Reset(ctx context.Context, token, newPassword string) error {
 		return common.NewAppError("INVALID_TOKEN", "invalid or expired token", httpStatusBadRequest, nil)
 	}
 
-	hash, err := argon2id.CreateHash(newPassword, argon2id.DefaultParams)
+	hash, err := argon2id.CreateHash(newPassword, s.hashParams)
 	if err != nil {
 		return fmt.Errorf("hash password: %w", err)
 	}
@@ -427,13 +603,31 @@ func extractTokenAlgorithm(token string) (jwa.SignatureAlgorithm, error) {
 	return algorithm, nil
 }
 
-func (s *Service) signAccessToken(userID string) (string, time.Time, error) {
+// roleTTL returns the shortest override among roles, or fallback when none of
+// the roles has one.
+func roleTTL(overrides map[string]time.Duration, roles []string, fallback time.Duration) time.Duration {
+	ttl := time.Duration(0)
+	for _, role := range roles {
+		if d, ok := overrides[role]; ok && d > 0 && (ttl == 0 || d < ttl) {
+			ttl = d
+		}
+	}
+	if ttl == 0 {
+		return fallback
+	}
+	return ttl
+}
+
+func (s *Service) signAccessToken(userID, audience string, roles []string) (string, time.Time, error) {
+	if audience == "" {
+		audience = s.audience
+	}
 	now := s.now()
-	expiresAt := now.Add(s.accessTTL)
+	expiresAt := now.Add(roleTTL(s.accessTTLByRole, roles, s.accessTTL))
 	builder := jwt.NewBuilder().
 		Subject(userID).
 		Issuer(s.issuer).
-		Audience([]string{s.audience}).
+		Audience([]string{audience}).
 		IssuedAt(now).
 		NotBefore(now.Add(-s.clockSkew)).
 		Expiration(expiresAt)
@@ -448,11 +642,11 @@ func (s *Service) signAccessToken(userID string) (string, time.Time, error) {
 	return string(signed), expiresAt, nil
 }
 
-func (s *Service) generateRefreshToken(ctx context.Context, userID pgtype.UUID, userAgent, ip string) (string, time.Time, error) {
+func (s *Service) generateRefreshToken(ctx context.Context, userID pgtype.UUID, audience string, roles []string, userAgent, ip string) (string, time.Time, error) {
 	if !userID.Valid {
 		return "", time.Time{}, errors.New("auth: invalid user identifier")
 	}
-	token, hashed, expiresAt, err := s.newRefreshToken()
+	token, hashed, expiresAt, err := s.newRefreshToken(roles)
 	if err != nil {
 		return "", time.Time{}, err
 	}
@@ -462,23 +656,24 @@ func (s *Service) generateRefreshToken(ctx context.Context, userID pgtype.UUID,
 		UserAgent:    pgText(userAgent),
 		Ip:           pgText(ip),
 		ExpiresAt:    pgTimestamp(expiresAt),
+		Audience:     pgText(audience),
 	}); err != nil {
 		return "", time.Time{}, err
 	}
 	return token, expiresAt, nil
 }
 
-func (s *Service) newRefreshToken() (string, string, time.Time, error) {
+func (s *Service) newRefreshToken(roles []string) (string, string, time.Time, error) {
 	token, err := generateToken(48)
 	if err != nil {
 		return "", "", time.Time{}, err
 	}
-	expiresAt := s.now().Add(s.refreshTTL)
+	expiresAt := s.now().Add(roleTTL(s.refreshTTLByRole, roles, s.refreshTTL))
 	return token, hashRefreshToken(token), expiresAt, nil
 }
 
-func (s *Service) rotateSessionToken(ctx context.Context, sessionID pgtype.UUID) (string, time.Time, error) {
-	token, hashed, expiresAt, err := s.newRefreshToken()
+func (s *Service) rotateSessionToken(ctx context.Context, sessionID pgtype.UUID, roles []string) (string, time.Time, error) {
+	token, hashed, expiresAt, err := s.newRefreshToken(roles)
 	if err != nil {
 		return "", time.Time{}, err
 	}
@@ -579,4 +774,5 @@ func toTime(ts pgtype.Timestamptz) time.Time {
 
 const httpStatusBadRequest = 400
 const httpStatusUnauthorized = 401
+const httpStatusForbidden = 403
 const httpStatusConflict = 409
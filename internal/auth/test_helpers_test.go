@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 
 	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
@@ -196,15 +197,45 @@ func (f *fakeQueries) GetUserByID(ctx context.Context, id pgtype.UUID) (dbgen.Ge
 		return dbgen.GetUserByIDRow{}, fmt.Errorf("user not found")
 	}
 	return dbgen.GetUserByIDRow{
-		ID:        user.ID,
-		Name:      user.Name,
-		Email:     user.Email,
-		Roles:     user.Roles,
-		CreatedAt: user.CreatedAt,
-		UpdatedAt: user.UpdatedAt,
+		ID:            user.ID,
+		Name:          user.Name,
+		Email:         user.Email,
+		Roles:         user.Roles,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+		DeactivatedAt: user.DeactivatedAt,
 	}, nil
 }
 
+func (f *fakeQueries) DeactivateUser(ctx context.Context, id pgtype.UUID) (pgtype.Timestamptz, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	user, ok := f.usersByID[uuidString(id)]
+	if !ok {
+		return pgtype.Timestamptz{}, pgx.ErrNoRows
+	}
+	if !user.DeactivatedAt.Valid {
+		user.DeactivatedAt = pgTimestamp(time.Now())
+	}
+	f.usersByID[uuidString(id)] = user
+	f.usersByEmail[strings.ToLower(user.Email)] = user
+	return user.DeactivatedAt, nil
+}
+
+func (f *fakeQueries) ReactivateUser(ctx context.Context, id pgtype.UUID) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	user := f.usersByID[uuidString(id)]
+	user.DeactivatedAt = pgtype.Timestamptz{}
+	f.usersByID[uuidString(id)] = user
+	f.usersByEmail[strings.ToLower(user.Email)] = user
+	return nil
+}
+
+func (f *fakeQueries) DeleteCartsByUser(context.Context, pgtype.UUID) error {
+	return nil
+}
+
 func (f *fakeQueries) GetOrderByID(context.Context, pgtype.UUID) (dbgen.Order, error) {
 	return dbgen.Order{}, errNotImplemented
 }
@@ -213,10 +244,6 @@ func (f *fakeQueries) GetDeliveryByID(context.Context, pgtype.UUID) (dbgen.Webho
 	return dbgen.WebhookDelivery{}, errNotImplemented
 }
 
-func (f *fakeQueries) GetDomainEvent(context.Context, pgtype.UUID) (dbgen.DomainEvent, error) {
-	return dbgen.DomainEvent{}, errNotImplemented
-}
-
 func (f *fakeQueries) UpdateUserPassword(ctx context.Context, arg dbgen.UpdateUserPasswordParams) (dbgen.UpdateUserPasswordRow, error) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
@@ -252,6 +279,7 @@ func (f *fakeQueries) CreateSession(ctx context.Context, arg dbgen.CreateSession
 		Ip:           arg.Ip,
 		ExpiresAt:    arg.ExpiresAt,
 		CreatedAt:    pgTimestamp(time.Now()),
+		Audience:     arg.Audience,
 	}
 	f.sessionsByToken[arg.RefreshToken] = session
 	f.sessionsByID[id.String()] = session
@@ -414,14 +442,6 @@ func (f *fakeQueries) CreateOrderItem(context.Context, dbgen.CreateOrderItemPara
 	return errNotImplemented
 }
 
-func (f *fakeQueries) CreatePayment(context.Context, dbgen.CreatePaymentParams) (dbgen.Payment, error) {
-	return dbgen.Payment{}, errNotImplemented
-}
-
-func (f *fakeQueries) GetLatestPaymentByOrder(context.Context, pgtype.UUID) (dbgen.Payment, error) {
-	return dbgen.Payment{}, errNotImplemented
-}
-
 func (f *fakeQueries) UpdatePaymentStatus(context.Context, dbgen.UpdatePaymentStatusParams) error {
 	return errNotImplemented
 }
@@ -474,6 +494,14 @@ func (f *fakeQueries) GetProductForCart(context.Context, pgtype.UUID) (dbgen.Get
 	return dbgen.GetProductForCartRow{}, errNotImplemented
 }
 
+func (f *fakeQueries) ListCartItemAvailability(context.Context, pgtype.UUID) ([]dbgen.ListCartItemAvailabilityRow, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) ListProductScopesByIDs(context.Context, []pgtype.UUID) ([]dbgen.ListProductScopesByIDsRow, error) {
+	return nil, errNotImplemented
+}
+
 func (f *fakeQueries) GetVariantForCart(context.Context, pgtype.UUID) (dbgen.GetVariantForCartRow, error) {
 	return dbgen.GetVariantForCartRow{}, errNotImplemented
 }
@@ -522,6 +550,10 @@ func (f *fakeQueries) TouchCart(context.Context, dbgen.TouchCartParams) error {
 	return errNotImplemented
 }
 
+func (f *fakeQueries) MarkCartChanged(context.Context, dbgen.MarkCartChangedParams) error {
+	return errNotImplemented
+}
+
 func (f *fakeQueries) TransferCartToUser(context.Context, dbgen.TransferCartToUserParams) error {
 	return errNotImplemented
 }
@@ -530,22 +562,14 @@ func (f *fakeQueries) UpdateCartItemQty(context.Context, dbgen.UpdateCartItemQty
 	return dbgen.CartItem{}, errNotImplemented
 }
 
-func (f *fakeQueries) UpdateCartVoucher(context.Context, dbgen.UpdateCartVoucherParams) error {
-	return errNotImplemented
+func (f *fakeQueries) UpdateCartVoucher(context.Context, dbgen.UpdateCartVoucherParams) (int64, error) {
+	return 0, errNotImplemented
 }
 
 func (f *fakeQueries) UpdateOrderStatus(context.Context, dbgen.UpdateOrderStatusParams) error {
 	return errNotImplemented
 }
 
-func (f *fakeQueries) CreateShipment(context.Context, dbgen.CreateShipmentParams) (dbgen.Shipment, error) {
-	return dbgen.Shipment{}, errNotImplemented
-}
-
-func (f *fakeQueries) GetShipmentByOrder(context.Context, pgtype.UUID) (dbgen.Shipment, error) {
-	return dbgen.Shipment{}, errNotImplemented
-}
-
 func (f *fakeQueries) InsertShipmentEvent(context.Context, dbgen.InsertShipmentEventParams) (dbgen.ShipmentEvent, error) {
 	return dbgen.ShipmentEvent{}, errNotImplemented
 }
@@ -582,10 +606,6 @@ func (f *fakeQueries) RefreshTopProducts(context.Context) error {
 	return errNotImplemented
 }
 
-func (f *fakeQueries) InsertDomainEvent(context.Context, dbgen.InsertDomainEventParams) (dbgen.DomainEvent, error) {
-	return dbgen.DomainEvent{}, errNotImplemented
-}
-
 func (f *fakeQueries) InsertWebhookDlq(context.Context, dbgen.InsertWebhookDlqParams) (dbgen.WebhookDlq, error) {
 	return dbgen.WebhookDlq{}, errNotImplemented
 }
@@ -594,10 +614,6 @@ func (f *fakeQueries) ListActiveEndpointsForTopic(context.Context, string) ([]db
 	return nil, errNotImplemented
 }
 
-func (f *fakeQueries) ListDomainEventsByTopic(context.Context, dbgen.ListDomainEventsByTopicParams) ([]dbgen.DomainEvent, error) {
-	return nil, errNotImplemented
-}
-
 func (f *fakeQueries) ListWebhookEndpoints(context.Context, dbgen.ListWebhookEndpointsParams) ([]dbgen.WebhookEndpoint, error) {
 	return nil, errNotImplemented
 }
@@ -637,3 +653,407 @@ func (f *fakeQueries) EnqueueDelivery(context.Context, dbgen.EnqueueDeliveryPara
 func (f *fakeQueries) GetWebhookEndpoint(context.Context, pgtype.UUID) (dbgen.WebhookEndpoint, error) {
 	return dbgen.WebhookEndpoint{}, errNotImplemented
 }
+
+func (f *fakeQueries) ListBundleComponentsByVariantIDs(context.Context, []pgtype.UUID) ([]dbgen.ListBundleComponentsByVariantIDsRow, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) UpsertVariantBundle(context.Context, dbgen.UpsertVariantBundleParams) error {
+	return errNotImplemented
+}
+
+func (f *fakeQueries) UpsertBundleComponents(context.Context, dbgen.UpsertBundleComponentsParams) error {
+	return errNotImplemented
+}
+
+func (f *fakeQueries) DeleteBundleComponentsExcept(context.Context, dbgen.DeleteBundleComponentsExceptParams) error {
+	return errNotImplemented
+}
+
+func (f *fakeQueries) DeleteVariantBundle(context.Context, pgtype.UUID) error {
+	return errNotImplemented
+}
+
+func (f *fakeQueries) CountBundlesUsingComponent(context.Context, pgtype.UUID) (int64, error) {
+	return 0, errNotImplemented
+}
+
+func (f *fakeQueries) InsertDeliveryAttempt(context.Context, dbgen.InsertDeliveryAttemptParams) error {
+	return errNotImplemented
+}
+
+func (f *fakeQueries) PruneDeliveryAttempts(context.Context, dbgen.PruneDeliveryAttemptsParams) error {
+	return errNotImplemented
+}
+
+func (f *fakeQueries) ListDeliveryAttempts(context.Context, pgtype.UUID) ([]dbgen.WebhookDeliveryAttempt, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) GetUserTOTP(context.Context, pgtype.UUID) (dbgen.UserTotp, error) {
+	return dbgen.UserTotp{}, pgx.ErrNoRows
+}
+
+func (f *fakeQueries) UpsertPendingUserTOTP(context.Context, dbgen.UpsertPendingUserTOTPParams) (int64, error) {
+	return 0, errNotImplemented
+}
+
+func (f *fakeQueries) EnableUserTOTP(context.Context, dbgen.EnableUserTOTPParams) (int64, error) {
+	return 0, errNotImplemented
+}
+
+func (f *fakeQueries) AdvanceUserTOTPStep(context.Context, dbgen.AdvanceUserTOTPStepParams) (int64, error) {
+	return 0, errNotImplemented
+}
+
+func (f *fakeQueries) DeleteUserTOTP(context.Context, pgtype.UUID) error {
+	return errNotImplemented
+}
+
+func (f *fakeQueries) InsertBackupCodes(context.Context, dbgen.InsertBackupCodesParams) error {
+	return errNotImplemented
+}
+
+func (f *fakeQueries) UseBackupCode(context.Context, dbgen.UseBackupCodeParams) (int64, error) {
+	return 0, errNotImplemented
+}
+
+func (f *fakeQueries) DeleteBackupCodes(context.Context, pgtype.UUID) error {
+	return errNotImplemented
+}
+
+func (f *fakeQueries) AddFavorite(context.Context, dbgen.AddFavoriteParams) error {
+	return errNotImplemented
+}
+
+func (f *fakeQueries) AnonymizeOrdersByUser(context.Context, dbgen.AnonymizeOrdersByUserParams) (int64, error) {
+	return 0, errNotImplemented
+}
+
+func (f *fakeQueries) AnonymizeVoucherUsagesByUser(context.Context, dbgen.AnonymizeVoucherUsagesByUserParams) error {
+	return errNotImplemented
+}
+
+func (f *fakeQueries) CheckFavorite(context.Context, dbgen.CheckFavoriteParams) (int32, error) {
+	return 0, errNotImplemented
+}
+
+func (f *fakeQueries) CheckUserReview(context.Context, dbgen.CheckUserReviewParams) (pgtype.UUID, error) {
+	return pgtype.UUID{}, errNotImplemented
+}
+
+func (f *fakeQueries) ClaimInboundWebhook(context.Context, dbgen.ClaimInboundWebhookParams) (pgtype.Timestamptz, error) {
+	return pgtype.Timestamptz{}, errNotImplemented
+}
+
+func (f *fakeQueries) CompleteInboundWebhook(context.Context, dbgen.CompleteInboundWebhookParams) error {
+	return errNotImplemented
+}
+
+func (f *fakeQueries) CountAuditLogs(context.Context) (int64, error) {
+	return 0, errNotImplemented
+}
+
+func (f *fakeQueries) CountDomainEventsByTopic(context.Context, string) (int64, error) {
+	return 0, errNotImplemented
+}
+
+func (f *fakeQueries) CountOpenOrdersByUser(context.Context, pgtype.UUID) (int64, error) {
+	return 0, errNotImplemented
+}
+
+func (f *fakeQueries) CountOrdersAdmin(context.Context, pgtype.Text) (int64, error) {
+	return 0, errNotImplemented
+}
+
+func (f *fakeQueries) CountPriceHistoryByProduct(context.Context, dbgen.CountPriceHistoryByProductParams) (int64, error) {
+	return 0, errNotImplemented
+}
+
+func (f *fakeQueries) CountWebhookEndpoints(context.Context) (int64, error) {
+	return 0, errNotImplemented
+}
+
+func (f *fakeQueries) CreateCartItems(context.Context, []dbgen.CreateCartItemsParams) *dbgen.CreateCartItemsBatchResults {
+	return nil
+}
+
+func (f *fakeQueries) CreateOrderItems(context.Context, []dbgen.CreateOrderItemsParams) *dbgen.CreateOrderItemsBatchResults {
+	return nil
+}
+
+func (f *fakeQueries) CreatePayment(context.Context, dbgen.CreatePaymentParams) (dbgen.CreatePaymentRow, error) {
+	return dbgen.CreatePaymentRow{}, errNotImplemented
+}
+
+func (f *fakeQueries) CreateProductImage(context.Context, dbgen.CreateProductImageParams) (dbgen.CreateProductImageRow, error) {
+	return dbgen.CreateProductImageRow{}, errNotImplemented
+}
+
+func (f *fakeQueries) CreateProductVariant(context.Context, dbgen.CreateProductVariantParams) (dbgen.ProductVariant, error) {
+	return dbgen.ProductVariant{}, errNotImplemented
+}
+
+func (f *fakeQueries) CreateReview(context.Context, dbgen.CreateReviewParams) (dbgen.Review, error) {
+	return dbgen.Review{}, errNotImplemented
+}
+
+func (f *fakeQueries) CreateShipment(context.Context, dbgen.CreateShipmentParams) (dbgen.CreateShipmentRow, error) {
+	return dbgen.CreateShipmentRow{}, errNotImplemented
+}
+
+func (f *fakeQueries) CreateVoucherBatch(context.Context, dbgen.CreateVoucherBatchParams) ([]string, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) DeferDelivery(context.Context, dbgen.DeferDeliveryParams) error {
+	return errNotImplemented
+}
+
+func (f *fakeQueries) DeleteReview(context.Context, dbgen.DeleteReviewParams) error {
+	return errNotImplemented
+}
+
+func (f *fakeQueries) DeleteTenantSetting(context.Context, dbgen.DeleteTenantSettingParams) (int64, error) {
+	return 0, errNotImplemented
+}
+
+func (f *fakeQueries) DeleteUser(context.Context, pgtype.UUID) error {
+	return errNotImplemented
+}
+
+func (f *fakeQueries) DisableWebhookEndpoint(context.Context, dbgen.DisableWebhookEndpointParams) (dbgen.WebhookEndpoint, error) {
+	return dbgen.WebhookEndpoint{}, errNotImplemented
+}
+
+func (f *fakeQueries) EnableWebhookEndpoint(context.Context, pgtype.UUID) (dbgen.WebhookEndpoint, error) {
+	return dbgen.WebhookEndpoint{}, errNotImplemented
+}
+
+func (f *fakeQueries) GetAnalyticsViewRefreshedAt(context.Context, string) (pgtype.Timestamptz, error) {
+	return pgtype.Timestamptz{}, errNotImplemented
+}
+
+func (f *fakeQueries) GetCartItemTotals(context.Context, pgtype.UUID) (dbgen.GetCartItemTotalsRow, error) {
+	return dbgen.GetCartItemTotalsRow{}, errNotImplemented
+}
+
+func (f *fakeQueries) GetCategoryDefaultSort(context.Context, string) (string, error) {
+	return "", errNotImplemented
+}
+
+func (f *fakeQueries) GetDomainEvent(context.Context, pgtype.UUID) (dbgen.GetDomainEventRow, error) {
+	return dbgen.GetDomainEventRow{}, errNotImplemented
+}
+
+func (f *fakeQueries) GetInboundWebhookProcessedAt(context.Context, dbgen.GetInboundWebhookProcessedAtParams) (pgtype.Timestamptz, error) {
+	return pgtype.Timestamptz{}, errNotImplemented
+}
+
+func (f *fakeQueries) GetLatestPaymentByOrder(context.Context, pgtype.UUID) (dbgen.GetLatestPaymentByOrderRow, error) {
+	return dbgen.GetLatestPaymentByOrderRow{}, errNotImplemented
+}
+
+func (f *fakeQueries) GetOrderByTenant(context.Context, dbgen.GetOrderByTenantParams) (dbgen.GetOrderByTenantRow, error) {
+	return dbgen.GetOrderByTenantRow{}, errNotImplemented
+}
+
+func (f *fakeQueries) GetPendingPredecessors(context.Context, dbgen.GetPendingPredecessorsParams) (dbgen.GetPendingPredecessorsRow, error) {
+	return dbgen.GetPendingPredecessorsRow{}, errNotImplemented
+}
+
+func (f *fakeQueries) GetProductDetailByTenant(context.Context, dbgen.GetProductDetailByTenantParams) (dbgen.GetProductDetailByTenantRow, error) {
+	return dbgen.GetProductDetailByTenantRow{}, errNotImplemented
+}
+
+func (f *fakeQueries) GetProductOptionSchema(context.Context, pgtype.UUID) (dbgen.GetProductOptionSchemaRow, error) {
+	return dbgen.GetProductOptionSchemaRow{}, errNotImplemented
+}
+
+func (f *fakeQueries) GetProductReviews(context.Context, dbgen.GetProductReviewsParams) ([]dbgen.Review, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) GetReviewStats(context.Context, dbgen.GetReviewStatsParams) (dbgen.GetReviewStatsRow, error) {
+	return dbgen.GetReviewStatsRow{}, errNotImplemented
+}
+
+func (f *fakeQueries) GetShipmentByOrder(context.Context, pgtype.UUID) (dbgen.GetShipmentByOrderRow, error) {
+	return dbgen.GetShipmentByOrderRow{}, errNotImplemented
+}
+
+func (f *fakeQueries) GetTenantSetting(context.Context, dbgen.GetTenantSettingParams) ([]byte, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) GetVoucherByTenant(context.Context, dbgen.GetVoucherByTenantParams) (dbgen.GetVoucherByTenantRow, error) {
+	return dbgen.GetVoucherByTenantRow{}, errNotImplemented
+}
+
+func (f *fakeQueries) GetVoucherPerformance(context.Context, dbgen.GetVoucherPerformanceParams) ([]dbgen.GetVoucherPerformanceRow, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) InsertDomainEvent(context.Context, dbgen.InsertDomainEventParams) (dbgen.InsertDomainEventRow, error) {
+	return dbgen.InsertDomainEventRow{}, errNotImplemented
+}
+
+func (f *fakeQueries) ListAuditLogsByActorAfter(context.Context, dbgen.ListAuditLogsByActorAfterParams) ([]dbgen.ListAuditLogsByActorAfterRow, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) ListCartItemCatalogPrices(context.Context, pgtype.UUID) ([]dbgen.ListCartItemCatalogPricesRow, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) ListCartItemParcels(context.Context, pgtype.UUID) ([]dbgen.ListCartItemParcelsRow, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) ListDomainEventsByTopic(context.Context, dbgen.ListDomainEventsByTopicParams) ([]dbgen.ListDomainEventsByTopicRow, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) ListFavorites(context.Context, dbgen.ListFavoritesParams) ([]dbgen.ListFavoritesRow, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) ListOrderItemsByOrders(context.Context, []pgtype.UUID) ([]dbgen.OrderItem, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) ListOrdersAdmin(context.Context, dbgen.ListOrdersAdminParams) ([]dbgen.Order, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) ListOrdersByTenant(context.Context, dbgen.ListOrdersByTenantParams) ([]dbgen.ListOrdersByTenantRow, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) ListOrdersForExportAfter(context.Context, dbgen.ListOrdersForExportAfterParams) ([]dbgen.Order, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) ListOrdersForUserAfter(context.Context, dbgen.ListOrdersForUserAfterParams) ([]dbgen.Order, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) ListPreviousProductPrices(context.Context, dbgen.ListPreviousProductPricesParams) ([]dbgen.ListPreviousProductPricesRow, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) ListPriceHistoryByProduct(context.Context, dbgen.ListPriceHistoryByProductParams) ([]dbgen.PriceHistory, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) ListProductPriceTrend(context.Context, dbgen.ListProductPriceTrendParams) ([]dbgen.ListProductPriceTrendRow, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) ListProductTranslations(context.Context, dbgen.ListProductTranslationsParams) ([]dbgen.ListProductTranslationsRow, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) ListProductsByTenant(context.Context, dbgen.ListProductsByTenantParams) ([]dbgen.ListProductsByTenantRow, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) ListRecommendationCandidates(context.Context, dbgen.ListRecommendationCandidatesParams) ([]dbgen.ListRecommendationCandidatesRow, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) ListReviewsByUser(context.Context, pgtype.UUID) ([]dbgen.ListReviewsByUserRow, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) ListTopProductSlugs(context.Context, int32) ([]string, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) ListUserEmailPreferences(context.Context, pgtype.UUID) ([]dbgen.UserEmailPreference, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) ListUsersDueForPurge(context.Context, dbgen.ListUsersDueForPurgeParams) ([]pgtype.UUID, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) LockUserForPurge(context.Context, pgtype.UUID) (pgtype.UUID, error) {
+	return pgtype.UUID{}, errNotImplemented
+}
+
+func (f *fakeQueries) MarkAnalyticsViewRefreshed(context.Context, dbgen.MarkAnalyticsViewRefreshedParams) error {
+	return errNotImplemented
+}
+
+func (f *fakeQueries) PurgeDeliveryAttempts(context.Context, dbgen.PurgeDeliveryAttemptsParams) (int64, error) {
+	return 0, errNotImplemented
+}
+
+func (f *fakeQueries) PurgeDomainEvents(context.Context, dbgen.PurgeDomainEventsParams) (int64, error) {
+	return 0, errNotImplemented
+}
+
+func (f *fakeQueries) PurgeInboundWebhookEvents(context.Context, dbgen.PurgeInboundWebhookEventsParams) (int64, error) {
+	return 0, errNotImplemented
+}
+
+func (f *fakeQueries) PurgeWebhookDeliveries(context.Context, dbgen.PurgeWebhookDeliveriesParams) (int64, error) {
+	return 0, errNotImplemented
+}
+
+func (f *fakeQueries) RecordEndpointFailure(context.Context, pgtype.UUID) (int32, error) {
+	return 0, errNotImplemented
+}
+
+func (f *fakeQueries) RefreshProductCopurchase(context.Context) error {
+	return errNotImplemented
+}
+
+func (f *fakeQueries) ReleaseInboundWebhook(context.Context, dbgen.ReleaseInboundWebhookParams) error {
+	return errNotImplemented
+}
+
+func (f *fakeQueries) ReleaseVoucherUsageByOrder(context.Context, pgtype.UUID) (int64, error) {
+	return 0, errNotImplemented
+}
+
+func (f *fakeQueries) RemoveFavorite(context.Context, dbgen.RemoveFavoriteParams) error {
+	return errNotImplemented
+}
+
+func (f *fakeQueries) RepriceCartItem(context.Context, dbgen.RepriceCartItemParams) error {
+	return errNotImplemented
+}
+
+func (f *fakeQueries) ResetEndpointFailures(context.Context, pgtype.UUID) error {
+	return errNotImplemented
+}
+
+func (f *fakeQueries) SetProductDefaultVariant(context.Context, dbgen.SetProductDefaultVariantParams) error {
+	return errNotImplemented
+}
+
+func (f *fakeQueries) UpdateCartItemsQty(context.Context, []dbgen.UpdateCartItemsQtyParams) *dbgen.UpdateCartItemsQtyBatchResults {
+	return nil
+}
+
+func (f *fakeQueries) UpdateProductAvailability(context.Context, dbgen.UpdateProductAvailabilityParams) (string, error) {
+	return "", errNotImplemented
+}
+
+func (f *fakeQueries) UpdateProductOptionSchema(context.Context, dbgen.UpdateProductOptionSchemaParams) error {
+	return errNotImplemented
+}
+
+func (f *fakeQueries) UpdateProductVariant(context.Context, dbgen.UpdateProductVariantParams) (dbgen.ProductVariant, error) {
+	return dbgen.ProductVariant{}, errNotImplemented
+}
+
+func (f *fakeQueries) UpsertTenantSetting(context.Context, dbgen.UpsertTenantSettingParams) ([]byte, error) {
+	return nil, errNotImplemented
+}
+
+func (f *fakeQueries) UpsertUserEmailPreference(context.Context, dbgen.UpsertUserEmailPreferenceParams) error {
+	return errNotImplemented
+}
@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTOTPMatchesRFC6238Vectors(t *testing.T) {
+	// RFC 6238 appendix B SHA-1 secret "12345678901234567890", truncated to six digits.
+	const secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	cases := map[int64]string{
+		59:         "287082",
+		1111111109: "081804",
+		1234567890: "005924",
+		2000000000: "279037",
+	}
+	for unix, want := range cases {
+		now := time.Unix(unix, 0)
+		got, err := totpCode(secret, totpStep(now))
+		if err != nil {
+			t.Fatalf("totp code: %v", err)
+		}
+		if got != want {
+			t.Fatalf("at %d: got %s, want %s", unix, got, want)
+		}
+		if step, ok := matchTOTP(secret, want, now.Add(totpPeriod*time.Second)); !ok || step != totpStep(now) {
+			t.Fatalf("at %d: code from the previous step should match within skew", unix)
+		}
+		if _, ok := matchTOTP(secret, want, now.Add(3*totpPeriod*time.Second)); ok {
+			t.Fatalf("at %d: code outside the skew window should not match", unix)
+		}
+	}
+}
+
+func TestTOTPSecretEncryptionRoundTrip(t *testing.T) {
+	key := make([]byte, 32)
+	sealed, err := encryptSecret(key, "JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatalf("encrypt: %v", err)
+	}
+	if string(sealed) == "JBSWY3DPEHPK3PXP" {
+		t.Fatal("secret stored in plain text")
+	}
+	plain, err := decryptSecret(key, sealed)
+	if err != nil || plain != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("decrypt: %q, %v", plain, err)
+	}
+	key[0] = 1
+	if _, err := decryptSecret(key, sealed); err == nil {
+		t.Fatal("expected decrypt with a different key to fail")
+	}
+}
+
+func TestBackupCodesNormalize(t *testing.T) {
+	codes, err := newBackupCodes()
+	if err != nil {
+		t.Fatalf("backup codes: %v", err)
+	}
+	if len(codes) != backupCodeCount {
+		t.Fatalf("got %d codes", len(codes))
+	}
+	if normalizeBackupCode(" abcde-fghij ") != "ABCDEFGHIJ" {
+		t.Fatal("backup codes should ignore case and separators")
+	}
+}
@@ -0,0 +1,286 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	db "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+const challengePurpose = "2fa"
+
+// TwoFactorEnrollment is returned when a user starts TOTP enrollment. The
+// secret is shown once so it can be typed in when the QR code cannot be
+// scanned.
+type TwoFactorEnrollment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauthUri"`
+}
+
+// EnrollTwoFactor generates a new TOTP secret for the user. Two-factor stays
+// disabled until ConfirmTwoFactor receives a valid code; enrolling again
+// before then replaces the pending secret.
+func (s *Service) EnrollTwoFactor(ctx context.Context, userID string) (TwoFactorEnrollment, error) {
+	id, err := pgUUIDFromString(userID)
+	if err != nil {
+		return TwoFactorEnrollment{}, common.NewAppError("UNAUTHORIZED", "unauthorized", httpStatusUnauthorized, nil)
+	}
+	user, err := s.queries.GetUserByID(ctx, id)
+	if err != nil {
+		return TwoFactorEnrollment{}, common.NewAppError("UNAUTHORIZED", "unauthorized", httpStatusUnauthorized, nil)
+	}
+	secret, err := newTOTPSecret()
+	if err != nil {
+		return TwoFactorEnrollment{}, fmt.Errorf("generate totp secret: %w", err)
+	}
+	sealed, err := encryptSecret(s.totpKey, secret)
+	if err != nil {
+		return TwoFactorEnrollment{}, fmt.Errorf("encrypt totp secret: %w", err)
+	}
+	rows, err := s.queries.UpsertPendingUserTOTP(ctx, db.UpsertPendingUserTOTPParams{UserID: id, Secret: sealed})
+	if err != nil {
+		return TwoFactorEnrollment{}, fmt.Errorf("store totp secret: %w", err)
+	}
+	if rows == 0 {
+		return TwoFactorEnrollment{}, common.NewAppError("CONFLICT", "two-factor authentication is already enabled", httpStatusConflict, nil)
+	}
+	return TwoFactorEnrollment{Secret: secret, OTPAuthURI: totpURI(s.issuer, user.Email, secret)}, nil
+}
+
+// ConfirmTwoFactor enables two-factor authentication once the user proves
+// their authenticator produces valid codes, and returns fresh backup codes.
+// The plain codes are never stored and cannot be shown again.
+func (s *Service) ConfirmTwoFactor(ctx context.Context, userID, code string) ([]string, error) {
+	id, err := pgUUIDFromString(userID)
+	if err != nil {
+		return nil, common.NewAppError("UNAUTHORIZED", "unauthorized", httpStatusUnauthorized, nil)
+	}
+	record, err := s.queries.GetUserTOTP(ctx, id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, common.NewAppError("INVALID_STATE", "two-factor enrollment has not been started", httpStatusConflict, nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get totp: %w", err)
+	}
+	if record.EnabledAt.Valid {
+		return nil, common.NewAppError("CONFLICT", "two-factor authentication is already enabled", httpStatusConflict, nil)
+	}
+	secret, err := decryptSecret(s.totpKey, record.Secret)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt totp secret: %w", err)
+	}
+	step, ok := matchTOTP(secret, strings.TrimSpace(code), s.now())
+	if !ok {
+		return nil, invalidOTP()
+	}
+
+	codes, err := newBackupCodes()
+	if err != nil {
+		return nil, fmt.Errorf("generate backup codes: %w", err)
+	}
+	hashes := make([]string, len(codes))
+	for i, c := range codes {
+		hashes[i] = hashBackupCode(c)
+	}
+	// Backup codes only count while two-factor is enabled, so writing them
+	// before the enable step leaves nothing usable behind if it fails.
+	if err := s.queries.DeleteBackupCodes(ctx, id); err != nil {
+		return nil, fmt.Errorf("delete backup codes: %w", err)
+	}
+	if err := s.queries.InsertBackupCodes(ctx, db.InsertBackupCodesParams{UserID: id, CodeHashes: hashes}); err != nil {
+		return nil, fmt.Errorf("insert backup codes: %w", err)
+	}
+	rows, err := s.queries.EnableUserTOTP(ctx, db.EnableUserTOTPParams{UserID: id, LastStep: step})
+	if err != nil {
+		return nil, fmt.Errorf("enable totp: %w", err)
+	}
+	if rows == 0 {
+		return nil, common.NewAppError("CONFLICT", "two-factor authentication is already enabled", httpStatusConflict, nil)
+	}
+	return codes, nil
+}
+
+// DisableTwoFactor turns two-factor authentication off after checking a
+// current TOTP or backup code, and discards the secret and backup codes.
+func (s *Service) DisableTwoFactor(ctx context.Context, userID, code string) error {
+	id, err := pgUUIDFromString(userID)
+	if err != nil {
+		return common.NewAppError("UNAUTHORIZED", "unauthorized", httpStatusUnauthorized, nil)
+	}
+	if err := s.verifySecondFactor(ctx, id, code); err != nil {
+		return err
+	}
+	if err := s.queries.DeleteUserTOTP(ctx, id); err != nil {
+		return fmt.Errorf("delete totp: %w", err)
+	}
+	if err := s.queries.DeleteBackupCodes(ctx, id); err != nil {
+		return fmt.Errorf("delete backup codes: %w", err)
+	}
+	return nil
+}
+
+// CompleteTwoFactorLogin exchanges a login challenge and a TOTP or backup
+// code for the token pair Login would otherwise have issued.
+func (s *Service) CompleteTwoFactorLogin(ctx context.Context, challenge, code, userAgent, ip string) (LoginResult, error) {
+	userID, audience, err := s.parseChallenge(challenge)
+	if err != nil {
+		return LoginResult{}, common.NewAppError("UNAUTHORIZED", "invalid or expired challenge", httpStatusUnauthorized, err)
+	}
+	id, err := pgUUIDFromString(userID)
+	if err != nil {
+		return LoginResult{}, common.NewAppError("UNAUTHORIZED", "invalid or expired challenge", httpStatusUnauthorized, err)
+	}
+	user, err := s.queries.GetUserByID(ctx, id)
+	if err != nil {
+		return LoginResult{}, common.NewAppError("UNAUTHORIZED", "invalid or expired challenge", httpStatusUnauthorized, err)
+	}
+	if err := s.verifySecondFactor(ctx, id, code); err != nil {
+		return LoginResult{}, err
+	}
+	return s.issueLogin(ctx, convertUserFromGet(user), id, audience, userAgent, ip)
+}
+
+// verifySecondFactor accepts either a TOTP code for a step not used before or
+// an unused backup code, which is consumed.
+func (s *Service) verifySecondFactor(ctx context.Context, userID pgtype.UUID, code string) error {
+	record, err := s.queries.GetUserTOTP(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && !record.EnabledAt.Valid) {
+		return common.NewAppError("INVALID_STATE", "two-factor authentication is not enabled", httpStatusConflict, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("get totp: %w", err)
+	}
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return invalidOTP()
+	}
+
+	if len(code) == totpDigits {
+		secret, err := decryptSecret(s.totpKey, record.Secret)
+		if err != nil {
+			return fmt.Errorf("decrypt totp secret: %w", err)
+		}
+		step, ok := matchTOTP(secret, code, s.now())
+		if !ok {
+			return invalidOTP()
+		}
+		rows, err := s.queries.AdvanceUserTOTPStep(ctx, db.AdvanceUserTOTPStepParams{UserID: userID, LastStep: step})
+		if err != nil {
+			return fmt.Errorf("advance totp step: %w", err)
+		}
+		if rows == 0 {
+			return invalidOTP()
+		}
+		return nil
+	}
+
+	rows, err := s.queries.UseBackupCode(ctx, db.UseBackupCodeParams{UserID: userID, CodeHash: hashBackupCode(code)})
+	if err != nil {
+		return fmt.Errorf("use backup code: %w", err)
+	}
+	if rows == 0 {
+		return invalidOTP()
+	}
+	return nil
+}
+
+func (s *Service) twoFactorEnabled(ctx context.Context, userID pgtype.UUID) (bool, error) {
+	record, err := s.queries.GetUserTOTP(ctx, userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("get totp: %w", err)
+	}
+	return record.EnabledAt.Valid, nil
+}
+
+// signChallenge mints the short-lived token proving the password step passed.
+// It is signed with a key derived from, but distinct from, the access token
+// secret so a challenge can never be presented as an access token.
+func (s *Service) signChallenge(userID, audience string) (string, time.Time, error) {
+	now := s.now()
+	expiresAt := now.Add(s.challengeTTL)
+	token, err := jwt.NewBuilder().
+		Subject(userID).
+		Issuer(s.issuer).
+		Audience([]string{audience}).
+		IssuedAt(now).
+		Expiration(expiresAt).
+		Claim("purpose", challengePurpose).
+		Build()
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	signed, err := jwt.Sign(token, jwt.WithKey(jwa.HS256, s.challengeKey))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return string(signed), expiresAt, nil
+}
+
+// ChallengeUser returns the user a valid login challenge was issued to, so
+// the two-factor step can be rate limited per user instead of per client.
+func (s *Service) ChallengeUser(challenge string) (string, bool) {
+	userID, _, err := s.parseChallenge(challenge)
+	return userID, err == nil && userID != ""
+}
+
+func (s *Service) parseChallenge(challenge string) (string, string, error) {
+	challenge = strings.TrimSpace(challenge)
+	if challenge == "" {
+		return "", "", errors.New("auth: challenge missing")
+	}
+	parsed, err := jwt.ParseString(challenge,
+		jwt.WithKey(jwa.HS256, s.challengeKey),
+		jwt.WithIssuer(s.issuer),
+		jwt.WithClaimValue("purpose", challengePurpose),
+		jwt.WithClock(jwt.ClockFunc(s.now)),
+		jwt.WithAcceptableSkew(s.clockSkew),
+	)
+	if err != nil {
+		return "", "", err
+	}
+	audiences := parsed.Audience()
+	if len(audiences) != 1 {
+		return "", "", errors.New("auth: challenge audience missing")
+	}
+	return parsed.Subject(), audiences[0], nil
+}
+
+func invalidOTP() error {
+	return common.NewAppError("INVALID_OTP", "invalid two-factor code", httpStatusUnauthorized, nil)
+}
+
+func hashBackupCode(code string) string {
+	sum := sha256.Sum256([]byte(normalizeBackupCode(code)))
+	return fmt.Sprintf("%x", sum)
+}
+
+func totpEncryptionKey(encoded, secret string) ([]byte, error) {
+	encoded = strings.TrimSpace(encoded)
+	if encoded == "" {
+		key := sha256.Sum256([]byte("totp:" + secret))
+		return key[:], nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("auth: decode totp encryption key: %w", err)
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return key, nil
+	}
+	return nil, fmt.Errorf("auth: totp encryption key must be 16, 24, or 32 bytes, got %d", len(key))
+}
@@ -0,0 +1,248 @@
+package banlist
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// Ban kinds.
+const (
+	KindIP   = "ip"
+	KindUser = "user"
+)
+
+// maxNegatives bounds the negative cache; it is reset when full of live entries.
+const maxNegatives = 50_000
+
+// ErrInvalidBan is returned for bans with an unknown kind or malformed value.
+var ErrInvalidBan = errors.New("banlist: invalid ban")
+
+// Ban blocks one IP address or user account, optionally until ExpiresAt.
+type Ban struct {
+	Kind      string     `json:"kind"`
+	Value     string     `json:"value"`
+	Reason    string     `json:"reason"`
+	CreatedAt time.Time  `json:"createdAt"`
+	CreatedBy string     `json:"createdBy,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// List stores bans in Redis. Each ban lives under its own key so a lookup is
+// a single MGET, and expiring bans carry a matching Redis TTL.
+type List struct {
+	R      *redis.Client
+	Prefix string
+	// NegativeTTL caches "not banned" answers in process so clean traffic does
+	// not hit Redis on every request. New bans reach other instances within
+	// this window. Zero disables the cache.
+	NegativeTTL time.Duration
+
+	mu        sync.Mutex
+	negatives map[string]time.Time
+	now       func() time.Time
+}
+
+// Normalize validates kind and value and returns their canonical form.
+func Normalize(kind, value string) (string, string, error) {
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	value = strings.TrimSpace(value)
+	switch kind {
+	case KindIP:
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return "", "", ErrInvalidBan
+		}
+		return kind, ip.String(), nil
+	case KindUser:
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return "", "", ErrInvalidBan
+		}
+		return kind, id.String(), nil
+	default:
+		return "", "", ErrInvalidBan
+	}
+}
+
+// Add stores or replaces a ban.
+func (l *List) Add(ctx context.Context, ban Ban) (Ban, error) {
+	kind, value, err := Normalize(ban.Kind, ban.Value)
+	if err != nil {
+		return Ban{}, err
+	}
+	ban.Kind, ban.Value = kind, value
+	if ban.CreatedAt.IsZero() {
+		ban.CreatedAt = l.clock().UTC()
+	}
+	var ttl time.Duration
+	if ban.ExpiresAt != nil {
+		if ttl = ban.ExpiresAt.Sub(l.clock()); ttl <= 0 {
+			return Ban{}, ErrInvalidBan
+		}
+	}
+	raw, err := json.Marshal(ban)
+	if err != nil {
+		return Ban{}, err
+	}
+	member := kind + ":" + value
+	pipe := l.R.TxPipeline()
+	pipe.Set(ctx, l.key(member), raw, ttl)
+	pipe.SAdd(ctx, l.indexKey(), member)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return Ban{}, err
+	}
+	l.mu.Lock()
+	delete(l.negatives, member)
+	l.mu.Unlock()
+	return ban, nil
+}
+
+// Remove deletes a ban and reports whether one existed.
+func (l *List) Remove(ctx context.Context, kind, value string) (bool, error) {
+	kind, value, err := Normalize(kind, value)
+	if err != nil {
+		return false, err
+	}
+	member := kind + ":" + value
+	pipe := l.R.TxPipeline()
+	del := pipe.Del(ctx, l.key(member))
+	pipe.SRem(ctx, l.indexKey(), member)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, err
+	}
+	return del.Val() > 0, nil
+}
+
+// List returns all active bans, pruning index entries whose ban expired.
+func (l *List) List(ctx context.Context) ([]Ban, error) {
+	members, err := l.R.SMembers(ctx, l.indexKey()).Result()
+	if err != nil {
+		return nil, err
+	}
+	bans := make([]Ban, 0, len(members))
+	if len(members) == 0 {
+		return bans, nil
+	}
+	keys := make([]string, len(members))
+	for i, m := range members {
+		keys[i] = l.key(m)
+	}
+	values, err := l.R.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	var stale []any
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			stale = append(stale, members[i])
+			continue
+		}
+		var ban Ban
+		if err := json.Unmarshal([]byte(s), &ban); err != nil {
+			continue
+		}
+		bans = append(bans, ban)
+	}
+	if len(stale) > 0 {
+		_ = l.R.SRem(ctx, l.indexKey(), stale...).Err()
+	}
+	return bans, nil
+}
+
+// Check returns the ban matching ip or userID, or nil when neither is banned.
+func (l *List) Check(ctx context.Context, ip, userID string) (*Ban, error) {
+	var members []string
+	if _, v, err := Normalize(KindIP, ip); err == nil {
+		members = append(members, KindIP+":"+v)
+	}
+	if _, v, err := Normalize(KindUser, userID); err == nil {
+		members = append(members, KindUser+":"+v)
+	}
+	now := l.clock()
+	pending := members[:0:0]
+	l.mu.Lock()
+	for _, m := range members {
+		if until, ok := l.negatives[m]; ok && now.Before(until) {
+			continue
+		}
+		pending = append(pending, m)
+	}
+	l.mu.Unlock()
+	if len(pending) == 0 {
+		return nil, nil
+	}
+	keys := make([]string, len(pending))
+	for i, m := range pending {
+		keys[i] = l.key(m)
+	}
+	values, err := l.R.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, err
+	}
+	var found *Ban
+	for i, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			l.rememberNegative(pending[i], now)
+			continue
+		}
+		var ban Ban
+		if err := json.Unmarshal([]byte(s), &ban); err == nil && found == nil {
+			found = &ban
+		}
+	}
+	return found, nil
+}
+
+func (l *List) rememberNegative(member string, now time.Time) {
+	if l.NegativeTTL <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.negatives == nil {
+		l.negatives = make(map[string]time.Time)
+	}
+	if len(l.negatives) >= maxNegatives {
+		for m, until := range l.negatives {
+			if !now.Before(until) {
+				delete(l.negatives, m)
+			}
+		}
+		if len(l.negatives) >= maxNegatives {
+			l.negatives = make(map[string]time.Time)
+		}
+	}
+	l.negatives[member] = now.Add(l.NegativeTTL)
+}
+
+func (l *List) key(member string) string {
+	return l.prefix() + member
+}
+
+func (l *List) indexKey() string {
+	return l.prefix() + "index"
+}
+
+func (l *List) prefix() string {
+	if l.Prefix != "" {
+		return l.Prefix
+	}
+	return "ban:"
+}
+
+func (l *List) clock() time.Time {
+	if l.now != nil {
+		return l.now()
+	}
+	return time.Now()
+}
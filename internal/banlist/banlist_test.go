@@ -0,0 +1,155 @@
+package banlist_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/audit"
+	"github.com/noah-isme/backend-toko/internal/banlist"
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+const bannedUser = "7b7d0f7e-3c1e-4a43-9a44-0f1f3a2b9c11"
+
+func newList(t *testing.T, negativeTTL time.Duration) (*banlist.List, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+	return &banlist.List{R: client, NegativeTTL: negativeTTL}, mr
+}
+
+func TestListAddCheckRemove(t *testing.T) {
+	ctx := context.Background()
+	list, mr := newList(t, 0)
+
+	_, err := list.Add(ctx, banlist.Ban{Kind: "ip", Value: "not-an-ip", Reason: "x"})
+	require.ErrorIs(t, err, banlist.ErrInvalidBan)
+
+	expires := time.Now().Add(time.Hour)
+	_, err = list.Add(ctx, banlist.Ban{Kind: "IP", Value: " 203.0.113.7 ", Reason: "card testing", ExpiresAt: &expires})
+	require.NoError(t, err)
+	_, err = list.Add(ctx, banlist.Ban{Kind: "user", Value: strings.ToUpper(bannedUser), Reason: "chargeback fraud"})
+	require.NoError(t, err)
+	require.Greater(t, mr.TTL("ban:ip:203.0.113.7"), time.Duration(0))
+
+	ban, err := list.Check(ctx, "203.0.113.7", "")
+	require.NoError(t, err)
+	require.NotNil(t, ban)
+	require.Equal(t, "card testing", ban.Reason)
+
+	ban, err = list.Check(ctx, "198.51.100.1", bannedUser)
+	require.NoError(t, err)
+	require.NotNil(t, ban)
+	require.Equal(t, banlist.KindUser, ban.Kind)
+
+	bans, err := list.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, bans, 2)
+
+	mr.FastForward(2 * time.Hour)
+	bans, err = list.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, bans, 1, "expired bans drop out of the listing")
+
+	removed, err := list.Remove(ctx, "user", bannedUser)
+	require.NoError(t, err)
+	require.True(t, removed)
+	ban, err = list.Check(ctx, "", bannedUser)
+	require.NoError(t, err)
+	require.Nil(t, ban)
+}
+
+func TestListCachesNegativeLookups(t *testing.T) {
+	ctx := context.Background()
+	list, mr := newList(t, time.Minute)
+
+	ban, err := list.Check(ctx, "203.0.113.9", "")
+	require.NoError(t, err)
+	require.Nil(t, ban)
+
+	// A ban written by another instance is not seen until the negative entry expires.
+	require.NoError(t, mr.Set("ban:ip:203.0.113.9", `{"kind":"ip","value":"203.0.113.9","reason":"elsewhere"}`))
+	mr.Close()
+	ban, err = list.Check(ctx, "203.0.113.9", "")
+	require.NoError(t, err, "cached negatives do not touch redis")
+	require.Nil(t, ban)
+}
+
+type auditStore struct {
+	entries []dbgen.InsertAuditLogParams
+}
+
+func (s *auditStore) InsertAuditLog(ctx context.Context, arg dbgen.InsertAuditLogParams) (dbgen.InsertAuditLogRow, error) {
+	s.entries = append(s.entries, arg)
+	return dbgen.InsertAuditLogRow{}, nil
+}
+
+func (s *auditStore) ListAuditLogs(ctx context.Context, arg dbgen.ListAuditLogsParams) ([]dbgen.AuditLog, error) {
+	return nil, nil
+}
+
+func (s *auditStore) CountAuditLogs(ctx context.Context) (int64, error) {
+	return 0, nil
+}
+
+func TestAdminBansAreEnforcedAndAudited(t *testing.T) {
+	list, _ := newList(t, time.Minute)
+	store := &auditStore{}
+	admin := &banlist.AdminHandler{List: list, Audit: &audit.Service{Store: store, Enabled: true}}
+
+	router := chi.NewRouter()
+	router.Post("/admin/bans", admin.CreateBan)
+	router.Get("/admin/bans", admin.ListBans)
+	router.Delete("/admin/bans/{kind}/{value}", admin.DeleteBan)
+	protected := banlist.Guard{List: list}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	call := func(userID string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products", nil)
+		req.RemoteAddr = "198.51.100.4:1234"
+		if userID != "" {
+			req = req.WithContext(common.WithUserID(req.Context(), userID))
+		}
+		rec := httptest.NewRecorder()
+		protected.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	require.Equal(t, http.StatusNoContent, call(bannedUser))
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/bans", strings.NewReader(`{"kind":"user","value":"`+bannedUser+`","reason":"fraud","ttlSec":600}`)))
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	require.Equal(t, http.StatusForbidden, call(bannedUser), "adding a ban clears the local negative cache")
+	require.Equal(t, http.StatusNoContent, call(""))
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/bans", strings.NewReader(`{"kind":"ip","value":"198.51.100.4"}`)))
+	require.Equal(t, http.StatusBadRequest, rec.Code, "a reason is required")
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/admin/bans/user/"+bannedUser, nil))
+	require.Equal(t, http.StatusNoContent, rec.Code)
+	require.Equal(t, http.StatusNoContent, call(bannedUser))
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/admin/bans/user/"+bannedUser, nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	require.Len(t, store.entries, 2)
+	require.Equal(t, "ban.create", store.entries[0].Action)
+	require.Equal(t, "ban.delete", store.entries[1].Action)
+	require.Contains(t, string(store.entries[0].Metadata), `"reason":"fraud"`)
+}
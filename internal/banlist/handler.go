@@ -0,0 +1,128 @@
+package banlist
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/noah-isme/backend-toko/internal/audit"
+	"github.com/noah-isme/backend-toko/internal/common"
+)
+
+// AdminHandler exposes ban management endpoints.
+type AdminHandler struct {
+	List  *List
+	Audit *audit.Service
+	// OnError reports audit failures; the ban change itself has already succeeded.
+	OnError func(error)
+}
+
+type banPayload struct {
+	Kind      string     `json:"kind"`
+	Value     string     `json:"value"`
+	Reason    string     `json:"reason"`
+	TTLSec    int        `json:"ttlSec"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+}
+
+// ListBans returns all active bans.
+func (h *AdminHandler) ListBans(w http.ResponseWriter, r *http.Request) {
+	if h.List == nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "ban list not configured", nil)
+		return
+	}
+	bans, err := h.List.List(r.Context())
+	if err != nil {
+		common.JSONError(w, http.StatusServiceUnavailable, common.CodeUnavailable, "failed to load bans", nil)
+		return
+	}
+	common.JSON(w, http.StatusOK, map[string]any{"data": bans})
+}
+
+// CreateBan bans an IP address or user, optionally for ttlSec seconds or until expiresAt.
+func (h *AdminHandler) CreateBan(w http.ResponseWriter, r *http.Request) {
+	if h.List == nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "ban list not configured", nil)
+		return
+	}
+	var payload banPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		common.JSONError(w, http.StatusBadRequest, common.CodeInvalidBody, "invalid payload", nil)
+		return
+	}
+	reason := strings.TrimSpace(payload.Reason)
+	if reason == "" {
+		common.JSONError(w, http.StatusBadRequest, common.CodeValidation, "reason is required", map[string]any{"field": "reason"})
+		return
+	}
+	if payload.TTLSec < 0 || (payload.TTLSec > 0 && payload.ExpiresAt != nil) {
+		common.JSONError(w, http.StatusBadRequest, common.CodeValidation, "use either a positive ttlSec or expiresAt", map[string]any{"field": "ttlSec"})
+		return
+	}
+	ban := Ban{Kind: payload.Kind, Value: payload.Value, Reason: reason, ExpiresAt: payload.ExpiresAt}
+	if payload.TTLSec > 0 {
+		expires := time.Now().UTC().Add(time.Duration(payload.TTLSec) * time.Second)
+		ban.ExpiresAt = &expires
+	}
+	if userID, ok := common.UserID(r.Context()); ok {
+		ban.CreatedBy = userID
+	}
+	stored, err := h.List.Add(r.Context(), ban)
+	if err != nil {
+		if errors.Is(err, ErrInvalidBan) {
+			common.JSONError(w, http.StatusBadRequest, common.CodeValidation, "kind must be ip or user with a valid value and a future expiry", nil)
+			return
+		}
+		common.JSONError(w, http.StatusServiceUnavailable, common.CodeUnavailable, "failed to store ban", nil)
+		return
+	}
+	h.record(r, "ban.create", stored.Kind+":"+stored.Value, http.StatusCreated, map[string]any{
+		"kind":      stored.Kind,
+		"value":     stored.Value,
+		"reason":    stored.Reason,
+		"expiresAt": stored.ExpiresAt,
+	})
+	common.JSON(w, http.StatusCreated, map[string]any{"data": stored})
+}
+
+// DeleteBan lifts the ban identified by the kind and value URL parameters.
+func (h *AdminHandler) DeleteBan(w http.ResponseWriter, r *http.Request) {
+	if h.List == nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "ban list not configured", nil)
+		return
+	}
+	kind, value, err := Normalize(chi.URLParam(r, "kind"), chi.URLParam(r, "value"))
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, common.CodeBadRequest, "invalid ban kind or value", nil)
+		return
+	}
+	removed, err := h.List.Remove(r.Context(), kind, value)
+	if err != nil {
+		common.JSONError(w, http.StatusServiceUnavailable, common.CodeUnavailable, "failed to remove ban", nil)
+		return
+	}
+	if !removed {
+		common.JSONError(w, http.StatusNotFound, common.CodeNotFound, "ban not found", nil)
+		return
+	}
+	h.record(r, "ban.delete", kind+":"+value, http.StatusNoContent, map[string]any{"kind": kind, "value": value})
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AdminHandler) record(r *http.Request, action, resourceID string, status int, metadata map[string]any) {
+	if h.Audit == nil {
+		return
+	}
+	actor := audit.Actor{Kind: audit.ActorKindAnonymous}
+	if userID, ok := common.UserID(r.Context()); ok {
+		actor = audit.Actor{Kind: audit.ActorKindUser, UserID: &userID}
+	}
+	data, _ := json.Marshal(metadata)
+	if err := h.Audit.Record(r.Context(), actor, action, "ban", resourceID, r, status, data); err != nil && h.OnError != nil {
+		h.OnError(err)
+	}
+}
@@ -0,0 +1,38 @@
+package banlist
+
+import (
+	"net/http"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+)
+
+// Guard rejects requests from banned IPs or users with 403. It must run after
+// authentication has attached the user ID for user bans to apply.
+type Guard struct {
+	List    *List
+	OnError func(error)
+}
+
+// Middleware enforces the ban list, failing open when Redis is unavailable.
+func (g Guard) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if g.List == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		userID, _ := common.UserID(r.Context())
+		ban, err := g.List.Check(r.Context(), common.ClientIP(r), userID)
+		if err != nil {
+			if g.OnError != nil {
+				g.OnError(err)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		if ban != nil {
+			common.JSONError(w, http.StatusForbidden, common.CodeForbidden, "access has been blocked", nil)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,52 @@
+package bootstrap
+
+import (
+	"net/http"
+
+	"github.com/rs/zerolog"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	"github.com/noah-isme/backend-toko/internal/config"
+	"github.com/noah-isme/backend-toko/internal/notify"
+	"github.com/noah-isme/backend-toko/internal/resilience"
+)
+
+// NewEmailSender builds the configured mail provider client. The API sends
+// through it when the queue is off and the worker drains queued mail with it.
+func NewEmailSender(cfg *config.Config, logger zerolog.Logger) common.EmailSender {
+	transport, err := resilience.NewTransport(cfg.OutboundTransport(false))
+	if err != nil {
+		logger.Fatal().Err(err).Msg("build email transport")
+	}
+	sender, err := notify.NewEmailSender(notify.EmailSettings{
+		Provider: cfg.EmailProvider,
+		From:     cfg.NotifyEmailFrom,
+		HTTP: &resilience.HTTPClient{
+			Client:      &http.Client{Timeout: cfg.EmailSendTimeout, Transport: transport},
+			Breaker:     resilience.NewBreaker(cfg.CircuitEmailMinReq, cfg.CircuitEmailFailureRate, cfg.CircuitEmailOpenFor).WithHalfOpenProbes(cfg.CircuitHalfOpenProbes, cfg.CircuitHalfOpenSuccessRatio),
+			BaseBackoff: cfg.RetryBase,
+			MaxAttempts: cfg.RetryMaxAttempts,
+			Jitter:      cfg.RetryJitterPercent,
+			Timeout:     cfg.EmailSendTimeout,
+			Target:      "email-provider",
+			RetryBudget: resilience.NewRetryBudget(cfg.RetryBudgetEmail, cfg.RetryBudgetRefillPerSec),
+			Chaos:       cfg.Chaos(),
+			Logger:      &logger,
+		},
+		URL:    cfg.EmailProviderURL,
+		APIKey: cfg.EmailProviderAPIKey,
+		SMTP: notify.SMTPEmailSender{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			TLS:      cfg.SMTPTLS,
+			Timeout:  cfg.EmailSendTimeout,
+		},
+		Logger: logger.With().Str("component", "email").Logger(),
+	})
+	if err != nil {
+		logger.Fatal().Err(err).Msg("initialise email sender")
+	}
+	return sender
+}
@@ -0,0 +1,39 @@
+package bootstrap
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/noah-isme/backend-toko/internal/config"
+	"github.com/noah-isme/backend-toko/internal/media"
+)
+
+// NewMediaStorage builds the configured image storage. The local provider is
+// also returned so its files can be served by the API. Cached catalog entries
+// hold resolved image URLs, so every process that fills the cache must build
+// its storage here.
+func NewMediaStorage(cfg *config.Config) (media.StorageProvider, *media.LocalStorage) {
+	if cfg.MediaStorage == "s3" {
+		return &media.S3Storage{
+			Endpoint:      cfg.S3Endpoint,
+			Region:        cfg.S3Region,
+			Bucket:        cfg.S3Bucket,
+			AccessKey:     cfg.S3AccessKeyID,
+			SecretKey:     cfg.S3SecretAccessKey,
+			PathStyle:     cfg.S3PathStyle,
+			PublicBaseURL: cfg.MediaBaseURL,
+			PrivateBucket: cfg.MediaPrivate,
+			SignedURLTTL:  cfg.MediaSignedURLTTL,
+			Client:        &http.Client{Timeout: 30 * time.Second},
+		}, nil
+	}
+	local := &media.LocalStorage{
+		Root:         cfg.MediaLocalDir,
+		BaseURL:      cfg.MediaBaseURL,
+		SignedURLTTL: cfg.MediaSignedURLTTL,
+	}
+	if cfg.MediaPrivate {
+		local.SigningKey = []byte(cfg.MediaSigningKey)
+	}
+	return local, local
+}
@@ -0,0 +1,33 @@
+// Package bootstrap builds the dependencies the api and worker binaries
+// share at startup, so both processes configure them the same way.
+package bootstrap
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+
+	"github.com/noah-isme/backend-toko/internal/config"
+	"github.com/noah-isme/backend-toko/internal/db"
+)
+
+// CheckSchema compares the database migration version with the build's
+// latest migration according to SCHEMA_CHECK, so running against an
+// un-migrated database fails with a clear message rather than query errors.
+func CheckSchema(ctx context.Context, cfg *config.Config, logger zerolog.Logger, q db.RowQuerier) {
+	if cfg.SchemaCheck == db.SchemaCheckOff {
+		return
+	}
+	status, err := db.CheckSchema(ctx, q)
+	if err == nil {
+		err = status.Err()
+	}
+	if err == nil {
+		logger.Info().Uint("schema_version", status.Current).Msg("database schema up to date")
+		return
+	}
+	if cfg.SchemaCheck == db.SchemaCheckFail {
+		logger.Fatal().Err(err).Msg("check database schema")
+	}
+	logger.Warn().Err(err).Msg("database schema does not match this build")
+}
@@ -0,0 +1,58 @@
+package bootstrap
+
+import (
+	"time"
+
+	redis "github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+
+	"github.com/noah-isme/backend-toko/internal/config"
+	"github.com/noah-isme/backend-toko/internal/notify"
+	"github.com/noah-isme/backend-toko/internal/queue"
+	"github.com/noah-isme/backend-toko/internal/resilience"
+)
+
+// NewWebhookDispatcher builds the webhook dispatcher with the outbound
+// transport (proxy, CA bundle, TLS policy) and resilience settings from cfg.
+// The API schedules and the worker delivers with it, so both must agree.
+func NewWebhookDispatcher(cfg *config.Config, logger zerolog.Logger, store notify.Store, tasks queue.Enqueuer, rdb *redis.Client) *notify.Dispatcher {
+	transport, err := resilience.NewTransport(cfg.OutboundTransport(cfg.WebhookAllowInsecureTLS))
+	if err != nil {
+		logger.Fatal().Err(err).Msg("build webhook transport")
+	}
+	client := notify.HttpClient(int(cfg.WebhookRequestTimeout/time.Millisecond), transport)
+	headers, err := notify.NewHeaderCipher(cfg.WebhookHeadersEncryptionKey, cfg.JWTSecret)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("build webhook header cipher")
+	}
+	return &notify.Dispatcher{
+		Store: store,
+		HTTP: &resilience.HTTPClient{
+			Client:      client,
+			Breaker:     resilience.NewBreaker(cfg.CircuitWebhookMinReq, cfg.CircuitWebhookFailureRate, cfg.CircuitWebhookOpenFor).WithHalfOpenProbes(cfg.CircuitHalfOpenProbes, cfg.CircuitHalfOpenSuccessRatio),
+			BaseBackoff: cfg.RetryBase,
+			MaxAttempts: cfg.RetryMaxAttempts,
+			Jitter:      cfg.RetryJitterPercent,
+			Timeout:     cfg.OutboundTimeout,
+			Target:      "webhook-delivery",
+			RetryBudget: resilience.NewRetryBudget(cfg.RetryBudgetWebhook, cfg.RetryBudgetRefillPerSec),
+			Chaos:       cfg.Chaos(),
+			Logger:      &logger,
+		},
+		Queue:               tasks,
+		BackoffBaseSec:      cfg.WebhookBackoffBaseSec,
+		DefaultMaxAttempts:  cfg.WebhookDefaultMaxAttempts,
+		Enabled:             cfg.WebhookDeliveryEnabled,
+		Replay:              notify.RedisReplayProtector{Client: rdb},
+		ReplayTTL:           cfg.WebhookReplayTTL,
+		AttemptBodyLimit:    cfg.WebhookAttemptBodyLimit,
+		AttemptHistoryLimit: cfg.WebhookAttemptHistoryLimit,
+		MaxPayloadBytes:     cfg.WebhookMaxPayloadBytes,
+		PublicBaseURL:       cfg.PublicBaseURL,
+		PayloadURLTTL:       cfg.WebhookPayloadURLTTL,
+		AutoDisableAfter:    cfg.WebhookAutoDisableAfter,
+		WorkConcurrency:     cfg.WebhookWorkConcurrency,
+		RetryClientErrors:   cfg.WebhookRetryClientErrors,
+		Headers:             headers,
+	}
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	"github.com/noah-isme/backend-toko/internal/tenant"
+)
+
+// scanBatch is the COUNT hint for each SCAN round and the most keys deleted
+// per DEL.
+const scanBatch = 500
+
+// DeleteMatching removes every key matching the Redis glob pattern and
+// returns how many were deleted. It walks the keyspace with SCAN, so it is
+// meant for invalidation, not the request path.
+func DeleteMatching(ctx context.Context, client *redis.Client, pattern string) (int, error) {
+	if client == nil || pattern == "" {
+		return 0, nil
+	}
+	deleted := 0
+	iter := client.Scan(ctx, 0, pattern, scanBatch).Iterator()
+	batch := make([]string, 0, scanBatch)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		n, err := client.Del(ctx, batch...).Result()
+		deleted += int(n)
+		batch = batch[:0]
+		return err
+	}
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == scanBatch {
+			if err := flush(); err != nil {
+				return deleted, err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return deleted, err
+	}
+	return deleted, flush()
+}
+
+// FlushTenant removes every key scoped to tenantID, that is its cached
+// catalog payloads. It does nothing when
+// scope is disabled, since flat keys cannot be told apart by tenant.
+func FlushTenant(ctx context.Context, client *redis.Client, scope tenant.KeyScope, tenantID string) (int, error) {
+	return DeleteMatching(ctx, client, scope.Pattern(tenantID))
+}
+
+// AdminHandler exposes per-tenant cache invalidation.
+type AdminHandler struct {
+	Client *redis.Client
+	Scope  tenant.KeyScope
+}
+
+// FlushTenant handles POST /admin/tenants/{tenant}/cache/flush.
+func (h AdminHandler) FlushTenant(w http.ResponseWriter, r *http.Request) {
+	if !h.Scope.Enabled || h.Client == nil {
+		common.JSONError(w, http.StatusConflict, common.CodeConflict, "tenant key isolation is disabled", nil)
+		return
+	}
+	tenantID := strings.TrimSpace(chi.URLParam(r, "tenant"))
+	if tenantID == "" {
+		common.JSONError(w, http.StatusBadRequest, common.CodeBadRequest, "tenant is required", nil)
+		return
+	}
+	deleted, err := FlushTenant(r.Context(), h.Client, h.Scope, tenantID)
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to flush tenant keys", map[string]any{"deleted": deleted})
+		return
+	}
+	common.JSON(w, http.StatusOK, map[string]any{"data": map[string]any{"tenant": tenantID, "deleted": deleted}})
+}
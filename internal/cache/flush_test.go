@@ -0,0 +1,40 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/noah-isme/backend-toko/internal/tenant"
+)
+
+func TestFlushTenantOnlyRemovesThatTenant(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	for _, key := range []string{"t:acme:cache:catalog:x", "t:acme:idem:1", "t:acme2:idem:1", "t:globex:idem:1", "idem:1"} {
+		if err := mr.Set(key, "v"); err != nil {
+			t.Fatalf("seed %s: %v", key, err)
+		}
+	}
+	scope := tenant.KeyScope{Enabled: true}
+	deleted, err := FlushTenant(context.Background(), client, scope, "acme")
+	if err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	if deleted != 2 {
+		t.Fatalf("expected 2 keys deleted, got %d", deleted)
+	}
+	for _, key := range []string{"t:acme2:idem:1", "t:globex:idem:1", "idem:1"} {
+		if !mr.Exists(key) {
+			t.Fatalf("expected %s to survive", key)
+		}
+	}
+
+	if deleted, err := FlushTenant(context.Background(), client, tenant.KeyScope{}, "globex"); err != nil || deleted != 0 {
+		t.Fatalf("disabled scope should flush nothing, got %d, %v", deleted, err)
+	}
+}
@@ -0,0 +1,92 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+// versionedDB holds a single cart item and applies UpdateCartItemQty with the
+// same version check as the SQL. reads gates GetCartItemByID so every caller
+// has read the item before any of them writes.
+type versionedDB struct {
+	countingDB
+	mu    sync.Mutex
+	item  dbgen.CartItem
+	reads sync.WaitGroup
+}
+
+func (d *versionedDB) QueryRow(_ context.Context, sql string, args ...interface{}) pgx.Row {
+	switch strings.Fields(strings.TrimPrefix(sql, "-- name:"))[0] {
+	case "GetCartItemByID":
+		d.mu.Lock()
+		item := d.item
+		d.mu.Unlock()
+		d.reads.Done()
+		d.reads.Wait()
+		return &structRows{items: []any{item}, pos: 0}
+	case "UpdateCartItemQty":
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		if args[3].(int32) != d.item.Version {
+			return &structRows{pos: 0}
+		}
+		d.item.Qty = args[1].(int32)
+		d.item.Subtotal = args[2].(int64)
+		d.item.Version++
+		return &structRows{items: []any{d.item}, pos: 0}
+	case "GetProductForCart":
+		return &structRows{items: []any{dbgen.GetProductForCartRow{ID: d.item.ProductID, InStock: true}}, pos: 0}
+	}
+	return &structRows{pos: 0}
+}
+
+func (d *versionedDB) Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, nil
+}
+
+func TestUpdateQtyConcurrentWritesConflict(t *testing.T) {
+	const writers = 2
+	db := &versionedDB{item: dbgen.CartItem{ID: testUUID(0x01, 1), CartID: testUUID(0xca, 5), Qty: 1, UnitPrice: 10000, Subtotal: 10000, Version: 1}}
+	db.reads.Add(writers)
+	svc := &Service{Q: dbgen.New(db)}
+
+	errs := make([]error, writers)
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = svc.UpdateQty(context.Background(), UUIDString(db.item.ID), i+2)
+		}(i)
+	}
+	wg.Wait()
+
+	var ok, conflicts int
+	for _, err := range errs {
+		var appErr *common.AppError
+		switch {
+		case err == nil:
+			ok++
+		case errors.As(err, &appErr) && appErr.Code == common.CodeConflict && appErr.HTTPStatus == http.StatusConflict:
+			conflicts++
+		default:
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if ok != 1 || conflicts != 1 {
+		t.Fatalf("expected one write and one conflict, got %d and %d", ok, conflicts)
+	}
+	if db.item.Version != 2 || db.item.Subtotal != int64(db.item.Qty)*db.item.UnitPrice {
+		t.Fatalf("expected a single consistent write, got %+v", db.item)
+	}
+}
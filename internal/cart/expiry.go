@@ -0,0 +1,60 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/reqcache"
+)
+
+// ErrExpired indicates the cart outlived its TTL and can no longer be used
+// or extended.
+var ErrExpired = errors.New("cart expired")
+
+// Expired reports whether the cart's expiry has passed.
+func (s *Service) Expired(expiresAt pgtype.Timestamptz) bool {
+	return expiresAt.Valid && expiresAt.Time.Before(s.now())
+}
+
+// ExpiringSoon reports whether an unexpired cart lapses within
+// ExpiryWarning, so clients can prompt the shopper before it does.
+func (s *Service) ExpiringSoon(expiresAt pgtype.Timestamptz) bool {
+	if s == nil || s.ExpiryWarning <= 0 || !expiresAt.Valid || s.Expired(expiresAt) {
+		return false
+	}
+	return expiresAt.Time.Sub(s.now()) <= s.ExpiryWarning
+}
+
+// Touch pushes an unexpired cart's expiry a full TTL from now without
+// changing its contents, and returns the new expiry.
+func (s *Service) Touch(ctx context.Context, cartID string) (time.Time, error) {
+	if s == nil || s.Q == nil {
+		return time.Time{}, errors.New("cart service not configured")
+	}
+	cID, err := toUUID(cartID)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse cart id: %w", ErrInvalidInput)
+	}
+	cart, err := s.cartByID(ctx, cID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return time.Time{}, ErrNotFound
+		}
+		return time.Time{}, err
+	}
+	if s.Expired(cart.ExpiresAt) {
+		return time.Time{}, ErrExpired
+	}
+	expires := s.now().Add(s.ttl())
+	if err := s.Q.TouchCart(ctx, dbgen.TouchCartParams{ID: cID, ExpiresAt: pgtype.Timestamptz{Time: expires, Valid: true}}); err != nil {
+		return time.Time{}, err
+	}
+	reqcache.Forget(ctx, CartCacheKey(cID))
+	return expires, nil
+}
@@ -1,6 +1,7 @@
 package cart
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -15,6 +16,7 @@ import (
 	"github.com/noah-isme/backend-toko/internal/common"
 	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
 	"github.com/noah-isme/backend-toko/internal/pricing"
+	"github.com/noah-isme/backend-toko/internal/resilience"
 	"github.com/noah-isme/backend-toko/internal/shipping"
 )
 
@@ -24,8 +26,21 @@ type Handler struct {
 	Svc            *Service
 	ShippingClient shipping.Client
 	ShippingOrigin string
-	TaxBps         int
-	Currency       string
+	// ShippingRules are the default shipping rules; tenants may replace them
+	// under shipping.TenantRulesKey.
+	ShippingRules []shipping.Rule
+	TaxBps        int
+	Currency      string
+	// ProviderTimeout bounds each shipping rate call; zero leaves only the
+	// request's own deadline.
+	ProviderTimeout time.Duration
+	// Rounding rounds tax; the zero value floors.
+	Rounding pricing.Rounding
+	// Parcel weighs cart lines for shipping quotes.
+	Parcel shipping.ParcelConfig
+	// CourierPolicy filters and orders quoted rates; tenants may replace it
+	// under shipping.TenantCourierPolicyKey.
+	CourierPolicy shipping.CourierPolicy
 }
 
 // Create creates or returns a guest cart identifier.
@@ -77,11 +92,7 @@ func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
 		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "unable to load cart", nil)
 		return
 	}
-	now := time.Now()
-	if h.Svc != nil {
-		now = h.Svc.now()
-	}
-	if cart.ExpiresAt.Valid && cart.ExpiresAt.Time.Before(now) {
+	if h.Svc.Expired(cart.ExpiresAt) {
 		common.JSONError(w, http.StatusNotFound, "NOT_FOUND", "cart expired", nil)
 		return
 	}
@@ -100,8 +111,8 @@ func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
 			"title":     it.Title,
 			"slug":      it.Slug,
 			"qty":       it.Qty,
-			"unitPrice": it.UnitPrice,
-			"subtotal":  it.Subtotal,
+			"unitPrice": common.Int64(it.UnitPrice),
+			"subtotal":  common.Int64(it.Subtotal),
 		})
 		pricingItems = append(pricingItems, pricing.Item{Qty: int(it.Qty), UnitPrice: pricing.Money(it.UnitPrice)})
 	}
@@ -121,25 +132,52 @@ func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
 			discount = 0
 		}
 	}
-	summary := pricing.Compute(pricingItems, discount, h.TaxBps, 0)
+	summary := pricing.Compute(pricingItems, discount, h.TaxBps, 0, h.Rounding)
+	// Without a destination only rules that apply everywhere can price
+	// shipping; otherwise it stays zero until checkout quotes it.
+	rules, err := shipping.TenantRules(r.Context(), h.Q, UUIDString(cart.TenantID), h.ShippingRules)
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "unable to load shipping rules", nil)
+		return
+	}
+	decision := shipping.Evaluate(rules, summary.Subtotal-summary.Discount)
+	if !decision.Quoted() {
+		summary = pricing.Compute(pricingItems, discount, h.TaxBps, pricing.Money(decision.Price), h.Rounding)
+	}
 	common.JSON(w, http.StatusOK, map[string]any{
 		"data": map[string]any{
-			"id":      UUIDString(cart.ID),
-			"anonId":  nullableText(cart.AnonID),
-			"voucher": nullableText(cart.AppliedVoucherCode),
-			"items":   responseItems,
+			"id":           UUIDString(cart.ID),
+			"anonId":       nullableText(cart.AnonID),
+			"voucher":      nullableText(cart.AppliedVoucherCode),
+			"items":        responseItems,
+			"shippingRule": decision,
 			"pricing": map[string]any{
-				"subtotal": summary.Subtotal,
-				"discount": summary.Discount,
-				"tax":      summary.Tax,
-				"shipping": summary.Shipping,
-				"total":    summary.Total,
+				"subtotal": common.Int64(summary.Subtotal),
+				"discount": common.Int64(summary.Discount),
+				"tax":      common.Int64(summary.Tax),
+				"shipping": common.Int64(summary.Shipping),
+				"total":    common.Int64(summary.Total),
 			},
-			"currency": h.Currency,
+			"currency":     h.Currency,
+			"expiresAt":    nullableTime(cart.ExpiresAt),
+			"expiringSoon": h.Svc.ExpiringSoon(cart.ExpiresAt),
 		},
 	})
 }
 
+// Touch extends the cart's expiry without changing it and returns the cart.
+func (h *Handler) Touch(w http.ResponseWriter, r *http.Request) {
+	if h.Svc == nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "cart service not configured", nil)
+		return
+	}
+	if _, err := h.Svc.Touch(r.Context(), chi.URLParam(r, "id")); err != nil {
+		h.writeError(w, err)
+		return
+	}
+	h.Get(w, r)
+}
+
 // GetActive resolves the current active cart for the user or anon ID.
 func (h *Handler) GetActive(w http.ResponseWriter, r *http.Request) {
 	if h.Svc == nil {
@@ -298,7 +336,6 @@ func (h *Handler) QuoteShipping(w http.ResponseWriter, r *http.Request) {
 	var payload struct {
 		Destination string `json:"destination"`
 		Courier     string `json:"courier"`
-		WeightGram  int    `json:"weightGram"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
 		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid payload", nil)
@@ -308,16 +345,16 @@ func (h *Handler) QuoteShipping(w http.ResponseWriter, r *http.Request) {
 		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "destination is required", nil)
 		return
 	}
-	if payload.WeightGram <= 0 {
-		payload.WeightGram = 1000
-	}
 	cID, err := toUUID(cartID)
 	if err != nil {
 		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid cart id", nil)
 		return
 	}
+	parcel := h.Parcel.Pack(nil)
+	policy := h.CourierPolicy
 	if h.Q != nil {
-		if _, err := h.Q.GetCartByID(r.Context(), cID); err != nil {
+		cart, err := h.Q.GetCartByID(r.Context(), cID)
+		if err != nil {
 			if errors.Is(err, pgx.ErrNoRows) {
 				common.JSONError(w, http.StatusNotFound, "NOT_FOUND", "cart not found", nil)
 				return
@@ -325,17 +362,40 @@ func (h *Handler) QuoteShipping(w http.ResponseWriter, r *http.Request) {
 			common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "unable to load cart", nil)
 			return
 		}
+		if parcel, err = Parcel(r.Context(), h.Q, cID, h.Parcel); err != nil {
+			common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "unable to load cart items", nil)
+			return
+		}
+		if policy, err = shipping.TenantCourierPolicy(r.Context(), h.Q, UUIDString(cart.TenantID), h.CourierPolicy); err != nil {
+			common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "unable to load courier policy", nil)
+			return
+		}
+	}
+	ctx := r.Context()
+	if h.ProviderTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.ProviderTimeout)
+		defer cancel()
 	}
-	rates, err := h.ShippingClient.Rates(r.Context(), shipping.RateReq{
+	rates, err := h.ShippingClient.Rates(ctx, shipping.RateReq{
 		Origin:      h.ShippingOrigin,
 		Destination: payload.Destination,
-		WeightGram:  payload.WeightGram,
+		Parcel:      parcel,
 		Courier:     payload.Courier,
 	})
 	if err != nil {
+		if resilience.IsTimeout(err) {
+			common.JSONError(w, http.StatusGatewayTimeout, common.CodeProviderTimeout, "shipping provider timed out", nil)
+			return
+		}
 		common.JSONError(w, http.StatusBadGateway, "SHIPPING_ERROR", "failed to fetch rates", nil)
 		return
 	}
+	rates = policy.Apply(rates, payload.Courier, payload.Destination)
+	if len(rates) == 0 {
+		common.JSONError(w, http.StatusUnprocessableEntity, common.CodeNoShippingOptions, "no shipping options are available for this destination", nil)
+		return
+	}
 	common.JSON(w, http.StatusOK, map[string]any{"data": rates})
 }
 
@@ -360,8 +420,8 @@ func (h *Handler) QuoteTax(w http.ResponseWriter, r *http.Request) {
 	for _, it := range items {
 		pricingItems = append(pricingItems, pricing.Item{Qty: int(it.Qty), UnitPrice: pricing.Money(it.UnitPrice)})
 	}
-	summary := pricing.Compute(pricingItems, 0, h.TaxBps, 0)
-	common.JSON(w, http.StatusOK, map[string]any{"data": map[string]any{"tax": summary.Tax}})
+	summary := pricing.Compute(pricingItems, 0, h.TaxBps, 0, h.Rounding)
+	common.JSON(w, http.StatusOK, map[string]any{"data": map[string]any{"tax": common.Int64(summary.Tax)}})
 }
 
 // Merge merges a guest cart into the authenticated user's cart.
@@ -416,7 +476,7 @@ func (h *Handler) writeError(w http.ResponseWriter, err error) {
 	switch {
 	case errors.Is(err, ErrInvalidInput):
 		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
-	case errors.Is(err, ErrNotFound):
+	case errors.Is(err, ErrNotFound), errors.Is(err, ErrExpired):
 		common.JSONError(w, http.StatusNotFound, "NOT_FOUND", err.Error(), nil)
 	default:
 		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", err.Error(), nil)
@@ -438,3 +498,11 @@ func nullableUUID(v pgtype.UUID) *string {
 	s := uuid.UUID(v.Bytes).String()
 	return &s
 }
+
+func nullableTime(v pgtype.Timestamptz) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := v.Time.UTC()
+	return &t
+}
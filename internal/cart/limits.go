@@ -0,0 +1,94 @@
+package cart
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/noah-isme/backend-toko/internal/catalog"
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+// Limit names reported in CART_LIMIT_EXCEEDED details.
+const (
+	LimitItems    = "items"
+	LimitLineQty  = "lineQty"
+	LimitTotalQty = "totalQty"
+)
+
+// Limits caps what a cart may hold so pathological carts cannot slow pricing
+// and checkout. Zero disables a cap.
+type Limits struct {
+	// MaxItems is the number of distinct lines per cart.
+	MaxItems int
+	// MaxLineQty is the quantity of a single line.
+	MaxLineQty int
+	// MaxTotalQty is the quantity summed over all lines.
+	MaxTotalQty int
+}
+
+func errLimitExceeded(limit string, max int, msg string) error {
+	err := common.NewAppError(common.CodeCartLimitExceeded, msg, http.StatusUnprocessableEntity, nil)
+	err.Details = map[string]any{"limit": limit, "max": max}
+	return err
+}
+
+// checkLine rejects a line quantity above the per-line cap.
+func (l Limits) checkLine(qty int) error {
+	if l.MaxLineQty > 0 && qty > l.MaxLineQty {
+		return errLimitExceeded(LimitLineQty, l.MaxLineQty, fmt.Sprintf("at most %d of an item per cart", l.MaxLineQty))
+	}
+	return nil
+}
+
+// checkCart rejects a change that adds newLines lines and addQty units to a
+// cart over the distinct-item or total-quantity cap. The cart's totals are
+// only loaded when one of those caps is set.
+func (s *Service) checkCart(ctx context.Context, cartID pgtype.UUID, newLines, addQty int) error {
+	l := s.Limits
+	if (l.MaxItems <= 0 || newLines <= 0) && (l.MaxTotalQty <= 0 || addQty <= 0) {
+		return nil
+	}
+	totals, err := s.Q.GetCartItemTotals(ctx, cartID)
+	if err != nil {
+		return err
+	}
+	if l.MaxItems > 0 && newLines > 0 && int(totals.Items)+newLines > l.MaxItems {
+		return errLimitExceeded(LimitItems, l.MaxItems, fmt.Sprintf("a cart holds at most %d different items", l.MaxItems))
+	}
+	if l.MaxTotalQty > 0 && addQty > 0 && int(totals.Qty)+addQty > l.MaxTotalQty {
+		return errLimitExceeded(LimitTotalQty, l.MaxTotalQty, fmt.Sprintf("a cart holds at most %d items in total", l.MaxTotalQty))
+	}
+	return nil
+}
+
+// checkUpdate applies the caps and the stock check to setting item's
+// quantity to qty. Stock is only checked when the quantity grows, so a line
+// left over stock can still be reduced.
+func (s *Service) checkUpdate(ctx context.Context, item dbgen.CartItem, qty int) error {
+	if err := s.Limits.checkLine(qty); err != nil {
+		return err
+	}
+	grow := qty - int(item.Qty)
+	if grow <= 0 {
+		return nil
+	}
+	product, err := s.Q.GetProductForCart(ctx, item.ProductID)
+	if err != nil {
+		return err
+	}
+	preorder := s.availability(product).Status == catalog.AvailabilityPreorder
+	stock := productStock(product, preorder)
+	if item.VariantID.Valid {
+		if _, stock, err = s.variantStock(ctx, item.ProductID, item.VariantID, product.Title, preorder); err != nil {
+			return err
+		}
+	}
+	if err := stock.check(qty); err != nil {
+		return err
+	}
+	return s.checkCart(ctx, item.CartID, 0, grow)
+}
@@ -0,0 +1,31 @@
+package cart
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/shipping"
+)
+
+// Parcel weighs and measures the lines of a cart from their variants for a
+// shipping quote. Lines without a variant, or whose variant has no weight,
+// count at the configured default weight.
+func Parcel(ctx context.Context, q *dbgen.Queries, cartID pgtype.UUID, cfg shipping.ParcelConfig) (shipping.Parcel, error) {
+	rows, err := q.ListCartItemParcels(ctx, cartID)
+	if err != nil {
+		return shipping.Parcel{}, err
+	}
+	items := make([]shipping.ParcelItem, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, shipping.ParcelItem{
+			Qty:        int(row.Qty),
+			WeightGram: int(row.WeightGram.Int32),
+			LengthCm:   int(row.LengthCm.Int32),
+			WidthCm:    int(row.WidthCm.Int32),
+			HeightCm:   int(row.HeightCm.Int32),
+		})
+	}
+	return cfg.Pack(items), nil
+}
@@ -4,13 +4,19 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 
+	"github.com/noah-isme/backend-toko/internal/catalog"
+	"github.com/noah-isme/backend-toko/internal/common"
 	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/pricing"
+	"github.com/noah-isme/backend-toko/internal/reqcache"
 	"github.com/noah-isme/backend-toko/internal/tenant"
 )
 
@@ -20,13 +26,56 @@ var ErrNotFound = errors.New("cart not found")
 // ErrInvalidInput is returned when the provided payload is invalid.
 var ErrInvalidInput = errors.New("invalid input")
 
+// errConcurrentUpdate reports a write that lost an optimistic lock because
+// the row changed after it was read. Clients reload the cart and retry.
+func errConcurrentUpdate(what string) error {
+	return common.NewAppError(common.CodeConflict, what+" was changed by another request; reload the cart and retry", http.StatusConflict, nil)
+}
+
 // Service encapsulates cart domain operations.
 type Service struct {
 	Q                          *dbgen.Queries
 	TTL                        time.Duration
 	Now                        func() time.Time
 	VoucherPerUserLimitDefault int
+	VoucherReleaseOnCancel     bool
 	DefaultTenantID            pgtype.UUID
+	// Rounding rounds percentage discounts; the zero value floors.
+	Rounding pricing.Rounding
+	// Limits caps cart size; the zero value leaves carts unbounded.
+	Limits Limits
+	// RequestCache memoizes cart and line lookups for the rest of the
+	// request, e.g. when checkout evaluates the voucher of a cart it loaded.
+	RequestCache bool
+	// ExpiryWarning flags carts that expire within this window; zero never
+	// flags them.
+	ExpiryWarning time.Duration
+	// Pool runs each line change and its cart version bump in one
+	// transaction; nil runs them directly on Q.
+	Pool *pgxpool.Pool
+}
+
+// CartCacheKey keys a cart in the request cache, so services that load the
+// cart themselves share the entry with this service.
+func CartCacheKey(id pgtype.UUID) string {
+	return reqcache.Key("GetCartByID", uuidString(id))
+}
+
+// ItemsCacheKey keys the lines of a cart in the request cache.
+func ItemsCacheKey(cartID pgtype.UUID) string {
+	return reqcache.Key("ListCartItems", uuidString(cartID))
+}
+
+func (s *Service) cartByID(ctx context.Context, id pgtype.UUID) (dbgen.Cart, error) {
+	return reqcache.Load(ctx, s.RequestCache, CartCacheKey(id), func(ctx context.Context) (dbgen.Cart, error) {
+		return s.Q.GetCartByID(ctx, id)
+	})
+}
+
+func (s *Service) cartItems(ctx context.Context, cartID pgtype.UUID) ([]dbgen.CartItem, error) {
+	return reqcache.Load(ctx, s.RequestCache, ItemsCacheKey(cartID), func(ctx context.Context) ([]dbgen.CartItem, error) {
+		return s.Q.ListCartItems(ctx, cartID)
+	})
 }
 
 func (s *Service) resolveTenant(ctx context.Context) pgtype.UUID {
@@ -175,44 +224,75 @@ func (s *Service) AddItem(ctx context.Context, cartID string, productID string,
 			return fmt.Errorf("parse variant id: %w", err)
 		}
 	}
+	product, err := s.Q.GetProductForCart(ctx, pID)
+	if err != nil {
+		return err
+	}
+	availability := s.availability(product)
+	if !catalog.Sellable(availability.Status) {
+		err := common.NewAppError(common.CodeProductNotAvailable, fmt.Sprintf("%s is not available to order", product.Title), http.StatusUnprocessableEntity, nil)
+		err.Details = map[string]any{"availability": availability}
+		return err
+	}
+	// Preorders are taken ahead of stock, so they skip the stock check.
+	preorder := availability.Status == catalog.AvailabilityPreorder
+	if !vID.Valid {
+		// A product sold through variants resolves to its default variant so
+		// the line is priced and stocked like that variant, not the base row.
+		switch {
+		case product.DefaultVariantID.Valid:
+			vID = product.DefaultVariantID
+		case product.HasVariants:
+			return common.NewAppError(common.CodeVariantRequired, "product has variants; choose a variantId", http.StatusUnprocessableEntity, nil)
+		}
+	}
 
-	expires := pgtype.Timestamptz{Time: s.now().Add(s.ttl()), Valid: true}
 	item, err := s.Q.FindCartItemByProductVariant(ctx, dbgen.FindCartItemByProductVariantParams{
 		CartID:    cID,
 		ProductID: pID,
 		VariantID: vID,
 	})
-	if err == nil {
-		newQty := item.Qty + int32(qty)
-		newSubtotal := int64(newQty) * item.UnitPrice
-		if _, err := s.Q.UpdateCartItemQty(ctx, dbgen.UpdateCartItemQtyParams{ID: item.ID, Qty: newQty, Subtotal: newSubtotal}); err != nil {
-			return err
-		}
-		_ = s.Q.TouchCart(ctx, dbgen.TouchCartParams{ID: cID, ExpiresAt: expires})
-		return nil
-	}
-	if !errors.Is(err, pgx.ErrNoRows) {
+	exists := err == nil
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
 		return err
 	}
 
-	product, err := s.Q.GetProductForCart(ctx, pID)
-	if err != nil {
-		return err
-	}
 	unitPrice := product.Price
+	stock := productStock(product, preorder)
 	if vID.Valid {
-		variant, err := s.Q.GetVariantForCart(ctx, vID)
+		unitPrice, stock, err = s.variantStock(ctx, pID, vID, product.Title, preorder)
 		if err != nil {
 			return err
 		}
-		if !uuidEqual(variant.ProductID, pID) {
-			return fmt.Errorf("variant does not belong to product: %w", ErrInvalidInput)
-		}
-		unitPrice = variant.Price
-		if variant.Stock <= 0 {
-			return fmt.Errorf("variant out of stock: %w", ErrInvalidInput)
-		}
 	}
+	lineQty, newLines := qty, 1
+	if exists {
+		lineQty, newLines = int(item.Qty)+qty, 0
+	}
+	if err := s.Limits.checkLine(lineQty); err != nil {
+		return err
+	}
+	if err := stock.check(lineQty); err != nil {
+		return err
+	}
+	if err := s.checkCart(ctx, cID, newLines, qty); err != nil {
+		return err
+	}
+
+	if exists {
+		newQty := int32(lineQty)
+		newSubtotal := int64(newQty) * item.UnitPrice
+		return s.changeCart(ctx, cID, func(q *dbgen.Queries) error {
+			if _, err := q.UpdateCartItemQty(ctx, dbgen.UpdateCartItemQtyParams{ID: item.ID, Qty: newQty, Subtotal: newSubtotal, Version: item.Version}); err != nil {
+				if errors.Is(err, pgx.ErrNoRows) {
+					return errConcurrentUpdate("cart item")
+				}
+				return err
+			}
+			return nil
+		})
+	}
+
 	if unitPrice < 0 {
 		unitPrice = 0
 	}
@@ -220,23 +300,24 @@ func (s *Service) AddItem(ctx context.Context, cartID string, productID string,
 	if subtotal < 0 {
 		subtotal = 0
 	}
-	if _, err := s.Q.CreateCartItem(ctx, dbgen.CreateCartItemParams{
-		CartID:    cID,
-		ProductID: pID,
-		VariantID: vID,
-		Title:     product.Title,
-		Slug:      product.Slug,
-		Qty:       int32(qty),
-		UnitPrice: unitPrice,
-		Subtotal:  subtotal,
-	}); err != nil {
+	return s.changeCart(ctx, cID, func(q *dbgen.Queries) error {
+		_, err := q.CreateCartItem(ctx, dbgen.CreateCartItemParams{
+			CartID:    cID,
+			ProductID: pID,
+			VariantID: vID,
+			Title:     product.Title,
+			Slug:      product.Slug,
+			Qty:       int32(qty),
+			UnitPrice: unitPrice,
+			Subtotal:  subtotal,
+		})
 		return err
-	}
-	_ = s.Q.TouchCart(ctx, dbgen.TouchCartParams{ID: cID, ExpiresAt: expires})
-	return nil
+	})
 }
 
-// UpdateQty updates the quantity for a cart item.
+// UpdateQty updates the quantity for a cart item. The write only applies if
+// the item is unchanged since it was read; otherwise a CONFLICT error is
+// returned.
 func (s *Service) UpdateQty(ctx context.Context, itemID string, qty int) error {
 	if s == nil || s.Q == nil {
 		return errors.New("cart service not configured")
@@ -255,14 +336,19 @@ func (s *Service) UpdateQty(ctx context.Context, itemID string, qty int) error {
 		}
 		return err
 	}
-	newSubtotal := int64(qty) * item.UnitPrice
-	_, err = s.Q.UpdateCartItemQty(ctx, dbgen.UpdateCartItemQtyParams{ID: item.ID, Qty: int32(qty), Subtotal: newSubtotal})
-	if err != nil {
+	if err := s.checkUpdate(ctx, item, qty); err != nil {
 		return err
 	}
-	expires := pgtype.Timestamptz{Time: s.now().Add(s.ttl()), Valid: true}
-	_ = s.Q.TouchCart(ctx, dbgen.TouchCartParams{ID: item.CartID, ExpiresAt: expires})
-	return nil
+	newSubtotal := int64(qty) * item.UnitPrice
+	return s.changeCart(ctx, item.CartID, func(q *dbgen.Queries) error {
+		if _, err := q.UpdateCartItemQty(ctx, dbgen.UpdateCartItemQtyParams{ID: item.ID, Qty: int32(qty), Subtotal: newSubtotal, Version: item.Version}); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return errConcurrentUpdate("cart item")
+			}
+			return err
+		}
+		return nil
+	})
 }
 
 // RemoveItem deletes a cart item.
@@ -278,15 +364,14 @@ func (s *Service) RemoveItem(ctx context.Context, cartID string, itemID string)
 	if err != nil {
 		return fmt.Errorf("parse item id: %w", err)
 	}
-	if err := s.Q.DeleteCartItem(ctx, dbgen.DeleteCartItemParams{ID: iID, CartID: cID}); err != nil {
-		return err
-	}
-	expires := pgtype.Timestamptz{Time: s.now().Add(s.ttl()), Valid: true}
-	_ = s.Q.TouchCart(ctx, dbgen.TouchCartParams{ID: cID, ExpiresAt: expires})
-	return nil
+	return s.changeCart(ctx, cID, func(q *dbgen.Queries) error {
+		return q.DeleteCartItem(ctx, dbgen.DeleteCartItemParams{ID: iID, CartID: cID})
+	})
 }
 
 // ApplyVoucher validates and attaches a voucher to the cart returning the applied discount amount.
+// If the cart's items change while the voucher is evaluated, nothing is
+// applied and a CONFLICT error is returned.
 func (s *Service) ApplyVoucher(ctx context.Context, cartID string, code string) (int64, error) {
 	if s == nil || s.Q == nil {
 		return 0, errors.New("cart service not configured")
@@ -298,29 +383,25 @@ func (s *Service) ApplyVoucher(ctx context.Context, cartID string, code string)
 	if err != nil {
 		return 0, fmt.Errorf("parse cart id: %w", err)
 	}
-	row, err := s.Q.GetCartByID(ctx, cID)
+	cart, err := s.Q.GetCartByID(ctx, cID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return 0, ErrNotFound
 		}
 		return 0, err
 	}
-	cart := dbgen.Cart{
-		ID:                 row.ID,
-		UserID:             row.UserID,
-		AnonID:             row.AnonID,
-		AppliedVoucherCode: row.AppliedVoucherCode,
-		CreatedAt:          row.CreatedAt,
-		UpdatedAt:          row.UpdatedAt,
-		ExpiresAt:          row.ExpiresAt,
-	}
 	discount, voucher, err := s.evaluateVoucher(ctx, cart, code)
 	if err != nil {
 		return 0, err
 	}
-	if err := s.Q.UpdateCartVoucher(ctx, dbgen.UpdateCartVoucherParams{ID: cart.ID, AppliedVoucherCode: pgtype.Text{String: voucher.Code, Valid: true}}); err != nil {
+	updated, err := s.Q.UpdateCartVoucher(ctx, dbgen.UpdateCartVoucherParams{ID: cart.ID, AppliedVoucherCode: pgtype.Text{String: voucher.Code, Valid: true}, Version: cart.Version})
+	if err != nil {
 		return 0, err
 	}
+	if updated == 0 {
+		return 0, errConcurrentUpdate("cart")
+	}
+	reqcache.Forget(ctx, CartCacheKey(cart.ID))
 	expires := pgtype.Timestamptz{Time: s.now().Add(s.ttl()), Valid: true}
 	_ = s.Q.TouchCart(ctx, dbgen.TouchCartParams{ID: cart.ID, ExpiresAt: expires})
 	return discount, nil
@@ -335,9 +416,21 @@ func (s *Service) RemoveVoucher(ctx context.Context, cartID string) error {
 	if err != nil {
 		return fmt.Errorf("parse cart id: %w", err)
 	}
-	if err := s.Q.UpdateCartVoucher(ctx, dbgen.UpdateCartVoucherParams{ID: cID, AppliedVoucherCode: pgtype.Text{}}); err != nil {
+	cart, err := s.Q.GetCartByID(ctx, cID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrNotFound
+		}
 		return err
 	}
+	updated, err := s.Q.UpdateCartVoucher(ctx, dbgen.UpdateCartVoucherParams{ID: cID, AppliedVoucherCode: pgtype.Text{}, Version: cart.Version})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return errConcurrentUpdate("cart")
+	}
+	reqcache.Forget(ctx, CartCacheKey(cID))
 	expires := pgtype.Timestamptz{Time: s.now().Add(s.ttl()), Valid: true}
 	_ = s.Q.TouchCart(ctx, dbgen.TouchCartParams{ID: cID, ExpiresAt: expires})
 	return nil
@@ -372,25 +465,24 @@ func (s *Service) Merge(ctx context.Context, guestCartID string, userID string)
 	if err != nil {
 		return "", err
 	}
+	userItems, err := s.Q.ListCartItems(ctx, userCart.ID)
+	if err != nil {
+		return "", err
+	}
+	existing := make(map[string]dbgen.CartItem, len(userItems))
+	for _, item := range userItems {
+		existing[lineKey(item.ProductID, item.VariantID)] = item
+	}
+	var creates []dbgen.CreateCartItemsParams
+	var updates []dbgen.UpdateCartItemsQtyParams
 	for _, item := range guestItems {
-		existing, err := s.Q.FindCartItemByProductVariant(ctx, dbgen.FindCartItemByProductVariantParams{
-			CartID:    userCart.ID,
-			ProductID: item.ProductID,
-			VariantID: item.VariantID,
-		})
-		if err == nil {
-			if existing.Qty < item.Qty {
-				_, err = s.Q.UpdateCartItemQty(ctx, dbgen.UpdateCartItemQtyParams{ID: existing.ID, Qty: item.Qty, Subtotal: int64(item.Qty) * existing.UnitPrice})
-				if err != nil {
-					return "", err
-				}
+		if current, ok := existing[lineKey(item.ProductID, item.VariantID)]; ok {
+			if current.Qty < item.Qty {
+				updates = append(updates, dbgen.UpdateCartItemsQtyParams{ID: current.ID, Qty: item.Qty, Subtotal: int64(item.Qty) * current.UnitPrice})
 			}
 			continue
 		}
-		if !errors.Is(err, pgx.ErrNoRows) {
-			return "", err
-		}
-		if _, err := s.Q.CreateCartItem(ctx, dbgen.CreateCartItemParams{
+		creates = append(creates, dbgen.CreateCartItemsParams{
 			CartID:    userCart.ID,
 			ProductID: item.ProductID,
 			VariantID: item.VariantID,
@@ -399,48 +491,152 @@ func (s *Service) Merge(ctx context.Context, guestCartID string, userID string)
 			Qty:       item.Qty,
 			UnitPrice: item.UnitPrice,
 			Subtotal:  item.Subtotal,
-		}); err != nil {
-			return "", err
+		})
+	}
+	err = s.changeCart(ctx, userCart.ID, func(q *dbgen.Queries) error {
+		if len(updates) > 0 {
+			if err := execBatch(q.UpdateCartItemsQty(ctx, updates).Exec); err != nil {
+				return err
+			}
+		}
+		if len(creates) > 0 {
+			return execBatch(q.CreateCartItems(ctx, creates).Exec)
 		}
+		return nil
+	})
+	if err != nil {
+		return "", err
 	}
-	expires := pgtype.Timestamptz{Time: s.now().Add(s.ttl()), Valid: true}
-	_ = s.Q.TouchCart(ctx, dbgen.TouchCartParams{ID: userCart.ID, ExpiresAt: expires})
 	_ = s.Q.TouchCart(ctx, dbgen.TouchCartParams{ID: guestCart.ID, ExpiresAt: pgtype.Timestamptz{Time: s.now(), Valid: true}})
-	_ = s.Q.UpdateCartVoucher(ctx, dbgen.UpdateCartVoucherParams{ID: guestCart.ID, AppliedVoucherCode: pgtype.Text{}})
+	_, _ = s.Q.UpdateCartVoucher(ctx, dbgen.UpdateCartVoucherParams{ID: guestCart.ID, AppliedVoucherCode: pgtype.Text{}, Version: guestCart.Version})
 	_ = s.Q.TransferCartToUser(ctx, dbgen.TransferCartToUserParams{ID: guestCart.ID, UserID: uID})
 	return uuidString(userCart.ID), nil
 }
 
-func (s *Service) itemEligible(ctx context.Context, item dbgen.CartItem, voucher dbgen.Voucher) (bool, error) {
-	if len(voucher.ProductIds) == 0 && len(voucher.CategoryIds) == 0 && len(voucher.BrandIds) == 0 {
-		return true, nil
+// changeCart applies write to the lines of cartID and bumps the cart version
+// in one transaction. The bump goes first, so a concurrent voucher apply that
+// read the old lines waits for the cart row lock and then fails its version
+// check instead of committing against stale lines.
+func (s *Service) changeCart(ctx context.Context, cartID pgtype.UUID, write func(q *dbgen.Queries) error) error {
+	q := s.Q
+	var tx pgx.Tx
+	if s.Pool != nil {
+		var err error
+		tx, err = s.Pool.BeginTx(ctx, pgx.TxOptions{})
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = tx.Rollback(ctx)
+		}()
+		q = s.Q.WithTx(tx)
 	}
-	product, err := s.Q.GetProductForCart(ctx, item.ProductID)
+	expires := pgtype.Timestamptz{Time: s.now().Add(s.ttl()), Valid: true}
+	if err := q.MarkCartChanged(ctx, dbgen.MarkCartChangedParams{ID: cartID, ExpiresAt: expires}); err != nil {
+		return fmt.Errorf("mark cart changed: %w", err)
+	}
+	if err := write(q); err != nil {
+		return err
+	}
+	if tx != nil {
+		if err := tx.Commit(ctx); err != nil {
+			return err
+		}
+	}
+	// The cart's version and lines changed, so neither may be served from
+	// the request cache for the rest of the request.
+	reqcache.Forget(ctx, CartCacheKey(cartID))
+	reqcache.Forget(ctx, ItemsCacheKey(cartID))
+	return nil
+}
+
+// lineKey identifies a cart line by its product and optional variant.
+func lineKey(productID, variantID pgtype.UUID) string {
+	return uuidString(productID) + "/" + uuidString(variantID)
+}
+
+// execBatch drains a sqlc batch result and reports the first failure.
+func execBatch(exec func(func(int, error))) error {
+	var batchErr error
+	exec(func(_ int, err error) {
+		if err != nil && batchErr == nil {
+			batchErr = err
+		}
+	})
+	return batchErr
+}
+
+// eligibleSubtotal sums the items covered by the voucher's scope.
+func (s *Service) eligibleSubtotal(ctx context.Context, items []dbgen.CartItem, voucher dbgen.Voucher) (int64, error) {
+	covered, err := s.VoucherCoverage(ctx, items, voucher)
 	if err != nil {
-		return false, err
+		return 0, err
+	}
+	var eligible int64
+	for i, it := range items {
+		if covered == nil || covered[i] {
+			eligible += it.Subtotal
+		}
 	}
-	if len(voucher.ProductIds) > 0 {
-		for _, el := range voucher.ProductIds {
-			if uuidEqual(el, item.ProductID) {
-				return true, nil
+	return eligible, nil
+}
+
+// VoucherCoverage reports, in item order, which cart items fall within the
+// voucher's product, category, or brand scope. A nil slice means the voucher
+// is unscoped and covers every item. Category and brand scopes are resolved with
+// a single query for every product in the cart.
+func (s *Service) VoucherCoverage(ctx context.Context, items []dbgen.CartItem, voucher dbgen.Voucher) ([]bool, error) {
+	if len(voucher.ProductIds) == 0 && len(voucher.CategoryIds) == 0 && len(voucher.BrandIds) == 0 {
+		return nil, nil
+	}
+	var scopes map[[16]byte]dbgen.ListProductScopesByIDsRow
+	if len(voucher.CategoryIds) > 0 || len(voucher.BrandIds) > 0 {
+		ids := make([]pgtype.UUID, 0, len(items))
+		seen := make(map[[16]byte]struct{}, len(items))
+		for _, it := range items {
+			if _, ok := seen[it.ProductID.Bytes]; ok {
+				continue
 			}
+			seen[it.ProductID.Bytes] = struct{}{}
+			ids = append(ids, it.ProductID)
+		}
+		rows, err := s.Q.ListProductScopesByIDs(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		scopes = make(map[[16]byte]dbgen.ListProductScopesByIDsRow, len(rows))
+		for _, row := range rows {
+			scopes[row.ID.Bytes] = row
+		}
+	}
+	covered := make([]bool, len(items))
+	for i, it := range items {
+		covered[i] = itemEligible(it, scopes[it.ProductID.Bytes], voucher)
+	}
+	return covered, nil
+}
+
+func itemEligible(item dbgen.CartItem, product dbgen.ListProductScopesByIDsRow, voucher dbgen.Voucher) bool {
+	for _, el := range voucher.ProductIds {
+		if uuidEqual(el, item.ProductID) {
+			return true
 		}
 	}
-	if len(voucher.CategoryIds) > 0 && product.CategoryID.Valid {
+	if product.CategoryID.Valid {
 		for _, el := range voucher.CategoryIds {
 			if uuidEqual(el, product.CategoryID) {
-				return true, nil
+				return true
 			}
 		}
 	}
-	if len(voucher.BrandIds) > 0 && product.BrandID.Valid {
+	if product.BrandID.Valid {
 		for _, el := range voucher.BrandIds {
 			if uuidEqual(el, product.BrandID) {
-				return true, nil
+				return true
 			}
 		}
 	}
-	return false, nil
+	return false
 }
 
 func (s *Service) evaluateVoucher(ctx context.Context, cart dbgen.Cart, code string) (int64, dbgen.Voucher, error) {
@@ -470,7 +666,7 @@ func (s *Service) evaluateVoucher(ctx context.Context, cart dbgen.Cart, code str
 		limit = voucher.PerUserLimit.Int32
 	}
 	if limit > 0 && cart.UserID.Valid {
-		used, err := s.Q.CountVoucherUsageByUser(ctx, dbgen.CountVoucherUsageByUserParams{VoucherID: voucher.ID, UserID: cart.UserID})
+		used, err := s.Q.CountVoucherUsageByUser(ctx, dbgen.CountVoucherUsageByUserParams{VoucherID: voucher.ID, UserID: cart.UserID, ExcludeReleased: s.VoucherReleaseOnCancel})
 		if err != nil {
 			return 0, dbgen.Voucher{}, err
 		}
@@ -486,15 +682,9 @@ func (s *Service) evaluateVoucher(ctx context.Context, cart dbgen.Cart, code str
 	hasScope := len(voucher.ProductIds) > 0 || len(voucher.CategoryIds) > 0
 	hasBrandScope := len(voucher.BrandIds) > 0
 	if hasScope || hasBrandScope {
-		eligible = 0
-		for _, it := range items {
-			allowed, err := s.itemEligible(ctx, it, voucher)
-			if err != nil {
-				return 0, dbgen.Voucher{}, err
-			}
-			if allowed {
-				eligible += it.Subtotal
-			}
+		eligible, err = s.eligibleSubtotal(ctx, items, voucher)
+		if err != nil {
+			return 0, dbgen.Voucher{}, err
 		}
 		if eligible == 0 {
 			return 0, dbgen.Voucher{}, fmt.Errorf("voucher not applicable: %w", ErrInvalidInput)
@@ -507,7 +697,7 @@ func (s *Service) evaluateVoucher(ctx context.Context, cart dbgen.Cart, code str
 		if !voucher.PercentBps.Valid || voucher.PercentBps.Int32 <= 0 {
 			return 0, dbgen.Voucher{}, fmt.Errorf("invalid percent voucher: %w", ErrInvalidInput)
 		}
-		discount = (eligible * int64(voucher.PercentBps.Int32)) / 10000
+		discount = s.Rounding.ApplyBps(eligible, int(voucher.PercentBps.Int32))
 	default:
 		discount = voucher.Value
 	}
@@ -521,7 +711,7 @@ func (s *Service) evaluateVoucher(ctx context.Context, cart dbgen.Cart, code str
 }
 
 func (s *Service) loadCartItems(ctx context.Context, cartID pgtype.UUID) ([]dbgen.CartItem, int64, error) {
-	items, err := s.Q.ListCartItems(ctx, cartID)
+	items, err := s.cartItems(ctx, cartID)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -570,7 +760,7 @@ func (s *Service) EvaluateVoucher(ctx context.Context, cartID pgtype.UUID, code
 	if s == nil {
 		return 0, dbgen.Voucher{}, errors.New("cart service not configured")
 	}
-	row, err := s.Q.GetCartByID(ctx, cartID)
+	row, err := s.cartByID(ctx, cartID)
 	if err != nil {
 		return 0, dbgen.Voucher{}, err
 	}
@@ -0,0 +1,577 @@
+package cart
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/noah-isme/backend-toko/internal/catalog"
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/reqcache"
+)
+
+// countingDB is a dbgen.DBTX that serves canned rows keyed by sqlc query name
+// and counts every round-trip, keeping the arguments of the last call to each
+// query. Rows are structs whose fields are scanned in declaration order, which
+// matches the column order sqlc generates. Exec succeeds only for
+// MarkCartChanged, which every line change runs, and the queries named in
+// execs.
+type countingDB struct {
+	rows  map[string][]any
+	calls map[string]int
+	args  map[string][]any
+	execs map[string]bool
+}
+
+func (d *countingDB) record(sql string, args []any) []any {
+	name := strings.Fields(strings.TrimPrefix(sql, "-- name:"))[0]
+	if d.calls == nil {
+		d.calls = map[string]int{}
+		d.args = map[string][]any{}
+	}
+	d.calls[name]++
+	d.args[name] = args
+	return d.rows[name]
+}
+
+func (d *countingDB) total() int {
+	n := 0
+	for _, c := range d.calls {
+		n += c
+	}
+	return n
+}
+
+func (d *countingDB) Exec(_ context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	d.record(sql, args)
+	name := strings.Fields(strings.TrimPrefix(sql, "-- name:"))[0]
+	if name != "MarkCartChanged" && !d.execs[name] {
+		return pgconn.CommandTag{}, errors.New("not implemented")
+	}
+	return pgconn.NewCommandTag("UPDATE 1"), nil
+}
+
+func (d *countingDB) Query(_ context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	return &structRows{items: d.record(sql, args), pos: -1}, nil
+}
+
+func (d *countingDB) QueryRow(_ context.Context, sql string, args ...interface{}) pgx.Row {
+	rows := d.record(sql, args)
+	if len(rows) == 0 {
+		return &structRows{pos: 0}
+	}
+	return &structRows{items: rows[:1], pos: 0}
+}
+
+func (d *countingDB) SendBatch(context.Context, *pgx.Batch) pgx.BatchResults {
+	return nil
+}
+
+type structRows struct {
+	pgx.Rows
+	items []any
+	pos   int
+}
+
+func (r *structRows) Next() bool {
+	r.pos++
+	return r.pos < len(r.items)
+}
+
+func (r *structRows) Scan(dest ...any) error {
+	if r.pos >= len(r.items) {
+		return pgx.ErrNoRows
+	}
+	src := reflect.ValueOf(r.items[r.pos])
+	for i, d := range dest {
+		reflect.ValueOf(d).Elem().Set(src.Field(i))
+	}
+	return nil
+}
+
+func (r *structRows) Err() error { return nil }
+func (r *structRows) Close()     {}
+
+func testUUID(a, b byte) pgtype.UUID {
+	return pgtype.UUID{Bytes: [16]byte{a, b}, Valid: true}
+}
+
+func TestEvaluateVoucherLoadsProductScopesOnce(t *testing.T) {
+	const itemCount = 30
+	category := testUUID(0xc0, 1)
+	brand := testUUID(0xb0, 1)
+
+	items := make([]any, 0, itemCount)
+	scopes := make([]any, 0, itemCount)
+	for i := 0; i < itemCount; i++ {
+		productID := testUUID(0xaa, byte(i))
+		items = append(items, dbgen.CartItem{ID: testUUID(0x01, byte(i)), ProductID: productID, Qty: 1, UnitPrice: 10000, Subtotal: 10000})
+		scope := dbgen.ListProductScopesByIDsRow{ID: productID}
+		switch {
+		case i < 10:
+			scope.CategoryID = category
+		case i < 15:
+			scope.BrandID = brand
+		}
+		scopes = append(scopes, scope)
+	}
+	db := &countingDB{rows: map[string][]any{
+		"ListCartItems": items,
+		"GetVoucherByCode": {dbgen.Voucher{
+			Code:        "SCOPED",
+			Kind:        dbgen.DiscountKindPercent,
+			PercentBps:  pgtype.Int4{Int32: 1000, Valid: true},
+			CategoryIds: []pgtype.UUID{category},
+			BrandIds:    []pgtype.UUID{brand},
+		}},
+		"ListProductScopesByIDs": scopes,
+	}}
+	svc := &Service{Q: dbgen.New(db)}
+
+	discount, _, err := svc.evaluateVoucher(context.Background(), dbgen.Cart{ID: testUUID(0xca, 1)}, "SCOPED")
+	if err != nil {
+		t.Fatalf("evaluate voucher: %v", err)
+	}
+	// 15 of 30 items are in scope: 10% of 150000.
+	if discount != 15000 {
+		t.Fatalf("expected discount 15000, got %d", discount)
+	}
+	if db.calls["ListProductScopesByIDs"] != 1 {
+		t.Fatalf("expected one batched scope lookup, got %d", db.calls["ListProductScopesByIDs"])
+	}
+	if db.total() > 3 {
+		t.Fatalf("expected at most 3 queries for a %d-item cart, got %d (%v)", itemCount, db.total(), db.calls)
+	}
+}
+
+func TestEvaluateVoucherProductScopeNeedsNoLookup(t *testing.T) {
+	inScope := testUUID(0xaa, 1)
+	db := &countingDB{rows: map[string][]any{
+		"ListCartItems": {
+			dbgen.CartItem{ProductID: inScope, Subtotal: 20000},
+			dbgen.CartItem{ProductID: testUUID(0xaa, 2), Subtotal: 50000},
+		},
+		"GetVoucherByCode": {dbgen.Voucher{Code: "PRODUCT", Kind: dbgen.DiscountKindFixedAmount, Value: 30000, ProductIds: []pgtype.UUID{inScope}}},
+	}}
+	svc := &Service{Q: dbgen.New(db)}
+
+	discount, _, err := svc.evaluateVoucher(context.Background(), dbgen.Cart{ID: testUUID(0xca, 2)}, "PRODUCT")
+	if err != nil {
+		t.Fatalf("evaluate voucher: %v", err)
+	}
+	if discount != 20000 {
+		t.Fatalf("expected discount capped at eligible subtotal 20000, got %d", discount)
+	}
+	if db.calls["ListProductScopesByIDs"] != 0 {
+		t.Fatalf("expected no scope lookup for product-only vouchers, got %d", db.calls["ListProductScopesByIDs"])
+	}
+}
+
+func TestAddItemPricesBundleFromComponents(t *testing.T) {
+	productID := testUUID(0xaa, 1)
+	bundleID := testUUID(0xbb, 1)
+	components := []any{
+		dbgen.ListBundleComponentsByVariantIDsRow{BundleVariantID: bundleID, Pricing: "sum", ComponentVariantID: testUUID(0xcc, 1), Title: "Kaos", Qty: 2, Price: 30000, Stock: 4},
+		dbgen.ListBundleComponentsByVariantIDsRow{BundleVariantID: bundleID, Pricing: "sum", ComponentVariantID: testUUID(0xcc, 2), Title: "Topi", Qty: 1, Price: 15000, Stock: 1},
+	}
+	db := &countingDB{rows: map[string][]any{
+		"GetProductForCart":                {dbgen.GetProductForCartRow{ID: productID, Title: "Paket Kaos", Slug: "paket-kaos"}},
+		"GetVariantForCart":                {dbgen.GetVariantForCartRow{ID: bundleID, ProductID: productID, Price: 99000}},
+		"ListBundleComponentsByVariantIDs": components,
+		"CreateCartItem":                   {dbgen.CartItem{}},
+	}}
+	svc := &Service{Q: dbgen.New(db)}
+	variantID := UUIDString(bundleID)
+
+	// The bundle row itself has no stock; availability comes from the components.
+	if err := svc.AddItem(context.Background(), UUIDString(testUUID(0xca, 3)), UUIDString(productID), &variantID, 1); err != nil {
+		t.Fatalf("add bundle: %v", err)
+	}
+	if got := db.args["CreateCartItem"][6]; got != int64(75000) {
+		t.Fatalf("expected bundle priced at the component sum 75000, got %v", got)
+	}
+
+	components[1] = dbgen.ListBundleComponentsByVariantIDsRow{BundleVariantID: bundleID, Pricing: "sum", ComponentVariantID: testUUID(0xcc, 2), Title: "Topi", Qty: 1, Price: 15000}
+	err := svc.AddItem(context.Background(), UUIDString(testUUID(0xca, 3)), UUIDString(productID), &variantID, 1)
+	if !errors.Is(err, ErrInvalidInput) || !strings.Contains(err.Error(), "Topi") {
+		t.Fatalf("expected the empty component to block the bundle, got %v", err)
+	}
+}
+
+func TestAddItemResolvesDefaultVariant(t *testing.T) {
+	productID := testUUID(0xaa, 2)
+	defaultID := testUUID(0xbb, 2)
+	db := &countingDB{rows: map[string][]any{
+		"GetProductForCart": {dbgen.GetProductForCartRow{ID: productID, Title: "Kaos", Slug: "kaos", Price: 50000, DefaultVariantID: defaultID, HasVariants: true}},
+		"GetVariantForCart": {dbgen.GetVariantForCartRow{ID: defaultID, ProductID: productID, Price: 65000, Stock: 3}},
+		"CreateCartItem":    {dbgen.CartItem{}},
+	}}
+	svc := &Service{Q: dbgen.New(db)}
+
+	if err := svc.AddItem(context.Background(), UUIDString(testUUID(0xca, 4)), UUIDString(productID), nil, 2); err != nil {
+		t.Fatalf("add item: %v", err)
+	}
+	args := db.args["CreateCartItem"]
+	if args[2] != defaultID {
+		t.Fatalf("expected the default variant on the line, got %v", args[2])
+	}
+	if args[6] != int64(65000) {
+		t.Fatalf("expected the line priced at the default variant, got %v", args[6])
+	}
+
+	db.rows["GetProductForCart"] = []any{dbgen.GetProductForCartRow{ID: productID, Title: "Kaos", Slug: "kaos", Price: 50000, HasVariants: true}}
+	err := svc.AddItem(context.Background(), UUIDString(testUUID(0xca, 4)), UUIDString(productID), nil, 1)
+	var appErr *common.AppError
+	if !errors.As(err, &appErr) || appErr.Code != common.CodeVariantRequired {
+		t.Fatalf("expected VARIANT_REQUIRED, got %v", err)
+	}
+}
+
+func TestAddItemRespectsAvailabilityWindow(t *testing.T) {
+	productID := testUUID(0xaa, 3)
+	variantID := testUUID(0xbb, 3)
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	product := dbgen.GetProductForCartRow{
+		ID: productID, Title: "Kaos Edisi", Slug: "kaos-edisi", Price: 50000, HasVariants: true,
+		AvailableFrom: pgtype.Timestamptz{Time: from, Valid: true},
+		AvailableTo:   pgtype.Timestamptz{Time: to, Valid: true},
+	}
+	db := &countingDB{rows: map[string][]any{
+		"GetProductForCart": {product},
+		"GetVariantForCart": {dbgen.GetVariantForCartRow{ID: variantID, ProductID: productID, Price: 50000, Stock: 3}},
+		"CreateCartItem":    {dbgen.CartItem{}},
+	}}
+	var now time.Time
+	svc := &Service{Q: dbgen.New(db), Now: func() time.Time { return now }}
+	variant := UUIDString(variantID)
+	add := func() error {
+		return svc.AddItem(context.Background(), UUIDString(testUUID(0xca, 5)), UUIDString(productID), &variant, 1)
+	}
+	expectUnavailable := func(status string) {
+		t.Helper()
+		var appErr *common.AppError
+		if err := add(); !errors.As(err, &appErr) || appErr.Code != common.CodeProductNotAvailable {
+			t.Fatalf("expected PRODUCT_NOT_AVAILABLE, got %v", err)
+		}
+		details, _ := appErr.Details.(map[string]any)
+		if got := details["availability"].(catalog.Availability).Status; got != status {
+			t.Fatalf("expected availability %q, got %q", status, got)
+		}
+	}
+
+	now = from.Add(-time.Hour)
+	expectUnavailable(catalog.AvailabilityUpcoming)
+	now = to
+	expectUnavailable(catalog.AvailabilityEnded)
+	now = from.Add(time.Hour)
+	if err := add(); err != nil {
+		t.Fatalf("add within window: %v", err)
+	}
+
+	// Preorders are accepted before the window opens even without stock.
+	product.Preorder = true
+	db.rows["GetProductForCart"] = []any{product}
+	db.rows["GetVariantForCart"] = []any{dbgen.GetVariantForCartRow{ID: variantID, ProductID: productID, Price: 50000}}
+	now = from.Add(-time.Hour)
+	if err := add(); err != nil {
+		t.Fatalf("add preorder: %v", err)
+	}
+	now = from.Add(time.Hour)
+	if err := add(); !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected the stock check once the window opens, got %v", err)
+	}
+}
+
+func expectLimit(t *testing.T, err error, limit string, max int) {
+	t.Helper()
+	var appErr *common.AppError
+	if !errors.As(err, &appErr) || appErr.Code != common.CodeCartLimitExceeded {
+		t.Fatalf("expected CART_LIMIT_EXCEEDED, got %v", err)
+	}
+	details, _ := appErr.Details.(map[string]any)
+	if details["limit"] != limit || details["max"] != max {
+		t.Fatalf("expected %s limit %d, got %v", limit, max, details)
+	}
+}
+
+func TestAddItemEnforcesCartLimits(t *testing.T) {
+	productID := testUUID(0xaa, 4)
+	variantID := testUUID(0xbb, 4)
+	db := &countingDB{rows: map[string][]any{
+		"GetProductForCart": {dbgen.GetProductForCartRow{ID: productID, Title: "Kaos", Slug: "kaos", Price: 50000, HasVariants: true}},
+		"GetVariantForCart": {dbgen.GetVariantForCartRow{ID: variantID, ProductID: productID, Price: 50000, Stock: 100}},
+		"GetCartItemTotals": {dbgen.GetCartItemTotalsRow{Items: 2, Qty: 8}},
+		"CreateCartItem":    {dbgen.CartItem{}},
+	}}
+	svc := &Service{Q: dbgen.New(db), Limits: Limits{MaxItems: 3, MaxLineQty: 5, MaxTotalQty: 10}}
+	variant := UUIDString(variantID)
+	add := func(qty int) error {
+		return svc.AddItem(context.Background(), UUIDString(testUUID(0xca, 6)), UUIDString(productID), &variant, qty)
+	}
+
+	expectLimit(t, add(6), LimitLineQty, 5)
+	expectLimit(t, add(3), LimitTotalQty, 10)
+	if err := add(2); err != nil {
+		t.Fatalf("add up to the total cap: %v", err)
+	}
+
+	db.rows["GetCartItemTotals"] = []any{dbgen.GetCartItemTotalsRow{Items: 3, Qty: 3}}
+	expectLimit(t, add(1), LimitItems, 3)
+
+	// Incrementing an existing line counts against the line cap but adds no line.
+	db.rows["FindCartItemByProductVariant"] = []any{dbgen.CartItem{ID: testUUID(0x01, 4), ProductID: productID, VariantID: variantID, Qty: 4, UnitPrice: 50000}}
+	db.rows["UpdateCartItemQty"] = []any{dbgen.CartItem{}}
+	if err := add(1); err != nil {
+		t.Fatalf("increment to the line cap: %v", err)
+	}
+	if got := db.args["UpdateCartItemQty"][1]; got != int32(5) {
+		t.Fatalf("expected the line raised to 5, got %v", got)
+	}
+	expectLimit(t, add(2), LimitLineQty, 5)
+}
+
+func expectInsufficientStock(t *testing.T, err error, available, requested int) {
+	t.Helper()
+	var appErr *common.AppError
+	if !errors.As(err, &appErr) || appErr.Code != common.CodeInsufficientStock {
+		t.Fatalf("expected INSUFFICIENT_STOCK, got %v", err)
+	}
+	details, _ := appErr.Details.(map[string]any)
+	if details["available"] != available || details["requested"] != requested {
+		t.Fatalf("expected %d available for %d requested, got %v", available, requested, details)
+	}
+	if !errors.Is(err, ErrInvalidInput) {
+		t.Fatalf("expected INSUFFICIENT_STOCK to match ErrInvalidInput")
+	}
+}
+
+func TestAddItemChecksStock(t *testing.T) {
+	productID := testUUID(0xaa, 5)
+	variantID := testUUID(0xbb, 5)
+	db := &countingDB{rows: map[string][]any{
+		"GetProductForCart": {dbgen.GetProductForCartRow{ID: productID, Title: "Kaos", Slug: "kaos", Price: 50000, HasVariants: true}},
+		"GetVariantForCart": {dbgen.GetVariantForCartRow{ID: variantID, ProductID: productID, Price: 50000, Stock: 3}},
+		"CreateCartItem":    {dbgen.CartItem{}},
+	}}
+	svc := &Service{Q: dbgen.New(db)}
+	variant := UUIDString(variantID)
+	add := func(qty int) error {
+		return svc.AddItem(context.Background(), UUIDString(testUUID(0xca, 7)), UUIDString(productID), &variant, qty)
+	}
+
+	expectInsufficientStock(t, add(4), 3, 4)
+	if err := add(3); err != nil {
+		t.Fatalf("add all remaining stock: %v", err)
+	}
+	if db.calls["GetCartItemTotals"] != 0 {
+		t.Fatalf("expected no totals lookup without cart caps, got %d", db.calls["GetCartItemTotals"])
+	}
+
+	// An increment is checked against the line's new total.
+	db.rows["FindCartItemByProductVariant"] = []any{dbgen.CartItem{ID: testUUID(0x01, 5), ProductID: productID, VariantID: variantID, Qty: 2, UnitPrice: 50000}}
+	db.rows["UpdateCartItemQty"] = []any{dbgen.CartItem{}}
+	if err := add(1); err != nil {
+		t.Fatalf("increment to the stock: %v", err)
+	}
+	expectInsufficientStock(t, add(2), 3, 4)
+}
+
+func TestAddItemChecksProductStock(t *testing.T) {
+	productID := testUUID(0xaa, 7)
+	product := dbgen.GetProductForCartRow{ID: productID, Title: "Stiker", Slug: "stiker", Price: 5000}
+	db := &countingDB{rows: map[string][]any{
+		"GetProductForCart": {product},
+		"CreateCartItem":    {dbgen.CartItem{}},
+	}}
+	svc := &Service{Q: dbgen.New(db)}
+	add := func() error {
+		return svc.AddItem(context.Background(), UUIDString(testUUID(0xca, 9)), UUIDString(productID), nil, 2)
+	}
+
+	expectInsufficientStock(t, add(), 0, 2)
+	product.InStock = true
+	db.rows["GetProductForCart"] = []any{product}
+	if err := add(); err != nil {
+		t.Fatalf("add in-stock product: %v", err)
+	}
+}
+
+func TestUpdateQtyEnforcesLimits(t *testing.T) {
+	productID := testUUID(0xaa, 6)
+	variantID := testUUID(0xbb, 6)
+	item := dbgen.CartItem{ID: testUUID(0x01, 6), CartID: testUUID(0xca, 8), ProductID: productID, VariantID: variantID, Qty: 4, UnitPrice: 50000}
+	db := &countingDB{rows: map[string][]any{
+		"GetCartItemByID":   {item},
+		"GetProductForCart": {dbgen.GetProductForCartRow{ID: productID, Title: "Kaos", Slug: "kaos", Price: 50000, HasVariants: true}},
+		"GetVariantForCart": {dbgen.GetVariantForCartRow{ID: variantID, ProductID: productID, Price: 50000, Stock: 2}},
+		"GetCartItemTotals": {dbgen.GetCartItemTotalsRow{Items: 2, Qty: 9}},
+		"UpdateCartItemQty": {dbgen.CartItem{}},
+	}}
+	svc := &Service{Q: dbgen.New(db), Limits: Limits{MaxLineQty: 6, MaxTotalQty: 10}}
+	update := func(qty int) error {
+		return svc.UpdateQty(context.Background(), UUIDString(item.ID), qty)
+	}
+
+	expectLimit(t, update(7), LimitLineQty, 6)
+	// The line already holds more than the 2 left in stock.
+	expectInsufficientStock(t, update(5), 2, 5)
+	// Reducing is always allowed and needs no stock or totals lookup.
+	lookups := db.calls["GetVariantForCart"] + db.calls["GetCartItemTotals"]
+	if err := update(1); err != nil {
+		t.Fatalf("reduce quantity: %v", err)
+	}
+	if got := db.calls["GetVariantForCart"] + db.calls["GetCartItemTotals"]; got != lookups {
+		t.Fatalf("expected no lookups when reducing, got %d more", got-lookups)
+	}
+
+	db.rows["GetVariantForCart"] = []any{dbgen.GetVariantForCartRow{ID: variantID, ProductID: productID, Price: 50000, Stock: 50}}
+	expectLimit(t, update(6), LimitTotalQty, 10)
+	if err := update(5); err != nil {
+		t.Fatalf("update up to the total cap: %v", err)
+	}
+}
+
+func TestUpdateQtyChecksStockAtBoundary(t *testing.T) {
+	productID := testUUID(0xaa, 8)
+	variantID := testUUID(0xbb, 8)
+	item := dbgen.CartItem{ID: testUUID(0x01, 8), CartID: testUUID(0xca, 10), ProductID: productID, VariantID: variantID, Qty: 1, UnitPrice: 50000}
+	db := &countingDB{rows: map[string][]any{
+		"GetCartItemByID":   {item},
+		"GetProductForCart": {dbgen.GetProductForCartRow{ID: productID, Title: "Kaos", Slug: "kaos", Price: 50000, HasVariants: true}},
+		"GetVariantForCart": {dbgen.GetVariantForCartRow{ID: variantID, ProductID: productID, Price: 50000, Stock: 4}},
+		"UpdateCartItemQty": {dbgen.CartItem{}},
+	}}
+	svc := &Service{Q: dbgen.New(db)}
+	update := func(qty int) error {
+		return svc.UpdateQty(context.Background(), UUIDString(item.ID), qty)
+	}
+
+	expectInsufficientStock(t, update(5), 4, 5)
+	if db.calls["UpdateCartItemQty"] != 0 {
+		t.Fatalf("expected no write above stock")
+	}
+	if err := update(4); err != nil {
+		t.Fatalf("update to the stock: %v", err)
+	}
+	if got := db.args["UpdateCartItemQty"][1]; got != int32(4) {
+		t.Fatalf("expected the line set to 4, got %v", got)
+	}
+}
+
+func TestTouchExtendsUnexpiredCart(t *testing.T) {
+	now := time.Date(2025, 12, 7, 10, 0, 0, 0, time.UTC)
+	cartID := testUUID(0xca, 9)
+	expires := pgtype.Timestamptz{Time: now.Add(20 * time.Minute), Valid: true}
+	db := &countingDB{
+		rows:  map[string][]any{"GetCartByID": {dbgen.Cart{ID: cartID, ExpiresAt: expires}}},
+		execs: map[string]bool{"TouchCart": true},
+	}
+	svc := &Service{Q: dbgen.New(db), TTL: 2 * time.Hour, ExpiryWarning: 30 * time.Minute, Now: func() time.Time { return now }}
+
+	if !svc.ExpiringSoon(expires) {
+		t.Fatal("expected a cart 20 minutes from expiry to be flagged")
+	}
+	got, err := svc.Touch(context.Background(), UUIDString(cartID))
+	if err != nil {
+		t.Fatalf("touch: %v", err)
+	}
+	if !got.Equal(now.Add(2 * time.Hour)) {
+		t.Fatalf("expected expiry a full TTL from now, got %v", got)
+	}
+	if db.calls["TouchCart"] != 1 {
+		t.Fatalf("expected one TouchCart, got %d", db.calls["TouchCart"])
+	}
+	if svc.ExpiringSoon(pgtype.Timestamptz{Time: got, Valid: true}) {
+		t.Fatal("a freshly extended cart should not be flagged")
+	}
+
+	svc.Now = func() time.Time { return now.Add(time.Hour) }
+	if _, err := svc.Touch(context.Background(), UUIDString(cartID)); !errors.Is(err, ErrExpired) {
+		t.Fatalf("expected ErrExpired for a lapsed cart, got %v", err)
+	}
+	if svc.ExpiringSoon(expires) {
+		t.Fatal("an expired cart is not expiring soon")
+	}
+}
+
+// unmarkableDB fails the cart version bump.
+type unmarkableDB struct {
+	countingDB
+}
+
+func (d *unmarkableDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	if strings.HasPrefix(sql, "-- name: MarkCartChanged ") {
+		d.record(sql, args)
+		return pgconn.CommandTag{}, errors.New("carts table unavailable")
+	}
+	return d.countingDB.Exec(ctx, sql, args...)
+}
+
+func TestLineChangeFailsWithoutCartVersionBump(t *testing.T) {
+	db := &unmarkableDB{countingDB{execs: map[string]bool{"DeleteCartItem": true}}}
+	svc := &Service{Q: dbgen.New(db)}
+
+	err := svc.RemoveItem(context.Background(), UUIDString(testUUID(0xca, 1)), UUIDString(testUUID(0x01, 1)))
+	if err == nil || !strings.Contains(err.Error(), "mark cart changed") {
+		t.Fatalf("expected the failed bump to be returned, got %v", err)
+	}
+	if db.calls["DeleteCartItem"] != 0 {
+		t.Fatal("the line must not change when the cart version cannot be bumped")
+	}
+}
+
+func TestApplyVoucherChecksTheLoadedCartVersion(t *testing.T) {
+	cartID := testUUID(0xca, 3)
+	db := &countingDB{
+		rows: map[string][]any{
+			"GetCartByID":      {dbgen.Cart{ID: cartID, TenantID: testUUID(0x7e, 1), Version: 4}},
+			"ListCartItems":    {dbgen.CartItem{ProductID: testUUID(0xaa, 1), Qty: 1, UnitPrice: 50000, Subtotal: 50000}},
+			"GetVoucherByCode": {dbgen.Voucher{Code: "HEMAT", Kind: dbgen.DiscountKindFixedAmount, Value: 10000}},
+		},
+		execs: map[string]bool{"UpdateCartVoucher": true, "TouchCart": true},
+	}
+	svc := &Service{Q: dbgen.New(db)}
+
+	discount, err := svc.ApplyVoucher(context.Background(), UUIDString(cartID), "HEMAT")
+	if err != nil {
+		t.Fatalf("apply voucher: %v", err)
+	}
+	if discount != 10000 {
+		t.Fatalf("expected discount 10000, got %d", discount)
+	}
+	if version := db.args["UpdateCartVoucher"][2]; version != int32(4) {
+		t.Fatalf("expected the update to check version 4, got %v", version)
+	}
+}
+
+func TestLineChangeForgetsCachedItems(t *testing.T) {
+	cartID := testUUID(0xca, 4)
+	db := &countingDB{
+		rows:  map[string][]any{"ListCartItems": {dbgen.CartItem{CartID: cartID, Qty: 1}}},
+		execs: map[string]bool{"DeleteCartItem": true},
+	}
+	svc := &Service{Q: dbgen.New(db), RequestCache: true}
+	ctx := reqcache.WithCache(context.Background())
+
+	if _, err := svc.cartItems(ctx, cartID); err != nil {
+		t.Fatalf("load items: %v", err)
+	}
+	if err := svc.RemoveItem(ctx, UUIDString(cartID), UUIDString(testUUID(0x01, 1))); err != nil {
+		t.Fatalf("remove item: %v", err)
+	}
+	if _, err := svc.cartItems(ctx, cartID); err != nil {
+		t.Fatalf("reload items: %v", err)
+	}
+	if db.calls["ListCartItems"] != 2 {
+		t.Fatalf("expected the lines to be reloaded after the change, got %d loads", db.calls["ListCartItems"])
+	}
+}
@@ -0,0 +1,92 @@
+package cart
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/noah-isme/backend-toko/internal/catalog"
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+// Stock checks here are advisory: they read current stock without reserving
+// it, so a line that passes can still fail at checkout, which reserves.
+
+// unboundedStock marks a line whose quantity is not limited by stock, e.g. a
+// preorder.
+const unboundedStock = -1
+
+// stockLevel is how many units a line may hold and what limits it.
+type stockLevel struct {
+	available int
+	item      string
+}
+
+// check rejects a line quantity above the available stock with
+// INSUFFICIENT_STOCK; the error still matches ErrInvalidInput.
+func (l stockLevel) check(qty int) error {
+	if l.available == unboundedStock || qty <= l.available {
+		return nil
+	}
+	msg := fmt.Sprintf("only %d of %s left in stock", l.available, l.item)
+	if l.available <= 0 {
+		msg = l.item + " is out of stock"
+	}
+	err := common.NewAppError(common.CodeInsufficientStock, msg, http.StatusUnprocessableEntity, fmt.Errorf("%s: %w", msg, ErrInvalidInput))
+	err.Details = map[string]any{"available": max(l.available, 0), "requested": qty}
+	return err
+}
+
+func (s *Service) availability(product dbgen.GetProductForCartRow) catalog.Availability {
+	return catalog.AvailabilityWindow{
+		From: product.AvailableFrom, To: product.AvailableTo, Preorder: product.Preorder, ShipsAt: product.PreorderShipsAt,
+	}.At(s.now())
+}
+
+// productStock is the stock of a product sold without a variant. Products only
+// track whether they are in stock, so an in-stock one is unbounded.
+func productStock(product dbgen.GetProductForCartRow, preorder bool) stockLevel {
+	if preorder || product.InStock {
+		return stockLevel{available: unboundedStock, item: product.Title}
+	}
+	return stockLevel{available: 0, item: product.Title}
+}
+
+// variantStock loads the unit price and stock of a variant of productID,
+// titled title; a bundle takes both from its components. Preorders are not
+// stock-bounded.
+func (s *Service) variantStock(ctx context.Context, productID, variantID pgtype.UUID, title string, preorder bool) (int64, stockLevel, error) {
+	variant, err := s.Q.GetVariantForCart(ctx, variantID)
+	if err != nil {
+		return 0, stockLevel{}, err
+	}
+	if !uuidEqual(variant.ProductID, productID) {
+		return 0, stockLevel{}, fmt.Errorf("variant does not belong to product: %w", ErrInvalidInput)
+	}
+	unitPrice := variant.Price
+	level := stockLevel{available: int(variant.Stock), item: title}
+	rows, err := s.Q.ListBundleComponentsByVariantIDs(ctx, []pgtype.UUID{variantID})
+	if err != nil {
+		return 0, stockLevel{}, err
+	}
+	if bundle, ok := catalog.BundlesFromRows(rows)[variantID]; ok {
+		unitPrice = bundle.UnitPrice(variant.Price)
+		level.available = bundle.Available()
+		// Name the component that runs the bundle out.
+		for _, c := range bundle.Components {
+			if c.Stock < c.Qty {
+				level.item = "bundle component " + c.Title
+				break
+			}
+		}
+	}
+	if preorder {
+		level.available = unboundedStock
+	} else if level.available < 0 {
+		level.available = 0
+	}
+	return unitPrice, level, nil
+}
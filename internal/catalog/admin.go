@@ -0,0 +1,531 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+type adminQueries interface {
+	GetProductOptionSchema(ctx context.Context, id pgtype.UUID) (dbgen.GetProductOptionSchemaRow, error)
+	UpdateProductOptionSchema(ctx context.Context, arg dbgen.UpdateProductOptionSchemaParams) error
+	SetProductDefaultVariant(ctx context.Context, arg dbgen.SetProductDefaultVariantParams) error
+	UpdateProductAvailability(ctx context.Context, arg dbgen.UpdateProductAvailabilityParams) (string, error)
+	ListVariantsByProduct(ctx context.Context, productID pgtype.UUID) ([]dbgen.ProductVariant, error)
+	CreateProductVariant(ctx context.Context, arg dbgen.CreateProductVariantParams) (dbgen.ProductVariant, error)
+	UpdateProductVariant(ctx context.Context, arg dbgen.UpdateProductVariantParams) (dbgen.ProductVariant, error)
+	ListBundleComponentsByVariantIDs(ctx context.Context, variantIds []pgtype.UUID) ([]dbgen.ListBundleComponentsByVariantIDsRow, error)
+	CountBundlesUsingComponent(ctx context.Context, componentVariantID pgtype.UUID) (int64, error)
+	UpsertVariantBundle(ctx context.Context, arg dbgen.UpsertVariantBundleParams) error
+	UpsertBundleComponents(ctx context.Context, arg dbgen.UpsertBundleComponentsParams) error
+	DeleteBundleComponentsExcept(ctx context.Context, arg dbgen.DeleteBundleComponentsExceptParams) error
+	DeleteVariantBundle(ctx context.Context, variantID pgtype.UUID) error
+	CountPriceHistoryByProduct(ctx context.Context, arg dbgen.CountPriceHistoryByProductParams) (int64, error)
+	ListPriceHistoryByProduct(ctx context.Context, arg dbgen.ListPriceHistoryByProductParams) ([]dbgen.PriceHistory, error)
+}
+
+// AdminHandler exposes product option schema, variant, and bundle management,
+// product price history, and catalog cache warming.
+type AdminHandler struct {
+	Q     adminQueries
+	Cache *Cache
+	// Service serves Warm; warming reuses its cached read path.
+	Service *Service
+}
+
+type optionsPayload struct {
+	Options []Option `json:"options"`
+}
+
+type variantPayload struct {
+	SKU        *string        `json:"sku"`
+	Price      int64          `json:"price"`
+	Stock      int32          `json:"stock"`
+	Attributes map[string]any `json:"attributes"`
+	// IsDefault, when present, makes the variant the product default (true)
+	// or clears it if it is the current default (false).
+	IsDefault *bool `json:"isDefault"`
+	// WeightGram and the dimensions feed shipping quotes; omitted values are
+	// cleared, so the default weight applies.
+	WeightGram *int32 `json:"weightGram"`
+	LengthCm   *int32 `json:"lengthCm"`
+	WidthCm    *int32 `json:"widthCm"`
+	HeightCm   *int32 `json:"heightCm"`
+}
+
+type availabilityPayload struct {
+	AvailableFrom   *time.Time `json:"availableFrom"`
+	AvailableTo     *time.Time `json:"availableTo"`
+	Preorder        bool       `json:"preorder"`
+	PreorderShipsAt *time.Time `json:"preorderShipsAt"`
+}
+
+type bundlePayload struct {
+	Pricing    string `json:"pricing"`
+	Components []struct {
+		VariantID string `json:"variantId"`
+		Qty       int32  `json:"qty"`
+	} `json:"components"`
+}
+
+// PutOptions replaces the option schema of a product. Existing variants may
+// omit newly added options until they are updated, but the schema is
+// rejected if it would drop an option or value a variant still uses.
+func (h *AdminHandler) PutOptions(w http.ResponseWriter, r *http.Request) {
+	productID, ok := h.productID(w, r)
+	if !ok {
+		return
+	}
+	var payload optionsPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		common.JSONError(w, http.StatusBadRequest, common.CodeInvalidBody, "invalid payload", nil)
+		return
+	}
+	options, err := NormalizeOptions(payload.Options)
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+	ctx := r.Context()
+	product, err := h.Q.GetProductOptionSchema(ctx, productID)
+	if err != nil {
+		writeAdminError(w, productLookupError(err))
+		return
+	}
+	variants, err := h.Q.ListVariantsByProduct(ctx, productID)
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to load variants", nil)
+		return
+	}
+	var conflicting []string
+	for _, row := range variants {
+		if _, err := validateAttributes(options, variantFromRow(row).Attributes, true); err != nil {
+			conflicting = append(conflicting, uuidString(row.ID))
+		}
+	}
+	if len(conflicting) > 0 {
+		common.JSONError(w, http.StatusConflict, common.CodeConflict, "existing variants use options or values missing from the schema", map[string]any{"variants": conflicting})
+		return
+	}
+	raw, err := json.Marshal(options)
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to encode options", nil)
+		return
+	}
+	if err := h.Q.UpdateProductOptionSchema(ctx, dbgen.UpdateProductOptionSchemaParams{ID: productID, OptionSchema: raw}); err != nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to update options", nil)
+		return
+	}
+	h.Cache.InvalidateProduct(ctx, product.Slug)
+	common.JSON(w, http.StatusOK, map[string]any{"data": map[string]any{"options": options}})
+}
+
+// PutAvailability replaces a product's availability window and preorder
+// settings. Omitted bounds leave that side of the window open.
+func (h *AdminHandler) PutAvailability(w http.ResponseWriter, r *http.Request) {
+	productID, ok := h.productID(w, r)
+	if !ok {
+		return
+	}
+	var payload availabilityPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		common.JSONError(w, http.StatusBadRequest, common.CodeInvalidBody, "invalid payload", nil)
+		return
+	}
+	if payload.AvailableFrom != nil && payload.AvailableTo != nil && !payload.AvailableFrom.Before(*payload.AvailableTo) {
+		common.JSONError(w, http.StatusBadRequest, common.CodeValidation, "availableFrom must be before availableTo", map[string]any{"field": "availableTo"})
+		return
+	}
+	if payload.PreorderShipsAt != nil && !payload.Preorder {
+		common.JSONError(w, http.StatusBadRequest, common.CodeValidation, "preorderShipsAt requires preorder", map[string]any{"field": "preorderShipsAt"})
+		return
+	}
+	window := AvailabilityWindow{
+		From:     timestamptz(payload.AvailableFrom),
+		To:       timestamptz(payload.AvailableTo),
+		Preorder: payload.Preorder,
+		ShipsAt:  timestamptz(payload.PreorderShipsAt),
+	}
+	ctx := r.Context()
+	slug, err := h.Q.UpdateProductAvailability(ctx, dbgen.UpdateProductAvailabilityParams{
+		ID:              productID,
+		AvailableFrom:   window.From,
+		AvailableTo:     window.To,
+		Preorder:        window.Preorder,
+		PreorderShipsAt: window.ShipsAt,
+	})
+	if err != nil {
+		writeAdminError(w, productLookupError(err))
+		return
+	}
+	h.Cache.InvalidateProduct(ctx, slug)
+	common.JSON(w, http.StatusOK, map[string]any{"data": window.At(time.Now())})
+}
+
+func int4(v *int32) pgtype.Int4 {
+	if v == nil {
+		return pgtype.Int4{}
+	}
+	return pgtype.Int4{Int32: *v, Valid: true}
+}
+
+func timestamptz(t *time.Time) pgtype.Timestamptz {
+	if t == nil {
+		return pgtype.Timestamptz{}
+	}
+	return pgtype.Timestamptz{Time: *t, Valid: true}
+}
+
+// CreateVariant adds a variant whose attributes satisfy the product's option schema.
+func (h *AdminHandler) CreateVariant(w http.ResponseWriter, r *http.Request) {
+	h.saveVariant(w, r, pgtype.UUID{})
+}
+
+// UpdateVariant replaces a variant's SKU, price, stock, and attributes.
+func (h *AdminHandler) UpdateVariant(w http.ResponseWriter, r *http.Request) {
+	parsed, err := uuid.Parse(strings.TrimSpace(chi.URLParam(r, "variantId")))
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, common.CodeBadRequest, "invalid variant id", map[string]any{"field": "variantId"})
+		return
+	}
+	h.saveVariant(w, r, pgtype.UUID{Bytes: parsed, Valid: true})
+}
+
+func (h *AdminHandler) saveVariant(w http.ResponseWriter, r *http.Request, variantID pgtype.UUID) {
+	productID, ok := h.productID(w, r)
+	if !ok {
+		return
+	}
+	var payload variantPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		common.JSONError(w, http.StatusBadRequest, common.CodeInvalidBody, "invalid payload", nil)
+		return
+	}
+	if payload.Price < 0 || payload.Stock < 0 {
+		common.JSONError(w, http.StatusBadRequest, common.CodeValidation, "price and stock cannot be negative", nil)
+		return
+	}
+	for _, m := range []struct {
+		field string
+		value *int32
+	}{{"weightGram", payload.WeightGram}, {"lengthCm", payload.LengthCm}, {"widthCm", payload.WidthCm}, {"heightCm", payload.HeightCm}} {
+		if m.value != nil && *m.value <= 0 {
+			common.JSONError(w, http.StatusBadRequest, common.CodeValidation, "weight and dimensions must be positive", map[string]any{"field": m.field})
+			return
+		}
+	}
+	ctx := r.Context()
+	product, err := h.Q.GetProductOptionSchema(ctx, productID)
+	if err != nil {
+		writeAdminError(w, productLookupError(err))
+		return
+	}
+	attrs, err := validateAttributes(parseOptions(product.OptionSchema), payload.Attributes, false)
+	if err != nil {
+		writeAdminError(w, err)
+		return
+	}
+	if len(attrs) > 0 {
+		siblings, err := h.Q.ListVariantsByProduct(ctx, productID)
+		if err != nil {
+			common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to load variants", nil)
+			return
+		}
+		key := attributesKey(attrs)
+		for _, row := range siblings {
+			if row.ID == variantID {
+				continue
+			}
+			existing, err := validateAttributes(parseOptions(product.OptionSchema), variantFromRow(row).Attributes, true)
+			if err == nil && attributesKey(existing) == key {
+				common.JSONError(w, http.StatusConflict, common.CodeConflict, "another variant already has these options", map[string]any{"variantId": uuidString(row.ID)})
+				return
+			}
+		}
+	}
+	raw, err := json.Marshal(attrs)
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to encode attributes", nil)
+		return
+	}
+	var sku pgtype.Text
+	if payload.SKU != nil && strings.TrimSpace(*payload.SKU) != "" {
+		sku = pgtype.Text{String: strings.TrimSpace(*payload.SKU), Valid: true}
+	}
+
+	var row dbgen.ProductVariant
+	status := http.StatusOK
+	if variantID.Valid {
+		row, err = h.Q.UpdateProductVariant(ctx, dbgen.UpdateProductVariantParams{
+			ID: variantID, ProductID: productID, Sku: sku, Price: payload.Price, Stock: payload.Stock, Attributes: raw,
+			WeightGram: int4(payload.WeightGram), LengthCm: int4(payload.LengthCm), WidthCm: int4(payload.WidthCm), HeightCm: int4(payload.HeightCm),
+		})
+	} else {
+		status = http.StatusCreated
+		row, err = h.Q.CreateProductVariant(ctx, dbgen.CreateProductVariantParams{
+			ProductID: productID, Sku: sku, Price: payload.Price, Stock: payload.Stock, Attributes: raw,
+			WeightGram: int4(payload.WeightGram), LengthCm: int4(payload.LengthCm), WidthCm: int4(payload.WidthCm), HeightCm: int4(payload.HeightCm),
+		})
+	}
+	if err != nil {
+		var pgErr *pgconn.PgError
+		switch {
+		case errors.Is(err, pgx.ErrNoRows):
+			common.JSONError(w, http.StatusNotFound, common.CodeNotFound, "variant not found", nil)
+		case errors.As(err, &pgErr) && pgErr.Code == "23505":
+			common.JSONError(w, http.StatusConflict, common.CodeAlreadyExists, "sku already exists", map[string]any{"field": "sku"})
+		default:
+			common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to save variant", nil)
+		}
+		return
+	}
+	isDefault := product.DefaultVariantID.Valid && product.DefaultVariantID == row.ID
+	if payload.IsDefault != nil && *payload.IsDefault != isDefault {
+		params := dbgen.SetProductDefaultVariantParams{ID: productID}
+		if *payload.IsDefault {
+			params.VariantID = row.ID
+		}
+		if err := h.Q.SetProductDefaultVariant(ctx, params); err != nil {
+			common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to set default variant", nil)
+			return
+		}
+		isDefault = *payload.IsDefault
+	}
+	h.Cache.InvalidateProduct(ctx, product.Slug)
+	variant := variantFromRow(row)
+	variant.IsDefault = isDefault
+	common.JSON(w, status, map[string]any{"data": variant})
+}
+
+// PutBundle turns a variant into a bundle or replaces its components. The
+// bundle is priced at its own variant price or, with "sum" pricing, at the
+// total of its components.
+func (h *AdminHandler) PutBundle(w http.ResponseWriter, r *http.Request) {
+	productID, variantID, ok := h.bundleVariant(w, r)
+	if !ok {
+		return
+	}
+	var payload bundlePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		common.JSONError(w, http.StatusBadRequest, common.CodeInvalidBody, "invalid payload", nil)
+		return
+	}
+	pricing := strings.ToLower(strings.TrimSpace(payload.Pricing))
+	if pricing == "" {
+		pricing = BundlePricingFixed
+	}
+	if pricing != BundlePricingFixed && pricing != BundlePricingSum {
+		common.JSONError(w, http.StatusBadRequest, common.CodeValidation, "pricing must be fixed or sum", map[string]any{"field": "pricing", "allowed": []string{BundlePricingFixed, BundlePricingSum}})
+		return
+	}
+	if len(payload.Components) == 0 {
+		common.JSONError(w, http.StatusBadRequest, common.CodeValidation, "a bundle needs at least one component", map[string]any{"field": "components"})
+		return
+	}
+	ids := make([]pgtype.UUID, 0, len(payload.Components))
+	qtys := make([]int32, 0, len(payload.Components))
+	seen := make(map[pgtype.UUID]struct{}, len(payload.Components))
+	for _, c := range payload.Components {
+		parsed, err := uuid.Parse(strings.TrimSpace(c.VariantID))
+		if err != nil {
+			common.JSONError(w, http.StatusBadRequest, common.CodeValidation, "invalid component variant id", map[string]any{"variantId": c.VariantID})
+			return
+		}
+		id := pgtype.UUID{Bytes: parsed, Valid: true}
+		if _, dup := seen[id]; dup || id == variantID {
+			common.JSONError(w, http.StatusBadRequest, common.CodeValidation, "components must be distinct variants other than the bundle", map[string]any{"variantId": c.VariantID})
+			return
+		}
+		if c.Qty <= 0 {
+			common.JSONError(w, http.StatusBadRequest, common.CodeValidation, "component qty must be positive", map[string]any{"variantId": c.VariantID})
+			return
+		}
+		seen[id] = struct{}{}
+		ids = append(ids, id)
+		qtys = append(qtys, c.Qty)
+	}
+
+	ctx := r.Context()
+	product, ok := h.bundleProduct(w, r, productID, variantID)
+	if !ok {
+		return
+	}
+	// Bundles do not nest: a bundle cannot contain bundles or be a component.
+	nested, err := h.Q.ListBundleComponentsByVariantIDs(ctx, ids)
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to load components", nil)
+		return
+	}
+	if len(nested) > 0 {
+		common.JSONError(w, http.StatusBadRequest, common.CodeValidation, "a component cannot itself be a bundle", map[string]any{"variantId": uuidString(nested[0].BundleVariantID)})
+		return
+	}
+	used, err := h.Q.CountBundlesUsingComponent(ctx, variantID)
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to load components", nil)
+		return
+	}
+	if used > 0 {
+		common.JSONError(w, http.StatusConflict, common.CodeConflict, "variant is a component of another bundle", nil)
+		return
+	}
+
+	// Upsert before pruning so a failed request leaves the previous
+	// components in place rather than an empty bundle.
+	if err := h.Q.UpsertVariantBundle(ctx, dbgen.UpsertVariantBundleParams{VariantID: variantID, Pricing: pricing}); err != nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to save bundle", nil)
+		return
+	}
+	if err := h.Q.UpsertBundleComponents(ctx, dbgen.UpsertBundleComponentsParams{BundleVariantID: variantID, ComponentVariantIds: ids, Qtys: qtys}); err != nil {
+		var pgErr *pgconn.PgError
+		if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+			common.JSONError(w, http.StatusBadRequest, common.CodeValidation, "component variant not found", map[string]any{"field": "components"})
+			return
+		}
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to save bundle", nil)
+		return
+	}
+	if err := h.Q.DeleteBundleComponentsExcept(ctx, dbgen.DeleteBundleComponentsExceptParams{BundleVariantID: variantID, KeepVariantIds: ids}); err != nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to save bundle", nil)
+		return
+	}
+	rows, err := h.Q.ListBundleComponentsByVariantIDs(ctx, []pgtype.UUID{variantID})
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to load components", nil)
+		return
+	}
+	h.Cache.InvalidateProduct(ctx, product.Slug)
+	bundle := BundlesFromRows(rows)[variantID]
+	if bundle == nil {
+		bundle = &Bundle{Pricing: pricing, Components: []BundleComponent{}}
+	}
+	common.JSON(w, http.StatusOK, map[string]any{"data": bundle})
+}
+
+// DeleteBundle turns a bundle back into a plain variant.
+func (h *AdminHandler) DeleteBundle(w http.ResponseWriter, r *http.Request) {
+	productID, variantID, ok := h.bundleVariant(w, r)
+	if !ok {
+		return
+	}
+	product, ok := h.bundleProduct(w, r, productID, variantID)
+	if !ok {
+		return
+	}
+	if err := h.Q.DeleteVariantBundle(r.Context(), variantID); err != nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to delete bundle", nil)
+		return
+	}
+	h.Cache.InvalidateProduct(r.Context(), product.Slug)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AdminHandler) bundleVariant(w http.ResponseWriter, r *http.Request) (pgtype.UUID, pgtype.UUID, bool) {
+	productID, ok := h.productID(w, r)
+	if !ok {
+		return pgtype.UUID{}, pgtype.UUID{}, false
+	}
+	parsed, err := uuid.Parse(strings.TrimSpace(chi.URLParam(r, "variantId")))
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, common.CodeBadRequest, "invalid variant id", map[string]any{"field": "variantId"})
+		return pgtype.UUID{}, pgtype.UUID{}, false
+	}
+	return productID, pgtype.UUID{Bytes: parsed, Valid: true}, true
+}
+
+// bundleProduct loads the product and checks that variantID belongs to it.
+func (h *AdminHandler) bundleProduct(w http.ResponseWriter, r *http.Request, productID, variantID pgtype.UUID) (dbgen.GetProductOptionSchemaRow, bool) {
+	ctx := r.Context()
+	product, err := h.Q.GetProductOptionSchema(ctx, productID)
+	if err != nil {
+		writeAdminError(w, productLookupError(err))
+		return dbgen.GetProductOptionSchemaRow{}, false
+	}
+	variants, err := h.Q.ListVariantsByProduct(ctx, productID)
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to load variants", nil)
+		return dbgen.GetProductOptionSchemaRow{}, false
+	}
+	for _, row := range variants {
+		if row.ID == variantID {
+			return product, true
+		}
+	}
+	common.JSONError(w, http.StatusNotFound, common.CodeNotFound, "variant not found", nil)
+	return dbgen.GetProductOptionSchemaRow{}, false
+}
+
+// Warm pre-loads the catalog cache, typically after a deploy or bulk import.
+// ?top= sets how many best sellers get their detail cached and ?concurrency=
+// how many load in parallel.
+func (h *AdminHandler) Warm(w http.ResponseWriter, r *http.Request) {
+	if h.Service == nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "catalog service not configured", nil)
+		return
+	}
+	opts := WarmOptions{}
+	for field, dst := range map[string]*int{"top": &opts.Top, "concurrency": &opts.Concurrency} {
+		raw := strings.TrimSpace(r.URL.Query().Get(field))
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			common.JSONError(w, http.StatusBadRequest, common.CodeBadRequest, field+" must be a positive integer", map[string]any{"field": field})
+			return
+		}
+		*dst = n
+	}
+	result, err := h.Service.Warm(r.Context(), opts)
+	if errors.Is(err, ErrCacheDisabled) {
+		common.JSONError(w, http.StatusServiceUnavailable, common.CodeUnavailable, "catalog cache not configured", nil)
+		return
+	}
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to warm catalog cache", map[string]any{"warmed": result})
+		return
+	}
+	common.JSON(w, http.StatusOK, map[string]any{"data": result})
+}
+
+func (h *AdminHandler) productID(w http.ResponseWriter, r *http.Request) (pgtype.UUID, bool) {
+	if h.Q == nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "catalog queries not configured", nil)
+		return pgtype.UUID{}, false
+	}
+	parsed, err := uuid.Parse(strings.TrimSpace(chi.URLParam(r, "id")))
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, common.CodeBadRequest, "invalid product id", map[string]any{"field": "id"})
+		return pgtype.UUID{}, false
+	}
+	return pgtype.UUID{Bytes: parsed, Valid: true}, true
+}
+
+func productLookupError(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return common.NewAppError(common.CodeNotFound, "product not found", http.StatusNotFound, err)
+	}
+	return err
+}
+
+func writeAdminError(w http.ResponseWriter, err error) {
+	var appErr *common.AppError
+	if errors.As(err, &appErr) {
+		common.JSONError(w, appErr.HTTPStatus, appErr.Code, appErr.Message, appErr.Details)
+		return
+	}
+	common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "internal error", nil)
+}
@@ -0,0 +1,357 @@
+package catalog_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/catalog"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+const adminProductID = "33333333-3333-3333-3333-333333333333"
+
+type fakeAdminQueries struct {
+	schema   []byte
+	variants []dbgen.ProductVariant
+	defaultV pgtype.UUID
+	window   dbgen.UpdateProductAvailabilityParams
+	pricing  map[pgtype.UUID]string
+	parts    map[pgtype.UUID]map[pgtype.UUID]int32
+	history  []dbgen.PriceHistory
+}
+
+func (f *fakeAdminQueries) GetProductOptionSchema(ctx context.Context, id pgtype.UUID) (dbgen.GetProductOptionSchemaRow, error) {
+	if uuid.UUID(id.Bytes).String() != adminProductID {
+		return dbgen.GetProductOptionSchemaRow{}, pgx.ErrNoRows
+	}
+	return dbgen.GetProductOptionSchemaRow{ID: id, Slug: "kaos", OptionSchema: f.schema, DefaultVariantID: f.defaultV}, nil
+}
+
+func (f *fakeAdminQueries) UpdateProductOptionSchema(ctx context.Context, arg dbgen.UpdateProductOptionSchemaParams) error {
+	f.schema = arg.OptionSchema
+	return nil
+}
+
+func (f *fakeAdminQueries) SetProductDefaultVariant(ctx context.Context, arg dbgen.SetProductDefaultVariantParams) error {
+	f.defaultV = arg.VariantID
+	return nil
+}
+
+func (f *fakeAdminQueries) UpdateProductAvailability(ctx context.Context, arg dbgen.UpdateProductAvailabilityParams) (string, error) {
+	if uuid.UUID(arg.ID.Bytes).String() != adminProductID {
+		return "", pgx.ErrNoRows
+	}
+	f.window = arg
+	return "kaos", nil
+}
+
+func (f *fakeAdminQueries) ListVariantsByProduct(ctx context.Context, productID pgtype.UUID) ([]dbgen.ProductVariant, error) {
+	return append([]dbgen.ProductVariant(nil), f.variants...), nil
+}
+
+func (f *fakeAdminQueries) CreateProductVariant(ctx context.Context, arg dbgen.CreateProductVariantParams) (dbgen.ProductVariant, error) {
+	row := dbgen.ProductVariant{
+		ID:         pgtype.UUID{Bytes: uuid.New(), Valid: true},
+		ProductID:  arg.ProductID,
+		Sku:        arg.Sku,
+		Price:      arg.Price,
+		Stock:      arg.Stock,
+		Attributes: arg.Attributes,
+	}
+	f.variants = append(f.variants, row)
+	return row, nil
+}
+
+func (f *fakeAdminQueries) UpdateProductVariant(ctx context.Context, arg dbgen.UpdateProductVariantParams) (dbgen.ProductVariant, error) {
+	for i, row := range f.variants {
+		if row.ID == arg.ID {
+			f.variants[i].Attributes = arg.Attributes
+			return f.variants[i], nil
+		}
+	}
+	return dbgen.ProductVariant{}, pgx.ErrNoRows
+}
+
+func (f *fakeAdminQueries) ListBundleComponentsByVariantIDs(ctx context.Context, variantIds []pgtype.UUID) ([]dbgen.ListBundleComponentsByVariantIDsRow, error) {
+	var rows []dbgen.ListBundleComponentsByVariantIDsRow
+	for _, id := range variantIds {
+		for component, qty := range f.parts[id] {
+			rows = append(rows, dbgen.ListBundleComponentsByVariantIDsRow{BundleVariantID: id, Pricing: f.pricing[id], ComponentVariantID: component, Qty: qty})
+		}
+	}
+	return rows, nil
+}
+
+func (f *fakeAdminQueries) CountBundlesUsingComponent(ctx context.Context, componentVariantID pgtype.UUID) (int64, error) {
+	var n int64
+	for _, parts := range f.parts {
+		if _, ok := parts[componentVariantID]; ok {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (f *fakeAdminQueries) UpsertVariantBundle(ctx context.Context, arg dbgen.UpsertVariantBundleParams) error {
+	if f.pricing == nil {
+		f.pricing = map[pgtype.UUID]string{}
+		f.parts = map[pgtype.UUID]map[pgtype.UUID]int32{}
+	}
+	f.pricing[arg.VariantID] = arg.Pricing
+	return nil
+}
+
+func (f *fakeAdminQueries) UpsertBundleComponents(ctx context.Context, arg dbgen.UpsertBundleComponentsParams) error {
+	if f.parts[arg.BundleVariantID] == nil {
+		f.parts[arg.BundleVariantID] = map[pgtype.UUID]int32{}
+	}
+	for i, id := range arg.ComponentVariantIds {
+		f.parts[arg.BundleVariantID][id] = arg.Qtys[i]
+	}
+	return nil
+}
+
+func (f *fakeAdminQueries) DeleteBundleComponentsExcept(ctx context.Context, arg dbgen.DeleteBundleComponentsExceptParams) error {
+	for id := range f.parts[arg.BundleVariantID] {
+		keep := false
+		for _, k := range arg.KeepVariantIds {
+			keep = keep || k == id
+		}
+		if !keep {
+			delete(f.parts[arg.BundleVariantID], id)
+		}
+	}
+	return nil
+}
+
+func (f *fakeAdminQueries) DeleteVariantBundle(ctx context.Context, variantID pgtype.UUID) error {
+	delete(f.pricing, variantID)
+	delete(f.parts, variantID)
+	return nil
+}
+
+func (f *fakeAdminQueries) CountPriceHistoryByProduct(ctx context.Context, arg dbgen.CountPriceHistoryByProductParams) (int64, error) {
+	return int64(len(f.history)), nil
+}
+
+func (f *fakeAdminQueries) ListPriceHistoryByProduct(ctx context.Context, arg dbgen.ListPriceHistoryByProductParams) ([]dbgen.PriceHistory, error) {
+	start := min(int(arg.PageOffset), len(f.history))
+	end := min(start+int(arg.PageLimit), len(f.history))
+	return f.history[start:end], nil
+}
+
+func adminRouter(q *fakeAdminQueries) http.Handler {
+	h := &catalog.AdminHandler{Q: q}
+	r := chi.NewRouter()
+	r.Put("/products/{id}/options", h.PutOptions)
+	r.Put("/products/{id}/availability", h.PutAvailability)
+	r.Get("/products/{id}/price-history", h.PriceHistory)
+	r.Post("/products/{id}/variants", h.CreateVariant)
+	r.Put("/products/{id}/variants/{variantId}", h.UpdateVariant)
+	r.Put("/products/{id}/variants/{variantId}/bundle", h.PutBundle)
+	r.Delete("/products/{id}/variants/{variantId}/bundle", h.DeleteBundle)
+	return r
+}
+
+func adminDo(t *testing.T, h http.Handler, method, path, body string) (int, map[string]any) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	var out map[string]any
+	if rec.Body.Len() > 0 {
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &out))
+	}
+	return rec.Code, out
+}
+
+func errorCode(body map[string]any) string {
+	errBody, _ := body["error"].(map[string]any)
+	code, _ := errBody["code"].(string)
+	return code
+}
+
+func TestAdminOptionSchemaValidatesVariants(t *testing.T) {
+	q := &fakeAdminQueries{}
+	h := adminRouter(q)
+	base := "/products/" + adminProductID
+
+	status, body := adminDo(t, h, http.MethodPut, base+"/options", `{"options":[{"name":" Size ","values":["S","M","L"]},{"name":"color","values":["Black"]}]}`)
+	require.Equal(t, http.StatusOK, status)
+	require.JSONEq(t, `[{"name":"size","values":["S","M","L"]},{"name":"color","values":["Black"]}]`, string(q.schema))
+
+	status, body = adminDo(t, h, http.MethodPut, base+"/options", `{"options":[{"name":"size","values":["S","s"]}]}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "VALIDATION_ERROR", errorCode(body))
+
+	status, body = adminDo(t, h, http.MethodPost, base+"/variants", `{"sku":"K-M","price":100,"stock":1,"attributes":{"Size":"m","COLOR":"black"}}`)
+	require.Equal(t, http.StatusCreated, status)
+	data := body["data"].(map[string]any)
+	require.Equal(t, map[string]any{"size": "M", "color": "Black"}, data["attributes"])
+
+	status, body = adminDo(t, h, http.MethodPost, base+"/variants", `{"price":100,"attributes":{"size":"XL","color":"Black"}}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "VALIDATION_ERROR", errorCode(body))
+
+	status, body = adminDo(t, h, http.MethodPost, base+"/variants", `{"price":100,"attributes":{"size":"S","fit":"slim","color":"Black"}}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "VALIDATION_ERROR", errorCode(body))
+
+	status, body = adminDo(t, h, http.MethodPost, base+"/variants", `{"price":100,"attributes":{"size":"S"}}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, []any{"color"}, body["error"].(map[string]any)["details"].(map[string]any)["missing"])
+
+	status, body = adminDo(t, h, http.MethodPost, base+"/variants", `{"price":120,"attributes":{"size":"M","color":"Black"}}`)
+	require.Equal(t, http.StatusConflict, status, "duplicate option combinations are rejected")
+	require.Equal(t, "CONFLICT", errorCode(body))
+
+	variantID := data["id"].(string)
+	status, _ = adminDo(t, h, http.MethodPut, base+"/variants/"+variantID, `{"price":100,"attributes":{"size":"L","color":"Black"}}`)
+	require.Equal(t, http.StatusOK, status, "updating a variant does not conflict with itself")
+
+	status, body = adminDo(t, h, http.MethodPut, base+"/options", `{"options":[{"name":"size","values":["S","M"]},{"name":"color","values":["Black"]}]}`)
+	require.Equal(t, http.StatusConflict, status, "values still used by variants cannot be dropped")
+	require.Equal(t, []any{variantID}, body["error"].(map[string]any)["details"].(map[string]any)["variants"])
+
+	status, _ = adminDo(t, h, http.MethodPut, base+"/options", `{"options":[{"name":"size","values":["S","M","L"]},{"name":"color","values":["Black","White"]},{"name":"fit","values":["Slim"]}]}`)
+	require.Equal(t, http.StatusOK, status, "adding options keeps existing variants valid until they are updated")
+
+	status, body = adminDo(t, h, http.MethodPost, "/products/"+uuid.NewString()+"/variants", `{"price":1,"attributes":{}}`)
+	require.Equal(t, http.StatusNotFound, status)
+	require.Equal(t, "NOT_FOUND", errorCode(body))
+}
+
+func TestAdminVariantDefault(t *testing.T) {
+	q := &fakeAdminQueries{}
+	h := adminRouter(q)
+	base := "/products/" + adminProductID
+
+	status, body := adminDo(t, h, http.MethodPost, base+"/variants", `{"price":100,"stock":1,"attributes":{},"isDefault":true}`)
+	require.Equal(t, http.StatusCreated, status)
+	first := body["data"].(map[string]any)
+	require.Equal(t, true, first["isDefault"])
+	require.Equal(t, first["id"], uuid.UUID(q.defaultV.Bytes).String())
+
+	status, body = adminDo(t, h, http.MethodPost, base+"/variants", `{"sku":"K-2","price":120,"stock":1,"attributes":{}}`)
+	require.Equal(t, http.StatusCreated, status)
+	second := body["data"].(map[string]any)
+	require.Equal(t, false, second["isDefault"], "omitting isDefault leaves the default alone")
+	require.Equal(t, first["id"], uuid.UUID(q.defaultV.Bytes).String())
+
+	status, body = adminDo(t, h, http.MethodPut, base+"/variants/"+second["id"].(string), `{"sku":"K-2","price":120,"stock":1,"attributes":{},"isDefault":true}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, true, body["data"].(map[string]any)["isDefault"])
+	require.Equal(t, second["id"], uuid.UUID(q.defaultV.Bytes).String())
+
+	status, _ = adminDo(t, h, http.MethodPut, base+"/variants/"+first["id"].(string), `{"price":100,"stock":1,"attributes":{},"isDefault":false}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, second["id"], uuid.UUID(q.defaultV.Bytes).String(), "clearing a variant that is not the default is a no-op")
+
+	status, _ = adminDo(t, h, http.MethodPut, base+"/variants/"+second["id"].(string), `{"sku":"K-2","price":120,"stock":1,"attributes":{},"isDefault":false}`)
+	require.Equal(t, http.StatusOK, status)
+	require.False(t, q.defaultV.Valid)
+}
+
+func TestAdminAvailability(t *testing.T) {
+	q := &fakeAdminQueries{}
+	h := adminRouter(q)
+	path := "/products/" + adminProductID + "/availability"
+
+	status, body := adminDo(t, h, http.MethodPut, path, `{"availableFrom":"2099-01-01T00:00:00Z","preorder":true,"preorderShipsAt":"2099-01-15T00:00:00Z"}`)
+	require.Equal(t, http.StatusOK, status)
+	data := body["data"].(map[string]any)
+	require.Equal(t, catalog.AvailabilityPreorder, data["status"])
+	require.Equal(t, "2099-01-15T00:00:00Z", data["preorderShipsAt"])
+	require.True(t, q.window.Preorder)
+	require.True(t, q.window.AvailableFrom.Valid)
+	require.False(t, q.window.AvailableTo.Valid)
+
+	status, body = adminDo(t, h, http.MethodPut, path, `{"availableFrom":"2099-02-01T00:00:00Z","availableTo":"2099-01-01T00:00:00Z"}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "VALIDATION_ERROR", errorCode(body))
+
+	status, body = adminDo(t, h, http.MethodPut, path, `{"preorderShipsAt":"2099-01-15T00:00:00Z"}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "VALIDATION_ERROR", errorCode(body))
+
+	status, _ = adminDo(t, h, http.MethodPut, "/products/"+uuid.NewString()+"/availability", `{}`)
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+func TestAdminPriceHistory(t *testing.T) {
+	product := pgtype.UUID{Bytes: uuid.MustParse(adminProductID), Valid: true}
+	variant := pgtype.UUID{Bytes: uuid.New(), Valid: true}
+	at := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	q := &fakeAdminQueries{history: []dbgen.PriceHistory{
+		{ProductID: product, VariantID: variant, OldPrice: 120, NewPrice: 100, ChangedAt: pgtype.Timestamptz{Time: at, Valid: true}},
+		{ProductID: product, OldPrice: 150, NewPrice: 120, ChangedAt: pgtype.Timestamptz{Time: at.Add(-time.Hour), Valid: true}},
+	}}
+	h := adminRouter(q)
+
+	status, body := adminDo(t, h, http.MethodGet, "/products/"+adminProductID+"/price-history?limit=1", "")
+	require.Equal(t, http.StatusOK, status)
+	data := body["data"].([]any)
+	require.Len(t, data, 1)
+	require.Equal(t, map[string]any{
+		"variantId": uuid.UUID(variant.Bytes).String(),
+		"oldPrice":  float64(120),
+		"newPrice":  float64(100),
+		"changedAt": "2025-06-01T08:00:00Z",
+	}, data[0])
+	require.Equal(t, true, body["pagination"].(map[string]any)["has_more"])
+
+	status, _ = adminDo(t, h, http.MethodGet, "/products/"+uuid.NewString()+"/price-history", "")
+	require.Equal(t, http.StatusNotFound, status)
+}
+
+func TestAdminBundleComponents(t *testing.T) {
+	kit := pgtype.UUID{Bytes: uuid.New(), Valid: true}
+	shirt := pgtype.UUID{Bytes: uuid.New(), Valid: true}
+	hat := pgtype.UUID{Bytes: uuid.New(), Valid: true}
+	q := &fakeAdminQueries{variants: []dbgen.ProductVariant{{ID: kit}, {ID: shirt}}}
+	h := adminRouter(q)
+	base := "/products/" + adminProductID + "/variants/"
+	kitPath := base + uuid.UUID(kit.Bytes).String() + "/bundle"
+	shirtID := uuid.UUID(shirt.Bytes).String()
+	hatID := uuid.UUID(hat.Bytes).String()
+
+	status, body := adminDo(t, h, http.MethodPut, kitPath, `{"pricing":"sum","components":[{"variantId":"`+shirtID+`","qty":2},{"variantId":"`+hatID+`","qty":1}]}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, "sum", body["data"].(map[string]any)["pricing"])
+	require.Len(t, body["data"].(map[string]any)["components"], 2)
+
+	status, _ = adminDo(t, h, http.MethodPut, kitPath, `{"components":[{"variantId":"`+shirtID+`","qty":3}]}`)
+	require.Equal(t, http.StatusOK, status)
+	require.Equal(t, map[pgtype.UUID]int32{shirt: 3}, q.parts[kit], "components missing from the request are removed")
+	require.Equal(t, "fixed", q.pricing[kit])
+
+	status, body = adminDo(t, h, http.MethodPut, kitPath, `{"pricing":"avg","components":[{"variantId":"`+shirtID+`","qty":1}]}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "VALIDATION_ERROR", errorCode(body))
+
+	status, body = adminDo(t, h, http.MethodPut, kitPath, `{"components":[{"variantId":"`+shirtID+`","qty":0}]}`)
+	require.Equal(t, http.StatusBadRequest, status)
+	require.Equal(t, "VALIDATION_ERROR", errorCode(body))
+
+	status, body = adminDo(t, h, http.MethodPut, base+shirtID+"/bundle", `{"components":[{"variantId":"`+hatID+`","qty":1}]}`)
+	require.Equal(t, http.StatusConflict, status, "a component cannot become a bundle")
+	require.Equal(t, "CONFLICT", errorCode(body))
+
+	status, body = adminDo(t, h, http.MethodPut, base+hatID+"/bundle", `{"components":[{"variantId":"`+shirtID+`","qty":1}]}`)
+	require.Equal(t, http.StatusNotFound, status, "the bundle variant must belong to the product")
+	require.Equal(t, "NOT_FOUND", errorCode(body))
+
+	status, _ = adminDo(t, h, http.MethodDelete, kitPath, "")
+	require.Equal(t, http.StatusNoContent, status)
+	require.Empty(t, q.parts[kit])
+}
@@ -0,0 +1,75 @@
+package catalog
+
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Availability statuses. A product is sold normally while available, taken as
+// a preorder before its window opens when preorder is enabled, and not sold
+// while upcoming or once its window has ended.
+const (
+	AvailabilityAvailable = "available"
+	AvailabilityPreorder  = "preorder"
+	AvailabilityUpcoming  = "upcoming"
+	AvailabilityEnded     = "ended"
+)
+
+// Availability describes when a product can be bought.
+type Availability struct {
+	Status          string     `json:"status"`
+	AvailableFrom   *time.Time `json:"availableFrom,omitempty"`
+	AvailableTo     *time.Time `json:"availableTo,omitempty"`
+	Preorder        bool       `json:"preorder"`
+	PreorderShipsAt *time.Time `json:"preorderShipsAt,omitempty"`
+}
+
+// AvailabilityWindow holds the stored availability columns of a product.
+type AvailabilityWindow struct {
+	From     pgtype.Timestamptz
+	To       pgtype.Timestamptz
+	Preorder bool
+	ShipsAt  pgtype.Timestamptz
+}
+
+// Status reports the availability status at now. A preorder product without
+// a start date stays a preorder until the flag is cleared.
+func (w AvailabilityWindow) Status(now time.Time) string {
+	switch {
+	case w.To.Valid && !now.Before(w.To.Time):
+		return AvailabilityEnded
+	case w.From.Valid && now.Before(w.From.Time):
+		if w.Preorder {
+			return AvailabilityPreorder
+		}
+		return AvailabilityUpcoming
+	case w.Preorder && !w.From.Valid:
+		return AvailabilityPreorder
+	}
+	return AvailabilityAvailable
+}
+
+// At returns the availability payload at now.
+func (w AvailabilityWindow) At(now time.Time) Availability {
+	out := Availability{Status: w.Status(now), Preorder: w.Preorder}
+	out.AvailableFrom = timePtr(w.From)
+	out.AvailableTo = timePtr(w.To)
+	if w.Preorder {
+		out.PreorderShipsAt = timePtr(w.ShipsAt)
+	}
+	return out
+}
+
+// Sellable reports whether a status allows adding the product to a cart.
+func Sellable(status string) bool {
+	return status == AvailabilityAvailable || status == AvailabilityPreorder
+}
+
+func timePtr(ts pgtype.Timestamptz) *time.Time {
+	if !ts.Valid {
+		return nil
+	}
+	t := ts.Time.UTC()
+	return &t
+}
@@ -0,0 +1,47 @@
+package catalog_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/catalog"
+)
+
+func TestAvailabilityWindowStatus(t *testing.T) {
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 4, 1, 0, 0, 0, 0, time.UTC)
+	ts := func(t time.Time) pgtype.Timestamptz { return pgtype.Timestamptz{Time: t, Valid: true} }
+	window := catalog.AvailabilityWindow{From: ts(from), To: ts(to)}
+	preorder := catalog.AvailabilityWindow{From: ts(from), To: ts(to), Preorder: true, ShipsAt: ts(from)}
+
+	cases := []struct {
+		name   string
+		window catalog.AvailabilityWindow
+		now    time.Time
+		want   string
+	}{
+		{"open", catalog.AvailabilityWindow{}, from, catalog.AvailabilityAvailable},
+		{"before", window, from.Add(-time.Hour), catalog.AvailabilityUpcoming},
+		{"within", window, from, catalog.AvailabilityAvailable},
+		{"after", window, to, catalog.AvailabilityEnded},
+		{"preorder before", preorder, from.Add(-time.Hour), catalog.AvailabilityPreorder},
+		{"preorder within", preorder, from.Add(time.Hour), catalog.AvailabilityAvailable},
+		{"preorder after", preorder, to.Add(time.Hour), catalog.AvailabilityEnded},
+		{"preorder without start", catalog.AvailabilityWindow{Preorder: true}, from, catalog.AvailabilityPreorder},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, tc.window.Status(tc.now))
+		})
+	}
+
+	got := preorder.At(from.Add(-time.Hour))
+	require.Equal(t, catalog.AvailabilityPreorder, got.Status)
+	require.Equal(t, from, *got.PreorderShipsAt)
+	require.Nil(t, window.At(from).PreorderShipsAt)
+	require.False(t, catalog.Sellable(catalog.AvailabilityUpcoming))
+	require.False(t, catalog.Sellable(catalog.AvailabilityEnded))
+}
@@ -0,0 +1,159 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+)
+
+// Badges derived from product data at read time.
+const (
+	BadgeSale       = "sale"
+	BadgeNew        = "new"
+	BadgeLowStock   = "low-stock"
+	BadgeBestseller = "bestseller"
+	BadgePriceDrop  = "price-drop"
+)
+
+// BadgeRules configures which badges are derived from product data. Zero
+// values disable the matching badge.
+type BadgeRules struct {
+	// Sale marks products whose compareAt price is above the price.
+	Sale bool
+	// NewWithin marks products created within this long.
+	NewWithin time.Duration
+	// LowStock marks products with stock left at or below this count.
+	LowStock int
+	// Bestsellers marks products among this many top sellers.
+	Bestsellers int
+	// PriceDropWithin marks products whose price was lowered within this
+	// long by at least PriceDropMinPercent of the previous price.
+	PriceDropWithin     time.Duration
+	PriceDropMinPercent int
+}
+
+// BadgeFacts are the product fields badge rules read.
+type BadgeFacts struct {
+	Slug      string
+	Price     int64
+	CompareAt *int64
+	CreatedAt time.Time
+	Stock     int
+	// PreviousPrice is the price before the latest change within
+	// PriceDropWithin, if any.
+	PreviousPrice *int64
+}
+
+// Apply returns the manual badges followed by the derived ones, without
+// duplicates. top holds the slugs of the current bestsellers.
+func (r BadgeRules) Apply(manual []string, facts BadgeFacts, now time.Time, top map[string]struct{}) []string {
+	var derived []string
+	if r.Sale && facts.CompareAt != nil && *facts.CompareAt > facts.Price {
+		derived = append(derived, BadgeSale)
+	}
+	if r.NewWithin > 0 && !facts.CreatedAt.IsZero() && now.Sub(facts.CreatedAt) < r.NewWithin {
+		derived = append(derived, BadgeNew)
+	}
+	if r.LowStock > 0 && facts.Stock > 0 && facts.Stock <= r.LowStock {
+		derived = append(derived, BadgeLowStock)
+	}
+	if _, ok := top[facts.Slug]; ok && r.Bestsellers > 0 {
+		derived = append(derived, BadgeBestseller)
+	}
+	if r.PriceDropWithin > 0 && facts.PreviousPrice != nil && *facts.PreviousPrice > facts.Price &&
+		(*facts.PreviousPrice-facts.Price)*100 >= int64(r.PriceDropMinPercent)**facts.PreviousPrice {
+		derived = append(derived, BadgePriceDrop)
+	}
+	if len(derived) == 0 {
+		return manual
+	}
+	out := make([]string, 0, len(manual)+len(derived))
+	seen := make(map[string]struct{}, cap(out))
+	for _, badge := range append(append([]string(nil), manual...), derived...) {
+		key := strings.ToLower(strings.TrimSpace(badge))
+		if _, dup := seen[key]; dup {
+			continue
+		}
+		seen[key] = struct{}{}
+		out = append(out, badge)
+	}
+	return out
+}
+
+// bestsellers returns the slugs of the current top sellers when the
+// bestseller badge is enabled.
+func (s *Service) bestsellers(ctx context.Context) (map[string]struct{}, error) {
+	if s.badges.Bestsellers <= 0 {
+		return nil, nil
+	}
+	slugs, err := s.queries.ListTopProductSlugs(ctx, int32(s.badges.Bestsellers))
+	if err != nil {
+		return nil, fmt.Errorf("list top products: %w", err)
+	}
+	top := make(map[string]struct{}, len(slugs))
+	for _, slug := range slugs {
+		top[slug] = struct{}{}
+	}
+	return top, nil
+}
+
+// applyItemBadges merges derived badges into list items; ids and createdAt
+// hold each item's ID and creation time.
+func (s *Service) applyItemBadges(ctx context.Context, items []ProductListItem, ids []pgtype.UUID, createdAt []pgtype.Timestamptz) error {
+	if s.badges == (BadgeRules{}) || len(items) == 0 {
+		return nil
+	}
+	top, err := s.bestsellers(ctx)
+	if err != nil {
+		return err
+	}
+	previous, err := s.previousPrices(ctx, ids...)
+	if err != nil {
+		return err
+	}
+	now := s.clock()
+	for i, item := range items {
+		facts := BadgeFacts{Slug: item.Slug, Price: int64(item.Price), CreatedAt: createdAt[i].Time, Stock: item.Stock}
+		if item.CompareAt != nil {
+			compareAt := int64(*item.CompareAt)
+			facts.CompareAt = &compareAt
+		}
+		if price, ok := previous[ids[i].Bytes]; ok {
+			facts.PreviousPrice = &price
+			items[i].PreviousPrice = (*common.Int64)(&price)
+		}
+		items[i].Badges = s.badges.Apply(item.Badges, facts, now, top)
+	}
+	return nil
+}
+
+// applyDetailBadges merges derived badges into a product detail.
+func (s *Service) applyDetailBadges(ctx context.Context, detail *ProductDetail, id pgtype.UUID, createdAt pgtype.Timestamptz) error {
+	if s.badges == (BadgeRules{}) {
+		return nil
+	}
+	top, err := s.bestsellers(ctx)
+	if err != nil {
+		return err
+	}
+	previous, err := s.previousPrices(ctx, id)
+	if err != nil {
+		return err
+	}
+	facts := BadgeFacts{Slug: detail.Slug, Price: int64(detail.Price), CreatedAt: createdAt.Time, Stock: detail.Stock}
+	if detail.CompareAt != nil {
+		compareAt := int64(*detail.CompareAt)
+		facts.CompareAt = &compareAt
+	}
+	if price, ok := previous[id.Bytes]; ok {
+		facts.PreviousPrice = &price
+		detail.PreviousPrice = (*common.Int64)(&price)
+	}
+	detail.Badges = s.badges.Apply(detail.Badges, facts, s.clock(), top)
+	return nil
+}
@@ -0,0 +1,124 @@
+package catalog_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/catalog"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+func TestBadgeRulesApply(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	rules := catalog.BadgeRules{Sale: true, NewWithin: 14 * 24 * time.Hour, LowStock: 5, Bestsellers: 10, PriceDropWithin: 30 * 24 * time.Hour, PriceDropMinPercent: 5}
+	top := map[string]struct{}{"laris": {}}
+	price := func(v int64) *int64 { return &v }
+	base := catalog.BadgeFacts{Slug: "biasa", Price: 100000, CreatedAt: now.AddDate(0, -2, 0), Stock: 50}
+
+	cases := []struct {
+		name   string
+		rules  catalog.BadgeRules
+		manual []string
+		facts  func(f catalog.BadgeFacts) catalog.BadgeFacts
+		want   []string
+	}{
+		{"none", rules, nil, func(f catalog.BadgeFacts) catalog.BadgeFacts { return f }, nil},
+		{"sale", rules, nil, func(f catalog.BadgeFacts) catalog.BadgeFacts { f.CompareAt = price(120000); return f }, []string{catalog.BadgeSale}},
+		{"compareAt not above price", rules, nil, func(f catalog.BadgeFacts) catalog.BadgeFacts { f.CompareAt = price(100000); return f }, nil},
+		{"new", rules, nil, func(f catalog.BadgeFacts) catalog.BadgeFacts { f.CreatedAt = now.AddDate(0, 0, -3); return f }, []string{catalog.BadgeNew}},
+		{"low stock", rules, nil, func(f catalog.BadgeFacts) catalog.BadgeFacts { f.Stock = 5; return f }, []string{catalog.BadgeLowStock}},
+		{"sold out is not low stock", rules, nil, func(f catalog.BadgeFacts) catalog.BadgeFacts { f.Stock = 0; return f }, nil},
+		{"bestseller", rules, nil, func(f catalog.BadgeFacts) catalog.BadgeFacts { f.Slug = "laris"; return f }, []string{catalog.BadgeBestseller}},
+		{"price drop", rules, nil, func(f catalog.BadgeFacts) catalog.BadgeFacts { f.PreviousPrice = price(110000); return f }, []string{catalog.BadgePriceDrop}},
+		{"drop below minimum", rules, nil, func(f catalog.BadgeFacts) catalog.BadgeFacts { f.PreviousPrice = price(102000); return f }, nil},
+		{"price rise", rules, nil, func(f catalog.BadgeFacts) catalog.BadgeFacts { f.PreviousPrice = price(90000); return f }, nil},
+		{"disabled rules", catalog.BadgeRules{}, []string{"promo"}, func(f catalog.BadgeFacts) catalog.BadgeFacts {
+			f.CompareAt, f.Stock, f.Slug = price(120000), 1, "laris"
+			return f
+		}, []string{"promo"}},
+		{"merged with manual", rules, []string{"promo", "Sale"}, func(f catalog.BadgeFacts) catalog.BadgeFacts {
+			f.CompareAt, f.Stock = price(120000), 2
+			return f
+		}, []string{"promo", "Sale", catalog.BadgeLowStock}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, tc.rules.Apply(tc.manual, tc.facts(base), now, top))
+		})
+	}
+}
+
+func TestListProductsDerivesBadges(t *testing.T) {
+	queries := newFakeCatalogQueries(t)
+	queries.topSlugs = []string{"kaos-hitam"}
+	svc, err := catalog.NewService(catalog.ServiceConfig{
+		Queries: queries,
+		Badges:  catalog.BadgeRules{Sale: true, NewWithin: 24 * time.Hour, Bestsellers: 1},
+	})
+	require.NoError(t, err)
+
+	params, err := svc.ParseListParams(url.Values{})
+	require.NoError(t, err)
+	result, err := svc.ListProducts(context.Background(), params)
+	require.NoError(t, err)
+
+	badges := map[string][]string{}
+	for _, item := range result.Items {
+		badges[item.Slug] = item.Badges
+	}
+	require.Equal(t, []string{"promo", "new", catalog.BadgeSale, catalog.BadgeBestseller}, badges["kaos-hitam"])
+	require.Equal(t, []string{catalog.BadgeNew}, badges["sepatu-putih"])
+
+	detail, err := svc.GetProductDetail(context.Background(), "kaos-hitam")
+	require.NoError(t, err)
+	require.Contains(t, detail.Badges, catalog.BadgeBestseller)
+	require.Contains(t, detail.Badges, catalog.BadgeSale)
+}
+
+func TestPriceDropBadgeAndTrend(t *testing.T) {
+	queries := newFakeCatalogQueries(t)
+	now := time.Now()
+	product := queries.productsBySlug["kaos-hitam"]
+	queries.previousPrices = []dbgen.ListPreviousProductPricesRow{
+		{ProductID: product.ID, OldPrice: 299000, ChangedAt: pgtype.Timestamptz{Time: now.Add(-48 * time.Hour), Valid: true}},
+	}
+	queries.priceTrend = []dbgen.ListProductPriceTrendRow{
+		{OldPrice: 299000, NewPrice: 249000, ChangedAt: pgtype.Timestamptz{Time: now.Add(-48 * time.Hour), Valid: true}},
+		{OldPrice: 319000, NewPrice: 299000, ChangedAt: pgtype.Timestamptz{Time: now.Add(-30 * 24 * time.Hour), Valid: true}, OldPriceSince: pgtype.Timestamptz{Time: now.Add(-90 * 24 * time.Hour), Valid: true}},
+	}
+	svc, err := catalog.NewService(catalog.ServiceConfig{
+		Queries:          queries,
+		Badges:           catalog.BadgeRules{PriceDropWithin: 7 * 24 * time.Hour, PriceDropMinPercent: 10},
+		PriceTrendPoints: 5,
+	})
+	require.NoError(t, err)
+
+	detail, err := svc.GetProductDetail(context.Background(), "kaos-hitam")
+	require.NoError(t, err)
+	require.Contains(t, detail.Badges, catalog.BadgePriceDrop)
+	require.NotNil(t, detail.PreviousPrice)
+	require.EqualValues(t, 299000, *detail.PreviousPrice)
+	require.Len(t, detail.PriceTrend, 3)
+	require.EqualValues(t, 319000, detail.PriceTrend[0].Price, "trend starts with the price before the oldest change")
+	require.WithinDuration(t, now.Add(-90*24*time.Hour), detail.PriceTrend[0].At, time.Second)
+	require.EqualValues(t, 299000, detail.PriceTrend[1].Price, "trend runs oldest first")
+	require.EqualValues(t, 249000, detail.PriceTrend[2].Price)
+
+	params, err := svc.ParseListParams(url.Values{})
+	require.NoError(t, err)
+	result, err := svc.ListProducts(context.Background(), params)
+	require.NoError(t, err)
+	for _, item := range result.Items {
+		if item.Slug == "kaos-hitam" {
+			require.Contains(t, item.Badges, catalog.BadgePriceDrop)
+		} else {
+			require.NotContains(t, item.Badges, catalog.BadgePriceDrop)
+			require.Nil(t, item.PreviousPrice)
+		}
+	}
+}
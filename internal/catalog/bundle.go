@@ -0,0 +1,92 @@
+package catalog
+
+import (
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+// Bundle pricing modes. A fixed bundle is sold at its own variant price; a sum
+// bundle is sold at the total price of its components.
+const (
+	BundlePricingFixed = "fixed"
+	BundlePricingSum   = "sum"
+)
+
+// BundleComponent is one variant shipped as part of a bundle.
+type BundleComponent struct {
+	VariantID string       `json:"variantId"`
+	ProductID string       `json:"productId"`
+	Title     string       `json:"title"`
+	Slug      string       `json:"slug"`
+	SKU       *string      `json:"sku,omitempty"`
+	Qty       int          `json:"qty"`
+	Price     common.Int64 `json:"price"`
+	Stock     int          `json:"stock"`
+}
+
+// Bundle describes a variant sold as a kit of component variants.
+type Bundle struct {
+	Pricing    string            `json:"pricing"`
+	Components []BundleComponent `json:"components"`
+}
+
+// Available returns how many bundles the component stock can fill. A single
+// component without enough stock makes the whole bundle unavailable.
+func (b Bundle) Available() int {
+	if len(b.Components) == 0 {
+		return 0
+	}
+	available := -1
+	for _, c := range b.Components {
+		if c.Qty <= 0 {
+			continue
+		}
+		n := max(c.Stock, 0) / c.Qty
+		if available < 0 || n < available {
+			available = n
+		}
+	}
+	return max(available, 0)
+}
+
+// UnitPrice returns the price of one bundle given the bundle variant's own price.
+func (b Bundle) UnitPrice(variantPrice int64) int64 {
+	if b.Pricing != BundlePricingSum {
+		return variantPrice
+	}
+	var total int64
+	for _, c := range b.Components {
+		total += int64(c.Qty) * int64(c.Price)
+	}
+	return total
+}
+
+// BundlesFromRows groups component rows by bundle variant ID. Variants without
+// components are not bundles and are absent from the result.
+func BundlesFromRows(rows []dbgen.ListBundleComponentsByVariantIDsRow) map[pgtype.UUID]*Bundle {
+	bundles := make(map[pgtype.UUID]*Bundle)
+	for _, row := range rows {
+		bundle, ok := bundles[row.BundleVariantID]
+		if !ok {
+			bundle = &Bundle{Pricing: row.Pricing}
+			bundles[row.BundleVariantID] = bundle
+		}
+		component := BundleComponent{
+			VariantID: uuidString(row.ComponentVariantID),
+			ProductID: uuidString(row.ProductID),
+			Title:     row.Title,
+			Slug:      row.Slug,
+			Qty:       int(row.Qty),
+			Price:     common.Int64(row.Price),
+			Stock:     int(row.Stock),
+		}
+		if row.Sku.Valid {
+			sku := row.Sku.String
+			component.SKU = &sku
+		}
+		bundle.Components = append(bundle.Components, component)
+	}
+	return bundles
+}
@@ -0,0 +1,68 @@
+package catalog_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/catalog"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+func TestBundleAvailabilityAndPricing(t *testing.T) {
+	bundle := catalog.Bundle{Pricing: catalog.BundlePricingSum, Components: []catalog.BundleComponent{
+		{Qty: 2, Price: 30000, Stock: 9},
+		{Qty: 1, Price: 15000, Stock: 7},
+	}}
+	require.Equal(t, 4, bundle.Available())
+	require.Equal(t, int64(75000), bundle.UnitPrice(99000))
+
+	bundle.Pricing = catalog.BundlePricingFixed
+	require.Equal(t, int64(99000), bundle.UnitPrice(99000))
+
+	bundle.Components[1].Stock = 0
+	require.Equal(t, 0, bundle.Available(), "one empty component blocks the bundle")
+}
+
+func TestProductDetailExposesBundleComponents(t *testing.T) {
+	queries := newFakeCatalogQueries(t)
+	productID := mustUUID(t, "33333333-3333-3333-3333-333333333333")
+	bundleID := mustUUID(t, "88888888-8888-8888-8888-888888888888")
+	componentID := mustUUID(t, "55555555-5555-5555-5555-555555555555")
+	queries.variants[uuidString(productID)] = append(queries.variants[uuidString(productID)], dbgen.ProductVariant{
+		ID:         bundleID,
+		ProductID:  productID,
+		Sku:        pgtype.Text{String: "KIT", Valid: true},
+		Price:      1,
+		Stock:      100,
+		Attributes: []byte(`{"size":"M"}`),
+	})
+	queries.bundles = []dbgen.ListBundleComponentsByVariantIDsRow{{
+		BundleVariantID:    bundleID,
+		Pricing:            catalog.BundlePricingSum,
+		ComponentVariantID: componentID,
+		ProductID:          productID,
+		Title:              "Kaos Hitam",
+		Slug:               "kaos-hitam",
+		Qty:                3,
+		Price:              249000,
+		Stock:              10,
+	}}
+	svc, err := catalog.NewService(catalog.ServiceConfig{Queries: queries, DefaultPage: 1, DefaultLimit: 20, MaxLimit: 100})
+	require.NoError(t, err)
+
+	detail, err := svc.GetProductDetail(context.Background(), "kaos-hitam")
+	require.NoError(t, err)
+	require.Len(t, detail.Variants, 2)
+	require.Nil(t, detail.Variants[0].Bundle)
+	kit := detail.Variants[1]
+	require.NotNil(t, kit.Bundle)
+	require.Len(t, kit.Bundle.Components, 1)
+	require.Equal(t, uuidString(componentID), kit.Bundle.Components[0].VariantID)
+	require.Equal(t, int64(747000), int64(kit.Price))
+	require.Equal(t, 3, kit.Stock, "stock is limited by the component, not the bundle row")
+	require.Equal(t, 13, detail.Stock)
+	require.True(t, detail.InStock)
+}
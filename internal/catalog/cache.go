@@ -7,13 +7,17 @@ import (
 	"time"
 
 	"github.com/redis/go-redis/v9"
+
+	"github.com/noah-isme/backend-toko/internal/tenant"
 )
 
 // Cache wraps Redis helpers for JSON payloads and exposes convenience invalidation helpers.
 type Cache struct {
-	client *redis.Client
-	ttl    time.Duration
-	prefix string
+	client  *redis.Client
+	ttl     time.Duration
+	prefix  string
+	locales []string
+	scope   tenant.KeyScope
 }
 
 // NewCache constructs a cache helper with an optional namespace prefix.
@@ -39,14 +43,58 @@ func (c *Cache) key(parts ...string) string {
 	return strings.Join(sanitized, ":")
 }
 
-// ProductListKey returns the cache key for the default product listing payload.
-func (c *Cache) ProductListKey() string {
-	return c.key("catalog", "products", "list", "popular")
+// SetLocales records the locales payloads may be cached under so that
+// invalidation clears every localized variant of an entry.
+func (c *Cache) SetLocales(locales []string) {
+	if c == nil {
+		return
+	}
+	c.locales = append([]string(nil), locales...)
+}
+
+// SetTenantScope namespaces cached payloads by the request's tenant when
+// scope is enabled.
+func (c *Cache) SetTenantScope(scope tenant.KeyScope) {
+	if c == nil {
+		return
+	}
+	c.scope = scope
+}
+
+// ProductListKey returns the cache key for the unfiltered product listing
+// payload in the given locale and sort order.
+func (c *Cache) ProductListKey(locale, sort string) string {
+	return c.key("catalog", "products", "list", "popular", sort, locale)
+}
+
+// InStockProductListKey returns the cache key for the product listing served
+// when out-of-stock products are hidden.
+func (c *Cache) InStockProductListKey(locale, sort string) string {
+	return c.key("catalog", "products", "list", "popular", "in-stock", sort, locale)
+}
+
+// StockVisibilityKey returns the cache key for a tenant's resolved
+// out-of-stock visibility.
+func (c *Cache) StockVisibilityKey(tenantID string) string {
+	return c.key("catalog", "visibility", "tenant", tenantID)
+}
+
+// DefaultSortKey returns the cache key for a resolved default sort of a
+// category or tenant.
+func (c *Cache) DefaultSortKey(scope, id string) string {
+	return c.key("catalog", "sort", scope, id)
+}
+
+// ProductDetailKey returns the cache key for a product detail payload in the
+// given locale.
+func (c *Cache) ProductDetailKey(slug, locale string) string {
+	return c.key("catalog", "products", "detail", locale, slug)
 }
 
-// ProductDetailKey returns the cache key for a product detail payload.
-func (c *Cache) ProductDetailKey(slug string) string {
-	return c.key("catalog", "products", "detail", slug)
+// tenantsKey returns the key of the set of tenants holding scoped payloads,
+// so shared invalidations reach each tenant's copy without scanning.
+func (c *Cache) tenantsKey() string {
+	return c.key("catalog", "tenants")
 }
 
 // GetJSON unmarshals a cached JSON payload into dst. It reports whether the key existed.
@@ -54,7 +102,7 @@ func (c *Cache) GetJSON(ctx context.Context, key string, dst any) (bool, error)
 	if c == nil || c.client == nil || key == "" {
 		return false, nil
 	}
-	data, err := c.client.Get(ctx, key).Bytes()
+	data, err := c.client.Get(ctx, c.scope.Key(ctx, key)).Bytes()
 	if err != nil {
 		if err == redis.Nil {
 			return false, nil
@@ -76,10 +124,23 @@ func (c *Cache) SetJSON(ctx context.Context, key string, v any) error {
 	if err != nil {
 		return err
 	}
-	return c.client.Set(ctx, key, data, c.ttl).Err()
+	scoped := c.scope.Key(ctx, key)
+	if scoped == key {
+		return c.client.Set(ctx, key, data, c.ttl).Err()
+	}
+	tenantID, _ := tenant.FromContext(ctx)
+	pipe := c.client.TxPipeline()
+	pipe.Set(ctx, scoped, data, c.ttl)
+	pipe.SAdd(ctx, c.tenantsKey(), tenantID)
+	if c.ttl > 0 {
+		pipe.Expire(ctx, c.tenantsKey(), c.ttl)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
 }
 
-// Delete removes the provided keys from the cache.
+// Delete removes the provided keys from the cache, scoped to the tenant in
+// ctx.
 func (c *Cache) Delete(ctx context.Context, keys ...string) {
 	if c == nil || c.client == nil {
 		return
@@ -87,7 +148,7 @@ func (c *Cache) Delete(ctx context.Context, keys ...string) {
 	filtered := make([]string, 0, len(keys))
 	for _, key := range keys {
 		if strings.TrimSpace(key) != "" {
-			filtered = append(filtered, key)
+			filtered = append(filtered, c.scope.Key(ctx, key))
 		}
 	}
 	if len(filtered) == 0 {
@@ -101,14 +162,56 @@ func (c *Cache) InvalidateProduct(ctx context.Context, slug string) {
 	if c == nil {
 		return
 	}
-	c.Delete(ctx, c.ProductDetailKey(slug))
+	keys := []string{c.ProductDetailKey(slug, "")}
+	for _, locale := range c.locales {
+		keys = append(keys, c.ProductDetailKey(slug, locale))
+	}
+	c.deleteShared(ctx, keys...)
 	c.InvalidateList(ctx)
 }
 
-// InvalidateList removes the cached list payload.
+// InvalidateList removes the cached list payloads for every locale and
+// stock visibility.
 func (c *Cache) InvalidateList(ctx context.Context) {
 	if c == nil {
 		return
 	}
-	c.Delete(ctx, c.ProductListKey())
+	var keys []string
+	for _, sort := range append([]string{""}, sortOptions...) {
+		keys = append(keys, c.ProductListKey("", sort), c.InStockProductListKey("", sort))
+		for _, locale := range c.locales {
+			keys = append(keys, c.ProductListKey(locale, sort), c.InStockProductListKey(locale, sort))
+		}
+	}
+	c.deleteShared(ctx, keys...)
+}
+
+// deleteShared removes keys of the shared catalog: the flat keys and, with
+// tenant scoping, every tenant's copy. Failures are ignored like those of
+// Delete.
+func (c *Cache) deleteShared(ctx context.Context, keys ...string) {
+	if c == nil || c.client == nil {
+		return
+	}
+	targets := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if strings.TrimSpace(key) != "" {
+			targets = append(targets, key)
+		}
+	}
+	if c.scope.Enabled {
+		tenants, err := c.client.SMembers(ctx, c.tenantsKey()).Result()
+		if err == nil {
+			flat := len(targets)
+			for _, tenantID := range tenants {
+				for _, key := range targets[:flat] {
+					targets = append(targets, c.scope.For(tenantID, key))
+				}
+			}
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+	_ = c.client.Del(ctx, targets...).Err()
 }
@@ -0,0 +1,59 @@
+package catalog_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/catalog"
+	"github.com/noah-isme/backend-toko/internal/tenant"
+)
+
+func TestCacheScopesPayloadsByTenant(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	cache := catalog.NewCache(client, time.Minute, "test")
+	cache.SetLocales([]string{"id"})
+	cache.SetTenantScope(tenant.KeyScope{Enabled: true})
+
+	acme := tenant.WithTenant(context.Background(), "acme")
+	globex := tenant.WithTenant(context.Background(), "globex")
+	key := cache.ProductDetailKey("kaos-hitam", "id")
+	require.NoError(t, cache.SetJSON(acme, key, map[string]string{"tenant": "acme"}))
+	require.True(t, mr.Exists("t:acme:"+key))
+	require.False(t, mr.Exists(key))
+
+	var got map[string]string
+	found, err := cache.GetJSON(globex, key, &got)
+	require.NoError(t, err)
+	require.False(t, found, "tenants must not share cached payloads")
+	found, err = cache.GetJSON(acme, key, &got)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "acme", got["tenant"])
+
+	// The catalog is shared, so a change outside any tenant clears every
+	// tracked tenant's copy.
+	require.NoError(t, cache.SetJSON(globex, key, got))
+	require.NoError(t, cache.SetJSON(globex, cache.ProductListKey("id", ""), got))
+	members, err := mr.SMembers("test:catalog:tenants")
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"acme", "globex"}, members)
+	cache.InvalidateProduct(context.Background(), "kaos-hitam")
+	require.Equal(t, []string{"test:catalog:tenants"}, mr.Keys())
+}
+
+func TestCacheKeepsFlatKeysByDefault(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	cache := catalog.NewCache(client, time.Minute, "test")
+
+	ctx := tenant.WithTenant(context.Background(), "acme")
+	key := cache.ProductListKey("id", "")
+	require.NoError(t, cache.SetJSON(ctx, key, []string{}))
+	require.True(t, mr.Exists(key))
+}
@@ -65,13 +65,14 @@ func (h *Handler) Products(w http.ResponseWriter, r *http.Request) {
 		h.writeError(w, err)
 		return
 	}
-	result, err := h.service.ListProducts(r.Context(), params)
+	result, err := h.service.ListProducts(h.service.WithRequestLocale(r), params)
 	if err != nil {
 		h.writeError(w, err)
 		return
 	}
 	w.Header().Set("X-Total-Count", strconv.FormatInt(result.Total, 10))
-	common.JSON(w, http.StatusOK, map[string]any{
+	page := common.ListPage{Type: "products", Items: result.Items, Page: result.Page, PerPage: result.Limit, Total: result.Total}
+	common.WriteList(w, r, page, map[string]any{
 		"data":       result.Items,
 		"pagination": common.Pagination{Page: result.Page, PerPage: result.Limit, TotalItems: int(result.Total)},
 	})
@@ -84,7 +85,7 @@ func (h *Handler) ProductDetail(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	slug := chi.URLParam(r, "slug")
-	detail, err := h.service.GetProductDetail(r.Context(), slug)
+	detail, err := h.service.GetProductDetail(h.service.WithRequestLocale(r), slug)
 	if err != nil {
 		h.writeError(w, err)
 		return
@@ -99,7 +100,31 @@ func (h *Handler) Related(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	slug := chi.URLParam(r, "slug")
-	items, err := h.service.ListRelatedProducts(r.Context(), slug)
+	items, err := h.service.ListRelatedProducts(h.service.WithRequestLocale(r), slug)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	common.JSON(w, http.StatusOK, map[string]any{"data": items})
+}
+
+// Recommendations handles GET /api/v1/products/{slug}/recommendations.
+func (h *Handler) Recommendations(w http.ResponseWriter, r *http.Request) {
+	if h.service == nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "catalog service not configured", nil)
+		return
+	}
+	count := 0
+	if raw := r.URL.Query().Get("count"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "count must be a positive integer", map[string]any{"field": "count"})
+			return
+		}
+		count = n
+	}
+	slug := chi.URLParam(r, "slug")
+	items, err := h.service.ListRecommendations(h.service.WithRequestLocale(r), slug, count)
 	if err != nil {
 		h.writeError(w, err)
 		return
@@ -6,12 +6,14 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"slices"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
 
@@ -109,6 +111,7 @@ func TestCatalogHandlers(t *testing.T) {
 		require.ElementsMatch(t, []string{"fashion"}, resp.Data.CategoryPath)
 		require.Len(t, resp.Data.Variants, 1)
 		require.Equal(t, "S", strings.ToUpper(*resp.Data.Variants[0].SKU))
+		require.Equal(t, []catalog.Option{{Name: "size", Values: []string{"S", "M"}}}, resp.Data.Options)
 		require.Len(t, resp.Data.Images, 1)
 		require.Len(t, resp.Data.Specs, 1)
 	})
@@ -136,9 +139,19 @@ type fakeCatalogQueries struct {
 	productsBySlug map[string]dbgen.GetProductBySlugRow
 	productList    []dbgen.ListProductsPublicRow
 	variants       map[string][]dbgen.ProductVariant
+	bundles        []dbgen.ListBundleComponentsByVariantIDsRow
 	images         map[string][]dbgen.ProductImage
 	specs          map[string][]dbgen.ProductSpec
 	related        map[string][]dbgen.ListRelatedByCategoryRow
+	candidates     []dbgen.ListRecommendationCandidatesRow
+	candidateArgs  dbgen.ListRecommendationCandidatesParams
+	translations   []dbgen.ListProductTranslationsRow
+	categorySorts  map[string]string
+	tenantSettings map[string][]byte
+	topSlugs       []string
+	lastSort       string
+	previousPrices []dbgen.ListPreviousProductPricesRow
+	priceTrend     []dbgen.ListProductPriceTrendRow
 }
 
 func newFakeCatalogQueries(t *testing.T) *fakeCatalogQueries {
@@ -183,17 +196,18 @@ func newFakeCatalogQueries(t *testing.T) *fakeCatalogQueries {
 		},
 		productsBySlug: map[string]dbgen.GetProductBySlugRow{
 			"kaos-hitam": {
-				ID:         productID,
-				Title:      "Kaos Hitam",
-				Slug:       "kaos-hitam",
-				Price:      249000,
-				CompareAt:  pgtype.Int8{Int64: 299000, Valid: true},
-				InStock:    true,
-				Thumbnail:  pgtype.Text{String: "https://cdn.example/kaos.jpg", Valid: true},
-				Badges:     []string{"promo", "new"},
-				BrandID:    brandID,
-				CategoryID: categoryID,
-				CreatedAt:  now,
+				ID:           productID,
+				Title:        "Kaos Hitam",
+				Slug:         "kaos-hitam",
+				Price:        249000,
+				CompareAt:    pgtype.Int8{Int64: 299000, Valid: true},
+				InStock:      true,
+				Thumbnail:    pgtype.Text{String: "https://cdn.example/kaos.jpg", Valid: true},
+				Badges:       []string{"promo", "new"},
+				BrandID:      brandID,
+				CategoryID:   categoryID,
+				CreatedAt:    now,
+				OptionSchema: []byte(`[{"name":"size","values":["S","M"]}]`),
 			},
 			"sepatu-putih": {
 				ID:         relatedID,
@@ -281,6 +295,7 @@ func (f *fakeCatalogQueries) CountProductsPublic(ctx context.Context, arg dbgen.
 }
 
 func (f *fakeCatalogQueries) ListProductsPublic(ctx context.Context, arg dbgen.ListProductsPublicParams) ([]dbgen.ListProductsPublicRow, error) {
+	f.lastSort = arg.Sort
 	filtered := f.filterProducts(dbgen.CountProductsPublicParams{
 		Q:            arg.Q,
 		CategorySlug: arg.CategorySlug,
@@ -314,6 +329,36 @@ func (f *fakeCatalogQueries) ListVariantsByProduct(ctx context.Context, productI
 	return append([]dbgen.ProductVariant(nil), rows...), nil
 }
 
+func (f *fakeCatalogQueries) ListTopProductSlugs(ctx context.Context, limitCount int32) ([]string, error) {
+	return f.topSlugs[:min(int(limitCount), len(f.topSlugs))], nil
+}
+
+func (f *fakeCatalogQueries) ListPreviousProductPrices(ctx context.Context, arg dbgen.ListPreviousProductPricesParams) ([]dbgen.ListPreviousProductPricesRow, error) {
+	var rows []dbgen.ListPreviousProductPricesRow
+	for _, row := range f.previousPrices {
+		if slices.Contains(arg.ProductIds, row.ProductID) && !row.ChangedAt.Time.Before(arg.Since.Time) {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func (f *fakeCatalogQueries) ListProductPriceTrend(ctx context.Context, arg dbgen.ListProductPriceTrendParams) ([]dbgen.ListProductPriceTrendRow, error) {
+	return f.priceTrend[:min(int(arg.LimitCount), len(f.priceTrend))], nil
+}
+
+func (f *fakeCatalogQueries) ListBundleComponentsByVariantIDs(ctx context.Context, variantIds []pgtype.UUID) ([]dbgen.ListBundleComponentsByVariantIDsRow, error) {
+	var rows []dbgen.ListBundleComponentsByVariantIDsRow
+	for _, row := range f.bundles {
+		for _, id := range variantIds {
+			if row.BundleVariantID == id {
+				rows = append(rows, row)
+			}
+		}
+	}
+	return rows, nil
+}
+
 func (f *fakeCatalogQueries) ListImagesByProduct(ctx context.Context, productID pgtype.UUID) ([]dbgen.ProductImage, error) {
 	key := uuidString(productID)
 	rows := f.images[key]
@@ -326,6 +371,11 @@ func (f *fakeCatalogQueries) ListSpecsByProduct(ctx context.Context, productID p
 	return append([]dbgen.ProductSpec(nil), rows...), nil
 }
 
+func (f *fakeCatalogQueries) ListRecommendationCandidates(ctx context.Context, arg dbgen.ListRecommendationCandidatesParams) ([]dbgen.ListRecommendationCandidatesRow, error) {
+	f.candidateArgs = arg
+	return f.candidates, nil
+}
+
 func (f *fakeCatalogQueries) ListRelatedByCategory(ctx context.Context, arg dbgen.ListRelatedByCategoryParams) ([]dbgen.ListRelatedByCategoryRow, error) {
 	rows := f.related[uuidString(arg.CategoryID)]
 	result := make([]dbgen.ListRelatedByCategoryRow, 0, len(rows))
@@ -338,6 +388,37 @@ func (f *fakeCatalogQueries) ListRelatedByCategory(ctx context.Context, arg dbge
 	return result, nil
 }
 
+func (f *fakeCatalogQueries) ListProductTranslations(ctx context.Context, arg dbgen.ListProductTranslationsParams) ([]dbgen.ListProductTranslationsRow, error) {
+	var result []dbgen.ListProductTranslationsRow
+	for _, row := range f.translations {
+		if row.Locale != arg.Locale {
+			continue
+		}
+		for _, id := range arg.ProductIds {
+			if id == row.ProductID {
+				result = append(result, row)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (f *fakeCatalogQueries) GetCategoryDefaultSort(ctx context.Context, slug string) (string, error) {
+	sort, ok := f.categorySorts[slug]
+	if !ok {
+		return "", pgx.ErrNoRows
+	}
+	return sort, nil
+}
+
+func (f *fakeCatalogQueries) GetTenantSetting(ctx context.Context, arg dbgen.GetTenantSettingParams) ([]byte, error) {
+	value, ok := f.tenantSettings[arg.Tenant+"/"+arg.Key]
+	if !ok {
+		return nil, pgx.ErrNoRows
+	}
+	return value, nil
+}
+
 func (f *fakeCatalogQueries) filterProducts(arg dbgen.CountProductsPublicParams) []dbgen.ListProductsPublicRow {
 	result := make([]dbgen.ListProductsPublicRow, 0, len(f.productList))
 	for _, row := range f.productList {
@@ -353,10 +434,8 @@ func (f *fakeCatalogQueries) filterProducts(arg dbgen.CountProductsPublicParams)
 		if !matchesMin(arg.MinPrice, row.Price) || !matchesMax(arg.MaxPrice, row.Price) {
 			continue
 		}
-		if arg.InStock != nil {
-			if b, ok := arg.InStock.(bool); ok && b != row.InStock {
-				continue
-			}
+		if arg.InStock.Valid && arg.InStock.Bool != row.InStock {
+			continue
 		}
 		result = append(result, row)
 	}
@@ -387,46 +466,26 @@ func (f *fakeCatalogQueries) brandSlugForProduct(slug string) string {
 	return brand.Slug
 }
 
-func matchesString(pattern any, value string) bool {
-	if pattern == nil {
-		return true
-	}
-	s, ok := pattern.(string)
-	if !ok {
+func matchesString(pattern pgtype.Text, value string) bool {
+	if !pattern.Valid {
 		return true
 	}
-	return strings.Contains(strings.ToLower(value), strings.ToLower(s))
+	return strings.Contains(strings.ToLower(value), strings.ToLower(pattern.String))
 }
 
-func matchesEqual(pattern any, value string) bool {
-	if pattern == nil {
-		return true
-	}
-	s, ok := pattern.(string)
-	if !ok || s == "" {
+func matchesEqual(pattern pgtype.Text, value string) bool {
+	if !pattern.Valid || pattern.String == "" {
 		return true
 	}
-	return strings.EqualFold(s, value)
+	return strings.EqualFold(pattern.String, value)
 }
 
-func matchesMin(pattern any, price int64) bool {
-	if pattern == nil {
-		return true
-	}
-	if v, ok := pattern.(int64); ok {
-		return price >= v
-	}
-	return true
+func matchesMin(pattern pgtype.Int8, price int64) bool {
+	return !pattern.Valid || price >= pattern.Int64
 }
 
-func matchesMax(pattern any, price int64) bool {
-	if pattern == nil {
-		return true
-	}
-	if v, ok := pattern.(int64); ok {
-		return price <= v
-	}
-	return true
+func matchesMax(pattern pgtype.Int8, price int64) bool {
+	return !pattern.Valid || price <= pattern.Int64
 }
 
 func mustUUID(t *testing.T, value string) pgtype.UUID {
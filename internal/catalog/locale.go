@@ -0,0 +1,168 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+const defaultLocale = "id"
+
+type localeCtxKey struct{}
+
+// WithLocale stores the requested content locale on the context. Service
+// methods fall back to the default locale when none is present.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeCtxKey{}, locale)
+}
+
+// LocaleFromContext returns the locale stored by WithLocale.
+func LocaleFromContext(ctx context.Context) string {
+	locale, _ := ctx.Value(localeCtxKey{}).(string)
+	return locale
+}
+
+// RequestLocale picks the content locale for r. An explicit ?locale= wins over
+// Accept-Language; unsupported values resolve to the default locale.
+func (s *Service) RequestLocale(r *http.Request) string {
+	if locale, ok := s.matchLocale(r.URL.Query().Get("locale")); ok {
+		return locale
+	}
+	for _, tag := range parseAcceptLanguage(r.Header.Get("Accept-Language")) {
+		if locale, ok := s.matchLocale(tag); ok {
+			return locale
+		}
+	}
+	return s.defaultLocale
+}
+
+// WithRequestLocale returns r's context carrying the locale chosen by RequestLocale.
+func (s *Service) WithRequestLocale(r *http.Request) context.Context {
+	return WithLocale(r.Context(), s.RequestLocale(r))
+}
+
+func (s *Service) matchLocale(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if tag == "" {
+		return "", false
+	}
+	tag = strings.ReplaceAll(tag, "_", "-")
+	if _, ok := s.locales[tag]; ok {
+		return tag, true
+	}
+	if base, _, found := strings.Cut(tag, "-"); found {
+		if _, ok := s.locales[base]; ok {
+			return base, true
+		}
+	}
+	return "", false
+}
+
+// contentLocale resolves the locale for ctx, defaulting when unset or unsupported.
+func (s *Service) contentLocale(ctx context.Context) string {
+	if locale, ok := s.matchLocale(LocaleFromContext(ctx)); ok {
+		return locale
+	}
+	return s.defaultLocale
+}
+
+// translations loads translations for ids in locale. The default locale is
+// stored on the product rows themselves, so it never hits the table.
+func (s *Service) translations(ctx context.Context, locale string, ids ...pgtype.UUID) (map[[16]byte]dbgen.ListProductTranslationsRow, error) {
+	if locale == s.defaultLocale || len(ids) == 0 {
+		return nil, nil
+	}
+	rows, err := s.queries.ListProductTranslations(ctx, dbgen.ListProductTranslationsParams{ProductIds: ids, Locale: locale})
+	if err != nil {
+		return nil, fmt.Errorf("list product translations: %w", err)
+	}
+	out := make(map[[16]byte]dbgen.ListProductTranslationsRow, len(rows))
+	for _, row := range rows {
+		out[row.ProductID.Bytes] = row
+	}
+	return out, nil
+}
+
+func (s *Service) localizeItems(ctx context.Context, locale string, ids []pgtype.UUID, items []ProductListItem) error {
+	tr, err := s.translations(ctx, locale, ids...)
+	if err != nil || len(tr) == 0 {
+		return err
+	}
+	for i, id := range ids {
+		row, ok := tr[id.Bytes]
+		if !ok {
+			continue
+		}
+		items[i].Title = row.Title
+		if row.Badges != nil {
+			items[i].Badges = row.Badges
+		}
+	}
+	return nil
+}
+
+func (s *Service) localizeDetail(ctx context.Context, locale string, id pgtype.UUID, detail *ProductDetail) error {
+	tr, err := s.translations(ctx, locale, id)
+	if err != nil {
+		return err
+	}
+	row, ok := tr[id.Bytes]
+	if !ok {
+		return nil
+	}
+	detail.Title = row.Title
+	if row.Description.Valid {
+		description := row.Description.String
+		detail.Description = &description
+	}
+	if row.Badges != nil {
+		detail.Badges = row.Badges
+	}
+	if len(row.Specs) > 0 {
+		var specs []Spec
+		if err := json.Unmarshal(row.Specs, &specs); err == nil && len(specs) > 0 {
+			detail.Specs = specs
+		}
+	}
+	return nil
+}
+
+// parseAcceptLanguage returns language tags ordered by descending quality.
+func parseAcceptLanguage(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var tags []weighted
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(v, 64); err == nil {
+				q = parsed
+			}
+		}
+		if q <= 0 {
+			continue
+		}
+		tags = append(tags, weighted{tag: tag, q: q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+	out := make([]string, 0, len(tags))
+	for _, t := range tags {
+		out = append(out, t.tag)
+	}
+	return out
+}
@@ -0,0 +1,80 @@
+package catalog_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/catalog"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+func TestProductDetailLocalized(t *testing.T) {
+	queries := newFakeCatalogQueries(t)
+	productID := queries.productsBySlug["kaos-hitam"].ID
+	queries.translations = []dbgen.ListProductTranslationsRow{{
+		ProductID:   productID,
+		Locale:      "en",
+		Title:       "Black Tee",
+		Description: pgtype.Text{String: "Cotton tee", Valid: true},
+		Specs:       []byte(`[{"key":"material","value":"cotton"}]`),
+	}}
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	cache := catalog.NewCache(client, time.Minute, "test")
+	svc, err := catalog.NewService(catalog.ServiceConfig{
+		Queries:       queries,
+		Cache:         cache,
+		DefaultLocale: "id",
+		Locales:       []string{"en"},
+	})
+	require.NoError(t, err)
+	handler := catalog.NewHandler(catalog.HandlerConfig{Service: svc})
+
+	get := func(target, acceptLanguage string) catalog.ProductDetail {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		if acceptLanguage != "" {
+			req.Header.Set("Accept-Language", acceptLanguage)
+		}
+		routeCtx := chi.NewRouteContext()
+		routeCtx.URLParams.Add("slug", "kaos-hitam")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+		rec := httptest.NewRecorder()
+		handler.ProductDetail(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+		var resp productDetailResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		return resp.Data
+	}
+
+	en := get("/api/v1/products/kaos-hitam", "fr-FR, en-US;q=0.8")
+	require.Equal(t, "en", en.Locale)
+	require.Equal(t, "Black Tee", en.Title)
+	require.NotNil(t, en.Description)
+	require.Equal(t, []catalog.Spec{{Key: "material", Value: "cotton"}}, en.Specs)
+
+	// The English entry is cached; the default locale must not be served from it.
+	id := get("/api/v1/products/kaos-hitam?locale=id", "en")
+	require.Equal(t, "id", id.Locale)
+	require.Equal(t, "Kaos Hitam", id.Title)
+	require.True(t, mr.Exists(cache.ProductDetailKey("kaos-hitam", "en")))
+	require.True(t, mr.Exists(cache.ProductDetailKey("kaos-hitam", "id")))
+
+	// Unsupported locales fall back to the default content.
+	fallback := get("/api/v1/products/kaos-hitam?locale=ja", "")
+	require.Equal(t, "Kaos Hitam", fallback.Title)
+
+	cache.InvalidateProduct(context.Background(), "kaos-hitam")
+	require.False(t, mr.Exists(cache.ProductDetailKey("kaos-hitam", "en")))
+	require.False(t, mr.Exists(cache.ProductDetailKey("kaos-hitam", "id")))
+}
@@ -0,0 +1,175 @@
+package catalog
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+)
+
+// Option is one selectable dimension of a product (e.g. size) together with
+// the values its variants may take. Names are stored trimmed and lower-cased.
+type Option struct {
+	Name   string   `json:"name"`
+	Values []string `json:"values"`
+}
+
+// NormalizeOptions validates a declared option schema. Names are folded to
+// lower case and must be unique; values are trimmed and must be unique
+// ignoring case. Declaration order is preserved for the storefront selector.
+func NormalizeOptions(options []Option) ([]Option, error) {
+	out := make([]Option, 0, len(options))
+	seen := make(map[string]struct{}, len(options))
+	for i, opt := range options {
+		name := strings.ToLower(strings.TrimSpace(opt.Name))
+		field := fmt.Sprintf("options[%d]", i)
+		if name == "" {
+			return nil, invalidOption(field+".name", "option name is required")
+		}
+		if _, dup := seen[name]; dup {
+			return nil, invalidOption(field+".name", "option "+name+" is declared twice")
+		}
+		seen[name] = struct{}{}
+		if len(opt.Values) == 0 {
+			return nil, invalidOption(field+".values", "option "+name+" needs at least one value")
+		}
+		values := make([]string, 0, len(opt.Values))
+		seenValues := make(map[string]struct{}, len(opt.Values))
+		for _, v := range opt.Values {
+			v = strings.TrimSpace(v)
+			folded := strings.ToLower(v)
+			if v == "" {
+				return nil, invalidOption(field+".values", "option values cannot be empty")
+			}
+			if _, dup := seenValues[folded]; dup {
+				return nil, invalidOption(field+".values", "value "+v+" is declared twice")
+			}
+			seenValues[folded] = struct{}{}
+			values = append(values, v)
+		}
+		out = append(out, Option{Name: name, Values: values})
+	}
+	return out, nil
+}
+
+// parseOptions decodes a stored option schema; malformed data yields no options.
+func parseOptions(raw []byte) []Option {
+	options := []Option{}
+	if len(raw) == 0 {
+		return options
+	}
+	if err := json.Unmarshal(raw, &options); err != nil || options == nil {
+		return []Option{}
+	}
+	return options
+}
+
+// validateAttributes checks variant attributes against options and returns
+// them keyed by option name with the declared spelling of each value. Unless
+// partial is set, every declared option must be present.
+func validateAttributes(options []Option, attrs map[string]any, partial bool) (map[string]string, error) {
+	out := make(map[string]string, len(attrs))
+	for key, raw := range attrs {
+		name := strings.ToLower(strings.TrimSpace(key))
+		field := "attributes." + key
+		if _, dup := out[name]; dup {
+			return nil, invalidOption(field, "attribute "+name+" is given twice")
+		}
+		opt, ok := findOption(options, name)
+		if !ok {
+			return nil, invalidOption(field, "unknown option "+name)
+		}
+		value, ok := scalarString(raw)
+		if !ok {
+			return nil, invalidOption(field, "attribute values must be strings")
+		}
+		canonical, ok := matchValue(opt.Values, value)
+		if !ok {
+			return nil, &common.AppError{
+				Code:       common.CodeValidation,
+				Message:    fmt.Sprintf("value %q is not allowed for option %s", value, name),
+				HTTPStatus: http.StatusBadRequest,
+				Details:    map[string]any{"field": field, "allowed": opt.Values},
+			}
+		}
+		out[name] = canonical
+	}
+	if !partial {
+		var missing []string
+		for _, opt := range options {
+			if _, ok := out[opt.Name]; !ok {
+				missing = append(missing, opt.Name)
+			}
+		}
+		if len(missing) > 0 {
+			return nil, &common.AppError{
+				Code:       common.CodeValidation,
+				Message:    "attributes must set every product option",
+				HTTPStatus: http.StatusBadRequest,
+				Details:    map[string]any{"field": "attributes", "missing": missing},
+			}
+		}
+	}
+	return out, nil
+}
+
+// attributesKey identifies a variant's option combination.
+func attributesKey(attrs map[string]string) string {
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(strings.ToLower(attrs[name]))
+		b.WriteByte(';')
+	}
+	return b.String()
+}
+
+func findOption(options []Option, name string) (Option, bool) {
+	for _, opt := range options {
+		if opt.Name == name {
+			return opt, true
+		}
+	}
+	return Option{}, false
+}
+
+func matchValue(allowed []string, value string) (string, bool) {
+	for _, v := range allowed {
+		if strings.EqualFold(v, value) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+func scalarString(v any) (string, bool) {
+	switch val := v.(type) {
+	case string:
+		return strings.TrimSpace(val), true
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(val), true
+	default:
+		return "", false
+	}
+}
+
+func invalidOption(field, message string) *common.AppError {
+	return &common.AppError{
+		Code:       common.CodeValidation,
+		Message:    message,
+		HTTPStatus: http.StatusBadRequest,
+		Details:    map[string]any{"field": field},
+	}
+}
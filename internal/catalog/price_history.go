@@ -0,0 +1,123 @@
+package catalog
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/tenant"
+)
+
+// PricePoint is a product price and when it took effect.
+type PricePoint struct {
+	Price common.Int64 `json:"price"`
+	At    time.Time    `json:"at"`
+}
+
+// previousPrices returns, per product ID, the price before the product's
+// latest price change within the price-drop badge window.
+func (s *Service) previousPrices(ctx context.Context, ids ...pgtype.UUID) (map[[16]byte]int64, error) {
+	if s.badges.PriceDropWithin <= 0 || len(ids) == 0 {
+		return nil, nil
+	}
+	rows, err := s.queries.ListPreviousProductPrices(ctx, dbgen.ListPreviousProductPricesParams{
+		ProductIds: ids,
+		Since:      pgtype.Timestamptz{Time: s.clock().Add(-s.badges.PriceDropWithin), Valid: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list previous prices: %w", err)
+	}
+	previous := make(map[[16]byte]int64, len(rows))
+	for _, row := range rows {
+		previous[row.ProductID.Bytes] = row.OldPrice
+	}
+	return previous, nil
+}
+
+// priceTrend returns the product's latest prices, oldest first, or nil when
+// the trend is disabled or the price never changed. The price before the
+// oldest returned change leads the trend, so a single change yields two points.
+func (s *Service) priceTrend(ctx context.Context, id pgtype.UUID) ([]PricePoint, error) {
+	if s.trendPoints <= 0 {
+		return nil, nil
+	}
+	rows, err := s.queries.ListProductPriceTrend(ctx, dbgen.ListProductPriceTrendParams{ProductID: id, LimitCount: int32(s.trendPoints)})
+	if err != nil {
+		return nil, fmt.Errorf("list price trend: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	trend := make([]PricePoint, 0, len(rows)+1)
+	for _, row := range rows {
+		trend = append(trend, PricePoint{Price: common.Int64(row.NewPrice), At: row.ChangedAt.Time.UTC()})
+	}
+	oldest := rows[len(rows)-1]
+	trend = append(trend, PricePoint{Price: common.Int64(oldest.OldPrice), At: oldest.OldPriceSince.Time.UTC()})
+	slices.Reverse(trend)
+	return trend, nil
+}
+
+// PriceChange is one recorded product or variant price change.
+type PriceChange struct {
+	VariantID *string      `json:"variantId,omitempty"`
+	OldPrice  common.Int64 `json:"oldPrice"`
+	NewPrice  common.Int64 `json:"newPrice"`
+	ChangedAt time.Time    `json:"changedAt"`
+}
+
+// PriceHistory lists a product's price changes, its variants' included,
+// newest first, paginated with limit and offset.
+func (h *AdminHandler) PriceHistory(w http.ResponseWriter, r *http.Request) {
+	productID, ok := h.productID(w, r)
+	if !ok {
+		return
+	}
+	ctx := r.Context()
+	if _, err := h.Q.GetProductOptionSchema(ctx, productID); err != nil {
+		writeAdminError(w, productLookupError(err))
+		return
+	}
+	var tenantID pgtype.UUID
+	if raw, ok := tenant.FromContext(ctx); ok {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			common.JSONError(w, http.StatusBadRequest, common.CodeBadRequest, "invalid tenant", nil)
+			return
+		}
+		tenantID = pgtype.UUID{Bytes: parsed, Valid: true}
+	}
+	limit, offset := common.ParseOffsetPagination(r, 50, 200)
+	total, err := h.Q.CountPriceHistoryByProduct(ctx, dbgen.CountPriceHistoryByProductParams{ProductID: productID, TenantID: tenantID})
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to count price history", nil)
+		return
+	}
+	rows, err := h.Q.ListPriceHistoryByProduct(ctx, dbgen.ListPriceHistoryByProductParams{
+		ProductID:  productID,
+		TenantID:   tenantID,
+		PageLimit:  int32(limit),
+		PageOffset: int32(offset),
+	})
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to list price history", nil)
+		return
+	}
+	changes := make([]PriceChange, 0, len(rows))
+	for _, row := range rows {
+		change := PriceChange{OldPrice: common.Int64(row.OldPrice), NewPrice: common.Int64(row.NewPrice), ChangedAt: row.ChangedAt.Time.UTC()}
+		if row.VariantID.Valid {
+			id := uuidString(row.VariantID)
+			change.VariantID = &id
+		}
+		changes = append(changes, change)
+	}
+	common.WritePage(w, r, "price-history", changes, limit, offset, total, nil)
+}
@@ -0,0 +1,230 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+// Reasons a product is recommended, reported with each recommendation.
+const (
+	ReasonBoughtTogether = "bought_together"
+	ReasonSameBrand      = "same_brand"
+	ReasonSameCategory   = "same_category"
+	ReasonSimilarPrice   = "similar_price"
+)
+
+// minRecommendationCandidates is the smallest candidate pool ranked, so a short
+// list still picks from enough products.
+const minRecommendationCandidates = 40
+
+// RecommendationWeights blends the recommendation signals into one score.
+type RecommendationWeights struct {
+	// BoughtTogether scales with the orders shared with the product, relative
+	// to the candidate bought together most often.
+	BoughtTogether float64
+	SameBrand      float64
+	SameCategory   float64
+	// SimilarPrice scales with how close the price is within the price band.
+	SimilarPrice float64
+}
+
+// DefaultRecommendationWeights favour products bought together, then brand
+// and price matches, with category as a weak tiebreaker.
+var DefaultRecommendationWeights = RecommendationWeights{BoughtTogether: 3, SameBrand: 1, SameCategory: 0.5, SimilarPrice: 1}
+
+// RecommendationConfig tunes ListRecommendations.
+type RecommendationConfig struct {
+	// DefaultCount is returned when the request names none; MaxCount caps it.
+	DefaultCount int
+	MaxCount     int
+	// PriceBand is the relative price difference still considered similar,
+	// e.g. 0.3 for ±30%.
+	PriceBand float64
+	// Weights blends the signals; the zero value uses
+	// DefaultRecommendationWeights.
+	Weights RecommendationWeights
+}
+
+func (c RecommendationConfig) withDefaults() RecommendationConfig {
+	if c.DefaultCount < 1 {
+		c.DefaultCount = 8
+	}
+	if c.MaxCount < c.DefaultCount {
+		c.MaxCount = c.DefaultCount
+	}
+	if c.PriceBand <= 0 {
+		c.PriceBand = 0.3
+	}
+	if c.Weights == (RecommendationWeights{}) {
+		c.Weights = DefaultRecommendationWeights
+	}
+	return c
+}
+
+// Recommendation is a recommended product with its score and the signals that
+// produced it.
+type Recommendation struct {
+	ProductListItem
+	Score   float64  `json:"score"`
+	Reasons []string `json:"reasons"`
+}
+
+type scoredCandidate struct {
+	row     dbgen.ListRecommendationCandidatesRow
+	score   float64
+	reasons []string
+}
+
+// ListRecommendations returns up to count products to show alongside the
+// product, ranked by a blend of being bought together, brand, category, and
+// price. A count of zero uses the configured default.
+func (s *Service) ListRecommendations(ctx context.Context, slug string, count int) ([]Recommendation, error) {
+	cfg := s.recommend
+	if count <= 0 {
+		count = cfg.DefaultCount
+	}
+	if count > cfg.MaxCount {
+		count = cfg.MaxCount
+	}
+	product, err := s.queries.GetProductBySlug(ctx, slug)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &common.AppError{Code: "NOT_FOUND", Message: "product not found", HTTPStatus: http.StatusNotFound, Err: err}
+		}
+		return nil, fmt.Errorf("get product by slug: %w", err)
+	}
+	spread := int64(float64(product.Price) * cfg.PriceBand)
+	rows, err := s.queries.ListRecommendationCandidates(ctx, dbgen.ListRecommendationCandidatesParams{
+		ProductID:  product.ID,
+		BrandID:    product.BrandID,
+		CategoryID: product.CategoryID,
+		PriceMin:   product.Price - spread,
+		PriceMax:   product.Price + spread,
+		Price:      product.Price,
+		LimitCount: int32(max(count*4, minRecommendationCandidates)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list recommendation candidates: %w", err)
+	}
+	hide, err := s.hidesOutOfStock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if hide {
+		kept := rows[:0]
+		for _, row := range rows {
+			if row.InStock {
+				kept = append(kept, row)
+			}
+		}
+		rows = kept
+	}
+	ranked := rankRecommendations(product, rows, cfg)
+	if len(ranked) > count {
+		ranked = ranked[:count]
+	}
+
+	items := make([]ProductListItem, len(ranked))
+	ids := make([]pgtype.UUID, len(ranked))
+	created := make([]pgtype.Timestamptz, len(ranked))
+	for i, c := range ranked {
+		row := c.row
+		ids[i] = row.ID
+		created[i] = row.CreatedAt
+		items[i] = ProductListItem{
+			ID:      uuidString(row.ID),
+			Title:   row.Title,
+			Slug:    row.Slug,
+			Price:   common.Int64(row.Price),
+			InStock: row.InStock,
+			Stock:   int(row.TotalStock),
+			Badges:  row.Badges,
+			Availability: AvailabilityWindow{
+				From: row.AvailableFrom, To: row.AvailableTo, Preorder: row.Preorder,
+			}.Status(s.clock()),
+		}
+		if row.CompareAt.Valid {
+			compareAt := common.Int64(row.CompareAt.Int64)
+			items[i].CompareAt = &compareAt
+		}
+		if row.Thumbnail.Valid {
+			thumb := s.imageURL(ctx, row.Thumbnail.String)
+			items[i].Thumbnail = &thumb
+		}
+	}
+	if err := s.localizeItems(ctx, s.contentLocale(ctx), ids, items); err != nil {
+		return nil, err
+	}
+	if err := s.applyItemBadges(ctx, items, ids, created); err != nil {
+		return nil, err
+	}
+	out := make([]Recommendation, len(ranked))
+	for i, c := range ranked {
+		out[i] = Recommendation{ProductListItem: items[i], Score: math.Round(c.score*1000) / 1000, Reasons: c.reasons}
+	}
+	return out, nil
+}
+
+// rankRecommendations scores candidates against product and orders them best
+// first. Candidates matching no signal are dropped.
+func rankRecommendations(product dbgen.GetProductBySlugRow, rows []dbgen.ListRecommendationCandidatesRow, cfg RecommendationConfig) []scoredCandidate {
+	var topOrders int64
+	for _, row := range rows {
+		topOrders = max(topOrders, row.CoOrders)
+	}
+	w := cfg.Weights
+	ranked := make([]scoredCandidate, 0, len(rows))
+	for _, row := range rows {
+		c := scoredCandidate{row: row, reasons: []string{}}
+		if row.CoOrders > 0 && topOrders > 0 {
+			c.score += w.BoughtTogether * float64(row.CoOrders) / float64(topOrders)
+			c.reasons = append(c.reasons, ReasonBoughtTogether)
+		}
+		if product.BrandID.Valid && row.BrandID == product.BrandID {
+			c.score += w.SameBrand
+			c.reasons = append(c.reasons, ReasonSameBrand)
+		}
+		if product.CategoryID.Valid && row.CategoryID == product.CategoryID {
+			c.score += w.SameCategory
+			c.reasons = append(c.reasons, ReasonSameCategory)
+		}
+		if closeness := priceCloseness(product.Price, row.Price, cfg.PriceBand); closeness > 0 {
+			c.score += w.SimilarPrice * closeness
+			c.reasons = append(c.reasons, ReasonSimilarPrice)
+		}
+		if len(c.reasons) == 0 {
+			continue
+		}
+		ranked = append(ranked, c)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		if ranked[i].score != ranked[j].score {
+			return ranked[i].score > ranked[j].score
+		}
+		return ranked[i].row.Slug < ranked[j].row.Slug
+	})
+	return ranked
+}
+
+// priceCloseness is 1 for the same price, falling linearly to 0 at the edge
+// of the band.
+func priceCloseness(price, other int64, band float64) float64 {
+	if price <= 0 || band <= 0 {
+		return 0
+	}
+	diff := math.Abs(float64(other-price)) / float64(price)
+	if diff > band {
+		return 0
+	}
+	return 1 - diff/band
+}
@@ -0,0 +1,90 @@
+package catalog_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/catalog"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+type recommendationsResponse struct {
+	Data []catalog.Recommendation `json:"data"`
+}
+
+func TestRecommendationsBlendSignals(t *testing.T) {
+	queries := newFakeCatalogQueries(t)
+	brandID := mustUUID(t, "11111111-1111-1111-1111-111111111111")
+	categoryID := mustUUID(t, "22222222-2222-2222-2222-222222222222")
+	otherID := mustUUID(t, "99999999-9999-9999-9999-999999999999")
+	candidate := func(id, slug string, price, coOrders int64) dbgen.ListRecommendationCandidatesRow {
+		return dbgen.ListRecommendationCandidatesRow{
+			ID: mustUUID(t, id), Title: slug, Slug: slug, Price: price, InStock: true,
+			BrandID: otherID, CategoryID: otherID, CoOrders: coOrders,
+		}
+	}
+	// The product is kaos-hitam: Acme, fashion, 249000.
+	together := candidate("a0000000-0000-0000-0000-000000000001", "celana", 1_000_000, 10)
+	sameBrand := candidate("a0000000-0000-0000-0000-000000000002", "kaos-putih", 249000, 0)
+	sameBrand.BrandID, sameBrand.CategoryID = brandID, categoryID
+	mixed := candidate("a0000000-0000-0000-0000-000000000003", "topi", 300000, 2)
+	mixed.BrandID = brandID
+	category := candidate("a0000000-0000-0000-0000-000000000004", "jaket", 500000, 0)
+	category.CategoryID = categoryID
+	unrelated := candidate("a0000000-0000-0000-0000-000000000005", "stiker", 10, 0)
+	queries.candidates = []dbgen.ListRecommendationCandidatesRow{category, unrelated, mixed, sameBrand, together}
+
+	svc, err := catalog.NewService(catalog.ServiceConfig{
+		Queries:         queries,
+		Recommendations: catalog.RecommendationConfig{DefaultCount: 3, MaxCount: 4},
+	})
+	require.NoError(t, err)
+	handler := catalog.NewHandler(catalog.HandlerConfig{Service: svc})
+	get := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/products/kaos-hitam/recommendations"+query, nil)
+		routeCtx := chi.NewRouteContext()
+		routeCtx.URLParams.Add("slug", "kaos-hitam")
+		req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, routeCtx))
+		rec := httptest.NewRecorder()
+		handler.Recommendations(rec, req)
+		return rec
+	}
+	slugs := func(rec *httptest.ResponseRecorder) []string {
+		t.Helper()
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var resp recommendationsResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		out := make([]string, len(resp.Data))
+		for i, item := range resp.Data {
+			out[i] = item.Slug
+			require.NotEmpty(t, item.Reasons)
+		}
+		return out
+	}
+
+	rec := get("")
+	require.Equal(t, []string{"celana", "kaos-putih", "topi"}, slugs(rec))
+	var resp recommendationsResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Equal(t, []string{catalog.ReasonBoughtTogether}, resp.Data[0].Reasons)
+	require.Equal(t, []string{catalog.ReasonSameBrand, catalog.ReasonSameCategory, catalog.ReasonSimilarPrice}, resp.Data[1].Reasons)
+	require.Equal(t, 2.5, resp.Data[1].Score)
+
+	args := queries.candidateArgs
+	require.Equal(t, brandID, args.BrandID)
+	require.Equal(t, categoryID, args.CategoryID)
+	require.Equal(t, int64(174300), args.PriceMin)
+	require.Equal(t, int64(323700), args.PriceMax)
+
+	// Products matching no signal are never padded in, and count is capped.
+	require.Equal(t, []string{"celana", "kaos-putih", "topi", "jaket"}, slugs(get("?count=50")))
+	require.Equal(t, []string{"celana"}, slugs(get("?count=1")))
+
+	require.Equal(t, http.StatusBadRequest, get("?count=zero").Code)
+}
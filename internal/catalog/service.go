@@ -9,6 +9,7 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -27,27 +28,78 @@ type queryProvider interface {
 	ListProductsPublic(ctx context.Context, arg dbgen.ListProductsPublicParams) ([]dbgen.ListProductsPublicRow, error)
 	GetProductBySlug(ctx context.Context, slug string) (dbgen.GetProductBySlugRow, error)
 	ListVariantsByProduct(ctx context.Context, productID pgtype.UUID) ([]dbgen.ProductVariant, error)
+	ListBundleComponentsByVariantIDs(ctx context.Context, variantIds []pgtype.UUID) ([]dbgen.ListBundleComponentsByVariantIDsRow, error)
 	ListImagesByProduct(ctx context.Context, productID pgtype.UUID) ([]dbgen.ProductImage, error)
 	ListSpecsByProduct(ctx context.Context, productID pgtype.UUID) ([]dbgen.ProductSpec, error)
 	ListRelatedByCategory(ctx context.Context, arg dbgen.ListRelatedByCategoryParams) ([]dbgen.ListRelatedByCategoryRow, error)
+	ListRecommendationCandidates(ctx context.Context, arg dbgen.ListRecommendationCandidatesParams) ([]dbgen.ListRecommendationCandidatesRow, error)
+	ListProductTranslations(ctx context.Context, arg dbgen.ListProductTranslationsParams) ([]dbgen.ListProductTranslationsRow, error)
+	GetCategoryDefaultSort(ctx context.Context, slug string) (string, error)
+	GetTenantSetting(ctx context.Context, arg dbgen.GetTenantSettingParams) ([]byte, error)
+	ListTopProductSlugs(ctx context.Context, limitCount int32) ([]string, error)
+	ListPreviousProductPrices(ctx context.Context, arg dbgen.ListPreviousProductPricesParams) ([]dbgen.ListPreviousProductPricesRow, error)
+	ListProductPriceTrend(ctx context.Context, arg dbgen.ListProductPriceTrendParams) ([]dbgen.ListProductPriceTrendRow, error)
+}
+
+// URLResolver maps stored image references to URLs clients can fetch, e.g.
+// signing references to objects in a private bucket.
+type URLResolver interface {
+	ResolveURL(ctx context.Context, ref string) string
 }
 
 // Service orchestrates catalog queries, DTO assembly, and caching.
 type Service struct {
 	queries      queryProvider
 	cache        *Cache
+	images       URLResolver
 	defaultPage  int
 	defaultLimit int
 	maxLimit     int
+	defaultSort  string
+
+	defaultLocale string
+	locales       map[string]struct{}
+	now           func() time.Time
+	badges        BadgeRules
+	recommend     RecommendationConfig
+	trendPoints   int
+
+	hideOutOfStock bool
 }
 
 // ServiceConfig groups Service dependencies.
 type ServiceConfig struct {
-	Queries      queryProvider
-	Cache        *Cache
+	Queries queryProvider
+	Cache   *Cache
+	// Images resolves product image references. Nil returns them unchanged.
+	Images       URLResolver
 	DefaultPage  int
 	DefaultLimit int
 	MaxLimit     int
+	// DefaultSort orders listings when neither the request, the category, nor
+	// the tenant settings choose a sort. Empty means newest first.
+	DefaultSort string
+	// DefaultLocale is the language stored on the product rows; other locales
+	// are read from product_translations. Empty defaults to "id".
+	DefaultLocale string
+	// Locales lists the locales clients may request. The default is always included.
+	Locales []string
+	// Now reports the time availability windows are evaluated at. Nil uses
+	// time.Now.
+	Now func() time.Time
+	// Badges configures the badges derived from product data and merged with
+	// the stored ones. The zero value derives none.
+	Badges BadgeRules
+	// HideOutOfStock leaves out-of-stock products out of listings and related
+	// products and answers their detail pages with 404, unless the tenant
+	// setting TenantHideOutOfStockKey says otherwise. An explicit inStock
+	// filter still lists them on requests marked with WithStockOverride.
+	HideOutOfStock bool
+	// Recommendations tunes ListRecommendations; zero values use defaults.
+	Recommendations RecommendationConfig
+	// PriceTrendPoints is how many of a product's latest price changes its
+	// detail shows as a price trend; zero leaves the trend out.
+	PriceTrendPoints int
 }
 
 // ListParams captures filters for product listing.
@@ -65,42 +117,67 @@ type ListParams struct {
 
 // ProductListItem represents an entry in list/related responses.
 type ProductListItem struct {
-	ID        string   `json:"id"`
-	Title     string   `json:"title"`
-	Slug      string   `json:"slug"`
-	Price     int64    `json:"price"`
-	CompareAt *int64   `json:"compareAt,omitempty"`
-	InStock   bool     `json:"inStock"`
-	Stock     int      `json:"stock"`
-	Thumbnail *string  `json:"thumbnail,omitempty"`
-	Badges    []string `json:"badges"`
+	ID           string        `json:"id"`
+	Title        string        `json:"title"`
+	Slug         string        `json:"slug"`
+	Price        common.Int64  `json:"price"`
+	CompareAt    *common.Int64 `json:"compareAt,omitempty"`
+	InStock      bool          `json:"inStock"`
+	Stock        int           `json:"stock"`
+	Thumbnail    *string       `json:"thumbnail,omitempty"`
+	Badges       []string      `json:"badges"`
+	Availability string        `json:"availability"`
+	// PreviousPrice is the price before a change within the price-drop
+	// badge window.
+	PreviousPrice *common.Int64 `json:"previousPrice,omitempty"`
 }
 
 // ProductDetail aggregates the full detail payload.
 type ProductDetail struct {
-	ID           string    `json:"id"`
-	Title        string    `json:"title"`
-	Slug         string    `json:"slug"`
-	Price        int64     `json:"price"`
-	CompareAt    *int64    `json:"compareAt,omitempty"`
-	InStock      bool      `json:"inStock"`
-	Stock        int       `json:"stock"`
-	Thumbnail    *string   `json:"thumbnail,omitempty"`
-	Badges       []string  `json:"badges"`
-	Variants     []Variant `json:"variants"`
-	Images       []string  `json:"images"`
-	Specs        []Spec    `json:"specs"`
-	Brand        *Mini     `json:"brand,omitempty"`
-	CategoryPath []string  `json:"categoryPath,omitempty"`
-}
-
-// Variant describes a product variant.
+	ID          string        `json:"id"`
+	Title       string        `json:"title"`
+	Slug        string        `json:"slug"`
+	Description *string       `json:"description,omitempty"`
+	Locale      string        `json:"locale"`
+	Price       common.Int64  `json:"price"`
+	CompareAt   *common.Int64 `json:"compareAt,omitempty"`
+	InStock     bool          `json:"inStock"`
+	Stock       int           `json:"stock"`
+	Thumbnail   *string       `json:"thumbnail,omitempty"`
+	Badges      []string      `json:"badges"`
+	Options     []Option      `json:"options"`
+	Variants    []Variant     `json:"variants"`
+	// DefaultVariantID is the variant a cart line resolves to when only the
+	// product is given.
+	DefaultVariantID *string      `json:"defaultVariantId,omitempty"`
+	Images           []string     `json:"images"`
+	Specs            []Spec       `json:"specs"`
+	Brand            *Mini        `json:"brand,omitempty"`
+	CategoryPath     []string     `json:"categoryPath,omitempty"`
+	Availability     Availability `json:"availability"`
+	// PreviousPrice is the price before a change within the price-drop
+	// badge window.
+	PreviousPrice *common.Int64 `json:"previousPrice,omitempty"`
+	// PriceTrend holds the latest product prices, oldest first.
+	PriceTrend []PricePoint `json:"priceTrend,omitempty"`
+}
+
+// Variant describes a product variant. Bundle variants carry their
+// components, and their price and stock are derived from them.
 type Variant struct {
 	ID         string         `json:"id"`
 	SKU        *string        `json:"sku,omitempty"`
-	Price      int64          `json:"price"`
+	Price      common.Int64   `json:"price"`
 	Stock      int            `json:"stock"`
 	Attributes map[string]any `json:"attributes"`
+	IsDefault  bool           `json:"isDefault"`
+	Bundle     *Bundle        `json:"bundle,omitempty"`
+	// WeightGram and the dimensions are used for shipping quotes; unset
+	// values are omitted.
+	WeightGram *int32 `json:"weightGram,omitempty"`
+	LengthCm   *int32 `json:"lengthCm,omitempty"`
+	WidthCm    *int32 `json:"widthCm,omitempty"`
+	HeightCm   *int32 `json:"heightCm,omitempty"`
 }
 
 // Spec represents a key/value specification entry.
@@ -159,12 +236,39 @@ func NewService(cfg ServiceConfig) (*Service, error) {
 	if defaultLimit > maxLimit {
 		defaultLimit = maxLimit
 	}
+	locale := strings.ToLower(strings.TrimSpace(cfg.DefaultLocale))
+	if locale == "" {
+		locale = defaultLocale
+	}
+	locales := map[string]struct{}{locale: {}}
+	for _, l := range cfg.Locales {
+		if l = strings.ToLower(strings.TrimSpace(l)); l != "" {
+			locales[l] = struct{}{}
+		}
+	}
+	if cfg.Cache != nil {
+		known := make([]string, 0, len(locales))
+		for l := range locales {
+			known = append(known, l)
+		}
+		cfg.Cache.SetLocales(known)
+	}
 	return &Service{
-		queries:      cfg.Queries,
-		cache:        cfg.Cache,
-		defaultPage:  defaultPage,
-		defaultLimit: defaultLimit,
-		maxLimit:     maxLimit,
+		queries:       cfg.Queries,
+		cache:         cfg.Cache,
+		images:        cfg.Images,
+		defaultPage:   defaultPage,
+		defaultLimit:  defaultLimit,
+		maxLimit:      maxLimit,
+		defaultSort:   normalizeSort(cfg.DefaultSort),
+		defaultLocale: locale,
+		locales:       locales,
+		now:           cfg.Now,
+		badges:        cfg.Badges,
+		recommend:     cfg.Recommendations.withDefaults(),
+		trendPoints:   max(cfg.PriceTrendPoints, 0),
+
+		hideOutOfStock: cfg.HideOutOfStock,
 	}, nil
 }
 
@@ -270,7 +374,21 @@ func (s *Service) ListCategories(ctx context.Context) ([]Category, error) {
 
 // ListProducts returns filtered product list with pagination metadata.
 func (s *Service) ListProducts(ctx context.Context, params ListParams) (ProductListResult, error) {
-	key, shouldUseCache := s.listCacheKey(params)
+	locale := s.contentLocale(ctx)
+	sort, err := s.resolveSort(ctx, params)
+	if err != nil {
+		return ProductListResult{}, err
+	}
+	params.Sort = sort
+	hide, err := s.hidesOutOfStock(ctx)
+	if err != nil {
+		return ProductListResult{}, err
+	}
+	key, shouldUseCache := s.listCacheKey(params, locale, hide)
+	if hide && (params.InStock == nil || !stockOverride(ctx)) {
+		inStock := true
+		params.InStock = &inStock
+	}
 	if shouldUseCache && s.cache != nil {
 		var cached cachedList
 		ok, err := s.cache.GetJSON(ctx, key, &cached)
@@ -311,26 +429,39 @@ func (s *Service) ListProducts(ctx context.Context, params ListParams) (ProductL
 		return ProductListResult{}, fmt.Errorf("list products: %w", err)
 	}
 	items := make([]ProductListItem, 0, len(rows))
+	ids := make([]pgtype.UUID, 0, len(rows))
+	created := make([]pgtype.Timestamptz, 0, len(rows))
 	for _, row := range rows {
+		ids = append(ids, row.ID)
+		created = append(created, row.CreatedAt)
 		item := ProductListItem{
 			ID:      uuidString(row.ID),
 			Title:   row.Title,
 			Slug:    row.Slug,
-			Price:   row.Price,
+			Price:   common.Int64(row.Price),
 			InStock: row.InStock,
 			Stock:   int(row.TotalStock),
 			Badges:  row.Badges,
+			Availability: AvailabilityWindow{
+				From: row.AvailableFrom, To: row.AvailableTo, Preorder: row.Preorder,
+			}.Status(s.clock()),
 		}
 		if row.CompareAt.Valid {
-			compareAt := row.CompareAt.Int64
+			compareAt := common.Int64(row.CompareAt.Int64)
 			item.CompareAt = &compareAt
 		}
 		if row.Thumbnail.Valid {
-			thumb := row.Thumbnail.String
+			thumb := s.imageURL(ctx, row.Thumbnail.String)
 			item.Thumbnail = &thumb
 		}
 		items = append(items, item)
 	}
+	if err := s.localizeItems(ctx, locale, ids, items); err != nil {
+		return ProductListResult{}, err
+	}
+	if err := s.applyItemBadges(ctx, items, ids, created); err != nil {
+		return ProductListResult{}, err
+	}
 	result := ProductListResult{Items: items, Total: total, Page: params.Page, Limit: params.Limit}
 	if shouldUseCache && s.cache != nil && key != "" {
 		_ = s.cache.SetJSON(ctx, key, cachedList{Items: items, Total: total})
@@ -344,12 +475,22 @@ func (s *Service) GetProductDetail(ctx context.Context, slug string) (ProductDet
 	if slug == "" {
 		return ProductDetail{}, badRequest("slug", "slug is required", nil)
 	}
+	locale := s.contentLocale(ctx)
+	hide, err := s.hidesOutOfStock(ctx)
+	if err != nil {
+		return ProductDetail{}, err
+	}
+	// The cached payload is shared by every tenant, so the policy is applied
+	// after reading it rather than baked into it.
 	var cacheKey string
 	if s.cache != nil {
-		cacheKey = s.cache.ProductDetailKey(slug)
+		cacheKey = s.cache.ProductDetailKey(slug, locale)
 		var cached ProductDetail
 		ok, err := s.cache.GetJSON(ctx, cacheKey, &cached)
 		if err == nil && ok {
+			if hide && !cached.InStock {
+				return ProductDetail{}, errProductHidden
+			}
 			return cached, nil
 		}
 	}
@@ -364,17 +505,22 @@ func (s *Service) GetProductDetail(ctx context.Context, slug string) (ProductDet
 		ID:      uuidString(product.ID),
 		Title:   product.Title,
 		Slug:    product.Slug,
-		Price:   product.Price,
+		Locale:  locale,
+		Price:   common.Int64(product.Price),
 		InStock: product.InStock,
 		Stock:   int(product.TotalStock),
 		Badges:  product.Badges,
+		Options: parseOptions(product.OptionSchema),
+		Availability: AvailabilityWindow{
+			From: product.AvailableFrom, To: product.AvailableTo, Preorder: product.Preorder, ShipsAt: product.PreorderShipsAt,
+		}.At(s.clock()),
 	}
 	if product.CompareAt.Valid {
-		compareAt := product.CompareAt.Int64
+		compareAt := common.Int64(product.CompareAt.Int64)
 		detail.CompareAt = &compareAt
 	}
 	if product.Thumbnail.Valid {
-		thumb := product.Thumbnail.String
+		thumb := s.imageURL(ctx, product.Thumbnail.String)
 		detail.Thumbnail = &thumb
 	}
 	if product.BrandID.Valid {
@@ -395,31 +541,23 @@ func (s *Service) GetProductDetail(ctx context.Context, slug string) (ProductDet
 	}
 	detail.Variants = make([]Variant, 0, len(variants))
 	for _, row := range variants {
-		attrs := map[string]any{}
-		if len(row.Attributes) > 0 {
-			if err := json.Unmarshal(row.Attributes, &attrs); err != nil {
-				attrs = map[string]any{}
-			}
-		}
-		variant := Variant{
-			ID:         uuidString(row.ID),
-			Price:      row.Price,
-			Stock:      int(row.Stock),
-			Attributes: attrs,
-		}
-		if row.Sku.Valid {
-			sku := row.Sku.String
-			variant.SKU = &sku
+		variant := variantFromRow(row)
+		if product.DefaultVariantID.Valid && row.ID == product.DefaultVariantID {
+			variant.IsDefault = true
+			detail.DefaultVariantID = &variant.ID
 		}
 		detail.Variants = append(detail.Variants, variant)
 	}
+	if err := s.attachBundles(ctx, variants, &detail); err != nil {
+		return ProductDetail{}, err
+	}
 	images, err := s.queries.ListImagesByProduct(ctx, product.ID)
 	if err != nil {
 		return ProductDetail{}, fmt.Errorf("list images: %w", err)
 	}
 	detail.Images = make([]string, 0, len(images))
 	for _, row := range images {
-		detail.Images = append(detail.Images, row.Url)
+		detail.Images = append(detail.Images, s.imageURL(ctx, row.Url))
 	}
 	specs, err := s.queries.ListSpecsByProduct(ctx, product.ID)
 	if err != nil {
@@ -429,12 +567,89 @@ func (s *Service) GetProductDetail(ctx context.Context, slug string) (ProductDet
 	for _, row := range specs {
 		detail.Specs = append(detail.Specs, Spec{Key: row.Key, Value: row.Value})
 	}
+	if err := s.localizeDetail(ctx, locale, product.ID, &detail); err != nil {
+		return ProductDetail{}, err
+	}
+	if err := s.applyDetailBadges(ctx, &detail, product.ID, product.CreatedAt); err != nil {
+		return ProductDetail{}, err
+	}
+	if detail.PriceTrend, err = s.priceTrend(ctx, product.ID); err != nil {
+		return ProductDetail{}, err
+	}
 	if s.cache != nil && cacheKey != "" {
 		_ = s.cache.SetJSON(ctx, cacheKey, detail)
 	}
+	if hide && !detail.InStock {
+		return ProductDetail{}, errProductHidden
+	}
 	return detail, nil
 }
 
+func variantFromRow(row dbgen.ProductVariant) Variant {
+	attrs := map[string]any{}
+	if len(row.Attributes) > 0 {
+		if err := json.Unmarshal(row.Attributes, &attrs); err != nil {
+			attrs = map[string]any{}
+		}
+	}
+	variant := Variant{
+		ID:         uuidString(row.ID),
+		Price:      common.Int64(row.Price),
+		Stock:      int(row.Stock),
+		Attributes: attrs,
+	}
+	if row.Sku.Valid {
+		sku := row.Sku.String
+		variant.SKU = &sku
+	}
+	variant.WeightGram = int4Ptr(row.WeightGram)
+	variant.LengthCm = int4Ptr(row.LengthCm)
+	variant.WidthCm = int4Ptr(row.WidthCm)
+	variant.HeightCm = int4Ptr(row.HeightCm)
+	return variant
+}
+
+func int4Ptr(v pgtype.Int4) *int32 {
+	if !v.Valid {
+		return nil
+	}
+	n := v.Int32
+	return &n
+}
+
+// attachBundles adds components to bundle variants and replaces their price
+// and stock with the values derived from the components. The product stock is
+// recounted so a bundle blocked by an empty component reads as unavailable.
+func (s *Service) attachBundles(ctx context.Context, variants []dbgen.ProductVariant, detail *ProductDetail) error {
+	if len(variants) == 0 {
+		return nil
+	}
+	ids := make([]pgtype.UUID, 0, len(variants))
+	for _, row := range variants {
+		ids = append(ids, row.ID)
+	}
+	rows, err := s.queries.ListBundleComponentsByVariantIDs(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("list bundle components: %w", err)
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	bundles := BundlesFromRows(rows)
+	total := 0
+	for i, row := range variants {
+		if bundle, ok := bundles[row.ID]; ok {
+			detail.Variants[i].Bundle = bundle
+			detail.Variants[i].Price = common.Int64(bundle.UnitPrice(row.Price))
+			detail.Variants[i].Stock = bundle.Available()
+		}
+		total += detail.Variants[i].Stock
+	}
+	detail.Stock = total
+	detail.InStock = detail.InStock && total > 0
+	return nil
+}
+
 // ListRelatedProducts fetches related products from the same category.
 func (s *Service) ListRelatedProducts(ctx context.Context, slug string) ([]ProductListItem, error) {
 	product, err := s.queries.GetProductBySlug(ctx, slug)
@@ -451,29 +666,64 @@ func (s *Service) ListRelatedProducts(ctx context.Context, slug string) ([]Produ
 	if err != nil {
 		return nil, fmt.Errorf("list related products: %w", err)
 	}
+	hide, err := s.hidesOutOfStock(ctx)
+	if err != nil {
+		return nil, err
+	}
 	items := make([]ProductListItem, 0, len(rows))
+	ids := make([]pgtype.UUID, 0, len(rows))
+	created := make([]pgtype.Timestamptz, 0, len(rows))
 	for _, row := range rows {
+		if hide && !row.InStock {
+			continue
+		}
+		ids = append(ids, row.ID)
+		created = append(created, row.CreatedAt)
 		item := ProductListItem{
 			ID:      uuidString(row.ID),
 			Title:   row.Title,
 			Slug:    row.Slug,
-			Price:   row.Price,
+			Price:   common.Int64(row.Price),
 			InStock: row.InStock,
+			Stock:   int(row.TotalStock),
 			Badges:  row.Badges,
+			Availability: AvailabilityWindow{
+				From: row.AvailableFrom, To: row.AvailableTo, Preorder: row.Preorder,
+			}.Status(s.clock()),
 		}
 		if row.CompareAt.Valid {
-			compareAt := row.CompareAt.Int64
+			compareAt := common.Int64(row.CompareAt.Int64)
 			item.CompareAt = &compareAt
 		}
 		if row.Thumbnail.Valid {
-			thumb := row.Thumbnail.String
+			thumb := s.imageURL(ctx, row.Thumbnail.String)
 			item.Thumbnail = &thumb
 		}
 		items = append(items, item)
 	}
+	if err := s.localizeItems(ctx, s.contentLocale(ctx), ids, items); err != nil {
+		return nil, err
+	}
+	if err := s.applyItemBadges(ctx, items, ids, created); err != nil {
+		return nil, err
+	}
 	return items, nil
 }
 
+func (s *Service) clock() time.Time {
+	if s.now != nil {
+		return s.now()
+	}
+	return time.Now()
+}
+
+func (s *Service) imageURL(ctx context.Context, ref string) string {
+	if s.images == nil {
+		return ref
+	}
+	return s.images.ResolveURL(ctx, ref)
+}
+
 func (s *Service) categoryPath(ctx context.Context, id pgtype.UUID) ([]string, error) {
 	var path []string
 	if !id.Valid {
@@ -508,7 +758,7 @@ type cachedList struct {
 	Total int64             `json:"total"`
 }
 
-func (s *Service) listCacheKey(params ListParams) (string, bool) {
+func (s *Service) listCacheKey(params ListParams, locale string, hideOutOfStock bool) (string, bool) {
 	if s.cache == nil {
 		return "", false
 	}
@@ -518,10 +768,13 @@ func (s *Service) listCacheKey(params ListParams) (string, bool) {
 	if params.Limit != s.defaultLimit {
 		return "", false
 	}
-	if params.Query != "" || params.Category != "" || params.Brand != "" || params.MinPrice != nil || params.MaxPrice != nil || params.InStock != nil || params.Sort != "" {
+	if params.Query != "" || params.Category != "" || params.Brand != "" || params.MinPrice != nil || params.MaxPrice != nil || params.InStock != nil {
 		return "", false
 	}
-	return s.cache.ProductListKey(), true
+	if hideOutOfStock {
+		return s.cache.InStockProductListKey(locale, params.Sort), true
+	}
+	return s.cache.ProductListKey(locale, params.Sort), true
 }
 
 func optionalStringValue(value string) pgtype.Text {
@@ -557,16 +810,6 @@ func parseBool(value string) (bool, error) {
 	}
 }
 
-func normalizeSort(s string) string {
-	s = strings.ToLower(strings.TrimSpace(s))
-	switch s {
-	case "price:asc", "price:desc", "title:asc", "title:desc":
-		return s
-	default:
-		return ""
-	}
-}
-
 func uuidString(id pgtype.UUID) string {
 	if !id.Valid {
 		return ""
@@ -0,0 +1,105 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/tenant"
+)
+
+// Supported sort orders for product listings. The empty sort orders by
+// created_at descending, which is equivalent to SortNewest.
+const (
+	SortNewest     = "newest"
+	SortBestseller = "bestseller"
+	SortPriceAsc   = "price:asc"
+	SortPriceDesc  = "price:desc"
+	SortTitleAsc   = "title:asc"
+	SortTitleDesc  = "title:desc"
+)
+
+// TenantDefaultSortKey is the tenant_settings key holding a tenant's default
+// listing sort as a JSON string, e.g. "bestseller".
+const TenantDefaultSortKey = "catalog.default_sort"
+
+var sortOptions = []string{SortNewest, SortBestseller, SortPriceAsc, SortPriceDesc, SortTitleAsc, SortTitleDesc}
+
+func normalizeSort(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, option := range sortOptions {
+		if s == option {
+			return s
+		}
+	}
+	return ""
+}
+
+// resolveSort picks the listing sort when the client did not request one: the
+// category default applies first, then the tenant default, then the service default.
+func (s *Service) resolveSort(ctx context.Context, params ListParams) (string, error) {
+	if params.Sort != "" {
+		return params.Sort, nil
+	}
+	if params.Category != "" {
+		sort, err := s.cachedDefaultSort(ctx, "category", params.Category, func() (string, error) {
+			return s.queries.GetCategoryDefaultSort(ctx, params.Category)
+		})
+		if err != nil {
+			return "", err
+		}
+		if sort != "" {
+			return sort, nil
+		}
+	}
+	if tenantID, ok := tenant.FromContext(ctx); ok {
+		sort, err := s.cachedDefaultSort(ctx, "tenant", tenantID, func() (string, error) {
+			raw, err := s.queries.GetTenantSetting(ctx, dbgen.GetTenantSettingParams{Tenant: tenantID, Key: TenantDefaultSortKey})
+			if err != nil {
+				return "", err
+			}
+			var value string
+			if err := json.Unmarshal(raw, &value); err != nil {
+				return "", nil
+			}
+			return value, nil
+		})
+		if err != nil {
+			return "", err
+		}
+		if sort != "" {
+			return sort, nil
+		}
+	}
+	return s.defaultSort, nil
+}
+
+// cachedDefaultSort memoises default sort lookups in the catalog cache so the
+// cached popular list can be served without touching the database.
+func (s *Service) cachedDefaultSort(ctx context.Context, scope, id string, load func() (string, error)) (string, error) {
+	key := ""
+	if s.cache != nil {
+		key = s.cache.DefaultSortKey(scope, id)
+		var cached string
+		if ok, err := s.cache.GetJSON(ctx, key, &cached); err == nil && ok {
+			return cached, nil
+		}
+	}
+	sort, err := load()
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return "", fmt.Errorf("load %s default sort: %w", scope, err)
+		}
+		sort = ""
+	}
+	sort = normalizeSort(sort)
+	if key != "" {
+		_ = s.cache.SetJSON(ctx, key, sort)
+	}
+	return sort, nil
+}
@@ -0,0 +1,56 @@
+package catalog_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/catalog"
+	"github.com/noah-isme/backend-toko/internal/tenant"
+)
+
+func TestListProductsDefaultSortResolution(t *testing.T) {
+	queries := newFakeCatalogQueries(t)
+	queries.categorySorts = map[string]string{"fashion": "newest"}
+	queries.tenantSettings = map[string][]byte{"acme/" + catalog.TenantDefaultSortKey: []byte(`"bestseller"`)}
+
+	mr := miniredis.RunT(t)
+	cache := catalog.NewCache(redis.NewClient(&redis.Options{Addr: mr.Addr()}), time.Minute, "test")
+	svc, err := catalog.NewService(catalog.ServiceConfig{Queries: queries, Cache: cache, DefaultSort: "price:asc"})
+	require.NoError(t, err)
+
+	list := func(ctx context.Context, query string) {
+		values, err := url.ParseQuery(query)
+		require.NoError(t, err)
+		params, err := svc.ParseListParams(values)
+		require.NoError(t, err)
+		_, err = svc.ListProducts(ctx, params)
+		require.NoError(t, err)
+	}
+
+	list(context.Background(), "")
+	require.Equal(t, catalog.SortPriceAsc, queries.lastSort, "service default applies without tenant or category")
+
+	tenantCtx := tenant.WithTenant(context.Background(), "acme")
+	list(tenantCtx, "")
+	require.Equal(t, catalog.SortBestseller, queries.lastSort, "tenant default overrides service default")
+
+	list(tenantCtx, "category=fashion")
+	require.Equal(t, catalog.SortNewest, queries.lastSort, "category default overrides tenant default")
+
+	list(tenantCtx, "sort=title:desc")
+	require.Equal(t, catalog.SortTitleDesc, queries.lastSort, "explicit sort wins")
+
+	// Each sort order of the unfiltered list is cached under its own key.
+	require.True(t, mr.Exists(cache.ProductListKey("id", catalog.SortPriceAsc)))
+	require.True(t, mr.Exists(cache.ProductListKey("id", catalog.SortBestseller)))
+	require.True(t, mr.Exists(cache.ProductListKey("id", catalog.SortTitleDesc)))
+
+	cache.InvalidateList(context.Background())
+	require.False(t, mr.Exists(cache.ProductListKey("id", catalog.SortBestseller)))
+}
@@ -0,0 +1,98 @@
+package catalog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/tenant"
+)
+
+// TenantHideOutOfStockKey is the tenant_settings key holding whether a
+// tenant hides out-of-stock products, as a JSON boolean. It overrides
+// ServiceConfig.HideOutOfStock.
+const TenantHideOutOfStockKey = "catalog.hide_out_of_stock"
+
+// Cached tenant visibility values; the empty value means the tenant has no
+// setting and the service default applies.
+const (
+	visibilityHide = "hide"
+	visibilityShow = "show"
+)
+
+type stockOverrideKey struct{}
+
+// WithStockOverride marks ctx as an admin request whose explicit inStock
+// filter overrides the out-of-stock visibility policy.
+func WithStockOverride(ctx context.Context) context.Context {
+	return context.WithValue(ctx, stockOverrideKey{}, true)
+}
+
+// StockOverride is middleware for admin routes that applies
+// WithStockOverride to every request.
+func StockOverride(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(WithStockOverride(r.Context())))
+	})
+}
+
+func stockOverride(ctx context.Context) bool {
+	ok, _ := ctx.Value(stockOverrideKey{}).(bool)
+	return ok
+}
+
+// hidesOutOfStock reports whether out-of-stock products are hidden for the
+// request: the tenant setting wins over the service default.
+func (s *Service) hidesOutOfStock(ctx context.Context) (bool, error) {
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok {
+		return s.hideOutOfStock, nil
+	}
+	key := ""
+	if s.cache != nil {
+		key = s.cache.StockVisibilityKey(tenantID)
+		var cached string
+		if ok, err := s.cache.GetJSON(ctx, key, &cached); err == nil && ok {
+			return s.visibility(cached), nil
+		}
+	}
+	value := ""
+	raw, err := s.queries.GetTenantSetting(ctx, dbgen.GetTenantSettingParams{Tenant: tenantID, Key: TenantHideOutOfStockKey})
+	switch {
+	case err == nil:
+		var hide bool
+		if json.Unmarshal(raw, &hide) == nil {
+			value = visibilityShow
+			if hide {
+				value = visibilityHide
+			}
+		}
+	case !errors.Is(err, pgx.ErrNoRows):
+		return false, fmt.Errorf("load tenant stock visibility: %w", err)
+	}
+	if key != "" {
+		_ = s.cache.SetJSON(ctx, key, value)
+	}
+	return s.visibility(value), nil
+}
+
+func (s *Service) visibility(value string) bool {
+	switch value {
+	case visibilityHide:
+		return true
+	case visibilityShow:
+		return false
+	default:
+		return s.hideOutOfStock
+	}
+}
+
+// errProductHidden answers detail requests for products the visibility policy
+// hides exactly like requests for products that do not exist.
+var errProductHidden = &common.AppError{Code: "NOT_FOUND", Message: "product not found", HTTPStatus: http.StatusNotFound}
@@ -0,0 +1,123 @@
+package catalog_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/catalog"
+	"github.com/noah-isme/backend-toko/internal/common"
+	"github.com/noah-isme/backend-toko/internal/tenant"
+)
+
+// soldOutQueries marks sepatu-putih as out of stock everywhere it appears.
+func soldOutQueries(t *testing.T) *fakeCatalogQueries {
+	queries := newFakeCatalogQueries(t)
+	queries.productList[1].InStock = false
+	product := queries.productsBySlug["sepatu-putih"]
+	product.InStock = false
+	queries.productsBySlug["sepatu-putih"] = product
+	for _, rows := range queries.related {
+		for i := range rows {
+			rows[i].InStock = false
+		}
+	}
+	return queries
+}
+
+func listSlugs(t *testing.T, svc *catalog.Service, ctx context.Context, query string) []string {
+	t.Helper()
+	values, err := url.ParseQuery(query)
+	require.NoError(t, err)
+	params, err := svc.ParseListParams(values)
+	require.NoError(t, err)
+	result, err := svc.ListProducts(ctx, params)
+	require.NoError(t, err)
+	slugs := make([]string, 0, len(result.Items))
+	for _, item := range result.Items {
+		slugs = append(slugs, item.Slug)
+	}
+	require.Equal(t, int64(len(slugs)), result.Total)
+	return slugs
+}
+
+func TestOutOfStockShownByDefault(t *testing.T) {
+	svc, err := catalog.NewService(catalog.ServiceConfig{Queries: soldOutQueries(t)})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.Equal(t, []string{"kaos-hitam", "sepatu-putih"}, listSlugs(t, svc, ctx, ""))
+
+	detail, err := svc.GetProductDetail(ctx, "sepatu-putih")
+	require.NoError(t, err)
+	require.False(t, detail.InStock, "shown out-of-stock products are flagged")
+
+	related, err := svc.ListRelatedProducts(ctx, "kaos-hitam")
+	require.NoError(t, err)
+	require.Len(t, related, 1)
+}
+
+func TestHideOutOfStock(t *testing.T) {
+	mr := miniredis.RunT(t)
+	cache := catalog.NewCache(redis.NewClient(&redis.Options{Addr: mr.Addr()}), time.Minute, "test")
+	svc, err := catalog.NewService(catalog.ServiceConfig{Queries: soldOutQueries(t), Cache: cache, HideOutOfStock: true})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.Equal(t, []string{"kaos-hitam"}, listSlugs(t, svc, ctx, ""))
+	require.True(t, mr.Exists(cache.InStockProductListKey("id", "")))
+	require.False(t, mr.Exists(cache.ProductListKey("id", "")))
+	// Served from the cache the second time, still filtered.
+	require.Equal(t, []string{"kaos-hitam"}, listSlugs(t, svc, ctx, ""))
+
+	require.Equal(t, []string{"kaos-hitam"}, listSlugs(t, svc, ctx, "inStock=false"), "public requests cannot lift the policy")
+	admin := catalog.WithStockOverride(ctx)
+	require.Equal(t, []string{"sepatu-putih"}, listSlugs(t, svc, admin, "inStock=false"), "explicit admin filter overrides the policy")
+
+	for range 2 { // uncached, then cached
+		_, err = svc.GetProductDetail(ctx, "sepatu-putih")
+		var appErr *common.AppError
+		require.True(t, errors.As(err, &appErr))
+		require.Equal(t, http.StatusNotFound, appErr.HTTPStatus)
+	}
+	_, err = svc.GetProductDetail(ctx, "kaos-hitam")
+	require.NoError(t, err)
+
+	related, err := svc.ListRelatedProducts(ctx, "kaos-hitam")
+	require.NoError(t, err)
+	require.Empty(t, related)
+
+	cache.InvalidateList(ctx)
+	require.False(t, mr.Exists(cache.InStockProductListKey("id", "")))
+}
+
+func TestHideOutOfStockTenantOverride(t *testing.T) {
+	queries := soldOutQueries(t)
+	queries.tenantSettings = map[string][]byte{
+		"acme/" + catalog.TenantHideOutOfStockKey:   []byte(`true`),
+		"globex/" + catalog.TenantHideOutOfStockKey: []byte(`false`),
+	}
+	mr := miniredis.RunT(t)
+	cache := catalog.NewCache(redis.NewClient(&redis.Options{Addr: mr.Addr()}), time.Minute, "test")
+	svc, err := catalog.NewService(catalog.ServiceConfig{Queries: queries, Cache: cache})
+	require.NoError(t, err)
+
+	acme := tenant.WithTenant(context.Background(), "acme")
+	globex := tenant.WithTenant(context.Background(), "globex")
+
+	require.Equal(t, []string{"kaos-hitam"}, listSlugs(t, svc, acme, ""))
+	require.Equal(t, []string{"kaos-hitam", "sepatu-putih"}, listSlugs(t, svc, globex, ""), "the hiding tenant's cached list is not reused")
+	require.Equal(t, []string{"kaos-hitam", "sepatu-putih"}, listSlugs(t, svc, context.Background(), ""))
+
+	_, err = svc.GetProductDetail(acme, "sepatu-putih")
+	require.Error(t, err)
+	_, err = svc.GetProductDetail(globex, "sepatu-putih")
+	require.NoError(t, err)
+}
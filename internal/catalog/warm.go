@@ -0,0 +1,114 @@
+package catalog
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	defaultWarmTop         = 50
+	maxWarmTop             = 500
+	defaultWarmConcurrency = 4
+	maxWarmConcurrency     = 16
+)
+
+// ErrCacheDisabled is returned by Warm when the service has no cache.
+var ErrCacheDisabled = errors.New("catalog: cache not configured")
+
+// WarmOptions bounds a cache warm run. Zero values use the defaults.
+type WarmOptions struct {
+	// Top is how many best-selling products get their detail cached.
+	Top int
+	// Concurrency caps parallel detail loads so warming does not starve the
+	// database pool.
+	Concurrency int
+}
+
+// WarmResult counts the cache entries a warm run loaded.
+type WarmResult struct {
+	Lists   int `json:"lists"`
+	Details int `json:"details"`
+	Failed  int `json:"failed"`
+}
+
+// Warm loads the unfiltered product listings for every sort and the details
+// of the top selling products into the cache, in every configured locale. It
+// goes through the regular read path, so entries already cached are left as
+// they are and running it again is harmless.
+func (s *Service) Warm(ctx context.Context, opts WarmOptions) (WarmResult, error) {
+	if s.cache == nil {
+		return WarmResult{}, ErrCacheDisabled
+	}
+	top := opts.Top
+	if top <= 0 {
+		top = defaultWarmTop
+	}
+	top = min(top, maxWarmTop)
+	workers := opts.Concurrency
+	if workers <= 0 {
+		workers = defaultWarmConcurrency
+	}
+	workers = min(workers, maxWarmConcurrency)
+
+	locales := make([]string, 0, len(s.locales))
+	for locale := range s.locales {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+
+	var result WarmResult
+	// The empty sort warms whatever the tenant or service default resolves to.
+	sorts := append([]string{""}, sortOptions...)
+	for _, locale := range locales {
+		localeCtx := WithLocale(ctx, locale)
+		for _, sortOption := range sorts {
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+			params := ListParams{Sort: sortOption, Page: s.defaultPage, Limit: s.defaultLimit}
+			if _, err := s.ListProducts(localeCtx, params); err != nil {
+				result.Failed++
+				continue
+			}
+			result.Lists++
+		}
+	}
+
+	slugs, err := s.queries.ListTopProductSlugs(ctx, int32(top))
+	if err != nil {
+		return result, fmt.Errorf("list top products: %w", err)
+	}
+
+	var details, failed atomic.Int64
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+schedule:
+	for _, slug := range slugs {
+		for _, locale := range locales {
+			select {
+			case <-ctx.Done():
+				break schedule
+			case sem <- struct{}{}:
+			}
+			wg.Add(1)
+			go func(slug, locale string) {
+				defer wg.Done()
+				defer func() { <-sem }()
+				// A hidden product's detail is still cached before it is refused.
+				if _, err := s.GetProductDetail(WithLocale(ctx, locale), slug); err != nil && !errors.Is(err, errProductHidden) {
+					failed.Add(1)
+					return
+				}
+				details.Add(1)
+			}(slug, locale)
+		}
+	}
+	wg.Wait()
+	result.Details = int(details.Load())
+	result.Failed += int(failed.Load())
+	return result, ctx.Err()
+}
@@ -0,0 +1,49 @@
+package catalog_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/catalog"
+)
+
+func TestWarmLoadsListingsAndTopDetails(t *testing.T) {
+	queries := newFakeCatalogQueries(t)
+	queries.topSlugs = []string{"kaos-hitam"}
+
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	cache := catalog.NewCache(client, time.Minute, "test")
+	svc, err := catalog.NewService(catalog.ServiceConfig{
+		Queries:       queries,
+		Cache:         cache,
+		DefaultLocale: "id",
+		Locales:       []string{"en"},
+	})
+	require.NoError(t, err)
+
+	result, err := svc.Warm(context.Background(), catalog.WarmOptions{Top: 10, Concurrency: 2})
+	require.NoError(t, err)
+	require.Zero(t, result.Failed)
+	// Two locales, each with the default sort plus every explicit sort.
+	require.Equal(t, 2*7, result.Lists)
+	require.Equal(t, 2, result.Details)
+
+	for _, locale := range []string{"en", "id"} {
+		require.True(t, mr.Exists(cache.ProductDetailKey("kaos-hitam", locale)))
+		require.True(t, mr.Exists(cache.ProductListKey(locale, catalog.SortPriceAsc)))
+	}
+}
+
+func TestWarmRequiresCache(t *testing.T) {
+	svc, err := catalog.NewService(catalog.ServiceConfig{Queries: newFakeCatalogQueries(t)})
+	require.NoError(t, err)
+
+	_, err = svc.Warm(context.Background(), catalog.WarmOptions{})
+	require.ErrorIs(t, err, catalog.ErrCacheDisabled)
+}
@@ -0,0 +1,148 @@
+package checkout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+// simulatedRTT approximates a same-region network round-trip to PostgreSQL.
+const simulatedRTT = 200 * time.Microsecond
+
+// latencyDB is a dbgen.DBTX that charges one simulated round-trip per call,
+// which is the cost that dominates order item inserts inside the checkout tx.
+type latencyDB struct {
+	rtt time.Duration
+}
+
+func (d latencyDB) Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error) {
+	time.Sleep(d.rtt)
+	return pgconn.NewCommandTag("INSERT 0 1"), nil
+}
+
+func (d latencyDB) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (d latencyDB) QueryRow(context.Context, string, ...interface{}) pgx.Row {
+	return nil
+}
+
+func (d latencyDB) SendBatch(_ context.Context, b *pgx.Batch) pgx.BatchResults {
+	time.Sleep(d.rtt)
+	return &latencyBatchResults{remaining: b.Len()}
+}
+
+type latencyBatchResults struct {
+	remaining int
+}
+
+func (r *latencyBatchResults) Exec() (pgconn.CommandTag, error) {
+	if r.remaining == 0 {
+		return pgconn.CommandTag{}, errors.New("no more results")
+	}
+	r.remaining--
+	return pgconn.NewCommandTag("INSERT 0 1"), nil
+}
+
+func (r *latencyBatchResults) Query() (pgx.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (r *latencyBatchResults) QueryRow() pgx.Row {
+	return nil
+}
+
+func (r *latencyBatchResults) Close() error {
+	return nil
+}
+
+func benchCartItems(n int) []dbgen.CartItem {
+	items := make([]dbgen.CartItem, n)
+	for i := range items {
+		items[i] = dbgen.CartItem{
+			ID:        pgtype.UUID{Bytes: [16]byte{byte(i + 1)}, Valid: true},
+			ProductID: pgtype.UUID{Bytes: [16]byte{0xaa, byte(i + 1)}, Valid: true},
+			Title:     fmt.Sprintf("Item %d", i),
+			Slug:      fmt.Sprintf("item-%d", i),
+			Qty:       2,
+			UnitPrice: 15000,
+			Subtotal:  30000,
+		}
+	}
+	return items
+}
+
+func TestInsertOrderItemsUsesSingleBatch(t *testing.T) {
+	db := &countingDB{}
+	items := benchCartItems(5)
+	if err := insertOrderItems(context.Background(), dbgen.New(db), pgtype.UUID{Valid: true}, items, nil, nil, nil); err != nil {
+		t.Fatalf("insert order items: %v", err)
+	}
+	if db.batches != 1 || db.queued != len(items) || db.execs != 0 {
+		t.Fatalf("expected one batch of %d statements, got batches=%d queued=%d execs=%d", len(items), db.batches, db.queued, db.execs)
+	}
+}
+
+type countingDB struct {
+	latencyDB
+	batches int
+	queued  int
+	execs   int
+}
+
+func (d *countingDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	d.execs++
+	return d.latencyDB.Exec(ctx, sql, args...)
+}
+
+func (d *countingDB) SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults {
+	d.batches++
+	d.queued += b.Len()
+	return d.latencyDB.SendBatch(ctx, b)
+}
+
+func BenchmarkCreateOrderItems50PerRow(b *testing.B) {
+	ctx := context.Background()
+	q := dbgen.New(latencyDB{rtt: simulatedRTT})
+	items := benchCartItems(50)
+	orderID := pgtype.UUID{Valid: true}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, it := range items {
+			if err := q.CreateOrderItem(ctx, dbgen.CreateOrderItemParams{
+				OrderID:   orderID,
+				ProductID: it.ProductID,
+				VariantID: it.VariantID,
+				Title:     it.Title,
+				Slug:      it.Slug,
+				Qty:       it.Qty,
+				UnitPrice: it.UnitPrice,
+				Subtotal:  it.Subtotal,
+			}); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkCreateOrderItems50Batched(b *testing.B) {
+	ctx := context.Background()
+	q := dbgen.New(latencyDB{rtt: simulatedRTT})
+	items := benchCartItems(50)
+	orderID := pgtype.UUID{Valid: true}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := insertOrderItems(ctx, q, orderID, items, nil, nil, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"errors"
 	"net/http"
 
+	"github.com/jackc/pgx/v5"
+
 	"github.com/noah-isme/backend-toko/internal/common"
 )
 
@@ -35,6 +37,31 @@ func (h *Handler) Checkout(w http.ResponseWriter, r *http.Request) {
 	common.JSON(w, http.StatusCreated, map[string]any{"data": out})
 }
 
+// Preview handles POST /api/v1/checkout/preview, validating and pricing a
+// checkout without creating an order.
+func (h *Handler) Preview(w http.ResponseWriter, r *http.Request) {
+	if h.Svc == nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "checkout service not configured", nil)
+		return
+	}
+	userID, ok := common.UserID(r.Context())
+	if !ok || userID == "" {
+		common.JSONError(w, http.StatusUnauthorized, "UNAUTHORIZED", "authentication required", nil)
+		return
+	}
+	var payload PreviewInput
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid payload", nil)
+		return
+	}
+	out, err := h.Svc.Preview(r.Context(), &userID, payload)
+	if err != nil {
+		h.writeError(w, err)
+		return
+	}
+	common.JSON(w, http.StatusOK, map[string]any{"data": out})
+}
+
 func (h *Handler) writeError(w http.ResponseWriter, err error) {
 	if err == nil {
 		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "unknown error", nil)
@@ -53,5 +80,9 @@ func (h *Handler) writeError(w http.ResponseWriter, err error) {
 		common.JSONError(w, status, code, appErr.Message, appErr.Details)
 		return
 	}
+	if errors.Is(err, pgx.ErrNoRows) {
+		common.JSONError(w, http.StatusNotFound, "NOT_FOUND", "cart not found", nil)
+		return
+	}
 	common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
 }
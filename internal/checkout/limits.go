@@ -0,0 +1,73 @@
+package checkout
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/pricing"
+)
+
+// TenantOrderLimitsKey is the tenant_settings key holding a tenant's order
+// value limits as JSON, e.g. {"min": 50000, "max": 50000000}. Fields that are
+// absent fall back to the service defaults; zero disables a limit.
+const TenantOrderLimitsKey = "checkout.order_limits"
+
+// OrderLimits bounds the value of a single order in minor units. Min applies
+// to the item subtotal after discounts; Max applies to the charged total.
+// Zero disables a bound.
+type OrderLimits struct {
+	Min int64 `json:"min"`
+	Max int64 `json:"max"`
+}
+
+// orderLimits returns the limits for tenantID, letting tenant settings
+// override the service defaults field by field.
+func (s *Service) orderLimits(ctx context.Context, q *dbgen.Queries, tenantID string) (OrderLimits, error) {
+	limits := s.Limits
+	raw, err := q.GetTenantSetting(ctx, dbgen.GetTenantSettingParams{Tenant: tenantID, Key: TenantOrderLimitsKey})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return limits, nil
+		}
+		return OrderLimits{}, fmt.Errorf("load order limits: %w", err)
+	}
+	var override struct {
+		Min *int64 `json:"min"`
+		Max *int64 `json:"max"`
+	}
+	if err := json.Unmarshal(raw, &override); err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Str("tenant", tenantID).Msg("malformed tenant order limits; using defaults")
+		return limits, nil
+	}
+	if override.Min != nil {
+		limits.Min = *override.Min
+	}
+	if override.Max != nil {
+		limits.Max = *override.Max
+	}
+	return limits, nil
+}
+
+// Check returns an AppError when summary falls outside the limits.
+func (l OrderLimits) Check(summary pricing.Summary, currency string) error {
+	value := summary.Subtotal - summary.Discount
+	if l.Min > 0 && value < l.Min {
+		err := common.NewAppError(common.CodeOrderBelowMinimum, fmt.Sprintf("order value must be at least %d %s after discounts", l.Min, currency), http.StatusUnprocessableEntity, nil)
+		err.Details = map[string]any{"minimum": l.Min, "value": value, "currency": currency}
+		return err
+	}
+	if l.Max > 0 && summary.Total > l.Max {
+		err := common.NewAppError(common.CodeOrderAboveMaximum, fmt.Sprintf("order total must not exceed %d %s", l.Max, currency), http.StatusUnprocessableEntity, nil)
+		err.Details = map[string]any{"maximum": l.Max, "value": summary.Total, "currency": currency}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package checkout
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/pricing"
+)
+
+func TestOrderLimitsCheck(t *testing.T) {
+	limits := OrderLimits{Min: 50000, Max: 1000000}
+
+	// 60000 subtotal drops to 45000 after the discount, under the minimum.
+	err := limits.Check(pricing.Summary{Subtotal: 60000, Discount: 15000, Shipping: 20000, Total: 65000}, "IDR")
+	var appErr *common.AppError
+	if !errors.As(err, &appErr) || appErr.Code != common.CodeOrderBelowMinimum || appErr.HTTPStatus != http.StatusUnprocessableEntity {
+		t.Fatalf("expected ORDER_BELOW_MINIMUM, got %v", err)
+	}
+	if details := appErr.Details.(map[string]any); details["minimum"] != int64(50000) || details["value"] != int64(45000) {
+		t.Fatalf("unexpected details %v", appErr.Details)
+	}
+
+	err = limits.Check(pricing.Summary{Subtotal: 990000, Shipping: 20000, Total: 1010000}, "IDR")
+	if !errors.As(err, &appErr) || appErr.Code != common.CodeOrderAboveMaximum {
+		t.Fatalf("expected ORDER_ABOVE_MAXIMUM, got %v", err)
+	}
+	if details := appErr.Details.(map[string]any); details["maximum"] != int64(1000000) {
+		t.Fatalf("unexpected details %v", appErr.Details)
+	}
+
+	if err := limits.Check(pricing.Summary{Subtotal: 50000, Total: 55500}, "IDR"); err != nil {
+		t.Fatalf("expected order at the minimum to pass, got %v", err)
+	}
+	if err := (OrderLimits{}).Check(pricing.Summary{}, "IDR"); err != nil {
+		t.Fatalf("expected zero limits to be disabled, got %v", err)
+	}
+}
+
+func TestPreviewAppliesTenantOrderLimits(t *testing.T) {
+	svc, db, ctx, userID, cartID := previewFixture(t)
+	svc.Limits = OrderLimits{Min: 1000, Max: 100000}
+	// The tenant raises the maximum and leaves the default minimum in place.
+	db.rows["GetTenantSetting"] = []any{struct{ Value []byte }{[]byte(`{"max": 150000}`)}}
+
+	in := PreviewInput{Input: Input{CartID: cartID, Shipping: ShipOpt{Courier: "jne", Service: "REG"}}}
+	out, err := svc.Preview(ctx, &userID, in)
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	var limitIssue *Issue
+	for i := range out.Issues {
+		if out.Issues[i].Code == common.CodeOrderAboveMaximum {
+			limitIssue = &out.Issues[i]
+		}
+	}
+	if limitIssue == nil {
+		t.Fatalf("expected ORDER_ABOVE_MAXIMUM issue, got %+v", out.Issues)
+	}
+	if details := limitIssue.Details.(map[string]any); details["maximum"] != int64(150000) || details["value"] != int64(out.Pricing.Total) {
+		t.Fatalf("unexpected details %v", limitIssue.Details)
+	}
+}
+
+func TestOrderLimitsLogsMalformedTenantSetting(t *testing.T) {
+	var logs bytes.Buffer
+	ctx := zerolog.New(&logs).WithContext(context.Background())
+	db := &readOnlyDB{rows: map[string][]any{
+		"GetTenantSetting": {struct{ Value []byte }{[]byte(`{"min":`)}},
+	}}
+	svc := &Service{Limits: OrderLimits{Min: 10000, Max: 500000}}
+
+	limits, err := svc.orderLimits(ctx, dbgen.New(db), "acme")
+	if err != nil {
+		t.Fatalf("expected defaults on malformed setting, got %v", err)
+	}
+	if limits != svc.Limits {
+		t.Fatalf("expected defaults %+v, got %+v", svc.Limits, limits)
+	}
+	if !strings.Contains(logs.String(), "malformed tenant order limits") || !strings.Contains(logs.String(), `"tenant":"acme"`) {
+		t.Fatalf("expected a warning naming the tenant, got %q", logs.String())
+	}
+}
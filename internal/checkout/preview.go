@@ -0,0 +1,340 @@
+package checkout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/noah-isme/backend-toko/internal/cart"
+	"github.com/noah-isme/backend-toko/internal/catalog"
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/pricing"
+	"github.com/noah-isme/backend-toko/internal/reqcache"
+	"github.com/noah-isme/backend-toko/internal/resilience"
+	"github.com/noah-isme/backend-toko/internal/shipping"
+)
+
+// Issue codes reported by Preview. Any issue blocks the real checkout.
+const (
+	IssueCartEmpty           = "CART_EMPTY"
+	IssueOutOfStock          = "OUT_OF_STOCK"
+	IssueProductUnavailable  = "PRODUCT_UNAVAILABLE"
+	IssueVoucherInvalid      = "VOUCHER_INVALID"
+	IssueShippingRequired    = "SHIPPING_REQUIRED"
+	IssueShippingUnavailable = "SHIPPING_UNAVAILABLE"
+	IssueProviderTimeout     = "PROVIDER_TIMEOUT"
+	IssuePriceChanged        = "PRICE_CHANGED"
+	IssueNoShippingOptions   = common.CodeNoShippingOptions
+	// Order value limits reuse common.CodeOrderBelowMinimum and
+	// common.CodeOrderAboveMaximum so preview and checkout agree.
+)
+
+// PreviewInput is a checkout request plus the optional voucher a review
+// screen tries instead of the one applied to the cart. The parcel is weighed
+// from the cart.
+type PreviewInput struct {
+	Input
+	VoucherCode *string `json:"voucherCode"`
+}
+
+// Issue describes a problem that would make checkout fail or change its result.
+type Issue struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	ItemID  string `json:"itemId,omitempty"`
+	Details any    `json:"details,omitempty"`
+}
+
+// PreviewItem is a cart line as it would be ordered.
+type PreviewItem struct {
+	ID        string       `json:"id"`
+	ProductID string       `json:"productId"`
+	VariantID *string      `json:"variantId,omitempty"`
+	Title     string       `json:"title"`
+	Qty       int32        `json:"qty"`
+	UnitPrice common.Int64 `json:"unitPrice"`
+	Subtotal  common.Int64 `json:"subtotal"`
+	// Preorder marks a line ordered ahead of the product's availability;
+	// it is not held to current stock.
+	Preorder bool `json:"preorder,omitempty"`
+}
+
+// PreviewPricing is the price breakdown checkout would charge.
+type PreviewPricing struct {
+	Subtotal common.Int64 `json:"subtotal"`
+	Discount common.Int64 `json:"discount"`
+	Tax      common.Int64 `json:"tax"`
+	Shipping common.Int64 `json:"shipping"`
+	Total    common.Int64 `json:"total"`
+}
+
+// PreviewResult is the outcome of a checkout dry run.
+type PreviewResult struct {
+	Valid       bool          `json:"valid"`
+	Currency    string        `json:"currency"`
+	Items       []PreviewItem `json:"items"`
+	VoucherCode *string       `json:"voucherCode,omitempty"`
+	Shipping    ShipOpt       `json:"shipping"`
+	// ShippingRule is the shipping rule outcome, including how far the cart
+	// is from free shipping.
+	ShippingRule shipping.Decision `json:"shippingRule"`
+	Pricing      PreviewPricing    `json:"pricing"`
+	Issues       []Issue           `json:"issues"`
+}
+
+// Preview runs checkout validation and pricing without writing anything. Bad
+// input and foreign carts fail as they do in Create; problems a shopper can fix
+// (stock, voucher, shipping) are returned as issues on a priced result.
+func (s *Service) Preview(ctx context.Context, userID *string, in PreviewInput) (PreviewResult, error) {
+	if s == nil || s.Q == nil {
+		return PreviewResult{}, errors.New("checkout service not configured")
+	}
+	cID, uID, tID, err := s.resolveScope(ctx, userID, in.CartID)
+	if err != nil {
+		return PreviewResult{}, err
+	}
+	cartRow, err := reqcache.Load(ctx, s.RequestCache, cart.CartCacheKey(cID), func(ctx context.Context) (dbgen.Cart, error) {
+		return s.Q.GetCartByID(ctx, cID)
+	})
+	if err != nil {
+		return PreviewResult{}, err
+	}
+	if cartRow.UserID.Valid && !cart.UUIDEqual(cartRow.UserID, uID) {
+		return PreviewResult{}, errors.New("cart does not belong to user")
+	}
+	items, err := reqcache.Load(ctx, s.RequestCache, cart.ItemsCacheKey(cID), func(ctx context.Context) ([]dbgen.CartItem, error) {
+		return s.Q.ListCartItems(ctx, cID)
+	})
+	if err != nil {
+		return PreviewResult{}, err
+	}
+
+	result := PreviewResult{Currency: s.Currency, Items: make([]PreviewItem, 0, len(items)), Issues: []Issue{}}
+	var availability map[[16]byte]dbgen.ListCartItemAvailabilityRow
+	if len(items) == 0 {
+		result.Issues = append(result.Issues, Issue{Code: IssueCartEmpty, Message: "cart is empty"})
+	} else {
+		availability, err = s.lineAvailability(ctx, s.Q, cID)
+		if err != nil {
+			return PreviewResult{}, err
+		}
+		result.Issues = append(result.Issues, s.stockIssues(items, availability)...)
+		prices, err := catalogPrices(ctx, s.Q, cID)
+		if err != nil {
+			return PreviewResult{}, err
+		}
+		for _, c := range s.priceChanges(items, prices) {
+			result.Issues = append(result.Issues, Issue{
+				Code:    IssuePriceChanged,
+				Message: fmt.Sprintf("the price of %s changed from %d to %d", c.Title, c.OldPrice, c.NewPrice),
+				ItemID:  c.ItemID,
+				Details: map[string]any{"oldPrice": c.OldPrice, "newPrice": c.NewPrice},
+			})
+		}
+	}
+	pricingItems := make([]pricing.Item, 0, len(items))
+	for _, it := range items {
+		pricingItems = append(pricingItems, pricing.Item{Qty: int(it.Qty), UnitPrice: pricing.Money(it.UnitPrice)})
+		item := PreviewItem{
+			ID:        cart.UUIDString(it.ID),
+			ProductID: cart.UUIDString(it.ProductID),
+			Title:     it.Title,
+			Qty:       it.Qty,
+			UnitPrice: common.Int64(it.UnitPrice),
+			Subtotal:  common.Int64(it.Subtotal),
+			Preorder:  s.lineStatus(availability[it.ID.Bytes]) == catalog.AvailabilityPreorder,
+		}
+		if it.VariantID.Valid {
+			variantID := cart.UUIDString(it.VariantID)
+			item.VariantID = &variantID
+		}
+		result.Items = append(result.Items, item)
+	}
+
+	code := cartRow.AppliedVoucherCode.String
+	if in.VoucherCode != nil {
+		code = strings.TrimSpace(*in.VoucherCode)
+	}
+	var discount int64
+	if code != "" && len(items) > 0 && s.CartSvc != nil {
+		discount, _, err = s.CartSvc.EvaluateVoucher(ctx, cID, code)
+		if err != nil {
+			discount = 0
+			result.Issues = append(result.Issues, Issue{Code: IssueVoucherInvalid, Message: voucherIssueMessage(err)})
+		} else {
+			result.VoucherCode = &code
+		}
+	}
+
+	result.ShippingRule, err = s.shippingDecision(ctx, s.Q, cart.UUIDString(tID), pricing.Compute(pricingItems, pricing.Money(discount), s.TaxBps, 0, s.Rounding), in.Address, in.Destination)
+	if err != nil {
+		return PreviewResult{}, err
+	}
+	result.Shipping, err = s.quoteShipping(ctx, cID, cart.UUIDString(tID), in, result.ShippingRule, &result.Issues)
+	if err != nil {
+		return PreviewResult{}, err
+	}
+	summary := pricing.Compute(pricingItems, pricing.Money(discount), s.TaxBps, pricing.Money(result.Shipping.Price), s.Rounding)
+	result.Pricing = PreviewPricing{
+		Subtotal: common.Int64(summary.Subtotal),
+		Discount: common.Int64(summary.Discount),
+		Tax:      common.Int64(summary.Tax),
+		Shipping: common.Int64(summary.Shipping),
+		Total:    common.Int64(summary.Total),
+	}
+	if len(items) > 0 {
+		limits, err := s.orderLimits(ctx, s.Q, cart.UUIDString(tID))
+		if err != nil {
+			return PreviewResult{}, err
+		}
+		var appErr *common.AppError
+		if errors.As(limits.Check(summary, s.Currency), &appErr) {
+			result.Issues = append(result.Issues, Issue{Code: appErr.Code, Message: appErr.Message, Details: appErr.Details})
+		}
+	}
+	result.Valid = len(result.Issues) == 0
+	return result, nil
+}
+
+// lineAvailability loads the stock and availability window of each cart line,
+// keyed by item ID.
+func (s *Service) lineAvailability(ctx context.Context, q *dbgen.Queries, cartID pgtype.UUID) (map[[16]byte]dbgen.ListCartItemAvailabilityRow, error) {
+	rows, err := q.ListCartItemAvailability(ctx, cartID)
+	if err != nil {
+		return nil, err
+	}
+	availability := make(map[[16]byte]dbgen.ListCartItemAvailabilityRow, len(rows))
+	for _, row := range rows {
+		availability[row.ID.Bytes] = row
+	}
+	return availability, nil
+}
+
+// lineStatus is the availability status of a line's product now.
+func (s *Service) lineStatus(row dbgen.ListCartItemAvailabilityRow) string {
+	return catalog.AvailabilityWindow{From: row.AvailableFrom, To: row.AvailableTo, Preorder: row.Preorder}.Status(s.now())
+}
+
+// stockIssues reports lines whose product was removed, marked out of stock, or
+// is outside its availability window, or whose variant (or, for bundles, any
+// component) has less stock than the requested quantity. Preorder lines are
+// not held to current stock.
+func (s *Service) stockIssues(items []dbgen.CartItem, availability map[[16]byte]dbgen.ListCartItemAvailabilityRow) []Issue {
+	var issues []Issue
+	for _, it := range items {
+		row := availability[it.ID.Bytes]
+		itemID := cart.UUIDString(it.ID)
+		stock := row.VariantStock
+		if row.IsBundle {
+			// A bundle is limited by its scarcest component.
+			stock = pgtype.Int4{Int32: row.BundleStock, Valid: stock.Valid}
+		}
+		status := s.lineStatus(row)
+		switch {
+		case !row.ProductAvailable && status != catalog.AvailabilityPreorder:
+			issues = append(issues, Issue{Code: IssueProductUnavailable, Message: fmt.Sprintf("%s is no longer available", it.Title), ItemID: itemID})
+		case !catalog.Sellable(status):
+			issues = append(issues, Issue{Code: IssueProductUnavailable, Message: fmt.Sprintf("%s is not available to order", it.Title), ItemID: itemID, Details: map[string]any{"status": status}})
+		case status == catalog.AvailabilityPreorder:
+		case it.VariantID.Valid && (!stock.Valid || stock.Int32 < it.Qty):
+			issues = append(issues, Issue{Code: IssueOutOfStock, Message: fmt.Sprintf("only %d of %s left in stock", max(stock.Int32, 0), it.Title), ItemID: itemID})
+		}
+	}
+	return issues
+}
+
+// quoteShipping checks the selected shipping option against the courier
+// policy and a fresh quote and returns it with the quoted price. A shipping rule that prices the cart
+// replaces the quote; without a rate client or destination the submitted
+// option is used as is.
+func (s *Service) quoteShipping(ctx context.Context, cartID pgtype.UUID, tenantID string, in PreviewInput, decision shipping.Decision, issues *[]Issue) (ShipOpt, error) {
+	selected := in.Shipping
+	if selected.Price < 0 {
+		selected.Price = 0
+	}
+	if strings.TrimSpace(selected.Courier) == "" || strings.TrimSpace(selected.Service) == "" {
+		*issues = append(*issues, Issue{Code: IssueShippingRequired, Message: "select a shipping courier and service"})
+		return selected, nil
+	}
+	policy, err := s.courierPolicy(ctx, s.Q, tenantID)
+	if err != nil {
+		return ShipOpt{}, err
+	}
+	if !policy.Offers(selected.Courier, selected.Service, in.Destination, in.Address.PostalCode, in.Address.City) {
+		*issues = append(*issues, Issue{Code: IssueNoShippingOptions, Message: fmt.Sprintf("%s %s is not offered for this address", selected.Courier, selected.Service)})
+		return selected, nil
+	}
+	if !decision.Quoted() {
+		selected.Price = int64(decision.Price)
+		return selected, nil
+	}
+	destination := strings.TrimSpace(in.Destination)
+	if destination == "" {
+		destination = strings.TrimSpace(in.Address.PostalCode)
+	}
+	if destination == "" {
+		destination = strings.TrimSpace(in.Address.City)
+	}
+	if s.Shipping == nil || destination == "" {
+		return selected, nil
+	}
+	parcel, err := cart.Parcel(ctx, s.Q, cartID, s.Parcel)
+	if err != nil {
+		return ShipOpt{}, err
+	}
+	quoteCtx, cancel := ctx, context.CancelFunc(func() {})
+	if s.ShippingTimeout > 0 {
+		quoteCtx, cancel = context.WithTimeout(ctx, s.ShippingTimeout)
+	}
+	rates, err := s.Shipping.Rates(quoteCtx, shipping.RateReq{
+		Origin:      s.ShippingOrigin,
+		Destination: destination,
+		Parcel:      parcel,
+		Courier:     selected.Courier,
+	})
+	cancel()
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return ShipOpt{}, ctxErr
+		}
+		if resilience.IsTimeout(err) {
+			*issues = append(*issues, Issue{Code: IssueProviderTimeout, Message: "the shipping provider timed out, try again"})
+			return selected, nil
+		}
+		*issues = append(*issues, Issue{Code: IssueShippingUnavailable, Message: "shipping rates are unavailable, try again later"})
+		return selected, nil
+	}
+	rates = policy.Apply(rates, selected.Courier, in.Destination, in.Address.PostalCode, in.Address.City)
+	if len(rates) == 0 {
+		*issues = append(*issues, Issue{Code: IssueNoShippingOptions, Message: "no shipping options are available for this destination"})
+		return selected, nil
+	}
+	for _, rate := range rates {
+		if strings.EqualFold(rate.Service, selected.Service) {
+			selected.Price = rate.Price
+			selected.ETD = rate.ETD
+			return selected, nil
+		}
+	}
+	*issues = append(*issues, Issue{Code: IssueShippingUnavailable, Message: fmt.Sprintf("%s %s does not deliver to this address", selected.Courier, selected.Service)})
+	return selected, nil
+}
+
+func voucherIssueMessage(err error) string {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "voucher not found"
+	}
+	if errors.Is(err, cart.ErrInvalidInput) {
+		msg := err.Error()
+		if i := strings.Index(msg, ": "); i > 0 {
+			msg = msg[:i]
+		}
+		return msg
+	}
+	return "voucher could not be applied"
+}
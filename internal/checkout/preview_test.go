@@ -0,0 +1,434 @@
+package checkout
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/noah-isme/backend-toko/internal/cart"
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/reqcache"
+	"github.com/noah-isme/backend-toko/internal/shipping"
+	"github.com/noah-isme/backend-toko/internal/tenant"
+)
+
+// readOnlyDB serves canned rows keyed by sqlc query name and fails every
+// write, so a passing test proves the code under test did not mutate state.
+type readOnlyDB struct {
+	rows   map[string][]any
+	writes int
+	reads  map[string]int
+}
+
+func (d *readOnlyDB) read(name string) {
+	if d.reads == nil {
+		d.reads = map[string]int{}
+	}
+	d.reads[name]++
+}
+
+func queryName(sql string) string {
+	return strings.Fields(strings.TrimPrefix(sql, "-- name:"))[0]
+}
+
+func (d *readOnlyDB) Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error) {
+	d.writes++
+	return pgconn.CommandTag{}, errors.New("write not allowed")
+}
+
+func (d *readOnlyDB) Query(_ context.Context, sql string, _ ...interface{}) (pgx.Rows, error) {
+	d.read(queryName(sql))
+	return &cannedRows{items: d.rows[queryName(sql)], pos: -1}, nil
+}
+
+func (d *readOnlyDB) QueryRow(_ context.Context, sql string, _ ...interface{}) pgx.Row {
+	name := queryName(sql)
+	if strings.HasPrefix(name, "Create") || strings.HasPrefix(name, "Update") {
+		d.writes++
+	}
+	d.read(name)
+	rows := d.rows[name]
+	if len(rows) > 1 {
+		rows = rows[:1]
+	}
+	return &cannedRows{items: rows, pos: 0}
+}
+
+func (d *readOnlyDB) SendBatch(context.Context, *pgx.Batch) pgx.BatchResults {
+	d.writes++
+	return nil
+}
+
+// cannedRows scans struct fields in declaration order, matching sqlc's column order.
+type cannedRows struct {
+	pgx.Rows
+	items []any
+	pos   int
+}
+
+func (r *cannedRows) Next() bool {
+	r.pos++
+	return r.pos < len(r.items)
+}
+
+func (r *cannedRows) Scan(dest ...any) error {
+	if r.pos < 0 || r.pos >= len(r.items) {
+		return pgx.ErrNoRows
+	}
+	src := reflect.ValueOf(r.items[r.pos])
+	for i, d := range dest {
+		reflect.ValueOf(d).Elem().Set(src.Field(i))
+	}
+	return nil
+}
+
+func (r *cannedRows) Err() error { return nil }
+func (r *cannedRows) Close()     {}
+
+func previewFixture(t *testing.T) (*Service, *readOnlyDB, context.Context, string, string) {
+	t.Helper()
+	userID := "0b6f4a5e-8a57-4f0e-9d51-7c1b2f3e4d5a"
+	cartID := "5d0c1b2a-3e4f-4a5b-8c6d-7e8f9a0b1c2d"
+	uID, _ := cart.ToUUID(userID)
+	cID, _ := cart.ToUUID(cartID)
+	inStock := pgtype.UUID{Bytes: [16]byte{0x11}, Valid: true}
+	lowStock := pgtype.UUID{Bytes: [16]byte{0x22}, Valid: true}
+	variant := pgtype.UUID{Bytes: [16]byte{0x33}, Valid: true}
+
+	db := &readOnlyDB{rows: map[string][]any{
+		"GetCartByID": {dbgen.Cart{ID: cID, UserID: uID, AppliedVoucherCode: pgtype.Text{String: "HEMAT10", Valid: true}}},
+		"ListCartItems": {
+			dbgen.CartItem{ID: inStock, CartID: cID, ProductID: inStock, Title: "Kopi", Qty: 2, UnitPrice: 50000, Subtotal: 100000},
+			dbgen.CartItem{ID: lowStock, CartID: cID, ProductID: lowStock, VariantID: variant, Title: "Teh", Qty: 3, UnitPrice: 20000, Subtotal: 60000},
+		},
+		"ListCartItemAvailability": {
+			dbgen.ListCartItemAvailabilityRow{ID: inStock, ProductAvailable: true},
+			dbgen.ListCartItemAvailabilityRow{ID: lowStock, ProductAvailable: true, VariantStock: pgtype.Int4{Int32: 1, Valid: true}},
+		},
+		"GetVoucherByCode": {dbgen.Voucher{Code: "HEMAT10", Kind: dbgen.DiscountKindPercent, PercentBps: pgtype.Int4{Int32: 1000, Valid: true}}},
+	}}
+	q := dbgen.New(db)
+	svc := &Service{
+		Q:        q,
+		CartSvc:  &cart.Service{Q: q},
+		TaxBps:   1100,
+		Currency: "IDR",
+		Shipping: shipping.MockClient{},
+	}
+	ctx := tenant.WithTenant(context.Background(), "9f8e7d6c-5b4a-4392-8170-6f5e4d3c2b1a")
+	return svc, db, ctx, userID, cartID
+}
+
+func TestPreviewPricesCartAndReportsIssuesWithoutWriting(t *testing.T) {
+	svc, db, ctx, userID, cartID := previewFixture(t)
+
+	in := PreviewInput{Input: Input{
+		CartID:   cartID,
+		Address:  Addr{City: "Kediri", PostalCode: "64111"},
+		Shipping: ShipOpt{Courier: "jne", Service: "reg", Price: 1},
+	}}
+	out, err := svc.Preview(ctx, &userID, in)
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if db.writes != 0 {
+		t.Fatalf("preview must not write, got %d writes", db.writes)
+	}
+	if out.Valid || len(out.Issues) != 1 || out.Issues[0].Code != IssueOutOfStock || out.Issues[0].ItemID == "" {
+		t.Fatalf("expected a single out-of-stock issue, got %+v", out.Issues)
+	}
+	if out.Shipping.Price != 15000 || out.Shipping.ETD != "2-3" {
+		t.Fatalf("expected quoted REG rate, got %+v", out.Shipping)
+	}
+	// 160000 subtotal, 10% voucher, 11% tax on 144000, plus shipping.
+	want := PreviewPricing{Subtotal: 160000, Discount: 16000, Tax: 15840, Shipping: 15000, Total: 174840}
+	if out.Pricing != want {
+		t.Fatalf("unexpected pricing %+v, want %+v", out.Pricing, want)
+	}
+	if out.VoucherCode == nil || *out.VoucherCode != "HEMAT10" {
+		t.Fatalf("expected applied voucher in result, got %v", out.VoucherCode)
+	}
+}
+
+func TestPreviewLoadsCartOncePerRequest(t *testing.T) {
+	in := func(cartID string) PreviewInput {
+		return PreviewInput{Input: Input{
+			CartID:   cartID,
+			Address:  Addr{City: "Kediri", PostalCode: "64111"},
+			Shipping: ShipOpt{Courier: "jne", Service: "reg", Price: 1},
+		}}
+	}
+
+	svc, db, ctx, userID, cartID := previewFixture(t)
+	if _, err := svc.Preview(reqcache.WithCache(ctx), &userID, in(cartID)); err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if db.reads["GetCartByID"] != 2 || db.reads["ListCartItems"] != 2 {
+		t.Fatalf("expected the voucher evaluation to reload the cart when not opted in, got %v", db.reads)
+	}
+
+	svc, db, ctx, userID, cartID = previewFixture(t)
+	svc.RequestCache = true
+	svc.CartSvc.RequestCache = true
+	out, err := svc.Preview(reqcache.WithCache(ctx), &userID, in(cartID))
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if db.reads["GetCartByID"] != 1 || db.reads["ListCartItems"] != 1 {
+		t.Fatalf("expected one cart and one line lookup, got %v", db.reads)
+	}
+	if out.Pricing.Discount != 16000 {
+		t.Fatalf("expected the voucher to price from the cached cart, got %+v", out.Pricing)
+	}
+}
+
+func TestPreviewReportsInvalidVoucherAndShipping(t *testing.T) {
+	svc, _, ctx, userID, cartID := previewFixture(t)
+	svc.Q = dbgen.New(&readOnlyDB{rows: map[string][]any{
+		"GetCartByID":   {dbgen.Cart{}},
+		"ListCartItems": {dbgen.CartItem{ID: pgtype.UUID{Bytes: [16]byte{1}, Valid: true}, Qty: 1, UnitPrice: 10000, Subtotal: 10000}},
+		"ListCartItemAvailability": {
+			dbgen.ListCartItemAvailabilityRow{ID: pgtype.UUID{Bytes: [16]byte{1}, Valid: true}, ProductAvailable: true},
+		},
+	}})
+	svc.CartSvc = &cart.Service{Q: svc.Q}
+
+	code := "NOPE"
+	out, err := svc.Preview(ctx, &userID, PreviewInput{
+		Input:       Input{CartID: cartID, Address: Addr{PostalCode: "64111"}, Shipping: ShipOpt{Courier: "jne", Service: "OKE"}},
+		VoucherCode: &code,
+	})
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	codes := make([]string, 0, len(out.Issues))
+	for _, issue := range out.Issues {
+		codes = append(codes, issue.Code)
+	}
+	if !reflect.DeepEqual(codes, []string{IssueVoucherInvalid, IssueShippingUnavailable}) {
+		t.Fatalf("unexpected issues %+v", out.Issues)
+	}
+	if out.Issues[0].Message != "voucher not found" {
+		t.Fatalf("unexpected voucher message %q", out.Issues[0].Message)
+	}
+	if out.Pricing.Discount != 0 || out.Pricing.Total != 11100 {
+		t.Fatalf("expected undiscounted pricing, got %+v", out.Pricing)
+	}
+}
+
+func TestPreviewChecksBundleStockAgainstComponents(t *testing.T) {
+	svc, db, ctx, userID, cartID := previewFixture(t)
+	rows := db.rows["ListCartItemAvailability"]
+	// The bundle row has one unit of its own stock, but its components can
+	// fill three, so the line for three is fine.
+	bundle := rows[1].(dbgen.ListCartItemAvailabilityRow)
+	bundle.IsBundle = true
+	bundle.BundleStock = 3
+	rows[1] = bundle
+
+	in := PreviewInput{Input: Input{CartID: cartID, Shipping: ShipOpt{Courier: "jne", Service: "REG"}}}
+	out, err := svc.Preview(ctx, &userID, in)
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if !out.Valid {
+		t.Fatalf("expected bundle within component stock to pass, got %+v", out.Issues)
+	}
+
+	bundle.BundleStock = 0
+	rows[1] = bundle
+	out, err = svc.Preview(ctx, &userID, in)
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if len(out.Issues) != 1 || out.Issues[0].Code != IssueOutOfStock {
+		t.Fatalf("expected an empty component to block the bundle, got %+v", out.Issues)
+	}
+}
+
+func TestPreviewAppliesAvailabilityWindows(t *testing.T) {
+	svc, db, ctx, userID, cartID := previewFixture(t)
+	from := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	now := from.Add(-time.Hour)
+	svc.Now = func() time.Time { return now }
+	rows := db.rows["ListCartItemAvailability"]
+	// The short-stocked line is a preorder before its window opens, so its
+	// stock does not matter.
+	teh := rows[1].(dbgen.ListCartItemAvailabilityRow)
+	teh.AvailableFrom = pgtype.Timestamptz{Time: from, Valid: true}
+	teh.Preorder = true
+	rows[1] = teh
+
+	in := PreviewInput{Input: Input{CartID: cartID, Shipping: ShipOpt{Courier: "jne", Service: "REG"}}}
+	out, err := svc.Preview(ctx, &userID, in)
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if !out.Valid || !out.Items[1].Preorder || out.Items[0].Preorder {
+		t.Fatalf("expected the preorder line to pass, got %+v items %+v", out.Issues, out.Items)
+	}
+
+	// Once its window has ended the product can no longer be ordered.
+	kopi := rows[0].(dbgen.ListCartItemAvailabilityRow)
+	kopi.AvailableTo = pgtype.Timestamptz{Time: now, Valid: true}
+	rows[0] = kopi
+	out, err = svc.Preview(ctx, &userID, in)
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if out.Valid || len(out.Issues) != 1 || out.Issues[0].Code != IssueProductUnavailable {
+		t.Fatalf("expected the ended product to be unavailable, got %+v", out.Issues)
+	}
+}
+
+func TestPreviewAppliesShippingRules(t *testing.T) {
+	svc, db, ctx, userID, cartID := previewFixture(t)
+	svc.ShippingRules = shipping.DefaultRules(100000)
+	in := PreviewInput{Input: Input{
+		CartID:   cartID,
+		Address:  Addr{City: "Kediri", PostalCode: "64111"},
+		Shipping: ShipOpt{Courier: "jne", Service: "REG", Price: 15000},
+	}}
+
+	// 144000 after the voucher clears the default threshold.
+	out, err := svc.Preview(ctx, &userID, in)
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if out.ShippingRule.Rule != "free_shipping" || out.Shipping.Price != 0 || out.Pricing.Shipping != 0 {
+		t.Fatalf("expected free shipping, got rule %+v shipping %+v", out.ShippingRule, out.Shipping)
+	}
+
+	// The tenant's own rules replace the default, and a cart short of the
+	// threshold keeps the provider quote.
+	db.rows["GetTenantSetting"] = []any{struct{ Value []byte }{[]byte(`[{"name":"jatim","kind":"free_over","regions":["64*"],"threshold":200000}]`)}}
+	out, err = svc.Preview(ctx, &userID, in)
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if !out.ShippingRule.Quoted() || out.ShippingRule.Remaining != 56000 || out.Pricing.Shipping != 15000 {
+		t.Fatalf("expected quoted shipping 56000 short of free, got rule %+v pricing %+v", out.ShippingRule, out.Pricing)
+	}
+}
+
+// hungShipping blocks until the quote context ends, like an upstream that
+// never answers.
+type hungShipping struct{}
+
+func (hungShipping) Rates(ctx context.Context, _ shipping.RateReq) ([]shipping.Rate, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestPreviewReportsShippingProviderTimeout(t *testing.T) {
+	svc, _, ctx, userID, cartID := previewFixture(t)
+	svc.Shipping = hungShipping{}
+	svc.ShippingTimeout = 20 * time.Millisecond
+
+	out, err := svc.Preview(ctx, &userID, PreviewInput{Input: Input{CartID: cartID, Address: Addr{PostalCode: "64111"}, Shipping: ShipOpt{Courier: "jne", Service: "REG"}}})
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	var timedOut bool
+	for _, issue := range out.Issues {
+		timedOut = timedOut || issue.Code == IssueProviderTimeout
+	}
+	if !timedOut {
+		t.Fatalf("expected a provider timeout issue, got %+v", out.Issues)
+	}
+}
+
+// recordingShipping remembers the last rate request and returns canned rates.
+type recordingShipping struct {
+	shipping.MockClient
+	req *shipping.RateReq
+}
+
+func (c recordingShipping) Rates(ctx context.Context, r shipping.RateReq) ([]shipping.Rate, error) {
+	*c.req = r
+	return c.MockClient.Rates(ctx, r)
+}
+
+func TestPreviewQuotesHeavyCartByItsWeight(t *testing.T) {
+	svc, db, ctx, userID, cartID := previewFixture(t)
+	var req shipping.RateReq
+	svc.Shipping = recordingShipping{req: &req}
+	svc.Parcel = shipping.ParcelConfig{DefaultItemWeightGram: 250, VolumetricDivisor: 6000}
+	db.rows["ListCartItemParcels"] = []any{
+		dbgen.ListCartItemParcelsRow{Qty: 2},
+		dbgen.ListCartItemParcelsRow{
+			Qty:        3,
+			WeightGram: pgtype.Int4{Int32: 7000, Valid: true},
+			LengthCm:   pgtype.Int4{Int32: 50, Valid: true},
+			WidthCm:    pgtype.Int4{Int32: 40, Valid: true},
+			HeightCm:   pgtype.Int4{Int32: 30, Valid: true},
+		},
+	}
+
+	_, err := svc.Preview(ctx, &userID, PreviewInput{Input: Input{CartID: cartID, Address: Addr{PostalCode: "64111"}, Shipping: ShipOpt{Courier: "jne", Service: "REG"}}})
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	// Two unweighed units at 250g plus three 7kg units; 3×60000cm³ / 6000.
+	if req.WeightGram != 21500 || req.VolumetricWeightGram != 30000 || req.ChargeableWeightGram() != 30000 {
+		t.Fatalf("unexpected parcel %+v", req.Parcel)
+	}
+	if req.LengthCm != 50 || req.WidthCm != 40 || req.HeightCm != 90 || req.Destination != "64111" {
+		t.Fatalf("unexpected rate request %+v", req)
+	}
+}
+
+func TestPreviewReportsCourierOutsidePolicy(t *testing.T) {
+	svc, _, ctx, userID, cartID := previewFixture(t)
+	svc.CourierPolicy = shipping.CourierPolicy{Rules: []shipping.CourierRule{{Regions: []string{"64*"}, Deny: []string{"jne:YES"}}}}
+
+	out, err := svc.Preview(ctx, &userID, PreviewInput{Input: Input{CartID: cartID, Address: Addr{PostalCode: "64111"}, Shipping: ShipOpt{Courier: "jne", Service: "YES"}}})
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if !hasIssue(out.Issues, IssueNoShippingOptions) {
+		t.Fatalf("expected a no shipping options issue, got %+v", out.Issues)
+	}
+
+	svc.CourierPolicy = shipping.CourierPolicy{Rules: []shipping.CourierRule{{Allow: []string{"jne:REG"}}}}
+	out, err = svc.Preview(ctx, &userID, PreviewInput{Input: Input{CartID: cartID, Address: Addr{PostalCode: "64111"}, Shipping: ShipOpt{Courier: "jne", Service: "REG"}}})
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if hasIssue(out.Issues, IssueNoShippingOptions) || out.Shipping.Price != 15000 {
+		t.Fatalf("expected the allowed REG rate, got %+v %+v", out.Shipping, out.Issues)
+	}
+}
+
+func TestCheckCourierMatchesDestinationCode(t *testing.T) {
+	svc, _, ctx, _, _ := previewFixture(t)
+	svc.CourierPolicy = shipping.CourierPolicy{Rules: []shipping.CourierRule{{Regions: []string{"JKT*"}, Deny: []string{"jne:YES"}}}}
+	tenantID, _ := tenant.FromContext(ctx)
+	opt := ShipOpt{Courier: "jne", Service: "YES"}
+
+	if err := svc.checkCourier(ctx, svc.Q, tenantID, opt, Addr{City: "Jakarta"}, ""); err != nil {
+		t.Fatalf("expected no rule to match without a destination, got %v", err)
+	}
+	err := svc.checkCourier(ctx, svc.Q, tenantID, opt, Addr{City: "Jakarta"}, "JKT01")
+	var appErr *common.AppError
+	if !errors.As(err, &appErr) || appErr.Code != common.CodeNoShippingOptions {
+		t.Fatalf("expected NO_SHIPPING_OPTIONS for the destination rule, got %v", err)
+	}
+}
+
+func hasIssue(issues []Issue, code string) bool {
+	for _, issue := range issues {
+		if issue.Code == code {
+			return true
+		}
+	}
+	return false
+}
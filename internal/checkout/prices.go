@@ -0,0 +1,116 @@
+package checkout
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/noah-isme/backend-toko/internal/cart"
+	"github.com/noah-isme/backend-toko/internal/catalog"
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+// PriceChange is a cart line whose catalog price moved beyond the tolerance
+// since it was added.
+type PriceChange struct {
+	ItemID   string       `json:"itemId"`
+	Title    string       `json:"title"`
+	OldPrice common.Int64 `json:"oldPrice"`
+	NewPrice common.Int64 `json:"newPrice"`
+}
+
+func errPriceChanged(changes []PriceChange) error {
+	msg := fmt.Sprintf("the price of %s changed; review the cart and confirm", changes[0].Title)
+	if len(changes) > 1 {
+		msg = fmt.Sprintf("the prices of %d items changed; review the cart and confirm", len(changes))
+	}
+	err := common.NewAppError(common.CodePriceChanged, msg, http.StatusConflict, nil)
+	err.Details = map[string]any{"items": changes}
+	return err
+}
+
+// catalogPrices returns the current catalog unit price of each cart line,
+// keyed by cart item ID. A bundle is priced from its components as the cart
+// prices it. Lines whose product is gone have no entry.
+func catalogPrices(ctx context.Context, q *dbgen.Queries, cartID pgtype.UUID) (map[[16]byte]int64, error) {
+	rows, err := q.ListCartItemCatalogPrices(ctx, cartID)
+	if err != nil {
+		return nil, err
+	}
+	var variantIDs []pgtype.UUID
+	for _, row := range rows {
+		if row.VariantPrice.Valid {
+			variantIDs = append(variantIDs, row.VariantID)
+		}
+	}
+	var bundles map[pgtype.UUID]*catalog.Bundle
+	if len(variantIDs) > 0 {
+		components, err := q.ListBundleComponentsByVariantIDs(ctx, variantIDs)
+		if err != nil {
+			return nil, err
+		}
+		bundles = catalog.BundlesFromRows(components)
+	}
+	prices := make(map[[16]byte]int64, len(rows))
+	for _, row := range rows {
+		switch {
+		case row.VariantPrice.Valid:
+			price := row.VariantPrice.Int64
+			if bundle, ok := bundles[row.VariantID]; ok {
+				price = bundle.UnitPrice(price)
+			}
+			prices[row.ID.Bytes] = price
+		case !row.VariantID.Valid && row.ProductPrice.Valid:
+			prices[row.ID.Bytes] = row.ProductPrice.Int64
+		}
+	}
+	return prices, nil
+}
+
+// withinTolerance reports whether a cart price may still be charged against
+// the current catalog price.
+func (s *Service) withinTolerance(cartPrice, catalogPrice int64) bool {
+	diff := cartPrice - catalogPrice
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff*10000 <= catalogPrice*int64(max(s.PriceToleranceBps, 0))
+}
+
+// priceChanges compares each line with its catalog price and returns the
+// lines that moved beyond the tolerance. Lines without a catalog price are
+// left to the availability checks.
+func (s *Service) priceChanges(items []dbgen.CartItem, prices map[[16]byte]int64) []PriceChange {
+	var changes []PriceChange
+	for _, it := range items {
+		price, ok := prices[it.ID.Bytes]
+		if !ok || s.withinTolerance(it.UnitPrice, price) {
+			continue
+		}
+		changes = append(changes, PriceChange{
+			ItemID:   cart.UUIDString(it.ID),
+			Title:    it.Title,
+			OldPrice: common.Int64(it.UnitPrice),
+			NewPrice: common.Int64(price),
+		})
+	}
+	return changes
+}
+
+// repriceCart moves each changed line to its catalog price, so the cart the
+// shopper reviews again shows what checkout will charge.
+func repriceCart(ctx context.Context, q *dbgen.Queries, changes []PriceChange) error {
+	for _, c := range changes {
+		id, err := cart.ToUUID(c.ItemID)
+		if err != nil {
+			return err
+		}
+		if err := q.RepriceCartItem(ctx, dbgen.RepriceCartItemParams{ID: id, UnitPrice: int64(c.NewPrice)}); err != nil {
+			return fmt.Errorf("reprice cart item: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package checkout
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/noah-isme/backend-toko/internal/catalog"
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+func TestPriceChangesHonourTolerance(t *testing.T) {
+	id := pgtype.UUID{Bytes: [16]byte{1}, Valid: true}
+	items := []dbgen.CartItem{{ID: id, Title: "Kopi", UnitPrice: 50000}}
+	cases := []struct {
+		name         string
+		toleranceBps int
+		catalog      int64
+		changed      bool
+	}{
+		{"unchanged", 0, 50000, false},
+		{"any drift without tolerance", 0, 50001, true},
+		{"rise within tolerance", 200, 51000, false},
+		{"drop within tolerance", 200, 49020, false},
+		{"rise beyond tolerance", 200, 51500, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			svc := &Service{PriceToleranceBps: tc.toleranceBps}
+			changes := svc.priceChanges(items, map[[16]byte]int64{id.Bytes: tc.catalog})
+			if got := len(changes) > 0; got != tc.changed {
+				t.Fatalf("expected changed=%v, got %+v", tc.changed, changes)
+			}
+			if tc.changed && (changes[0].OldPrice != 50000 || changes[0].NewPrice != common.Int64(tc.catalog)) {
+				t.Fatalf("unexpected change %+v", changes[0])
+			}
+		})
+	}
+	if changes := (&Service{}).priceChanges(items, nil); len(changes) != 0 {
+		t.Fatalf("lines without a catalog price must be skipped, got %+v", changes)
+	}
+}
+
+func TestCatalogPricesPriceBundlesFromComponents(t *testing.T) {
+	plain := pgtype.UUID{Bytes: [16]byte{1}, Valid: true}
+	variantLine := pgtype.UUID{Bytes: [16]byte{2}, Valid: true}
+	bundleLine := pgtype.UUID{Bytes: [16]byte{3}, Valid: true}
+	variant := pgtype.UUID{Bytes: [16]byte{0x22}, Valid: true}
+	bundle := pgtype.UUID{Bytes: [16]byte{0x33}, Valid: true}
+	q := dbgen.New(&readOnlyDB{rows: map[string][]any{
+		"ListCartItemCatalogPrices": {
+			dbgen.ListCartItemCatalogPricesRow{ID: plain, ProductPrice: pgtype.Int8{Int64: 50000, Valid: true}},
+			dbgen.ListCartItemCatalogPricesRow{ID: variantLine, VariantID: variant, ProductPrice: pgtype.Int8{Int64: 50000, Valid: true}, VariantPrice: pgtype.Int8{Int64: 55000, Valid: true}},
+			dbgen.ListCartItemCatalogPricesRow{ID: bundleLine, VariantID: bundle, VariantPrice: pgtype.Int8{Int64: 1, Valid: true}},
+		},
+		"ListBundleComponentsByVariantIDs": {
+			dbgen.ListBundleComponentsByVariantIDsRow{BundleVariantID: bundle, Pricing: catalog.BundlePricingSum, Qty: 2, Price: 20000},
+			dbgen.ListBundleComponentsByVariantIDsRow{BundleVariantID: bundle, Pricing: catalog.BundlePricingSum, Qty: 1, Price: 15000},
+		},
+	}})
+	prices, err := catalogPrices(context.Background(), q, pgtype.UUID{Valid: true})
+	if err != nil {
+		t.Fatalf("catalog prices: %v", err)
+	}
+	want := map[[16]byte]int64{plain.Bytes: 50000, variantLine.Bytes: 55000, bundleLine.Bytes: 55000}
+	for id, price := range want {
+		if prices[id] != price {
+			t.Fatalf("line %x: expected %d, got %d", id[0], price, prices[id])
+		}
+	}
+}
+
+func TestPreviewReportsPriceChangesWithoutWriting(t *testing.T) {
+	svc, db, ctx, userID, cartID := previewFixture(t)
+	kopi := pgtype.UUID{Bytes: [16]byte{0x11}, Valid: true}
+	db.rows["ListCartItemCatalogPrices"] = []any{
+		dbgen.ListCartItemCatalogPricesRow{ID: kopi, ProductPrice: pgtype.Int8{Int64: 55000, Valid: true}},
+	}
+
+	out, err := svc.Preview(ctx, &userID, PreviewInput{Input: Input{CartID: cartID, Shipping: ShipOpt{Courier: "jne", Service: "reg"}}})
+	if err != nil {
+		t.Fatalf("preview: %v", err)
+	}
+	if db.writes != 0 {
+		t.Fatalf("preview must not reprice the cart, got %d writes", db.writes)
+	}
+	var found *Issue
+	for i := range out.Issues {
+		if out.Issues[i].Code == IssuePriceChanged {
+			found = &out.Issues[i]
+		}
+	}
+	if found == nil || found.ItemID == "" {
+		t.Fatalf("expected a price change issue, got %+v", out.Issues)
+	}
+	details, _ := found.Details.(map[string]any)
+	if details["oldPrice"] != common.Int64(50000) || details["newPrice"] != common.Int64(55000) {
+		t.Fatalf("unexpected details %+v", found.Details)
+	}
+}
@@ -5,16 +5,23 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/noah-isme/backend-toko/internal/cart"
+	"github.com/noah-isme/backend-toko/internal/catalog"
+	"github.com/noah-isme/backend-toko/internal/common"
 	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
 	"github.com/noah-isme/backend-toko/internal/events"
 	"github.com/noah-isme/backend-toko/internal/pricing"
+	"github.com/noah-isme/backend-toko/internal/shipping"
 	"github.com/noah-isme/backend-toko/internal/tenant"
+	"github.com/noah-isme/backend-toko/internal/voucher"
 )
 
 type Addr struct {
@@ -41,6 +48,9 @@ type Input struct {
 	Shipping       ShipOpt `json:"shipping"`
 	Notes          *string `json:"notes"`
 	PaymentChannel *string `json:"paymentChannel"`
+	// Destination is the shipping destination code the options were quoted
+	// for; region rules also match the address postal code and city.
+	Destination string `json:"destination"`
 }
 
 type Output struct {
@@ -60,33 +70,51 @@ type Service struct {
 	TaxBps   int
 	Currency string
 	Events   *events.Bus
+	// Shipping quotes rates for Preview; ShippingOrigin is the quote origin.
+	Shipping       shipping.Client
+	ShippingOrigin string
+	// ShippingTimeout bounds each rate quote; zero leaves only the request's
+	// own deadline.
+	ShippingTimeout time.Duration
+	// ShippingRules are the default shipping rules; tenants may replace them
+	// under shipping.TenantRulesKey.
+	ShippingRules []shipping.Rule
+	// Parcel weighs cart lines for the Preview quote.
+	Parcel shipping.ParcelConfig
+	// CourierPolicy limits the couriers customers may pick; tenants may
+	// replace it under shipping.TenantCourierPolicyKey.
+	CourierPolicy shipping.CourierPolicy
+	// Limits are the default order value bounds; tenants may override them
+	// under TenantOrderLimitsKey.
+	Limits OrderLimits
+	// Now overrides the clock used for availability windows; nil means
+	// time.Now.
+	Now func() time.Time
+	// Rounding rounds tax; the zero value floors.
+	Rounding pricing.Rounding
+	// PriceToleranceBps is how far, in basis points of the catalog price, a
+	// cart price may drift and still be charged. Lines beyond it fail
+	// checkout with PRICE_CHANGED; zero requires an exact match.
+	PriceToleranceBps int
+	// RequestCache memoizes the cart and its lines for the request, so the
+	// voucher evaluation in Preview reuses them instead of loading them again.
+	RequestCache bool
+}
+
+func (s *Service) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
 }
 
 func (s *Service) Create(ctx context.Context, userID *string, in Input) (Output, error) {
 	if s == nil || s.Q == nil || s.Pool == nil {
 		return Output{}, errors.New("checkout service not configured")
 	}
-	if userID == nil || *userID == "" {
-		return Output{}, errors.New("user is required for checkout")
-	}
-	if in.CartID == "" {
-		return Output{}, errors.New("cartId is required")
-	}
-	tenantID, ok := tenant.FromContext(ctx)
-	if !ok || tenantID == "" {
-		return Output{}, errors.New("tenant is required")
-	}
-	tID, err := cart.ToUUID(tenantID)
+	cID, uID, tID, err := s.resolveScope(ctx, userID, in.CartID)
 	if err != nil {
-		return Output{}, fmt.Errorf("invalid tenant id: %w", err)
-	}
-	cID, err := cart.ToUUID(in.CartID)
-	if err != nil {
-		return Output{}, fmt.Errorf("invalid cart id: %w", err)
-	}
-	uID, err := cart.ToUUID(*userID)
-	if err != nil {
-		return Output{}, fmt.Errorf("invalid user id: %w", err)
+		return Output{}, err
 	}
 	tx, err := s.Pool.BeginTx(ctx, pgx.TxOptions{})
 	if err != nil {
@@ -110,22 +138,78 @@ func (s *Service) Create(ctx context.Context, userID *string, in Input) (Output,
 	if len(items) == 0 {
 		return Output{}, errors.New("cart is empty")
 	}
-	pricingItems := make([]pricing.Item, 0, len(items))
+	availability, err := s.lineAvailability(ctx, qtx, cID)
+	if err != nil {
+		return Output{}, err
+	}
+	preorders := make(map[[16]byte]bool, len(items))
 	for _, it := range items {
-		pricingItems = append(pricingItems, pricing.Item{Qty: int(it.Qty), UnitPrice: pricing.Money(it.UnitPrice)})
+		switch status := s.lineStatus(availability[it.ID.Bytes]); {
+		case !catalog.Sellable(status):
+			appErr := common.NewAppError(common.CodeProductNotAvailable, fmt.Sprintf("%s is not available to order", it.Title), http.StatusUnprocessableEntity, nil)
+			appErr.Details = map[string]any{"itemId": cart.UUIDString(it.ID), "status": status}
+			return Output{}, appErr
+		case status == catalog.AvailabilityPreorder:
+			preorders[it.ID.Bytes] = true
+		}
+	}
+	prices, err := catalogPrices(ctx, qtx, cID)
+	if err != nil {
+		return Output{}, err
+	}
+	if changes := s.priceChanges(items, prices); len(changes) > 0 {
+		// Commit the repriced cart instead of an order, so the shopper can
+		// confirm the new prices and check out again.
+		if err := repriceCart(ctx, qtx, changes); err != nil {
+			return Output{}, err
+		}
+		if err := qtx.MarkCartChanged(ctx, dbgen.MarkCartChangedParams{ID: cID, ExpiresAt: cartRow.ExpiresAt}); err != nil {
+			return Output{}, err
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return Output{}, err
+		}
+		return Output{}, errPriceChanged(changes)
 	}
 	var discount int64
+	var coverage []bool
 	if cartRow.AppliedVoucherCode.Valid && cartRow.AppliedVoucherCode.String != "" && s.CartSvc != nil {
-		discount, _, err = s.CartSvc.EvaluateVoucher(ctx, cID, cartRow.AppliedVoucherCode.String)
+		var applied dbgen.Voucher
+		discount, applied, err = s.CartSvc.EvaluateVoucher(ctx, cID, cartRow.AppliedVoucherCode.String)
 		if err != nil {
 			discount = 0
+		} else if coverage, err = s.CartSvc.VoucherCoverage(ctx, items, applied); err != nil {
+			return Output{}, err
 		}
 	}
+	pricingItems := make([]pricing.Item, 0, len(items))
+	for i, it := range items {
+		// Lines outside a scoped voucher absorb none of its discount.
+		excluded := coverage != nil && !coverage[i]
+		pricingItems = append(pricingItems, pricing.Item{Qty: int(it.Qty), UnitPrice: pricing.Money(it.UnitPrice), Excluded: excluded})
+	}
 	shippingCost := in.Shipping.Price
 	if shippingCost < 0 {
 		shippingCost = 0
 	}
-	summary := pricing.Compute(pricingItems, pricing.Money(discount), s.TaxBps, pricing.Money(shippingCost))
+	decision, err := s.shippingDecision(ctx, qtx, cart.UUIDString(tID), pricing.Compute(pricingItems, pricing.Money(discount), s.TaxBps, 0, s.Rounding), in.Address, in.Destination)
+	if err != nil {
+		return Output{}, err
+	}
+	if !decision.Quoted() {
+		shippingCost = int64(decision.Price)
+	}
+	if err := s.checkCourier(ctx, qtx, cart.UUIDString(tID), in.Shipping, in.Address, in.Destination); err != nil {
+		return Output{}, err
+	}
+	summary := pricing.Compute(pricingItems, pricing.Money(discount), s.TaxBps, pricing.Money(shippingCost), s.Rounding)
+	limits, err := s.orderLimits(ctx, qtx, cart.UUIDString(tID))
+	if err != nil {
+		return Output{}, err
+	}
+	if err := limits.Check(summary, s.Currency); err != nil {
+		return Output{}, err
+	}
 	order, err := qtx.CreateOrder(ctx, dbgen.CreateOrderParams{
 		UserID:             uID,
 		CartID:             cID,
@@ -145,32 +229,22 @@ func (s *Service) Create(ctx context.Context, userID *string, in Input) (Output,
 	if err != nil {
 		return Output{}, err
 	}
-	for _, it := range items {
-		if err := qtx.CreateOrderItem(ctx, dbgen.CreateOrderItemParams{
-			OrderID:   order.ID,
-			ProductID: it.ProductID,
-			VariantID: it.VariantID,
-			Title:     it.Title,
-			Slug:      it.Slug,
-			Qty:       it.Qty,
-			UnitPrice: it.UnitPrice,
-			Subtotal:  it.Subtotal,
-		}); err != nil {
-			return Output{}, err
-		}
+	if err := insertOrderItems(ctx, qtx, order.ID, items, prices, preorders, summary.Allocations); err != nil {
+		return Output{}, err
+	}
+	if err := s.redeemVoucher(ctx, qtx, order, summary.Discount); err != nil {
+		return Output{}, err
 	}
 	if err := tx.Commit(ctx); err != nil {
 		return Output{}, err
 	}
 	if s.Events != nil {
 		user, _ := s.Q.GetUserByID(ctx, uID)
-		payload := map[string]any{
-			"orderId": cart.UUIDString(order.ID),
-			"userId":  *userID,
-			"total":   summary.Total,
-		}
-		if user.Email != "" {
-			payload["email"] = user.Email
+		payload := &events.OrderCreatedPayload{
+			OrderID: cart.UUIDString(order.ID),
+			UserID:  *userID,
+			Total:   summary.Total,
+			Email:   user.Email,
 		}
 		_, _ = s.Events.Emit(ctx, events.TopicOrderCreated, order.ID, payload)
 	}
@@ -183,6 +257,86 @@ func (s *Service) Create(ctx context.Context, userID *string, in Input) (Output,
 	return out, nil
 }
 
+// resolveScope validates and parses the cart, user, and tenant a checkout runs for.
+func (s *Service) resolveScope(ctx context.Context, userID *string, cartID string) (cID, uID, tID pgtype.UUID, err error) {
+	if userID == nil || *userID == "" {
+		return cID, uID, tID, errors.New("user is required for checkout")
+	}
+	if cartID == "" {
+		return cID, uID, tID, errors.New("cartId is required")
+	}
+	tenantID, ok := tenant.FromContext(ctx)
+	if !ok || tenantID == "" {
+		return cID, uID, tID, errors.New("tenant is required")
+	}
+	if tID, err = cart.ToUUID(tenantID); err != nil {
+		return cID, uID, tID, fmt.Errorf("invalid tenant id: %w", err)
+	}
+	if cID, err = cart.ToUUID(cartID); err != nil {
+		return cID, uID, tID, fmt.Errorf("invalid cart id: %w", err)
+	}
+	if uID, err = cart.ToUUID(*userID); err != nil {
+		return cID, uID, tID, fmt.Errorf("invalid user id: %w", err)
+	}
+	return cID, uID, tID, nil
+}
+
+// redeemVoucher records the order's voucher usage inside the checkout
+// transaction, so the usage row and count commit with the order or not at
+// all. Redemption is keyed by order, making a retried redemption a no-op.
+func (s *Service) redeemVoucher(ctx context.Context, q voucher.Querier, order dbgen.Order, discount int64) error {
+	if !order.AppliedVoucherCode.Valid || discount <= 0 {
+		return nil
+	}
+	redeemer := &voucher.Service{Q: q, Now: s.now, Rounding: s.Rounding}
+	if err := redeemer.Redeem(ctx, strings.TrimSpace(order.AppliedVoucherCode.String), order.ID, order.UserID, discount); err != nil {
+		return fmt.Errorf("redeem voucher: %w", err)
+	}
+	return nil
+}
+
+// insertOrderItems writes all order lines in a single pipelined batch so the
+// checkout transaction pays one round-trip regardless of cart size. Lines whose
+// cart item ID is in preorders are stored as preorders, which settlement does
+// not take stock for. prices holds the catalog price snapshot stored beside the
+// charged price, and discounts each line's share of the order discount.
+func insertOrderItems(ctx context.Context, q *dbgen.Queries, orderID pgtype.UUID, items []dbgen.CartItem, prices map[[16]byte]int64, preorders map[[16]byte]bool, discounts []pricing.Money) error {
+	if len(items) == 0 {
+		return nil
+	}
+	params := make([]dbgen.CreateOrderItemsParams, 0, len(items))
+	for i, it := range items {
+		var allocated int64
+		if i < len(discounts) {
+			allocated = discounts[i]
+		}
+		var catalogPrice pgtype.Int8
+		if price, ok := prices[it.ID.Bytes]; ok {
+			catalogPrice = pgtype.Int8{Int64: price, Valid: true}
+		}
+		params = append(params, dbgen.CreateOrderItemsParams{
+			OrderID:           orderID,
+			ProductID:         it.ProductID,
+			VariantID:         it.VariantID,
+			Title:             it.Title,
+			Slug:              it.Slug,
+			Qty:               it.Qty,
+			UnitPrice:         it.UnitPrice,
+			Subtotal:          it.Subtotal,
+			Preorder:          preorders[it.ID.Bytes],
+			DiscountAllocated: allocated,
+			CatalogUnitPrice:  catalogPrice,
+		})
+	}
+	var batchErr error
+	q.CreateOrderItems(ctx, params).Exec(func(i int, err error) {
+		if err != nil && batchErr == nil {
+			batchErr = fmt.Errorf("insert order item %d: %w", i, err)
+		}
+	})
+	return batchErr
+}
+
 func toJSON(v any) []byte {
 	if v == nil {
 		return nil
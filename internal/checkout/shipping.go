@@ -0,0 +1,46 @@
+package checkout
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/pricing"
+	"github.com/noah-isme/backend-toko/internal/shipping"
+)
+
+// shippingDecision evaluates the tenant's shipping rules for a cart priced at
+// summary and delivered to addr (or destination, when given).
+func (s *Service) shippingDecision(ctx context.Context, q *dbgen.Queries, tenantID string, summary pricing.Summary, addr Addr, destination string) (shipping.Decision, error) {
+	rules, err := shipping.TenantRules(ctx, q, tenantID, s.ShippingRules)
+	if err != nil {
+		return shipping.Decision{}, err
+	}
+	return shipping.Evaluate(rules, summary.Subtotal-summary.Discount, destination, addr.PostalCode, addr.City), nil
+}
+
+// courierPolicy returns the tenant's courier policy, or the service default.
+func (s *Service) courierPolicy(ctx context.Context, q *dbgen.Queries, tenantID string) (shipping.CourierPolicy, error) {
+	return shipping.TenantCourierPolicy(ctx, q, tenantID, s.CourierPolicy)
+}
+
+// checkCourier rejects a selected courier and service the tenant's courier
+// policy does not offer at the address with NO_SHIPPING_OPTIONS.
+func (s *Service) checkCourier(ctx context.Context, q *dbgen.Queries, tenantID string, opt ShipOpt, addr Addr, destination string) error {
+	if strings.TrimSpace(opt.Courier) == "" {
+		return nil
+	}
+	policy, err := s.courierPolicy(ctx, q, tenantID)
+	if err != nil {
+		return err
+	}
+	if policy.Offers(opt.Courier, opt.Service, destination, addr.PostalCode, addr.City) {
+		return nil
+	}
+	appErr := common.NewAppError(common.CodeNoShippingOptions, fmt.Sprintf("%s %s is not offered for this address", opt.Courier, opt.Service), http.StatusUnprocessableEntity, nil)
+	appErr.Details = map[string]any{"courier": opt.Courier, "service": opt.Service}
+	return appErr
+}
@@ -0,0 +1,98 @@
+package checkout
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/voucher"
+)
+
+// voucherDB keeps one voucher and its usage rows in memory so redemption can
+// be replayed against the state earlier calls left behind.
+type voucherDB struct {
+	voucher dbgen.Voucher
+	usages  map[pgtype.UUID]dbgen.VoucherUsage
+	locks   int
+}
+
+func (d *voucherDB) Exec(_ context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	switch queryName(sql) {
+	case "InsertVoucherUsage":
+		orderID := args[2].(pgtype.UUID)
+		d.usages[orderID] = dbgen.VoucherUsage{VoucherID: args[0].(pgtype.UUID), OrderID: orderID, Amount: args[3].(int64)}
+	case "IncreaseVoucherUsedCount":
+		d.voucher.UsedCount++
+	default:
+		return pgconn.CommandTag{}, errors.New("unexpected query " + queryName(sql))
+	}
+	return pgconn.NewCommandTag("UPDATE 1"), nil
+}
+
+func (d *voucherDB) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	return nil, errors.New("unexpected query")
+}
+
+func (d *voucherDB) QueryRow(_ context.Context, sql string, args ...interface{}) pgx.Row {
+	switch queryName(sql) {
+	case "GetVoucherByCodeForUpdate":
+		d.locks++
+		return &cannedRows{items: []any{d.voucher}}
+	case "GetVoucherUsageByOrder":
+		if usage, ok := d.usages[args[1].(pgtype.UUID)]; ok {
+			return &cannedRows{items: []any{usage}}
+		}
+	}
+	return &cannedRows{pos: -1}
+}
+
+func (d *voucherDB) SendBatch(context.Context, *pgx.Batch) pgx.BatchResults {
+	return nil
+}
+
+func TestRedeemVoucherRetryDoesNotDoubleCount(t *testing.T) {
+	db := &voucherDB{
+		voucher: dbgen.Voucher{ID: pgtype.UUID{Bytes: [16]byte{0x77}, Valid: true}, Code: "HEMAT10", UsageLimit: pgtype.Int4{Int32: 2, Valid: true}},
+		usages:  map[pgtype.UUID]dbgen.VoucherUsage{},
+	}
+	svc := &Service{}
+	q := dbgen.New(db)
+	order := dbgen.Order{
+		ID:                 pgtype.UUID{Bytes: [16]byte{0x01}, Valid: true},
+		UserID:             pgtype.UUID{Bytes: [16]byte{0x02}, Valid: true},
+		AppliedVoucherCode: pgtype.Text{String: "HEMAT10", Valid: true},
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		if err := svc.redeemVoucher(context.Background(), q, order, 16000); err != nil {
+			t.Fatalf("redeem attempt %d: %v", attempt, err)
+		}
+	}
+	if db.voucher.UsedCount != 1 || len(db.usages) != 1 {
+		t.Fatalf("expected one redemption, got used_count %d with %d usage rows", db.voucher.UsedCount, len(db.usages))
+	}
+	if db.locks != 2 {
+		t.Fatalf("expected every attempt to lock the voucher, got %d locks", db.locks)
+	}
+	if got := db.usages[order.ID].Amount; got != 16000 {
+		t.Fatalf("expected the discount recorded on the usage, got %d", got)
+	}
+
+	// A second order takes the last use; a third is over the limit.
+	order.ID = pgtype.UUID{Bytes: [16]byte{0x03}, Valid: true}
+	if err := svc.redeemVoucher(context.Background(), q, order, 16000); err != nil {
+		t.Fatalf("redeem second order: %v", err)
+	}
+	order.ID = pgtype.UUID{Bytes: [16]byte{0x04}, Valid: true}
+	if err := svc.redeemVoucher(context.Background(), q, order, 16000); !errors.Is(err, voucher.ErrUsageLimitReached) {
+		t.Fatalf("expected usage limit reached, got %v", err)
+	}
+	if db.voucher.UsedCount != 2 {
+		t.Fatalf("expected used_count to stop at the limit, got %d", db.voucher.UsedCount)
+	}
+}
@@ -0,0 +1,22 @@
+package clock_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/clock"
+)
+
+func TestFakeMovesOnlyWhenTold(t *testing.T) {
+	start := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start)
+	require.Equal(t, start, fake.Now())
+
+	fake.Advance(90 * time.Second)
+	require.Equal(t, start.Add(90*time.Second), fake.Now())
+
+	fake.Set(start.Add(-time.Hour))
+	require.Equal(t, start.Add(-time.Hour), fake.Now())
+}
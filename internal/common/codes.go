@@ -0,0 +1,186 @@
+package common
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// Canonical error codes returned in the "error.code" field. Clients switch on
+// these values, so existing codes must never be renamed or repurposed.
+const (
+	CodeBadRequest             = "BAD_REQUEST"
+	CodeValidation             = "VALIDATION_ERROR"
+	CodeInvalidBody            = "INVALID_BODY"
+	CodeUnauthorized           = "UNAUTHORIZED"
+	CodeUnauthenticated        = "UNAUTHENTICATED"
+	CodeForbidden              = "FORBIDDEN"
+	CodeNotFound               = "NOT_FOUND"
+	CodeConflict               = "CONFLICT"
+	CodeAlreadyExists          = "ALREADY_EXISTS"
+	CodeInvalidState           = "INVALID_STATE"
+	CodeIdempotentReplay       = "IDEMPOTENT_REPLAY"
+	CodeReplay                 = "REPLAY"
+	CodeRateLimited            = "RATE_LIMIT_EXCEEDED"
+	CodePayloadTooLarge        = "PAYLOAD_TOO_LARGE"
+	CodeUnsupportedMediaType   = "UNSUPPORTED_MEDIA_TYPE"
+	CodeCSRFInvalid            = "CSRF_INVALID"
+	CodeTenantRequired         = "TENANT_REQUIRED"
+	CodeRequestCancelled       = "REQUEST_CANCELLED"
+	CodeNotImplemented         = "NOT_IMPLEMENTED"
+	CodeUnavailable            = "UNAVAILABLE"
+	CodeMaintenance            = "MAINTENANCE"
+	CodeInternal               = "INTERNAL"
+	CodeNoContent              = "NO_CONTENT"
+	CodeNotEligible            = "NOT_ELIGIBLE"
+	CodeShippingError          = "SHIPPING_ERROR"
+	CodeEmailAlreadyUsed       = "EMAIL_ALREADY_USED"
+	CodeInvalidCredentials     = "INVALID_CREDENTIALS"
+	CodeInvalidToken           = "INVALID_TOKEN"
+	CodeWeakPassword           = "WEAK_PASSWORD"
+	CodeInvalidOTP             = "INVALID_OTP"
+	CodeInvalidSignature       = "INVALID_SIGNATURE"
+	CodeWebhookInvalid         = "WEBHOOK_INVALID"
+	CodeWebhookExpired         = "WEBHOOK_EXPIRED"
+	CodeAmountMismatch         = "AMOUNT_MISMATCH"
+	CodeInvalidOrderID         = "INVALID_ORDER_ID"
+	CodeOrderNotFound          = "ORDER_NOT_FOUND"
+	CodePaymentNotFound        = "PAYMENT_NOT_FOUND"
+	CodeProviderNotSupported   = "PROVIDER_NOT_SUPPORTED"
+	CodePaymentNotConfigured   = "PAYMENT_NOT_CONFIGURED"
+	CodeIntentFailed           = "INTENT_FAILED"
+	CodeProviderTimeout        = "PROVIDER_TIMEOUT"
+	CodeCapabilityUnsupported  = "CAPABILITY_UNSUPPORTED"
+	CodeRefundFailed           = "REFUND_FAILED"
+	CodeAnalyticsNotConfigured = "ANALYTICS_NOT_CONFIGURED"
+	CodeAnalyticsError         = "ANALYTICS_ERROR"
+	CodeAuditNotConfigured     = "AUDIT_NOT_CONFIGURED"
+	CodeAuditQueryFailed       = "AUDIT_QUERY_FAILED"
+	CodeOrderBelowMinimum      = "ORDER_BELOW_MINIMUM"
+	CodeOrderAboveMaximum      = "ORDER_ABOVE_MAXIMUM"
+	CodeLinkExpired            = "LINK_EXPIRED"
+	CodeVariantRequired        = "VARIANT_REQUIRED"
+	CodeProductNotAvailable    = "PRODUCT_NOT_AVAILABLE"
+	CodeCartLimitExceeded      = "CART_LIMIT_EXCEEDED"
+	CodeInsufficientStock      = "INSUFFICIENT_STOCK"
+	CodePriceChanged           = "PRICE_CHANGED"
+	CodeAccountDeactivated     = "ACCOUNT_DEACTIVATED"
+	CodeAccountHasOpenOrders   = "ACCOUNT_HAS_OPEN_ORDERS"
+	CodeNoShippingOptions      = "NO_SHIPPING_OPTIONS"
+)
+
+// CodeSpec documents the HTTP status a code is normally paired with.
+type CodeSpec struct {
+	Code        string `json:"code"`
+	Status      int    `json:"status"`
+	Description string `json:"description"`
+}
+
+// codeCatalog is the single registry of error codes; add new codes here
+// together with their documented status.
+var codeCatalog = map[string]CodeSpec{}
+
+func init() {
+	for _, spec := range []CodeSpec{
+		{CodeBadRequest, http.StatusBadRequest, "request is malformed or a parameter is invalid"},
+		{CodeValidation, http.StatusBadRequest, "payload failed field validation"},
+		{CodeInvalidBody, http.StatusBadRequest, "request body could not be decoded"},
+		{CodeUnauthorized, http.StatusUnauthorized, "missing, invalid, or expired credentials"},
+		{CodeUnauthenticated, http.StatusUnauthorized, "endpoint requires an authenticated user"},
+		{CodeForbidden, http.StatusForbidden, "caller lacks permission for the resource"},
+		{CodeNotFound, http.StatusNotFound, "resource does not exist"},
+		{CodeConflict, http.StatusConflict, "request conflicts with current resource state"},
+		{CodeAlreadyExists, http.StatusConflict, "resource already exists"},
+		{CodeInvalidState, http.StatusConflict, "transition is not allowed from the current state"},
+		{CodeIdempotentReplay, http.StatusConflict, "Idempotency-Key was already used"},
+		{CodeReplay, http.StatusConflict, "inbound callback is still being processed; retry later"},
+		{CodeRateLimited, http.StatusTooManyRequests, "rate limit exceeded; see Retry-After"},
+		{CodePayloadTooLarge, http.StatusRequestEntityTooLarge, "request body exceeds the configured limit"},
+		{CodeUnsupportedMediaType, http.StatusUnsupportedMediaType, "uploaded file type is not accepted"},
+		{CodeCSRFInvalid, http.StatusForbidden, "CSRF token missing or mismatched"},
+		{CodeTenantRequired, http.StatusBadRequest, "tenant could not be resolved"},
+		{CodeRequestCancelled, http.StatusRequestTimeout, "request cancelled before it could be served"},
+		{CodeNotImplemented, http.StatusNotImplemented, "feature is not available"},
+		{CodeUnavailable, http.StatusServiceUnavailable, "dependency temporarily unavailable"},
+		{CodeMaintenance, http.StatusServiceUnavailable, "API is in maintenance mode; see Retry-After"},
+		{CodeInternal, http.StatusInternalServerError, "unexpected server error"},
+		{CodeNoContent, http.StatusOK, "no cart context supplied"},
+		{CodeNotEligible, http.StatusBadRequest, "voucher is not applicable to the request"},
+		{CodeShippingError, http.StatusBadGateway, "shipping provider failed to quote"},
+		{CodeEmailAlreadyUsed, http.StatusConflict, "email is already registered"},
+		{CodeInvalidCredentials, http.StatusUnauthorized, "email or password is incorrect"},
+		{CodeInvalidToken, http.StatusBadRequest, "reset token is invalid or expired"},
+		{CodeWeakPassword, http.StatusBadRequest, "password does not meet the policy"},
+		{CodeInvalidOTP, http.StatusUnauthorized, "two-factor code or backup code is invalid"},
+		{CodeInvalidSignature, http.StatusUnauthorized, "webhook signature verification failed"},
+		{CodeWebhookInvalid, http.StatusBadRequest, "webhook payload could not be parsed"},
+		{CodeWebhookExpired, http.StatusBadRequest, "webhook timestamp is outside the accepted replay window"},
+		{CodeAmountMismatch, http.StatusBadRequest, "provider amount does not match the order"},
+		{CodeInvalidOrderID, http.StatusBadRequest, "order identifier is malformed"},
+		{CodeOrderNotFound, http.StatusNotFound, "order does not exist"},
+		{CodePaymentNotFound, http.StatusNotFound, "payment does not exist"},
+		{CodeProviderNotSupported, http.StatusNotFound, "payment provider is not supported"},
+		{CodePaymentNotConfigured, http.StatusInternalServerError, "payment provider is not configured"},
+		{CodeIntentFailed, http.StatusBadGateway, "payment intent could not be created"},
+		{CodeProviderTimeout, http.StatusGatewayTimeout, "upstream provider timed out; safe to retry"},
+		{CodeCapabilityUnsupported, http.StatusUnprocessableEntity, "payment provider does not support the request"},
+		{CodeRefundFailed, http.StatusBadGateway, "payment provider rejected the refund"},
+		{CodeAnalyticsNotConfigured, http.StatusInternalServerError, "analytics service is not configured"},
+		{CodeAnalyticsError, http.StatusInternalServerError, "analytics query failed"},
+		{CodeAuditNotConfigured, http.StatusInternalServerError, "audit store is not configured"},
+		{CodeAuditQueryFailed, http.StatusInternalServerError, "audit query failed"},
+		{CodeOrderBelowMinimum, http.StatusUnprocessableEntity, "order value after discounts is below the minimum"},
+		{CodeOrderAboveMaximum, http.StatusUnprocessableEntity, "order total exceeds the maximum"},
+		{CodeLinkExpired, http.StatusGone, "signed link has expired"},
+		{CodeVariantRequired, http.StatusUnprocessableEntity, "product is sold through variants and has no default"},
+		{CodeProductNotAvailable, http.StatusUnprocessableEntity, "product is outside its availability window"},
+		{CodeCartLimitExceeded, http.StatusUnprocessableEntity, "cart would exceed an item or quantity limit"},
+		{CodeInsufficientStock, http.StatusUnprocessableEntity, "requested quantity is above the stock available"},
+		{CodePriceChanged, http.StatusConflict, "cart prices changed; review and confirm before checking out"},
+		{CodeAccountDeactivated, http.StatusForbidden, "account was closed by its owner"},
+		{CodeAccountHasOpenOrders, http.StatusConflict, "account still has orders that are not delivered or canceled"},
+		{CodeNoShippingOptions, http.StatusUnprocessableEntity, "no courier option is offered for the destination"},
+		// Internal failures surfaced by the payment webhook pipeline.
+		{"TX_ERROR", http.StatusInternalServerError, "could not open a transaction"},
+		{"TX_COMMIT_ERROR", http.StatusInternalServerError, "could not commit a transaction"},
+		{"ORDER_FETCH_ERROR", http.StatusInternalServerError, "order lookup failed"},
+		{"ORDER_ITEMS_ERROR", http.StatusInternalServerError, "order items lookup failed"},
+		{"ORDER_UPDATE_ERROR", http.StatusInternalServerError, "order update failed"},
+		{"PAYMENT_FETCH_ERROR", http.StatusInternalServerError, "payment lookup failed"},
+		{"PAYMENT_UPDATE_ERROR", http.StatusInternalServerError, "payment update failed"},
+		{"STATUS_ERROR", http.StatusInternalServerError, "status update failed"},
+		{"STOCK_UPDATE_ERROR", http.StatusInternalServerError, "stock adjustment failed"},
+		{"REPLAY_STORE_ERROR", http.StatusInternalServerError, "replay protection store failed"},
+		{"VOUCHER_SETTLEMENT_FAILED", http.StatusInternalServerError, "voucher usage could not be recorded"},
+		{"VOUCHER_RELEASE_FAILED", http.StatusInternalServerError, "voucher usage could not be released"},
+	} {
+		codeCatalog[spec.Code] = spec
+	}
+}
+
+// LookupCode returns the catalog entry for code.
+func LookupCode(code string) (CodeSpec, bool) {
+	spec, ok := codeCatalog[code]
+	return spec, ok
+}
+
+// Codes lists the catalog sorted by code, e.g. for documentation endpoints.
+func Codes() []CodeSpec {
+	out := make([]CodeSpec, 0, len(codeCatalog))
+	for _, spec := range codeCatalog {
+		out = append(out, spec)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Code < out[j].Code })
+	return out
+}
+
+// checkCode panics on unknown codes when built with the debug tag so new
+// call sites cannot ship codes the frontend does not know about.
+func checkCode(code string) {
+	if !validateCodes {
+		return
+	}
+	if _, ok := codeCatalog[code]; !ok {
+		panic(fmt.Sprintf("common: error code %q is not registered in the catalog", code))
+	}
+}
@@ -0,0 +1,6 @@
+//go:build debug
+
+package common
+
+// validateCodes enables catalog checks in JSONError and NewAppError.
+const validateCodes = true
@@ -0,0 +1,6 @@
+//go:build !debug
+
+package common
+
+// validateCodes is off in release builds; unknown codes are passed through.
+const validateCodes = false
@@ -0,0 +1,65 @@
+package common
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// codeLiteral matches string literals passed as the code argument to the
+// error helpers, e.g. JSONError(w, 400, "BAD_REQUEST", ...).
+var codeLiteral = regexp.MustCompile(`(?:JSONError\([^,]+,[^,]+,|NewAppError\()\s*"([^"]+)"`)
+
+func TestErrorCodesAreCatalogued(t *testing.T) {
+	root := filepath.Join("..", "..")
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if name := d.Name(); name == "vendor" || name == "node_modules" || strings.HasPrefix(name, ".") && path != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") || strings.HasSuffix(path, "_test.go") {
+			return nil
+		}
+		src, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		for _, m := range codeLiteral.FindAllStringSubmatch(string(src), -1) {
+			if _, ok := LookupCode(m[1]); !ok {
+				t.Errorf("%s: error code %q is not in the catalog", path, m[1])
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk: %v", err)
+	}
+}
+
+func TestJSONErrorEnvelope(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rec.Header().Set(RequestIDHeader, "req-123")
+	JSONError(rec, http.StatusNotFound, CodeNotFound, "missing", nil)
+
+	var body map[string]map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	env := body["error"]
+	if env["code"] != CodeNotFound || env["message"] != "missing" || env["requestId"] != "req-123" {
+		t.Fatalf("unexpected envelope: %v", env)
+	}
+	if _, ok := env["details"]; !ok {
+		t.Fatalf("details must always be present: %v", env)
+	}
+}
@@ -0,0 +1,248 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// List envelope formats. ListFormatFlat keeps each endpoint's historical
+// {data, pagination} style body; the others wrap results with hypermedia links.
+const (
+	ListFormatFlat    = "flat"
+	ListFormatJSONAPI = "jsonapi"
+	ListFormatHAL     = "hal"
+)
+
+// Media types that select a list envelope through the Accept header.
+const (
+	MediaTypeJSONAPI = "application/vnd.api+json"
+	MediaTypeHAL     = "application/hal+json"
+)
+
+// DefaultListFormat is used when the Accept header does not ask for a
+// hypermedia media type. It is set once at startup from configuration.
+var DefaultListFormat = ListFormatFlat
+
+// ParseListFormat validates a configured list envelope format.
+func ParseListFormat(value string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", ListFormatFlat:
+		return ListFormatFlat, true
+	case ListFormatJSONAPI, "json:api":
+		return ListFormatJSONAPI, true
+	case ListFormatHAL:
+		return ListFormatHAL, true
+	default:
+		return "", false
+	}
+}
+
+// ListPage describes one page of a list response. Page-based listings set
+// Page and PerPage; offset-based listings set Offset and Limit instead.
+// Total is -1 when the listing does not count its rows.
+type ListPage struct {
+	Type    string
+	Items   any
+	Page    int
+	PerPage int
+	Offset  int
+	Limit   int
+	Total   int64
+}
+
+// PageMeta is the pagination metadata attached to hypermedia envelopes.
+type PageMeta struct {
+	Number     int    `json:"number,omitempty"`
+	Size       int    `json:"size"`
+	Offset     *int   `json:"offset,omitempty"`
+	TotalItems *int64 `json:"totalItems,omitempty"`
+	TotalPages *int64 `json:"totalPages,omitempty"`
+}
+
+// ListFormat returns the envelope format for r: an explicit JSON:API or HAL
+// Accept header wins, otherwise DefaultListFormat applies.
+func ListFormat(r *http.Request) string {
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		switch mediaType {
+		case MediaTypeJSONAPI:
+			return ListFormatJSONAPI
+		case MediaTypeHAL:
+			return ListFormatHAL
+		}
+	}
+	return DefaultListFormat
+}
+
+// WriteList renders a list response. flat is written unchanged in the flat
+// format so existing clients keep their shape; hypermedia formats are built
+// from page.
+func WriteList(w http.ResponseWriter, r *http.Request, page ListPage, flat any) {
+	w.Header().Add("Vary", "Accept")
+	format := ListFormat(r)
+	if format == ListFormatFlat {
+		JSON(w, http.StatusOK, flat)
+		return
+	}
+	raw, err := json.Marshal(page.Items)
+	if err != nil {
+		JSONError(w, http.StatusInternalServerError, CodeInternal, "failed to encode list", nil)
+		return
+	}
+	items := []json.RawMessage{}
+	if string(raw) != "null" {
+		if err := json.Unmarshal(raw, &items); err != nil {
+			JSONError(w, http.StatusInternalServerError, CodeInternal, "failed to encode list", nil)
+			return
+		}
+	}
+	links := page.links(r.URL, len(items))
+	meta := page.meta()
+
+	var body any
+	contentType := MediaTypeHAL
+	if format == ListFormatJSONAPI {
+		contentType = MediaTypeJSONAPI
+		body = map[string]any{
+			"data":  jsonAPIResources(page.Type, items),
+			"links": links,
+			"meta":  map[string]any{"page": meta},
+		}
+	} else {
+		halLinks := make(map[string]any, len(links))
+		for rel, href := range links {
+			if href != nil {
+				halLinks[rel] = map[string]string{"href": *href}
+			}
+		}
+		body = map[string]any{
+			"_links":    halLinks,
+			"_embedded": map[string]any{page.Type: items},
+			"page":      meta,
+		}
+	}
+	payload, err := json.Marshal(body)
+	if err != nil {
+		JSONError(w, http.StatusInternalServerError, CodeInternal, "failed to encode list", nil)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(append(payload, '\n'))
+}
+
+func (p ListPage) offsetBased() bool {
+	return p.PerPage == 0
+}
+
+func (p ListPage) size() int {
+	if p.offsetBased() {
+		return p.Limit
+	}
+	return p.PerPage
+}
+
+func (p ListPage) meta() PageMeta {
+	meta := PageMeta{Size: p.size()}
+	if p.offsetBased() {
+		offset := p.Offset
+		meta.Offset = &offset
+	} else {
+		meta.Number = p.Page
+	}
+	if p.Total >= 0 {
+		total := p.Total
+		meta.TotalItems = &total
+		if size := int64(p.size()); size > 0 {
+			pages := (total + size - 1) / size
+			meta.TotalPages = &pages
+		}
+	}
+	return meta
+}
+
+// links builds self/first/prev/next/last hrefs relative to the request path.
+// Missing relations are nil so JSON:API renders them as null.
+func (p ListPage) links(u *url.URL, count int) map[string]*string {
+	size := p.size()
+	links := map[string]*string{"self": p.href(u, p.Page, p.Offset), "prev": nil, "next": nil}
+	if size <= 0 {
+		return links
+	}
+	hasNext := count >= size
+	if p.offsetBased() {
+		if p.Total >= 0 {
+			hasNext = int64(p.Offset+size) < p.Total
+		}
+		links["first"] = p.href(u, 0, 0)
+		if p.Offset > 0 {
+			links["prev"] = p.href(u, 0, max(p.Offset-size, 0))
+		}
+		if hasNext {
+			links["next"] = p.href(u, 0, p.Offset+size)
+		}
+		if p.Total > 0 {
+			links["last"] = p.href(u, 0, int((p.Total-1)/int64(size))*size)
+		}
+		return links
+	}
+	if p.Total >= 0 {
+		hasNext = int64(p.Page*size) < p.Total
+	}
+	links["first"] = p.href(u, 1, 0)
+	if p.Page > 1 {
+		links["prev"] = p.href(u, p.Page-1, 0)
+	}
+	if hasNext {
+		links["next"] = p.href(u, p.Page+1, 0)
+	}
+	if p.Total > 0 {
+		links["last"] = p.href(u, int((p.Total+int64(size)-1)/int64(size)), 0)
+	}
+	return links
+}
+
+func (p ListPage) href(u *url.URL, page, offset int) *string {
+	q := u.Query()
+	if p.offsetBased() {
+		q.Set("offset", strconv.Itoa(offset))
+		q.Set("limit", strconv.Itoa(p.Limit))
+	} else {
+		q.Set("page", strconv.Itoa(page))
+		q.Set("limit", strconv.Itoa(p.PerPage))
+	}
+	href := u.Path + "?" + q.Encode()
+	return &href
+}
+
+// jsonAPIResources turns encoded items into JSON:API resource objects. The
+// item's id moves to the top level and the remaining fields become attributes.
+func jsonAPIResources(kind string, items []json.RawMessage) []map[string]any {
+	resources := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		var attrs map[string]any
+		if err := json.Unmarshal(item, &attrs); err != nil {
+			resources = append(resources, map[string]any{"type": kind, "attributes": map[string]any{"value": item}})
+			continue
+		}
+		resource := map[string]any{"type": kind}
+		for _, key := range []string{"id", "ID"} {
+			if id, ok := attrs[key]; ok {
+				resource["id"] = fmt.Sprint(id)
+				delete(attrs, key)
+				break
+			}
+		}
+		resource["attributes"] = attrs
+		resources = append(resources, resource)
+	}
+	return resources
+}
@@ -0,0 +1,92 @@
+package common
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type listItem struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func writeTestList(t *testing.T, accept, target string, page ListPage) (*httptest.ResponseRecorder, map[string]any) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	rec := httptest.NewRecorder()
+	WriteList(rec, req, page, map[string]any{"data": page.Items, "pagination": Pagination{Page: page.Page, PerPage: page.PerPage, TotalItems: int(page.Total)}})
+	var body map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	return rec, body
+}
+
+func TestWriteListFlatByDefault(t *testing.T) {
+	page := ListPage{Type: "products", Items: []listItem{{ID: "p1", Name: "Kopi"}}, Page: 1, PerPage: 1, Total: 3}
+	rec, body := writeTestList(t, "application/json", "/api/v1/products?limit=1", page)
+
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	require.Equal(t, "Accept", rec.Header().Get("Vary"))
+	require.Contains(t, body, "pagination")
+	require.NotContains(t, body, "links")
+}
+
+func TestWriteListJSONAPI(t *testing.T) {
+	page := ListPage{Type: "products", Items: []listItem{{ID: "p3", Name: "Teh"}}, Page: 2, PerPage: 1, Total: 3}
+	rec, body := writeTestList(t, MediaTypeJSONAPI, "/api/v1/products?category=drinks&page=2&limit=1", page)
+
+	require.Equal(t, MediaTypeJSONAPI, rec.Header().Get("Content-Type"))
+	data := body["data"].([]any)
+	require.Len(t, data, 1)
+	resource := data[0].(map[string]any)
+	require.Equal(t, "products", resource["type"])
+	require.Equal(t, "p3", resource["id"])
+	require.Equal(t, map[string]any{"name": "Teh"}, resource["attributes"])
+
+	links := body["links"].(map[string]any)
+	require.Equal(t, "/api/v1/products?category=drinks&limit=1&page=2", links["self"])
+	require.Equal(t, "/api/v1/products?category=drinks&limit=1&page=1", links["prev"])
+	require.Equal(t, "/api/v1/products?category=drinks&limit=1&page=3", links["next"])
+	require.Equal(t, "/api/v1/products?category=drinks&limit=1&page=3", links["last"])
+
+	meta := body["meta"].(map[string]any)["page"].(map[string]any)
+	require.EqualValues(t, 2, meta["number"])
+	require.EqualValues(t, 3, meta["totalItems"])
+	require.EqualValues(t, 3, meta["totalPages"])
+}
+
+func TestWriteListHALWithOffsets(t *testing.T) {
+	page := ListPage{Type: "audit-logs", Items: []listItem{{ID: "a1"}, {ID: "a2"}}, Offset: 0, Limit: 2, Total: -1}
+	rec, body := writeTestList(t, "text/html, application/hal+json;q=0.9", "/api/v1/admin/audit-logs?limit=2", page)
+
+	require.Equal(t, MediaTypeHAL, rec.Header().Get("Content-Type"))
+	links := body["_links"].(map[string]any)
+	require.Equal(t, map[string]any{"href": "/api/v1/admin/audit-logs?limit=2&offset=2"}, links["next"])
+	require.NotContains(t, links, "prev")
+	require.NotContains(t, links, "last", "uncounted listings have no last page")
+	require.Len(t, body["_embedded"].(map[string]any)["audit-logs"], 2)
+	require.NotContains(t, body["page"], "totalItems")
+}
+
+func TestWriteListConfiguredDefault(t *testing.T) {
+	DefaultListFormat = ListFormatHAL
+	t.Cleanup(func() { DefaultListFormat = ListFormatFlat })
+
+	page := ListPage{Type: "orders", Items: []listItem{}, Page: 1, PerPage: 20, Total: 0}
+	rec, body := writeTestList(t, "", "/api/v1/orders", page)
+	require.Equal(t, MediaTypeHAL, rec.Header().Get("Content-Type"))
+	require.NotContains(t, body["_links"], "next")
+	require.Empty(t, body["_embedded"].(map[string]any)["orders"])
+
+	format, ok := ParseListFormat("JSON:API")
+	require.True(t, ok)
+	require.Equal(t, ListFormatJSONAPI, format)
+	_, ok = ParseListFormat("xml")
+	require.False(t, ok)
+}
@@ -30,8 +30,15 @@ func (e *AppError) Unwrap() error {
 	return e.Err
 }
 
-// NewAppError constructs an AppError.
+// NewAppError constructs an AppError. A zero status falls back to the status
+// documented for code in the catalog.
 func NewAppError(code, message string, status int, err error) *AppError {
+	checkCode(code)
+	if status == 0 {
+		if spec, ok := LookupCode(code); ok {
+			status = spec.Status
+		}
+	}
 	return &AppError{Code: code, Message: message, HTTPStatus: status, Err: err}
 }
 
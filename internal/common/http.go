@@ -1,32 +1,102 @@
 package common
 
 import (
+	"fmt"
 	"net"
 	"net/http"
 	"strings"
 )
 
-// ClientIP attempts to determine the real client IP address from the request.
+// TrustedProxies lists the networks whose forwarded headers are honoured. It
+// is set once at startup from configuration; when empty, client IPs always
+// come from the socket address.
+var TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses CIDRs or bare IP addresses into networks.
+func ParseTrustedProxies(values []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", value)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", value, err)
+		}
+		nets = append(nets, network)
+	}
+	return nets, nil
+}
+
+// ClientIP determines the client IP address of the request. X-Forwarded-For
+// and X-Real-IP are only honoured when the immediate peer is a trusted proxy;
+// the forwarded chain is then walked from the right, skipping trusted hops,
+// so a client cannot spoof its address by prepending entries.
 func ClientIP(r *http.Request) string {
 	if r == nil {
 		return ""
 	}
-	if ip := strings.TrimSpace(r.Header.Get("X-Forwarded-For")); ip != "" {
-		parts := strings.Split(ip, ",")
-		if len(parts) > 0 {
-			candidate := strings.TrimSpace(parts[0])
-			if candidate != "" {
-				return candidate
+	peer := strings.TrimSpace(r.RemoteAddr)
+	if host, _, err := net.SplitHostPort(peer); err == nil {
+		peer = host
+	}
+	if !trustedProxy(peer) {
+		return peer
+	}
+	if header := strings.TrimSpace(r.Header.Get("X-Forwarded-For")); header != "" {
+		hops := strings.Split(header, ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if hop == "" {
+				continue
+			}
+			if i == 0 || !trustedProxy(hop) {
+				return hop
 			}
 		}
-		return strings.TrimSpace(ip)
 	}
 	if ip := strings.TrimSpace(r.Header.Get("X-Real-IP")); ip != "" {
 		return ip
 	}
-	host, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
-	if err == nil {
-		return host
+	return peer
+}
+
+// RealIP replaces the request's RemoteAddr with ClientIP so downstream
+// handlers and access logs see the resolved client address.
+func RealIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ip := ClientIP(r); ip != "" {
+			r.RemoteAddr = ip
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func trustedProxy(addr string) bool {
+	if len(TrustedProxies) == 0 {
+		return false
+	}
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	for _, network := range TrustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
 	}
-	return strings.TrimSpace(r.RemoteAddr)
+	return false
 }
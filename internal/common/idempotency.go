@@ -4,17 +4,42 @@ import (
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
-	"io"
 	"net/http"
 	"time"
 
 	redis "github.com/redis/go-redis/v9"
 )
 
-// Idem provides an Idempotency-Key middleware backed by Redis.
+// IdemStore reserves idempotency keys for a bounded period.
+type IdemStore interface {
+	// Reserve stores key for ttl and reports false when it already exists.
+	Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	// Extend pushes the expiry of an existing key out by ttl.
+	Extend(ctx context.Context, key string, ttl time.Duration) error
+}
+
+// RedisIdemStore implements IdemStore with SETNX/EXPIRE.
+type RedisIdemStore struct {
+	R *redis.Client
+}
+
+// Reserve implements IdemStore.
+func (s RedisIdemStore) Reserve(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	return s.R.SetNX(ctx, key, "locked", ttl).Result()
+}
+
+// Extend implements IdemStore.
+func (s RedisIdemStore) Extend(ctx context.Context, key string, ttl time.Duration) error {
+	return s.R.Expire(ctx, key, ttl).Err()
+}
+
+// Idem provides an Idempotency-Key middleware backed by Redis, or by Store
+// when one is configured. Keys are scoped to the caller, not the tenant,
+// which clients choose freely.
 type Idem struct {
-	R   *redis.Client
-	TTL time.Duration
+	R     *redis.Client
+	TTL   time.Duration
+	Store IdemStore
 }
 
 func hashKey(key string) string {
@@ -22,30 +47,51 @@ func hashKey(key string) string {
 	return "idem:" + hex.EncodeToString(sum[:])
 }
 
-// Middleware enforces idempotency semantics for write endpoints.
+// scopedKey namespaces a client-supplied key by the caller and the route so
+// two principals reusing the same key value never share a reservation.
+// Authenticated callers are scoped by user, anonymous ones by IP.
+func scopedKey(r *http.Request, key string) string {
+	principal := "anon:" + ClientIP(r)
+	if id, ok := UserID(r.Context()); ok && id != "" {
+		principal = "user:" + id
+	}
+	return hashKey(principal + "\n" + r.Method + " " + r.URL.Path + "\n" + key)
+}
+
+func (i Idem) store() IdemStore {
+	if i.Store != nil {
+		return i.Store
+	}
+	if i.R != nil {
+		return RedisIdemStore{R: i.R}
+	}
+	return nil
+}
+
+// Middleware enforces idempotency semantics for write endpoints. It must run
+// after authentication so keys are scoped to the caller.
 func (i Idem) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		header := r.Header.Get("Idempotency-Key")
-		if header == "" || i.R == nil {
+		store := i.store()
+		if header == "" || store == nil {
 			next.ServeHTTP(w, r)
 			return
 		}
 		ctx := r.Context()
-		key := hashKey(header)
-		ok, err := i.R.SetNX(ctx, key, "locked", i.TTL).Result()
+		key := scopedKey(r, header)
+		ok, err := store.Reserve(ctx, key, i.TTL)
 		if err != nil {
 			commonJSONError(w, err)
 			return
 		}
 		if !ok {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusConflict)
-			_, _ = io.WriteString(w, "{\"error\":{\"code\":\"IDEMPOTENT_REPLAY\",\"message\":\"duplicate request\"}}")
+			JSONError(w, http.StatusConflict, CodeIdempotentReplay, "duplicate request", nil)
 			return
 		}
 		defer func() {
 			// ensure the key expires even if handler panics
-			_ = i.R.Expire(context.Background(), key, i.TTL).Err()
+			_ = store.Extend(context.Background(), key, i.TTL)
 		}()
 		next.ServeHTTP(w, r)
 	})
@@ -55,5 +101,5 @@ func commonJSONError(w http.ResponseWriter, err error) {
 	if err == nil {
 		return
 	}
-	JSONError(w, http.StatusInternalServerError, "INTERNAL", "idempotency store error", map[string]any{"error": err.Error()})
+	JSONError(w, http.StatusInternalServerError, CodeInternal, "idempotency store error", map[string]any{"error": err.Error()})
 }
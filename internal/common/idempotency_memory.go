@@ -0,0 +1,66 @@
+package common
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryIdemStore keeps idempotency keys in process memory. It is meant for
+// single-node development and tests and offers no cross-instance guarantees.
+type MemoryIdemStore struct {
+	mu        sync.Mutex
+	keys      map[string]time.Time
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryIdemStore builds an empty in-memory idempotency store.
+func NewMemoryIdemStore() *MemoryIdemStore {
+	return &MemoryIdemStore{keys: make(map[string]time.Time), now: time.Now}
+}
+
+// Reserve implements IdemStore.
+func (m *MemoryIdemStore) Reserve(_ context.Context, key string, ttl time.Duration) (bool, error) {
+	now := m.now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep(now)
+	if exp, ok := m.keys[key]; ok && now.Before(exp) {
+		return false, nil
+	}
+	m.keys[key] = expiry(now, ttl)
+	return true, nil
+}
+
+// Extend implements IdemStore. Missing or expired keys are left untouched,
+// mirroring Redis EXPIRE.
+func (m *MemoryIdemStore) Extend(_ context.Context, key string, ttl time.Duration) error {
+	now := m.now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if exp, ok := m.keys[key]; ok && now.Before(exp) {
+		m.keys[key] = expiry(now, ttl)
+	}
+	return nil
+}
+
+func (m *MemoryIdemStore) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < time.Minute {
+		return
+	}
+	m.lastSweep = now
+	for key, exp := range m.keys {
+		if !now.Before(exp) {
+			delete(m.keys, key)
+		}
+	}
+}
+
+// expiry treats a non-positive ttl as "never expires", like SETNX without EX.
+func expiry(now time.Time, ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return now.Add(100 * 365 * 24 * time.Hour)
+	}
+	return now.Add(ttl)
+}
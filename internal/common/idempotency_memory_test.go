@@ -0,0 +1,82 @@
+package common
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIdemMiddlewareWithMemoryStore(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	store := NewMemoryIdemStore()
+	store.now = func() time.Time { return now }
+
+	calls := 0
+	handler := Idem{TTL: time.Minute, Store: store}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	send := func() int {
+		req := httptest.NewRequest(http.MethodPost, "/carts", nil)
+		req.Header.Set("Idempotency-Key", "abc")
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	if code := send(); code != http.StatusCreated {
+		t.Fatalf("expected first request to pass, got %d", code)
+	}
+	if code := send(); code != http.StatusConflict {
+		t.Fatalf("expected replay to conflict, got %d", code)
+	}
+
+	now = now.Add(time.Minute + time.Second)
+	if code := send(); code != http.StatusCreated {
+		t.Fatalf("expected key to expire after TTL, got %d", code)
+	}
+	if calls != 2 {
+		t.Fatalf("expected handler to run twice, got %d", calls)
+	}
+}
+
+func TestIdemKeysAreScopedByPrincipalAndRoute(t *testing.T) {
+	handler := Idem{TTL: time.Minute, Store: NewMemoryIdemStore()}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	}))
+
+	send := func(method, path, userID, ip string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Idempotency-Key", "shared-key")
+		req.RemoteAddr = ip + ":4000"
+		if userID != "" {
+			req = req.WithContext(WithUserID(req.Context(), userID))
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	cases := []struct {
+		name                 string
+		method, path, userID string
+		ip                   string
+		want                 int
+	}{
+		{"first user", http.MethodPost, "/checkout", "user-a", "10.0.0.1", http.StatusCreated},
+		{"same user replays", http.MethodPost, "/checkout", "user-a", "10.0.0.9", http.StatusConflict},
+		{"other user same key", http.MethodPost, "/checkout", "user-b", "10.0.0.1", http.StatusCreated},
+		{"same user other route", http.MethodPost, "/carts", "user-a", "10.0.0.1", http.StatusCreated},
+		{"same user other method", http.MethodPut, "/checkout", "user-a", "10.0.0.1", http.StatusCreated},
+		{"anonymous", http.MethodPost, "/checkout", "", "10.0.0.1", http.StatusCreated},
+		{"anonymous same ip", http.MethodPost, "/checkout", "", "10.0.0.1", http.StatusConflict},
+		{"anonymous other ip", http.MethodPost, "/checkout", "", "10.0.0.2", http.StatusCreated},
+	}
+	for _, tc := range cases {
+		if code := send(tc.method, tc.path, tc.userID, tc.ip); code != tc.want {
+			t.Fatalf("%s: expected %d, got %d", tc.name, tc.want, code)
+		}
+	}
+}
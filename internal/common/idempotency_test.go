@@ -0,0 +1,39 @@
+package common
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/noah-isme/backend-toko/internal/tenant"
+)
+
+type recordingIdemStore struct {
+	keys []string
+}
+
+func (s *recordingIdemStore) Reserve(_ context.Context, key string, _ time.Duration) (bool, error) {
+	s.keys = append(s.keys, key)
+	return true, nil
+}
+
+func (s *recordingIdemStore) Extend(context.Context, string, time.Duration) error { return nil }
+
+func TestIdemKeysIgnoreTenant(t *testing.T) {
+	store := &recordingIdemStore{}
+	handler := Idem{TTL: time.Minute, Store: store}.Middleware(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusCreated) }))
+
+	for _, id := range []string{"acme", "globex"} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/checkout", nil)
+		req.Header.Set("Idempotency-Key", "same")
+		req = req.WithContext(tenant.WithTenant(req.Context(), id))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	if len(store.keys) != 2 || store.keys[0] != store.keys[1] || !strings.HasPrefix(store.keys[0], "idem:") {
+		t.Fatalf("switching tenants must not change the reserved key: %v", store.keys)
+	}
+}
@@ -0,0 +1,48 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Int64AsString makes Int64 values encode as JSON strings, so JavaScript
+// clients keep full precision above 2^53. It is set once at startup from
+// configuration; the default keeps plain numbers for existing clients.
+var Int64AsString = false
+
+// Int64 is an int64 response field, such as an amount in minor units, that
+// may exceed what a JavaScript number holds exactly. It encodes as a number
+// or, when Int64AsString is set, a decimal string. It decodes from either
+// form so cached responses survive a change of setting.
+type Int64 int64
+
+// MarshalJSON implements json.Marshaler.
+func (v Int64) MarshalJSON() ([]byte, error) {
+	if Int64AsString {
+		return []byte(`"` + strconv.FormatInt(int64(v), 10) + `"`), nil
+	}
+	return []byte(strconv.FormatInt(int64(v), 10)), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (v *Int64) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		data = []byte(s)
+	}
+	n, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid int64 %q", data)
+	}
+	*v = Int64(n)
+	return nil
+}
@@ -0,0 +1,38 @@
+package common
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInt64Encoding(t *testing.T) {
+	const big = int64(9007199254740993) // 2^53 + 1
+	payload := struct {
+		Total Int64 `json:"total"`
+	}{Total: Int64(big)}
+
+	out, err := json.Marshal(payload)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"total":9007199254740993}`, string(out))
+
+	Int64AsString = true
+	t.Cleanup(func() { Int64AsString = false })
+	out, err = json.Marshal(payload)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"total":"9007199254740993"}`, string(out))
+}
+
+func TestInt64DecodesNumberOrString(t *testing.T) {
+	var v struct {
+		A Int64 `json:"a"`
+		B Int64 `json:"b"`
+		C Int64 `json:"c"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"a":12,"b":"9007199254740993","c":null}`), &v))
+	require.Equal(t, Int64(12), v.A)
+	require.Equal(t, Int64(9007199254740993), v.B)
+	require.Equal(t, Int64(0), v.C)
+	require.Error(t, json.Unmarshal([]byte(`{"a":"1.5"}`), &v))
+}
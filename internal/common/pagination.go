@@ -3,6 +3,7 @@ package common
 import (
 	"net/http"
 	"strconv"
+	"strings"
 )
 
 // Pagination holds pagination metadata for list responses.
@@ -24,3 +25,57 @@ func ParsePagination(r *http.Request, defaultPerPage int) (page, perPage int) {
 	}
 	return
 }
+
+// PageInfo is the pagination metadata admin listings return next to their
+// data.
+type PageInfo struct {
+	Page    int   `json:"page"`
+	Limit   int   `json:"limit"`
+	Offset  int   `json:"offset"`
+	Total   int64 `json:"total"`
+	HasMore bool  `json:"has_more"`
+}
+
+// NewPageInfo describes the window limit/offset over total rows.
+func NewPageInfo(limit, offset int, total int64) PageInfo {
+	info := PageInfo{Page: 1, Limit: limit, Offset: offset, Total: total}
+	if limit > 0 {
+		info.Page = offset/limit + 1
+	}
+	info.HasMore = int64(offset+limit) < total
+	return info
+}
+
+// ParseOffsetPagination extracts limit and offset query values. A limit
+// outside 1..maxLimit falls back to defaultLimit, and page selects the offset
+// when offset is not given.
+func ParseOffsetPagination(r *http.Request, defaultLimit, maxLimit int) (limit, offset int) {
+	query := r.URL.Query()
+	limit = defaultLimit
+	if l, err := strconv.Atoi(strings.TrimSpace(query.Get("limit"))); err == nil && l > 0 && l <= maxLimit {
+		limit = l
+	}
+	if o, err := strconv.Atoi(strings.TrimSpace(query.Get("offset"))); err == nil && o >= 0 {
+		return limit, o
+	}
+	if p, err := strconv.Atoi(strings.TrimSpace(query.Get("page"))); err == nil && p > 1 {
+		offset = (p - 1) * limit
+	}
+	return limit, offset
+}
+
+// WritePage renders an admin listing. The flat format is
+// {data, pagination, total} plus any extra fields; hypermedia formats are
+// built by WriteList.
+func WritePage(w http.ResponseWriter, r *http.Request, kind string, items any, limit, offset int, total int64, extra map[string]any) {
+	body := map[string]any{
+		"data":       items,
+		"pagination": NewPageInfo(limit, offset, total),
+		"total":      total,
+	}
+	for key, value := range extra {
+		body[key] = value
+	}
+	page := ListPage{Type: kind, Items: items, Offset: offset, Limit: limit, Total: total}
+	WriteList(w, r, page, body)
+}
@@ -0,0 +1,50 @@
+package common
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseOffsetPagination(t *testing.T) {
+	cases := []struct {
+		query         string
+		limit, offset int
+	}{
+		{"", 50, 0},
+		{"limit=20&offset=40", 20, 40},
+		{"limit=500", 50, 0},
+		{"limit=0&offset=-1", 50, 0},
+		{"limit=20&page=3", 20, 40},
+		{"limit=20&page=3&offset=5", 20, 5},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, "/admin/things?"+tc.query, nil)
+		limit, offset := ParseOffsetPagination(req, 50, 200)
+		require.Equal(t, tc.limit, limit, tc.query)
+		require.Equal(t, tc.offset, offset, tc.query)
+	}
+}
+
+func TestWritePageFlat(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/admin/things?limit=2&offset=2", nil)
+	rec := httptest.NewRecorder()
+	WritePage(rec, req, "things", []listItem{{ID: "a"}, {ID: "b"}}, 2, 2, 5, map[string]any{"kind": "email"})
+
+	var body struct {
+		Data       []listItem `json:"data"`
+		Pagination PageInfo   `json:"pagination"`
+		Total      int64      `json:"total"`
+		Kind       string     `json:"kind"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Len(t, body.Data, 2)
+	require.Equal(t, PageInfo{Page: 2, Limit: 2, Offset: 2, Total: 5, HasMore: true}, body.Pagination)
+	require.Equal(t, int64(5), body.Total)
+	require.Equal(t, "email", body.Kind)
+
+	require.False(t, NewPageInfo(2, 4, 5).HasMore)
+}
@@ -0,0 +1,185 @@
+package common
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// RedactedValue replaces sensitive values in logs and audit entries.
+const RedactedValue = "[REDACTED]"
+
+// DefaultRedactFields are always redacted. An entry matches any field whose
+// name contains it, ignoring case, "_" and "-", so "password" also covers
+// new_password and "card" covers cardNumber. "code" and "challenge" cover
+// two-factor codes, recovery codes included, and login challenges;
+// "custom_headers" masks webhook endpoint headers, whose names vary.
+var DefaultRedactFields = []string{"password", "passwd", "token", "secret", "apikey", "authorization", "card", "cvv", "cvc", "otp", "code", "challenge", "custom_headers"}
+
+// DefaultRedactHeaders are always redacted.
+var DefaultRedactHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-API-Key", "X-Maintenance-Bypass"}
+
+// Redactor masks sensitive fields in request bodies, query strings, and
+// headers before they are logged or audited.
+type Redactor struct {
+	// Fields adds to DefaultRedactFields. Entries containing a dot are
+	// paths, such as "payment.details.number", matched exactly.
+	Fields []string
+	// Headers adds to DefaultRedactHeaders.
+	Headers []string
+}
+
+// Field reports whether the field at path is sensitive. Path is the dotted
+// list of object keys leading to the field; array indexes are skipped.
+func (r Redactor) Field(path string) bool {
+	path = strings.ToLower(path)
+	name := normalizeField(path[strings.LastIndex(path, ".")+1:])
+	for _, entry := range slices.Concat(DefaultRedactFields, r.Fields) {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if strings.Contains(entry, ".") {
+			if entry == path {
+				return true
+			}
+			continue
+		}
+		if strings.Contains(name, normalizeField(entry)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Header reports whether the header is sensitive.
+func (r Redactor) Header(name string) bool {
+	return slices.ContainsFunc(slices.Concat(DefaultRedactHeaders, r.Headers), func(h string) bool {
+		return strings.EqualFold(strings.TrimSpace(h), name)
+	})
+}
+
+// JSON returns body with every sensitive field replaced by RedactedValue.
+// Bodies that are not valid JSON are returned as nil with ok false, since
+// they cannot be checked.
+func (r Redactor) JSON(body []byte) (redacted []byte, ok bool) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	if err := decoder.Decode(&value); err != nil {
+		return nil, false
+	}
+	out, err := json.Marshal(r.Value(value))
+	if err != nil {
+		return nil, false
+	}
+	return out, true
+}
+
+// Value redacts a decoded JSON value in place and returns it.
+func (r Redactor) Value(value any) any {
+	return r.walk("", value)
+}
+
+func (r Redactor) walk(path string, value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, child := range v {
+			childPath := key
+			if path != "" {
+				childPath = path + "." + key
+			}
+			if r.Field(childPath) {
+				v[key] = RedactedValue
+				continue
+			}
+			v[key] = r.walk(childPath, child)
+		}
+	case []any:
+		for i, child := range v {
+			v[i] = r.walk(path, child)
+		}
+	}
+	return value
+}
+
+// Query redacts sensitive parameters of a raw query string or form body.
+// Input that does not parse is dropped.
+func (r Redactor) Query(raw string) string {
+	values, err := url.ParseQuery(raw)
+	if err != nil {
+		return ""
+	}
+	for key := range values {
+		if r.Field(key) {
+			for i := range values[key] {
+				values[key][i] = RedactedValue
+			}
+		}
+	}
+	return values.Encode()
+}
+
+// HeaderValues flattens h with sensitive headers redacted.
+func (r Redactor) HeaderValues(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for name, values := range h {
+		if r.Header(name) {
+			out[name] = RedactedValue
+			continue
+		}
+		out[name] = strings.Join(values, ", ")
+	}
+	return out
+}
+
+// Body returns the request body redacted for logging: JSON and form bodies
+// up to limit bytes are redacted, anything else is omitted. The request body
+// is left intact for the handler.
+func (r Redactor) Body(req *http.Request, limit int) (any, bool) {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(req.Header.Get("Content-Type"), ";")[0]))
+	isJSON := mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+	if req.Body == nil || (!isJSON && mediaType != "application/x-www-form-urlencoded") {
+		return nil, false
+	}
+	body, complete := PeekBody(req, limit)
+	if !complete || len(body) == 0 {
+		return nil, false
+	}
+	if !isJSON {
+		return r.Query(string(body)), true
+	}
+	redacted, ok := r.JSON(body)
+	if !ok {
+		return nil, false
+	}
+	return json.RawMessage(redacted), true
+}
+
+// PeekBody reads up to limit bytes of the request body and puts them back so
+// the handler still sees the whole body. Complete is false when the body is
+// longer than limit.
+func PeekBody(req *http.Request, limit int) (body []byte, complete bool) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, true
+	}
+	buf, err := io.ReadAll(io.LimitReader(req.Body, int64(limit)+1))
+	req.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(buf), req.Body), Closer: req.Body}
+	if err != nil || len(buf) > limit {
+		return nil, false
+	}
+	return buf, true
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func normalizeField(name string) string {
+	return strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(name))
+}
@@ -0,0 +1,39 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactorJSONMasksDefaultsAndPaths(t *testing.T) {
+	r := Redactor{Fields: []string{"payment.details.number"}}
+	body := `{"new_password":"p1","cardNumber":"4111","items":[{"accessToken":"t1"}],"payment":{"details":{"number":"99","bank":"bca"}},"name":"ok"}`
+	out, ok := r.JSON([]byte(body))
+	if !ok {
+		t.Fatalf("expected valid json")
+	}
+	got := string(out)
+	for _, leaked := range []string{"p1", "4111", "t1", `"99"`} {
+		if strings.Contains(got, leaked) {
+			t.Fatalf("%q not redacted: %s", leaked, got)
+		}
+	}
+	if !strings.Contains(got, `"bank":"bca"`) || !strings.Contains(got, `"name":"ok"`) {
+		t.Fatalf("non-sensitive fields changed: %s", got)
+	}
+
+	out, _ = Redactor{}.JSON([]byte(`{"url":"https://hooks.example.com","custom_headers":{"X-Tenant-Key":"k-123"}}`))
+	if got := string(out); strings.Contains(got, "k-123") || !strings.Contains(got, `"custom_headers":"[REDACTED]"`) {
+		t.Fatalf("custom headers not redacted whole: %s", got)
+	}
+}
+
+func TestRedactorQueryAndHeaders(t *testing.T) {
+	r := Redactor{Headers: []string{"X-Provider-Key"}}
+	if got := r.Query("token=abc&page=2"); strings.Contains(got, "abc") || !strings.Contains(got, "page=2") {
+		t.Fatalf("unexpected query %q", got)
+	}
+	if !r.Header("x-api-key") || !r.Header("X-Provider-Key") || r.Header("Accept") {
+		t.Fatalf("unexpected header classification")
+	}
+}
@@ -0,0 +1,32 @@
+package common
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// RequestIDHeader carries the per-request identifier on responses and on
+// outbound calls made while serving a request.
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID returns the identifier assigned to the current request by chi's
+// RequestID middleware, or an empty string outside of a request.
+func RequestID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	return middleware.GetReqID(ctx)
+}
+
+// EchoRequestID copies the request ID assigned by chi's RequestID middleware
+// onto the response so clients and error bodies can reference it.
+func EchoRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id := RequestID(r.Context()); id != "" {
+			w.Header().Set(RequestIDHeader, id)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -7,9 +7,10 @@ import (
 
 // ErrorBody represents a consistent error payload returned by the API.
 type ErrorBody struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
-	Details any    `json:"details,omitempty"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Details   any    `json:"details"`
+	RequestID string `json:"requestId,omitempty"`
 }
 
 // JSON writes the provided value to the response writer as JSON.
@@ -19,13 +20,16 @@ func JSON(w http.ResponseWriter, status int, v any) {
 	_ = json.NewEncoder(w).Encode(v)
 }
 
-// JSONError renders an error response using the canonical error shape.
+// JSONError renders an error response using the canonical error shape. The
+// request ID is taken from the response header set by EchoRequestID.
 func JSONError(w http.ResponseWriter, status int, code, message string, details any) {
+	checkCode(code)
 	JSON(w, status, map[string]any{
 		"error": ErrorBody{
-			Code:    code,
-			Message: message,
-			Details: details,
+			Code:      code,
+			Message:   message,
+			Details:   details,
+			RequestID: w.Header().Get(RequestIDHeader),
 		},
 	})
 }
@@ -3,8 +3,11 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"os"
+	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -13,7 +16,14 @@ import (
 	"github.com/knadh/koanf/providers/env"
 	"github.com/knadh/koanf/v2"
 
+	"github.com/noah-isme/backend-toko/internal/common"
+	"github.com/noah-isme/backend-toko/internal/db"
 	"github.com/noah-isme/backend-toko/internal/events"
+	"github.com/noah-isme/backend-toko/internal/maintenance"
+	"github.com/noah-isme/backend-toko/internal/obs"
+	"github.com/noah-isme/backend-toko/internal/pricing"
+	"github.com/noah-isme/backend-toko/internal/resilience"
+	"github.com/noah-isme/backend-toko/internal/shipping"
 )
 
 // Config holds application configuration loaded from the environment.
@@ -28,12 +38,23 @@ type Config struct {
 	DBMaxIdleConns             int
 	DBConnMaxLifetime          time.Duration
 	DBStatementCacheCapacity   int
+	DBSlowQueryThreshold       time.Duration
+	StartupTimeout             time.Duration
+	StartupConnectAttempts     int
+	StartupConnectMaxWait      time.Duration
 	HTTPMaxInFlight            int
 	JWTSecret                  string
 	JWTIssuer                  string
 	JWTAudience                string
+	JWTAudiences               []string
+	JWTAcceptedAudiences       []string
 	JWTClockSkew               time.Duration
+	TOTPEncryptionKey          string
+	PasswordHashMemoryKiB      int
+	PasswordHashIterations     int
+	PasswordHashParallelism    int
 	CORSAllowedOrigins         []string
+	TrustedProxies             []*net.IPNet
 	MidtransServerKey          string
 	MidtransClientKey          string
 	MidtransBaseURL            string
@@ -54,6 +75,8 @@ type Config struct {
 	AccessTokenTTL             time.Duration
 	RefreshTokenTTL            time.Duration
 	PasswordResetTTL           time.Duration
+	AccessTokenTTLByRole       map[string]time.Duration
+	RefreshTokenTTLByRole      map[string]time.Duration
 	RefreshCookieName          string
 	RefreshCookieDomain        string
 	RefreshCookieSecure        bool
@@ -63,25 +86,58 @@ type Config struct {
 	CatalogDefaultLimit        int
 	CatalogMaxLimit            int
 	CatalogCacheTTL            time.Duration
+	CatalogDefaultSort         string
+	CatalogDefaultLocale       string
+	CatalogLocales             []string
+	CatalogBadgeSale           bool
+	CatalogBadgeNewDays        int
+	CatalogBadgeLowStock       int
+	CatalogBadgeBestsellerTop  int
 	CartTTL                    time.Duration
 	PricingTaxRateBPS          int
 	CurrencyCode               string
 	CurrencyMinorUnit          int
 	IdempotencyTTL             time.Duration
+	StateBackend               string
+	MaintenanceMode            string
+	MaintenanceMessage         string
+	MaintenanceRetryAfter      time.Duration
+	MaintenanceBypassToken     string
+	ListEnvelope               string
+	JSONInt64AsString          bool
+	CheckoutMinOrderTotal      int64
+	CheckoutMaxOrderTotal      int64
+	ShippingFreeThreshold      int64
 	VoucherMaxStack            int
 	VoucherDefaultPriority     int
 	VoucherPerUserLimit        int
+	VoucherReleaseOnCancel     bool
 	AnalyticsCacheTTL          time.Duration
 	AnalyticsDefaultRange      int
 	NotifyEmailEnabled         bool
 	NotifyEmailFrom            string
 	NotifyEmailTopics          map[string]bool
+	EmailProvider              string
+	EmailProviderURL           string
+	EmailProviderAPIKey        string
+	EmailSendTimeout           time.Duration
+	SMTPHost                   string
+	SMTPPort                   int
+	SMTPUsername               string
+	SMTPPassword               string
+	SMTPTLS                    string
+	EmailQueueEnabled          bool
+	EmailMaxAttempts           int
 	WebhookDeliveryEnabled     bool
 	WebhookDefaultMaxAttempts  int
 	WebhookBackoffBaseSec      int
 	WebhookRequestTimeout      time.Duration
 	WebhookAllowInsecureTLS    bool
 	WebhookReplayTTL           time.Duration
+	WebhookAttemptBodyLimit    int
+	WebhookAttemptHistoryLimit int
+	WebhookMaxPayloadBytes     int
+	WebhookPayloadURLTTL       time.Duration
 	EventWorkerConcurrency     int
 	CircuitPaymentMinReq       int
 	CircuitPaymentFailureRate  float64
@@ -92,6 +148,9 @@ type Config struct {
 	CircuitWebhookMinReq       int
 	CircuitWebhookFailureRate  float64
 	CircuitWebhookOpenFor      time.Duration
+	CircuitEmailMinReq         int
+	CircuitEmailFailureRate    float64
+	CircuitEmailOpenFor        time.Duration
 	RetryBase                  time.Duration
 	RetryMaxAttempts           int
 	RetryJitterPercent         float64
@@ -104,14 +163,190 @@ type Config struct {
 	QueueConcurrencyWebhook    int
 	QueueConcurrencyEmail      int
 	QueueConcurrencyAnalytics  int
+	QueueAdaptiveConcurrency   bool
+	QueueAdaptiveMin           int
+	QueueAdaptiveLatencyTarget time.Duration
 	LockTTL                    time.Duration
 	LockRetryBackoff           time.Duration
+	LockRenewInterval          time.Duration
 	WorkerShutdownGrace        time.Duration
 	WorkerHeartbeatInterval    time.Duration
 	WorkerJobSoftDeadline      time.Duration
 	APIMaxShutdownGrace        time.Duration
 	EnableAPIEmbeddedWorkers   bool
 	AdminDLQPageSize           int
+	MediaStorage               string
+	MediaLocalDir              string
+	MediaBaseURL               string
+	MediaMaxUploadBytes        int64
+	MediaPrivate               bool
+	MediaSigningKey            string
+	MediaSignedURLTTL          time.Duration
+	S3Endpoint                 string
+	S3Region                   string
+	S3Bucket                   string
+	S3AccessKeyID              string
+	S3SecretAccessKey          string
+	S3PathStyle                bool
+	// PaymentProviders configures every enabled payment provider by name.
+	// PAYMENT_PROVIDER picks the one used for new intents.
+	PaymentProviders map[string]PaymentProviderConfig
+	// WebhookAutoDisableAfter deactivates a webhook endpoint after this many
+	// consecutive failed delivery attempts; zero never disables.
+	WebhookAutoDisableAfter int
+	// Retention* configure the worker's purge of old webhook deliveries,
+	// delivery attempts, domain events, and processed inbound callbacks. A
+	// zero period keeps rows forever.
+	RetentionEnabled       bool
+	RetentionInterval      time.Duration
+	RetentionBatchSize     int
+	RetentionDeliveredDays int
+	RetentionDLQDays       int
+	RetentionAttemptsDays  int
+	RetentionEventsDays    int
+	RetentionInboundDays   int
+	// PaymentFakeCallbackDelay is how long the fake payment provider waits
+	// before posting its own webhook.
+	PaymentFakeCallbackDelay time.Duration
+	// PricingRounding rounds tax and percentage discounts.
+	PricingRounding pricing.Rounding
+	// CatalogHideOutOfStock hides out-of-stock products from the public
+	// catalog unless a tenant setting overrides it.
+	CatalogHideOutOfStock bool
+	// SchemaCheck is what happens when the database is not migrated to this
+	// build's latest migration: fail, warn, or off.
+	SchemaCheck string
+	// CircuitHalfOpenProbes requests are let through once a breaker's open
+	// period ends; CircuitHalfOpenSuccessRatio of them must succeed to close it.
+	CircuitHalfOpenProbes       int
+	CircuitHalfOpenSuccessRatio float64
+	// RetryBudgetWebhook and RetryBudgetEmail bound the retries in flight
+	// toward each target; the budgets refill at RetryBudgetRefillPerSec.
+	RetryBudgetWebhook      int
+	RetryBudgetEmail        int
+	RetryBudgetRefillPerSec float64
+	// CartMaxItems, CartMaxLineQty, and CartMaxTotalQty cap the distinct
+	// lines, the quantity of a line, and the total quantity of a cart.
+	CartMaxItems    int
+	CartMaxLineQty  int
+	CartMaxTotalQty int
+	// AnalyticsRefreshInterval is how often the worker refreshes the
+	// analytics views; zero leaves them to admin refreshes.
+	AnalyticsRefreshInterval time.Duration
+	// AnalyticsWarmConcurrency caps parallel report loads when the worker
+	// re-warms the analytics cache after a refresh; zero disables warming.
+	AnalyticsWarmConcurrency int
+	// AnalyticsMaxRangeDays caps the date range of the sales and voucher
+	// reports, with AnalyticsMaxRangeDaysByReport overriding it per report.
+	// Sales ranges longer than AnalyticsWeeklyAfterDays or
+	// AnalyticsMonthlyAfterDays are bucketed at least weekly or monthly.
+	AnalyticsMaxRangeDays         int
+	AnalyticsMaxRangeDaysByReport map[string]int
+	AnalyticsWeeklyAfterDays      int
+	AnalyticsMonthlyAfterDays     int
+	// RecommendationsDefaultCount and RecommendationsMaxCount size the
+	// product recommendations list.
+	RecommendationsDefaultCount int
+	RecommendationsMaxCount     int
+	// RedisTenantIsolation namespaces catalog cache keys by tenant; off keeps
+	// the flat keys of single-tenant deployments.
+	RedisTenantIsolation bool
+	// CheckoutPriceToleranceBps is how far a cart price may drift from the
+	// catalog price, in basis points, and still be charged at checkout.
+	CheckoutPriceToleranceBps int
+	// RequestCacheServices names the services (cart, checkout, shipping)
+	// that memoize repeated lookups within one request.
+	RequestCacheServices []string
+	// InboundWebhookMaxAge rejects provider callbacks whose signed timestamp is
+	// older than this; zero disables the check.
+	InboundWebhookMaxAge time.Duration
+	// InboundWebhookMaxSkew is how far ahead of this server's clock a
+	// callback timestamp may be.
+	InboundWebhookMaxSkew time.Duration
+	// InboundWebhookMaxAgeBySource and InboundWebhookClaimTTLBySource
+	// override the window and replay TTL per source, e.g. payment:midtrans.
+	InboundWebhookMaxAgeBySource   map[string]time.Duration
+	InboundWebhookClaimTTLBySource map[string]time.Duration
+	// AccessLogFields selects the fields on each access log line; empty logs
+	// the default fields.
+	AccessLogFields []string
+	// AccessLogSampleRates maps a path prefix to the fraction of its
+	// requests written to the access log.
+	AccessLogSampleRates map[string]float64
+	// AccessLogSlowThreshold always logs requests at least this long.
+	AccessLogSlowThreshold time.Duration
+	// OutboundProxyURL routes webhook and provider calls through a proxy;
+	// empty honours HTTPS_PROXY and NO_PROXY.
+	OutboundProxyURL string
+	// OutboundCAFile is a PEM bundle trusted for webhook and provider calls
+	// on top of the system roots.
+	OutboundCAFile string
+	// AccountRecoveryWindow is how long a closed account can be reactivated
+	// before the worker purges it; zero disables recovery.
+	AccountRecoveryWindow time.Duration
+	// AccountPurgeInterval is how often the worker purges closed accounts
+	// past the recovery window; zero disables the job.
+	AccountPurgeInterval time.Duration
+	// ShippingDefaultItemWeightGram weighs cart units whose variant has no
+	// weight when quoting shipping.
+	ShippingDefaultItemWeightGram int
+	// ShippingVolumetricDivisor converts parcel volume (cm³) into volumetric
+	// weight (kg); zero quotes by actual weight only.
+	ShippingVolumetricDivisor int
+	// ShippingPreferredCouriers orders quoted rates for tenants without their
+	// own courier policy, e.g. "jne:REG,sicepat".
+	ShippingPreferredCouriers []string
+	// WebhookWorkConcurrency bounds how many endpoints one webhook delivery
+	// batch is delivered to in parallel.
+	WebhookWorkConcurrency int
+	// WorkerLivenessTTL is how long a worker heartbeat stays valid and how
+	// long a worker loop may go without progress before the heartbeat stops.
+	WorkerLivenessTTL time.Duration
+	// RedactFields and RedactHeaders add to the fields and headers masked in
+	// access logs and audit entries.
+	RedactFields  []string
+	RedactHeaders []string
+	// NotifyEmailCategories maps topic patterns to the preference category
+	// users opt in or out of; NotifyEmailRequiredCategories cannot be opted
+	// out of. Nil keeps the notifier defaults.
+	NotifyEmailCategories         map[string]string
+	NotifyEmailRequiredCategories []string
+	// WebhookRetryClientErrors retries 4xx webhook responses instead of
+	// dead-lettering them on the first attempt.
+	WebhookRetryClientErrors bool
+	// CartExpiryWarning flags carts that expire within this window in cart
+	// responses; zero disables the flag.
+	CartExpiryWarning time.Duration
+	// WebhookHeadersEncryptionKey is a base64 AES key (16, 24, or 32 bytes)
+	// sealing webhook endpoint custom headers; empty derives one from
+	// JWTSecret.
+	WebhookHeadersEncryptionKey string
+	// OrderExportFormat is the default accounting export format, csv or
+	// json; OrderExportStatuses are the order statuses it exports by
+	// default, empty meaning paid and later.
+	OrderExportFormat   string
+	OrderExportStatuses []string
+	// ChaosEnabled turns on fault injection for chaos testing: calls to
+	// ChaosTargets (empty means all) fail at ChaosFailureRate and are
+	// delayed by ChaosLatency at ChaosLatencyRate. It is refused when
+	// APP_ENV=production.
+	ChaosEnabled     bool
+	ChaosFailureRate float64
+	ChaosLatencyRate float64
+	ChaosLatency     time.Duration
+	ChaosTargets     []string
+	// CatalogBadgePriceDropDays and CatalogBadgePriceDropMinPercent derive
+	// the price-drop badge from price history; CatalogPriceTrendPoints is how
+	// many recent prices product detail shows. Zero disables each.
+	CatalogBadgePriceDropDays       int
+	CatalogBadgePriceDropMinPercent int
+	CatalogPriceTrendPoints         int
+}
+
+// PaymentProviderConfig holds one payment provider's credentials.
+type PaymentProviderConfig struct {
+	SecretKey string
+	BaseURL   string
 }
 
 // Load reads configuration from environment variables and optional .env files.
@@ -142,6 +377,15 @@ func Load() (*Config, error) {
 		queueJitter = parseFloatAllowZero(k.String("RETRY_JITTER_PCT"), 0.2)
 	}
 
+	accessTTLByRole, err := parseRoleDurations(k.String("ACCESS_TOKEN_TTL_BY_ROLE"))
+	if err != nil {
+		return nil, fmt.Errorf("ACCESS_TOKEN_TTL_BY_ROLE: %w", err)
+	}
+	refreshTTLByRole, err := parseRoleDurations(k.String("REFRESH_TOKEN_TTL_BY_ROLE"))
+	if err != nil {
+		return nil, fmt.Errorf("REFRESH_TOKEN_TTL_BY_ROLE: %w", err)
+	}
+
 	cfg := &Config{
 		AppEnv:                     valueOrDefault(k.String("APP_ENV"), "development"),
 		Port:                       valueOrDefault(k.String("PORT"), "8080"),
@@ -153,11 +397,21 @@ func Load() (*Config, error) {
 		DBMaxIdleConns:             parsePositiveIntAllowZero(k.String("DB_MAX_IDLE_CONNS"), 10),
 		DBConnMaxLifetime:          time.Duration(parsePositiveIntAllowZero(k.String("DB_CONN_MAX_LIFETIME_MIN"), 30)) * time.Minute,
 		DBStatementCacheCapacity:   parsePositiveIntAllowZero(k.String("DB_STATEMENT_CACHE_CAPACITY"), 256),
+		DBSlowQueryThreshold:       time.Duration(parsePositiveIntAllowZero(k.String("DB_SLOW_QUERY_MS"), 200)) * time.Millisecond,
+		StartupTimeout:             time.Duration(parsePositiveInt(k.String("STARTUP_TIMEOUT_SEC"), 60)) * time.Second,
+		StartupConnectAttempts:     parsePositiveInt(k.String("STARTUP_CONNECT_ATTEMPTS"), 10),
+		StartupConnectMaxWait:      time.Duration(parsePositiveInt(k.String("STARTUP_CONNECT_MAX_WAIT_MS"), 5000)) * time.Millisecond,
 		HTTPMaxInFlight:            parsePositiveIntAllowZero(k.String("HTTP_MAX_INFLIGHT"), 400),
 		JWTSecret:                  k.String("JWT_SECRET"),
 		JWTIssuer:                  strings.TrimSpace(valueOrDefault(k.String("JWT_ISSUER"), "backend-toko")),
 		JWTAudience:                strings.TrimSpace(valueOrDefault(k.String("JWT_AUDIENCE"), "toko-frontend")),
+		JWTAudiences:               splitAndTrim(k.String("JWT_AUDIENCES")),
+		JWTAcceptedAudiences:       splitAndTrim(k.String("JWT_ACCEPTED_AUDIENCES")),
 		JWTClockSkew:               time.Duration(parsePositiveIntAllowZero(k.String("JWT_CLOCK_SKEW_SEC"), 60)) * time.Second,
+		TOTPEncryptionKey:          strings.TrimSpace(k.String("TOTP_ENCRYPTION_KEY")),
+		PasswordHashMemoryKiB:      parsePositiveInt(k.String("PASSWORD_HASH_MEMORY_KIB"), 64*1024),
+		PasswordHashIterations:     parsePositiveInt(k.String("PASSWORD_HASH_ITERATIONS"), 1),
+		PasswordHashParallelism:    min(parsePositiveInt(k.String("PASSWORD_HASH_PARALLELISM"), runtime.NumCPU()), 255),
 		CORSAllowedOrigins:         splitAndTrim(k.String("CORS_ALLOWED_ORIGINS")),
 		MidtransServerKey:          k.String("MIDTRANS_SERVER_KEY"),
 		MidtransClientKey:          k.String("MIDTRANS_CLIENT_KEY"),
@@ -179,6 +433,8 @@ func Load() (*Config, error) {
 		AccessTokenTTL:             parseDuration(k.String("ACCESS_TOKEN_TTL"), "15m"),
 		RefreshTokenTTL:            parseDuration(k.String("REFRESH_TOKEN_TTL"), "720h"),
 		PasswordResetTTL:           parseDuration(k.String("PASSWORD_RESET_TTL"), "1h"),
+		AccessTokenTTLByRole:       accessTTLByRole,
+		RefreshTokenTTLByRole:      refreshTTLByRole,
 		RefreshCookieName:          valueOrDefault(k.String("REFRESH_COOKIE_NAME"), "rt"),
 		RefreshCookieDomain:        strings.TrimSpace(k.String("REFRESH_COOKIE_DOMAIN")),
 		RefreshCookieSecure:        parseBool(k.String("REFRESH_COOKIE_SECURE")),
@@ -188,25 +444,83 @@ func Load() (*Config, error) {
 		CatalogDefaultLimit:        parsePositiveInt(k.String("CATALOG_DEFAULT_LIMIT"), 20),
 		CatalogMaxLimit:            parsePositiveInt(k.String("CATALOG_MAX_LIMIT"), 100),
 		CatalogCacheTTL:            time.Duration(catalogTTL) * time.Second,
+		MediaStorage:               strings.ToLower(valueOrDefault(k.String("MEDIA_STORAGE"), "local")),
+		MediaLocalDir:              valueOrDefault(k.String("MEDIA_LOCAL_DIR"), "./var/media"),
+		MediaBaseURL:               strings.TrimRight(strings.TrimSpace(k.String("MEDIA_PUBLIC_BASE_URL")), "/"),
+		MediaMaxUploadBytes:        int64(parsePositiveInt(k.String("MEDIA_MAX_UPLOAD_BYTES"), 5<<20)),
+		MediaPrivate:               parseBool(k.String("MEDIA_PRIVATE")),
+		MediaSigningKey:            k.String("MEDIA_SIGNING_KEY"),
+		MediaSignedURLTTL:          time.Duration(parsePositiveInt(k.String("MEDIA_SIGNED_URL_TTL_SEC"), 3600)) * time.Second,
+		S3Endpoint:                 strings.TrimSpace(k.String("S3_ENDPOINT")),
+		S3Region:                   valueOrDefault(k.String("S3_REGION"), "us-east-1"),
+		S3Bucket:                   strings.TrimSpace(k.String("S3_BUCKET")),
+		S3AccessKeyID:              k.String("S3_ACCESS_KEY_ID"),
+		S3SecretAccessKey:          k.String("S3_SECRET_ACCESS_KEY"),
+		S3PathStyle:                parseBool(k.String("S3_PATH_STYLE")),
+		CatalogDefaultSort:         strings.ToLower(strings.TrimSpace(k.String("CATALOG_DEFAULT_SORT"))),
+		CatalogDefaultLocale:       strings.ToLower(valueOrDefault(k.String("CATALOG_DEFAULT_LOCALE"), "id")),
+		CatalogLocales:             splitAndTrim(strings.ToLower(valueOrDefault(k.String("CATALOG_LOCALES"), "id,en"))),
+		CatalogBadgeSale:           parseBoolWithDefault(k.String("CATALOG_BADGE_SALE"), false),
+		CatalogBadgeNewDays:        parsePositiveIntAllowZero(k.String("CATALOG_BADGE_NEW_DAYS"), 0),
+		CatalogBadgeLowStock:       parsePositiveIntAllowZero(k.String("CATALOG_BADGE_LOW_STOCK"), 0),
+		CatalogBadgeBestsellerTop:  parsePositiveIntAllowZero(k.String("CATALOG_BADGE_BESTSELLER_TOP"), 0),
 		CartTTL:                    time.Duration(parsePositiveInt(k.String("CART_TTL_HOURS"), 168)) * time.Hour,
 		PricingTaxRateBPS:          parsePositiveInt(k.String("PRICING_TAX_RATE_BPS"), 1100),
 		CurrencyCode:               valueOrDefault(k.String("CURRENCY_CODE"), "IDR"),
 		CurrencyMinorUnit:          parsePositiveIntAllowZero(k.String("CURRENCY_MINOR_UNIT"), 0),
 		IdempotencyTTL:             time.Duration(parsePositiveInt(k.String("IDEMPOTENCY_TTL_SEC"), 600)) * time.Second,
+		StateBackend:               strings.ToLower(strings.TrimSpace(valueOrDefault(k.String("STATE_BACKEND"), "redis"))),
+		MaintenanceMode:            k.String("MAINTENANCE_MODE"),
+		MaintenanceMessage:         strings.TrimSpace(k.String("MAINTENANCE_MESSAGE")),
+		MaintenanceRetryAfter:      time.Duration(parsePositiveInt(k.String("MAINTENANCE_RETRY_AFTER_SEC"), 300)) * time.Second,
+		MaintenanceBypassToken:     k.String("MAINTENANCE_BYPASS_TOKEN"),
+		ListEnvelope:               k.String("API_LIST_ENVELOPE"),
+		JSONInt64AsString:          parseBoolWithDefault(k.String("API_INT64_AS_STRING"), false),
+		CheckoutMinOrderTotal:      int64(parsePositiveIntAllowZero(k.String("CHECKOUT_MIN_ORDER_TOTAL"), 0)),
+		CheckoutMaxOrderTotal:      int64(parsePositiveIntAllowZero(k.String("CHECKOUT_MAX_ORDER_TOTAL"), 0)),
+		ShippingFreeThreshold:      int64(parsePositiveIntAllowZero(k.String("SHIPPING_FREE_THRESHOLD"), 0)),
 		VoucherMaxStack:            parsePositiveIntAllowZero(k.String("VOUCHER_MAX_STACK"), 1),
 		VoucherDefaultPriority:     parsePositiveIntAllowZero(k.String("VOUCHER_DEFAULT_PRIORITY"), 100),
 		VoucherPerUserLimit:        parsePositiveIntAllowZero(k.String("VOUCHER_PER_USER_LIMIT_DEFAULT"), 1),
+		VoucherReleaseOnCancel:     parseBoolWithDefault(k.String("VOUCHER_RELEASE_ON_CANCEL"), true),
 		AnalyticsCacheTTL:          time.Duration(analyticsTTL) * time.Second,
 		AnalyticsDefaultRange:      parsePositiveIntAllowZero(k.String("ANALYTICS_DEFAULT_RANGE_DAYS"), 30),
 		NotifyEmailEnabled:         parseBoolWithDefault(k.String("NOTIFY_EMAIL_ENABLED"), true),
 		NotifyEmailFrom:            valueOrDefault(k.String("NOTIFY_FROM_EMAIL"), "no-reply@toko.local"),
 		NotifyEmailTopics:          parseTopicToggles(k, "NOTIFY_EMAIL_TOPIC_", true),
+		EmailProvider:              strings.ToLower(strings.TrimSpace(k.String("NOTIFY_EMAIL_PROVIDER"))),
+		EmailProviderURL:           strings.TrimSpace(k.String("EMAIL_PROVIDER_URL")),
+		EmailProviderAPIKey:        k.String("EMAIL_PROVIDER_API_KEY"),
+		EmailSendTimeout:           time.Duration(parsePositiveIntAllowZero(k.String("EMAIL_SEND_TIMEOUT_MS"), 10000)) * time.Millisecond,
+		SMTPHost:                   strings.TrimSpace(k.String("SMTP_HOST")),
+		SMTPPort:                   parsePositiveIntAllowZero(k.String("SMTP_PORT"), 587),
+		SMTPUsername:               k.String("SMTP_USERNAME"),
+		SMTPPassword:               k.String("SMTP_PASSWORD"),
+		SMTPTLS:                    strings.ToLower(strings.TrimSpace(k.String("SMTP_TLS"))),
+		EmailQueueEnabled:          parseBoolWithDefault(k.String("EMAIL_QUEUE_ENABLED"), true),
+		EmailMaxAttempts:           parsePositiveIntAllowZero(k.String("EMAIL_MAX_ATTEMPTS"), 5),
 		WebhookDeliveryEnabled:     parseBoolWithDefault(k.String("WEBHOOK_DELIVERY_ENABLED"), true),
 		WebhookDefaultMaxAttempts:  parsePositiveIntAllowZero(k.String("WEBHOOK_DEFAULT_MAX_ATTEMPTS"), 6),
 		WebhookBackoffBaseSec:      parsePositiveIntAllowZero(k.String("WEBHOOK_BACKOFF_BASE_SEC"), 5),
 		WebhookRequestTimeout:      time.Duration(parsePositiveIntAllowZero(k.String("WEBHOOK_REQUEST_TIMEOUT_MS"), 5000)) * time.Millisecond,
 		WebhookAllowInsecureTLS:    parseBool(k.String("WEBHOOK_ALLOW_INSECURE_TLS")),
 		WebhookReplayTTL:           time.Duration(parsePositiveIntAllowZero(k.String("WEBHOOK_REPLAY_TTL_SEC"), 600)) * time.Second,
+		WebhookAttemptBodyLimit:    parsePositiveIntAllowZero(k.String("WEBHOOK_ATTEMPT_BODY_LIMIT_BYTES"), 2048),
+		WebhookAttemptHistoryLimit: parsePositiveIntAllowZero(k.String("WEBHOOK_ATTEMPT_HISTORY_LIMIT"), 20),
+		WebhookMaxPayloadBytes:     parsePositiveIntAllowZero(k.String("WEBHOOK_MAX_PAYLOAD_BYTES"), 262144),
+		WebhookPayloadURLTTL:       time.Duration(parsePositiveIntAllowZero(k.String("WEBHOOK_PAYLOAD_URL_TTL_SEC"), 604800)) * time.Second,
+		WebhookAutoDisableAfter:    parsePositiveIntAllowZero(k.String("WEBHOOK_AUTO_DISABLE_AFTER"), 50),
+		WebhookWorkConcurrency:     parsePositiveIntAllowZero(k.String("WEBHOOK_WORK_CONCURRENCY"), 8),
+		RetentionEnabled:           parseBoolWithDefault(k.String("RETENTION_ENABLED"), true),
+		RetentionInterval:          parseDuration(k.String("RETENTION_INTERVAL"), "1h"),
+		RetentionBatchSize:         parsePositiveInt(k.String("RETENTION_BATCH_SIZE"), 1000),
+		RetentionDeliveredDays:     parsePositiveIntAllowZero(k.String("RETENTION_DELIVERED_DAYS"), 14),
+		RetentionDLQDays:           parsePositiveIntAllowZero(k.String("RETENTION_DLQ_DAYS"), 90),
+		RetentionAttemptsDays:      parsePositiveIntAllowZero(k.String("RETENTION_ATTEMPTS_DAYS"), 14),
+		RetentionEventsDays:        parsePositiveIntAllowZero(k.String("RETENTION_EVENTS_DAYS"), 30),
+		RetentionInboundDays:       parsePositiveIntAllowZero(k.String("RETENTION_INBOUND_DAYS"), 30),
+		PaymentFakeCallbackDelay:   time.Duration(parsePositiveIntAllowZero(k.String("PAYMENT_FAKE_CALLBACK_DELAY_MS"), 3000)) * time.Millisecond,
+		CatalogHideOutOfStock:      parseBoolWithDefault(k.String("CATALOG_HIDE_OUT_OF_STOCK"), false),
 		EventWorkerConcurrency:     parsePositiveIntAllowZero(k.String("EVENT_WORKER_CONCURRENCY"), 1),
 		CircuitPaymentMinReq:       parsePositiveIntAllowZero(k.String("CB_PAYMENT_MIN_REQUESTS"), 20),
 		CircuitPaymentFailureRate:  parseFloatAllowZero(k.String("CB_PAYMENT_FAILURE_RATE_THRESHOLD"), 0.5),
@@ -217,6 +531,9 @@ func Load() (*Config, error) {
 		CircuitWebhookMinReq:       parsePositiveIntAllowZero(k.String("CB_WEBHOOK_MIN_REQUESTS"), 5),
 		CircuitWebhookFailureRate:  parseFloatAllowZero(k.String("CB_WEBHOOK_FAILURE_RATE_THRESHOLD"), 0.5),
 		CircuitWebhookOpenFor:      time.Duration(parsePositiveIntAllowZero(k.String("CB_WEBHOOK_OPEN_SEC"), 30)) * time.Second,
+		CircuitEmailMinReq:         parsePositiveIntAllowZero(k.String("CB_EMAIL_MIN_REQUESTS"), 5),
+		CircuitEmailFailureRate:    parseFloatAllowZero(k.String("CB_EMAIL_FAILURE_RATE_THRESHOLD"), 0.5),
+		CircuitEmailOpenFor:        time.Duration(parsePositiveIntAllowZero(k.String("CB_EMAIL_OPEN_SEC"), 30)) * time.Second,
 		RetryBase:                  time.Duration(retryBaseMs) * time.Millisecond,
 		RetryMaxAttempts:           parsePositiveIntAllowZero(k.String("RETRY_MAX_ATTEMPTS"), 5),
 		RetryJitterPercent:         parseFloatAllowZero(k.String("RETRY_JITTER_PCT"), 0.2),
@@ -229,8 +546,12 @@ func Load() (*Config, error) {
 		QueueConcurrencyWebhook:    parsePositiveIntAllowZero(k.String("QUEUE_CONCURRENCY_WEBHOOK"), 16),
 		QueueConcurrencyEmail:      parsePositiveIntAllowZero(k.String("QUEUE_CONCURRENCY_EMAIL"), 8),
 		QueueConcurrencyAnalytics:  parsePositiveIntAllowZero(k.String("QUEUE_CONCURRENCY_ANALYTICS"), 4),
+		QueueAdaptiveConcurrency:   parseBool(k.String("QUEUE_ADAPTIVE_CONCURRENCY")),
+		QueueAdaptiveMin:           parsePositiveIntAllowZero(k.String("QUEUE_ADAPTIVE_MIN"), 1),
+		QueueAdaptiveLatencyTarget: time.Duration(parsePositiveIntAllowZero(k.String("QUEUE_ADAPTIVE_LATENCY_TARGET_MS"), 2000)) * time.Millisecond,
 		LockTTL:                    time.Duration(parsePositiveIntAllowZero(k.String("LOCK_TTL_SEC"), 45)) * time.Second,
 		LockRetryBackoff:           time.Duration(parsePositiveIntAllowZero(k.String("LOCK_RETRY_MS"), 80)) * time.Millisecond,
+		LockRenewInterval:          time.Duration(parsePositiveIntAllowZero(k.String("LOCK_RENEW_MS"), 0)) * time.Millisecond,
 		WorkerShutdownGrace:        time.Duration(parsePositiveIntAllowZero(k.String("WORKER_SHUTDOWN_GRACE_SEC"), 25)) * time.Second,
 		WorkerHeartbeatInterval:    time.Duration(parsePositiveIntAllowZero(k.String("WORKER_HEARTBEAT_SEC"), 5)) * time.Second,
 		WorkerJobSoftDeadline:      time.Duration(parsePositiveIntAllowZero(k.String("WORKER_JOB_SOFT_DEADLINE_SEC"), 20)) * time.Second,
@@ -270,6 +591,15 @@ func Load() (*Config, error) {
 	if cfg.CircuitWebhookOpenFor <= 0 {
 		cfg.CircuitWebhookOpenFor = 30 * time.Second
 	}
+	if cfg.CircuitEmailMinReq <= 0 {
+		cfg.CircuitEmailMinReq = 5
+	}
+	if cfg.CircuitEmailFailureRate <= 0 {
+		cfg.CircuitEmailFailureRate = 0.5
+	}
+	if cfg.CircuitEmailOpenFor <= 0 {
+		cfg.CircuitEmailOpenFor = 30 * time.Second
+	}
 	if cfg.CircuitPaymentFailureRate <= 0 {
 		cfg.CircuitPaymentFailureRate = 0.5
 	}
@@ -288,6 +618,100 @@ func Load() (*Config, error) {
 	if cfg.CircuitShippingOpenFor <= 0 {
 		cfg.CircuitShippingOpenFor = 30 * time.Second
 	}
+	cfg.CircuitHalfOpenProbes = parsePositiveIntAllowZero(k.String("CB_HALF_OPEN_PROBES"), 1)
+	cfg.CircuitHalfOpenSuccessRatio = parseFloatAllowZero(k.String("CB_HALF_OPEN_SUCCESS_RATIO"), 1)
+	if cfg.CircuitHalfOpenProbes <= 0 {
+		cfg.CircuitHalfOpenProbes = 1
+	}
+	if cfg.CircuitHalfOpenSuccessRatio <= 0 || cfg.CircuitHalfOpenSuccessRatio > 1 {
+		cfg.CircuitHalfOpenSuccessRatio = 1
+	}
+	cfg.RetryBudgetWebhook = parsePositiveIntAllowZero(k.String("RETRY_BUDGET_WEBHOOK"), 50)
+	cfg.RetryBudgetEmail = parsePositiveIntAllowZero(k.String("RETRY_BUDGET_EMAIL"), 20)
+	cfg.RetryBudgetRefillPerSec = parseFloatAllowZero(k.String("RETRY_BUDGET_REFILL_PER_SEC"), 1)
+	cfg.CartMaxItems = parsePositiveIntAllowZero(k.String("CART_MAX_ITEMS"), 100)
+	cfg.CartMaxLineQty = parsePositiveIntAllowZero(k.String("CART_MAX_LINE_QTY"), 99)
+	cfg.CartMaxTotalQty = parsePositiveIntAllowZero(k.String("CART_MAX_TOTAL_QTY"), 500)
+	cfg.AnalyticsRefreshInterval = parseDuration(k.String("ANALYTICS_REFRESH_INTERVAL"), "1h")
+	cfg.AnalyticsWarmConcurrency = parsePositiveIntAllowZero(k.String("ANALYTICS_WARM_CONCURRENCY"), 2)
+	if cfg.NotifyEmailCategories, err = parseTopicCategories(strings.ToLower(k.String("NOTIFY_EMAIL_TOPIC_CATEGORIES"))); err != nil {
+		return nil, fmt.Errorf("NOTIFY_EMAIL_TOPIC_CATEGORIES: %w", err)
+	}
+	if raw := k.String("NOTIFY_EMAIL_REQUIRED_CATEGORIES"); raw != "" {
+		cfg.NotifyEmailRequiredCategories = splitAndTrim(strings.ToLower(raw))
+	}
+	cfg.WebhookRetryClientErrors = parseBool(k.String("WEBHOOK_RETRY_CLIENT_ERRORS"))
+	cfg.WebhookHeadersEncryptionKey = strings.TrimSpace(k.String("WEBHOOK_HEADERS_ENCRYPTION_KEY"))
+	cfg.CartExpiryWarning = time.Duration(parsePositiveIntAllowZero(k.String("CART_EXPIRY_WARNING_MINUTES"), 60)) * time.Minute
+	cfg.OrderExportFormat = strings.ToLower(strings.TrimSpace(k.String("ORDER_EXPORT_FORMAT")))
+	if cfg.OrderExportFormat == "" {
+		cfg.OrderExportFormat = "csv"
+	}
+	if cfg.OrderExportFormat != "csv" && cfg.OrderExportFormat != "json" {
+		return nil, fmt.Errorf("ORDER_EXPORT_FORMAT must be csv or json, got %q", cfg.OrderExportFormat)
+	}
+	cfg.OrderExportStatuses = splitAndTrim(strings.ToUpper(k.String("ORDER_EXPORT_STATUSES")))
+	cfg.ChaosEnabled = parseBool(k.String("CHAOS_ENABLED"))
+	cfg.ChaosFailureRate = parseFloatAllowZero(k.String("CHAOS_FAILURE_RATE"), 0)
+	cfg.ChaosLatencyRate = parseFloatAllowZero(k.String("CHAOS_LATENCY_RATE"), 0)
+	cfg.ChaosLatency = time.Duration(parsePositiveIntAllowZero(k.String("CHAOS_LATENCY_MS"), 0)) * time.Millisecond
+	cfg.ChaosTargets = splitAndTrim(strings.ToLower(k.String("CHAOS_TARGETS")))
+	if cfg.ChaosEnabled && cfg.AppEnv == "production" {
+		return nil, errors.New("CHAOS_ENABLED cannot be set when APP_ENV=production")
+	}
+	if cfg.ChaosFailureRate > 1 || cfg.ChaosLatencyRate > 1 {
+		return nil, errors.New("CHAOS_FAILURE_RATE and CHAOS_LATENCY_RATE must be between 0 and 1")
+	}
+	cfg.CatalogBadgePriceDropDays = parsePositiveIntAllowZero(k.String("CATALOG_BADGE_PRICE_DROP_DAYS"), 0)
+	cfg.CatalogBadgePriceDropMinPercent = parsePositiveIntAllowZero(k.String("CATALOG_BADGE_PRICE_DROP_MIN_PERCENT"), 5)
+	cfg.CatalogPriceTrendPoints = parsePositiveIntAllowZero(k.String("CATALOG_PRICE_TREND_POINTS"), 10)
+	cfg.AnalyticsMaxRangeDays = parsePositiveInt(k.String("ANALYTICS_MAX_RANGE_DAYS"), 366)
+	if cfg.AnalyticsMaxRangeDaysByReport, err = parseReportDays(strings.ToLower(k.String("ANALYTICS_MAX_RANGE_DAYS_BY_REPORT"))); err != nil {
+		return nil, fmt.Errorf("ANALYTICS_MAX_RANGE_DAYS_BY_REPORT: %w", err)
+	}
+	cfg.AnalyticsWeeklyAfterDays = parsePositiveInt(k.String("ANALYTICS_WEEKLY_AFTER_DAYS"), 92)
+	cfg.AnalyticsMonthlyAfterDays = parsePositiveInt(k.String("ANALYTICS_MONTHLY_AFTER_DAYS"), 366)
+	cfg.RecommendationsDefaultCount = parsePositiveInt(k.String("RECOMMENDATIONS_DEFAULT_COUNT"), 8)
+	cfg.RecommendationsMaxCount = parsePositiveInt(k.String("RECOMMENDATIONS_MAX_COUNT"), 24)
+	if cfg.RecommendationsMaxCount < cfg.RecommendationsDefaultCount {
+		cfg.RecommendationsMaxCount = cfg.RecommendationsDefaultCount
+	}
+	cfg.RedisTenantIsolation = parseBool(k.String("REDIS_TENANT_ISOLATION"))
+	cfg.CheckoutPriceToleranceBps = parsePositiveIntAllowZero(k.String("CHECKOUT_PRICE_TOLERANCE_BPS"), 0)
+	cfg.RequestCacheServices = splitAndTrim(strings.ToLower(k.String("REQUEST_CACHE_SERVICES")))
+	cfg.InboundWebhookMaxAge = parseDuration(k.String("INBOUND_WEBHOOK_MAX_AGE"), "72h")
+	cfg.InboundWebhookMaxSkew = parseDuration(k.String("INBOUND_WEBHOOK_MAX_SKEW"), "5m")
+	if cfg.InboundWebhookMaxAgeBySource, err = parseRoleDurations(strings.ToLower(k.String("INBOUND_WEBHOOK_MAX_AGE_BY_SOURCE"))); err != nil {
+		return nil, fmt.Errorf("INBOUND_WEBHOOK_MAX_AGE_BY_SOURCE: %w", err)
+	}
+	if cfg.InboundWebhookClaimTTLBySource, err = parseRoleDurations(strings.ToLower(k.String("INBOUND_WEBHOOK_CLAIM_TTL_BY_SOURCE"))); err != nil {
+		return nil, fmt.Errorf("INBOUND_WEBHOOK_CLAIM_TTL_BY_SOURCE: %w", err)
+	}
+	cfg.AccessLogFields = splitAndTrim(strings.ToLower(k.String("OBS_ACCESS_LOG_FIELDS")))
+	for _, field := range cfg.AccessLogFields {
+		if !slices.Contains(obs.DefaultAccessLogFields, field) && !slices.Contains(obs.OptionalAccessLogFields, field) {
+			return nil, fmt.Errorf("OBS_ACCESS_LOG_FIELDS: unknown field %q", field)
+		}
+	}
+	if cfg.AccessLogSampleRates, err = parseSampleRates(k.String("OBS_ACCESS_LOG_SAMPLE_RATES")); err != nil {
+		return nil, fmt.Errorf("OBS_ACCESS_LOG_SAMPLE_RATES: %w", err)
+	}
+	cfg.RedactFields = splitAndTrim(strings.ToLower(k.String("LOG_REDACT_FIELDS")))
+	cfg.RedactHeaders = splitAndTrim(k.String("LOG_REDACT_HEADERS"))
+	cfg.AccessLogSlowThreshold = time.Duration(parsePositiveIntAllowZero(k.String("OBS_ACCESS_LOG_SLOW_MS"), 1000)) * time.Millisecond
+	cfg.OutboundProxyURL = strings.TrimSpace(k.String("OUTBOUND_PROXY_URL"))
+	cfg.OutboundCAFile = strings.TrimSpace(k.String("OUTBOUND_CA_FILE"))
+	if _, err := resilience.NewTransport(cfg.OutboundTransport(false)); err != nil {
+		return nil, fmt.Errorf("outbound transport: %w", err)
+	}
+	cfg.AccountRecoveryWindow = time.Duration(parsePositiveIntAllowZero(k.String("ACCOUNT_RECOVERY_DAYS"), 30)) * 24 * time.Hour
+	cfg.AccountPurgeInterval = parseDuration(k.String("ACCOUNT_PURGE_INTERVAL"), "1h")
+	cfg.ShippingDefaultItemWeightGram = parsePositiveInt(k.String("SHIPPING_DEFAULT_ITEM_WEIGHT_GRAM"), shipping.DefaultItemWeightGram)
+	cfg.ShippingVolumetricDivisor = parsePositiveIntAllowZero(k.String("SHIPPING_VOLUMETRIC_DIVISOR"), shipping.DefaultVolumetricDivisor)
+	cfg.ShippingPreferredCouriers = splitAndTrim(strings.ToLower(k.String("SHIPPING_PREFERRED_COURIERS")))
+	if err := (shipping.CourierPolicy{Prefer: cfg.ShippingPreferredCouriers}).Validate(); err != nil {
+		return nil, fmt.Errorf("SHIPPING_PREFERRED_COURIERS: %w", err)
+	}
 	if cfg.QueueConcurrencyWebhook <= 0 {
 		cfg.QueueConcurrencyWebhook = 1
 	}
@@ -297,6 +721,12 @@ func Load() (*Config, error) {
 	if cfg.QueueConcurrencyAnalytics <= 0 {
 		cfg.QueueConcurrencyAnalytics = 1
 	}
+	if cfg.QueueAdaptiveMin <= 0 {
+		cfg.QueueAdaptiveMin = 1
+	}
+	if cfg.QueueAdaptiveLatencyTarget <= 0 {
+		cfg.QueueAdaptiveLatencyTarget = 2 * time.Second
+	}
 	if cfg.QueueMaxAttempts <= 0 {
 		cfg.QueueMaxAttempts = 8
 	}
@@ -312,6 +742,10 @@ func Load() (*Config, error) {
 	if cfg.WorkerHeartbeatInterval <= 0 {
 		cfg.WorkerHeartbeatInterval = 5 * time.Second
 	}
+	cfg.WorkerLivenessTTL = time.Duration(parsePositiveIntAllowZero(k.String("WORKER_LIVENESS_TTL_SEC"), 30)) * time.Second
+	if cfg.WorkerLivenessTTL <= cfg.WorkerHeartbeatInterval {
+		cfg.WorkerLivenessTTL = 3 * cfg.WorkerHeartbeatInterval
+	}
 	if cfg.WorkerJobSoftDeadline <= 0 {
 		cfg.WorkerJobSoftDeadline = cfg.QueueVisibilityTimeout / 2
 		if cfg.WorkerJobSoftDeadline <= 0 {
@@ -324,6 +758,21 @@ func Load() (*Config, error) {
 	if cfg.NotifyEmailTopics == nil {
 		cfg.NotifyEmailTopics = parseTopicToggles(k, "NOTIFY_EMAIL_TOPIC_", true)
 	}
+	if cfg.EmailProvider == "" {
+		cfg.EmailProvider = "nop"
+	}
+	if cfg.SMTPPort <= 0 {
+		cfg.SMTPPort = 587
+	}
+	if cfg.SMTPTLS == "" {
+		cfg.SMTPTLS = "starttls"
+	}
+	if cfg.EmailSendTimeout <= 0 {
+		cfg.EmailSendTimeout = 10 * time.Second
+	}
+	if cfg.EmailMaxAttempts <= 0 {
+		cfg.EmailMaxAttempts = 5
+	}
 
 	if cfg.ShippingOriginCode == "" {
 		cfg.ShippingOriginCode = "KOTA_KEDIRI"
@@ -367,6 +816,40 @@ func Load() (*Config, error) {
 	if cfg.XenditBaseURL == "" {
 		cfg.XenditBaseURL = "https://api.xendit.co"
 	}
+	cfg.PricingRounding, err = pricing.ParseRounding(valueOrDefault(k.String("PRICING_ROUNDING"), string(pricing.RoundFloor)))
+	if err != nil {
+		return nil, fmt.Errorf("PRICING_ROUNDING: %w", err)
+	}
+	cfg.PaymentProviders = parsePaymentProviders(k, cfg)
+	if _, ok := cfg.PaymentProviders[cfg.PaymentProvider]; !ok {
+		return nil, fmt.Errorf("PAYMENT_PROVIDER %q is not listed in PAYMENT_PROVIDERS", cfg.PaymentProvider)
+	}
+	if _, ok := cfg.PaymentProviders["fake"]; ok && cfg.AppEnv == "production" {
+		return nil, errors.New("the fake payment provider cannot be enabled when APP_ENV=production")
+	}
+	cfg.SchemaCheck, err = db.ParseSchemaCheck(strings.ToLower(strings.TrimSpace(k.String("SCHEMA_CHECK"))), cfg.AppEnv)
+	if err != nil {
+		return nil, fmt.Errorf("SCHEMA_CHECK: %w", err)
+	}
+
+	if cfg.StateBackend != "memory" {
+		cfg.StateBackend = "redis"
+	}
+	if mode, ok := maintenance.ParseMode(cfg.MaintenanceMode); ok {
+		cfg.MaintenanceMode = mode
+	} else {
+		return nil, fmt.Errorf("MAINTENANCE_MODE must be off, read_only, or offline, got %q", cfg.MaintenanceMode)
+	}
+	proxies, err := common.ParseTrustedProxies(splitAndTrim(valueOrDefault(k.String("TRUSTED_PROXIES"), "127.0.0.1,::1")))
+	if err != nil {
+		return nil, fmt.Errorf("TRUSTED_PROXIES: %w", err)
+	}
+	cfg.TrustedProxies = proxies
+	if format, ok := common.ParseListFormat(cfg.ListEnvelope); ok {
+		cfg.ListEnvelope = format
+	} else {
+		return nil, fmt.Errorf("API_LIST_ENVELOPE must be flat, jsonapi, or hal, got %q", cfg.ListEnvelope)
+	}
 
 	if cfg.CurrencyCode == "" {
 		cfg.CurrencyCode = "IDR"
@@ -396,7 +879,48 @@ func Load() (*Config, error) {
 	if cfg.AnalyticsCacheTTL <= 0 {
 		cfg.AnalyticsCacheTTL = 120 * time.Second
 	}
+	if cfg.MediaStorage != "s3" {
+		cfg.MediaStorage = "local"
+	}
+	if cfg.MediaBaseURL == "" && cfg.MediaStorage == "local" {
+		base := cfg.PublicBaseURL
+		if base == "" {
+			base = "http://localhost" + cfg.HTTPAddr()
+		}
+		cfg.MediaBaseURL = base + "/media"
+	}
+	if cfg.MediaStorage == "s3" && (cfg.S3Endpoint == "" || cfg.S3Bucket == "") {
+		return nil, errors.New("S3_ENDPOINT and S3_BUCKET are required when MEDIA_STORAGE=s3")
+	}
+	if cfg.MediaStorage == "local" && cfg.MediaPrivate && cfg.MediaSigningKey == "" {
+		return nil, errors.New("MEDIA_SIGNING_KEY is required when MEDIA_PRIVATE=true")
+	}
 
+	switch cfg.EmailProvider {
+	case "nop", "log":
+	case "http":
+		if cfg.EmailProviderURL == "" {
+			return nil, errors.New("EMAIL_PROVIDER_URL is required when NOTIFY_EMAIL_PROVIDER=http")
+		}
+	case "sendgrid":
+		if cfg.EmailProviderAPIKey == "" {
+			return nil, errors.New("EMAIL_PROVIDER_API_KEY is required when NOTIFY_EMAIL_PROVIDER=sendgrid")
+		}
+	case "smtp":
+		if cfg.SMTPHost == "" {
+			return nil, errors.New("SMTP_HOST is required when NOTIFY_EMAIL_PROVIDER=smtp")
+		}
+		if cfg.SMTPTLS != "starttls" && cfg.SMTPTLS != "tls" && cfg.SMTPTLS != "none" {
+			return nil, fmt.Errorf("SMTP_TLS must be starttls, tls, or none, got %q", cfg.SMTPTLS)
+		}
+	default:
+		return nil, fmt.Errorf("NOTIFY_EMAIL_PROVIDER must be nop, log, http, smtp, or sendgrid, got %q", cfg.EmailProvider)
+	}
+
+	// Dead letters are what operators investigate, so they outlive successes.
+	if cfg.RetentionDLQDays > 0 && (cfg.RetentionDeliveredDays == 0 || cfg.RetentionDLQDays < cfg.RetentionDeliveredDays) {
+		return nil, errors.New("RETENTION_DLQ_DAYS must not be shorter than RETENTION_DELIVERED_DAYS")
+	}
 	if cfg.DatabaseURL == "" {
 		return nil, errors.New("DATABASE_URL is required")
 	}
@@ -410,6 +934,25 @@ func Load() (*Config, error) {
 	return cfg, nil
 }
 
+// OutboundTransport returns the proxy and trust settings for webhook and
+// provider clients; insecure skips certificate checks for development.
+func (c *Config) OutboundTransport(insecure bool) resilience.TransportConfig {
+	return resilience.TransportConfig{
+		ProxyURL:           c.OutboundProxyURL,
+		CAFile:             c.OutboundCAFile,
+		InsecureSkipVerify: insecure,
+	}
+}
+
+// Chaos returns the configured fault injection, or nil when chaos testing is
+// off.
+func (c *Config) Chaos() *resilience.Chaos {
+	if !c.ChaosEnabled {
+		return nil
+	}
+	return resilience.NewChaos(c.ChaosFailureRate, c.ChaosLatencyRate, c.ChaosLatency, c.ChaosTargets)
+}
+
 // HTTPAddr returns the address the HTTP server should bind to.
 func (c *Config) HTTPAddr() string {
 	port := strings.TrimSpace(c.Port)
@@ -437,6 +980,97 @@ func splitAndTrim(value string) []string {
 	return result
 }
 
+// parseRoleDurations reads "role=duration" pairs such as "admin=5m,staff=10m".
+// Malformed entries are rejected rather than skipped since they weaken a
+// security policy silently.
+func parseRoleDurations(value string) (map[string]time.Duration, error) {
+	parts := splitAndTrim(value)
+	if len(parts) == 0 {
+		return nil, nil
+	}
+	durations := make(map[string]time.Duration, len(parts))
+	for _, part := range parts {
+		role, raw, ok := strings.Cut(part, "=")
+		role = strings.TrimSpace(role)
+		if !ok || role == "" {
+			return nil, fmt.Errorf("invalid entry %q, want role=duration", part)
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid duration for role %s: %q", role, raw)
+		}
+		durations[role] = d
+	}
+	return durations, nil
+}
+
+// parseTopicCategories reads pattern=category pairs such as
+// "order.*=transactional,shipment.delivered=shipping". Patterns must cover a
+// known topic.
+func parseTopicCategories(value string) (map[string]string, error) {
+	parts := splitAndTrim(value)
+	if len(parts) == 0 {
+		return nil, nil
+	}
+	categories := make(map[string]string, len(parts))
+	for _, part := range parts {
+		pattern, category, ok := strings.Cut(part, "=")
+		pattern, category = strings.TrimSpace(pattern), strings.TrimSpace(category)
+		if !ok || pattern == "" || category == "" {
+			return nil, fmt.Errorf("invalid entry %q, want topic=category", part)
+		}
+		if !slices.ContainsFunc(events.DefaultTopics(), func(t string) bool { return events.MatchTopic(pattern, t) }) {
+			return nil, fmt.Errorf("unknown topic %q", pattern)
+		}
+		categories[pattern] = category
+	}
+	return categories, nil
+}
+
+// parseReportDays reads report=days pairs, e.g. "sales=1095,vouchers=90".
+func parseReportDays(value string) (map[string]int, error) {
+	parts := splitAndTrim(value)
+	if len(parts) == 0 {
+		return nil, nil
+	}
+	days := make(map[string]int, len(parts))
+	for _, part := range parts {
+		report, raw, ok := strings.Cut(part, "=")
+		report = strings.TrimSpace(report)
+		if !ok || report == "" {
+			return nil, fmt.Errorf("invalid entry %q, want report=days", part)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid days for %s: %q", report, raw)
+		}
+		days[report] = n
+	}
+	return days, nil
+}
+
+// parseSampleRates reads prefix=rate pairs, e.g. "/health=0.01,/metrics=0".
+func parseSampleRates(value string) (map[string]float64, error) {
+	parts := splitAndTrim(value)
+	if len(parts) == 0 {
+		return nil, nil
+	}
+	rates := make(map[string]float64, len(parts))
+	for _, part := range parts {
+		prefix, raw, ok := strings.Cut(part, "=")
+		prefix = strings.TrimSpace(prefix)
+		if !ok || !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("invalid entry %q, want /path=rate", part)
+		}
+		rate, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+		if err != nil || rate < 0 || rate > 1 {
+			return nil, fmt.Errorf("invalid rate for %s: %q, want 0..1", prefix, raw)
+		}
+		rates[prefix] = rate
+	}
+	return rates, nil
+}
+
 func parseTopicToggles(k *koanf.Koanf, prefix string, fallback bool) map[string]bool {
 	topics := events.DefaultTopics()
 	toggles := make(map[string]bool, len(topics))
@@ -447,6 +1081,34 @@ func parseTopicToggles(k *koanf.Koanf, prefix string, fallback bool) map[string]
 	return toggles
 }
 
+// parsePaymentProviders reads the providers named in PAYMENT_PROVIDERS.
+// Midtrans and Xendit keep their own variables; any other provider reads
+// PAYMENT_<NAME>_SECRET_KEY and PAYMENT_<NAME>_BASE_URL.
+func parsePaymentProviders(k *koanf.Koanf, cfg *Config) map[string]PaymentProviderConfig {
+	names := splitAndTrim(valueOrDefault(k.String("PAYMENT_PROVIDERS"), "midtrans,xendit"))
+	// PAYMENT_PROVIDER=fake is enough to switch a QA or demo stack over.
+	if cfg.PaymentProvider == "fake" && !slices.ContainsFunc(names, func(n string) bool { return strings.EqualFold(n, "fake") }) {
+		names = append(names, "fake")
+	}
+	providers := make(map[string]PaymentProviderConfig, len(names))
+	for _, name := range names {
+		name = strings.ToLower(name)
+		switch name {
+		case "midtrans":
+			providers[name] = PaymentProviderConfig{SecretKey: cfg.MidtransServerKey, BaseURL: cfg.MidtransBaseURL}
+		case "xendit":
+			providers[name] = PaymentProviderConfig{SecretKey: cfg.XenditSecretKey, BaseURL: cfg.XenditBaseURL}
+		default:
+			prefix := "PAYMENT_" + strings.ToUpper(name) + "_"
+			providers[name] = PaymentProviderConfig{
+				SecretKey: k.String(prefix + "SECRET_KEY"),
+				BaseURL:   strings.TrimSpace(k.String(prefix + "BASE_URL")),
+			}
+		}
+	}
+	return providers
+}
+
 func valueOrDefault(value, fallback string) string {
 	if strings.TrimSpace(value) != "" {
 		return value
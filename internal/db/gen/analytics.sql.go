@@ -11,6 +11,19 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const getAnalyticsViewRefreshedAt = `-- name: GetAnalyticsViewRefreshedAt :one
+SELECT refreshed_at
+FROM analytics_view_refreshes
+WHERE view_name = $1
+`
+
+func (q *Queries) GetAnalyticsViewRefreshedAt(ctx context.Context, viewName string) (pgtype.Timestamptz, error) {
+	row := q.db.QueryRow(ctx, getAnalyticsViewRefreshedAt, viewName)
+	var refreshed_at pgtype.Timestamptz
+	err := row.Scan(&refreshed_at)
+	return refreshed_at, err
+}
+
 const getSalesDailyRange = `-- name: GetSalesDailyRange :many
 SELECT day::timestamptz AS day,
        paid_orders,
@@ -91,6 +104,99 @@ func (q *Queries) GetTopProducts(ctx context.Context, arg GetTopProductsParams)
 	return items, nil
 }
 
+const getVoucherPerformance = `-- name: GetVoucherPerformance :many
+SELECT v.code,
+       COUNT(u.id)::bigint AS redemptions,
+       COALESCE(SUM(u.amount), 0)::bigint AS discount,
+       COALESCE(SUM(o.pricing_total) FILTER (WHERE o.status <> 'CANCELED'), 0)::bigint AS revenue,
+       COUNT(DISTINCT u.user_id)::bigint AS unique_users
+FROM voucher_usages u
+JOIN vouchers v ON v.id = u.voucher_id
+JOIN orders o ON o.id = u.order_id
+WHERE u.used_at >= $1::timestamptz
+  AND u.used_at < $2::timestamptz
+GROUP BY v.code
+ORDER BY CASE WHEN $3::text = 'discount' THEN COALESCE(SUM(u.amount), 0) ELSE COUNT(u.id) END DESC,
+         v.code ASC
+LIMIT $5 OFFSET $4
+`
+
+type GetVoucherPerformanceParams struct {
+	StartDate  pgtype.Timestamptz `json:"start_date"`
+	EndDate    pgtype.Timestamptz `json:"end_date"`
+	SortBy     string             `json:"sort_by"`
+	OffsetRows int32              `json:"offset_rows"`
+	LimitCount int32              `json:"limit_count"`
+}
+
+type GetVoucherPerformanceRow struct {
+	Code        string `json:"code"`
+	Redemptions int64  `json:"redemptions"`
+	Discount    int64  `json:"discount"`
+	Revenue     int64  `json:"revenue"`
+	UniqueUsers int64  `json:"unique_users"`
+}
+
+func (q *Queries) GetVoucherPerformance(ctx context.Context, arg GetVoucherPerformanceParams) ([]GetVoucherPerformanceRow, error) {
+	rows, err := q.db.Query(ctx, getVoucherPerformance,
+		arg.StartDate,
+		arg.EndDate,
+		arg.SortBy,
+		arg.OffsetRows,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetVoucherPerformanceRow
+	for rows.Next() {
+		var i GetVoucherPerformanceRow
+		if err := rows.Scan(
+			&i.Code,
+			&i.Redemptions,
+			&i.Discount,
+			&i.Revenue,
+			&i.UniqueUsers,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markAnalyticsViewRefreshed = `-- name: MarkAnalyticsViewRefreshed :exec
+INSERT INTO analytics_view_refreshes (view_name, refreshed_at, duration_ms)
+VALUES ($1, $2, $3)
+ON CONFLICT (view_name) DO UPDATE
+SET refreshed_at = EXCLUDED.refreshed_at,
+    duration_ms = EXCLUDED.duration_ms
+`
+
+type MarkAnalyticsViewRefreshedParams struct {
+	ViewName    string             `json:"view_name"`
+	RefreshedAt pgtype.Timestamptz `json:"refreshed_at"`
+	DurationMs  int64              `json:"duration_ms"`
+}
+
+func (q *Queries) MarkAnalyticsViewRefreshed(ctx context.Context, arg MarkAnalyticsViewRefreshedParams) error {
+	_, err := q.db.Exec(ctx, markAnalyticsViewRefreshed, arg.ViewName, arg.RefreshedAt, arg.DurationMs)
+	return err
+}
+
+const refreshProductCopurchase = `-- name: RefreshProductCopurchase :exec
+REFRESH MATERIALIZED VIEW CONCURRENTLY mv_product_copurchase
+`
+
+func (q *Queries) RefreshProductCopurchase(ctx context.Context) error {
+	_, err := q.db.Exec(ctx, refreshProductCopurchase)
+	return err
+}
+
 const refreshSalesDaily = `-- name: RefreshSalesDaily :exec
 REFRESH MATERIALIZED VIEW CONCURRENTLY mv_sales_daily
 `
@@ -11,6 +11,18 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countAuditLogs = `-- name: CountAuditLogs :one
+SELECT COUNT(*)
+FROM audit_logs
+`
+
+func (q *Queries) CountAuditLogs(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countAuditLogs)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const insertAuditLog = `-- name: InsertAuditLog :one
 INSERT INTO audit_logs (
     actor_kind,
@@ -135,3 +147,72 @@ func (q *Queries) ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([
 	}
 	return items, nil
 }
+
+const listAuditLogsByActorAfter = `-- name: ListAuditLogsByActorAfter :many
+SELECT id, action, resource_type, resource_id, method, path, status, ip, user_agent, created_at
+FROM audit_logs
+WHERE actor_user_id = $1
+  AND (
+    $2::timestamptz IS NULL
+    OR (created_at, id) > ($2::timestamptz, $3::uuid)
+  )
+ORDER BY created_at, id
+LIMIT $4
+`
+
+type ListAuditLogsByActorAfterParams struct {
+	ActorUserID    pgtype.UUID        `json:"actor_user_id"`
+	AfterCreatedAt pgtype.Timestamptz `json:"after_created_at"`
+	AfterID        pgtype.UUID        `json:"after_id"`
+	PageLimit      int32              `json:"page_limit"`
+}
+
+type ListAuditLogsByActorAfterRow struct {
+	ID           pgtype.UUID        `json:"id"`
+	Action       string             `json:"action"`
+	ResourceType string             `json:"resource_type"`
+	ResourceID   pgtype.Text        `json:"resource_id"`
+	Method       string             `json:"method"`
+	Path         string             `json:"path"`
+	Status       int32              `json:"status"`
+	Ip           pgtype.Text        `json:"ip"`
+	UserAgent    pgtype.Text        `json:"user_agent"`
+	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+}
+
+// Pages through the requests a user made that were audited, oldest first.
+func (q *Queries) ListAuditLogsByActorAfter(ctx context.Context, arg ListAuditLogsByActorAfterParams) ([]ListAuditLogsByActorAfterRow, error) {
+	rows, err := q.db.Query(ctx, listAuditLogsByActorAfter,
+		arg.ActorUserID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListAuditLogsByActorAfterRow
+	for rows.Next() {
+		var i ListAuditLogsByActorAfterRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Action,
+			&i.ResourceType,
+			&i.ResourceID,
+			&i.Method,
+			&i.Path,
+			&i.Status,
+			&i.Ip,
+			&i.UserAgent,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
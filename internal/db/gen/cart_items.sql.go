@@ -14,7 +14,7 @@ import (
 const createCartItem = `-- name: CreateCartItem :one
 INSERT INTO cart_items (cart_id, product_id, variant_id, title, slug, qty, unit_price, subtotal)
 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, cart_id, product_id, variant_id, title, slug, qty, unit_price, subtotal
+RETURNING id, cart_id, product_id, variant_id, title, slug, qty, unit_price, subtotal, version
 `
 
 type CreateCartItemParams struct {
@@ -50,6 +50,7 @@ func (q *Queries) CreateCartItem(ctx context.Context, arg CreateCartItemParams)
 		&i.Qty,
 		&i.UnitPrice,
 		&i.Subtotal,
+		&i.Version,
 	)
 	return i, err
 }
@@ -71,7 +72,7 @@ func (q *Queries) DeleteCartItem(ctx context.Context, arg DeleteCartItemParams)
 }
 
 const findCartItemByProductVariant = `-- name: FindCartItemByProductVariant :one
-SELECT id, cart_id, product_id, variant_id, title, slug, qty, unit_price, subtotal
+SELECT id, cart_id, product_id, variant_id, title, slug, qty, unit_price, subtotal, version
 FROM cart_items
 WHERE cart_id = $1
   AND product_id = $2
@@ -98,12 +99,13 @@ func (q *Queries) FindCartItemByProductVariant(ctx context.Context, arg FindCart
 		&i.Qty,
 		&i.UnitPrice,
 		&i.Subtotal,
+		&i.Version,
 	)
 	return i, err
 }
 
 const getCartItemByID = `-- name: GetCartItemByID :one
-SELECT id, cart_id, product_id, variant_id, title, slug, qty, unit_price, subtotal
+SELECT id, cart_id, product_id, variant_id, title, slug, qty, unit_price, subtotal, version
 FROM cart_items
 WHERE id = $1
 LIMIT 1
@@ -122,12 +124,187 @@ func (q *Queries) GetCartItemByID(ctx context.Context, id pgtype.UUID) (CartItem
 		&i.Qty,
 		&i.UnitPrice,
 		&i.Subtotal,
+		&i.Version,
 	)
 	return i, err
 }
 
+const getCartItemTotals = `-- name: GetCartItemTotals :one
+SELECT COUNT(*)::int AS items,
+       COALESCE(SUM(qty), 0)::int AS qty
+FROM cart_items
+WHERE cart_id = $1
+`
+
+type GetCartItemTotalsRow struct {
+	Items int32 `json:"items"`
+	Qty   int32 `json:"qty"`
+}
+
+func (q *Queries) GetCartItemTotals(ctx context.Context, cartID pgtype.UUID) (GetCartItemTotalsRow, error) {
+	row := q.db.QueryRow(ctx, getCartItemTotals, cartID)
+	var i GetCartItemTotalsRow
+	err := row.Scan(&i.Items, &i.Qty)
+	return i, err
+}
+
+const listCartItemAvailability = `-- name: ListCartItemAvailability :many
+SELECT ci.id,
+       (p.id IS NOT NULL AND p.in_stock)::boolean AS product_available,
+       v.stock AS variant_stock,
+       (b.stock IS NOT NULL)::boolean AS is_bundle,
+       COALESCE(b.stock, 0)::int AS bundle_stock,
+       p.available_from,
+       p.available_to,
+       COALESCE(p.preorder, false)::boolean AS preorder
+FROM cart_items ci
+LEFT JOIN products p ON p.id = ci.product_id
+LEFT JOIN product_variants v ON v.id = ci.variant_id
+LEFT JOIN LATERAL (
+    SELECT MIN(cv.stock / bc.qty)::int AS stock
+    FROM variant_bundle_components bc
+    JOIN product_variants cv ON cv.id = bc.component_variant_id
+    WHERE bc.bundle_variant_id = ci.variant_id
+) b ON true
+WHERE ci.cart_id = $1
+`
+
+type ListCartItemAvailabilityRow struct {
+	ID               pgtype.UUID        `json:"id"`
+	ProductAvailable bool               `json:"product_available"`
+	VariantStock     pgtype.Int4        `json:"variant_stock"`
+	IsBundle         bool               `json:"is_bundle"`
+	BundleStock      int32              `json:"bundle_stock"`
+	AvailableFrom    pgtype.Timestamptz `json:"available_from"`
+	AvailableTo      pgtype.Timestamptz `json:"available_to"`
+	Preorder         bool               `json:"preorder"`
+}
+
+func (q *Queries) ListCartItemAvailability(ctx context.Context, cartID pgtype.UUID) ([]ListCartItemAvailabilityRow, error) {
+	rows, err := q.db.Query(ctx, listCartItemAvailability, cartID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCartItemAvailabilityRow
+	for rows.Next() {
+		var i ListCartItemAvailabilityRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductAvailable,
+			&i.VariantStock,
+			&i.IsBundle,
+			&i.BundleStock,
+			&i.AvailableFrom,
+			&i.AvailableTo,
+			&i.Preorder,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCartItemCatalogPrices = `-- name: ListCartItemCatalogPrices :many
+SELECT ci.id,
+       ci.variant_id,
+       p.price AS product_price,
+       v.price AS variant_price
+FROM cart_items ci
+LEFT JOIN products p ON p.id = ci.product_id
+LEFT JOIN product_variants v ON v.id = ci.variant_id
+WHERE ci.cart_id = $1
+`
+
+type ListCartItemCatalogPricesRow struct {
+	ID           pgtype.UUID `json:"id"`
+	VariantID    pgtype.UUID `json:"variant_id"`
+	ProductPrice pgtype.Int8 `json:"product_price"`
+	VariantPrice pgtype.Int8 `json:"variant_price"`
+}
+
+func (q *Queries) ListCartItemCatalogPrices(ctx context.Context, cartID pgtype.UUID) ([]ListCartItemCatalogPricesRow, error) {
+	rows, err := q.db.Query(ctx, listCartItemCatalogPrices, cartID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCartItemCatalogPricesRow
+	for rows.Next() {
+		var i ListCartItemCatalogPricesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.VariantID,
+			&i.ProductPrice,
+			&i.VariantPrice,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listCartItemParcels = `-- name: ListCartItemParcels :many
+SELECT ci.id,
+       ci.qty,
+       v.weight_gram,
+       v.length_cm,
+       v.width_cm,
+       v.height_cm
+FROM cart_items ci
+LEFT JOIN product_variants v ON v.id = ci.variant_id
+WHERE ci.cart_id = $1
+ORDER BY ci.id
+`
+
+type ListCartItemParcelsRow struct {
+	ID         pgtype.UUID `json:"id"`
+	Qty        int32       `json:"qty"`
+	WeightGram pgtype.Int4 `json:"weight_gram"`
+	LengthCm   pgtype.Int4 `json:"length_cm"`
+	WidthCm    pgtype.Int4 `json:"width_cm"`
+	HeightCm   pgtype.Int4 `json:"height_cm"`
+}
+
+// Weight and dimensions of each cart line's variant for shipping quotes;
+// lines without a variant or measurements come back NULL.
+func (q *Queries) ListCartItemParcels(ctx context.Context, cartID pgtype.UUID) ([]ListCartItemParcelsRow, error) {
+	rows, err := q.db.Query(ctx, listCartItemParcels, cartID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListCartItemParcelsRow
+	for rows.Next() {
+		var i ListCartItemParcelsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Qty,
+			&i.WeightGram,
+			&i.LengthCm,
+			&i.WidthCm,
+			&i.HeightCm,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listCartItems = `-- name: ListCartItems :many
-SELECT id, cart_id, product_id, variant_id, title, slug, qty, unit_price, subtotal
+SELECT id, cart_id, product_id, variant_id, title, slug, qty, unit_price, subtotal, version
 FROM cart_items
 WHERE cart_id = $1
 ORDER BY title ASC, id
@@ -152,6 +329,7 @@ func (q *Queries) ListCartItems(ctx context.Context, cartID pgtype.UUID) ([]Cart
 			&i.Qty,
 			&i.UnitPrice,
 			&i.Subtotal,
+			&i.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -163,22 +341,48 @@ func (q *Queries) ListCartItems(ctx context.Context, cartID pgtype.UUID) ([]Cart
 	return items, nil
 }
 
+const repriceCartItem = `-- name: RepriceCartItem :exec
+UPDATE cart_items
+SET unit_price = $2,
+    subtotal = qty * $2,
+    version = version + 1
+WHERE id = $1
+`
+
+type RepriceCartItemParams struct {
+	ID        pgtype.UUID `json:"id"`
+	UnitPrice int64       `json:"unit_price"`
+}
+
+func (q *Queries) RepriceCartItem(ctx context.Context, arg RepriceCartItemParams) error {
+	_, err := q.db.Exec(ctx, repriceCartItem, arg.ID, arg.UnitPrice)
+	return err
+}
+
 const updateCartItemQty = `-- name: UpdateCartItemQty :one
 UPDATE cart_items
 SET qty = $2,
-    subtotal = $3
+    subtotal = $3,
+    version = version + 1
 WHERE id = $1
-RETURNING id, cart_id, product_id, variant_id, title, slug, qty, unit_price, subtotal
+  AND version = $4
+RETURNING id, cart_id, product_id, variant_id, title, slug, qty, unit_price, subtotal, version
 `
 
 type UpdateCartItemQtyParams struct {
 	ID       pgtype.UUID `json:"id"`
 	Qty      int32       `json:"qty"`
 	Subtotal int64       `json:"subtotal"`
+	Version  int32       `json:"version"`
 }
 
 func (q *Queries) UpdateCartItemQty(ctx context.Context, arg UpdateCartItemQtyParams) (CartItem, error) {
-	row := q.db.QueryRow(ctx, updateCartItemQty, arg.ID, arg.Qty, arg.Subtotal)
+	row := q.db.QueryRow(ctx, updateCartItemQty,
+		arg.ID,
+		arg.Qty,
+		arg.Subtotal,
+		arg.Version,
+	)
 	var i CartItem
 	err := row.Scan(
 		&i.ID,
@@ -190,6 +394,7 @@ func (q *Queries) UpdateCartItemQty(ctx context.Context, arg UpdateCartItemQtyPa
 		&i.Qty,
 		&i.UnitPrice,
 		&i.Subtotal,
+		&i.Version,
 	)
 	return i, err
 }
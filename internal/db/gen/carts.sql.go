@@ -14,7 +14,7 @@ import (
 const createCart = `-- name: CreateCart :one
 INSERT INTO carts (user_id, anon_id, expires_at, tenant_id)
 VALUES ($1, $2, $3, $4)
-RETURNING id, user_id, anon_id, applied_voucher_code, created_at, updated_at, expires_at, tenant_id
+RETURNING id, user_id, anon_id, applied_voucher_code, created_at, updated_at, expires_at, tenant_id, version
 `
 
 type CreateCartParams struct {
@@ -41,12 +41,23 @@ func (q *Queries) CreateCart(ctx context.Context, arg CreateCartParams) (Cart, e
 		&i.UpdatedAt,
 		&i.ExpiresAt,
 		&i.TenantID,
+		&i.Version,
 	)
 	return i, err
 }
 
+const deleteCartsByUser = `-- name: DeleteCartsByUser :exec
+DELETE FROM carts
+WHERE user_id = $1
+`
+
+func (q *Queries) DeleteCartsByUser(ctx context.Context, userID pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteCartsByUser, userID)
+	return err
+}
+
 const getActiveCartByAnon = `-- name: GetActiveCartByAnon :one
-SELECT id, user_id, anon_id, applied_voucher_code, created_at, updated_at, expires_at, tenant_id
+SELECT id, user_id, anon_id, applied_voucher_code, created_at, updated_at, expires_at, tenant_id, version
 FROM carts
 WHERE anon_id = $1 AND (expires_at IS NULL OR expires_at > now())
 ORDER BY updated_at DESC
@@ -65,12 +76,13 @@ func (q *Queries) GetActiveCartByAnon(ctx context.Context, anonID pgtype.Text) (
 		&i.UpdatedAt,
 		&i.ExpiresAt,
 		&i.TenantID,
+		&i.Version,
 	)
 	return i, err
 }
 
 const getActiveCartByUser = `-- name: GetActiveCartByUser :one
-SELECT id, user_id, anon_id, applied_voucher_code, created_at, updated_at, expires_at, tenant_id
+SELECT id, user_id, anon_id, applied_voucher_code, created_at, updated_at, expires_at, tenant_id, version
 FROM carts
 WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > now())
 ORDER BY updated_at DESC
@@ -89,12 +101,13 @@ func (q *Queries) GetActiveCartByUser(ctx context.Context, userID pgtype.UUID) (
 		&i.UpdatedAt,
 		&i.ExpiresAt,
 		&i.TenantID,
+		&i.Version,
 	)
 	return i, err
 }
 
 const getCartByID = `-- name: GetCartByID :one
-SELECT id, user_id, anon_id, applied_voucher_code, created_at, updated_at, expires_at, tenant_id
+SELECT id, user_id, anon_id, applied_voucher_code, created_at, updated_at, expires_at, tenant_id, version
 FROM carts
 WHERE id = $1
 LIMIT 1
@@ -112,10 +125,29 @@ func (q *Queries) GetCartByID(ctx context.Context, id pgtype.UUID) (Cart, error)
 		&i.UpdatedAt,
 		&i.ExpiresAt,
 		&i.TenantID,
+		&i.Version,
 	)
 	return i, err
 }
 
+const markCartChanged = `-- name: MarkCartChanged :exec
+UPDATE carts
+SET version = version + 1,
+    updated_at = now(),
+    expires_at = $2
+WHERE id = $1
+`
+
+type MarkCartChangedParams struct {
+	ID        pgtype.UUID        `json:"id"`
+	ExpiresAt pgtype.Timestamptz `json:"expires_at"`
+}
+
+func (q *Queries) MarkCartChanged(ctx context.Context, arg MarkCartChangedParams) error {
+	_, err := q.db.Exec(ctx, markCartChanged, arg.ID, arg.ExpiresAt)
+	return err
+}
+
 const touchCart = `-- name: TouchCart :exec
 UPDATE carts
 SET updated_at = now(),
@@ -151,19 +183,25 @@ func (q *Queries) TransferCartToUser(ctx context.Context, arg TransferCartToUser
 	return err
 }
 
-const updateCartVoucher = `-- name: UpdateCartVoucher :exec
+const updateCartVoucher = `-- name: UpdateCartVoucher :execrows
 UPDATE carts
 SET applied_voucher_code = $2,
+    version = version + 1,
     updated_at = now()
 WHERE id = $1
+  AND version = $3
 `
 
 type UpdateCartVoucherParams struct {
 	ID                 pgtype.UUID `json:"id"`
 	AppliedVoucherCode pgtype.Text `json:"applied_voucher_code"`
+	Version            int32       `json:"version"`
 }
 
-func (q *Queries) UpdateCartVoucher(ctx context.Context, arg UpdateCartVoucherParams) error {
-	_, err := q.db.Exec(ctx, updateCartVoucher, arg.ID, arg.AppliedVoucherCode)
-	return err
+func (q *Queries) UpdateCartVoucher(ctx context.Context, arg UpdateCartVoucherParams) (int64, error) {
+	result, err := q.db.Exec(ctx, updateCartVoucher, arg.ID, arg.AppliedVoucherCode, arg.Version)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
 }
@@ -63,6 +63,20 @@ func (q *Queries) GetCategoryBySlug(ctx context.Context, slug string) (GetCatego
 	return i, err
 }
 
+const getCategoryDefaultSort = `-- name: GetCategoryDefaultSort :one
+SELECT COALESCE(default_sort, '')::text AS default_sort
+FROM categories
+WHERE slug = $1
+LIMIT 1
+`
+
+func (q *Queries) GetCategoryDefaultSort(ctx context.Context, slug string) (string, error) {
+	row := q.db.QueryRow(ctx, getCategoryDefaultSort, slug)
+	var default_sort string
+	err := row.Scan(&default_sort)
+	return default_sort, err
+}
+
 const listCategories = `-- name: ListCategories :many
 SELECT id, name, slug, parent_id
 FROM categories
@@ -11,6 +11,19 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countDomainEventsByTopic = `-- name: CountDomainEventsByTopic :one
+SELECT COUNT(*)
+FROM domain_events
+WHERE topic = $1
+`
+
+func (q *Queries) CountDomainEventsByTopic(ctx context.Context, topic string) (int64, error) {
+	row := q.db.QueryRow(ctx, countDomainEventsByTopic, topic)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const insertDomainEvent = `-- name: InsertDomainEvent :one
 INSERT INTO domain_events (topic, aggregate_id, payload)
 VALUES ($1, $2, $3)
@@ -91,3 +104,34 @@ func (q *Queries) ListDomainEventsByTopic(ctx context.Context, arg ListDomainEve
 	}
 	return items, nil
 }
+
+const purgeDomainEvents = `-- name: PurgeDomainEvents :execrows
+DELETE FROM domain_events
+WHERE id IN (
+  SELECT e.id
+  FROM domain_events e
+  WHERE e.occurred_at < $1::timestamptz
+    AND NOT EXISTS (
+      SELECT 1 FROM webhook_deliveries d WHERE d.event_id = e.id
+    )
+  ORDER BY e.occurred_at
+  LIMIT $2::int
+  FOR UPDATE SKIP LOCKED
+)
+`
+
+type PurgeDomainEventsParams struct {
+	Before    pgtype.Timestamptz `json:"before"`
+	BatchSize int32              `json:"batch_size"`
+}
+
+// Deletes up to batch_size events older than the cutoff. Deleting an event
+// cascades to its deliveries, so events any delivery still references are
+// kept; they become eligible once their deliveries have been purged.
+func (q *Queries) PurgeDomainEvents(ctx context.Context, arg PurgeDomainEventsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeDomainEvents, arg.Before, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
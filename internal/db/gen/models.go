@@ -258,6 +258,12 @@ type AnalyticsMaterialized struct {
 	TenantID pgtype.UUID `json:"tenant_id"`
 }
 
+type AnalyticsViewRefresh struct {
+	ViewName    string             `json:"view_name"`
+	RefreshedAt pgtype.Timestamptz `json:"refreshed_at"`
+	DurationMs  int64              `json:"duration_ms"`
+}
+
 type AuditLog struct {
 	ID           pgtype.UUID        `json:"id"`
 	ActorKind    interface{}        `json:"actor_kind"`
@@ -294,6 +300,7 @@ type Cart struct {
 	UpdatedAt          pgtype.Timestamptz `json:"updated_at"`
 	ExpiresAt          pgtype.Timestamptz `json:"expires_at"`
 	TenantID           pgtype.UUID        `json:"tenant_id"`
+	Version            int32              `json:"version"`
 }
 
 type CartItem struct {
@@ -306,16 +313,18 @@ type CartItem struct {
 	Qty       int32       `json:"qty"`
 	UnitPrice int64       `json:"unit_price"`
 	Subtotal  int64       `json:"subtotal"`
+	Version   int32       `json:"version"`
 }
 
 type Category struct {
-	ID        pgtype.UUID        `json:"id"`
-	Name      string             `json:"name"`
-	Slug      string             `json:"slug"`
-	ParentID  pgtype.UUID        `json:"parent_id"`
-	CreatedAt pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
-	TenantID  pgtype.UUID        `json:"tenant_id"`
+	ID          pgtype.UUID        `json:"id"`
+	Name        string             `json:"name"`
+	Slug        string             `json:"slug"`
+	ParentID    pgtype.UUID        `json:"parent_id"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+	TenantID    pgtype.UUID        `json:"tenant_id"`
+	DefaultSort pgtype.Text        `json:"default_sort"`
 }
 
 type DomainEvent struct {
@@ -334,6 +343,19 @@ type Favorite struct {
 	TenantID  pgtype.UUID        `json:"tenant_id"`
 }
 
+type InboundWebhookEvent struct {
+	Source      string             `json:"source"`
+	DedupKey    string             `json:"dedup_key"`
+	ClaimedAt   pgtype.Timestamptz `json:"claimed_at"`
+	ProcessedAt pgtype.Timestamptz `json:"processed_at"`
+}
+
+type MvProductCopurchase struct {
+	ProductID      pgtype.UUID `json:"product_id"`
+	OtherProductID pgtype.UUID `json:"other_product_id"`
+	Orders         int64       `json:"orders"`
+}
+
 type MvSalesDaily struct {
 	Day        pgtype.Interval `json:"day"`
 	PaidOrders int64           `json:"paid_orders"`
@@ -368,15 +390,18 @@ type Order struct {
 }
 
 type OrderItem struct {
-	ID        pgtype.UUID `json:"id"`
-	OrderID   pgtype.UUID `json:"order_id"`
-	ProductID pgtype.UUID `json:"product_id"`
-	VariantID pgtype.UUID `json:"variant_id"`
-	Title     string      `json:"title"`
-	Slug      string      `json:"slug"`
-	Qty       int32       `json:"qty"`
-	UnitPrice int64       `json:"unit_price"`
-	Subtotal  int64       `json:"subtotal"`
+	ID                pgtype.UUID `json:"id"`
+	OrderID           pgtype.UUID `json:"order_id"`
+	ProductID         pgtype.UUID `json:"product_id"`
+	VariantID         pgtype.UUID `json:"variant_id"`
+	Title             string      `json:"title"`
+	Slug              string      `json:"slug"`
+	Qty               int32       `json:"qty"`
+	UnitPrice         int64       `json:"unit_price"`
+	Subtotal          int64       `json:"subtotal"`
+	Preorder          bool        `json:"preorder"`
+	DiscountAllocated int64       `json:"discount_allocated"`
+	CatalogUnitPrice  pgtype.Int8 `json:"catalog_unit_price"`
 }
 
 type PasswordReset struct {
@@ -427,20 +452,36 @@ type Plan struct {
 	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
 }
 
+type PriceHistory struct {
+	ID        int64              `json:"id"`
+	TenantID  pgtype.UUID        `json:"tenant_id"`
+	ProductID pgtype.UUID        `json:"product_id"`
+	VariantID pgtype.UUID        `json:"variant_id"`
+	OldPrice  int64              `json:"old_price"`
+	NewPrice  int64              `json:"new_price"`
+	ChangedAt pgtype.Timestamptz `json:"changed_at"`
+}
+
 type Product struct {
-	ID         pgtype.UUID        `json:"id"`
-	Title      string             `json:"title"`
-	Slug       string             `json:"slug"`
-	BrandID    pgtype.UUID        `json:"brand_id"`
-	CategoryID pgtype.UUID        `json:"category_id"`
-	Price      int64              `json:"price"`
-	CompareAt  pgtype.Int8        `json:"compare_at"`
-	InStock    bool               `json:"in_stock"`
-	Thumbnail  pgtype.Text        `json:"thumbnail"`
-	Badges     []string           `json:"badges"`
-	CreatedAt  pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt  pgtype.Timestamptz `json:"updated_at"`
-	TenantID   pgtype.UUID        `json:"tenant_id"`
+	ID               pgtype.UUID        `json:"id"`
+	Title            string             `json:"title"`
+	Slug             string             `json:"slug"`
+	BrandID          pgtype.UUID        `json:"brand_id"`
+	CategoryID       pgtype.UUID        `json:"category_id"`
+	Price            int64              `json:"price"`
+	CompareAt        pgtype.Int8        `json:"compare_at"`
+	InStock          bool               `json:"in_stock"`
+	Thumbnail        pgtype.Text        `json:"thumbnail"`
+	Badges           []string           `json:"badges"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt        pgtype.Timestamptz `json:"updated_at"`
+	TenantID         pgtype.UUID        `json:"tenant_id"`
+	OptionSchema     []byte             `json:"option_schema"`
+	DefaultVariantID pgtype.UUID        `json:"default_variant_id"`
+	AvailableFrom    pgtype.Timestamptz `json:"available_from"`
+	AvailableTo      pgtype.Timestamptz `json:"available_to"`
+	Preorder         bool               `json:"preorder"`
+	PreorderShipsAt  pgtype.Timestamptz `json:"preorder_ships_at"`
 }
 
 type ProductImage struct {
@@ -457,6 +498,17 @@ type ProductSpec struct {
 	Value     string      `json:"value"`
 }
 
+type ProductTranslation struct {
+	ProductID   pgtype.UUID        `json:"product_id"`
+	Locale      string             `json:"locale"`
+	Title       string             `json:"title"`
+	Description pgtype.Text        `json:"description"`
+	Badges      []string           `json:"badges"`
+	Specs       []byte             `json:"specs"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
 type ProductVariant struct {
 	ID         pgtype.UUID `json:"id"`
 	ProductID  pgtype.UUID `json:"product_id"`
@@ -464,6 +516,10 @@ type ProductVariant struct {
 	Price      int64       `json:"price"`
 	Stock      int32       `json:"stock"`
 	Attributes []byte      `json:"attributes"`
+	WeightGram pgtype.Int4 `json:"weight_gram"`
+	LengthCm   pgtype.Int4 `json:"length_cm"`
+	WidthCm    pgtype.Int4 `json:"width_cm"`
+	HeightCm   pgtype.Int4 `json:"height_cm"`
 }
 
 type QueueDlq struct {
@@ -506,6 +562,7 @@ type Session struct {
 	Ip           pgtype.Text        `json:"ip"`
 	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
 	CreatedAt    pgtype.Timestamptz `json:"created_at"`
+	Audience     pgtype.Text        `json:"audience"`
 }
 
 type Shipment struct {
@@ -567,13 +624,50 @@ type TenantSetting struct {
 }
 
 type User struct {
-	ID           pgtype.UUID        `json:"id"`
-	Name         string             `json:"name"`
-	Email        string             `json:"email"`
-	PasswordHash string             `json:"password_hash"`
-	Roles        []string           `json:"roles"`
-	CreatedAt    pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt    pgtype.Timestamptz `json:"updated_at"`
+	ID            pgtype.UUID        `json:"id"`
+	Name          string             `json:"name"`
+	Email         string             `json:"email"`
+	PasswordHash  string             `json:"password_hash"`
+	Roles         []string           `json:"roles"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+	DeactivatedAt pgtype.Timestamptz `json:"deactivated_at"`
+}
+
+type UserBackupCode struct {
+	ID        pgtype.UUID        `json:"id"`
+	UserID    pgtype.UUID        `json:"user_id"`
+	CodeHash  string             `json:"code_hash"`
+	UsedAt    pgtype.Timestamptz `json:"used_at"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+}
+
+type UserEmailPreference struct {
+	UserID    pgtype.UUID        `json:"user_id"`
+	Category  string             `json:"category"`
+	Enabled   bool               `json:"enabled"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type UserTotp struct {
+	UserID    pgtype.UUID        `json:"user_id"`
+	Secret    []byte             `json:"secret"`
+	EnabledAt pgtype.Timestamptz `json:"enabled_at"`
+	LastStep  int64              `json:"last_step"`
+	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type VariantBundle struct {
+	VariantID pgtype.UUID        `json:"variant_id"`
+	Pricing   string             `json:"pricing"`
+	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+}
+
+type VariantBundleComponent struct {
+	BundleVariantID    pgtype.UUID `json:"bundle_variant_id"`
+	ComponentVariantID pgtype.UUID `json:"component_variant_id"`
+	Qty                int32       `json:"qty"`
 }
 
 type Voucher struct {
@@ -621,6 +715,19 @@ type WebhookDelivery struct {
 	CreatedAt      pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
 	TenantID       pgtype.UUID        `json:"tenant_id"`
+	AggregateID    pgtype.UUID        `json:"aggregate_id"`
+	Sequence       pgtype.Int8        `json:"sequence"`
+}
+
+type WebhookDeliveryAttempt struct {
+	ID             pgtype.UUID        `json:"id"`
+	DeliveryID     pgtype.UUID        `json:"delivery_id"`
+	Attempt        int32              `json:"attempt"`
+	ResponseStatus pgtype.Int4        `json:"response_status"`
+	ResponseBody   pgtype.Text        `json:"response_body"`
+	Error          pgtype.Text        `json:"error"`
+	DurationMs     int32              `json:"duration_ms"`
+	CreatedAt      pgtype.Timestamptz `json:"created_at"`
 }
 
 type WebhookDlq struct {
@@ -631,13 +738,31 @@ type WebhookDlq struct {
 }
 
 type WebhookEndpoint struct {
-	ID        pgtype.UUID        `json:"id"`
-	Name      string             `json:"name"`
-	Url       string             `json:"url"`
-	Secret    string             `json:"secret"`
-	Active    bool               `json:"active"`
-	Topics    []string           `json:"topics"`
-	CreatedAt pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
-	TenantID  pgtype.UUID        `json:"tenant_id"`
+	ID                  pgtype.UUID        `json:"id"`
+	Name                string             `json:"name"`
+	Url                 string             `json:"url"`
+	Secret              string             `json:"secret"`
+	Active              bool               `json:"active"`
+	Topics              []string           `json:"topics"`
+	CreatedAt           pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt           pgtype.Timestamptz `json:"updated_at"`
+	TenantID            pgtype.UUID        `json:"tenant_id"`
+	Format              string             `json:"format"`
+	Ordered             bool               `json:"ordered"`
+	MaxPayloadBytes     int32              `json:"max_payload_bytes"`
+	OversizePolicy      string             `json:"oversize_policy"`
+	DeliveryMode        string             `json:"delivery_mode"`
+	ConsecutiveFailures int32              `json:"consecutive_failures"`
+	DisabledReason      pgtype.Text        `json:"disabled_reason"`
+	DisabledAt          pgtype.Timestamptz `json:"disabled_at"`
+	RetryStatuses       []int32            `json:"retry_statuses"`
+	PermanentStatuses   []int32            `json:"permanent_statuses"`
+	CustomHeaderNames   []string           `json:"custom_header_names"`
+	CustomHeaders       []byte             `json:"custom_headers"`
+}
+
+type WebhookSequence struct {
+	EndpointID   pgtype.UUID `json:"endpoint_id"`
+	AggregateID  pgtype.UUID `json:"aggregate_id"`
+	LastSequence int64       `json:"last_sequence"`
 }
@@ -11,6 +11,19 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const countOrdersAdmin = `-- name: CountOrdersAdmin :one
+SELECT COUNT(*)
+FROM orders
+WHERE ($1::text IS NULL OR status::text = $1::text)
+`
+
+func (q *Queries) CountOrdersAdmin(ctx context.Context, status pgtype.Text) (int64, error) {
+	row := q.db.QueryRow(ctx, countOrdersAdmin, status)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const countOrdersForUser = `-- name: CountOrdersForUser :one
 SELECT COUNT(*)
 FROM orders
@@ -187,7 +200,7 @@ func (q *Queries) GetOrderByIDForUser(ctx context.Context, arg GetOrderByIDForUs
 }
 
 const listOrderItemsByOrder = `-- name: ListOrderItemsByOrder :many
-SELECT id, order_id, product_id, variant_id, title, slug, qty, unit_price, subtotal
+SELECT id, order_id, product_id, variant_id, title, slug, qty, unit_price, subtotal, preorder, discount_allocated, catalog_unit_price
 FROM order_items
 WHERE order_id = $1
 ORDER BY title ASC, id
@@ -212,6 +225,175 @@ func (q *Queries) ListOrderItemsByOrder(ctx context.Context, orderID pgtype.UUID
 			&i.Qty,
 			&i.UnitPrice,
 			&i.Subtotal,
+			&i.Preorder,
+			&i.DiscountAllocated,
+			&i.CatalogUnitPrice,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrderItemsByOrders = `-- name: ListOrderItemsByOrders :many
+SELECT id, order_id, product_id, variant_id, title, slug, qty, unit_price, subtotal, preorder, discount_allocated, catalog_unit_price
+FROM order_items
+WHERE order_id = ANY($1::uuid[])
+ORDER BY order_id, title ASC, id
+`
+
+func (q *Queries) ListOrderItemsByOrders(ctx context.Context, orderIds []pgtype.UUID) ([]OrderItem, error) {
+	rows, err := q.db.Query(ctx, listOrderItemsByOrders, orderIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []OrderItem
+	for rows.Next() {
+		var i OrderItem
+		if err := rows.Scan(
+			&i.ID,
+			&i.OrderID,
+			&i.ProductID,
+			&i.VariantID,
+			&i.Title,
+			&i.Slug,
+			&i.Qty,
+			&i.UnitPrice,
+			&i.Subtotal,
+			&i.Preorder,
+			&i.DiscountAllocated,
+			&i.CatalogUnitPrice,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrdersAdmin = `-- name: ListOrdersAdmin :many
+SELECT id, user_id, cart_id, status, currency, pricing_subtotal, pricing_discount, pricing_tax, pricing_shipping, pricing_total, shipping_address, shipping_option, notes, created_at, updated_at, applied_voucher_code, tenant_id
+FROM orders
+WHERE ($1::text IS NULL OR status::text = $1::text)
+ORDER BY created_at DESC
+LIMIT $3 OFFSET $2
+`
+
+type ListOrdersAdminParams struct {
+	Status     pgtype.Text `json:"status"`
+	PageOffset int32       `json:"page_offset"`
+	PageLimit  int32       `json:"page_limit"`
+}
+
+func (q *Queries) ListOrdersAdmin(ctx context.Context, arg ListOrdersAdminParams) ([]Order, error) {
+	rows, err := q.db.Query(ctx, listOrdersAdmin, arg.Status, arg.PageOffset, arg.PageLimit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CartID,
+			&i.Status,
+			&i.Currency,
+			&i.PricingSubtotal,
+			&i.PricingDiscount,
+			&i.PricingTax,
+			&i.PricingShipping,
+			&i.PricingTotal,
+			&i.ShippingAddress,
+			&i.ShippingOption,
+			&i.Notes,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.AppliedVoucherCode,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listOrdersForExportAfter = `-- name: ListOrdersForExportAfter :many
+SELECT id, user_id, cart_id, status, currency, pricing_subtotal, pricing_discount, pricing_tax, pricing_shipping, pricing_total, shipping_address, shipping_option, notes, created_at, updated_at, applied_voucher_code, tenant_id
+FROM orders
+WHERE created_at >= $1
+  AND created_at < $2
+  AND status::text = ANY($3::text[])
+  AND ($4::uuid IS NULL OR tenant_id = $4::uuid)
+  AND (
+    $5::timestamptz IS NULL
+    OR (created_at, id) > ($5::timestamptz, $6::uuid)
+  )
+ORDER BY created_at, id
+LIMIT $7
+`
+
+type ListOrdersForExportAfterParams struct {
+	FromAt         pgtype.Timestamptz `json:"from_at"`
+	ToAt           pgtype.Timestamptz `json:"to_at"`
+	Statuses       []string           `json:"statuses"`
+	TenantID       pgtype.UUID        `json:"tenant_id"`
+	AfterCreatedAt pgtype.Timestamptz `json:"after_created_at"`
+	AfterID        pgtype.UUID        `json:"after_id"`
+	PageLimit      int32              `json:"page_limit"`
+}
+
+// Pages through the orders created in [from_at, to_at) with one of the given
+// statuses oldest first, optionally for one tenant, resuming after the given
+// order, so an accounting export can stream any range.
+func (q *Queries) ListOrdersForExportAfter(ctx context.Context, arg ListOrdersForExportAfterParams) ([]Order, error) {
+	rows, err := q.db.Query(ctx, listOrdersForExportAfter,
+		arg.FromAt,
+		arg.ToAt,
+		arg.Statuses,
+		arg.TenantID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CartID,
+			&i.Status,
+			&i.Currency,
+			&i.PricingSubtotal,
+			&i.PricingDiscount,
+			&i.PricingTax,
+			&i.PricingShipping,
+			&i.PricingTotal,
+			&i.ShippingAddress,
+			&i.ShippingOption,
+			&i.Notes,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.AppliedVoucherCode,
+			&i.TenantID,
 		); err != nil {
 			return nil, err
 		}
@@ -275,6 +457,70 @@ func (q *Queries) ListOrdersForUser(ctx context.Context, arg ListOrdersForUserPa
 	return items, nil
 }
 
+const listOrdersForUserAfter = `-- name: ListOrdersForUserAfter :many
+SELECT id, user_id, cart_id, status, currency, pricing_subtotal, pricing_discount, pricing_tax, pricing_shipping, pricing_total, shipping_address, shipping_option, notes, created_at, updated_at, applied_voucher_code, tenant_id
+FROM orders
+WHERE user_id = $1
+  AND (
+    $2::timestamptz IS NULL
+    OR (created_at, id) > ($2::timestamptz, $3::uuid)
+  )
+ORDER BY created_at, id
+LIMIT $4
+`
+
+type ListOrdersForUserAfterParams struct {
+	UserID         pgtype.UUID        `json:"user_id"`
+	AfterCreatedAt pgtype.Timestamptz `json:"after_created_at"`
+	AfterID        pgtype.UUID        `json:"after_id"`
+	PageLimit      int32              `json:"page_limit"`
+}
+
+// Pages through a user's orders oldest first, resuming after the given
+// order, so an export can stream any number of them.
+func (q *Queries) ListOrdersForUserAfter(ctx context.Context, arg ListOrdersForUserAfterParams) ([]Order, error) {
+	rows, err := q.db.Query(ctx, listOrdersForUserAfter,
+		arg.UserID,
+		arg.AfterCreatedAt,
+		arg.AfterID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Order
+	for rows.Next() {
+		var i Order
+		if err := rows.Scan(
+			&i.ID,
+			&i.UserID,
+			&i.CartID,
+			&i.Status,
+			&i.Currency,
+			&i.PricingSubtotal,
+			&i.PricingDiscount,
+			&i.PricingTax,
+			&i.PricingShipping,
+			&i.PricingTotal,
+			&i.ShippingAddress,
+			&i.ShippingOption,
+			&i.Notes,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.AppliedVoucherCode,
+			&i.TenantID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateOrderStatus = `-- name: UpdateOrderStatus :exec
 UPDATE orders
 SET status = $2,
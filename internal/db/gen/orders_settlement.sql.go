@@ -40,7 +40,7 @@ func (q *Queries) IncrementVoucherUsageByCode(ctx context.Context, code string)
 }
 
 const listOrderItemsForStock = `-- name: ListOrderItemsForStock :many
-SELECT product_id, variant_id, qty, slug
+SELECT product_id, variant_id, qty, slug, preorder
 FROM order_items
 WHERE order_id = $1
 `
@@ -50,6 +50,7 @@ type ListOrderItemsForStockRow struct {
 	VariantID pgtype.UUID `json:"variant_id"`
 	Qty       int32       `json:"qty"`
 	Slug      string      `json:"slug"`
+	Preorder  bool        `json:"preorder"`
 }
 
 func (q *Queries) ListOrderItemsForStock(ctx context.Context, orderID pgtype.UUID) ([]ListOrderItemsForStockRow, error) {
@@ -66,6 +67,7 @@ func (q *Queries) ListOrderItemsForStock(ctx context.Context, orderID pgtype.UUI
 			&i.VariantID,
 			&i.Qty,
 			&i.Slug,
+			&i.Preorder,
 		); err != nil {
 			return nil, err
 		}
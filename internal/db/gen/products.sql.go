@@ -22,6 +22,7 @@ WHERE ($1::text IS NULL OR p.title ILIKE '%%' || $1 || '%%')
   AND ($4::bigint IS NULL OR p.price >= $4)
   AND ($5::bigint IS NULL OR p.price <= $5)
   AND ($6::boolean IS NULL OR p.in_stock = $6)
+  AND (p.available_to IS NULL OR p.available_to > now())
 `
 
 type CountProductsPublicParams struct {
@@ -47,6 +48,97 @@ func (q *Queries) CountProductsPublic(ctx context.Context, arg CountProductsPubl
 	return count, err
 }
 
+const createProductImage = `-- name: CreateProductImage :one
+WITH inserted AS (
+    INSERT INTO product_images (product_id, url, sort_order)
+    VALUES (
+        $1,
+        $2,
+        COALESCE((SELECT MAX(sort_order) + 1 FROM product_images WHERE product_id = $1), 0)
+    )
+    RETURNING id, product_id, url, sort_order
+)
+SELECT inserted.id,
+       inserted.product_id,
+       inserted.url,
+       inserted.sort_order,
+       p.slug
+FROM inserted
+JOIN products p ON p.id = inserted.product_id
+`
+
+type CreateProductImageParams struct {
+	ProductID pgtype.UUID `json:"product_id"`
+	Url       string      `json:"url"`
+}
+
+type CreateProductImageRow struct {
+	ID        pgtype.UUID `json:"id"`
+	ProductID pgtype.UUID `json:"product_id"`
+	Url       string      `json:"url"`
+	SortOrder int32       `json:"sort_order"`
+	Slug      string      `json:"slug"`
+}
+
+func (q *Queries) CreateProductImage(ctx context.Context, arg CreateProductImageParams) (CreateProductImageRow, error) {
+	row := q.db.QueryRow(ctx, createProductImage, arg.ProductID, arg.Url)
+	var i CreateProductImageRow
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.Url,
+		&i.SortOrder,
+		&i.Slug,
+	)
+	return i, err
+}
+
+const createProductVariant = `-- name: CreateProductVariant :one
+INSERT INTO product_variants (product_id, sku, price, stock, attributes, weight_gram, length_cm, width_cm, height_cm)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+RETURNING id, product_id, sku, price, stock, attributes, weight_gram, length_cm, width_cm, height_cm
+`
+
+type CreateProductVariantParams struct {
+	ProductID  pgtype.UUID `json:"product_id"`
+	Sku        pgtype.Text `json:"sku"`
+	Price      int64       `json:"price"`
+	Stock      int32       `json:"stock"`
+	Attributes []byte      `json:"attributes"`
+	WeightGram pgtype.Int4 `json:"weight_gram"`
+	LengthCm   pgtype.Int4 `json:"length_cm"`
+	WidthCm    pgtype.Int4 `json:"width_cm"`
+	HeightCm   pgtype.Int4 `json:"height_cm"`
+}
+
+func (q *Queries) CreateProductVariant(ctx context.Context, arg CreateProductVariantParams) (ProductVariant, error) {
+	row := q.db.QueryRow(ctx, createProductVariant,
+		arg.ProductID,
+		arg.Sku,
+		arg.Price,
+		arg.Stock,
+		arg.Attributes,
+		arg.WeightGram,
+		arg.LengthCm,
+		arg.WidthCm,
+		arg.HeightCm,
+	)
+	var i ProductVariant
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.Sku,
+		&i.Price,
+		&i.Stock,
+		&i.Attributes,
+		&i.WeightGram,
+		&i.LengthCm,
+		&i.WidthCm,
+		&i.HeightCm,
+	)
+	return i, err
+}
+
 const getProductBySlug = `-- name: GetProductBySlug :one
 SELECT id,
        title,
@@ -59,25 +151,37 @@ SELECT id,
        brand_id,
        category_id,
        created_at,
-       COALESCE((SELECT SUM(stock) FROM product_variants WHERE product_id = products.id), 0)::int AS total_stock
+       option_schema,
+       COALESCE((SELECT SUM(stock) FROM product_variants WHERE product_id = products.id), 0)::int AS total_stock,
+       default_variant_id,
+       available_from,
+       available_to,
+       preorder,
+       preorder_ships_at
 FROM products
 WHERE slug = $1
 LIMIT 1
 `
 
 type GetProductBySlugRow struct {
-	ID         pgtype.UUID        `json:"id"`
-	Title      string             `json:"title"`
-	Slug       string             `json:"slug"`
-	Price      int64              `json:"price"`
-	CompareAt  pgtype.Int8        `json:"compare_at"`
-	InStock    bool               `json:"in_stock"`
-	Thumbnail  pgtype.Text        `json:"thumbnail"`
-	Badges     []string           `json:"badges"`
-	BrandID    pgtype.UUID        `json:"brand_id"`
-	CategoryID pgtype.UUID        `json:"category_id"`
-	CreatedAt  pgtype.Timestamptz `json:"created_at"`
-	TotalStock int32              `json:"total_stock"`
+	ID               pgtype.UUID        `json:"id"`
+	Title            string             `json:"title"`
+	Slug             string             `json:"slug"`
+	Price            int64              `json:"price"`
+	CompareAt        pgtype.Int8        `json:"compare_at"`
+	InStock          bool               `json:"in_stock"`
+	Thumbnail        pgtype.Text        `json:"thumbnail"`
+	Badges           []string           `json:"badges"`
+	BrandID          pgtype.UUID        `json:"brand_id"`
+	CategoryID       pgtype.UUID        `json:"category_id"`
+	CreatedAt        pgtype.Timestamptz `json:"created_at"`
+	OptionSchema     []byte             `json:"option_schema"`
+	TotalStock       int32              `json:"total_stock"`
+	DefaultVariantID pgtype.UUID        `json:"default_variant_id"`
+	AvailableFrom    pgtype.Timestamptz `json:"available_from"`
+	AvailableTo      pgtype.Timestamptz `json:"available_to"`
+	Preorder         bool               `json:"preorder"`
+	PreorderShipsAt  pgtype.Timestamptz `json:"preorder_ships_at"`
 }
 
 func (q *Queries) GetProductBySlug(ctx context.Context, slug string) (GetProductBySlugRow, error) {
@@ -95,30 +199,50 @@ func (q *Queries) GetProductBySlug(ctx context.Context, slug string) (GetProduct
 		&i.BrandID,
 		&i.CategoryID,
 		&i.CreatedAt,
+		&i.OptionSchema,
 		&i.TotalStock,
+		&i.DefaultVariantID,
+		&i.AvailableFrom,
+		&i.AvailableTo,
+		&i.Preorder,
+		&i.PreorderShipsAt,
 	)
 	return i, err
 }
 
 const getProductForCart = `-- name: GetProductForCart :one
-SELECT id,
-       title,
-       slug,
-       price,
-       category_id,
-       brand_id
-FROM products
-WHERE id = $1
+SELECT p.id,
+       p.title,
+       p.slug,
+       p.price,
+       p.category_id,
+       p.brand_id,
+       p.default_variant_id,
+       EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id) AS has_variants,
+       p.available_from,
+       p.available_to,
+       p.preorder,
+       p.preorder_ships_at,
+       p.in_stock
+FROM products p
+WHERE p.id = $1
 LIMIT 1
 `
 
 type GetProductForCartRow struct {
-	ID         pgtype.UUID `json:"id"`
-	Title      string      `json:"title"`
-	Slug       string      `json:"slug"`
-	Price      int64       `json:"price"`
-	CategoryID pgtype.UUID `json:"category_id"`
-	BrandID    pgtype.UUID `json:"brand_id"`
+	ID               pgtype.UUID        `json:"id"`
+	Title            string             `json:"title"`
+	Slug             string             `json:"slug"`
+	Price            int64              `json:"price"`
+	CategoryID       pgtype.UUID        `json:"category_id"`
+	BrandID          pgtype.UUID        `json:"brand_id"`
+	DefaultVariantID pgtype.UUID        `json:"default_variant_id"`
+	HasVariants      bool               `json:"has_variants"`
+	AvailableFrom    pgtype.Timestamptz `json:"available_from"`
+	AvailableTo      pgtype.Timestamptz `json:"available_to"`
+	Preorder         bool               `json:"preorder"`
+	PreorderShipsAt  pgtype.Timestamptz `json:"preorder_ships_at"`
+	InStock          bool               `json:"in_stock"`
 }
 
 func (q *Queries) GetProductForCart(ctx context.Context, id pgtype.UUID) (GetProductForCartRow, error) {
@@ -131,6 +255,41 @@ func (q *Queries) GetProductForCart(ctx context.Context, id pgtype.UUID) (GetPro
 		&i.Price,
 		&i.CategoryID,
 		&i.BrandID,
+		&i.DefaultVariantID,
+		&i.HasVariants,
+		&i.AvailableFrom,
+		&i.AvailableTo,
+		&i.Preorder,
+		&i.PreorderShipsAt,
+		&i.InStock,
+	)
+	return i, err
+}
+
+const getProductOptionSchema = `-- name: GetProductOptionSchema :one
+SELECT id,
+       slug,
+       option_schema,
+       default_variant_id
+FROM products
+WHERE id = $1
+`
+
+type GetProductOptionSchemaRow struct {
+	ID               pgtype.UUID `json:"id"`
+	Slug             string      `json:"slug"`
+	OptionSchema     []byte      `json:"option_schema"`
+	DefaultVariantID pgtype.UUID `json:"default_variant_id"`
+}
+
+func (q *Queries) GetProductOptionSchema(ctx context.Context, id pgtype.UUID) (GetProductOptionSchemaRow, error) {
+	row := q.db.QueryRow(ctx, getProductOptionSchema, id)
+	var i GetProductOptionSchemaRow
+	err := row.Scan(
+		&i.ID,
+		&i.Slug,
+		&i.OptionSchema,
+		&i.DefaultVariantID,
 	)
 	return i, err
 }
@@ -199,6 +358,93 @@ func (q *Queries) ListImagesByProduct(ctx context.Context, productID pgtype.UUID
 	return items, nil
 }
 
+const listProductScopesByIDs = `-- name: ListProductScopesByIDs :many
+SELECT id,
+       category_id,
+       brand_id
+FROM products
+WHERE id = ANY($1::uuid[])
+`
+
+type ListProductScopesByIDsRow struct {
+	ID         pgtype.UUID `json:"id"`
+	CategoryID pgtype.UUID `json:"category_id"`
+	BrandID    pgtype.UUID `json:"brand_id"`
+}
+
+func (q *Queries) ListProductScopesByIDs(ctx context.Context, productIds []pgtype.UUID) ([]ListProductScopesByIDsRow, error) {
+	rows, err := q.db.Query(ctx, listProductScopesByIDs, productIds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListProductScopesByIDsRow
+	for rows.Next() {
+		var i ListProductScopesByIDsRow
+		if err := rows.Scan(&i.ID, &i.CategoryID, &i.BrandID); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listProductTranslations = `-- name: ListProductTranslations :many
+SELECT product_id,
+       locale,
+       title,
+       description,
+       badges,
+       specs
+FROM product_translations
+WHERE product_id = ANY($1::uuid[])
+  AND locale = $2
+`
+
+type ListProductTranslationsParams struct {
+	ProductIds []pgtype.UUID `json:"product_ids"`
+	Locale     string        `json:"locale"`
+}
+
+type ListProductTranslationsRow struct {
+	ProductID   pgtype.UUID `json:"product_id"`
+	Locale      string      `json:"locale"`
+	Title       string      `json:"title"`
+	Description pgtype.Text `json:"description"`
+	Badges      []string    `json:"badges"`
+	Specs       []byte      `json:"specs"`
+}
+
+func (q *Queries) ListProductTranslations(ctx context.Context, arg ListProductTranslationsParams) ([]ListProductTranslationsRow, error) {
+	rows, err := q.db.Query(ctx, listProductTranslations, arg.ProductIds, arg.Locale)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListProductTranslationsRow
+	for rows.Next() {
+		var i ListProductTranslationsRow
+		if err := rows.Scan(
+			&i.ProductID,
+			&i.Locale,
+			&i.Title,
+			&i.Description,
+			&i.Badges,
+			&i.Specs,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listProductsPublic = `-- name: ListProductsPublic :many
 SELECT p.id,
        p.title,
@@ -209,21 +455,28 @@ SELECT p.id,
        p.thumbnail,
        p.badges,
        p.created_at,
-       COALESCE((SELECT SUM(stock) FROM product_variants WHERE product_id = p.id), 0)::int AS total_stock
+       COALESCE((SELECT SUM(stock) FROM product_variants WHERE product_id = p.id), 0)::int AS total_stock,
+       p.available_from,
+       p.available_to,
+       p.preorder
 FROM products p
 LEFT JOIN brands b ON b.id = p.brand_id
 LEFT JOIN categories c ON c.id = p.category_id
+LEFT JOIN mv_top_products tp ON tp.product_id = p.id
 WHERE ($1::text IS NULL OR p.title ILIKE '%%' || $1 || '%%')
   AND ($2::text IS NULL OR c.slug = $2)
   AND ($3::text IS NULL OR b.slug = $3)
   AND ($4::bigint IS NULL OR p.price >= $4)
   AND ($5::bigint IS NULL OR p.price <= $5)
   AND ($6::boolean IS NULL OR p.in_stock = $6)
-ORDER BY CASE WHEN $7::text = 'price:asc' THEN p.price END ASC,
+  AND (p.available_to IS NULL OR p.available_to > now())
+ORDER BY CASE WHEN $7::text = 'bestseller' THEN COALESCE(tp.qty_sold, 0) END DESC,
+         CASE WHEN $7::text = 'price:asc' THEN p.price END ASC,
          CASE WHEN $7::text = 'price:desc' THEN p.price END DESC,
          CASE WHEN $7::text = 'title:asc' THEN p.title END ASC,
          CASE WHEN $7::text = 'title:desc' THEN p.title END DESC,
-         p.created_at DESC
+         p.created_at DESC,
+         p.id
 LIMIT $9 OFFSET $8
 `
 
@@ -240,16 +493,19 @@ type ListProductsPublicParams struct {
 }
 
 type ListProductsPublicRow struct {
-	ID         pgtype.UUID        `json:"id"`
-	Title      string             `json:"title"`
-	Slug       string             `json:"slug"`
-	Price      int64              `json:"price"`
-	CompareAt  pgtype.Int8        `json:"compare_at"`
-	InStock    bool               `json:"in_stock"`
-	Thumbnail  pgtype.Text        `json:"thumbnail"`
-	Badges     []string           `json:"badges"`
-	CreatedAt  pgtype.Timestamptz `json:"created_at"`
-	TotalStock int32              `json:"total_stock"`
+	ID            pgtype.UUID        `json:"id"`
+	Title         string             `json:"title"`
+	Slug          string             `json:"slug"`
+	Price         int64              `json:"price"`
+	CompareAt     pgtype.Int8        `json:"compare_at"`
+	InStock       bool               `json:"in_stock"`
+	Thumbnail     pgtype.Text        `json:"thumbnail"`
+	Badges        []string           `json:"badges"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	TotalStock    int32              `json:"total_stock"`
+	AvailableFrom pgtype.Timestamptz `json:"available_from"`
+	AvailableTo   pgtype.Timestamptz `json:"available_to"`
+	Preorder      bool               `json:"preorder"`
 }
 
 func (q *Queries) ListProductsPublic(ctx context.Context, arg ListProductsPublicParams) ([]ListProductsPublicRow, error) {
@@ -282,6 +538,119 @@ func (q *Queries) ListProductsPublic(ctx context.Context, arg ListProductsPublic
 			&i.Badges,
 			&i.CreatedAt,
 			&i.TotalStock,
+			&i.AvailableFrom,
+			&i.AvailableTo,
+			&i.Preorder,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listRecommendationCandidates = `-- name: ListRecommendationCandidates :many
+SELECT p.id,
+       p.title,
+       p.slug,
+       p.price,
+       p.compare_at,
+       p.in_stock,
+       p.thumbnail,
+       p.badges,
+       p.created_at,
+       p.available_from,
+       p.available_to,
+       p.preorder,
+       COALESCE((SELECT SUM(stock) FROM product_variants WHERE product_id = p.id), 0)::int AS total_stock,
+       p.brand_id,
+       p.category_id,
+       COALESCE(cp.orders, 0)::bigint AS co_orders
+FROM products p
+LEFT JOIN mv_product_copurchase cp ON cp.product_id = $1 AND cp.other_product_id = p.id
+WHERE p.id <> $1
+  AND (p.available_to IS NULL OR p.available_to > now())
+  AND (cp.orders IS NOT NULL
+       OR p.brand_id = $2
+       OR p.category_id = $3
+       OR p.price BETWEEN $4::bigint AND $5::bigint)
+ORDER BY COALESCE(cp.orders, 0) DESC,
+         (p.brand_id = $2) IS TRUE DESC,
+         (p.category_id = $3) IS TRUE DESC,
+         abs(p.price - $6::bigint) ASC,
+         p.id
+LIMIT $7
+`
+
+type ListRecommendationCandidatesParams struct {
+	ProductID  pgtype.UUID `json:"product_id"`
+	BrandID    pgtype.UUID `json:"brand_id"`
+	CategoryID pgtype.UUID `json:"category_id"`
+	PriceMin   int64       `json:"price_min"`
+	PriceMax   int64       `json:"price_max"`
+	Price      int64       `json:"price"`
+	LimitCount int32       `json:"limit_count"`
+}
+
+type ListRecommendationCandidatesRow struct {
+	ID            pgtype.UUID        `json:"id"`
+	Title         string             `json:"title"`
+	Slug          string             `json:"slug"`
+	Price         int64              `json:"price"`
+	CompareAt     pgtype.Int8        `json:"compare_at"`
+	InStock       bool               `json:"in_stock"`
+	Thumbnail     pgtype.Text        `json:"thumbnail"`
+	Badges        []string           `json:"badges"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	AvailableFrom pgtype.Timestamptz `json:"available_from"`
+	AvailableTo   pgtype.Timestamptz `json:"available_to"`
+	Preorder      bool               `json:"preorder"`
+	TotalStock    int32              `json:"total_stock"`
+	BrandID       pgtype.UUID        `json:"brand_id"`
+	CategoryID    pgtype.UUID        `json:"category_id"`
+	CoOrders      int64              `json:"co_orders"`
+}
+
+// Products sharing any recommendation signal with the given one: bought in
+// the same orders, same brand, same category, or priced within the band.
+// The strongest candidates come first; the caller ranks them.
+func (q *Queries) ListRecommendationCandidates(ctx context.Context, arg ListRecommendationCandidatesParams) ([]ListRecommendationCandidatesRow, error) {
+	rows, err := q.db.Query(ctx, listRecommendationCandidates,
+		arg.ProductID,
+		arg.BrandID,
+		arg.CategoryID,
+		arg.PriceMin,
+		arg.PriceMax,
+		arg.Price,
+		arg.LimitCount,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListRecommendationCandidatesRow
+	for rows.Next() {
+		var i ListRecommendationCandidatesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Title,
+			&i.Slug,
+			&i.Price,
+			&i.CompareAt,
+			&i.InStock,
+			&i.Thumbnail,
+			&i.Badges,
+			&i.CreatedAt,
+			&i.AvailableFrom,
+			&i.AvailableTo,
+			&i.Preorder,
+			&i.TotalStock,
+			&i.BrandID,
+			&i.CategoryID,
+			&i.CoOrders,
 		); err != nil {
 			return nil, err
 		}
@@ -302,10 +671,15 @@ SELECT p.id,
        p.in_stock,
        p.thumbnail,
        p.badges,
-       p.created_at
+       p.created_at,
+       p.available_from,
+       p.available_to,
+       p.preorder,
+       COALESCE((SELECT SUM(stock) FROM product_variants WHERE product_id = p.id), 0)::int AS total_stock
 FROM products p
 WHERE p.category_id = $1
   AND p.slug <> $2
+  AND (p.available_to IS NULL OR p.available_to > now())
 ORDER BY p.created_at DESC
 LIMIT 8
 `
@@ -316,15 +690,19 @@ type ListRelatedByCategoryParams struct {
 }
 
 type ListRelatedByCategoryRow struct {
-	ID        pgtype.UUID        `json:"id"`
-	Title     string             `json:"title"`
-	Slug      string             `json:"slug"`
-	Price     int64              `json:"price"`
-	CompareAt pgtype.Int8        `json:"compare_at"`
-	InStock   bool               `json:"in_stock"`
-	Thumbnail pgtype.Text        `json:"thumbnail"`
-	Badges    []string           `json:"badges"`
-	CreatedAt pgtype.Timestamptz `json:"created_at"`
+	ID            pgtype.UUID        `json:"id"`
+	Title         string             `json:"title"`
+	Slug          string             `json:"slug"`
+	Price         int64              `json:"price"`
+	CompareAt     pgtype.Int8        `json:"compare_at"`
+	InStock       bool               `json:"in_stock"`
+	Thumbnail     pgtype.Text        `json:"thumbnail"`
+	Badges        []string           `json:"badges"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	AvailableFrom pgtype.Timestamptz `json:"available_from"`
+	AvailableTo   pgtype.Timestamptz `json:"available_to"`
+	Preorder      bool               `json:"preorder"`
+	TotalStock    int32              `json:"total_stock"`
 }
 
 func (q *Queries) ListRelatedByCategory(ctx context.Context, arg ListRelatedByCategoryParams) ([]ListRelatedByCategoryRow, error) {
@@ -346,6 +724,10 @@ func (q *Queries) ListRelatedByCategory(ctx context.Context, arg ListRelatedByCa
 			&i.Thumbnail,
 			&i.Badges,
 			&i.CreatedAt,
+			&i.AvailableFrom,
+			&i.AvailableTo,
+			&i.Preorder,
+			&i.TotalStock,
 		); err != nil {
 			return nil, err
 		}
@@ -392,13 +774,45 @@ func (q *Queries) ListSpecsByProduct(ctx context.Context, productID pgtype.UUID)
 	return items, nil
 }
 
+const listTopProductSlugs = `-- name: ListTopProductSlugs :many
+SELECT p.slug
+FROM mv_top_products tp
+JOIN products p ON p.id = tp.product_id
+ORDER BY tp.qty_sold DESC, p.id
+LIMIT $1
+`
+
+func (q *Queries) ListTopProductSlugs(ctx context.Context, limitCount int32) ([]string, error) {
+	rows, err := q.db.Query(ctx, listTopProductSlugs, limitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var slug string
+		if err := rows.Scan(&slug); err != nil {
+			return nil, err
+		}
+		items = append(items, slug)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const listVariantsByProduct = `-- name: ListVariantsByProduct :many
 SELECT id,
        product_id,
        sku,
        price,
        stock,
-       attributes
+       attributes,
+       weight_gram,
+       length_cm,
+       width_cm,
+       height_cm
 FROM product_variants
 WHERE product_id = $1
 ORDER BY sku NULLS LAST, id
@@ -420,6 +834,10 @@ func (q *Queries) ListVariantsByProduct(ctx context.Context, productID pgtype.UU
 			&i.Price,
 			&i.Stock,
 			&i.Attributes,
+			&i.WeightGram,
+			&i.LengthCm,
+			&i.WidthCm,
+			&i.HeightCm,
 		); err != nil {
 			return nil, err
 		}
@@ -430,3 +848,126 @@ func (q *Queries) ListVariantsByProduct(ctx context.Context, productID pgtype.UU
 	}
 	return items, nil
 }
+
+const setProductDefaultVariant = `-- name: SetProductDefaultVariant :exec
+UPDATE products
+SET default_variant_id = $1,
+    updated_at = now()
+WHERE id = $2
+`
+
+type SetProductDefaultVariantParams struct {
+	VariantID pgtype.UUID `json:"variant_id"`
+	ID        pgtype.UUID `json:"id"`
+}
+
+func (q *Queries) SetProductDefaultVariant(ctx context.Context, arg SetProductDefaultVariantParams) error {
+	_, err := q.db.Exec(ctx, setProductDefaultVariant, arg.VariantID, arg.ID)
+	return err
+}
+
+const updateProductAvailability = `-- name: UpdateProductAvailability :one
+UPDATE products
+SET available_from = $1,
+    available_to = $2,
+    preorder = $3,
+    preorder_ships_at = $4,
+    updated_at = now()
+WHERE id = $5
+RETURNING slug
+`
+
+type UpdateProductAvailabilityParams struct {
+	AvailableFrom   pgtype.Timestamptz `json:"available_from"`
+	AvailableTo     pgtype.Timestamptz `json:"available_to"`
+	Preorder        bool               `json:"preorder"`
+	PreorderShipsAt pgtype.Timestamptz `json:"preorder_ships_at"`
+	ID              pgtype.UUID        `json:"id"`
+}
+
+func (q *Queries) UpdateProductAvailability(ctx context.Context, arg UpdateProductAvailabilityParams) (string, error) {
+	row := q.db.QueryRow(ctx, updateProductAvailability,
+		arg.AvailableFrom,
+		arg.AvailableTo,
+		arg.Preorder,
+		arg.PreorderShipsAt,
+		arg.ID,
+	)
+	var slug string
+	err := row.Scan(&slug)
+	return slug, err
+}
+
+const updateProductOptionSchema = `-- name: UpdateProductOptionSchema :exec
+UPDATE products
+SET option_schema = $2,
+    updated_at = now()
+WHERE id = $1
+`
+
+type UpdateProductOptionSchemaParams struct {
+	ID           pgtype.UUID `json:"id"`
+	OptionSchema []byte      `json:"option_schema"`
+}
+
+func (q *Queries) UpdateProductOptionSchema(ctx context.Context, arg UpdateProductOptionSchemaParams) error {
+	_, err := q.db.Exec(ctx, updateProductOptionSchema, arg.ID, arg.OptionSchema)
+	return err
+}
+
+const updateProductVariant = `-- name: UpdateProductVariant :one
+UPDATE product_variants
+SET sku = $3,
+    price = $4,
+    stock = $5,
+    attributes = $6,
+    weight_gram = $7,
+    length_cm = $8,
+    width_cm = $9,
+    height_cm = $10
+WHERE id = $1
+  AND product_id = $2
+RETURNING id, product_id, sku, price, stock, attributes, weight_gram, length_cm, width_cm, height_cm
+`
+
+type UpdateProductVariantParams struct {
+	ID         pgtype.UUID `json:"id"`
+	ProductID  pgtype.UUID `json:"product_id"`
+	Sku        pgtype.Text `json:"sku"`
+	Price      int64       `json:"price"`
+	Stock      int32       `json:"stock"`
+	Attributes []byte      `json:"attributes"`
+	WeightGram pgtype.Int4 `json:"weight_gram"`
+	LengthCm   pgtype.Int4 `json:"length_cm"`
+	WidthCm    pgtype.Int4 `json:"width_cm"`
+	HeightCm   pgtype.Int4 `json:"height_cm"`
+}
+
+func (q *Queries) UpdateProductVariant(ctx context.Context, arg UpdateProductVariantParams) (ProductVariant, error) {
+	row := q.db.QueryRow(ctx, updateProductVariant,
+		arg.ID,
+		arg.ProductID,
+		arg.Sku,
+		arg.Price,
+		arg.Stock,
+		arg.Attributes,
+		arg.WeightGram,
+		arg.LengthCm,
+		arg.WidthCm,
+		arg.HeightCm,
+	)
+	var i ProductVariant
+	err := row.Scan(
+		&i.ID,
+		&i.ProductID,
+		&i.Sku,
+		&i.Price,
+		&i.Stock,
+		&i.Attributes,
+		&i.WeightGram,
+		&i.LengthCm,
+		&i.WidthCm,
+		&i.HeightCm,
+	)
+	return i, err
+}
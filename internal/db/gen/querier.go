@@ -12,126 +12,256 @@ import (
 
 type Querier interface {
 	AddFavorite(ctx context.Context, arg AddFavoriteParams) error
+	AdvanceUserTOTPStep(ctx context.Context, arg AdvanceUserTOTPStepParams) (int64, error)
+	// Keeps the order and its totals for bookkeeping but drops the recipient's
+	// name, phone, street address, and notes, and points it at the placeholder.
+	AnonymizeOrdersByUser(ctx context.Context, arg AnonymizeOrdersByUserParams) (int64, error)
+	AnonymizeVoucherUsagesByUser(ctx context.Context, arg AnonymizeVoucherUsagesByUserParams) error
 	CheckFavorite(ctx context.Context, arg CheckFavoriteParams) (int32, error)
 	CheckUserReview(ctx context.Context, arg CheckUserReviewParams) (pgtype.UUID, error)
+	// tenant_guard:ignore provider callbacks are deduplicated before their tenant is known
+	// Takes over a claim left unfinished for stale_after_sec; returns no row when
+	// the callback was processed or another claim is still live.
+	ClaimInboundWebhook(ctx context.Context, arg ClaimInboundWebhookParams) (pgtype.Timestamptz, error)
+	// tenant_guard:ignore provider callbacks are deduplicated before their tenant is known
+	CompleteInboundWebhook(ctx context.Context, arg CompleteInboundWebhookParams) error
 	CountAddressesByUser(ctx context.Context, userID pgtype.UUID) (int64, error)
+	CountAuditLogs(ctx context.Context) (int64, error)
+	CountBundlesUsingComponent(ctx context.Context, componentVariantID pgtype.UUID) (int64, error)
+	CountDomainEventsByTopic(ctx context.Context, topic string) (int64, error)
+	CountOpenOrdersByUser(ctx context.Context, userID pgtype.UUID) (int64, error)
+	CountOrdersAdmin(ctx context.Context, status pgtype.Text) (int64, error)
 	CountOrdersForUser(ctx context.Context, userID pgtype.UUID) (int64, error)
+	CountPriceHistoryByProduct(ctx context.Context, arg CountPriceHistoryByProductParams) (int64, error)
 	CountProductsPublic(ctx context.Context, arg CountProductsPublicParams) (int64, error)
+	// With exclude_released, usages tied to canceled orders or refunded payments
+	// no longer count against the per-user limit.
 	CountVoucherUsageByUser(ctx context.Context, arg CountVoucherUsageByUserParams) (int64, error)
 	CountWebhookDeliveries(ctx context.Context, arg CountWebhookDeliveriesParams) (int64, error)
+	CountWebhookEndpoints(ctx context.Context) (int64, error)
 	CreateAddress(ctx context.Context, arg CreateAddressParams) (Address, error)
 	CreateCart(ctx context.Context, arg CreateCartParams) (Cart, error)
 	CreateCartItem(ctx context.Context, arg CreateCartItemParams) (CartItem, error)
+	CreateCartItems(ctx context.Context, arg []CreateCartItemsParams) *CreateCartItemsBatchResults
 	CreateOrder(ctx context.Context, arg CreateOrderParams) (Order, error)
 	CreateOrderItem(ctx context.Context, arg CreateOrderItemParams) error
+	CreateOrderItems(ctx context.Context, arg []CreateOrderItemsParams) *CreateOrderItemsBatchResults
 	CreatePasswordReset(ctx context.Context, arg CreatePasswordResetParams) (PasswordReset, error)
 	CreatePayment(ctx context.Context, arg CreatePaymentParams) (CreatePaymentRow, error)
+	CreateProductImage(ctx context.Context, arg CreateProductImageParams) (CreateProductImageRow, error)
+	CreateProductVariant(ctx context.Context, arg CreateProductVariantParams) (ProductVariant, error)
 	CreateReview(ctx context.Context, arg CreateReviewParams) (Review, error)
 	CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error)
 	CreateShipment(ctx context.Context, arg CreateShipmentParams) (CreateShipmentRow, error)
 	CreateUser(ctx context.Context, arg CreateUserParams) (CreateUserRow, error)
 	CreateVoucher(ctx context.Context, arg CreateVoucherParams) (Voucher, error)
+	CreateVoucherBatch(ctx context.Context, arg CreateVoucherBatchParams) ([]string, error)
 	CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error)
+	DeactivateUser(ctx context.Context, id pgtype.UUID) (pgtype.Timestamptz, error)
 	DecrementVariantStock(ctx context.Context, arg DecrementVariantStockParams) error
+	DeferDelivery(ctx context.Context, arg DeferDeliveryParams) error
 	DeleteAddress(ctx context.Context, arg DeleteAddressParams) error
+	DeleteBackupCodes(ctx context.Context, userID pgtype.UUID) error
+	DeleteBundleComponentsExcept(ctx context.Context, arg DeleteBundleComponentsExceptParams) error
 	DeleteCartItem(ctx context.Context, arg DeleteCartItemParams) error
+	DeleteCartsByUser(ctx context.Context, userID pgtype.UUID) error
 	DeleteDlqByDelivery(ctx context.Context, deliveryID pgtype.UUID) error
 	DeletePasswordReset(ctx context.Context, id pgtype.UUID) error
 	DeletePasswordResetsByUser(ctx context.Context, userID pgtype.UUID) error
 	DeleteReview(ctx context.Context, arg DeleteReviewParams) error
 	DeleteSessionByToken(ctx context.Context, refreshToken string) error
 	DeleteSessionsByUser(ctx context.Context, userID pgtype.UUID) error
+	DeleteTenantSetting(ctx context.Context, arg DeleteTenantSettingParams) (int64, error)
+	DeleteUser(ctx context.Context, id pgtype.UUID) error
+	DeleteUserTOTP(ctx context.Context, userID pgtype.UUID) error
+	DeleteVariantBundle(ctx context.Context, variantID pgtype.UUID) error
 	DeleteWebhookEndpoint(ctx context.Context, id pgtype.UUID) error
 	DequeueDueDeliveries(ctx context.Context, limit int32) ([]WebhookDelivery, error)
+	// Only an active endpoint is disabled, so concurrent callers agree on which
+	// of them did it.
+	DisableWebhookEndpoint(ctx context.Context, arg DisableWebhookEndpointParams) (WebhookEndpoint, error)
+	EnableUserTOTP(ctx context.Context, arg EnableUserTOTPParams) (int64, error)
+	EnableWebhookEndpoint(ctx context.Context, id pgtype.UUID) (WebhookEndpoint, error)
+	// The sequence bump and the insert share one statement, so a duplicate
+	// delivery rolls the bump back and leaves no gap.
 	EnqueueDelivery(ctx context.Context, arg EnqueueDeliveryParams) (WebhookDelivery, error)
 	FindCartItemByProductVariant(ctx context.Context, arg FindCartItemByProductVariantParams) (CartItem, error)
 	GetActiveCartByAnon(ctx context.Context, anonID pgtype.Text) (Cart, error)
 	GetActiveCartByUser(ctx context.Context, userID pgtype.UUID) (Cart, error)
 	GetAddressByID(ctx context.Context, arg GetAddressByIDParams) (Address, error)
+	GetAnalyticsViewRefreshedAt(ctx context.Context, viewName string) (pgtype.Timestamptz, error)
 	GetBrandByID(ctx context.Context, id pgtype.UUID) (GetBrandByIDRow, error)
 	GetBrandBySlug(ctx context.Context, slug string) (GetBrandBySlugRow, error)
 	GetCartByID(ctx context.Context, id pgtype.UUID) (Cart, error)
 	GetCartItemByID(ctx context.Context, id pgtype.UUID) (CartItem, error)
+	GetCartItemTotals(ctx context.Context, cartID pgtype.UUID) (GetCartItemTotalsRow, error)
 	GetCategoryByID(ctx context.Context, id pgtype.UUID) (GetCategoryByIDRow, error)
 	GetCategoryBySlug(ctx context.Context, slug string) (GetCategoryBySlugRow, error)
+	GetCategoryDefaultSort(ctx context.Context, slug string) (string, error)
 	GetDeliveryByID(ctx context.Context, id pgtype.UUID) (WebhookDelivery, error)
 	GetDomainEvent(ctx context.Context, id pgtype.UUID) (GetDomainEventRow, error)
+	// tenant_guard:ignore provider callbacks are deduplicated before their tenant is known
+	GetInboundWebhookProcessedAt(ctx context.Context, arg GetInboundWebhookProcessedAtParams) (pgtype.Timestamptz, error)
 	GetLatestPaymentByOrder(ctx context.Context, orderID pgtype.UUID) (GetLatestPaymentByOrderRow, error)
 	GetOrderByID(ctx context.Context, id pgtype.UUID) (Order, error)
 	GetOrderByIDForUser(ctx context.Context, arg GetOrderByIDForUserParams) (Order, error)
 	GetOrderByTenant(ctx context.Context, arg GetOrderByTenantParams) (GetOrderByTenantRow, error)
 	GetOrderStatus(ctx context.Context, id pgtype.UUID) (OrderStatus, error)
 	GetPasswordResetByToken(ctx context.Context, token string) (PasswordReset, error)
+	// Earlier deliveries of the same aggregate to the endpoint that have neither
+	// been delivered nor dead-lettered.
+	GetPendingPredecessors(ctx context.Context, arg GetPendingPredecessorsParams) (GetPendingPredecessorsRow, error)
 	GetProductBySlug(ctx context.Context, slug string) (GetProductBySlugRow, error)
 	GetProductDetailByTenant(ctx context.Context, arg GetProductDetailByTenantParams) (GetProductDetailByTenantRow, error)
 	GetProductForCart(ctx context.Context, id pgtype.UUID) (GetProductForCartRow, error)
+	GetProductOptionSchema(ctx context.Context, id pgtype.UUID) (GetProductOptionSchemaRow, error)
 	GetProductReviews(ctx context.Context, arg GetProductReviewsParams) ([]Review, error)
 	GetReviewStats(ctx context.Context, arg GetReviewStatsParams) (GetReviewStatsRow, error)
 	GetSalesDailyRange(ctx context.Context, arg GetSalesDailyRangeParams) ([]GetSalesDailyRangeRow, error)
 	GetSessionByToken(ctx context.Context, refreshToken string) (Session, error)
 	GetShipmentByOrder(ctx context.Context, orderID pgtype.UUID) (GetShipmentByOrderRow, error)
+	GetTenantSetting(ctx context.Context, arg GetTenantSettingParams) ([]byte, error)
 	GetTopProducts(ctx context.Context, arg GetTopProductsParams) ([]MvTopProduct, error)
 	GetUserByEmail(ctx context.Context, email string) (User, error)
 	GetUserByID(ctx context.Context, id pgtype.UUID) (GetUserByIDRow, error)
+	GetUserTOTP(ctx context.Context, userID pgtype.UUID) (UserTotp, error)
 	GetVariantForCart(ctx context.Context, id pgtype.UUID) (GetVariantForCartRow, error)
 	GetVoucherByCode(ctx context.Context, code string) (Voucher, error)
 	GetVoucherByCodeForUpdate(ctx context.Context, code string) (Voucher, error)
 	GetVoucherByTenant(ctx context.Context, arg GetVoucherByTenantParams) (GetVoucherByTenantRow, error)
+	GetVoucherPerformance(ctx context.Context, arg GetVoucherPerformanceParams) ([]GetVoucherPerformanceRow, error)
 	GetVoucherUsageByOrder(ctx context.Context, arg GetVoucherUsageByOrderParams) (VoucherUsage, error)
 	GetWebhookEndpoint(ctx context.Context, id pgtype.UUID) (WebhookEndpoint, error)
 	IncreaseVoucherUsedCount(ctx context.Context, id pgtype.UUID) error
 	IncrementVoucherUsageByCode(ctx context.Context, code string) error
 	InsertAuditLog(ctx context.Context, arg InsertAuditLogParams) (InsertAuditLogRow, error)
+	InsertBackupCodes(ctx context.Context, arg InsertBackupCodesParams) error
+	InsertDeliveryAttempt(ctx context.Context, arg InsertDeliveryAttemptParams) error
 	InsertDomainEvent(ctx context.Context, arg InsertDomainEventParams) (InsertDomainEventRow, error)
 	InsertPaymentEvent(ctx context.Context, arg InsertPaymentEventParams) error
 	InsertShipmentEvent(ctx context.Context, arg InsertShipmentEventParams) (ShipmentEvent, error)
 	InsertVoucherUsage(ctx context.Context, arg InsertVoucherUsageParams) error
 	InsertWebhookDlq(ctx context.Context, arg InsertWebhookDlqParams) (WebhookDlq, error)
+	// Besides exact topics, endpoints may subscribe to '*' or to a prefix such as
+	// 'order.*'; keep in sync with events.MatchTopic.
 	ListActiveEndpointsForTopic(ctx context.Context, topic string) ([]WebhookEndpoint, error)
 	ListAddressesByUser(ctx context.Context, arg ListAddressesByUserParams) ([]Address, error)
 	ListAuditLogs(ctx context.Context, arg ListAuditLogsParams) ([]AuditLog, error)
+	// Pages through the requests a user made that were audited, oldest first.
+	ListAuditLogsByActorAfter(ctx context.Context, arg ListAuditLogsByActorAfterParams) ([]ListAuditLogsByActorAfterRow, error)
 	ListBrands(ctx context.Context) ([]ListBrandsRow, error)
+	ListBundleComponentsByVariantIDs(ctx context.Context, variantIds []pgtype.UUID) ([]ListBundleComponentsByVariantIDsRow, error)
+	ListCartItemAvailability(ctx context.Context, cartID pgtype.UUID) ([]ListCartItemAvailabilityRow, error)
+	ListCartItemCatalogPrices(ctx context.Context, cartID pgtype.UUID) ([]ListCartItemCatalogPricesRow, error)
+	// Weight and dimensions of each cart line's variant for shipping quotes;
+	// lines without a variant or measurements come back NULL.
+	ListCartItemParcels(ctx context.Context, cartID pgtype.UUID) ([]ListCartItemParcelsRow, error)
 	ListCartItems(ctx context.Context, cartID pgtype.UUID) ([]CartItem, error)
 	ListCategories(ctx context.Context) ([]ListCategoriesRow, error)
+	ListDeliveryAttempts(ctx context.Context, deliveryID pgtype.UUID) ([]WebhookDeliveryAttempt, error)
 	ListDomainEventsByTopic(ctx context.Context, arg ListDomainEventsByTopicParams) ([]ListDomainEventsByTopicRow, error)
 	ListFavorites(ctx context.Context, arg ListFavoritesParams) ([]ListFavoritesRow, error)
 	ListImagesByProduct(ctx context.Context, productID pgtype.UUID) ([]ProductImage, error)
 	ListOrderItemsByOrder(ctx context.Context, orderID pgtype.UUID) ([]OrderItem, error)
+	ListOrderItemsByOrders(ctx context.Context, orderIds []pgtype.UUID) ([]OrderItem, error)
 	ListOrderItemsForStock(ctx context.Context, orderID pgtype.UUID) ([]ListOrderItemsForStockRow, error)
+	ListOrdersAdmin(ctx context.Context, arg ListOrdersAdminParams) ([]Order, error)
 	ListOrdersByTenant(ctx context.Context, arg ListOrdersByTenantParams) ([]ListOrdersByTenantRow, error)
+	// Pages through the orders created in [from_at, to_at) with one of the given
+	// statuses oldest first, optionally for one tenant, resuming after the given
+	// order, so an accounting export can stream any range.
+	ListOrdersForExportAfter(ctx context.Context, arg ListOrdersForExportAfterParams) ([]Order, error)
 	ListOrdersForUser(ctx context.Context, arg ListOrdersForUserParams) ([]Order, error)
+	// Pages through a user's orders oldest first, resuming after the given
+	// order, so an export can stream any number of them.
+	ListOrdersForUserAfter(ctx context.Context, arg ListOrdersForUserAfterParams) ([]Order, error)
+	// The price each product had before its latest own price change since the
+	// given time; products whose price has not changed since are left out.
+	ListPreviousProductPrices(ctx context.Context, arg ListPreviousProductPricesParams) ([]ListPreviousProductPricesRow, error)
+	// Price changes of the product and its variants, newest first.
+	ListPriceHistoryByProduct(ctx context.Context, arg ListPriceHistoryByProductParams) ([]PriceHistory, error)
+	// The product's latest own price changes, newest first.
+	ListProductPriceTrend(ctx context.Context, arg ListProductPriceTrendParams) ([]ListProductPriceTrendRow, error)
+	ListProductScopesByIDs(ctx context.Context, productIds []pgtype.UUID) ([]ListProductScopesByIDsRow, error)
+	ListProductTranslations(ctx context.Context, arg ListProductTranslationsParams) ([]ListProductTranslationsRow, error)
 	ListProductsByTenant(ctx context.Context, arg ListProductsByTenantParams) ([]ListProductsByTenantRow, error)
 	ListProductsPublic(ctx context.Context, arg ListProductsPublicParams) ([]ListProductsPublicRow, error)
+	// Products sharing any recommendation signal with the given one: bought in
+	// the same orders, same brand, same category, or priced within the band.
+	// The strongest candidates come first; the caller ranks them.
+	ListRecommendationCandidates(ctx context.Context, arg ListRecommendationCandidatesParams) ([]ListRecommendationCandidatesRow, error)
 	ListRelatedByCategory(ctx context.Context, arg ListRelatedByCategoryParams) ([]ListRelatedByCategoryRow, error)
+	ListReviewsByUser(ctx context.Context, userID pgtype.UUID) ([]ListReviewsByUserRow, error)
 	ListShipmentEvents(ctx context.Context, shipmentID pgtype.UUID) ([]ShipmentEvent, error)
 	ListSpecsByProduct(ctx context.Context, productID pgtype.UUID) ([]ProductSpec, error)
+	ListTopProductSlugs(ctx context.Context, limitCount int32) ([]string, error)
+	ListUserEmailPreferences(ctx context.Context, userID pgtype.UUID) ([]UserEmailPreference, error)
+	ListUsersDueForPurge(ctx context.Context, arg ListUsersDueForPurgeParams) ([]pgtype.UUID, error)
 	ListVariantsByProduct(ctx context.Context, productID pgtype.UUID) ([]ProductVariant, error)
 	ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeliveriesParams) ([]ListWebhookDeliveriesRow, error)
 	ListWebhookEndpoints(ctx context.Context, arg ListWebhookEndpointsParams) ([]WebhookEndpoint, error)
+	LockUserForPurge(ctx context.Context, id pgtype.UUID) (pgtype.UUID, error)
+	MarkAnalyticsViewRefreshed(ctx context.Context, arg MarkAnalyticsViewRefreshedParams) error
+	MarkCartChanged(ctx context.Context, arg MarkCartChangedParams) error
 	MarkDelivered(ctx context.Context, arg MarkDeliveredParams) error
 	MarkDelivering(ctx context.Context, id pgtype.UUID) error
 	MarkFailedWithBackoff(ctx context.Context, arg MarkFailedWithBackoffParams) error
 	MarkPasswordResetUsed(ctx context.Context, id pgtype.UUID) error
 	MoveToDLQ(ctx context.Context, arg MoveToDLQParams) error
+	PruneDeliveryAttempts(ctx context.Context, arg PruneDeliveryAttemptsParams) error
+	// Deletes up to batch_size attempts older than the cutoff, keeping the history
+	// of deliveries that are still being retried.
+	PurgeDeliveryAttempts(ctx context.Context, arg PurgeDeliveryAttemptsParams) (int64, error)
+	// Deletes up to batch_size events older than the cutoff. Deleting an event
+	// cascades to its deliveries, so events any delivery still references are
+	// kept; they become eligible once their deliveries have been purged.
+	PurgeDomainEvents(ctx context.Context, arg PurgeDomainEventsParams) (int64, error)
+	// tenant_guard:ignore provider callbacks are deduplicated before their tenant is known
+	// Deletes up to batch_size processed callbacks older than the cutoff.
+	// Retries arriving after that are processed again.
+	PurgeInboundWebhookEvents(ctx context.Context, arg PurgeInboundWebhookEventsParams) (int64, error)
+	// Deletes up to batch_size deliveries in the given terminal status that have
+	// not changed since before the cutoff. Attempts and DLQ rows cascade.
+	PurgeWebhookDeliveries(ctx context.Context, arg PurgeWebhookDeliveriesParams) (int64, error)
+	ReactivateUser(ctx context.Context, id pgtype.UUID) error
+	RecordEndpointFailure(ctx context.Context, id pgtype.UUID) (int32, error)
+	RefreshProductCopurchase(ctx context.Context) error
 	RefreshSalesDaily(ctx context.Context) error
 	RefreshTopProducts(ctx context.Context) error
+	// tenant_guard:ignore provider callbacks are deduplicated before their tenant is known
+	ReleaseInboundWebhook(ctx context.Context, arg ReleaseInboundWebhookParams) error
+	// Deletes the order's voucher usage and gives the use back to the voucher.
+	ReleaseVoucherUsageByOrder(ctx context.Context, orderID pgtype.UUID) (int64, error)
 	RemoveFavorite(ctx context.Context, arg RemoveFavoriteParams) error
+	RepriceCartItem(ctx context.Context, arg RepriceCartItemParams) error
 	ResetDeliveryForReplay(ctx context.Context, id pgtype.UUID) (WebhookDelivery, error)
+	ResetEndpointFailures(ctx context.Context, id pgtype.UUID) error
 	RotateSessionToken(ctx context.Context, arg RotateSessionTokenParams) (Session, error)
+	SetProductDefaultVariant(ctx context.Context, arg SetProductDefaultVariantParams) error
 	TouchCart(ctx context.Context, arg TouchCartParams) error
 	TransferCartToUser(ctx context.Context, arg TransferCartToUserParams) error
 	UnsetDefaultAddresses(ctx context.Context, arg UnsetDefaultAddressesParams) error
 	UpdateAddress(ctx context.Context, arg UpdateAddressParams) (Address, error)
 	UpdateCartItemQty(ctx context.Context, arg UpdateCartItemQtyParams) (CartItem, error)
-	UpdateCartVoucher(ctx context.Context, arg UpdateCartVoucherParams) error
+	UpdateCartItemsQty(ctx context.Context, arg []UpdateCartItemsQtyParams) *UpdateCartItemsQtyBatchResults
+	UpdateCartVoucher(ctx context.Context, arg UpdateCartVoucherParams) (int64, error)
 	UpdateOrderStatus(ctx context.Context, arg UpdateOrderStatusParams) error
 	UpdateOrderStatusIfAllowed(ctx context.Context, arg UpdateOrderStatusIfAllowedParams) (pgtype.UUID, error)
 	UpdatePaymentStatus(ctx context.Context, arg UpdatePaymentStatusParams) error
+	UpdateProductAvailability(ctx context.Context, arg UpdateProductAvailabilityParams) (string, error)
+	UpdateProductOptionSchema(ctx context.Context, arg UpdateProductOptionSchemaParams) error
+	UpdateProductVariant(ctx context.Context, arg UpdateProductVariantParams) (ProductVariant, error)
 	UpdateShipmentStatus(ctx context.Context, arg UpdateShipmentStatusParams) (pgtype.UUID, error)
 	UpdateUserPassword(ctx context.Context, arg UpdateUserPasswordParams) (UpdateUserPasswordRow, error)
 	UpdateUserProfile(ctx context.Context, arg UpdateUserProfileParams) (UpdateUserProfileRow, error)
 	UpdateVoucher(ctx context.Context, arg UpdateVoucherParams) (Voucher, error)
 	UpdateWebhookEndpoint(ctx context.Context, arg UpdateWebhookEndpointParams) (WebhookEndpoint, error)
+	UpsertBundleComponents(ctx context.Context, arg UpsertBundleComponentsParams) error
+	UpsertPendingUserTOTP(ctx context.Context, arg UpsertPendingUserTOTPParams) (int64, error)
+	UpsertTenantSetting(ctx context.Context, arg UpsertTenantSettingParams) ([]byte, error)
+	UpsertUserEmailPreference(ctx context.Context, arg UpsertUserEmailPreferenceParams) error
+	UpsertVariantBundle(ctx context.Context, arg UpsertVariantBundleParams) error
+	UseBackupCode(ctx context.Context, arg UseBackupCodeParams) (int64, error)
 	UsePasswordReset(ctx context.Context, token string) error
 }
 
@@ -174,3 +174,49 @@ func (q *Queries) GetReviewStats(ctx context.Context, arg GetReviewStatsParams)
 	)
 	return i, err
 }
+
+const listReviewsByUser = `-- name: ListReviewsByUser :many
+SELECT r.id, r.product_id, p.slug AS product_slug, r.rating, r.comment, r.created_at, r.updated_at
+FROM reviews r
+LEFT JOIN products p ON p.id = r.product_id
+WHERE r.user_id = $1
+ORDER BY r.created_at, r.id
+`
+
+type ListReviewsByUserRow struct {
+	ID          pgtype.UUID        `json:"id"`
+	ProductID   pgtype.UUID        `json:"product_id"`
+	ProductSlug pgtype.Text        `json:"product_slug"`
+	Rating      int32              `json:"rating"`
+	Comment     pgtype.Text        `json:"comment"`
+	CreatedAt   pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt   pgtype.Timestamptz `json:"updated_at"`
+}
+
+func (q *Queries) ListReviewsByUser(ctx context.Context, userID pgtype.UUID) ([]ListReviewsByUserRow, error) {
+	rows, err := q.db.Query(ctx, listReviewsByUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListReviewsByUserRow
+	for rows.Next() {
+		var i ListReviewsByUserRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.ProductID,
+			&i.ProductSlug,
+			&i.Rating,
+			&i.Comment,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
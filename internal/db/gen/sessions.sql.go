@@ -12,9 +12,9 @@ import (
 )
 
 const createSession = `-- name: CreateSession :one
-INSERT INTO sessions (user_id, refresh_token, user_agent, ip, expires_at)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, user_id, refresh_token, user_agent, ip, expires_at, created_at
+INSERT INTO sessions (user_id, refresh_token, user_agent, ip, expires_at, audience)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, user_id, refresh_token, user_agent, ip, expires_at, created_at, audience
 `
 
 type CreateSessionParams struct {
@@ -23,6 +23,7 @@ type CreateSessionParams struct {
 	UserAgent    pgtype.Text        `json:"user_agent"`
 	Ip           pgtype.Text        `json:"ip"`
 	ExpiresAt    pgtype.Timestamptz `json:"expires_at"`
+	Audience     pgtype.Text        `json:"audience"`
 }
 
 func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (Session, error) {
@@ -32,6 +33,7 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		arg.UserAgent,
 		arg.Ip,
 		arg.ExpiresAt,
+		arg.Audience,
 	)
 	var i Session
 	err := row.Scan(
@@ -42,6 +44,7 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) (S
 		&i.Ip,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.Audience,
 	)
 	return i, err
 }
@@ -67,7 +70,7 @@ func (q *Queries) DeleteSessionsByUser(ctx context.Context, userID pgtype.UUID)
 }
 
 const getSessionByToken = `-- name: GetSessionByToken :one
-SELECT id, user_id, refresh_token, user_agent, ip, expires_at, created_at
+SELECT id, user_id, refresh_token, user_agent, ip, expires_at, created_at, audience
 FROM sessions
 WHERE refresh_token = $1
 LIMIT 1
@@ -84,6 +87,7 @@ func (q *Queries) GetSessionByToken(ctx context.Context, refreshToken string) (S
 		&i.Ip,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.Audience,
 	)
 	return i, err
 }
@@ -93,7 +97,7 @@ UPDATE sessions
 SET refresh_token = $2,
     expires_at    = $3
 WHERE id = $1
-RETURNING id, user_id, refresh_token, user_agent, ip, expires_at, created_at
+RETURNING id, user_id, refresh_token, user_agent, ip, expires_at, created_at, audience
 `
 
 type RotateSessionTokenParams struct {
@@ -113,6 +117,7 @@ func (q *Queries) RotateSessionToken(ctx context.Context, arg RotateSessionToken
 		&i.Ip,
 		&i.ExpiresAt,
 		&i.CreatedAt,
+		&i.Audience,
 	)
 	return i, err
 }
@@ -11,6 +11,62 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+const anonymizeOrdersByUser = `-- name: AnonymizeOrdersByUser :execrows
+UPDATE orders
+SET user_id          = $1,
+    shipping_address = CASE
+        WHEN shipping_address IS NULL THEN NULL
+        ELSE shipping_address - 'receiverName' - 'phone' - 'addressLine1' - 'addressLine2'
+    END,
+    notes            = NULL
+WHERE user_id = $2
+`
+
+type AnonymizeOrdersByUserParams struct {
+	Placeholder pgtype.UUID `json:"placeholder"`
+	UserID      pgtype.UUID `json:"user_id"`
+}
+
+// Keeps the order and its totals for bookkeeping but drops the recipient's
+// name, phone, street address, and notes, and points it at the placeholder.
+func (q *Queries) AnonymizeOrdersByUser(ctx context.Context, arg AnonymizeOrdersByUserParams) (int64, error) {
+	result, err := q.db.Exec(ctx, anonymizeOrdersByUser, arg.Placeholder, arg.UserID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const anonymizeVoucherUsagesByUser = `-- name: AnonymizeVoucherUsagesByUser :exec
+UPDATE voucher_usages
+SET user_id = $1
+WHERE user_id = $2
+`
+
+type AnonymizeVoucherUsagesByUserParams struct {
+	Placeholder pgtype.UUID `json:"placeholder"`
+	UserID      pgtype.UUID `json:"user_id"`
+}
+
+func (q *Queries) AnonymizeVoucherUsagesByUser(ctx context.Context, arg AnonymizeVoucherUsagesByUserParams) error {
+	_, err := q.db.Exec(ctx, anonymizeVoucherUsagesByUser, arg.Placeholder, arg.UserID)
+	return err
+}
+
+const countOpenOrdersByUser = `-- name: CountOpenOrdersByUser :one
+SELECT count(*)
+FROM orders
+WHERE user_id = $1
+  AND status NOT IN ('DELIVERED', 'CANCELED')
+`
+
+func (q *Queries) CountOpenOrdersByUser(ctx context.Context, userID pgtype.UUID) (int64, error) {
+	row := q.db.QueryRow(ctx, countOpenOrdersByUser, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createUser = `-- name: CreateUser :one
 INSERT INTO users (name, email, password_hash)
 VALUES ($1, $2, $3)
@@ -46,8 +102,33 @@ func (q *Queries) CreateUser(ctx context.Context, arg CreateUserParams) (CreateU
 	return i, err
 }
 
+const deactivateUser = `-- name: DeactivateUser :one
+UPDATE users
+SET deactivated_at = COALESCE(deactivated_at, now()),
+    updated_at     = now()
+WHERE id = $1
+RETURNING deactivated_at
+`
+
+func (q *Queries) DeactivateUser(ctx context.Context, id pgtype.UUID) (pgtype.Timestamptz, error) {
+	row := q.db.QueryRow(ctx, deactivateUser, id)
+	var deactivated_at pgtype.Timestamptz
+	err := row.Scan(&deactivated_at)
+	return deactivated_at, err
+}
+
+const deleteUser = `-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = $1
+`
+
+func (q *Queries) DeleteUser(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, deleteUser, id)
+	return err
+}
+
 const getUserByEmail = `-- name: GetUserByEmail :one
-SELECT id, name, email, password_hash, roles, created_at, updated_at
+SELECT id, name, email, password_hash, roles, created_at, updated_at, deactivated_at
 FROM users
 WHERE email = $1
 LIMIT 1
@@ -64,24 +145,26 @@ func (q *Queries) GetUserByEmail(ctx context.Context, email string) (User, error
 		&i.Roles,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeactivatedAt,
 	)
 	return i, err
 }
 
 const getUserByID = `-- name: GetUserByID :one
-SELECT id, name, email, roles, created_at, updated_at
+SELECT id, name, email, roles, created_at, updated_at, deactivated_at
 FROM users
 WHERE id = $1
 LIMIT 1
 `
 
 type GetUserByIDRow struct {
-	ID        pgtype.UUID        `json:"id"`
-	Name      string             `json:"name"`
-	Email     string             `json:"email"`
-	Roles     []string           `json:"roles"`
-	CreatedAt pgtype.Timestamptz `json:"created_at"`
-	UpdatedAt pgtype.Timestamptz `json:"updated_at"`
+	ID            pgtype.UUID        `json:"id"`
+	Name          string             `json:"name"`
+	Email         string             `json:"email"`
+	Roles         []string           `json:"roles"`
+	CreatedAt     pgtype.Timestamptz `json:"created_at"`
+	UpdatedAt     pgtype.Timestamptz `json:"updated_at"`
+	DeactivatedAt pgtype.Timestamptz `json:"deactivated_at"`
 }
 
 func (q *Queries) GetUserByID(ctx context.Context, id pgtype.UUID) (GetUserByIDRow, error) {
@@ -94,10 +177,75 @@ func (q *Queries) GetUserByID(ctx context.Context, id pgtype.UUID) (GetUserByIDR
 		&i.Roles,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.DeactivatedAt,
 	)
 	return i, err
 }
 
+const listUsersDueForPurge = `-- name: ListUsersDueForPurge :many
+SELECT id
+FROM users
+WHERE deactivated_at IS NOT NULL
+  AND deactivated_at < $1
+  AND NOT EXISTS (
+      SELECT 1 FROM orders o
+      WHERE o.user_id = users.id
+        AND o.status NOT IN ('DELIVERED', 'CANCELED')
+  )
+ORDER BY deactivated_at
+LIMIT $2
+`
+
+type ListUsersDueForPurgeParams struct {
+	Before    pgtype.Timestamptz `json:"before"`
+	BatchSize int32              `json:"batch_size"`
+}
+
+func (q *Queries) ListUsersDueForPurge(ctx context.Context, arg ListUsersDueForPurgeParams) ([]pgtype.UUID, error) {
+	rows, err := q.db.Query(ctx, listUsersDueForPurge, arg.Before, arg.BatchSize)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []pgtype.UUID
+	for rows.Next() {
+		var id pgtype.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		items = append(items, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const lockUserForPurge = `-- name: LockUserForPurge :one
+SELECT id
+FROM users
+WHERE id = $1
+FOR UPDATE
+`
+
+func (q *Queries) LockUserForPurge(ctx context.Context, id pgtype.UUID) (pgtype.UUID, error) {
+	row := q.db.QueryRow(ctx, lockUserForPurge, id)
+	err := row.Scan(&id)
+	return id, err
+}
+
+const reactivateUser = `-- name: ReactivateUser :exec
+UPDATE users
+SET deactivated_at = NULL,
+    updated_at     = now()
+WHERE id = $1
+`
+
+func (q *Queries) ReactivateUser(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, reactivateUser, id)
+	return err
+}
+
 const updateUserPassword = `-- name: UpdateUserPassword :one
 UPDATE users
 SET password_hash = $2,
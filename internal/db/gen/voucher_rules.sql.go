@@ -13,18 +13,29 @@ import (
 
 const countVoucherUsageByUser = `-- name: CountVoucherUsageByUser :one
 SELECT COUNT(*)
-FROM voucher_usages
-WHERE voucher_id = $1
-  AND user_id = $2
+FROM voucher_usages vu
+LEFT JOIN orders o ON o.id = vu.order_id
+WHERE vu.voucher_id = $1
+  AND vu.user_id = $2
+  AND NOT (
+    $3::boolean
+    AND (
+      o.status = 'CANCELED'
+      OR EXISTS (SELECT 1 FROM payments p WHERE p.order_id = vu.order_id AND p.status = 'REFUNDED')
+    )
+  )
 `
 
 type CountVoucherUsageByUserParams struct {
-	VoucherID pgtype.UUID `json:"voucher_id"`
-	UserID    pgtype.UUID `json:"user_id"`
+	VoucherID       pgtype.UUID `json:"voucher_id"`
+	UserID          pgtype.UUID `json:"user_id"`
+	ExcludeReleased bool        `json:"exclude_released"`
 }
 
+// With exclude_released, usages tied to canceled orders or refunded payments
+// no longer count against the per-user limit.
 func (q *Queries) CountVoucherUsageByUser(ctx context.Context, arg CountVoucherUsageByUserParams) (int64, error) {
-	row := q.db.QueryRow(ctx, countVoucherUsageByUser, arg.VoucherID, arg.UserID)
+	row := q.db.QueryRow(ctx, countVoucherUsageByUser, arg.VoucherID, arg.UserID, arg.ExcludeReleased)
 	var count int64
 	err := row.Scan(&count)
 	return count, err
@@ -95,6 +106,66 @@ func (q *Queries) CreateVoucher(ctx context.Context, arg CreateVoucherParams) (V
 	return i, err
 }
 
+const createVoucherBatch = `-- name: CreateVoucherBatch :many
+INSERT INTO vouchers (code, value, kind, percent_bps, min_spend, usage_limit, valid_from, valid_to, product_ids, category_ids, brand_ids, combinable, priority, per_user_limit)
+SELECT code, $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13
+FROM unnest($14::text[]) AS code
+ON CONFLICT (code) DO NOTHING
+RETURNING code
+`
+
+type CreateVoucherBatchParams struct {
+	Value        int64              `json:"value"`
+	Kind         DiscountKind       `json:"kind"`
+	PercentBps   pgtype.Int4        `json:"percent_bps"`
+	MinSpend     int64              `json:"min_spend"`
+	UsageLimit   pgtype.Int4        `json:"usage_limit"`
+	ValidFrom    pgtype.Timestamptz `json:"valid_from"`
+	ValidTo      pgtype.Timestamptz `json:"valid_to"`
+	ProductIds   []pgtype.UUID      `json:"product_ids"`
+	CategoryIds  []pgtype.UUID      `json:"category_ids"`
+	BrandIds     []pgtype.UUID      `json:"brand_ids"`
+	Combinable   bool               `json:"combinable"`
+	Priority     int32              `json:"priority"`
+	PerUserLimit pgtype.Int4        `json:"per_user_limit"`
+	Codes        []string           `json:"codes"`
+}
+
+func (q *Queries) CreateVoucherBatch(ctx context.Context, arg CreateVoucherBatchParams) ([]string, error) {
+	rows, err := q.db.Query(ctx, createVoucherBatch,
+		arg.Value,
+		arg.Kind,
+		arg.PercentBps,
+		arg.MinSpend,
+		arg.UsageLimit,
+		arg.ValidFrom,
+		arg.ValidTo,
+		arg.ProductIds,
+		arg.CategoryIds,
+		arg.BrandIds,
+		arg.Combinable,
+		arg.Priority,
+		arg.PerUserLimit,
+		arg.Codes,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []string
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, err
+		}
+		items = append(items, code)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getVoucherByCodeForUpdate = `-- name: GetVoucherByCodeForUpdate :one
 SELECT id, code, value, min_spend, usage_limit, used_count, valid_from, valid_to, product_ids, category_ids, created_at, updated_at, kind, percent_bps, combinable, priority, per_user_limit, brand_ids, tenant_id
 FROM vouchers
@@ -179,6 +250,28 @@ func (q *Queries) InsertVoucherUsage(ctx context.Context, arg InsertVoucherUsage
 	return err
 }
 
+const releaseVoucherUsageByOrder = `-- name: ReleaseVoucherUsageByOrder :execrows
+WITH released AS (
+    DELETE FROM voucher_usages
+    WHERE order_id = $1
+    RETURNING voucher_id
+)
+UPDATE vouchers v
+SET used_count = GREATEST(v.used_count - r.n, 0),
+    updated_at = now()
+FROM (SELECT voucher_id, COUNT(*) AS n FROM released GROUP BY voucher_id) r
+WHERE v.id = r.voucher_id
+`
+
+// Deletes the order's voucher usage and gives the use back to the voucher.
+func (q *Queries) ReleaseVoucherUsageByOrder(ctx context.Context, orderID pgtype.UUID) (int64, error) {
+	result, err := q.db.Exec(ctx, releaseVoucherUsageByOrder, orderID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
 const updateVoucher = `-- name: UpdateVoucher :one
 UPDATE vouchers
 SET value = $2,
@@ -32,18 +32,39 @@ func (q *Queries) CountWebhookDeliveries(ctx context.Context, arg CountWebhookDe
 	return count, err
 }
 
+const countWebhookEndpoints = `-- name: CountWebhookEndpoints :one
+SELECT COUNT(*)
+FROM webhook_endpoints
+`
+
+func (q *Queries) CountWebhookEndpoints(ctx context.Context) (int64, error) {
+	row := q.db.QueryRow(ctx, countWebhookEndpoints)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
 const createWebhookEndpoint = `-- name: CreateWebhookEndpoint :one
-INSERT INTO webhook_endpoints (name, url, secret, active, topics)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, name, url, secret, active, topics, created_at, updated_at, tenant_id
+INSERT INTO webhook_endpoints (name, url, secret, active, topics, format, ordered, max_payload_bytes, oversize_policy, delivery_mode, retry_statuses, permanent_statuses, custom_header_names, custom_headers)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)
+RETURNING id, name, url, secret, active, topics, created_at, updated_at, tenant_id, format, ordered, max_payload_bytes, oversize_policy, delivery_mode, consecutive_failures, disabled_reason, disabled_at, retry_statuses, permanent_statuses, custom_header_names, custom_headers
 `
 
 type CreateWebhookEndpointParams struct {
-	Name   string   `json:"name"`
-	Url    string   `json:"url"`
-	Secret string   `json:"secret"`
-	Active bool     `json:"active"`
-	Topics []string `json:"topics"`
+	Name              string   `json:"name"`
+	Url               string   `json:"url"`
+	Secret            string   `json:"secret"`
+	Active            bool     `json:"active"`
+	Topics            []string `json:"topics"`
+	Format            string   `json:"format"`
+	Ordered           bool     `json:"ordered"`
+	MaxPayloadBytes   int32    `json:"max_payload_bytes"`
+	OversizePolicy    string   `json:"oversize_policy"`
+	DeliveryMode      string   `json:"delivery_mode"`
+	RetryStatuses     []int32  `json:"retry_statuses"`
+	PermanentStatuses []int32  `json:"permanent_statuses"`
+	CustomHeaderNames []string `json:"custom_header_names"`
+	CustomHeaders     []byte   `json:"custom_headers"`
 }
 
 func (q *Queries) CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEndpointParams) (WebhookEndpoint, error) {
@@ -53,6 +74,15 @@ func (q *Queries) CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEn
 		arg.Secret,
 		arg.Active,
 		arg.Topics,
+		arg.Format,
+		arg.Ordered,
+		arg.MaxPayloadBytes,
+		arg.OversizePolicy,
+		arg.DeliveryMode,
+		arg.RetryStatuses,
+		arg.PermanentStatuses,
+		arg.CustomHeaderNames,
+		arg.CustomHeaders,
 	)
 	var i WebhookEndpoint
 	err := row.Scan(
@@ -65,10 +95,39 @@ func (q *Queries) CreateWebhookEndpoint(ctx context.Context, arg CreateWebhookEn
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.TenantID,
+		&i.Format,
+		&i.Ordered,
+		&i.MaxPayloadBytes,
+		&i.OversizePolicy,
+		&i.DeliveryMode,
+		&i.ConsecutiveFailures,
+		&i.DisabledReason,
+		&i.DisabledAt,
+		&i.RetryStatuses,
+		&i.PermanentStatuses,
+		&i.CustomHeaderNames,
+		&i.CustomHeaders,
 	)
 	return i, err
 }
 
+const deferDelivery = `-- name: DeferDelivery :exec
+UPDATE webhook_deliveries
+SET next_attempt_at = now() + ($1::int * interval '1 second'),
+    updated_at = now()
+WHERE id = $2
+`
+
+type DeferDeliveryParams struct {
+	DelaySec int32       `json:"delay_sec"`
+	ID       pgtype.UUID `json:"id"`
+}
+
+func (q *Queries) DeferDelivery(ctx context.Context, arg DeferDeliveryParams) error {
+	_, err := q.db.Exec(ctx, deferDelivery, arg.DelaySec, arg.ID)
+	return err
+}
+
 const deleteDlqByDelivery = `-- name: DeleteDlqByDelivery :exec
 DELETE FROM webhook_dlq
 WHERE delivery_id = $1
@@ -90,7 +149,7 @@ func (q *Queries) DeleteWebhookEndpoint(ctx context.Context, id pgtype.UUID) err
 }
 
 const dequeueDueDeliveries = `-- name: DequeueDueDeliveries :many
-SELECT id, endpoint_id, event_id, status, attempt, max_attempt, next_attempt_at, last_error, response_status, response_body, created_at, updated_at, tenant_id
+SELECT id, endpoint_id, event_id, status, attempt, max_attempt, next_attempt_at, last_error, response_status, response_body, created_at, updated_at, tenant_id, aggregate_id, sequence
 FROM webhook_deliveries
 WHERE status IN ('PENDING', 'FAILED')
   AND (next_attempt_at IS NULL OR next_attempt_at <= now())
@@ -121,6 +180,8 @@ func (q *Queries) DequeueDueDeliveries(ctx context.Context, limit int32) ([]Webh
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.TenantID,
+			&i.AggregateID,
+			&i.Sequence,
 		); err != nil {
 			return nil, err
 		}
@@ -132,20 +193,123 @@ func (q *Queries) DequeueDueDeliveries(ctx context.Context, limit int32) ([]Webh
 	return items, nil
 }
 
+const disableWebhookEndpoint = `-- name: DisableWebhookEndpoint :one
+UPDATE webhook_endpoints
+SET active = false,
+    disabled_reason = $1,
+    disabled_at = now(),
+    updated_at = now()
+WHERE id = $2
+  AND active
+RETURNING id, name, url, secret, active, topics, created_at, updated_at, tenant_id, format, ordered, max_payload_bytes, oversize_policy, delivery_mode, consecutive_failures, disabled_reason, disabled_at, retry_statuses, permanent_statuses, custom_header_names, custom_headers
+`
+
+type DisableWebhookEndpointParams struct {
+	Reason pgtype.Text `json:"reason"`
+	ID     pgtype.UUID `json:"id"`
+}
+
+// Only an active endpoint is disabled, so concurrent callers agree on which
+// of them did it.
+func (q *Queries) DisableWebhookEndpoint(ctx context.Context, arg DisableWebhookEndpointParams) (WebhookEndpoint, error) {
+	row := q.db.QueryRow(ctx, disableWebhookEndpoint, arg.Reason, arg.ID)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.Secret,
+		&i.Active,
+		&i.Topics,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TenantID,
+		&i.Format,
+		&i.Ordered,
+		&i.MaxPayloadBytes,
+		&i.OversizePolicy,
+		&i.DeliveryMode,
+		&i.ConsecutiveFailures,
+		&i.DisabledReason,
+		&i.DisabledAt,
+		&i.RetryStatuses,
+		&i.PermanentStatuses,
+		&i.CustomHeaderNames,
+		&i.CustomHeaders,
+	)
+	return i, err
+}
+
+const enableWebhookEndpoint = `-- name: EnableWebhookEndpoint :one
+UPDATE webhook_endpoints
+SET active = true,
+    consecutive_failures = 0,
+    disabled_reason = NULL,
+    disabled_at = NULL,
+    updated_at = now()
+WHERE id = $1
+RETURNING id, name, url, secret, active, topics, created_at, updated_at, tenant_id, format, ordered, max_payload_bytes, oversize_policy, delivery_mode, consecutive_failures, disabled_reason, disabled_at, retry_statuses, permanent_statuses, custom_header_names, custom_headers
+`
+
+func (q *Queries) EnableWebhookEndpoint(ctx context.Context, id pgtype.UUID) (WebhookEndpoint, error) {
+	row := q.db.QueryRow(ctx, enableWebhookEndpoint, id)
+	var i WebhookEndpoint
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.Secret,
+		&i.Active,
+		&i.Topics,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.TenantID,
+		&i.Format,
+		&i.Ordered,
+		&i.MaxPayloadBytes,
+		&i.OversizePolicy,
+		&i.DeliveryMode,
+		&i.ConsecutiveFailures,
+		&i.DisabledReason,
+		&i.DisabledAt,
+		&i.RetryStatuses,
+		&i.PermanentStatuses,
+		&i.CustomHeaderNames,
+		&i.CustomHeaders,
+	)
+	return i, err
+}
+
 const enqueueDelivery = `-- name: EnqueueDelivery :one
-INSERT INTO webhook_deliveries (endpoint_id, event_id, status, max_attempt, next_attempt_at)
-VALUES ($1, $2, 'PENDING', $3, now())
-RETURNING id, endpoint_id, event_id, status, attempt, max_attempt, next_attempt_at, last_error, response_status, response_body, created_at, updated_at, tenant_id
+WITH seq AS (
+  INSERT INTO webhook_sequences (endpoint_id, aggregate_id, last_sequence)
+  VALUES ($1, $4, 1)
+  ON CONFLICT (endpoint_id, aggregate_id)
+  DO UPDATE SET last_sequence = webhook_sequences.last_sequence + 1
+  RETURNING last_sequence
+)
+INSERT INTO webhook_deliveries (endpoint_id, event_id, status, max_attempt, next_attempt_at, aggregate_id, sequence)
+SELECT $1, $2, 'PENDING', $3, now(), $4, seq.last_sequence
+FROM seq
+RETURNING id, endpoint_id, event_id, status, attempt, max_attempt, next_attempt_at, last_error, response_status, response_body, created_at, updated_at, tenant_id, aggregate_id, sequence
 `
 
 type EnqueueDeliveryParams struct {
-	EndpointID pgtype.UUID `json:"endpoint_id"`
-	EventID    pgtype.UUID `json:"event_id"`
-	MaxAttempt int32       `json:"max_attempt"`
+	EndpointID  pgtype.UUID `json:"endpoint_id"`
+	EventID     pgtype.UUID `json:"event_id"`
+	MaxAttempt  int32       `json:"max_attempt"`
+	AggregateID pgtype.UUID `json:"aggregate_id"`
 }
 
+// The sequence bump and the insert share one statement, so a duplicate
+// delivery rolls the bump back and leaves no gap.
 func (q *Queries) EnqueueDelivery(ctx context.Context, arg EnqueueDeliveryParams) (WebhookDelivery, error) {
-	row := q.db.QueryRow(ctx, enqueueDelivery, arg.EndpointID, arg.EventID, arg.MaxAttempt)
+	row := q.db.QueryRow(ctx, enqueueDelivery,
+		arg.EndpointID,
+		arg.EventID,
+		arg.MaxAttempt,
+		arg.AggregateID,
+	)
 	var i WebhookDelivery
 	err := row.Scan(
 		&i.ID,
@@ -161,12 +325,14 @@ func (q *Queries) EnqueueDelivery(ctx context.Context, arg EnqueueDeliveryParams
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.TenantID,
+		&i.AggregateID,
+		&i.Sequence,
 	)
 	return i, err
 }
 
 const getDeliveryByID = `-- name: GetDeliveryByID :one
-SELECT id, endpoint_id, event_id, status, attempt, max_attempt, next_attempt_at, last_error, response_status, response_body, created_at, updated_at, tenant_id
+SELECT id, endpoint_id, event_id, status, attempt, max_attempt, next_attempt_at, last_error, response_status, response_body, created_at, updated_at, tenant_id, aggregate_id, sequence
 FROM webhook_deliveries
 WHERE id = $1
 `
@@ -188,12 +354,44 @@ func (q *Queries) GetDeliveryByID(ctx context.Context, id pgtype.UUID) (WebhookD
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.TenantID,
+		&i.AggregateID,
+		&i.Sequence,
 	)
 	return i, err
 }
 
+const getPendingPredecessors = `-- name: GetPendingPredecessors :one
+SELECT count(*)::bigint AS pending,
+       min(next_attempt_at)::timestamptz AS next_attempt_at
+FROM webhook_deliveries
+WHERE endpoint_id = $1
+  AND aggregate_id = $2
+  AND sequence < $3
+  AND status IN ('PENDING', 'DELIVERING', 'FAILED')
+`
+
+type GetPendingPredecessorsParams struct {
+	EndpointID  pgtype.UUID `json:"endpoint_id"`
+	AggregateID pgtype.UUID `json:"aggregate_id"`
+	Sequence    pgtype.Int8 `json:"sequence"`
+}
+
+type GetPendingPredecessorsRow struct {
+	Pending       int64              `json:"pending"`
+	NextAttemptAt pgtype.Timestamptz `json:"next_attempt_at"`
+}
+
+// Earlier deliveries of the same aggregate to the endpoint that have neither
+// been delivered nor dead-lettered.
+func (q *Queries) GetPendingPredecessors(ctx context.Context, arg GetPendingPredecessorsParams) (GetPendingPredecessorsRow, error) {
+	row := q.db.QueryRow(ctx, getPendingPredecessors, arg.EndpointID, arg.AggregateID, arg.Sequence)
+	var i GetPendingPredecessorsRow
+	err := row.Scan(&i.Pending, &i.NextAttemptAt)
+	return i, err
+}
+
 const getWebhookEndpoint = `-- name: GetWebhookEndpoint :one
-SELECT id, name, url, secret, active, topics, created_at, updated_at, tenant_id
+SELECT id, name, url, secret, active, topics, created_at, updated_at, tenant_id, format, ordered, max_payload_bytes, oversize_policy, delivery_mode, consecutive_failures, disabled_reason, disabled_at, retry_statuses, permanent_statuses, custom_header_names, custom_headers
 FROM webhook_endpoints
 WHERE id = $1
 `
@@ -211,10 +409,48 @@ func (q *Queries) GetWebhookEndpoint(ctx context.Context, id pgtype.UUID) (Webho
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.TenantID,
+		&i.Format,
+		&i.Ordered,
+		&i.MaxPayloadBytes,
+		&i.OversizePolicy,
+		&i.DeliveryMode,
+		&i.ConsecutiveFailures,
+		&i.DisabledReason,
+		&i.DisabledAt,
+		&i.RetryStatuses,
+		&i.PermanentStatuses,
+		&i.CustomHeaderNames,
+		&i.CustomHeaders,
 	)
 	return i, err
 }
 
+const insertDeliveryAttempt = `-- name: InsertDeliveryAttempt :exec
+INSERT INTO webhook_delivery_attempts (delivery_id, attempt, response_status, response_body, error, duration_ms)
+VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+type InsertDeliveryAttemptParams struct {
+	DeliveryID     pgtype.UUID `json:"delivery_id"`
+	Attempt        int32       `json:"attempt"`
+	ResponseStatus pgtype.Int4 `json:"response_status"`
+	ResponseBody   pgtype.Text `json:"response_body"`
+	Error          pgtype.Text `json:"error"`
+	DurationMs     int32       `json:"duration_ms"`
+}
+
+func (q *Queries) InsertDeliveryAttempt(ctx context.Context, arg InsertDeliveryAttemptParams) error {
+	_, err := q.db.Exec(ctx, insertDeliveryAttempt,
+		arg.DeliveryID,
+		arg.Attempt,
+		arg.ResponseStatus,
+		arg.ResponseBody,
+		arg.Error,
+		arg.DurationMs,
+	)
+	return err
+}
+
 const insertWebhookDlq = `-- name: InsertWebhookDlq :one
 INSERT INTO webhook_dlq (delivery_id, reason)
 VALUES ($1, $2)
@@ -239,13 +475,24 @@ func (q *Queries) InsertWebhookDlq(ctx context.Context, arg InsertWebhookDlqPara
 }
 
 const listActiveEndpointsForTopic = `-- name: ListActiveEndpointsForTopic :many
-SELECT id, name, url, secret, active, topics, created_at, updated_at, tenant_id
+SELECT id, name, url, secret, active, topics, created_at, updated_at, tenant_id, format, ordered, max_payload_bytes, oversize_policy, delivery_mode, consecutive_failures, disabled_reason, disabled_at, retry_statuses, permanent_statuses, custom_header_names, custom_headers
 FROM webhook_endpoints
 WHERE active = true
-  AND (coalesce(array_length(topics, 1), 0) = 0 OR $1::text = ANY(topics))
+  AND (
+    coalesce(array_length(topics, 1), 0) = 0
+    OR $1::text = ANY(topics)
+    OR EXISTS (
+      SELECT 1
+      FROM unnest(topics) AS pattern
+      WHERE pattern = '*'
+         OR (pattern LIKE '%.*' AND starts_with($1::text, left(pattern, -1)))
+    )
+  )
 ORDER BY created_at ASC
 `
 
+// Besides exact topics, endpoints may subscribe to '*' or to a prefix such as
+// 'order.*'; keep in sync with events.MatchTopic.
 func (q *Queries) ListActiveEndpointsForTopic(ctx context.Context, topic string) ([]WebhookEndpoint, error) {
 	rows, err := q.db.Query(ctx, listActiveEndpointsForTopic, topic)
 	if err != nil {
@@ -265,6 +512,54 @@ func (q *Queries) ListActiveEndpointsForTopic(ctx context.Context, topic string)
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.TenantID,
+			&i.Format,
+			&i.Ordered,
+			&i.MaxPayloadBytes,
+			&i.OversizePolicy,
+			&i.DeliveryMode,
+			&i.ConsecutiveFailures,
+			&i.DisabledReason,
+			&i.DisabledAt,
+			&i.RetryStatuses,
+			&i.PermanentStatuses,
+			&i.CustomHeaderNames,
+			&i.CustomHeaders,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listDeliveryAttempts = `-- name: ListDeliveryAttempts :many
+SELECT id, delivery_id, attempt, response_status, response_body, error, duration_ms, created_at
+FROM webhook_delivery_attempts
+WHERE delivery_id = $1
+ORDER BY created_at ASC, id ASC
+`
+
+func (q *Queries) ListDeliveryAttempts(ctx context.Context, deliveryID pgtype.UUID) ([]WebhookDeliveryAttempt, error) {
+	rows, err := q.db.Query(ctx, listDeliveryAttempts, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WebhookDeliveryAttempt
+	for rows.Next() {
+		var i WebhookDeliveryAttempt
+		if err := rows.Scan(
+			&i.ID,
+			&i.DeliveryID,
+			&i.Attempt,
+			&i.ResponseStatus,
+			&i.ResponseBody,
+			&i.Error,
+			&i.DurationMs,
+			&i.CreatedAt,
 		); err != nil {
 			return nil, err
 		}
@@ -277,7 +572,7 @@ func (q *Queries) ListActiveEndpointsForTopic(ctx context.Context, topic string)
 }
 
 const listWebhookDeliveries = `-- name: ListWebhookDeliveries :many
-SELECT wd.id, wd.endpoint_id, wd.event_id, wd.status, wd.attempt, wd.max_attempt, wd.next_attempt_at, wd.last_error, wd.response_status, wd.response_body, wd.created_at, wd.updated_at, wd.tenant_id, we.name AS endpoint_name, we.url AS endpoint_url, we.active AS endpoint_active
+SELECT wd.id, wd.endpoint_id, wd.event_id, wd.status, wd.attempt, wd.max_attempt, wd.next_attempt_at, wd.last_error, wd.response_status, wd.response_body, wd.created_at, wd.updated_at, wd.tenant_id, wd.aggregate_id, wd.sequence, we.name AS endpoint_name, we.url AS endpoint_url, we.active AS endpoint_active
 FROM webhook_deliveries wd
 JOIN webhook_endpoints we ON we.id = wd.endpoint_id
 WHERE ($1::uuid IS NULL OR wd.endpoint_id = $1::uuid)
@@ -309,6 +604,8 @@ type ListWebhookDeliveriesRow struct {
 	CreatedAt      pgtype.Timestamptz `json:"created_at"`
 	UpdatedAt      pgtype.Timestamptz `json:"updated_at"`
 	TenantID       pgtype.UUID        `json:"tenant_id"`
+	AggregateID    pgtype.UUID        `json:"aggregate_id"`
+	Sequence       pgtype.Int8        `json:"sequence"`
 	EndpointName   string             `json:"endpoint_name"`
 	EndpointUrl    string             `json:"endpoint_url"`
 	EndpointActive bool               `json:"endpoint_active"`
@@ -343,6 +640,8 @@ func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeli
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.TenantID,
+			&i.AggregateID,
+			&i.Sequence,
 			&i.EndpointName,
 			&i.EndpointUrl,
 			&i.EndpointActive,
@@ -358,7 +657,7 @@ func (q *Queries) ListWebhookDeliveries(ctx context.Context, arg ListWebhookDeli
 }
 
 const listWebhookEndpoints = `-- name: ListWebhookEndpoints :many
-SELECT id, name, url, secret, active, topics, created_at, updated_at, tenant_id
+SELECT id, name, url, secret, active, topics, created_at, updated_at, tenant_id, format, ordered, max_payload_bytes, oversize_policy, delivery_mode, consecutive_failures, disabled_reason, disabled_at, retry_statuses, permanent_statuses, custom_header_names, custom_headers
 FROM webhook_endpoints
 ORDER BY created_at DESC
 LIMIT $2 OFFSET $1
@@ -388,6 +687,18 @@ func (q *Queries) ListWebhookEndpoints(ctx context.Context, arg ListWebhookEndpo
 			&i.CreatedAt,
 			&i.UpdatedAt,
 			&i.TenantID,
+			&i.Format,
+			&i.Ordered,
+			&i.MaxPayloadBytes,
+			&i.OversizePolicy,
+			&i.DeliveryMode,
+			&i.ConsecutiveFailures,
+			&i.DisabledReason,
+			&i.DisabledAt,
+			&i.RetryStatuses,
+			&i.PermanentStatuses,
+			&i.CustomHeaderNames,
+			&i.CustomHeaders,
 		); err != nil {
 			return nil, err
 		}
@@ -472,6 +783,100 @@ func (q *Queries) MoveToDLQ(ctx context.Context, arg MoveToDLQParams) error {
 	return err
 }
 
+const pruneDeliveryAttempts = `-- name: PruneDeliveryAttempts :exec
+DELETE FROM webhook_delivery_attempts a
+WHERE a.delivery_id = $1
+  AND a.id NOT IN (
+    SELECT k.id
+    FROM webhook_delivery_attempts k
+    WHERE k.delivery_id = $1
+    ORDER BY k.created_at DESC, k.id DESC
+    LIMIT $2::int
+  )
+`
+
+type PruneDeliveryAttemptsParams struct {
+	DeliveryID pgtype.UUID `json:"delivery_id"`
+	Keep       int32       `json:"keep"`
+}
+
+func (q *Queries) PruneDeliveryAttempts(ctx context.Context, arg PruneDeliveryAttemptsParams) error {
+	_, err := q.db.Exec(ctx, pruneDeliveryAttempts, arg.DeliveryID, arg.Keep)
+	return err
+}
+
+const purgeDeliveryAttempts = `-- name: PurgeDeliveryAttempts :execrows
+DELETE FROM webhook_delivery_attempts
+WHERE id IN (
+  SELECT a.id
+  FROM webhook_delivery_attempts a
+  JOIN webhook_deliveries d ON d.id = a.delivery_id
+  WHERE a.created_at < $1::timestamptz
+    AND d.status IN ('DELIVERED', 'DLQ')
+  ORDER BY a.created_at
+  LIMIT $2::int
+  FOR UPDATE OF a SKIP LOCKED
+)
+`
+
+type PurgeDeliveryAttemptsParams struct {
+	Before    pgtype.Timestamptz `json:"before"`
+	BatchSize int32              `json:"batch_size"`
+}
+
+// Deletes up to batch_size attempts older than the cutoff, keeping the history
+// of deliveries that are still being retried.
+func (q *Queries) PurgeDeliveryAttempts(ctx context.Context, arg PurgeDeliveryAttemptsParams) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeDeliveryAttempts, arg.Before, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const purgeWebhookDeliveries = `-- name: PurgeWebhookDeliveries :execrows
+DELETE FROM webhook_deliveries
+WHERE id IN (
+  SELECT d.id
+  FROM webhook_deliveries d
+  WHERE d.status = $1::delivery_status
+    AND d.updated_at < $2::timestamptz
+  ORDER BY d.updated_at
+  LIMIT $3::int
+  FOR UPDATE SKIP LOCKED
+)
+`
+
+type PurgeWebhookDeliveriesParams struct {
+	Status    DeliveryStatus     `json:"status"`
+	Before    pgtype.Timestamptz `json:"before"`
+	BatchSize int32              `json:"batch_size"`
+}
+
+// Deletes up to batch_size deliveries in the given terminal status that have
+// not changed since before the cutoff. Attempts and DLQ rows cascade.
+func (q *Queries) PurgeWebhookDeliveries(ctx context.Context, arg PurgeWebhookDeliveriesParams) (int64, error) {
+	result, err := q.db.Exec(ctx, purgeWebhookDeliveries, arg.Status, arg.Before, arg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected(), nil
+}
+
+const recordEndpointFailure = `-- name: RecordEndpointFailure :one
+UPDATE webhook_endpoints
+SET consecutive_failures = consecutive_failures + 1
+WHERE id = $1
+RETURNING consecutive_failures
+`
+
+func (q *Queries) RecordEndpointFailure(ctx context.Context, id pgtype.UUID) (int32, error) {
+	row := q.db.QueryRow(ctx, recordEndpointFailure, id)
+	var consecutive_failures int32
+	err := row.Scan(&consecutive_failures)
+	return consecutive_failures, err
+}
+
 const resetDeliveryForReplay = `-- name: ResetDeliveryForReplay :one
 UPDATE webhook_deliveries
 SET status = 'PENDING',
@@ -482,7 +887,7 @@ SET status = 'PENDING',
     response_body = NULL,
     updated_at = now()
 WHERE id = $1
-RETURNING id, endpoint_id, event_id, status, attempt, max_attempt, next_attempt_at, last_error, response_status, response_body, created_at, updated_at, tenant_id
+RETURNING id, endpoint_id, event_id, status, attempt, max_attempt, next_attempt_at, last_error, response_status, response_body, created_at, updated_at, tenant_id, aggregate_id, sequence
 `
 
 func (q *Queries) ResetDeliveryForReplay(ctx context.Context, id pgtype.UUID) (WebhookDelivery, error) {
@@ -502,10 +907,24 @@ func (q *Queries) ResetDeliveryForReplay(ctx context.Context, id pgtype.UUID) (W
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.TenantID,
+		&i.AggregateID,
+		&i.Sequence,
 	)
 	return i, err
 }
 
+const resetEndpointFailures = `-- name: ResetEndpointFailures :exec
+UPDATE webhook_endpoints
+SET consecutive_failures = 0
+WHERE id = $1
+  AND consecutive_failures <> 0
+`
+
+func (q *Queries) ResetEndpointFailures(ctx context.Context, id pgtype.UUID) error {
+	_, err := q.db.Exec(ctx, resetEndpointFailures, id)
+	return err
+}
+
 const updateWebhookEndpoint = `-- name: UpdateWebhookEndpoint :one
 UPDATE webhook_endpoints
 SET name = $1,
@@ -513,18 +932,41 @@ SET name = $1,
     secret = $3,
     active = $4,
     topics = $5,
+    format = $6,
+    ordered = $7,
+    max_payload_bytes = $8,
+    oversize_policy = $9,
+    delivery_mode = $10,
+    retry_statuses = $11,
+    permanent_statuses = $12,
+    -- Omitted custom headers keep the stored ones.
+    custom_header_names = COALESCE($13, custom_header_names),
+    custom_headers = COALESCE($14, custom_headers),
+    -- Re-activating through an update starts the failure count afresh.
+    consecutive_failures = CASE WHEN $4 AND NOT active THEN 0 ELSE consecutive_failures END,
+    disabled_reason = CASE WHEN $4 THEN NULL ELSE disabled_reason END,
+    disabled_at = CASE WHEN $4 THEN NULL ELSE disabled_at END,
     updated_at = now()
-WHERE id = $6
-RETURNING id, name, url, secret, active, topics, created_at, updated_at, tenant_id
+WHERE id = $15
+RETURNING id, name, url, secret, active, topics, created_at, updated_at, tenant_id, format, ordered, max_payload_bytes, oversize_policy, delivery_mode, consecutive_failures, disabled_reason, disabled_at, retry_statuses, permanent_statuses, custom_header_names, custom_headers
 `
 
 type UpdateWebhookEndpointParams struct {
-	Name   string      `json:"name"`
-	Url    string      `json:"url"`
-	Secret string      `json:"secret"`
-	Active bool        `json:"active"`
-	Topics []string    `json:"topics"`
-	ID     pgtype.UUID `json:"id"`
+	Name              string      `json:"name"`
+	Url               string      `json:"url"`
+	Secret            string      `json:"secret"`
+	Active            bool        `json:"active"`
+	Topics            []string    `json:"topics"`
+	Format            string      `json:"format"`
+	Ordered           bool        `json:"ordered"`
+	MaxPayloadBytes   int32       `json:"max_payload_bytes"`
+	OversizePolicy    string      `json:"oversize_policy"`
+	DeliveryMode      string      `json:"delivery_mode"`
+	RetryStatuses     []int32     `json:"retry_statuses"`
+	PermanentStatuses []int32     `json:"permanent_statuses"`
+	CustomHeaderNames []string    `json:"custom_header_names"`
+	CustomHeaders     []byte      `json:"custom_headers"`
+	ID                pgtype.UUID `json:"id"`
 }
 
 func (q *Queries) UpdateWebhookEndpoint(ctx context.Context, arg UpdateWebhookEndpointParams) (WebhookEndpoint, error) {
@@ -534,6 +976,15 @@ func (q *Queries) UpdateWebhookEndpoint(ctx context.Context, arg UpdateWebhookEn
 		arg.Secret,
 		arg.Active,
 		arg.Topics,
+		arg.Format,
+		arg.Ordered,
+		arg.MaxPayloadBytes,
+		arg.OversizePolicy,
+		arg.DeliveryMode,
+		arg.RetryStatuses,
+		arg.PermanentStatuses,
+		arg.CustomHeaderNames,
+		arg.CustomHeaders,
 		arg.ID,
 	)
 	var i WebhookEndpoint
@@ -547,6 +998,18 @@ func (q *Queries) UpdateWebhookEndpoint(ctx context.Context, arg UpdateWebhookEn
 		&i.CreatedAt,
 		&i.UpdatedAt,
 		&i.TenantID,
+		&i.Format,
+		&i.Ordered,
+		&i.MaxPayloadBytes,
+		&i.OversizePolicy,
+		&i.DeliveryMode,
+		&i.ConsecutiveFailures,
+		&i.DisabledReason,
+		&i.DisabledAt,
+		&i.RetryStatuses,
+		&i.PermanentStatuses,
+		&i.CustomHeaderNames,
+		&i.CustomHeaders,
 	)
 	return i, err
 }
@@ -4,6 +4,9 @@ REFRESH MATERIALIZED VIEW CONCURRENTLY mv_sales_daily;
 -- name: RefreshTopProducts :exec
 REFRESH MATERIALIZED VIEW CONCURRENTLY mv_top_products;
 
+-- name: RefreshProductCopurchase :exec
+REFRESH MATERIALIZED VIEW CONCURRENTLY mv_product_copurchase;
+
 -- name: GetSalesDailyRange :many
 SELECT day::timestamptz AS day,
        paid_orders,
@@ -19,3 +22,31 @@ SELECT product_id, qty_sold, gross
 FROM mv_top_products
 ORDER BY qty_sold DESC
 LIMIT sqlc.arg(limit_count) OFFSET sqlc.arg(offset_rows);
+
+-- name: GetVoucherPerformance :many
+SELECT v.code,
+       COUNT(u.id)::bigint AS redemptions,
+       COALESCE(SUM(u.amount), 0)::bigint AS discount,
+       COALESCE(SUM(o.pricing_total) FILTER (WHERE o.status <> 'CANCELED'), 0)::bigint AS revenue,
+       COUNT(DISTINCT u.user_id)::bigint AS unique_users
+FROM voucher_usages u
+JOIN vouchers v ON v.id = u.voucher_id
+JOIN orders o ON o.id = u.order_id
+WHERE u.used_at >= sqlc.arg(start_date)::timestamptz
+  AND u.used_at < sqlc.arg(end_date)::timestamptz
+GROUP BY v.code
+ORDER BY CASE WHEN sqlc.arg(sort_by)::text = 'discount' THEN COALESCE(SUM(u.amount), 0) ELSE COUNT(u.id) END DESC,
+         v.code ASC
+LIMIT sqlc.arg(limit_count) OFFSET sqlc.arg(offset_rows);
+
+-- name: MarkAnalyticsViewRefreshed :exec
+INSERT INTO analytics_view_refreshes (view_name, refreshed_at, duration_ms)
+VALUES (sqlc.arg(view_name), sqlc.arg(refreshed_at), sqlc.arg(duration_ms))
+ON CONFLICT (view_name) DO UPDATE
+SET refreshed_at = EXCLUDED.refreshed_at,
+    duration_ms = EXCLUDED.duration_ms;
+
+-- name: GetAnalyticsViewRefreshedAt :one
+SELECT refreshed_at
+FROM analytics_view_refreshes
+WHERE view_name = sqlc.arg(view_name);
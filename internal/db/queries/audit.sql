@@ -37,3 +37,19 @@ SELECT
 FROM audit_logs
 ORDER BY created_at DESC
 LIMIT $1 OFFSET $2;
+
+-- name: CountAuditLogs :one
+SELECT COUNT(*)
+FROM audit_logs;
+
+-- name: ListAuditLogsByActorAfter :many
+-- Pages through the requests a user made that were audited, oldest first.
+SELECT id, action, resource_type, resource_id, method, path, status, ip, user_agent, created_at
+FROM audit_logs
+WHERE actor_user_id = sqlc.arg(actor_user_id)
+  AND (
+    sqlc.narg(after_created_at)::timestamptz IS NULL
+    OR (created_at, id) > (sqlc.narg(after_created_at)::timestamptz, sqlc.narg(after_id)::uuid)
+  )
+ORDER BY created_at, id
+LIMIT sqlc.arg(page_limit);
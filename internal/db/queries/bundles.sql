@@ -0,0 +1,46 @@
+-- name: ListBundleComponentsByVariantIDs :many
+SELECT bc.bundle_variant_id,
+       b.pricing,
+       bc.component_variant_id,
+       cv.product_id,
+       p.title,
+       p.slug,
+       cv.sku,
+       bc.qty,
+       cv.price,
+       cv.stock
+FROM variant_bundle_components bc
+JOIN variant_bundles b ON b.variant_id = bc.bundle_variant_id
+JOIN product_variants cv ON cv.id = bc.component_variant_id
+JOIN products p ON p.id = cv.product_id
+WHERE bc.bundle_variant_id = ANY(sqlc.arg(variant_ids)::uuid[])
+ORDER BY bc.bundle_variant_id, p.title, bc.component_variant_id;
+
+-- name: UpsertVariantBundle :exec
+INSERT INTO variant_bundles (variant_id, pricing)
+VALUES ($1, $2)
+ON CONFLICT (variant_id) DO UPDATE
+SET pricing = EXCLUDED.pricing,
+    updated_at = now();
+
+-- name: DeleteVariantBundle :exec
+DELETE FROM variant_bundles
+WHERE variant_id = $1;
+
+-- name: UpsertBundleComponents :exec
+INSERT INTO variant_bundle_components (bundle_variant_id, component_variant_id, qty)
+SELECT sqlc.arg(bundle_variant_id)::uuid,
+       unnest(sqlc.arg(component_variant_ids)::uuid[]),
+       unnest(sqlc.arg(qtys)::int[])
+ON CONFLICT (bundle_variant_id, component_variant_id) DO UPDATE
+SET qty = EXCLUDED.qty;
+
+-- name: DeleteBundleComponentsExcept :exec
+DELETE FROM variant_bundle_components
+WHERE bundle_variant_id = sqlc.arg(bundle_variant_id)
+  AND NOT (component_variant_id = ANY(sqlc.arg(keep_variant_ids)::uuid[]));
+
+-- name: CountBundlesUsingComponent :one
+SELECT count(*)
+FROM variant_bundle_components
+WHERE component_variant_id = $1;
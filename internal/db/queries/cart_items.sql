@@ -1,20 +1,65 @@
 -- name: ListCartItems :many
-SELECT id, cart_id, product_id, variant_id, title, slug, qty, unit_price, subtotal
+SELECT id, cart_id, product_id, variant_id, title, slug, qty, unit_price, subtotal, version
 FROM cart_items
 WHERE cart_id = $1
 ORDER BY title ASC, id;
 
+-- name: ListCartItemAvailability :many
+SELECT ci.id,
+       (p.id IS NOT NULL AND p.in_stock)::boolean AS product_available,
+       v.stock AS variant_stock,
+       (b.stock IS NOT NULL)::boolean AS is_bundle,
+       COALESCE(b.stock, 0)::int AS bundle_stock,
+       p.available_from,
+       p.available_to,
+       COALESCE(p.preorder, false)::boolean AS preorder
+FROM cart_items ci
+LEFT JOIN products p ON p.id = ci.product_id
+LEFT JOIN product_variants v ON v.id = ci.variant_id
+LEFT JOIN LATERAL (
+    SELECT MIN(cv.stock / bc.qty)::int AS stock
+    FROM variant_bundle_components bc
+    JOIN product_variants cv ON cv.id = bc.component_variant_id
+    WHERE bc.bundle_variant_id = ci.variant_id
+) b ON true
+WHERE ci.cart_id = $1;
+
+-- name: ListCartItemCatalogPrices :many
+SELECT ci.id,
+       ci.variant_id,
+       p.price AS product_price,
+       v.price AS variant_price
+FROM cart_items ci
+LEFT JOIN products p ON p.id = ci.product_id
+LEFT JOIN product_variants v ON v.id = ci.variant_id
+WHERE ci.cart_id = $1;
+
+-- name: RepriceCartItem :exec
+UPDATE cart_items
+SET unit_price = $2,
+    subtotal = qty * $2,
+    version = version + 1
+WHERE id = $1;
+
+-- name: GetCartItemTotals :one
+SELECT COUNT(*)::int AS items,
+       COALESCE(SUM(qty), 0)::int AS qty
+FROM cart_items
+WHERE cart_id = $1;
+
 -- name: CreateCartItem :one
 INSERT INTO cart_items (cart_id, product_id, variant_id, title, slug, qty, unit_price, subtotal)
 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
-RETURNING id, cart_id, product_id, variant_id, title, slug, qty, unit_price, subtotal;
+RETURNING id, cart_id, product_id, variant_id, title, slug, qty, unit_price, subtotal, version;
 
 -- name: UpdateCartItemQty :one
 UPDATE cart_items
 SET qty = $2,
-    subtotal = $3
+    subtotal = $3,
+    version = version + 1
 WHERE id = $1
-RETURNING id, cart_id, product_id, variant_id, title, slug, qty, unit_price, subtotal;
+  AND version = $4
+RETURNING id, cart_id, product_id, variant_id, title, slug, qty, unit_price, subtotal, version;
 
 -- name: DeleteCartItem :exec
 DELETE FROM cart_items
@@ -22,7 +67,7 @@ WHERE id = $1
   AND cart_id = $2;
 
 -- name: FindCartItemByProductVariant :one
-SELECT id, cart_id, product_id, variant_id, title, slug, qty, unit_price, subtotal
+SELECT id, cart_id, product_id, variant_id, title, slug, qty, unit_price, subtotal, version
 FROM cart_items
 WHERE cart_id = $1
   AND product_id = $2
@@ -30,7 +75,32 @@ WHERE cart_id = $1
 LIMIT 1;
 
 -- name: GetCartItemByID :one
-SELECT id, cart_id, product_id, variant_id, title, slug, qty, unit_price, subtotal
+SELECT id, cart_id, product_id, variant_id, title, slug, qty, unit_price, subtotal, version
 FROM cart_items
 WHERE id = $1
 LIMIT 1;
+
+-- name: CreateCartItems :batchexec
+INSERT INTO cart_items (cart_id, product_id, variant_id, title, slug, qty, unit_price, subtotal)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8);
+
+-- name: UpdateCartItemsQty :batchexec
+UPDATE cart_items
+SET qty = $2,
+    subtotal = $3,
+    version = version + 1
+WHERE id = $1;
+
+-- name: ListCartItemParcels :many
+-- Weight and dimensions of each cart line's variant for shipping quotes;
+-- lines without a variant or measurements come back NULL.
+SELECT ci.id,
+       ci.qty,
+       v.weight_gram,
+       v.length_cm,
+       v.width_cm,
+       v.height_cm
+FROM cart_items ci
+LEFT JOIN product_variants v ON v.id = ci.variant_id
+WHERE ci.cart_id = $1
+ORDER BY ci.id;
@@ -14,3 +14,9 @@ SELECT id, name, slug, parent_id
 FROM categories
 WHERE slug = $1
 LIMIT 1;
+
+-- name: GetCategoryDefaultSort :one
+SELECT COALESCE(default_sort, '')::text AS default_sort
+FROM categories
+WHERE slug = $1
+LIMIT 1;
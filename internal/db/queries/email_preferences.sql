@@ -0,0 +1,12 @@
+-- name: ListUserEmailPreferences :many
+SELECT user_id, category, enabled, updated_at
+FROM user_email_preferences
+WHERE user_id = $1
+ORDER BY category;
+
+-- name: UpsertUserEmailPreference :exec
+INSERT INTO user_email_preferences (user_id, category, enabled)
+VALUES ($1, $2, $3)
+ON CONFLICT (user_id, category) DO UPDATE
+SET enabled = EXCLUDED.enabled,
+    updated_at = now();
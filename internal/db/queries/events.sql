@@ -9,3 +9,25 @@ FROM domain_events
 WHERE topic = $1
 ORDER BY occurred_at DESC
 LIMIT $2 OFFSET $3;
+
+-- name: CountDomainEventsByTopic :one
+SELECT COUNT(*)
+FROM domain_events
+WHERE topic = $1;
+
+-- name: PurgeDomainEvents :execrows
+-- Deletes up to batch_size events older than the cutoff. Deleting an event
+-- cascades to its deliveries, so events any delivery still references are
+-- kept; they become eligible once their deliveries have been purged.
+DELETE FROM domain_events
+WHERE id IN (
+  SELECT e.id
+  FROM domain_events e
+  WHERE e.occurred_at < sqlc.arg(before)::timestamptz
+    AND NOT EXISTS (
+      SELECT 1 FROM webhook_deliveries d WHERE d.event_id = e.id
+    )
+  ORDER BY e.occurred_at
+  LIMIT sqlc.arg(batch_size)::int
+  FOR UPDATE SKIP LOCKED
+);
@@ -0,0 +1,42 @@
+-- name: ClaimInboundWebhook :one
+-- tenant_guard:ignore provider callbacks are deduplicated before their tenant is known
+-- Takes over a claim left unfinished for stale_after_sec; returns no row when
+-- the callback was processed or another claim is still live.
+INSERT INTO inbound_webhook_events (source, dedup_key, claimed_at)
+VALUES (sqlc.arg(source), sqlc.arg(dedup_key), now())
+ON CONFLICT (source, dedup_key) DO UPDATE
+SET claimed_at = now()
+WHERE inbound_webhook_events.processed_at IS NULL
+  AND inbound_webhook_events.claimed_at < now() - make_interval(secs => sqlc.arg(stale_after_sec)::double precision)
+RETURNING claimed_at;
+
+-- name: GetInboundWebhookProcessedAt :one
+-- tenant_guard:ignore provider callbacks are deduplicated before their tenant is known
+SELECT processed_at
+FROM inbound_webhook_events
+WHERE source = sqlc.arg(source) AND dedup_key = sqlc.arg(dedup_key);
+
+-- name: CompleteInboundWebhook :exec
+-- tenant_guard:ignore provider callbacks are deduplicated before their tenant is known
+UPDATE inbound_webhook_events
+SET processed_at = now()
+WHERE source = sqlc.arg(source) AND dedup_key = sqlc.arg(dedup_key);
+
+-- name: ReleaseInboundWebhook :exec
+-- tenant_guard:ignore provider callbacks are deduplicated before their tenant is known
+DELETE FROM inbound_webhook_events
+WHERE source = sqlc.arg(source) AND dedup_key = sqlc.arg(dedup_key) AND processed_at IS NULL;
+
+-- name: PurgeInboundWebhookEvents :execrows
+-- tenant_guard:ignore provider callbacks are deduplicated before their tenant is known
+-- Deletes up to batch_size processed callbacks older than the cutoff.
+-- Retries arriving after that are processed again.
+DELETE FROM inbound_webhook_events
+WHERE (source, dedup_key) IN (
+  SELECT source, dedup_key
+  FROM inbound_webhook_events
+  WHERE processed_at < sqlc.arg(before)::timestamptz
+  ORDER BY processed_at
+  LIMIT sqlc.arg(batch_size)::int
+  FOR UPDATE SKIP LOCKED
+);
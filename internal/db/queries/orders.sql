@@ -31,6 +31,18 @@ SELECT COUNT(*)
 FROM orders
 WHERE user_id = $1;
 
+-- name: ListOrdersAdmin :many
+SELECT *
+FROM orders
+WHERE (sqlc.narg(status)::text IS NULL OR status::text = sqlc.narg(status)::text)
+ORDER BY created_at DESC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
+
+-- name: CountOrdersAdmin :one
+SELECT COUNT(*)
+FROM orders
+WHERE (sqlc.narg(status)::text IS NULL OR status::text = sqlc.narg(status)::text);
+
 -- name: UpdateOrderStatus :exec
 UPDATE orders
 SET status = $2,
@@ -38,7 +50,47 @@ SET status = $2,
 WHERE id = $1;
 
 -- name: ListOrderItemsByOrder :many
-SELECT id, order_id, product_id, variant_id, title, slug, qty, unit_price, subtotal
+SELECT id, order_id, product_id, variant_id, title, slug, qty, unit_price, subtotal, preorder, discount_allocated, catalog_unit_price
 FROM order_items
 WHERE order_id = $1
 ORDER BY title ASC, id;
+
+-- name: CreateOrderItems :batchexec
+INSERT INTO order_items (order_id, product_id, variant_id, title, slug, qty, unit_price, subtotal, preorder, discount_allocated, catalog_unit_price)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);
+
+-- name: ListOrdersForUserAfter :many
+-- Pages through a user's orders oldest first, resuming after the given
+-- order, so an export can stream any number of them.
+SELECT *
+FROM orders
+WHERE user_id = sqlc.arg(user_id)
+  AND (
+    sqlc.narg(after_created_at)::timestamptz IS NULL
+    OR (created_at, id) > (sqlc.narg(after_created_at)::timestamptz, sqlc.narg(after_id)::uuid)
+  )
+ORDER BY created_at, id
+LIMIT sqlc.arg(page_limit);
+
+-- name: ListOrderItemsByOrders :many
+SELECT id, order_id, product_id, variant_id, title, slug, qty, unit_price, subtotal, preorder, discount_allocated, catalog_unit_price
+FROM order_items
+WHERE order_id = ANY(sqlc.arg(order_ids)::uuid[])
+ORDER BY order_id, title ASC, id;
+
+-- name: ListOrdersForExportAfter :many
+-- Pages through the orders created in [from_at, to_at) with one of the given
+-- statuses oldest first, optionally for one tenant, resuming after the given
+-- order, so an accounting export can stream any range.
+SELECT *
+FROM orders
+WHERE created_at >= sqlc.arg(from_at)
+  AND created_at < sqlc.arg(to_at)
+  AND status::text = ANY(sqlc.arg(statuses)::text[])
+  AND (sqlc.narg(tenant_id)::uuid IS NULL OR tenant_id = sqlc.narg(tenant_id)::uuid)
+  AND (
+    sqlc.narg(after_created_at)::timestamptz IS NULL
+    OR (created_at, id) > (sqlc.narg(after_created_at)::timestamptz, sqlc.narg(after_id)::uuid)
+  )
+ORDER BY created_at, id
+LIMIT sqlc.arg(page_limit);
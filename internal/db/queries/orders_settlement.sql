@@ -1,5 +1,5 @@
 -- name: ListOrderItemsForStock :many
-SELECT product_id, variant_id, qty, slug
+SELECT product_id, variant_id, qty, slug, preorder
 FROM order_items
 WHERE order_id = $1;
 
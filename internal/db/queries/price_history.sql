@@ -0,0 +1,36 @@
+-- name: ListPreviousProductPrices :many
+-- The price each product had before its latest own price change since the
+-- given time; products whose price has not changed since are left out.
+SELECT DISTINCT ON (product_id) product_id, old_price, changed_at
+FROM price_history
+WHERE product_id = ANY(sqlc.arg(product_ids)::uuid[])
+  AND variant_id IS NULL
+  AND changed_at >= sqlc.arg(since)
+ORDER BY product_id, changed_at DESC, id DESC;
+
+-- name: ListProductPriceTrend :many
+-- The product's latest own price changes, newest first. old_price_since is
+-- when old_price took effect: the previous change, or the product's creation.
+SELECT ph.old_price, ph.new_price, ph.changed_at,
+       COALESCE(LAG(ph.changed_at) OVER (ORDER BY ph.changed_at, ph.id), p.created_at)::timestamptz AS old_price_since
+FROM price_history ph
+JOIN products p ON p.id = ph.product_id
+WHERE ph.product_id = sqlc.arg(product_id)
+  AND ph.variant_id IS NULL
+ORDER BY ph.changed_at DESC, ph.id DESC
+LIMIT sqlc.arg(limit_count);
+
+-- name: CountPriceHistoryByProduct :one
+SELECT COUNT(*)
+FROM price_history
+WHERE product_id = sqlc.arg(product_id)
+  AND (sqlc.narg(tenant_id)::uuid IS NULL OR tenant_id = sqlc.narg(tenant_id)::uuid);
+
+-- name: ListPriceHistoryByProduct :many
+-- Price changes of the product and its variants, newest first.
+SELECT *
+FROM price_history
+WHERE product_id = sqlc.arg(product_id)
+  AND (sqlc.narg(tenant_id)::uuid IS NULL OR tenant_id = sqlc.narg(tenant_id)::uuid)
+ORDER BY changed_at DESC, id DESC
+LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
@@ -8,7 +8,8 @@ WHERE (sqlc.narg(q)::text IS NULL OR p.title ILIKE '%%' || sqlc.arg(q) || '%%')
   AND (sqlc.narg(brand_slug)::text IS NULL OR b.slug = sqlc.arg(brand_slug))
   AND (sqlc.narg(min_price)::bigint IS NULL OR p.price >= sqlc.arg(min_price))
   AND (sqlc.narg(max_price)::bigint IS NULL OR p.price <= sqlc.arg(max_price))
-  AND (sqlc.narg(in_stock)::boolean IS NULL OR p.in_stock = sqlc.arg(in_stock));
+  AND (sqlc.narg(in_stock)::boolean IS NULL OR p.in_stock = sqlc.arg(in_stock))
+  AND (p.available_to IS NULL OR p.available_to > now());
 
 -- name: ListProductsPublic :many
 SELECT p.id,
@@ -20,21 +21,28 @@ SELECT p.id,
        p.thumbnail,
        p.badges,
        p.created_at,
-       COALESCE((SELECT SUM(stock) FROM product_variants WHERE product_id = p.id), 0)::int AS total_stock
+       COALESCE((SELECT SUM(stock) FROM product_variants WHERE product_id = p.id), 0)::int AS total_stock,
+       p.available_from,
+       p.available_to,
+       p.preorder
 FROM products p
 LEFT JOIN brands b ON b.id = p.brand_id
 LEFT JOIN categories c ON c.id = p.category_id
+LEFT JOIN mv_top_products tp ON tp.product_id = p.id
 WHERE (sqlc.narg(q)::text IS NULL OR p.title ILIKE '%%' || sqlc.arg(q) || '%%')
   AND (sqlc.narg(category_slug)::text IS NULL OR c.slug = sqlc.arg(category_slug))
   AND (sqlc.narg(brand_slug)::text IS NULL OR b.slug = sqlc.arg(brand_slug))
   AND (sqlc.narg(min_price)::bigint IS NULL OR p.price >= sqlc.arg(min_price))
   AND (sqlc.narg(max_price)::bigint IS NULL OR p.price <= sqlc.arg(max_price))
   AND (sqlc.narg(in_stock)::boolean IS NULL OR p.in_stock = sqlc.arg(in_stock))
-ORDER BY CASE WHEN sqlc.arg(sort)::text = 'price:asc' THEN p.price END ASC,
+  AND (p.available_to IS NULL OR p.available_to > now())
+ORDER BY CASE WHEN sqlc.arg(sort)::text = 'bestseller' THEN COALESCE(tp.qty_sold, 0) END DESC,
+         CASE WHEN sqlc.arg(sort)::text = 'price:asc' THEN p.price END ASC,
          CASE WHEN sqlc.arg(sort)::text = 'price:desc' THEN p.price END DESC,
          CASE WHEN sqlc.arg(sort)::text = 'title:asc' THEN p.title END ASC,
          CASE WHEN sqlc.arg(sort)::text = 'title:desc' THEN p.title END DESC,
-         p.created_at DESC
+         p.created_at DESC,
+         p.id
 LIMIT sqlc.arg(limit_value) OFFSET sqlc.arg(offset_value);
 
 -- name: GetProductBySlug :one
@@ -49,7 +57,13 @@ SELECT id,
        brand_id,
        category_id,
        created_at,
-       COALESCE((SELECT SUM(stock) FROM product_variants WHERE product_id = products.id), 0)::int AS total_stock
+       option_schema,
+       COALESCE((SELECT SUM(stock) FROM product_variants WHERE product_id = products.id), 0)::int AS total_stock,
+       default_variant_id,
+       available_from,
+       available_to,
+       preorder,
+       preorder_ships_at
 FROM products
 WHERE slug = $1
 LIMIT 1;
@@ -60,11 +74,64 @@ SELECT id,
        sku,
        price,
        stock,
-       attributes
+       attributes,
+       weight_gram,
+       length_cm,
+       width_cm,
+       height_cm
 FROM product_variants
 WHERE product_id = $1
 ORDER BY sku NULLS LAST, id;
 
+-- name: GetProductOptionSchema :one
+SELECT id,
+       slug,
+       option_schema,
+       default_variant_id
+FROM products
+WHERE id = $1;
+
+-- name: SetProductDefaultVariant :exec
+UPDATE products
+SET default_variant_id = sqlc.narg(variant_id),
+    updated_at = now()
+WHERE id = sqlc.arg(id);
+
+-- name: UpdateProductAvailability :one
+UPDATE products
+SET available_from = sqlc.narg(available_from),
+    available_to = sqlc.narg(available_to),
+    preorder = sqlc.arg(preorder),
+    preorder_ships_at = sqlc.narg(preorder_ships_at),
+    updated_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING slug;
+
+-- name: UpdateProductOptionSchema :exec
+UPDATE products
+SET option_schema = $2,
+    updated_at = now()
+WHERE id = $1;
+
+-- name: CreateProductVariant :one
+INSERT INTO product_variants (product_id, sku, price, stock, attributes, weight_gram, length_cm, width_cm, height_cm)
+VALUES ($1, $2, $3, $4, $5, sqlc.narg(weight_gram), sqlc.narg(length_cm), sqlc.narg(width_cm), sqlc.narg(height_cm))
+RETURNING id, product_id, sku, price, stock, attributes, weight_gram, length_cm, width_cm, height_cm;
+
+-- name: UpdateProductVariant :one
+UPDATE product_variants
+SET sku = $3,
+    price = $4,
+    stock = $5,
+    attributes = $6,
+    weight_gram = sqlc.narg(weight_gram),
+    length_cm = sqlc.narg(length_cm),
+    width_cm = sqlc.narg(width_cm),
+    height_cm = sqlc.narg(height_cm)
+WHERE id = $1
+  AND product_id = $2
+RETURNING id, product_id, sku, price, stock, attributes, weight_gram, length_cm, width_cm, height_cm;
+
 -- name: ListImagesByProduct :many
 SELECT id,
        product_id,
@@ -92,23 +159,77 @@ SELECT p.id,
        p.in_stock,
        p.thumbnail,
        p.badges,
-       p.created_at
+       p.created_at,
+       p.available_from,
+       p.available_to,
+       p.preorder,
+       COALESCE((SELECT SUM(stock) FROM product_variants WHERE product_id = p.id), 0)::int AS total_stock
 FROM products p
 WHERE p.category_id = $1
   AND p.slug <> $2
+  AND (p.available_to IS NULL OR p.available_to > now())
 ORDER BY p.created_at DESC
 LIMIT 8;
 
 -- name: GetProductForCart :one
+SELECT p.id,
+       p.title,
+       p.slug,
+       p.price,
+       p.category_id,
+       p.brand_id,
+       p.default_variant_id,
+       EXISTS (SELECT 1 FROM product_variants v WHERE v.product_id = p.id) AS has_variants,
+       p.available_from,
+       p.available_to,
+       p.preorder,
+       p.preorder_ships_at,
+       p.in_stock
+FROM products p
+WHERE p.id = $1
+LIMIT 1;
+
+-- name: ListRecommendationCandidates :many
+-- Products sharing any recommendation signal with the given one: bought in
+-- the same orders, same brand, same category, or priced within the band.
+-- The strongest candidates come first; the caller ranks them.
+SELECT p.id,
+       p.title,
+       p.slug,
+       p.price,
+       p.compare_at,
+       p.in_stock,
+       p.thumbnail,
+       p.badges,
+       p.created_at,
+       p.available_from,
+       p.available_to,
+       p.preorder,
+       COALESCE((SELECT SUM(stock) FROM product_variants WHERE product_id = p.id), 0)::int AS total_stock,
+       p.brand_id,
+       p.category_id,
+       COALESCE(cp.orders, 0)::bigint AS co_orders
+FROM products p
+LEFT JOIN mv_product_copurchase cp ON cp.product_id = sqlc.arg(product_id) AND cp.other_product_id = p.id
+WHERE p.id <> sqlc.arg(product_id)
+  AND (p.available_to IS NULL OR p.available_to > now())
+  AND (cp.orders IS NOT NULL
+       OR p.brand_id = sqlc.narg(brand_id)
+       OR p.category_id = sqlc.narg(category_id)
+       OR p.price BETWEEN sqlc.arg(price_min)::bigint AND sqlc.arg(price_max)::bigint)
+ORDER BY COALESCE(cp.orders, 0) DESC,
+         (p.brand_id = sqlc.narg(brand_id)) IS TRUE DESC,
+         (p.category_id = sqlc.narg(category_id)) IS TRUE DESC,
+         abs(p.price - sqlc.arg(price)::bigint) ASC,
+         p.id
+LIMIT sqlc.arg(limit_count);
+
+-- name: ListProductScopesByIDs :many
 SELECT id,
-       title,
-       slug,
-       price,
        category_id,
        brand_id
 FROM products
-WHERE id = $1
-LIMIT 1;
+WHERE id = ANY(sqlc.arg(product_ids)::uuid[]);
 
 -- name: GetVariantForCart :one
 SELECT id,
@@ -118,3 +239,39 @@ SELECT id,
 FROM product_variants
 WHERE id = $1
 LIMIT 1;
+
+-- name: ListProductTranslations :many
+SELECT product_id,
+       locale,
+       title,
+       description,
+       badges,
+       specs
+FROM product_translations
+WHERE product_id = ANY(sqlc.arg(product_ids)::uuid[])
+  AND locale = sqlc.arg(locale);
+
+-- name: CreateProductImage :one
+WITH inserted AS (
+    INSERT INTO product_images (product_id, url, sort_order)
+    VALUES (
+        sqlc.arg(product_id),
+        sqlc.arg(url),
+        COALESCE((SELECT MAX(sort_order) + 1 FROM product_images WHERE product_id = sqlc.arg(product_id)), 0)
+    )
+    RETURNING id, product_id, url, sort_order
+)
+SELECT inserted.id,
+       inserted.product_id,
+       inserted.url,
+       inserted.sort_order,
+       p.slug
+FROM inserted
+JOIN products p ON p.id = inserted.product_id;
+
+-- name: ListTopProductSlugs :many
+SELECT p.slug
+FROM mv_top_products tp
+JOIN products p ON p.id = tp.product_id
+ORDER BY tp.qty_sold DESC, p.id
+LIMIT sqlc.arg(limit_count);
@@ -31,3 +31,10 @@ SELECT id
 FROM reviews 
 WHERE user_id = $1 AND product_id = $2 AND tenant_id = $3
 LIMIT 1;
+
+-- name: ListReviewsByUser :many
+SELECT r.id, r.product_id, p.slug AS product_slug, r.rating, r.comment, r.created_at, r.updated_at
+FROM reviews r
+LEFT JOIN products p ON p.id = r.product_id
+WHERE r.user_id = $1
+ORDER BY r.created_at, r.id;
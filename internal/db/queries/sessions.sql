@@ -1,10 +1,10 @@
 -- name: CreateSession :one
-INSERT INTO sessions (user_id, refresh_token, user_agent, ip, expires_at)
-VALUES ($1, $2, $3, $4, $5)
-RETURNING id, user_id, refresh_token, user_agent, ip, expires_at, created_at;
+INSERT INTO sessions (user_id, refresh_token, user_agent, ip, expires_at, audience)
+VALUES ($1, $2, $3, $4, $5, $6)
+RETURNING id, user_id, refresh_token, user_agent, ip, expires_at, created_at, audience;
 
 -- name: GetSessionByToken :one
-SELECT id, user_id, refresh_token, user_agent, ip, expires_at, created_at
+SELECT id, user_id, refresh_token, user_agent, ip, expires_at, created_at, audience
 FROM sessions
 WHERE refresh_token = $1
 LIMIT 1;
@@ -14,7 +14,7 @@ UPDATE sessions
 SET refresh_token = $2,
     expires_at    = $3
 WHERE id = $1
-RETURNING id, user_id, refresh_token, user_agent, ip, expires_at, created_at;
+RETURNING id, user_id, refresh_token, user_agent, ip, expires_at, created_at, audience;
 
 -- name: DeleteSessionByToken :exec
 DELETE FROM sessions
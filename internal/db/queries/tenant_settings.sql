@@ -0,0 +1,25 @@
+-- name: GetTenantSetting :one
+SELECT ts.value
+FROM tenant_settings ts
+JOIN tenants t ON t.id = ts.tenant_id
+WHERE (t.id::text = sqlc.arg(tenant)::text OR t.slug = sqlc.arg(tenant)::text)
+  AND ts.key = sqlc.arg(key)
+LIMIT 1;
+
+-- name: UpsertTenantSetting :one
+INSERT INTO tenant_settings (tenant_id, key, value)
+SELECT t.id, sqlc.arg(key), sqlc.arg(value)::jsonb
+FROM tenants t
+WHERE t.id::text = sqlc.arg(tenant)::text OR t.slug = sqlc.arg(tenant)::text
+LIMIT 1
+ON CONFLICT (tenant_id, key) DO UPDATE
+SET value = EXCLUDED.value,
+    updated_at = now()
+RETURNING value;
+
+-- name: DeleteTenantSetting :execrows
+DELETE FROM tenant_settings ts
+USING tenants t
+WHERE t.id = ts.tenant_id
+  AND (t.id::text = sqlc.arg(tenant)::text OR t.slug = sqlc.arg(tenant)::text)
+  AND ts.key = sqlc.arg(key);
@@ -0,0 +1,43 @@
+-- name: GetUserTOTP :one
+SELECT user_id, secret, enabled_at, last_step, created_at, updated_at
+FROM user_totp
+WHERE user_id = $1;
+
+-- name: UpsertPendingUserTOTP :execrows
+INSERT INTO user_totp (user_id, secret)
+VALUES ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET secret = EXCLUDED.secret,
+    last_step = 0,
+    updated_at = now()
+WHERE user_totp.enabled_at IS NULL;
+
+-- name: EnableUserTOTP :execrows
+UPDATE user_totp
+SET enabled_at = now(),
+    last_step = $2,
+    updated_at = now()
+WHERE user_id = $1 AND enabled_at IS NULL;
+
+-- name: AdvanceUserTOTPStep :execrows
+UPDATE user_totp
+SET last_step = $2,
+    updated_at = now()
+WHERE user_id = $1 AND last_step < $2;
+
+-- name: DeleteUserTOTP :exec
+DELETE FROM user_totp
+WHERE user_id = $1;
+
+-- name: InsertBackupCodes :exec
+INSERT INTO user_backup_codes (user_id, code_hash)
+SELECT sqlc.arg(user_id)::uuid, unnest(sqlc.arg(code_hashes)::text[]);
+
+-- name: UseBackupCode :execrows
+UPDATE user_backup_codes
+SET used_at = now()
+WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL;
+
+-- name: DeleteBackupCodes :exec
+DELETE FROM user_backup_codes
+WHERE user_id = $1;
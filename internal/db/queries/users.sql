@@ -4,13 +4,13 @@ VALUES ($1, $2, $3)
 RETURNING id, name, email, roles, created_at, updated_at;
 
 -- name: GetUserByEmail :one
-SELECT id, name, email, password_hash, roles, created_at, updated_at
+SELECT id, name, email, password_hash, roles, created_at, updated_at, deactivated_at
 FROM users
 WHERE email = $1
 LIMIT 1;
 
 -- name: GetUserByID :one
-SELECT id, name, email, roles, created_at, updated_at
+SELECT id, name, email, roles, created_at, updated_at, deactivated_at
 FROM users
 WHERE id = $1
 LIMIT 1;
@@ -28,3 +28,62 @@ SET name       = $2,
     updated_at = now()
 WHERE id = $1
 RETURNING id, name, email, roles, created_at, updated_at;
+
+-- name: DeactivateUser :one
+UPDATE users
+SET deactivated_at = COALESCE(deactivated_at, now()),
+    updated_at     = now()
+WHERE id = $1
+RETURNING deactivated_at;
+
+-- name: ReactivateUser :exec
+UPDATE users
+SET deactivated_at = NULL,
+    updated_at     = now()
+WHERE id = $1;
+
+-- name: ListUsersDueForPurge :many
+SELECT id
+FROM users
+WHERE deactivated_at IS NOT NULL
+  AND deactivated_at < sqlc.arg(before)
+  AND NOT EXISTS (
+      SELECT 1 FROM orders o
+      WHERE o.user_id = users.id
+        AND o.status NOT IN ('DELIVERED', 'CANCELED')
+  )
+ORDER BY deactivated_at
+LIMIT sqlc.arg(batch_size);
+
+-- name: LockUserForPurge :one
+SELECT id
+FROM users
+WHERE id = $1
+FOR UPDATE;
+
+-- name: CountOpenOrdersByUser :one
+SELECT count(*)
+FROM orders
+WHERE user_id = $1
+  AND status NOT IN ('DELIVERED', 'CANCELED');
+
+-- name: AnonymizeOrdersByUser :execrows
+-- Keeps the order and its totals for bookkeeping but drops the recipient's
+-- name, phone, street address, and notes, and points it at the placeholder.
+UPDATE orders
+SET user_id          = sqlc.arg(placeholder),
+    shipping_address = CASE
+        WHEN shipping_address IS NULL THEN NULL
+        ELSE shipping_address - 'receiverName' - 'phone' - 'addressLine1' - 'addressLine2'
+    END,
+    notes            = NULL
+WHERE user_id = sqlc.arg(user_id);
+
+-- name: AnonymizeVoucherUsagesByUser :exec
+UPDATE voucher_usages
+SET user_id = sqlc.arg(placeholder)
+WHERE user_id = sqlc.arg(user_id);
+
+-- name: DeleteUser :exec
+DELETE FROM users
+WHERE id = $1;
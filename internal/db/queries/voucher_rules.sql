@@ -29,10 +29,20 @@ WHERE code = $1
 FOR UPDATE;
 
 -- name: CountVoucherUsageByUser :one
+-- With exclude_released, usages tied to canceled orders or refunded payments
+-- no longer count against the per-user limit.
 SELECT COUNT(*)
-FROM voucher_usages
-WHERE voucher_id = $1
-  AND user_id = $2;
+FROM voucher_usages vu
+LEFT JOIN orders o ON o.id = vu.order_id
+WHERE vu.voucher_id = $1
+  AND vu.user_id = $2
+  AND NOT (
+    sqlc.arg(exclude_released)::boolean
+    AND (
+      o.status = 'CANCELED'
+      OR EXISTS (SELECT 1 FROM payments p WHERE p.order_id = vu.order_id AND p.status = 'REFUNDED')
+    )
+  );
 
 -- name: InsertVoucherUsage :exec
 INSERT INTO voucher_usages (voucher_id, user_id, order_id, amount)
@@ -45,3 +55,23 @@ FROM voucher_usages
 WHERE voucher_id = $1
   AND order_id = $2
 LIMIT 1;
+
+-- name: ReleaseVoucherUsageByOrder :execrows
+-- Deletes the order's voucher usage and gives the use back to the voucher.
+WITH released AS (
+    DELETE FROM voucher_usages
+    WHERE order_id = $1
+    RETURNING voucher_id
+)
+UPDATE vouchers v
+SET used_count = GREATEST(v.used_count - r.n, 0),
+    updated_at = now()
+FROM (SELECT voucher_id, COUNT(*) AS n FROM released GROUP BY voucher_id) r
+WHERE v.id = r.voucher_id;
+
+-- name: CreateVoucherBatch :many
+INSERT INTO vouchers (code, value, kind, percent_bps, min_spend, usage_limit, valid_from, valid_to, product_ids, category_ids, brand_ids, combinable, priority, per_user_limit)
+SELECT code, sqlc.arg(value), sqlc.arg(kind), sqlc.narg(percent_bps), sqlc.arg(min_spend), sqlc.narg(usage_limit), sqlc.narg(valid_from), sqlc.narg(valid_to), sqlc.arg(product_ids), sqlc.arg(category_ids), sqlc.arg(brand_ids), sqlc.arg(combinable), sqlc.arg(priority), sqlc.narg(per_user_limit)
+FROM unnest(sqlc.arg(codes)::text[]) AS code
+ON CONFLICT (code) DO NOTHING
+RETURNING code;
@@ -1,6 +1,6 @@
 -- name: CreateWebhookEndpoint :one
-INSERT INTO webhook_endpoints (name, url, secret, active, topics)
-VALUES (sqlc.arg(name), sqlc.arg(url), sqlc.arg(secret), sqlc.arg(active), sqlc.arg(topics))
+INSERT INTO webhook_endpoints (name, url, secret, active, topics, format, ordered, max_payload_bytes, oversize_policy, delivery_mode, retry_statuses, permanent_statuses, custom_header_names, custom_headers)
+VALUES (sqlc.arg(name), sqlc.arg(url), sqlc.arg(secret), sqlc.arg(active), sqlc.arg(topics), sqlc.arg(format), sqlc.arg(ordered), sqlc.arg(max_payload_bytes), sqlc.arg(oversize_policy), sqlc.arg(delivery_mode), sqlc.arg(retry_statuses), sqlc.arg(permanent_statuses), sqlc.arg(custom_header_names), sqlc.narg(custom_headers))
 RETURNING *;
 
 -- name: UpdateWebhookEndpoint :one
@@ -10,6 +10,20 @@ SET name = sqlc.arg(name),
     secret = sqlc.arg(secret),
     active = sqlc.arg(active),
     topics = sqlc.arg(topics),
+    format = sqlc.arg(format),
+    ordered = sqlc.arg(ordered),
+    max_payload_bytes = sqlc.arg(max_payload_bytes),
+    oversize_policy = sqlc.arg(oversize_policy),
+    delivery_mode = sqlc.arg(delivery_mode),
+    retry_statuses = sqlc.arg(retry_statuses),
+    permanent_statuses = sqlc.arg(permanent_statuses),
+    -- Omitted custom headers keep the stored ones.
+    custom_header_names = COALESCE(sqlc.narg(custom_header_names), custom_header_names),
+    custom_headers = COALESCE(sqlc.narg(custom_headers), custom_headers),
+    -- Re-activating through an update starts the failure count afresh.
+    consecutive_failures = CASE WHEN sqlc.arg(active) AND NOT active THEN 0 ELSE consecutive_failures END,
+    disabled_reason = CASE WHEN sqlc.arg(active) THEN NULL ELSE disabled_reason END,
+    disabled_at = CASE WHEN sqlc.arg(active) THEN NULL ELSE disabled_at END,
     updated_at = now()
 WHERE id = sqlc.arg(id)
 RETURNING *;
@@ -25,22 +39,98 @@ FROM webhook_endpoints
 ORDER BY created_at DESC
 LIMIT sqlc.arg(page_limit) OFFSET sqlc.arg(page_offset);
 
+-- name: CountWebhookEndpoints :one
+SELECT COUNT(*)
+FROM webhook_endpoints;
+
 -- name: DeleteWebhookEndpoint :exec
 DELETE FROM webhook_endpoints
 WHERE id = sqlc.arg(id);
 
+-- name: RecordEndpointFailure :one
+UPDATE webhook_endpoints
+SET consecutive_failures = consecutive_failures + 1
+WHERE id = sqlc.arg(id)
+RETURNING consecutive_failures;
+
+-- name: ResetEndpointFailures :exec
+UPDATE webhook_endpoints
+SET consecutive_failures = 0
+WHERE id = sqlc.arg(id)
+  AND consecutive_failures <> 0;
+
+-- name: DisableWebhookEndpoint :one
+-- Only an active endpoint is disabled, so concurrent callers agree on which
+-- of them did it.
+UPDATE webhook_endpoints
+SET active = false,
+    disabled_reason = sqlc.arg(reason),
+    disabled_at = now(),
+    updated_at = now()
+WHERE id = sqlc.arg(id)
+  AND active
+RETURNING *;
+
+-- name: EnableWebhookEndpoint :one
+UPDATE webhook_endpoints
+SET active = true,
+    consecutive_failures = 0,
+    disabled_reason = NULL,
+    disabled_at = NULL,
+    updated_at = now()
+WHERE id = sqlc.arg(id)
+RETURNING *;
+
 -- name: ListActiveEndpointsForTopic :many
+-- Besides exact topics, endpoints may subscribe to '*' or to a prefix such as
+-- 'order.*'; keep in sync with events.MatchTopic.
 SELECT *
 FROM webhook_endpoints
 WHERE active = true
-  AND (coalesce(array_length(topics, 1), 0) = 0 OR sqlc.arg(topic)::text = ANY(topics))
+  AND (
+    coalesce(array_length(topics, 1), 0) = 0
+    OR sqlc.arg(topic)::text = ANY(topics)
+    OR EXISTS (
+      SELECT 1
+      FROM unnest(topics) AS pattern
+      WHERE pattern = '*'
+         OR (pattern LIKE '%.*' AND starts_with(sqlc.arg(topic)::text, left(pattern, -1)))
+    )
+  )
 ORDER BY created_at ASC;
 
 -- name: EnqueueDelivery :one
-INSERT INTO webhook_deliveries (endpoint_id, event_id, status, max_attempt, next_attempt_at)
-VALUES (sqlc.arg(endpoint_id), sqlc.arg(event_id), 'PENDING', sqlc.arg(max_attempt), now())
+-- The sequence bump and the insert share one statement, so a duplicate
+-- delivery rolls the bump back and leaves no gap.
+WITH seq AS (
+  INSERT INTO webhook_sequences (endpoint_id, aggregate_id, last_sequence)
+  VALUES (sqlc.arg(endpoint_id), sqlc.arg(aggregate_id), 1)
+  ON CONFLICT (endpoint_id, aggregate_id)
+  DO UPDATE SET last_sequence = webhook_sequences.last_sequence + 1
+  RETURNING last_sequence
+)
+INSERT INTO webhook_deliveries (endpoint_id, event_id, status, max_attempt, next_attempt_at, aggregate_id, sequence)
+SELECT sqlc.arg(endpoint_id), sqlc.arg(event_id), 'PENDING', sqlc.arg(max_attempt), now(), sqlc.arg(aggregate_id), seq.last_sequence
+FROM seq
 RETURNING *;
 
+-- name: GetPendingPredecessors :one
+-- Earlier deliveries of the same aggregate to the endpoint that have neither
+-- been delivered nor dead-lettered.
+SELECT count(*)::bigint AS pending,
+       min(next_attempt_at)::timestamptz AS next_attempt_at
+FROM webhook_deliveries
+WHERE endpoint_id = sqlc.arg(endpoint_id)
+  AND aggregate_id = sqlc.arg(aggregate_id)
+  AND sequence < sqlc.arg(sequence)
+  AND status IN ('PENDING', 'DELIVERING', 'FAILED');
+
+-- name: DeferDelivery :exec
+UPDATE webhook_deliveries
+SET next_attempt_at = now() + (sqlc.arg(delay_sec)::int * interval '1 second'),
+    updated_at = now()
+WHERE id = sqlc.arg(id);
+
 -- name: DequeueDueDeliveries :many
 SELECT *
 FROM webhook_deliveries
@@ -123,3 +213,53 @@ FROM webhook_deliveries wd
 WHERE (sqlc.arg(endpoint_id)::uuid IS NULL OR wd.endpoint_id = sqlc.arg(endpoint_id)::uuid)
   AND (sqlc.arg(event_id)::uuid IS NULL OR wd.event_id = sqlc.arg(event_id)::uuid)
   AND (sqlc.arg(status)::text IS NULL OR sqlc.arg(status)::text = '' OR wd.status = sqlc.arg(status)::delivery_status);
+
+-- name: InsertDeliveryAttempt :exec
+INSERT INTO webhook_delivery_attempts (delivery_id, attempt, response_status, response_body, error, duration_ms)
+VALUES (sqlc.arg(delivery_id), sqlc.arg(attempt), sqlc.arg(response_status), sqlc.arg(response_body), sqlc.arg(error), sqlc.arg(duration_ms));
+
+-- name: PruneDeliveryAttempts :exec
+DELETE FROM webhook_delivery_attempts a
+WHERE a.delivery_id = sqlc.arg(delivery_id)
+  AND a.id NOT IN (
+    SELECT k.id
+    FROM webhook_delivery_attempts k
+    WHERE k.delivery_id = sqlc.arg(delivery_id)
+    ORDER BY k.created_at DESC, k.id DESC
+    LIMIT sqlc.arg(keep)::int
+  );
+
+-- name: ListDeliveryAttempts :many
+SELECT *
+FROM webhook_delivery_attempts
+WHERE delivery_id = sqlc.arg(delivery_id)
+ORDER BY created_at ASC, id ASC;
+
+-- name: PurgeWebhookDeliveries :execrows
+-- Deletes up to batch_size deliveries in the given terminal status that have
+-- not changed since before the cutoff. Attempts and DLQ rows cascade.
+DELETE FROM webhook_deliveries
+WHERE id IN (
+  SELECT d.id
+  FROM webhook_deliveries d
+  WHERE d.status = sqlc.arg(status)::delivery_status
+    AND d.updated_at < sqlc.arg(before)::timestamptz
+  ORDER BY d.updated_at
+  LIMIT sqlc.arg(batch_size)::int
+  FOR UPDATE SKIP LOCKED
+);
+
+-- name: PurgeDeliveryAttempts :execrows
+-- Deletes up to batch_size attempts older than the cutoff, keeping the history
+-- of deliveries that are still being retried.
+DELETE FROM webhook_delivery_attempts
+WHERE id IN (
+  SELECT a.id
+  FROM webhook_delivery_attempts a
+  JOIN webhook_deliveries d ON d.id = a.delivery_id
+  WHERE a.created_at < sqlc.arg(before)::timestamptz
+    AND d.status IN ('DELIVERED', 'DLQ')
+  ORDER BY a.created_at
+  LIMIT sqlc.arg(batch_size)::int
+  FOR UPDATE OF a SKIP LOCKED
+);
@@ -0,0 +1,91 @@
+// Package db checks that the database schema matches the migrations this
+// build was compiled with.
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+
+	"github.com/noah-isme/backend-toko/migrations"
+)
+
+// Schema check modes.
+const (
+	SchemaCheckFail = "fail"
+	SchemaCheckWarn = "warn"
+	SchemaCheckOff  = "off"
+)
+
+// RowQuerier is the subset of pgxpool.Pool the schema check needs.
+type RowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// SchemaStatus is the migration version recorded by golang-migrate in
+// schema_migrations compared with the latest embedded migration.
+type SchemaStatus struct {
+	Current  uint
+	Expected uint
+	Dirty    bool
+	// Missing is set when schema_migrations does not exist yet.
+	Missing bool
+}
+
+// Err explains why the schema cannot serve this build, or returns nil. A
+// database ahead of the build is accepted so code can be rolled back without
+// rolling back migrations.
+func (s SchemaStatus) Err() error {
+	switch {
+	case s.Missing:
+		return fmt.Errorf("database not migrated to version %d: schema_migrations not found", s.Expected)
+	case s.Dirty:
+		return fmt.Errorf("database migration %d is dirty: a migration failed halfway and needs fixing before it can reach version %d", s.Current, s.Expected)
+	case s.Current < s.Expected:
+		return fmt.Errorf("database not migrated to version %d (at version %d)", s.Expected, s.Current)
+	default:
+		return nil
+	}
+}
+
+// CheckSchema reads the current migration version.
+func CheckSchema(ctx context.Context, q RowQuerier) (SchemaStatus, error) {
+	expected, err := migrations.Latest()
+	if err != nil {
+		return SchemaStatus{}, fmt.Errorf("read embedded migrations: %w", err)
+	}
+	status := SchemaStatus{Expected: expected}
+	var current int64
+	err = q.QueryRow(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&current, &status.Dirty)
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pgErr) && pgErr.Code == "42P01": // undefined_table
+		status.Missing = true
+	case errors.Is(err, pgx.ErrNoRows):
+		// golang-migrate leaves the table empty after migrating all the way down.
+	case err != nil:
+		return SchemaStatus{}, fmt.Errorf("read schema version: %w", err)
+	default:
+		status.Current = uint(current)
+	}
+	return status, nil
+}
+
+// ParseSchemaCheck validates a schema check mode; empty picks fail in
+// production and warn elsewhere.
+func ParseSchemaCheck(value, appEnv string) (string, error) {
+	switch value {
+	case "":
+		if appEnv == "production" {
+			return SchemaCheckFail, nil
+		}
+		return SchemaCheckWarn, nil
+	case SchemaCheckFail, SchemaCheckWarn, SchemaCheckOff:
+		return value, nil
+	default:
+		return "", fmt.Errorf("unknown schema check mode %q, want fail, warn, or off", value)
+	}
+}
@@ -0,0 +1,78 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+// AdminStore is the read access the admin handler needs.
+type AdminStore interface {
+	ListDomainEventsByTopic(ctx context.Context, arg dbgen.ListDomainEventsByTopicParams) ([]dbgen.ListDomainEventsByTopicRow, error)
+	CountDomainEventsByTopic(ctx context.Context, topic string) (int64, error)
+}
+
+// AdminHandler lets support staff browse emitted domain events.
+type AdminHandler struct {
+	Store AdminStore
+}
+
+// EventView is a domain event as the admin API returns it.
+type EventView struct {
+	ID          string          `json:"id"`
+	Topic       string          `json:"topic"`
+	AggregateID string          `json:"aggregateId"`
+	Payload     json.RawMessage `json:"payload"`
+	OccurredAt  time.Time       `json:"occurredAt"`
+}
+
+// List handles GET /admin/domain-events?topic=, newest first. The deliveries
+// of an event are listed by GET /admin/webhook-deliveries?eventId=.
+func (h *AdminHandler) List(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Store == nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "event store unavailable", nil)
+		return
+	}
+	topic := strings.TrimSpace(r.URL.Query().Get("topic"))
+	if topic == "" {
+		common.JSONError(w, http.StatusBadRequest, common.CodeBadRequest, "topic is required", map[string]any{"field": "topic"})
+		return
+	}
+	limit, offset := common.ParseOffsetPagination(r, 50, 200)
+	rows, err := h.Store.ListDomainEventsByTopic(r.Context(), dbgen.ListDomainEventsByTopicParams{
+		Topic:  topic,
+		Limit:  int32(limit),
+		Offset: int32(offset),
+	})
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to list domain events", nil)
+		return
+	}
+	total, err := h.Store.CountDomainEventsByTopic(r.Context(), topic)
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to count domain events", nil)
+		return
+	}
+	items := make([]EventView, 0, len(rows))
+	for _, row := range rows {
+		items = append(items, EventView{
+			ID:          uuidString(row.ID),
+			Topic:       row.Topic,
+			AggregateID: uuidString(row.AggregateID),
+			Payload:     json.RawMessage(row.Payload),
+			OccurredAt:  row.OccurredAt.Time,
+		})
+	}
+	common.WritePage(w, r, "domain-events", items, limit, offset, total, nil)
+}
+
+// Schemas handles GET /admin/domain-events/schemas, listing the payload
+// schema version of each typed topic.
+func (h *AdminHandler) Schemas(w http.ResponseWriter, _ *http.Request) {
+	common.JSON(w, http.StatusOK, map[string]any{"data": Schemas()})
+}
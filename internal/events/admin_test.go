@@ -0,0 +1,80 @@
+package events_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/events"
+)
+
+type adminStore struct {
+	params dbgen.ListDomainEventsByTopicParams
+	rows   []dbgen.ListDomainEventsByTopicRow
+}
+
+func (s *adminStore) ListDomainEventsByTopic(_ context.Context, arg dbgen.ListDomainEventsByTopicParams) ([]dbgen.ListDomainEventsByTopicRow, error) {
+	s.params = arg
+	return s.rows, nil
+}
+
+func (s *adminStore) CountDomainEventsByTopic(context.Context, string) (int64, error) {
+	return int64(len(s.rows)), nil
+}
+
+func TestAdminListsEventsByTopic(t *testing.T) {
+	aggregate := uuid.New()
+	store := &adminStore{rows: []dbgen.ListDomainEventsByTopicRow{{
+		ID:          toUUID(uuid.New()),
+		Topic:       events.TopicOrderPaid,
+		AggregateID: toUUID(aggregate),
+		Payload:     []byte(`{"orderId":"123"}`),
+		OccurredAt:  pgtype.Timestamptz{Time: time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC), Valid: true},
+	}}}
+	h := &events.AdminHandler{Store: store}
+
+	rr := httptest.NewRecorder()
+	h.List(rr, httptest.NewRequest(http.MethodGet, "/admin/domain-events?topic=order.paid&limit=10", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	require.Equal(t, events.TopicOrderPaid, store.params.Topic)
+	require.Equal(t, int32(10), store.params.Limit)
+
+	var body struct {
+		Data  []events.EventView `json:"data"`
+		Total int64              `json:"total"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	require.Equal(t, int64(1), body.Total)
+	require.Len(t, body.Data, 1)
+	require.Equal(t, aggregate.String(), body.Data[0].AggregateID)
+	require.JSONEq(t, `{"orderId":"123"}`, string(body.Data[0].Payload))
+
+	rr = httptest.NewRecorder()
+	h.List(rr, httptest.NewRequest(http.MethodGet, "/admin/domain-events", nil))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestAdminListsPayloadSchemas(t *testing.T) {
+	rr := httptest.NewRecorder()
+	(&events.AdminHandler{}).Schemas(rr, httptest.NewRequest(http.MethodGet, "/admin/domain-events/schemas", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var body struct {
+		Data []events.PayloadSchema `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	topics := make([]string, 0, len(body.Data))
+	for _, schema := range body.Data {
+		require.Equal(t, 1, schema.Version)
+		topics = append(topics, schema.Topic)
+	}
+	require.ElementsMatch(t, events.DefaultTopics(), topics)
+}
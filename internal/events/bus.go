@@ -7,9 +7,12 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
 
 	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/obs"
 )
 
 // EventStore defines the persistence operations required by the event bus.
@@ -17,9 +20,10 @@ type EventStore interface {
 	InsertDomainEvent(ctx context.Context, arg dbgen.InsertDomainEventParams) (dbgen.InsertDomainEventRow, error)
 }
 
-// DeliveryScheduler schedules webhook deliveries for emitted events.
+// DeliveryScheduler schedules webhook deliveries for emitted events and
+// reports how many it scheduled.
 type DeliveryScheduler interface {
-	Schedule(ctx context.Context, event dbgen.DomainEvent) error
+	Schedule(ctx context.Context, event dbgen.DomainEvent) (int, error)
 }
 
 // Notifier reacts to emitted events (e.g. email, metrics, etc.).
@@ -32,9 +36,22 @@ type Bus struct {
 	Store     EventStore
 	Scheduler DeliveryScheduler
 	Notifiers []Notifier
+	// Logger records every emitted event with the deliveries scheduled and
+	// each notifier's result; nil disables the log.
+	Logger *zerolog.Logger
 }
 
-// Emit records the event and dispatches it to all configured handlers.
+// Results of an Emit, reported in domain_events_total.
+const (
+	resultOK             = "ok"
+	resultPersistFailed  = "persist_failed"
+	resultScheduleFailed = "schedule_failed"
+	resultNotifyFailed   = "notify_failed"
+)
+
+// Emit records the event and dispatches it to all configured handlers. Topics
+// with a registered payload schema must be given their typed payload, which is
+// validated and stamped with its schema version.
 func (b *Bus) Emit(ctx context.Context, topic string, aggregateID pgtype.UUID, payload any) (dbgen.DomainEvent, error) {
 	if b == nil || b.Store == nil {
 		return dbgen.DomainEvent{}, errors.New("events: store not configured")
@@ -46,6 +63,9 @@ func (b *Bus) Emit(ctx context.Context, topic string, aggregateID pgtype.UUID, p
 	if !aggregateID.Valid {
 		return dbgen.DomainEvent{}, errors.New("events: aggregate id is required")
 	}
+	if err := preparePayload(topic, payload); err != nil {
+		return dbgen.DomainEvent{}, fmt.Errorf("events: %w", err)
+	}
 	encoded, err := encodePayload(payload)
 	if err != nil {
 		return dbgen.DomainEvent{}, fmt.Errorf("events: encode payload: %w", err)
@@ -56,6 +76,10 @@ func (b *Bus) Emit(ctx context.Context, topic string, aggregateID pgtype.UUID, p
 		Payload:     encoded,
 	})
 	if err != nil {
+		b.countEvent(topic, resultPersistFailed)
+		if b.Logger != nil {
+			b.Logger.Error().Err(err).Str("topic", topic).Str("aggregate_id", uuidString(aggregateID)).Msg("domain event not persisted")
+		}
 		return dbgen.DomainEvent{}, fmt.Errorf("events: persist event: %w", err)
 	}
 	ev := dbgen.DomainEvent{
@@ -65,23 +89,73 @@ func (b *Bus) Emit(ctx context.Context, topic string, aggregateID pgtype.UUID, p
 		Payload:     row.Payload,
 		OccurredAt:  row.OccurredAt,
 	}
-	var joined error
+	var joined, schedErr error
+	scheduled := 0
 	if b.Scheduler != nil {
-		if schedErr := b.Scheduler.Schedule(ctx, ev); schedErr != nil {
+		if scheduled, schedErr = b.Scheduler.Schedule(ctx, ev); schedErr != nil {
 			joined = errors.Join(joined, fmt.Errorf("events: schedule deliveries: %w", schedErr))
 		}
 	}
+	var notified, failed []string
 	for _, notifier := range b.Notifiers {
 		if notifier == nil {
 			continue
 		}
 		if notifyErr := notifier.Notify(ctx, ev); notifyErr != nil {
 			joined = errors.Join(joined, fmt.Errorf("events: notifier: %w", notifyErr))
+			failed = append(failed, notifierName(notifier))
+			continue
+		}
+		notified = append(notified, notifierName(notifier))
+	}
+
+	result := resultOK
+	switch {
+	case schedErr != nil:
+		result = resultScheduleFailed
+	case len(failed) > 0:
+		result = resultNotifyFailed
+	}
+	b.countEvent(ev.Topic, result)
+	if obs.DomainEventDeliveriesTotal != nil && scheduled > 0 {
+		obs.DomainEventDeliveriesTotal.WithLabelValues(ev.Topic).Add(float64(scheduled))
+	}
+	if b.Logger != nil {
+		entry := b.Logger.Info()
+		if joined != nil {
+			entry = b.Logger.Warn().Err(joined)
 		}
+		entry.Str("topic", ev.Topic).
+			Str("event_id", uuidString(ev.ID)).
+			Str("aggregate_id", uuidString(ev.AggregateID)).
+			Int("deliveries_scheduled", scheduled).
+			Strs("notified", notified).
+			Strs("notify_failed", failed).
+			Str("result", result).
+			Msg("domain event emitted")
 	}
 	return ev, joined
 }
 
+func (b *Bus) countEvent(topic, result string) {
+	if obs.DomainEventsTotal != nil {
+		obs.DomainEventsTotal.WithLabelValues(topic, result).Inc()
+	}
+}
+
+// notifierName identifies a notifier in logs by its type, e.g.
+// "notify.EmailNotifier".
+func notifierName(n Notifier) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", n), "*")
+}
+
+func uuidString(id pgtype.UUID) string {
+	if !id.Valid {
+		return ""
+	}
+	return uuid.UUID(id.Bytes).String()
+}
+
 func encodePayload(payload any) ([]byte, error) {
 	if payload == nil {
 		return []byte("{}"), nil
@@ -1,17 +1,23 @@
 package events_test
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
 
 	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
 	"github.com/noah-isme/backend-toko/internal/events"
+	"github.com/noah-isme/backend-toko/internal/obs"
 )
 
 type stubStore struct {
@@ -19,7 +25,7 @@ type stubStore struct {
 	event      dbgen.DomainEvent
 }
 
-func (s *stubStore) InsertDomainEvent(_ context.Context, arg dbgen.InsertDomainEventParams) (dbgen.DomainEvent, error) {
+func (s *stubStore) InsertDomainEvent(_ context.Context, arg dbgen.InsertDomainEventParams) (dbgen.InsertDomainEventRow, error) {
 	s.lastParams = arg
 	if !s.event.ID.Valid {
 		id := uuid.New()
@@ -31,16 +37,22 @@ func (s *stubStore) InsertDomainEvent(_ context.Context, arg dbgen.InsertDomainE
 	if !s.event.OccurredAt.Valid {
 		s.event.OccurredAt = pgtype.Timestamptz{Time: time.Now(), Valid: true}
 	}
-	return s.event, nil
+	return dbgen.InsertDomainEventRow{
+		ID:          s.event.ID,
+		Topic:       s.event.Topic,
+		AggregateID: s.event.AggregateID,
+		Payload:     s.event.Payload,
+		OccurredAt:  s.event.OccurredAt,
+	}, nil
 }
 
 type captureScheduler struct {
 	events []dbgen.DomainEvent
 }
 
-func (c *captureScheduler) Schedule(_ context.Context, event dbgen.DomainEvent) error {
+func (c *captureScheduler) Schedule(_ context.Context, event dbgen.DomainEvent) (int, error) {
 	c.events = append(c.events, event)
-	return nil
+	return 1, nil
 }
 
 type captureNotifier struct {
@@ -67,12 +79,12 @@ func TestEmitPersistsEvent(t *testing.T) {
 	}
 
 	aggregate := uuid.New()
-	payload := map[string]any{"orderId": "123"}
+	payload := &events.OrderCreatedPayload{OrderID: "123", UserID: "u-1", Total: 5000}
 	ctx := context.Background()
 	event, err := bus.Emit(ctx, events.TopicOrderCreated, toUUID(aggregate), payload)
 	require.NoError(t, err)
 	require.Equal(t, events.TopicOrderCreated, store.lastParams.Topic)
-	require.JSONEq(t, `{"orderId":"123"}`, string(store.lastParams.Payload))
+	require.JSONEq(t, `{"schemaVersion":1,"orderId":"123","userId":"u-1","total":5000}`, string(store.lastParams.Payload))
 	require.Len(t, scheduler.events, 1)
 	require.Len(t, notifier.events, 1)
 	require.Equal(t, event.ID, scheduler.events[0].ID)
@@ -81,3 +93,64 @@ func TestEmitPersistsEvent(t *testing.T) {
 	require.NoError(t, json.Unmarshal(event.Payload, &decoded))
 	require.Equal(t, "123", decoded["orderId"])
 }
+
+type failingNotifier struct{}
+
+func (failingNotifier) Notify(context.Context, dbgen.DomainEvent) error {
+	return errors.New("smtp down")
+}
+
+func TestEmitLogsAndCountsOutcome(t *testing.T) {
+	obs.MustRegisterDomainMetrics("test", prometheus.NewRegistry())
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+	bus := events.Bus{
+		Store:     &stubStore{},
+		Scheduler: &captureScheduler{},
+		Notifiers: []events.Notifier{&captureNotifier{}, failingNotifier{}},
+		Logger:    &logger,
+	}
+	topic := "test.logged"
+
+	_, err := bus.Emit(context.Background(), topic, toUUID(uuid.New()), nil)
+	require.ErrorContains(t, err, "smtp down")
+
+	var entry map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+	require.Equal(t, "domain event emitted", entry["message"])
+	require.Equal(t, topic, entry["topic"])
+	require.Equal(t, float64(1), entry["deliveries_scheduled"])
+	require.Equal(t, []any{"events_test.captureNotifier"}, entry["notified"])
+	require.Equal(t, []any{"events_test.failingNotifier"}, entry["notify_failed"])
+	require.Equal(t, "notify_failed", entry["result"])
+	require.NotEmpty(t, entry["event_id"])
+
+	require.Equal(t, float64(1), testutil.ToFloat64(obs.DomainEventsTotal.WithLabelValues(topic, "notify_failed")))
+	require.Equal(t, float64(1), testutil.ToFloat64(obs.DomainEventDeliveriesTotal.WithLabelValues(topic)))
+}
+
+func TestEmitEnforcesPayloadSchema(t *testing.T) {
+	store := &stubStore{}
+	bus := events.Bus{Store: store}
+	ctx := context.Background()
+	aggregate := toUUID(uuid.New())
+
+	_, err := bus.Emit(ctx, events.TopicOrderPaid, aggregate, map[string]any{"orderId": "123"})
+	require.ErrorIs(t, err, events.ErrInvalidPayload, "registered topics reject raw maps")
+
+	_, err = bus.Emit(ctx, events.TopicOrderPaid, aggregate, &events.ShipmentPayload{OrderID: "1", ShipmentID: "2", Status: "SHIPPED"})
+	require.ErrorIs(t, err, events.ErrInvalidPayload, "a topic accepts only its own payload type")
+
+	_, err = bus.Emit(ctx, events.TopicOrderPaid, aggregate, &events.OrderPaymentPayload{OrderID: "123"})
+	require.ErrorIs(t, err, events.ErrInvalidPayload)
+	require.ErrorContains(t, err, "missing paymentId, status")
+
+	_, err = bus.Emit(ctx, events.TopicShipmentShipped, aggregate, &events.ShipmentPayload{
+		OrderID: "1", ShipmentID: "2", Status: "SHIPPED", Payload: json.RawMessage(`{"awb":"JNE1"}`),
+	})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"schemaVersion":1,"orderId":"1","shipmentId":"2","status":"SHIPPED","payload":{"awb":"JNE1"}}`, string(store.lastParams.Payload))
+
+	_, err = bus.Emit(ctx, events.TopicWebhookEndpointDisabled, aggregate, map[string]any{"endpointId": "e-1"})
+	require.NoError(t, err, "topics without a schema still take ad-hoc payloads")
+}
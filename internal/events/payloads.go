@@ -0,0 +1,150 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Schema is embedded in every typed payload. Emit stamps SchemaVersion from
+// the registry, so consumers can tell which contract a payload follows.
+type Schema struct {
+	SchemaVersion int `json:"schemaVersion"`
+}
+
+func (s *Schema) stamp(version int) { s.SchemaVersion = version }
+
+// Payload is a typed domain event payload. Only the types in this package
+// implement it, and each topic in the registry accepts exactly one of them.
+type Payload interface {
+	// Validate reports a payload missing a required field.
+	Validate() error
+	stamp(version int)
+}
+
+// PayloadSchema is the payload contract of a topic. A breaking change to a
+// payload ships as a new Version.
+type PayloadSchema struct {
+	Topic   string `json:"topic"`
+	Version int    `json:"schemaVersion"`
+	typ     reflect.Type
+}
+
+// payloadSchemas registers the typed payload of each topic. Topics without an
+// entry still accept ad-hoc payloads.
+var payloadSchemas = map[string]PayloadSchema{}
+
+func register(version int, sample Payload, topics ...string) {
+	for _, topic := range topics {
+		payloadSchemas[topic] = PayloadSchema{Topic: topic, Version: version, typ: reflect.TypeOf(sample)}
+	}
+}
+
+func init() {
+	register(1, &OrderCreatedPayload{}, TopicOrderCreated)
+	register(1, &OrderPaymentPayload{}, TopicOrderPaid, TopicOrderCanceled, TopicPaymentFailed, TopicPaymentExpired)
+	register(1, &ShipmentPayload{}, TopicShipmentShipped, TopicShipmentOutForDelivery, TopicShipmentDelivered)
+}
+
+// Schemas returns the registered payload schemas ordered by topic.
+func Schemas() []PayloadSchema {
+	out := make([]PayloadSchema, 0, len(payloadSchemas))
+	for _, schema := range payloadSchemas {
+		out = append(out, schema)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Topic < out[j].Topic })
+	return out
+}
+
+// ErrInvalidPayload is returned when a payload does not match the schema
+// registered for its topic.
+var ErrInvalidPayload = errors.New("invalid event payload")
+
+// preparePayload checks payload against the schema registered for topic and
+// stamps its version. Registered topics only accept their typed payload.
+func preparePayload(topic string, payload any) error {
+	schema, registered := payloadSchemas[topic]
+	typed, isTyped := payload.(Payload)
+	switch {
+	case !registered && !isTyped:
+		return nil
+	case !registered:
+		return fmt.Errorf("%w: topic %s has no payload schema", ErrInvalidPayload, topic)
+	case !isTyped || reflect.TypeOf(payload) != schema.typ:
+		return fmt.Errorf("%w: topic %s expects %s, got %T", ErrInvalidPayload, topic, schema.typ, payload)
+	}
+	typed.stamp(schema.Version)
+	if err := typed.Validate(); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrInvalidPayload, topic, err)
+	}
+	return nil
+}
+
+func required(fields map[string]string) error {
+	var missing []string
+	for name, value := range fields {
+		if strings.TrimSpace(value) == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+	sort.Strings(missing)
+	return fmt.Errorf("missing %s", strings.Join(missing, ", "))
+}
+
+// OrderCreatedPayload is the payload of order.created.
+type OrderCreatedPayload struct {
+	Schema
+	OrderID string `json:"orderId"`
+	UserID  string `json:"userId"`
+	Total   int64  `json:"total"`
+	// Email is the customer's address for notifications, when known.
+	Email string `json:"email,omitempty"`
+}
+
+// Validate implements Payload.
+func (p *OrderCreatedPayload) Validate() error {
+	return required(map[string]string{"orderId": p.OrderID, "userId": p.UserID})
+}
+
+// OrderPaymentPayload is the payload of order.paid, order.canceled,
+// payment.failed, and payment.expired, which all follow a payment update.
+type OrderPaymentPayload struct {
+	Schema
+	OrderID   string `json:"orderId"`
+	PaymentID string `json:"paymentId"`
+	// Status is the payment status that produced the event.
+	Status string `json:"status"`
+	UserID string `json:"userId,omitempty"`
+	Email  string `json:"email,omitempty"`
+}
+
+// Validate implements Payload.
+func (p *OrderPaymentPayload) Validate() error {
+	return required(map[string]string{"orderId": p.OrderID, "paymentId": p.PaymentID, "status": p.Status})
+}
+
+// ShipmentPayload is the payload of the shipment.* topics.
+type ShipmentPayload struct {
+	Schema
+	OrderID    string `json:"orderId"`
+	ShipmentID string `json:"shipmentId"`
+	Status     string `json:"status"`
+	UserID     string `json:"userId,omitempty"`
+	Email      string `json:"email,omitempty"`
+	// Payload is the courier's tracking update, passed through as received.
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// Validate implements Payload.
+func (p *ShipmentPayload) Validate() error {
+	if len(p.Payload) > 0 && !json.Valid(p.Payload) {
+		return errors.New("payload is not valid json")
+	}
+	return required(map[string]string{"orderId": p.OrderID, "shipmentId": p.ShipmentID, "status": p.Status})
+}
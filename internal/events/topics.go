@@ -1,5 +1,7 @@
 package events
 
+import "strings"
+
 // Topic constants for domain events emitted by the platform.
 const (
 	TopicOrderCreated           = "order.created"
@@ -10,6 +12,9 @@ const (
 	TopicShipmentShipped        = "shipment.shipped"
 	TopicShipmentOutForDelivery = "shipment.out_for_delivery"
 	TopicShipmentDelivered      = "shipment.delivered"
+	// TopicWebhookEndpointDisabled is internal: the dispatcher emits it when
+	// an endpoint is disabled after repeated failures.
+	TopicWebhookEndpointDisabled = "webhook.endpoint.disabled"
 )
 
 // DefaultTopics returns the canonical list of topics that support notifications.
@@ -25,3 +30,23 @@ func DefaultTopics() []string {
 		TopicShipmentDelivered,
 	}
 }
+
+// SubscribableTopics returns every topic a webhook endpoint may subscribe to:
+// DefaultTopics plus the internal topics the platform emits about itself.
+func SubscribableTopics() []string {
+	return append(DefaultTopics(), TopicWebhookEndpointDisabled)
+}
+
+// TopicWildcard subscribes to every topic.
+const TopicWildcard = "*"
+
+// MatchTopic reports whether a subscription pattern covers topic. Patterns
+// are a topic, TopicWildcard, or a prefix ending in ".*" such as "order.*",
+// which covers every topic below that prefix.
+func MatchTopic(pattern, topic string) bool {
+	if pattern == TopicWildcard || pattern == topic {
+		return true
+	}
+	prefix, ok := strings.CutSuffix(pattern, "*")
+	return ok && strings.HasSuffix(prefix, ".") && strings.HasPrefix(topic, prefix)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"strconv"
 	"sync/atomic"
 	"time"
 )
@@ -14,11 +15,30 @@ type Checker interface {
 	PingRedis(ctx context.Context, timeout time.Duration) error
 }
 
+// SchemaChecker reports the database migration version. The error explains
+// why the schema does not match the build.
+type SchemaChecker interface {
+	SchemaVersion(ctx context.Context) (uint, error)
+}
+
 // Handler exposes HTTP handlers for health endpoints.
 type Handler struct {
 	Checker      Checker
 	DBTimeout    time.Duration
 	RedisTimeout time.Duration
+	// NoRedis skips the Redis probe for deployments running without Redis;
+	// it is reported as disabled.
+	NoRedis bool
+	// Schema, when set, adds the migration version to the readiness report.
+	Schema SchemaChecker
+	// SchemaRequired fails readiness when the schema check fails; otherwise
+	// the problem is only reported.
+	SchemaRequired bool
+	// Worker, when set, adds the background worker's heartbeat to the
+	// readiness report and backs the Worker handler. A heartbeat older than
+	// HeartbeatMaxAge is stale.
+	Heartbeat       WorkerProbe
+	HeartbeatMaxAge time.Duration
 }
 
 var ready atomic.Bool
@@ -55,14 +75,30 @@ func (h Handler) Ready(w http.ResponseWriter, r *http.Request) {
 		dbStatus = err.Error()
 	}
 	redisStatus := "ok"
-	if err := h.Checker.PingRedis(ctx, h.redisTimeout()); err != nil {
+	if h.NoRedis {
+		redisStatus = "disabled"
+	} else if err := h.Checker.PingRedis(ctx, h.redisTimeout()); err != nil {
 		redisStatus = err.Error()
 	}
 	status := map[string]string{
 		"db":    dbStatus,
 		"redis": redisStatus,
 	}
-	if dbStatus != "ok" || redisStatus != "ok" {
+	schemaOK := true
+	if h.Schema != nil {
+		version, err := h.Schema.SchemaVersion(ctx)
+		status["migration"] = strconv.FormatUint(uint64(version), 10)
+		if err != nil {
+			status["migration_error"] = err.Error()
+			schemaOK = !h.SchemaRequired
+		}
+	}
+	// A stalled worker is reported but does not take the API out of rotation;
+	// monitors alert on /health/worker instead.
+	if h.Heartbeat != nil {
+		status["worker"] = h.workerReport(ctx).Status
+	}
+	if dbStatus != "ok" || (redisStatus != "ok" && !h.NoRedis) || !schemaOK {
 		w.WriteHeader(http.StatusServiceUnavailable)
 	} else {
 		w.WriteHeader(http.StatusOK)
@@ -6,6 +6,7 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 	"time"
 
@@ -61,3 +62,62 @@ func TestReadyFailure(t *testing.T) {
 		t.Fatalf("expected 503 got %d", rr.Code)
 	}
 }
+
+func TestReadyWithoutRedis(t *testing.T) {
+	handler := health.Handler{Checker: stubChecker{redisErr: errors.New("redis not configured")}, NoRedis: true}
+	rr := httptest.NewRecorder()
+	handler.Ready(rr, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 got %d", rr.Code)
+	}
+	var status map[string]string
+	if err := json.Unmarshal(rr.Body.Bytes(), &status); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if status["redis"] != "disabled" {
+		t.Fatalf("unexpected status %#v", status)
+	}
+}
+
+type stubSchema struct {
+	version uint
+	err     error
+}
+
+func (s stubSchema) SchemaVersion(_ context.Context) (uint, error) {
+	return s.version, s.err
+}
+
+func TestReadyReportsMigration(t *testing.T) {
+	behind := stubSchema{version: 30, err: errors.New("database not migrated to version 37 (at version 30)")}
+	cases := []struct {
+		name     string
+		schema   stubSchema
+		required bool
+		code     int
+	}{
+		{name: "current", schema: stubSchema{version: 37}, required: true, code: http.StatusOK},
+		{name: "behind warns", schema: behind, code: http.StatusOK},
+		{name: "behind fails", schema: behind, required: true, code: http.StatusServiceUnavailable},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := health.Handler{Checker: stubChecker{}, Schema: tc.schema, SchemaRequired: tc.required}
+			rr := httptest.NewRecorder()
+			handler.Ready(rr, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+			if rr.Code != tc.code {
+				t.Fatalf("expected %d got %d", tc.code, rr.Code)
+			}
+			var status map[string]string
+			if err := json.Unmarshal(rr.Body.Bytes(), &status); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if want := strconv.FormatUint(uint64(tc.schema.version), 10); status["migration"] != want {
+				t.Fatalf("expected migration %s got %#v", want, status)
+			}
+			if tc.schema.err != nil && status["migration_error"] != tc.schema.err.Error() {
+				t.Fatalf("missing migration error in %#v", status)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	redis "github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+)
+
+// WorkerHeartbeatKey is the Redis sorted set holding the last heartbeat of
+// every worker process, scored by unix time in milliseconds.
+func WorkerHeartbeatKey(prefix string) string {
+	if prefix == "" {
+		return "worker:heartbeat"
+	}
+	return prefix + ":worker:heartbeat"
+}
+
+// WorkerHeartbeat publishes a process-level liveness signal for the worker.
+// Every loop registered with Loop must beat within TTL for the process to
+// count as alive, so one hung queue loop stops the heartbeat even while the
+// others keep running.
+type WorkerHeartbeat struct {
+	R        *redis.Client
+	Key      string
+	Instance string
+	// Interval is how often the heartbeat is written; TTL is how long it
+	// stays valid, and how long a loop may go without beating.
+	Interval time.Duration
+	TTL      time.Duration
+	Logger   zerolog.Logger
+	// Now overrides the clock; nil means time.Now.
+	Now func() time.Time
+
+	mu    sync.Mutex
+	loops map[string]time.Time
+}
+
+// Loop registers a loop and returns the function it calls on every
+// iteration.
+func (h *WorkerHeartbeat) Loop(name string) func() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.loops == nil {
+		h.loops = make(map[string]time.Time)
+	}
+	h.loops[name] = h.now()
+	return func() {
+		h.mu.Lock()
+		h.loops[name] = h.now()
+		h.mu.Unlock()
+	}
+}
+
+// Run writes the heartbeat every Interval until ctx is cancelled, and
+// removes this instance's entry on the way out.
+func (h *WorkerHeartbeat) Run(ctx context.Context) error {
+	if h.R == nil {
+		return errors.New("health: worker heartbeat redis client not configured")
+	}
+	interval := h.Interval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := h.Publish(ctx); err != nil && ctx.Err() == nil {
+			h.Logger.Warn().Err(err).Msg("publish worker heartbeat failed")
+		}
+		select {
+		case <-ctx.Done():
+			_ = h.R.ZRem(context.WithoutCancel(ctx), h.Key, h.instance()).Err()
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Publish writes one heartbeat unless a registered loop has stalled, and
+// drops entries of processes that stopped beating longer than TTL ago.
+func (h *WorkerHeartbeat) Publish(ctx context.Context) error {
+	now := h.now()
+	ttl := h.ttl()
+	if stalled := h.stalled(now, ttl); len(stalled) > 0 {
+		h.Logger.Error().Strs("loops", stalled).Dur("ttl", ttl).Msg("worker loop stalled; heartbeat withheld")
+		return nil
+	}
+	_, err := h.R.TxPipelined(ctx, func(p redis.Pipeliner) error {
+		p.ZAdd(ctx, h.Key, redis.Z{Score: float64(now.UnixMilli()), Member: h.instance()})
+		p.ZRemRangeByScore(ctx, h.Key, "-inf", "("+strconv.FormatInt(now.Add(-ttl).UnixMilli(), 10))
+		p.Expire(ctx, h.Key, ttl)
+		return nil
+	})
+	return err
+}
+
+func (h *WorkerHeartbeat) stalled(now time.Time, ttl time.Duration) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var stalled []string
+	for name, last := range h.loops {
+		if now.Sub(last) > ttl {
+			stalled = append(stalled, name)
+		}
+	}
+	slices.Sort(stalled)
+	return stalled
+}
+
+func (h *WorkerHeartbeat) instance() string {
+	if h.Instance != "" {
+		return h.Instance
+	}
+	host, _ := os.Hostname()
+	return host + ":" + strconv.Itoa(os.Getpid())
+}
+
+func (h *WorkerHeartbeat) ttl() time.Duration {
+	if h.TTL <= 0 {
+		return 30 * time.Second
+	}
+	return h.TTL
+}
+
+func (h *WorkerHeartbeat) now() time.Time {
+	if h.Now != nil {
+		return h.Now()
+	}
+	return time.Now()
+}
+
+// WorkerProbe reports the most recent worker heartbeat and how many worker
+// processes are beating. A zero time means no worker has beaten within its
+// TTL.
+type WorkerProbe interface {
+	LastWorkerHeartbeat(ctx context.Context) (time.Time, int, error)
+}
+
+// RedisWorkerProbe reads heartbeats written by WorkerHeartbeat.
+type RedisWorkerProbe struct {
+	R   *redis.Client
+	Key string
+}
+
+// LastWorkerHeartbeat implements WorkerProbe.
+func (p RedisWorkerProbe) LastWorkerHeartbeat(ctx context.Context) (time.Time, int, error) {
+	if p.R == nil {
+		return time.Time{}, 0, errors.New("redis not configured")
+	}
+	entries, err := p.R.ZRevRangeWithScores(ctx, p.Key, 0, -1).Result()
+	if err != nil || len(entries) == 0 {
+		return time.Time{}, 0, err
+	}
+	return time.UnixMilli(int64(entries[0].Score)), len(entries), nil
+}
+
+// WorkerReport is the worker section of the health responses.
+type WorkerReport struct {
+	Status        string     `json:"status"`
+	LastHeartbeat *time.Time `json:"lastHeartbeat,omitempty"`
+	AgeSeconds    *float64   `json:"ageSeconds,omitempty"`
+	Instances     int        `json:"instances"`
+	Error         string     `json:"error,omitempty"`
+}
+
+// Worker handles GET /health/worker: 200 while a worker heartbeat is younger
+// than HeartbeatMaxAge, 503 when it is stale or missing.
+func (h Handler) Worker(w http.ResponseWriter, r *http.Request) {
+	report := h.workerReport(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	if report.Status == "ok" {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
+
+func (h Handler) workerReport(ctx context.Context) WorkerReport {
+	if h.Heartbeat == nil {
+		return WorkerReport{Status: "unknown", Error: "worker heartbeat not configured"}
+	}
+	ctx, cancel := context.WithTimeout(ctx, h.redisTimeout())
+	defer cancel()
+	last, instances, err := h.Heartbeat.LastWorkerHeartbeat(ctx)
+	if err != nil {
+		return WorkerReport{Status: "unknown", Error: err.Error()}
+	}
+	if last.IsZero() {
+		return WorkerReport{Status: "missing"}
+	}
+	age := max(time.Since(last), 0).Seconds()
+	report := WorkerReport{Status: "ok", LastHeartbeat: &last, AgeSeconds: &age, Instances: instances}
+	if age > h.workerMaxAge().Seconds() {
+		report.Status = "stale"
+	}
+	return report
+}
+
+func (h Handler) workerMaxAge() time.Duration {
+	if h.HeartbeatMaxAge <= 0 {
+		return 30 * time.Second
+	}
+	return h.HeartbeatMaxAge
+}
+
+// NewWorkerHeartbeatAge returns a gauge of the seconds since the last worker
+// heartbeat, read from probe on every scrape. It is +Inf when no worker is
+// beating and NaN when the probe fails.
+func NewWorkerHeartbeatAge(namespace string, probe WorkerProbe, timeout time.Duration) prometheus.GaugeFunc {
+	return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "worker_heartbeat_age_seconds",
+		Help:      "Seconds since the most recent worker heartbeat.",
+	}, func() float64 {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		last, _, err := probe.LastWorkerHeartbeat(ctx)
+		switch {
+		case err != nil:
+			return math.NaN()
+		case last.IsZero():
+			return math.Inf(1)
+		}
+		return max(time.Since(last), 0).Seconds()
+	})
+}
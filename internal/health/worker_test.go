@@ -0,0 +1,87 @@
+package health_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	miniredis "github.com/alicebob/miniredis/v2"
+	redis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/health"
+)
+
+func TestWorkerHeartbeatWithheldWhileALoopStalls(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	now := time.Now()
+	key := health.WorkerHeartbeatKey("q")
+	heartbeat := &health.WorkerHeartbeat{R: client, Key: key, Instance: "worker-1", TTL: 30 * time.Second, Now: func() time.Time { return now }}
+	beatWebhook := heartbeat.Loop("webhook")
+	heartbeat.Loop("email")
+	probe := health.RedisWorkerProbe{R: client, Key: key}
+
+	require.NoError(t, heartbeat.Publish(context.Background()))
+	last, instances, err := probe.LastWorkerHeartbeat(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, now.UnixMilli(), last.UnixMilli())
+	require.Equal(t, 1, instances)
+
+	// Only the webhook loop keeps beating; the email loop is hung.
+	now = now.Add(time.Minute)
+	beatWebhook()
+	require.NoError(t, heartbeat.Publish(context.Background()))
+	last, _, err = probe.LastWorkerHeartbeat(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, now.Add(-time.Minute).UnixMilli(), last.UnixMilli(), "a stalled loop stops the heartbeat")
+	require.Positive(t, mr.TTL(key))
+}
+
+type stubProbe struct {
+	last time.Time
+	n    int
+}
+
+func (s stubProbe) LastWorkerHeartbeat(context.Context) (time.Time, int, error) {
+	return s.last, s.n, nil
+}
+
+func TestWorkerHandlerReportsStaleHeartbeat(t *testing.T) {
+	cases := []struct {
+		name   string
+		probe  stubProbe
+		code   int
+		status string
+	}{
+		{name: "fresh", probe: stubProbe{last: time.Now().Add(-5 * time.Second), n: 2}, code: http.StatusOK, status: "ok"},
+		{name: "stale", probe: stubProbe{last: time.Now().Add(-2 * time.Minute), n: 1}, code: http.StatusServiceUnavailable, status: "stale"},
+		{name: "missing", code: http.StatusServiceUnavailable, status: "missing"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			handler := health.Handler{Checker: noopChecker{}, Heartbeat: tc.probe, HeartbeatMaxAge: 30 * time.Second}
+			rr := httptest.NewRecorder()
+			handler.Worker(rr, httptest.NewRequest(http.MethodGet, "/health/worker", nil))
+			require.Equal(t, tc.code, rr.Code)
+			var report health.WorkerReport
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &report))
+			require.Equal(t, tc.status, report.Status)
+			require.Equal(t, tc.probe.n, report.Instances)
+
+			// Readiness only reports the worker; the API stays in rotation.
+			health.SetReady(true)
+			rr = httptest.NewRecorder()
+			handler.Ready(rr, httptest.NewRequest(http.MethodGet, "/health/ready", nil))
+			require.Equal(t, http.StatusOK, rr.Code)
+			var status map[string]string
+			require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &status))
+			require.Equal(t, tc.status, status["worker"])
+		})
+	}
+}
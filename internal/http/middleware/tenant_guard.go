@@ -3,6 +3,7 @@ package middleware
 import (
 	"net/http"
 
+	"github.com/noah-isme/backend-toko/internal/common"
 	"github.com/noah-isme/backend-toko/internal/tenant"
 )
 
@@ -10,9 +11,7 @@ import (
 func RequireTenant(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if _, ok := tenant.From(r.Context()); !ok {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(http.StatusBadRequest)
-			_, _ = w.Write([]byte(`{"error":{"code":"TENANT_REQUIRED","message":"tenant is required"}}`))
+			common.JSONError(w, http.StatusBadRequest, common.CodeTenantRequired, "tenant is required", nil)
 			return
 		}
 		next.ServeHTTP(w, r)
@@ -0,0 +1,171 @@
+// Package inbound makes provider callbacks idempotent: each event is run at
+// most once per source, however often or late the provider retries it.
+package inbound
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+// DefaultClaimTTL is used when Consumer.ClaimTTL is not set.
+const DefaultClaimTTL = 10 * time.Minute
+
+var (
+	// ErrDuplicate is returned by Claim for an event that was already
+	// processed. Acknowledge it so the provider stops retrying.
+	ErrDuplicate = errors.New("inbound: callback already processed")
+	// ErrInFlight is returned by Claim while another delivery of the event is
+	// being processed. Ask the provider to retry later.
+	ErrInFlight = errors.New("inbound: callback is being processed")
+	// ErrExpired is returned by CheckTimestamp for a callback sent outside
+	// the accepted window, such as an old callback replayed after its dedup
+	// row was purged.
+	ErrExpired = errors.New("inbound: callback timestamp outside the accepted window")
+)
+
+// DefaultMaxSkew is used when Consumer.MaxSkew is not set.
+const DefaultMaxSkew = 5 * time.Minute
+
+// Policy overrides the consumer's limits for one source. Zero fields keep the
+// consumer's values.
+type Policy struct {
+	MaxAge   time.Duration
+	ClaimTTL time.Duration
+}
+
+// Queries is the subset of dbgen.Queries the consumer needs.
+type Queries interface {
+	ClaimInboundWebhook(ctx context.Context, arg dbgen.ClaimInboundWebhookParams) (pgtype.Timestamptz, error)
+	GetInboundWebhookProcessedAt(ctx context.Context, arg dbgen.GetInboundWebhookProcessedAtParams) (pgtype.Timestamptz, error)
+	CompleteInboundWebhook(ctx context.Context, arg dbgen.CompleteInboundWebhookParams) error
+	ReleaseInboundWebhook(ctx context.Context, arg dbgen.ReleaseInboundWebhookParams) error
+}
+
+// Consumer records processed callbacks in the database so duplicates are
+// recognised for as long as the rows are kept.
+type Consumer struct {
+	Q Queries
+	// ClaimTTL is how long a delivery that never finished (e.g. the process
+	// died) blocks retries before one may take it over.
+	ClaimTTL time.Duration
+	// MaxAge rejects callbacks whose provider timestamp is older than this;
+	// zero accepts any age. Keep it shorter than the dedup rows are retained.
+	MaxAge time.Duration
+	// MaxSkew is how far ahead of this server's clock a provider timestamp
+	// may be.
+	MaxSkew time.Duration
+	// Sources overrides MaxAge and ClaimTTL per source, e.g.
+	// "payment:midtrans".
+	Sources map[string]Policy
+	// Now overrides the clock; nil means time.Now.
+	Now func() time.Time
+}
+
+func (c *Consumer) now() time.Time {
+	if c.Now != nil {
+		return c.Now()
+	}
+	return time.Now()
+}
+
+func (c *Consumer) claimTTL(source string) time.Duration {
+	if ttl := c.Sources[source].ClaimTTL; ttl > 0 {
+		return ttl
+	}
+	if c.ClaimTTL > 0 {
+		return c.ClaimTTL
+	}
+	return DefaultClaimTTL
+}
+
+// CheckTimestamp rejects a callback of source sent at sentAt when it is older
+// than the source's MaxAge or further in the future than MaxSkew. A zero
+// sentAt, from a provider that sends no timestamp, is accepted and left to
+// the dedup in Claim.
+func (c *Consumer) CheckTimestamp(source string, sentAt time.Time) error {
+	if sentAt.IsZero() {
+		return nil
+	}
+	maxAge := c.MaxAge
+	if override := c.Sources[source].MaxAge; override > 0 {
+		maxAge = override
+	}
+	skew := c.MaxSkew
+	if skew <= 0 {
+		skew = DefaultMaxSkew
+	}
+	age := c.now().Sub(sentAt)
+	switch {
+	case age < -skew:
+		return fmt.Errorf("%w: sent %s in the future", ErrExpired, (-age).Round(time.Second))
+	case maxAge > 0 && age > maxAge:
+		return fmt.Errorf("%w: sent %s ago, limit %s", ErrExpired, age.Round(time.Second), maxAge)
+	}
+	return nil
+}
+
+// Key derives the dedup key of a callback. Providers' event or transaction
+// identifiers are preferred; without one, identical bodies are treated as the
+// same event.
+func Key(eventID string, body []byte) string {
+	if id := strings.TrimSpace(eventID); id != "" {
+		return "id:" + id
+	}
+	return "sha256:" + common.Sha256Hex(string(body))
+}
+
+// Claim reserves key for the caller, who must call Finish once processing
+// ends. It returns ErrDuplicate or ErrInFlight when the event must not be
+// processed now.
+func (c *Consumer) Claim(ctx context.Context, source, key string) error {
+	ttl := c.claimTTL(source)
+	_, err := c.Q.ClaimInboundWebhook(ctx, dbgen.ClaimInboundWebhookParams{
+		Source:        source,
+		DedupKey:      key,
+		StaleAfterSec: ttl.Seconds(),
+	})
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+	processedAt, err := c.Q.GetInboundWebhookProcessedAt(ctx, dbgen.GetInboundWebhookProcessedAtParams{Source: source, DedupKey: key})
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		// Released between the two queries; the provider will retry.
+		return ErrInFlight
+	case err != nil:
+		return err
+	case processedAt.Valid:
+		return ErrDuplicate
+	default:
+		return ErrInFlight
+	}
+}
+
+// WithQueries returns a copy of the consumer that runs its queries on q, so
+// Finish can commit together with the caller's transaction.
+func (c *Consumer) WithQueries(q Queries) *Consumer {
+	clone := *c
+	clone.Q = q
+	return &clone
+}
+
+// Finish records a processed event, or releases the claim when processing
+// failed so the provider's retry runs it again.
+func (c *Consumer) Finish(ctx context.Context, source, key string, processed bool) error {
+	if processed {
+		return c.Q.CompleteInboundWebhook(ctx, dbgen.CompleteInboundWebhookParams{Source: source, DedupKey: key})
+	}
+	return c.Q.ReleaseInboundWebhook(ctx, dbgen.ReleaseInboundWebhookParams{Source: source, DedupKey: key})
+}
@@ -0,0 +1,227 @@
+package inbound_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/inbound"
+)
+
+type row struct {
+	claimedAt   time.Time
+	processedAt time.Time
+}
+
+// fakeQueries mirrors the inbound_webhook_events queries on a map.
+type fakeQueries struct {
+	mu   sync.Mutex
+	now  time.Time
+	rows map[string]*row
+}
+
+func newFakeQueries() *fakeQueries {
+	return &fakeQueries{now: time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC), rows: map[string]*row{}}
+}
+
+func (f *fakeQueries) advance(d time.Duration) {
+	f.mu.Lock()
+	f.now = f.now.Add(d)
+	f.mu.Unlock()
+}
+
+func (f *fakeQueries) ClaimInboundWebhook(_ context.Context, arg dbgen.ClaimInboundWebhookParams) (pgtype.Timestamptz, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := arg.Source + "|" + arg.DedupKey
+	r, ok := f.rows[id]
+	stale := ok && r.processedAt.IsZero() && f.now.Sub(r.claimedAt) > time.Duration(arg.StaleAfterSec*float64(time.Second))
+	if ok && !stale {
+		return pgtype.Timestamptz{}, pgx.ErrNoRows
+	}
+	f.rows[id] = &row{claimedAt: f.now}
+	return pgtype.Timestamptz{Time: f.now, Valid: true}, nil
+}
+
+func (f *fakeQueries) GetInboundWebhookProcessedAt(_ context.Context, arg dbgen.GetInboundWebhookProcessedAtParams) (pgtype.Timestamptz, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	r, ok := f.rows[arg.Source+"|"+arg.DedupKey]
+	if !ok {
+		return pgtype.Timestamptz{}, pgx.ErrNoRows
+	}
+	return pgtype.Timestamptz{Time: r.processedAt, Valid: !r.processedAt.IsZero()}, nil
+}
+
+func (f *fakeQueries) CompleteInboundWebhook(_ context.Context, arg dbgen.CompleteInboundWebhookParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r, ok := f.rows[arg.Source+"|"+arg.DedupKey]; ok {
+		r.processedAt = f.now
+	}
+	return nil
+}
+
+func (f *fakeQueries) ReleaseInboundWebhook(_ context.Context, arg dbgen.ReleaseInboundWebhookParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	id := arg.Source + "|" + arg.DedupKey
+	if r, ok := f.rows[id]; ok && r.processedAt.IsZero() {
+		delete(f.rows, id)
+	}
+	return nil
+}
+
+// deliver plays one provider callback: claim, apply the state transition,
+// and finish, the way the webhook handlers do.
+func deliver(c *inbound.Consumer, key string, transition func() error) error {
+	ctx := context.Background()
+	if err := c.Claim(ctx, "payment:midtrans", key); err != nil {
+		return err
+	}
+	err := transition()
+	if finishErr := c.Finish(ctx, "payment:midtrans", key, err == nil); finishErr != nil {
+		return finishErr
+	}
+	return err
+}
+
+func TestDuplicateCallbacksTransitionOnce(t *testing.T) {
+	q := newFakeQueries()
+	c := &inbound.Consumer{Q: q, ClaimTTL: time.Minute}
+	key := inbound.Key("txn-1:settlement", []byte(`{"transaction_id":"txn-1"}`))
+	var transitions atomic.Int32
+	settle := func() error {
+		transitions.Add(1)
+		return nil
+	}
+
+	require.NoError(t, deliver(c, key, settle))
+	require.ErrorIs(t, deliver(c, key, settle), inbound.ErrDuplicate)
+
+	// A retry days later, long after any cache TTL, is still recognised.
+	q.advance(72 * time.Hour)
+	require.ErrorIs(t, deliver(c, key, settle), inbound.ErrDuplicate)
+	require.EqualValues(t, 1, transitions.Load())
+
+	// The next status of the same transaction is a different event.
+	require.NoError(t, deliver(c, inbound.Key("txn-1:refund", nil), settle))
+	require.EqualValues(t, 2, transitions.Load())
+}
+
+func TestConcurrentDuplicatesTransitionOnce(t *testing.T) {
+	c := &inbound.Consumer{Q: newFakeQueries()}
+	key := inbound.Key("txn-2:settlement", nil)
+	var transitions atomic.Int32
+	release := make(chan struct{})
+
+	const deliveries = 8
+	errs := make(chan error, deliveries)
+	var wg sync.WaitGroup
+	for i := 0; i < deliveries; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- deliver(c, key, func() error {
+				transitions.Add(1)
+				<-release
+				return nil
+			})
+		}()
+	}
+	require.Eventually(t, func() bool { return transitions.Load() == 1 }, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+
+	var ok, inFlight int
+	for err := range errs {
+		switch {
+		case err == nil:
+			ok++
+		case errors.Is(err, inbound.ErrInFlight), errors.Is(err, inbound.ErrDuplicate):
+			inFlight++
+		default:
+			t.Fatalf("unexpected error %v", err)
+		}
+	}
+	require.Equal(t, 1, ok)
+	require.Equal(t, deliveries-1, inFlight)
+	require.EqualValues(t, 1, transitions.Load())
+}
+
+func TestFailedCallbackIsRetried(t *testing.T) {
+	q := newFakeQueries()
+	c := &inbound.Consumer{Q: q, ClaimTTL: time.Minute}
+	key := inbound.Key("", []byte(`{"orderId":"o-1","externalStatus":"delivered"}`))
+	var transitions int
+
+	boom := errors.New("db down")
+	require.ErrorIs(t, deliver(c, key, func() error { return boom }), boom)
+	require.NoError(t, deliver(c, key, func() error { transitions++; return nil }))
+	require.ErrorIs(t, deliver(c, key, func() error { transitions++; return nil }), inbound.ErrDuplicate)
+	require.Equal(t, 1, transitions)
+}
+
+func TestAbandonedClaimIsTakenOver(t *testing.T) {
+	q := newFakeQueries()
+	c := &inbound.Consumer{Q: q, ClaimTTL: time.Minute}
+	key := inbound.Key("evt-9", nil)
+	ctx := context.Background()
+
+	// The first delivery claims the event and dies without finishing.
+	require.NoError(t, c.Claim(ctx, "shipping:jne", key))
+	require.ErrorIs(t, c.Claim(ctx, "shipping:jne", key), inbound.ErrInFlight)
+
+	q.advance(2 * time.Minute)
+	require.NoError(t, c.Claim(ctx, "shipping:jne", key))
+	require.NoError(t, c.Finish(ctx, "shipping:jne", key, true))
+	require.ErrorIs(t, c.Claim(ctx, "shipping:jne", key), inbound.ErrDuplicate)
+	// Sources are deduplicated separately.
+	require.NoError(t, c.Claim(ctx, "shipping:sicepat", key))
+}
+
+func TestKeyPrefersEventID(t *testing.T) {
+	body := []byte(`{"id":"a"}`)
+	require.Equal(t, "id:evt-1", inbound.Key(" evt-1 ", body))
+	require.Equal(t, inbound.Key("", body), inbound.Key("", []byte(`{"id":"a"}`)))
+	require.NotEqual(t, inbound.Key("", body), inbound.Key("", []byte(`{"id":"b"}`)))
+}
+
+func TestCheckTimestampEnforcesWindowPerSource(t *testing.T) {
+	now := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	c := &inbound.Consumer{
+		MaxAge:  24 * time.Hour,
+		MaxSkew: time.Minute,
+		Sources: map[string]inbound.Policy{"payment:xendit": {MaxAge: time.Hour}},
+		Now:     func() time.Time { return now },
+	}
+
+	require.NoError(t, c.CheckTimestamp("payment:midtrans", now.Add(-23*time.Hour)))
+	require.ErrorIs(t, c.CheckTimestamp("payment:midtrans", now.Add(-25*time.Hour)), inbound.ErrExpired)
+	require.ErrorIs(t, c.CheckTimestamp("payment:xendit", now.Add(-2*time.Hour)), inbound.ErrExpired)
+	require.NoError(t, c.CheckTimestamp("payment:xendit", now.Add(30*time.Second)), "small clock skew is tolerated")
+	require.ErrorIs(t, c.CheckTimestamp("payment:xendit", now.Add(time.Hour)), inbound.ErrExpired)
+	require.NoError(t, c.CheckTimestamp("payment:xendit", time.Time{}), "callbacks without a timestamp rely on dedup")
+}
+
+func TestClaimTTLPerSource(t *testing.T) {
+	q := newFakeQueries()
+	c := &inbound.Consumer{Q: q, ClaimTTL: time.Hour, Sources: map[string]inbound.Policy{"shipping:jne": {ClaimTTL: time.Minute}}}
+	key := inbound.Key("evt-1", nil)
+	ctx := context.Background()
+
+	require.NoError(t, c.Claim(ctx, "shipping:jne", key))
+	require.NoError(t, c.Claim(ctx, "payment:midtrans", key))
+	q.advance(2 * time.Minute)
+	require.NoError(t, c.Claim(ctx, "shipping:jne", key), "the shorter override lets a retry take over")
+	require.ErrorIs(t, c.Claim(ctx, "payment:midtrans", key), inbound.ErrInFlight)
+}
@@ -4,12 +4,16 @@ import (
 	"context"
 	"errors"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/redis/go-redis/v9"
 )
 
+// ErrLocked is returned by the Try variants when another holder owns the lock.
+var ErrLocked = errors.New("lock: already held")
+
 // Locker provides a Redis-backed distributed lock.
 type Locker struct {
 	R            *redis.Client
@@ -20,12 +24,114 @@ type Locker struct {
 // released automatically even if fn returns an error. When the lock cannot be
 // acquired before the context is cancelled an error is returned.
 func (l Locker) WithLock(ctx context.Context, key string, ttl time.Duration, fn func(context.Context) error) error {
-	if l.R == nil {
-		return errors.New("lock: redis client not configured")
+	if fn == nil {
+		return errors.New("lock: callback not provided")
+	}
+	token, err := l.acquire(ctx, key, ttl)
+	if err != nil {
+		return err
+	}
+	defer l.release(context.Background(), key, token)
+	return fn(ctx)
+}
+
+// AcquireWithRenewal obtains the lock and keeps extending it every renewEvery
+// for as long as it is held. The returned context is cancelled when the lease
+// can no longer be renewed (the key expired or is owned by someone else), so
+// work bound to it stops before another holder can take over. The release
+// function stops renewal and deletes the lock; it is safe to call more than once.
+func (l Locker) AcquireWithRenewal(ctx context.Context, key string, ttl, renewEvery time.Duration) (context.Context, func(), error) {
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	if renewEvery <= 0 || renewEvery >= ttl {
+		renewEvery = ttl / 3
+	}
+	token, err := l.acquire(ctx, key, ttl)
+	if err != nil {
+		return nil, nil, err
+	}
+	leaseCtx, release := l.lease(ctx, key, token, ttl, renewEvery)
+	return leaseCtx, release, nil
+}
+
+// lease keeps a held lock renewed until the returned release is called.
+func (l Locker) lease(ctx context.Context, key, token string, ttl, renewEvery time.Duration) (context.Context, func()) {
+	leaseCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(renewEvery)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-leaseCtx.Done():
+				return
+			case <-ticker.C:
+				if !l.renew(leaseCtx, key, token, ttl) {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			cancel()
+			<-done
+			l.release(context.Background(), key, token)
+		})
+	}
+	return leaseCtx, release
+}
+
+// WithRenewingLock behaves like WithLock but renews the lease while fn runs.
+// fn receives a context that is cancelled if ownership of the lock is lost.
+func (l Locker) WithRenewingLock(ctx context.Context, key string, ttl, renewEvery time.Duration, fn func(context.Context) error) error {
+	if fn == nil {
+		return errors.New("lock: callback not provided")
+	}
+	leaseCtx, release, err := l.AcquireWithRenewal(ctx, key, ttl, renewEvery)
+	if err != nil {
+		return err
 	}
+	defer release()
+	return fn(leaseCtx)
+}
+
+// TryWithRenewingLock behaves like WithRenewingLock but does not wait: when
+// the lock is already held it returns ErrLocked without calling fn.
+func (l Locker) TryWithRenewingLock(ctx context.Context, key string, ttl, renewEvery time.Duration, fn func(context.Context) error) error {
 	if fn == nil {
 		return errors.New("lock: callback not provided")
 	}
+	if l.R == nil {
+		return errors.New("lock: redis client not configured")
+	}
+	if ttl <= 0 {
+		ttl = 30 * time.Second
+	}
+	if renewEvery <= 0 || renewEvery >= ttl {
+		renewEvery = ttl / 3
+	}
+	token := uuid.NewString()
+	ok, err := l.R.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrLocked
+	}
+	leaseCtx, release := l.lease(ctx, key, token, ttl, renewEvery)
+	defer release()
+	return fn(leaseCtx)
+}
+
+func (l Locker) acquire(ctx context.Context, key string, ttl time.Duration) (string, error) {
+	if l.R == nil {
+		return "", errors.New("lock: redis client not configured")
+	}
 	if ttl <= 0 {
 		ttl = 30 * time.Second
 	}
@@ -38,22 +144,35 @@ func (l Locker) WithLock(ctx context.Context, key string, ttl time.Duration, fn
 	for {
 		ok, err := l.R.SetNX(ctx, key, token, ttl).Result()
 		if err != nil {
-			return err
+			return "", err
 		}
 		if ok {
-			defer l.release(context.Background(), key, token)
-			return fn(ctx)
+			return token, nil
 		}
 		timer := time.NewTimer(retry)
 		select {
 		case <-ctx.Done():
 			timer.Stop()
-			return ctx.Err()
+			return "", ctx.Err()
 		case <-timer.C:
 		}
 	}
 }
 
+// renew extends the lock TTL only while the stored token still matches ours.
+func (l Locker) renew(ctx context.Context, key, token string, ttl time.Duration) bool {
+	const script = `if redis.call("get", KEYS[1]) == ARGV[1] then
+  return redis.call("pexpire", KEYS[1], ARGV[2])
+else
+  return 0
+end`
+	res, err := l.R.Eval(ctx, script, []string{key}, token, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false
+	}
+	return res == 1
+}
+
 func (l Locker) release(ctx context.Context, key, token string) {
 	const script = `if redis.call("get", KEYS[1]) == ARGV[1] then
   return redis.call("del", KEYS[1])
@@ -66,3 +66,87 @@ func TestWithLockIdempotent(t *testing.T) {
 	defer mu.Unlock()
 	require.Equal(t, []string{"first", "second"}, order)
 }
+
+func TestAcquireWithRenewalOutlivesBaseTTL(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	locker := lock.Locker{R: client, RetryBackoff: 5 * time.Millisecond}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	const ttl = 100 * time.Millisecond
+	err = locker.WithRenewingLock(ctx, "long-job", ttl, 10*time.Millisecond, func(leaseCtx context.Context) error {
+		// Simulate a handler running five times longer than the base TTL.
+		for i := 0; i < 10; i++ {
+			time.Sleep(30 * time.Millisecond)
+			mr.FastForward(50 * time.Millisecond)
+			require.True(t, mr.Exists("long-job"), "lock expired despite renewal at step %d", i)
+		}
+		require.NoError(t, leaseCtx.Err())
+
+		otherCtx, otherCancel := context.WithTimeout(ctx, 30*time.Millisecond)
+		defer otherCancel()
+		err := locker.WithLock(otherCtx, "long-job", ttl, func(context.Context) error {
+			t.Fatal("second holder must not acquire a renewed lock")
+			return nil
+		})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		return nil
+	})
+	require.NoError(t, err)
+	require.False(t, mr.Exists("long-job"), "lock should be released after the handler returns")
+}
+
+func TestAcquireWithRenewalCancelsWhenOwnershipLost(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	locker := lock.Locker{R: client}
+	leaseCtx, release, err := locker.AcquireWithRenewal(context.Background(), "stolen", time.Second, 10*time.Millisecond)
+	require.NoError(t, err)
+	defer release()
+
+	require.NoError(t, mr.Set("stolen", "someone-else"))
+
+	select {
+	case <-leaseCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("lease context should be cancelled once the lock is owned by another token")
+	}
+	release()
+	got, err := mr.Get("stolen")
+	require.NoError(t, err)
+	require.Equal(t, "someone-else", got, "release must not delete a lock we no longer own")
+}
+
+func TestTryWithRenewingLockDoesNotWait(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	locker := lock.Locker{R: client}
+	ctx := context.Background()
+	err := locker.TryWithRenewingLock(ctx, "demo", time.Second, 0, func(context.Context) error {
+		calls := 0
+		inner := locker.TryWithRenewingLock(ctx, "demo", time.Second, 0, func(context.Context) error {
+			calls++
+			return nil
+		})
+		require.ErrorIs(t, inner, lock.ErrLocked)
+		require.Zero(t, calls)
+		return nil
+	})
+	require.NoError(t, err)
+	require.False(t, mr.Exists("demo"), "lock released after fn returns")
+
+	require.NoError(t, locker.TryWithRenewingLock(ctx, "demo", time.Second, 0, func(context.Context) error { return nil }))
+}
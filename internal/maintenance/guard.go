@@ -0,0 +1,185 @@
+package maintenance
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	"github.com/noah-isme/backend-toko/internal/obs"
+)
+
+// BypassHeader carries the token that lets operators through maintenance.
+const BypassHeader = "X-Maintenance-Bypass"
+
+// Guard rejects traffic with 503 while maintenance is active. A non-off
+// Static state (from env) wins over the shared Store state.
+type Guard struct {
+	Store  Store
+	Static State
+	// BypassToken lets requests presenting it in BypassHeader through.
+	BypassToken string
+	// Exempt lists path prefixes that are always served, e.g. the admin
+	// endpoint used to end maintenance.
+	Exempt []string
+	// DefaultRetryAfter is used when the state carries no hint. Zero uses 5 minutes.
+	DefaultRetryAfter time.Duration
+	// Refresh bounds how often Store is read. Zero uses 2 seconds.
+	Refresh time.Duration
+	Logger  *zerolog.Logger
+
+	mu       sync.Mutex
+	cached   State
+	loadedAt time.Time
+	lastMode string
+	now      func() time.Time
+}
+
+// Current returns the effective state, reading Store at most once per Refresh.
+// Store failures keep the last known state so an outage does not flip modes.
+func (g *Guard) Current(ctx context.Context) State {
+	now := g.clock()
+	if g.Static.Active(now) {
+		g.observe(g.Static)
+		return g.Static
+	}
+	refresh := g.Refresh
+	if refresh <= 0 {
+		refresh = 2 * time.Second
+	}
+	g.mu.Lock()
+	state, fresh := g.cached, !g.loadedAt.IsZero() && now.Sub(g.loadedAt) < refresh
+	g.mu.Unlock()
+	if !fresh && g.Store != nil {
+		loaded, err := g.Store.Get(ctx)
+		g.mu.Lock()
+		g.loadedAt = now
+		if err == nil {
+			g.cached = loaded
+			state = loaded
+		} else if g.Logger != nil {
+			g.Logger.Error().Err(err).Msg("maintenance state lookup failed")
+		}
+		g.mu.Unlock()
+	}
+	if !state.Active(now) {
+		state = State{Mode: ModeOff}
+	}
+	g.observe(state)
+	return state
+}
+
+// Invalidate forces the next request to re-read Store.
+func (g *Guard) Invalidate() {
+	g.mu.Lock()
+	g.loadedAt = time.Time{}
+	g.mu.Unlock()
+}
+
+// Middleware enforces the current maintenance mode.
+func (g *Guard) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		state := g.Current(r.Context())
+		if state.Mode == ModeOff || g.exempt(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if state.Mode == ModeReadOnly && isRead(r.Method) {
+			w.Header().Set("X-Maintenance-Mode", state.Mode)
+			next.ServeHTTP(w, r)
+			return
+		}
+		retryAfter := g.retryAfter(state)
+		if obs.MaintenanceRejectedTotal != nil {
+			obs.MaintenanceRejectedTotal.WithLabelValues(state.Mode).Inc()
+		}
+		message := state.Message
+		if message == "" {
+			message = "service is under maintenance"
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		w.Header().Set("X-Maintenance-Mode", state.Mode)
+		common.JSONError(w, http.StatusServiceUnavailable, common.CodeMaintenance, message, map[string]any{
+			"mode":       state.Mode,
+			"retryAfter": retryAfter,
+		})
+	})
+}
+
+func (g *Guard) exempt(r *http.Request) bool {
+	for _, prefix := range g.Exempt {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return true
+		}
+	}
+	if g.BypassToken == "" {
+		return false
+	}
+	token := r.Header.Get(BypassHeader)
+	return token != "" && subtle.ConstantTimeCompare([]byte(token), []byte(g.BypassToken)) == 1
+}
+
+func (g *Guard) retryAfter(state State) int {
+	if state.Until != nil {
+		if secs := int(state.Until.Sub(g.clock()).Seconds()) + 1; secs > 0 {
+			return secs
+		}
+	}
+	if state.RetryAfter > 0 {
+		return state.RetryAfter
+	}
+	if g.DefaultRetryAfter > 0 {
+		return int(g.DefaultRetryAfter.Seconds())
+	}
+	return 300
+}
+
+// observe logs mode transitions and keeps the gauge in sync.
+func (g *Guard) observe(state State) {
+	g.mu.Lock()
+	prev := g.lastMode
+	g.lastMode = state.Mode
+	g.mu.Unlock()
+	if prev == state.Mode {
+		return
+	}
+	if obs.MaintenanceActive != nil {
+		for _, mode := range []string{ModeReadOnly, ModeOffline} {
+			value := 0.0
+			if mode == state.Mode {
+				value = 1
+			}
+			obs.MaintenanceActive.WithLabelValues(mode).Set(value)
+		}
+	}
+	if g.Logger == nil || (prev == "" && state.Mode == ModeOff) {
+		return
+	}
+	if state.Mode == ModeOff {
+		g.Logger.Info().Str("previous_mode", prev).Msg("maintenance mode ended")
+		return
+	}
+	g.Logger.Warn().Str("mode", state.Mode).Str("message", state.Message).Msg("maintenance mode active")
+}
+
+func (g *Guard) clock() time.Time {
+	if g.now != nil {
+		return g.now()
+	}
+	return time.Now()
+}
+
+func isRead(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	default:
+		return false
+	}
+}
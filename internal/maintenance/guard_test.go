@@ -0,0 +1,102 @@
+package maintenance_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/maintenance"
+)
+
+var okHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusNoContent)
+})
+
+func serve(h http.Handler, method, path string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestGuardReadOnlyRejectsWrites(t *testing.T) {
+	store := &maintenance.MemoryStore{}
+	require.NoError(t, store.Set(context.Background(), maintenance.State{Mode: maintenance.ModeReadOnly, RetryAfter: 42}))
+	h := (&maintenance.Guard{Store: store}).Middleware(okHandler)
+
+	require.Equal(t, http.StatusNoContent, serve(h, http.MethodGet, "/api/v1/products", nil).Code)
+
+	rec := serve(h, http.MethodPost, "/api/v1/carts", nil)
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Equal(t, "42", rec.Header().Get("Retry-After"))
+	var body struct {
+		Error struct {
+			Code    string         `json:"code"`
+			Details map[string]any `json:"details"`
+		} `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	require.Equal(t, "MAINTENANCE", body.Error.Code)
+	require.Equal(t, maintenance.ModeReadOnly, body.Error.Details["mode"])
+}
+
+func TestGuardOfflineHonoursBypassAndExemptions(t *testing.T) {
+	guard := &maintenance.Guard{
+		Static:      maintenance.State{Mode: maintenance.ModeOffline},
+		BypassToken: "ops-secret",
+		Exempt:      []string{"/api/v1/admin/maintenance"},
+	}
+	h := guard.Middleware(okHandler)
+
+	rec := serve(h, http.MethodGet, "/api/v1/products", nil)
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Equal(t, "300", rec.Header().Get("Retry-After"))
+
+	wrong := http.Header{maintenance.BypassHeader: {"nope"}}
+	require.Equal(t, http.StatusServiceUnavailable, serve(h, http.MethodGet, "/api/v1/products", wrong).Code)
+	bypass := http.Header{maintenance.BypassHeader: {"ops-secret"}}
+	require.Equal(t, http.StatusNoContent, serve(h, http.MethodPost, "/api/v1/carts", bypass).Code)
+	require.Equal(t, http.StatusNoContent, serve(h, http.MethodDelete, "/api/v1/admin/maintenance", nil).Code)
+}
+
+func TestAdminToggleIsSharedAcrossInstances(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	instanceA := &maintenance.Guard{Store: maintenance.RedisStore{R: client}}
+	instanceB := &maintenance.Guard{Store: maintenance.RedisStore{R: client}, Refresh: time.Nanosecond}
+	admin := &maintenance.AdminHandler{Guard: instanceA}
+	h := instanceB.Middleware(okHandler)
+
+	require.Equal(t, http.StatusNoContent, serve(h, http.MethodPost, "/api/v1/carts", nil).Code)
+
+	rec := httptest.NewRecorder()
+	admin.Put(rec, httptest.NewRequest(http.MethodPut, "/api/v1/admin/maintenance", strings.NewReader(`{"mode":"read_only","durationSec":600}`)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Greater(t, mr.TTL(maintenance.DefaultKey), time.Duration(0), "timed windows expire on their own")
+
+	rec = serve(h, http.MethodPost, "/api/v1/carts", nil)
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.Equal(t, http.StatusNoContent, serve(h, http.MethodGet, "/api/v1/products", nil).Code)
+
+	rec = httptest.NewRecorder()
+	admin.Put(rec, httptest.NewRequest(http.MethodPut, "/api/v1/admin/maintenance", strings.NewReader(`{"mode":"sideways"}`)))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	admin.Delete(rec, httptest.NewRequest(http.MethodDelete, "/api/v1/admin/maintenance", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, http.StatusNoContent, serve(h, http.MethodPost, "/api/v1/carts", nil).Code)
+}
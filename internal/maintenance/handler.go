@@ -0,0 +1,100 @@
+package maintenance
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+)
+
+// AdminHandler lets operators inspect and toggle the shared maintenance state.
+type AdminHandler struct {
+	Guard *Guard
+}
+
+type statePayload struct {
+	Mode    string `json:"mode"`
+	Message string `json:"message"`
+	// RetryAfterSec is the Retry-After hint returned to rejected clients.
+	RetryAfterSec int `json:"retryAfterSec"`
+	// DurationSec ends maintenance automatically after this many seconds.
+	DurationSec int `json:"durationSec"`
+}
+
+// Get returns the effective maintenance state.
+func (h *AdminHandler) Get(w http.ResponseWriter, r *http.Request) {
+	if h.Guard == nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "maintenance not configured", nil)
+		return
+	}
+	h.Guard.Invalidate()
+	state := h.Guard.Current(r.Context())
+	common.JSON(w, http.StatusOK, map[string]any{"data": map[string]any{
+		"state":  state,
+		"static": h.Guard.Static.Active(time.Now()),
+	}})
+}
+
+// Put enables maintenance for every instance; mode "off" clears it.
+func (h *AdminHandler) Put(w http.ResponseWriter, r *http.Request) {
+	if h.Guard == nil || h.Guard.Store == nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "maintenance not configured", nil)
+		return
+	}
+	var payload statePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		common.JSONError(w, http.StatusBadRequest, common.CodeInvalidBody, "invalid payload", nil)
+		return
+	}
+	mode, ok := ParseMode(payload.Mode)
+	if !ok || strings.TrimSpace(payload.Mode) == "" {
+		common.JSONError(w, http.StatusBadRequest, common.CodeValidation, "mode must be off, read_only, or offline", map[string]any{"field": "mode"})
+		return
+	}
+	if payload.RetryAfterSec < 0 || payload.DurationSec < 0 {
+		common.JSONError(w, http.StatusBadRequest, common.CodeValidation, "retryAfterSec and durationSec cannot be negative", nil)
+		return
+	}
+	ctx := r.Context()
+	if mode == ModeOff {
+		h.Delete(w, r)
+		return
+	}
+	now := time.Now().UTC()
+	state := State{
+		Mode:       mode,
+		Message:    strings.TrimSpace(payload.Message),
+		RetryAfter: payload.RetryAfterSec,
+		UpdatedAt:  now,
+	}
+	if userID, ok := common.UserID(ctx); ok {
+		state.UpdatedBy = userID
+	}
+	if payload.DurationSec > 0 {
+		until := now.Add(time.Duration(payload.DurationSec) * time.Second)
+		state.Until = &until
+	}
+	if err := h.Guard.Store.Set(ctx, state); err != nil {
+		common.JSONError(w, http.StatusServiceUnavailable, common.CodeUnavailable, "could not store maintenance state", nil)
+		return
+	}
+	h.Guard.Invalidate()
+	common.JSON(w, http.StatusOK, map[string]any{"data": map[string]any{"state": state}})
+}
+
+// Delete ends maintenance set through the API. Maintenance forced via env
+// stays active until the instances are restarted without it.
+func (h *AdminHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if h.Guard == nil || h.Guard.Store == nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "maintenance not configured", nil)
+		return
+	}
+	if err := h.Guard.Store.Clear(r.Context()); err != nil {
+		common.JSONError(w, http.StatusServiceUnavailable, common.CodeUnavailable, "could not clear maintenance state", nil)
+		return
+	}
+	h.Guard.Invalidate()
+	h.Get(w, r)
+}
@@ -0,0 +1,153 @@
+package maintenance
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Maintenance modes. ReadOnly rejects writes; Offline rejects every request.
+const (
+	ModeOff      = "off"
+	ModeReadOnly = "read_only"
+	ModeOffline  = "offline"
+)
+
+// DefaultKey is the Redis key the shared maintenance state is stored under.
+const DefaultKey = "maintenance:state"
+
+// State describes the maintenance window shared by all API instances.
+type State struct {
+	Mode    string `json:"mode"`
+	Message string `json:"message,omitempty"`
+	// RetryAfter is the Retry-After hint in seconds when Until is unset.
+	RetryAfter int        `json:"retryAfter,omitempty"`
+	Until      *time.Time `json:"until,omitempty"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+	UpdatedBy  string     `json:"updatedBy,omitempty"`
+}
+
+// Active reports whether the state rejects any traffic at now.
+func (s State) Active(now time.Time) bool {
+	if s.Mode != ModeReadOnly && s.Mode != ModeOffline {
+		return false
+	}
+	return s.Until == nil || now.Before(*s.Until)
+}
+
+// ParseMode normalises a mode name; empty input means ModeOff.
+func ParseMode(raw string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(strings.ReplaceAll(raw, "-", "_"))) {
+	case "", ModeOff, "false":
+		return ModeOff, true
+	case ModeReadOnly, "readonly":
+		return ModeReadOnly, true
+	case ModeOffline, "true", "on":
+		return ModeOffline, true
+	default:
+		return "", false
+	}
+}
+
+// Store persists the maintenance state. RedisStore shares it across instances;
+// MemoryStore is for single-node development and tests.
+type Store interface {
+	Get(ctx context.Context) (State, error)
+	Set(ctx context.Context, state State) error
+	Clear(ctx context.Context) error
+}
+
+// RedisStore keeps the state as JSON under Key, expiring it with the window.
+type RedisStore struct {
+	R   *redis.Client
+	Key string
+}
+
+func (s RedisStore) key() string {
+	if s.Key != "" {
+		return s.Key
+	}
+	return DefaultKey
+}
+
+// Get returns the stored state, or ModeOff when none is set.
+func (s RedisStore) Get(ctx context.Context) (State, error) {
+	if s.R == nil {
+		return State{Mode: ModeOff}, errors.New("maintenance: redis client not configured")
+	}
+	raw, err := s.R.Get(ctx, s.key()).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return State{Mode: ModeOff}, nil
+	}
+	if err != nil {
+		return State{Mode: ModeOff}, err
+	}
+	var state State
+	if err := json.Unmarshal(raw, &state); err != nil {
+		return State{Mode: ModeOff}, err
+	}
+	return state, nil
+}
+
+// Set stores state; a state with Until expires from Redis at that time.
+func (s RedisStore) Set(ctx context.Context, state State) error {
+	if s.R == nil {
+		return errors.New("maintenance: redis client not configured")
+	}
+	raw, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	var ttl time.Duration
+	if state.Until != nil {
+		if ttl = time.Until(*state.Until); ttl <= 0 {
+			return s.Clear(ctx)
+		}
+	}
+	return s.R.Set(ctx, s.key(), raw, ttl).Err()
+}
+
+// Clear removes the stored state.
+func (s RedisStore) Clear(ctx context.Context) error {
+	if s.R == nil {
+		return errors.New("maintenance: redis client not configured")
+	}
+	return s.R.Del(ctx, s.key()).Err()
+}
+
+// MemoryStore keeps the state in process memory.
+type MemoryStore struct {
+	mu    sync.RWMutex
+	state State
+}
+
+// Get returns the stored state, or ModeOff when none is set.
+func (s *MemoryStore) Get(context.Context) (State, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.state.Mode == "" {
+		return State{Mode: ModeOff}, nil
+	}
+	return s.state, nil
+}
+
+// Set replaces the stored state.
+func (s *MemoryStore) Set(_ context.Context, state State) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+	return nil
+}
+
+// Clear removes the stored state.
+func (s *MemoryStore) Clear(context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = State{}
+	return nil
+}
@@ -0,0 +1,103 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/noah-isme/backend-toko/internal/catalog"
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+type imageStore interface {
+	CreateProductImage(ctx context.Context, arg dbgen.CreateProductImageParams) (dbgen.CreateProductImageRow, error)
+}
+
+// AdminHandler exposes the admin image upload endpoint.
+type AdminHandler struct {
+	Uploader     *Uploader
+	Q            imageStore
+	CatalogCache *catalog.Cache
+}
+
+// UploadImage handles POST /api/v1/admin/media/images. It accepts a multipart
+// "file" field and, when "productId" is supplied, attaches the image to that product.
+func (h *AdminHandler) UploadImage(w http.ResponseWriter, r *http.Request) {
+	if h.Uploader == nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "media uploader not configured", nil)
+		return
+	}
+	limit := h.Uploader.Limit()
+	r.Body = http.MaxBytesReader(w, r.Body, limit+(1<<20))
+	if err := r.ParseMultipartForm(limit); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			common.JSONError(w, http.StatusRequestEntityTooLarge, common.CodePayloadTooLarge, "image exceeds the upload limit", map[string]any{"maxBytes": limit})
+			return
+		}
+		common.JSONError(w, http.StatusBadRequest, common.CodeInvalidBody, "expected multipart form data", nil)
+		return
+	}
+	defer func() { _ = r.MultipartForm.RemoveAll() }()
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, common.CodeValidation, "file is required", map[string]any{"field": "file"})
+		return
+	}
+	defer func() { _ = file.Close() }()
+	data, err := io.ReadAll(io.LimitReader(file, limit+1))
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, common.CodeInvalidBody, "could not read file", nil)
+		return
+	}
+
+	var productID pgtype.UUID
+	if raw := strings.TrimSpace(r.FormValue("productId")); raw != "" {
+		parsed, err := uuid.Parse(raw)
+		if err != nil {
+			common.JSONError(w, http.StatusBadRequest, common.CodeBadRequest, "invalid product id", map[string]any{"field": "productId"})
+			return
+		}
+		productID = pgtype.UUID{Bytes: parsed, Valid: true}
+	}
+
+	img, err := h.Uploader.Upload(r.Context(), data)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	resp := map[string]any{"image": img}
+	if productID.Valid && h.Q != nil {
+		row, err := h.Q.CreateProductImage(r.Context(), dbgen.CreateProductImageParams{ProductID: productID, Url: img.Ref})
+		if err != nil {
+			var pgErr *pgconn.PgError
+			if errors.As(err, &pgErr) && pgErr.Code == "23503" {
+				common.JSONError(w, http.StatusNotFound, common.CodeNotFound, "product not found", nil)
+				return
+			}
+			common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to attach image", nil)
+			return
+		}
+		h.CatalogCache.InvalidateProduct(r.Context(), row.Slug)
+		resp["productImage"] = map[string]any{"id": uuid.UUID(row.ID.Bytes).String(), "sortOrder": row.SortOrder}
+	}
+	common.JSON(w, http.StatusCreated, map[string]any{"data": resp})
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	var appErr *common.AppError
+	if errors.As(err, &appErr) {
+		common.JSONError(w, appErr.HTTPStatus, appErr.Code, appErr.Message, appErr.Details)
+		return
+	}
+	common.JSONError(w, http.StatusServiceUnavailable, common.CodeUnavailable, "image storage failed", nil)
+}
@@ -0,0 +1,69 @@
+package media
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// resizeToWidth scales src down to width pixels wide, preserving the aspect
+// ratio, by averaging the source pixels covered by each destination pixel.
+func resizeToWidth(src image.Image, width int) *image.NRGBA {
+	b := src.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if width <= 0 || width >= sw {
+		return toNRGBA(src)
+	}
+	height := sh * width / sw
+	if height < 1 {
+		height = 1
+	}
+	in := toNRGBA(src)
+	out := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0 := y * sh / height
+		y1 := (y + 1) * sh / height
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < width; x++ {
+			x0 := x * sw / width
+			x1 := (x + 1) * sw / width
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				off := in.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					px := in.Pix[off : off+4 : off+4]
+					alpha := uint64(px[3])
+					r += uint64(px[0]) * alpha
+					g += uint64(px[1]) * alpha
+					bl += uint64(px[2]) * alpha
+					a += alpha
+					n++
+					off += 4
+				}
+			}
+			c := color.NRGBA{A: uint8(a / n)}
+			if a > 0 {
+				c.R = uint8(r / a)
+				c.G = uint8(g / a)
+				c.B = uint8(bl / a)
+			}
+			out.SetNRGBA(x, y, c)
+		}
+	}
+	return out
+}
+
+func toNRGBA(src image.Image) *image.NRGBA {
+	if img, ok := src.(*image.NRGBA); ok && img.Rect.Min == (image.Point{}) {
+		return img
+	}
+	b := src.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(out, out.Bounds(), src, b.Min, draw.Src)
+	return out
+}
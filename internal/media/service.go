@@ -0,0 +1,166 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+)
+
+const defaultMaxUploadBytes = 5 << 20
+
+// DefaultVariants are the resized widths generated for every upload.
+var DefaultVariants = map[string]int{"thumb": 200, "medium": 600, "large": 1200}
+
+var allowedTypes = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+}
+
+// Image describes a stored upload and its resized variants.
+type Image struct {
+	Key         string            `json:"key"`
+	Ref         string            `json:"ref"`
+	URL         string            `json:"url"`
+	ContentType string            `json:"contentType"`
+	Width       int               `json:"width"`
+	Height      int               `json:"height"`
+	Variants    map[string]string `json:"variants"`
+}
+
+// Uploader validates images, generates variants, and writes them to storage.
+type Uploader struct {
+	Storage StorageProvider
+	// MaxBytes caps the accepted upload size. Zero uses 5 MiB.
+	MaxBytes int64
+	// MaxPixels rejects images whose decoded size would exceed this many pixels.
+	// Zero uses 40 megapixels.
+	MaxPixels int
+	// Variants maps variant names to target widths. Nil uses DefaultVariants.
+	Variants map[string]int
+
+	now func() time.Time
+}
+
+// Limit returns the effective maximum upload size in bytes.
+func (u *Uploader) Limit() int64 {
+	if u.MaxBytes > 0 {
+		return u.MaxBytes
+	}
+	return defaultMaxUploadBytes
+}
+
+// Upload validates data, stores the original under a fresh key, and stores
+// one resized copy per configured variant narrower than the original.
+func (u *Uploader) Upload(ctx context.Context, data []byte) (Image, error) {
+	if u.Storage == nil {
+		return Image{}, common.NewAppError(common.CodeInternal, "media storage not configured", http.StatusInternalServerError, nil)
+	}
+	if len(data) == 0 {
+		return Image{}, common.NewAppError(common.CodeBadRequest, "file is empty", http.StatusBadRequest, nil)
+	}
+	if int64(len(data)) > u.Limit() {
+		return Image{}, &common.AppError{Code: common.CodePayloadTooLarge, Message: "image exceeds the upload limit", HTTPStatus: http.StatusRequestEntityTooLarge, Details: map[string]any{"maxBytes": u.Limit()}}
+	}
+	contentType := http.DetectContentType(data)
+	ext, ok := allowedTypes[contentType]
+	if !ok {
+		return Image{}, &common.AppError{Code: common.CodeUnsupportedMediaType, Message: "only JPEG and PNG images are accepted", HTTPStatus: http.StatusUnsupportedMediaType, Details: map[string]any{"contentType": contentType}}
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, common.NewAppError(common.CodeBadRequest, "image could not be decoded", http.StatusBadRequest, err)
+	}
+	maxPixels := u.MaxPixels
+	if maxPixels <= 0 {
+		maxPixels = 40_000_000
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return Image{}, &common.AppError{Code: common.CodeBadRequest, Message: "image dimensions are too large", HTTPStatus: http.StatusBadRequest, Details: map[string]any{"maxPixels": maxPixels}}
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Image{}, common.NewAppError(common.CodeBadRequest, "image could not be decoded", http.StatusBadRequest, err)
+	}
+
+	dir := path.Join("products", u.clock().UTC().Format("2006/01"), uuid.NewString())
+	result := Image{
+		Key:         path.Join(dir, "original."+ext),
+		ContentType: contentType,
+		Width:       cfg.Width,
+		Height:      cfg.Height,
+		Variants:    map[string]string{},
+	}
+	if err := u.Storage.Put(ctx, result.Key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return Image{}, fmt.Errorf("store original: %w", err)
+	}
+
+	variants := u.Variants
+	if variants == nil {
+		variants = DefaultVariants
+	}
+	names := make([]string, 0, len(variants))
+	for name := range variants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		width := variants[name]
+		if width <= 0 || width >= cfg.Width {
+			continue
+		}
+		encoded, err := encode(resizeToWidth(img, width), contentType)
+		if err != nil {
+			return Image{}, fmt.Errorf("encode %s variant: %w", name, err)
+		}
+		key := path.Join(dir, name+"."+ext)
+		if err := u.Storage.Put(ctx, key, bytes.NewReader(encoded), int64(len(encoded)), contentType); err != nil {
+			return Image{}, fmt.Errorf("store %s variant: %w", name, err)
+		}
+		if result.Variants[name], err = u.Storage.URL(ctx, key); err != nil {
+			return Image{}, err
+		}
+	}
+
+	if result.URL, err = u.Storage.URL(ctx, result.Key); err != nil {
+		return Image{}, err
+	}
+	// Private objects are stored by reference so catalog reads can sign fresh URLs.
+	result.Ref = result.URL
+	if u.Storage.Private() {
+		result.Ref = RefScheme + result.Key
+	}
+	return result, nil
+}
+
+func encode(img image.Image, contentType string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch contentType {
+	case "image/png":
+		err = png.Encode(&buf, img)
+	case "image/jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	default:
+		err = errors.New("unsupported content type")
+	}
+	return buf.Bytes(), err
+}
+
+func (u *Uploader) clock() time.Time {
+	if u.now != nil {
+		return u.now()
+	}
+	return time.Now()
+}
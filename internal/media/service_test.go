@@ -0,0 +1,98 @@
+package media
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+)
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.NRGBA{R: uint8(x), G: uint8(y), B: 0x80, A: 0xff})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestUploadStoresOriginalAndVariants(t *testing.T) {
+	root := t.TempDir()
+	store := &LocalStorage{Root: root, BaseURL: "http://cdn.test/media/"}
+	u := &Uploader{Storage: store, Variants: map[string]int{"thumb": 100, "large": 1200}}
+
+	img, err := u.Upload(context.Background(), testPNG(t, 400, 200))
+	require.NoError(t, err)
+	require.Equal(t, "image/png", img.ContentType)
+	require.Equal(t, 400, img.Width)
+	require.True(t, strings.HasPrefix(img.URL, "http://cdn.test/media/products/"))
+	require.Equal(t, img.URL, img.Ref)
+	require.Len(t, img.Variants, 1, "variants wider than the original are skipped")
+
+	thumbPath := filepath.Join(root, filepath.FromSlash(strings.TrimPrefix(img.Variants["thumb"], "http://cdn.test/media/")))
+	f, err := os.Open(thumbPath)
+	require.NoError(t, err)
+	defer f.Close()
+	cfg, err := png.DecodeConfig(f)
+	require.NoError(t, err)
+	require.Equal(t, 100, cfg.Width)
+	require.Equal(t, 50, cfg.Height)
+}
+
+func TestUploadRejectsUnsupportedAndOversized(t *testing.T) {
+	u := &Uploader{Storage: &LocalStorage{Root: t.TempDir()}, MaxBytes: 64}
+
+	_, err := u.Upload(context.Background(), []byte("GIF89a not really an image"))
+	var appErr *common.AppError
+	require.True(t, errors.As(err, &appErr))
+	require.Equal(t, common.CodeUnsupportedMediaType, appErr.Code)
+	require.Equal(t, http.StatusUnsupportedMediaType, appErr.HTTPStatus)
+
+	_, err = u.Upload(context.Background(), testPNG(t, 50, 50))
+	require.True(t, errors.As(err, &appErr))
+	require.Equal(t, common.CodePayloadTooLarge, appErr.Code)
+}
+
+func TestPrivateLocalStorageRequiresSignature(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	store := &LocalStorage{Root: t.TempDir(), BaseURL: "/media", SigningKey: []byte("secret"), now: func() time.Time { return now }}
+	u := &Uploader{Storage: store, Variants: map[string]int{}}
+
+	img, err := u.Upload(context.Background(), testPNG(t, 20, 20))
+	require.NoError(t, err)
+	require.Equal(t, RefScheme+img.Key, img.Ref)
+
+	resolved := Resolver{Storage: store}.ResolveURL(context.Background(), img.Ref)
+	require.Contains(t, resolved, "sig=")
+
+	srv := http.StripPrefix("/media", store.Handler())
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, resolved, nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/media/"+img.Key, nil))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+
+	now = now.Add(2 * time.Hour)
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, resolved, nil))
+	require.Equal(t, http.StatusNotFound, rec.Code, "expired signatures are rejected")
+}
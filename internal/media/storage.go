@@ -0,0 +1,53 @@
+// Package media stores uploaded product images and resolves the URLs that
+// reference them.
+package media
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+// RefScheme prefixes image references stored in the database for objects held
+// in a private bucket. They are resolved to signed URLs when read.
+const RefScheme = "media://"
+
+// ErrNotFound is returned when an object does not exist in storage.
+var ErrNotFound = errors.New("media: object not found")
+
+// StorageProvider persists binary objects and hands out URLs for them.
+type StorageProvider interface {
+	// Put stores body under key, replacing any existing object.
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// URL returns the public URL of key. Private providers return a signed URL.
+	URL(ctx context.Context, key string) (string, error)
+	// SignedURL returns a time-limited URL granting read access to key.
+	SignedURL(ctx context.Context, key string, ttl time.Duration) (string, error)
+	// Private reports whether objects are only reachable through signed URLs.
+	Private() bool
+}
+
+// Resolver turns stored image references into URLs clients can fetch.
+type Resolver struct {
+	Storage StorageProvider
+}
+
+// ResolveURL returns ref unchanged unless it is a RefScheme reference, in which
+// case the storage URL is generated. Unresolvable references resolve to "".
+func (r Resolver) ResolveURL(ctx context.Context, ref string) string {
+	key, ok := strings.CutPrefix(ref, RefScheme)
+	if !ok || r.Storage == nil {
+		return ref
+	}
+	url, err := r.Storage.URL(ctx, key)
+	if err != nil {
+		return ""
+	}
+	return url
+}
+
+func cleanKey(key string) string {
+	return strings.TrimLeft(strings.TrimSpace(key), "/")
+}
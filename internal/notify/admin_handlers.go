@@ -4,7 +4,6 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
-	"strconv"
 	"strings"
 
 	"github.com/go-chi/chi/v5"
@@ -28,6 +27,21 @@ type endpointRequest struct {
 	Secret string   `json:"secret"`
 	Active *bool    `json:"active"`
 	Topics []string `json:"topics"`
+	Format string   `json:"format"`
+	// Ordered delivers events for the same aggregate one at a time, in order.
+	Ordered bool `json:"ordered"`
+	// MaxPayloadBytes caps request bodies; zero uses the server default.
+	MaxPayloadBytes int    `json:"max_payload_bytes"`
+	OversizePolicy  string `json:"oversize_policy"`
+	// DeliveryMode "sync" delivers inline and queues only on failure.
+	DeliveryMode string `json:"delivery_mode"`
+	// RetryStatuses and PermanentStatuses override how failed responses
+	// are classified; see ClassifyFailure.
+	RetryStatuses     []int `json:"retry_statuses"`
+	PermanentStatuses []int `json:"permanent_statuses"`
+	// CustomHeaders are added to every delivery and stored encrypted. On
+	// update, omitting them keeps the stored headers and {} removes them.
+	CustomHeaders map[string]string `json:"custom_headers"`
 }
 
 // CreateEndpoint registers a new webhook endpoint.
@@ -49,23 +63,66 @@ func (h *AdminHandler) CreateEndpoint(w http.ResponseWriter, r *http.Request) {
 		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
 		return
 	}
-	topics := normaliseTopics(req.Topics)
+	format, err := NormalizeFormat(req.Format)
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), map[string]any{"field": "format", "allowed": PayloadFormats})
+		return
+	}
+	policy, err := NormalizeOversizePolicy(req.OversizePolicy)
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), map[string]any{"field": "oversize_policy", "allowed": OversizePolicies})
+		return
+	}
+	if err := ValidateMaxPayloadBytes(req.MaxPayloadBytes); err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), map[string]any{"field": "max_payload_bytes", "min": MinPayloadBytes})
+		return
+	}
+	mode, err := NormalizeDeliveryMode(req.DeliveryMode)
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), map[string]any{"field": "delivery_mode", "allowed": DeliveryModes})
+		return
+	}
+	retryStatuses, permanentStatuses, err := NormalizeRetryStatuses(req.RetryStatuses, req.PermanentStatuses)
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), map[string]any{"field": "retry_statuses"})
+		return
+	}
+	headerNames, sealedHeaders, ok := h.customHeaders(w, req.CustomHeaders)
+	if !ok {
+		return
+	}
+	topics, ok := endpointTopics(w, req.Topics)
+	if !ok {
+		return
+	}
 	active := true
 	if req.Active != nil {
 		active = *req.Active
 	}
+	if headerNames == nil {
+		headerNames = []string{}
+	}
 	endpoint, err := h.Store.CreateWebhookEndpoint(r.Context(), dbgen.CreateWebhookEndpointParams{
-		Name:   req.Name,
-		Url:    req.URL,
-		Secret: req.Secret,
-		Active: active,
-		Topics: topics,
+		Name:              req.Name,
+		Url:               req.URL,
+		Secret:            req.Secret,
+		Active:            active,
+		Topics:            topics,
+		Format:            format,
+		Ordered:           req.Ordered,
+		MaxPayloadBytes:   int32(req.MaxPayloadBytes),
+		OversizePolicy:    policy,
+		DeliveryMode:      mode,
+		RetryStatuses:     retryStatuses,
+		PermanentStatuses: permanentStatuses,
+		CustomHeaderNames: headerNames,
+		CustomHeaders:     sealedHeaders,
 	})
 	if err != nil {
 		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", err.Error(), nil)
 		return
 	}
-	common.JSON(w, http.StatusCreated, endpoint)
+	common.JSON(w, http.StatusCreated, hideSealedHeaders(endpoint))
 }
 
 // UpdateEndpoint updates an existing webhook endpoint.
@@ -92,17 +149,58 @@ func (h *AdminHandler) UpdateEndpoint(w http.ResponseWriter, r *http.Request) {
 		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
 		return
 	}
+	format, err := NormalizeFormat(req.Format)
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), map[string]any{"field": "format", "allowed": PayloadFormats})
+		return
+	}
+	policy, err := NormalizeOversizePolicy(req.OversizePolicy)
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), map[string]any{"field": "oversize_policy", "allowed": OversizePolicies})
+		return
+	}
+	if err := ValidateMaxPayloadBytes(req.MaxPayloadBytes); err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), map[string]any{"field": "max_payload_bytes", "min": MinPayloadBytes})
+		return
+	}
+	mode, err := NormalizeDeliveryMode(req.DeliveryMode)
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), map[string]any{"field": "delivery_mode", "allowed": DeliveryModes})
+		return
+	}
+	retryStatuses, permanentStatuses, err := NormalizeRetryStatuses(req.RetryStatuses, req.PermanentStatuses)
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), map[string]any{"field": "retry_statuses"})
+		return
+	}
+	headerNames, sealedHeaders, ok := h.customHeaders(w, req.CustomHeaders)
+	if !ok {
+		return
+	}
+	topics, ok := endpointTopics(w, req.Topics)
+	if !ok {
+		return
+	}
 	active := true
 	if req.Active != nil {
 		active = *req.Active
 	}
 	endpoint, err := h.Store.UpdateWebhookEndpoint(r.Context(), dbgen.UpdateWebhookEndpointParams{
-		ID:     id,
-		Name:   req.Name,
-		Url:    req.URL,
-		Secret: req.Secret,
-		Active: active,
-		Topics: normaliseTopics(req.Topics),
+		ID:                id,
+		Name:              req.Name,
+		Url:               req.URL,
+		Secret:            req.Secret,
+		Active:            active,
+		Topics:            topics,
+		Format:            format,
+		Ordered:           req.Ordered,
+		MaxPayloadBytes:   int32(req.MaxPayloadBytes),
+		OversizePolicy:    policy,
+		DeliveryMode:      mode,
+		RetryStatuses:     retryStatuses,
+		PermanentStatuses: permanentStatuses,
+		CustomHeaderNames: headerNames,
+		CustomHeaders:     sealedHeaders,
 	})
 	if err != nil {
 		status := http.StatusInternalServerError
@@ -112,7 +210,37 @@ func (h *AdminHandler) UpdateEndpoint(w http.ResponseWriter, r *http.Request) {
 		common.JSONError(w, status, "INTERNAL", err.Error(), nil)
 		return
 	}
-	common.JSON(w, http.StatusOK, endpoint)
+	common.JSON(w, http.StatusOK, hideSealedHeaders(endpoint))
+}
+
+// customHeaders validates and seals the requested custom headers. Nil headers
+// return nil names and sealed value, which keeps the stored ones on update.
+func (h *AdminHandler) customHeaders(w http.ResponseWriter, headers map[string]string) ([]string, []byte, bool) {
+	if headers == nil {
+		return nil, nil, true
+	}
+	headers, names, err := NormalizeCustomHeaders(headers)
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), map[string]any{"field": "custom_headers"})
+		return nil, nil, false
+	}
+	var cipher HeaderCipher
+	if h.Disp != nil {
+		cipher = h.Disp.Headers
+	}
+	sealed, err := cipher.Seal(headers)
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "failed to encrypt custom headers", nil)
+		return nil, nil, false
+	}
+	return names, sealed, true
+}
+
+// hideSealedHeaders leaves the encrypted custom headers out of responses;
+// custom_header_names shows which are set.
+func hideSealedHeaders(endpoint dbgen.WebhookEndpoint) dbgen.WebhookEndpoint {
+	endpoint.CustomHeaders = nil
+	return endpoint
 }
 
 // ListEndpoints returns configured webhook endpoints.
@@ -121,7 +249,7 @@ func (h *AdminHandler) ListEndpoints(w http.ResponseWriter, r *http.Request) {
 		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "webhook store unavailable", nil)
 		return
 	}
-	limit, offset := pagination(r)
+	limit, offset := common.ParseOffsetPagination(r, 50, 200)
 	endpoints, err := h.Store.ListWebhookEndpoints(r.Context(), dbgen.ListWebhookEndpointsParams{
 		PageOffset: int32(offset),
 		PageLimit:  int32(limit),
@@ -130,7 +258,15 @@ func (h *AdminHandler) ListEndpoints(w http.ResponseWriter, r *http.Request) {
 		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", err.Error(), nil)
 		return
 	}
-	common.JSON(w, http.StatusOK, map[string]any{"data": endpoints})
+	total, err := h.Store.CountWebhookEndpoints(r.Context())
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", err.Error(), nil)
+		return
+	}
+	for i := range endpoints {
+		endpoints[i] = hideSealedHeaders(endpoints[i])
+	}
+	common.WritePage(w, r, "webhook-endpoints", endpoints, limit, offset, total, nil)
 }
 
 // DeleteEndpoint removes an endpoint by ID.
@@ -155,6 +291,72 @@ func (h *AdminHandler) DeleteEndpoint(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// EnableEndpoint reactivates an endpoint and resets its failure count.
+func (h *AdminHandler) EnableEndpoint(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Store == nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "webhook store unavailable", nil)
+		return
+	}
+	id, err := parseUUID(chi.URLParam(r, "id"))
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid id", nil)
+		return
+	}
+	endpoint, err := h.Store.EnableWebhookEndpoint(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			common.JSONError(w, http.StatusNotFound, "NOT_FOUND", "webhook endpoint not found", nil)
+			return
+		}
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", err.Error(), nil)
+		return
+	}
+	common.JSON(w, http.StatusOK, hideSealedHeaders(endpoint))
+}
+
+// DisableEndpoint deactivates an endpoint with an optional reason. Disabling
+// an inactive endpoint leaves it unchanged.
+func (h *AdminHandler) DisableEndpoint(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Store == nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "webhook store unavailable", nil)
+		return
+	}
+	id, err := parseUUID(chi.URLParam(r, "id"))
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid id", nil)
+		return
+	}
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid payload", nil)
+			return
+		}
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		reason = "disabled by admin"
+	}
+	endpoint, err := h.Store.DisableWebhookEndpoint(r.Context(), dbgen.DisableWebhookEndpointParams{
+		ID:     id,
+		Reason: pgtype.Text{String: reason, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		endpoint, err = h.Store.GetWebhookEndpoint(r.Context(), id)
+	}
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			common.JSONError(w, http.StatusNotFound, "NOT_FOUND", "webhook endpoint not found", nil)
+			return
+		}
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", err.Error(), nil)
+		return
+	}
+	common.JSON(w, http.StatusOK, hideSealedHeaders(endpoint))
+}
+
 // ListDeliveries returns webhook delivery attempts with optional filtering.
 func (h *AdminHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
 	if h == nil || h.Store == nil {
@@ -164,7 +366,7 @@ func (h *AdminHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
 	endpointID, _ := parseUUIDOptional(r.URL.Query().Get("endpointId"))
 	eventID, _ := parseUUIDOptional(r.URL.Query().Get("eventId"))
 	status := strings.TrimSpace(r.URL.Query().Get("status"))
-	limit, offset := pagination(r)
+	limit, offset := common.ParseOffsetPagination(r, 50, 200)
 	rows, err := h.Store.ListWebhookDeliveries(r.Context(), dbgen.ListWebhookDeliveriesParams{
 		EndpointID: endpointID,
 		EventID:    eventID,
@@ -185,7 +387,37 @@ func (h *AdminHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
 		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", err.Error(), nil)
 		return
 	}
-	common.JSON(w, http.StatusOK, map[string]any{"data": rows, "total": total})
+	common.WritePage(w, r, "webhook-deliveries", rows, limit, offset, total, nil)
+}
+
+// ListAttempts returns the recorded attempts of a delivery, oldest first.
+func (h *AdminHandler) ListAttempts(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Store == nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "webhook store unavailable", nil)
+		return
+	}
+	id, err := parseUUID(chi.URLParam(r, "id"))
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid id", nil)
+		return
+	}
+	if _, err := h.Store.GetDeliveryByID(r.Context(), id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			common.JSONError(w, http.StatusNotFound, "NOT_FOUND", "webhook delivery not found", nil)
+			return
+		}
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", err.Error(), nil)
+		return
+	}
+	attempts, err := h.Store.ListDeliveryAttempts(r.Context(), id)
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", err.Error(), nil)
+		return
+	}
+	if attempts == nil {
+		attempts = []dbgen.WebhookDeliveryAttempt{}
+	}
+	common.JSON(w, http.StatusOK, map[string]any{"data": attempts})
 }
 
 // ReplayDelivery resets a delivery for retry.
@@ -215,40 +447,57 @@ func (h *AdminHandler) ReplayDelivery(w http.ResponseWriter, r *http.Request) {
 	common.JSON(w, http.StatusOK, delivery)
 }
 
-func normaliseTopics(topics []string) []string {
-	seen := make(map[string]struct{}, len(topics))
-	result := make([]string, 0, len(topics))
-	for _, topic := range topics {
-		trimmed := strings.TrimSpace(strings.ToLower(topic))
-		if trimmed == "" {
-			continue
+// TestEndpoint sends a signed sample event to an endpoint once and returns the
+// endpoint's response. The delivery is not persisted.
+func (h *AdminHandler) TestEndpoint(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Store == nil || h.Disp == nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "webhook dispatcher unavailable", nil)
+		return
+	}
+	id, err := parseUUID(chi.URLParam(r, "id"))
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid id", nil)
+		return
+	}
+	var req struct {
+		Topic string `json:"topic"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid payload", nil)
+			return
 		}
-		if _, ok := seen[trimmed]; ok {
-			continue
+	}
+	endpoint, err := h.Store.GetWebhookEndpoint(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			common.JSONError(w, http.StatusNotFound, "NOT_FOUND", "webhook endpoint not found", nil)
+			return
 		}
-		seen[trimmed] = struct{}{}
-		result = append(result, trimmed)
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", err.Error(), nil)
+		return
 	}
-	if len(result) == 0 {
-		return []string{}
+	result, err := h.Disp.Ping(r.Context(), endpoint, req.Topic)
+	if err != nil {
+		if errors.Is(err, ErrTopicNotSubscribed) {
+			common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), map[string]any{"topics": endpoint.Topics})
+			return
+		}
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", err.Error(), nil)
+		return
 	}
-	return result
+	common.JSON(w, http.StatusOK, map[string]any{"data": result})
 }
 
-func pagination(r *http.Request) (limit, offset int) {
-	limit = 50
-	offset = 0
-	if v := strings.TrimSpace(r.URL.Query().Get("limit")); v != "" {
-		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 && parsed <= 200 {
-			limit = parsed
-		}
-	}
-	if v := strings.TrimSpace(r.URL.Query().Get("offset")); v != "" {
-		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
-			offset = parsed
-		}
+// endpointTopics normalises the requested topics, answering 400 with the
+// accepted patterns when any of them is unknown.
+func endpointTopics(w http.ResponseWriter, requested []string) ([]string, bool) {
+	topics, unknown := NormalizeTopics(requested)
+	if len(unknown) > 0 {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "unknown topics: "+strings.Join(unknown, ", "), map[string]any{"field": "topics", "invalid": unknown, "allowed": TopicPatterns()})
+		return nil, false
 	}
-	return
+	return topics, true
 }
 
 func parseUUID(value string) (pgtype.UUID, error) {
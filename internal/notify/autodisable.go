@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/events"
+)
+
+// EventEmitter records internal domain events; events.Bus implements it.
+type EventEmitter interface {
+	Emit(ctx context.Context, topic string, aggregateID pgtype.UUID, payload any) (dbgen.DomainEvent, error)
+}
+
+// endpointSucceeded ends the endpoint's failure streak.
+func (d *Dispatcher) endpointSucceeded(ctx context.Context, ep dbgen.WebhookEndpoint) {
+	if ep.ConsecutiveFailures > 0 {
+		_ = d.Store.ResetEndpointFailures(ctx, ep.ID)
+	}
+}
+
+// endpointFailed counts a failed attempt against the endpoint and, once the
+// streak reaches AutoDisableAfter, deactivates it so Schedule stops queuing
+// deliveries until an admin enables it again. Failure tracking is best effort
+// and never affects the delivery outcome.
+func (d *Dispatcher) endpointFailed(ctx context.Context, ep dbgen.WebhookEndpoint, reason string) {
+	failures, err := d.Store.RecordEndpointFailure(ctx, ep.ID)
+	if err != nil || d.AutoDisableAfter <= 0 || int(failures) < d.AutoDisableAfter {
+		return
+	}
+	why := capText(fmt.Sprintf("disabled after %d consecutive failed deliveries; last: %s", failures, reason), d.bodyLimit())
+	disabled, err := d.Store.DisableWebhookEndpoint(ctx, dbgen.DisableWebhookEndpointParams{
+		ID:     ep.ID,
+		Reason: pgtype.Text{String: why, Valid: true},
+	})
+	if err != nil {
+		// Already inactive, or the endpoint is gone.
+		return
+	}
+	if d.Events != nil {
+		_, _ = d.Events.Emit(ctx, events.TopicWebhookEndpointDisabled, disabled.ID, map[string]any{
+			"endpointId": uuidFrom(disabled.ID),
+			"name":       disabled.Name,
+			"url":        disabled.Url,
+			"failures":   failures,
+			"reason":     why,
+		})
+	}
+}
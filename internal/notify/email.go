@@ -19,10 +19,13 @@ type EmailNotifier struct {
 	Enabled      bool
 	From         string
 	TopicToggles map[string]bool
+	// Preferences skips topics the recipient opted out of and looks up their
+	// address when the payload names only the user.
+	Preferences EmailPreferences
 }
 
 // Notify implements the events.Notifier interface.
-func (n EmailNotifier) Notify(_ context.Context, event dbgen.DomainEvent) error {
+func (n EmailNotifier) Notify(ctx context.Context, event dbgen.DomainEvent) error {
 	if !n.Enabled || n.Mail == nil {
 		return nil
 	}
@@ -38,6 +41,22 @@ func (n EmailNotifier) Notify(_ context.Context, event dbgen.DomainEvent) error
 		}
 	}
 	to := extractRecipient(payload)
+	if userID, ok := recipientUser(payload); ok && n.Preferences.Store != nil {
+		allowed, err := n.Preferences.Allowed(ctx, userID, event.Topic)
+		if err != nil {
+			return fmt.Errorf("email notify: load preferences: %w", err)
+		}
+		if !allowed {
+			return nil
+		}
+		if to == "" {
+			user, err := n.Preferences.Store.GetUserByID(ctx, userID)
+			if err != nil {
+				return fmt.Errorf("email notify: load recipient: %w", err)
+			}
+			to = strings.TrimSpace(user.Email)
+		}
+	}
 	if to == "" {
 		return nil
 	}
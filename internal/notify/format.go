@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Payload formats an endpoint can request. FormatJSON is the nested envelope
+// every endpoint received before formats existed; the other two flatten the
+// envelope into dot-separated keys such as "data.items.0.qty".
+const (
+	FormatJSON     = "json"
+	FormatJSONFlat = "json_flat"
+	FormatForm     = "form"
+)
+
+// PayloadFormats lists the accepted endpoint formats.
+var PayloadFormats = []string{FormatJSON, FormatJSONFlat, FormatForm}
+
+// NormalizeFormat validates an endpoint format, defaulting empty to JSON.
+func NormalizeFormat(format string) (string, error) {
+	format = strings.ToLower(strings.TrimSpace(format))
+	switch format {
+	case "":
+		return FormatJSON, nil
+	case FormatJSON, FormatJSONFlat, FormatForm:
+		return format, nil
+	}
+	return "", fmt.Errorf("format must be one of %s", strings.Join(PayloadFormats, ", "))
+}
+
+// encodePayload serialises the nested JSON envelope in the endpoint's format
+// and returns the body with its content type. The signature is computed over
+// the returned bytes.
+func encodePayload(format string, envelope []byte) ([]byte, string, error) {
+	switch format {
+	case "", FormatJSON:
+		return envelope, "application/json", nil
+	case FormatJSONFlat, FormatForm:
+	default:
+		return nil, "", fmt.Errorf("unknown webhook format %q", format)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(envelope))
+	decoder.UseNumber()
+	var nested any
+	if err := decoder.Decode(&nested); err != nil {
+		return nil, "", err
+	}
+	flat := map[string]any{}
+	flatten("", nested, flat)
+	if format == FormatJSONFlat {
+		body, err := json.Marshal(flat)
+		return body, "application/json", err
+	}
+	form := url.Values{}
+	for key, value := range flat {
+		form.Set(key, formValue(value))
+	}
+	// Encode sorts by key, so the signed bytes are deterministic.
+	return []byte(form.Encode()), "application/x-www-form-urlencoded", nil
+}
+
+func flatten(prefix string, value any, out map[string]any) {
+	join := func(key string) string {
+		if prefix == "" {
+			return key
+		}
+		return prefix + "." + key
+	}
+	switch v := value.(type) {
+	case map[string]any:
+		if len(v) == 0 && prefix != "" {
+			out[prefix] = v
+		}
+		for key, child := range v {
+			flatten(join(key), child, out)
+		}
+	case []any:
+		if len(v) == 0 && prefix != "" {
+			out[prefix] = v
+		}
+		for i, child := range v {
+			flatten(join(strconv.Itoa(i)), child, out)
+		}
+	default:
+		out[prefix] = v
+	}
+}
+
+func formValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case map[string]any, []any:
+		return ""
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,175 @@
+package notify
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+)
+
+// Limits on endpoint custom headers.
+const (
+	MaxCustomHeaders        = 20
+	MaxCustomHeaderValueLen = 4096
+)
+
+// reservedHeaders cannot be set per endpoint: hop-by-hop and transport
+// headers the HTTP client owns, and the headers deliveries are signed and
+// identified with.
+var reservedHeaders = []string{
+	"Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Proxy-Connection", "Te", "Trailer", "Transfer-Encoding", "Upgrade",
+	"Host", "Content-Length", "Content-Type", "User-Agent",
+	"X-Event-Id", "X-Event-Sequence", "X-Idempotency-Key", "X-Payload-Part",
+	"X-Signature", "X-Timestamp", http.CanonicalHeaderKey(common.RequestIDHeader),
+}
+
+// NormalizeCustomHeaders validates endpoint custom headers and returns them
+// keyed by canonical name, with the sorted names. Reserved headers, invalid
+// names, and values with control characters are rejected.
+func NormalizeCustomHeaders(headers map[string]string) (map[string]string, []string, error) {
+	if len(headers) > MaxCustomHeaders {
+		return nil, nil, fmt.Errorf("custom_headers allows at most %d headers", MaxCustomHeaders)
+	}
+	out := make(map[string]string, len(headers))
+	for name, value := range headers {
+		name = strings.TrimSpace(name)
+		if !validHeaderName(name) {
+			return nil, nil, fmt.Errorf("invalid header name %q", name)
+		}
+		name = http.CanonicalHeaderKey(name)
+		if slices.Contains(reservedHeaders, name) {
+			return nil, nil, fmt.Errorf("header %s is reserved", name)
+		}
+		if _, dup := out[name]; dup {
+			return nil, nil, fmt.Errorf("header %s is given twice", name)
+		}
+		value = strings.TrimSpace(value)
+		if value == "" || len(value) > MaxCustomHeaderValueLen || strings.ContainsFunc(value, func(r rune) bool { return r < ' ' && r != '\t' || r == 0x7f }) {
+			return nil, nil, fmt.Errorf("invalid value for header %s", name)
+		}
+		out[name] = value
+	}
+	names := make([]string, 0, len(out))
+	for name := range out {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return out, names, nil
+}
+
+func validHeaderName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, r := range name {
+		if r > 0x7e || r <= ' ' || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// HeaderCipher seals endpoint custom headers at rest with AES-GCM.
+type HeaderCipher struct {
+	Key []byte
+}
+
+// NewHeaderCipher decodes a base64 AES key of 16, 24, or 32 bytes. An empty
+// key is derived from fallbackSecret.
+func NewHeaderCipher(encoded, fallbackSecret string) (HeaderCipher, error) {
+	encoded = strings.TrimSpace(encoded)
+	if encoded == "" {
+		key := sha256.Sum256([]byte("webhook-headers:" + fallbackSecret))
+		return HeaderCipher{Key: key[:]}, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return HeaderCipher{}, fmt.Errorf("notify: decode header encryption key: %w", err)
+	}
+	switch len(key) {
+	case 16, 24, 32:
+		return HeaderCipher{Key: key}, nil
+	}
+	return HeaderCipher{}, fmt.Errorf("notify: header encryption key must be 16, 24, or 32 bytes, got %d", len(key))
+}
+
+func (c HeaderCipher) gcm() (cipher.AEAD, error) {
+	if len(c.Key) == 0 {
+		return nil, errors.New("notify: header encryption key not configured")
+	}
+	block, err := aes.NewCipher(c.Key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Seal encrypts headers for storage.
+func (c HeaderCipher) Seal(headers map[string]string) ([]byte, error) {
+	gcm, err := c.gcm()
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := json.Marshal(headers)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// Open decrypts headers sealed by Seal. Nothing stored opens to no headers.
+func (c HeaderCipher) Open(sealed []byte) (map[string]string, error) {
+	if len(sealed) == 0 {
+		return nil, nil
+	}
+	gcm, err := c.gcm()
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, errors.New("notify: sealed headers too short")
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("notify: open custom headers: %w", err)
+	}
+	var headers map[string]string
+	if err := json.Unmarshal(plaintext, &headers); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
+// setCustomHeaders adds the endpoint's custom headers to reqs. Headers the
+// request already carries are left alone.
+func (c HeaderCipher) setCustomHeaders(sealed []byte, reqs ...*http.Request) error {
+	headers, err := c.Open(sealed)
+	if err != nil {
+		return err
+	}
+	for _, req := range reqs {
+		for name, value := range headers {
+			if req.Header.Get(name) == "" {
+				req.Header.Set(name, value)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	"github.com/noah-isme/backend-toko/internal/queue"
+	"github.com/noah-isme/backend-toko/internal/resilience"
+)
+
+const (
+	emailSendTask = "email-send"
+
+	defaultEmailEnqueueTimeout = 3 * time.Second
+	defaultEmailSendTimeout    = 10 * time.Second
+)
+
+// EmailSendTask returns the queue kind used for outgoing emails.
+func EmailSendTask() string {
+	return emailSendTask
+}
+
+type emailMessage struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+}
+
+// QueuedEmailSender hands emails to the worker instead of talking to the mail
+// provider on the request goroutine, so a slow provider cannot stall the
+// caller. Without a queue it falls back to sending synchronously through
+// Fallback, which is only meant for local development.
+type QueuedEmailSender struct {
+	Queue       queue.Enqueuer
+	MaxAttempts int
+	// EnqueueTimeout bounds the Redis write; zero uses three seconds.
+	EnqueueTimeout time.Duration
+	Fallback       common.EmailSender
+}
+
+// Send implements common.EmailSender.
+func (s QueuedEmailSender) Send(to, subject, html string) error {
+	if s.Queue.R == nil {
+		if s.Fallback == nil {
+			return nil
+		}
+		return s.Fallback.Send(to, subject, html)
+	}
+	payload, err := json.Marshal(emailMessage{To: to, Subject: subject, HTML: html})
+	if err != nil {
+		return fmt.Errorf("encode email: %w", err)
+	}
+	timeout := s.EnqueueTimeout
+	if timeout <= 0 {
+		timeout = defaultEmailEnqueueTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := s.Queue.Enqueue(ctx, queue.Task{Kind: emailSendTask, Payload: payload, MaxAttempts: s.MaxAttempts}); err != nil {
+		return fmt.Errorf("enqueue email: %w", err)
+	}
+	return nil
+}
+
+// ContextEmailSender is implemented by senders that can abandon a send when
+// the context ends. EmailWorker prefers it so its timeout reaches the provider
+// call.
+type ContextEmailSender interface {
+	SendContext(ctx context.Context, to, subject, html string) error
+}
+
+// EmailWorker delivers queued emails. A returned error makes the queue retry
+// the task with backoff until its attempts run out.
+type EmailWorker struct {
+	Mail    common.EmailSender
+	Timeout time.Duration
+}
+
+// Handle sends the email encoded in payload.
+func (w EmailWorker) Handle(ctx context.Context, payload []byte) error {
+	if w.Mail == nil {
+		return errors.New("email worker: sender not configured")
+	}
+	var msg emailMessage
+	if err := json.Unmarshal(payload, &msg); err != nil {
+		// A payload that cannot be decoded never will be; retrying is pointless.
+		return nil
+	}
+	if strings.TrimSpace(msg.To) == "" {
+		return nil
+	}
+	timeout := w.Timeout
+	if timeout <= 0 {
+		timeout = defaultEmailSendTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	if sender, ok := w.Mail.(ContextEmailSender); ok {
+		return sender.SendContext(ctx, msg.To, msg.Subject, msg.HTML)
+	}
+	return w.Mail.Send(msg.To, msg.Subject, msg.HTML)
+}
+
+// HTTPEmailSender posts emails as JSON to an HTTP mail provider or relay. The
+// resilience client supplies per-attempt timeouts, retries, and the circuit
+// breaker.
+type HTTPEmailSender struct {
+	HTTP   *resilience.HTTPClient
+	URL    string
+	APIKey string
+	From   string
+}
+
+// Send implements common.EmailSender.
+func (s HTTPEmailSender) Send(to, subject, html string) error {
+	return s.SendContext(context.Background(), to, subject, html)
+}
+
+// SendContext implements ContextEmailSender.
+func (s HTTPEmailSender) SendContext(ctx context.Context, to, subject, html string) error {
+	if s.HTTP == nil {
+		return errors.New("email: http client not configured")
+	}
+	body, err := json.Marshal(map[string]string{
+		"from":    s.From,
+		"to":      to,
+		"subject": subject,
+		"html":    html,
+		"text":    htmlToText(html),
+	})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	}
+	resp, err := s.HTTP.Do(ctx, req)
+	if err != nil {
+		return fmt.Errorf("email provider: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("email provider: unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// LogEmailSender is a dry run sender for staging: it logs who would have
+// received which email without contacting a provider. Bodies are not logged
+// since they carry reset tokens and links.
+type LogEmailSender struct {
+	Logger zerolog.Logger
+}
+
+// Send implements common.EmailSender.
+func (s LogEmailSender) Send(to, subject, html string) error {
+	s.Logger.Info().Str("to", to).Str("subject", subject).Int("bytes", len(html)).Msg("email dry run")
+	return nil
+}
+
+// EmailSettings selects and configures the mail provider built by
+// NewEmailSender.
+type EmailSettings struct {
+	// Provider is nop, log, http, smtp, or sendgrid. Empty means nop.
+	Provider string
+	From     string
+	// HTTP carries the retry and breaker policy for the http and sendgrid
+	// providers.
+	HTTP *resilience.HTTPClient
+	// URL is the http provider endpoint, or a SendGrid endpoint override.
+	URL    string
+	APIKey string
+	// SMTP configures the smtp provider; From is filled in from above.
+	SMTP   SMTPEmailSender
+	Logger zerolog.Logger
+}
+
+// NewEmailSender builds the configured provider.
+func NewEmailSender(settings EmailSettings) (common.EmailSender, error) {
+	switch settings.Provider {
+	case "", "nop":
+		return common.NopEmailSender{}, nil
+	case "log":
+		return LogEmailSender{Logger: settings.Logger}, nil
+	case "http":
+		return HTTPEmailSender{HTTP: settings.HTTP, URL: settings.URL, APIKey: settings.APIKey, From: settings.From}, nil
+	case "sendgrid":
+		return SendGridEmailSender{HTTP: settings.HTTP, URL: settings.URL, APIKey: settings.APIKey, From: settings.From}, nil
+	case "smtp":
+		sender := settings.SMTP
+		sender.From = settings.From
+		return sender, nil
+	default:
+		return nil, fmt.Errorf("email: unknown provider %q", settings.Provider)
+	}
+}
@@ -0,0 +1,134 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	miniredis "github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	"github.com/noah-isme/backend-toko/internal/notify"
+	"github.com/noah-isme/backend-toko/internal/queue"
+	"github.com/noah-isme/backend-toko/internal/resilience"
+)
+
+func TestQueuedEmailSenderEnqueuesForWorker(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	direct := &common.InMemoryEmail{}
+	sender := notify.QueuedEmailSender{Queue: queue.Enqueuer{R: client, Prefix: "test"}, MaxAttempts: 3, Fallback: direct}
+	require.NoError(t, sender.Send("buyer@example.com", "Reset Password", "link"))
+	require.Empty(t, direct.Outbox, "queued sends must not reach the provider synchronously")
+
+	members, err := client.ZRange(context.Background(), "test:queue:"+notify.EmailSendTask(), 0, -1).Result()
+	require.NoError(t, err)
+	require.Len(t, members, 1)
+	var msg struct {
+		Payload     []byte `json:"payload"`
+		MaxAttempts int    `json:"max_attempts"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(members[0]), &msg))
+	require.Equal(t, 3, msg.MaxAttempts)
+
+	delivered := &common.InMemoryEmail{}
+	worker := notify.EmailWorker{Mail: delivered, Timeout: time.Second}
+	require.NoError(t, worker.Handle(context.Background(), msg.Payload))
+	require.Equal(t, []common.Email{{To: "buyer@example.com", Subject: "Reset Password", HTML: "link"}}, delivered.Outbox)
+}
+
+func TestQueuedEmailSenderFallsBackWithoutQueue(t *testing.T) {
+	direct := &common.InMemoryEmail{}
+	sender := notify.QueuedEmailSender{Fallback: direct}
+	require.NoError(t, sender.Send("buyer@example.com", "Hi", "body"))
+	require.Len(t, direct.Outbox, 1)
+}
+
+func TestEmailWorkerTimesOutSlowProvider(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(500 * time.Millisecond):
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	sender := notify.HTTPEmailSender{
+		HTTP: &resilience.HTTPClient{Client: srv.Client(), MaxAttempts: 1},
+		URL:  srv.URL,
+	}
+	worker := notify.EmailWorker{Mail: sender, Timeout: 50 * time.Millisecond}
+	payload, err := json.Marshal(map[string]string{"to": "buyer@example.com", "subject": "Hi", "html": "body"})
+	require.NoError(t, err)
+
+	start := time.Now()
+	require.Error(t, worker.Handle(context.Background(), payload))
+	require.Less(t, time.Since(start), 400*time.Millisecond)
+}
+
+func TestHTTPEmailSenderRejectsErrorStatus(t *testing.T) {
+	var got map[string]string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}))
+	t.Cleanup(srv.Close)
+
+	sender := notify.HTTPEmailSender{
+		HTTP:   &resilience.HTTPClient{Client: srv.Client(), MaxAttempts: 1},
+		URL:    srv.URL,
+		APIKey: "secret",
+		From:   "no-reply@toko.local",
+	}
+	require.Error(t, sender.Send("buyer@example.com", "Hi", "body"))
+	require.Equal(t, "no-reply@toko.local", got["from"])
+	require.Equal(t, "buyer@example.com", got["to"])
+}
+
+func TestSendGridEmailSenderSendsTextAndHTML(t *testing.T) {
+	var got struct {
+		Personalizations []struct {
+			To []struct {
+				Email string `json:"email"`
+			} `json:"to"`
+		} `json:"personalizations"`
+		From struct {
+			Email string `json:"email"`
+			Name  string `json:"name"`
+		} `json:"from"`
+		Subject string `json:"subject"`
+		Content []struct {
+			Type  string `json:"type"`
+			Value string `json:"value"`
+		} `json:"content"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer sg-key", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&got))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(srv.Close)
+
+	sender := notify.SendGridEmailSender{
+		HTTP:   &resilience.HTTPClient{Client: srv.Client(), MaxAttempts: 1},
+		URL:    srv.URL,
+		APIKey: "sg-key",
+		From:   "Toko <no-reply@toko.local>",
+	}
+	require.NoError(t, sender.Send("buyer@example.com", "Pesanan dikirim", "<p>Resi: <b>JNE123</b></p>"))
+	require.Equal(t, "buyer@example.com", got.Personalizations[0].To[0].Email)
+	require.Equal(t, "no-reply@toko.local", got.From.Email)
+	require.Equal(t, "Toko", got.From.Name)
+	require.Len(t, got.Content, 2)
+	require.Equal(t, "text/plain", got.Content[0].Type)
+	require.Equal(t, "Resi: JNE123", got.Content[0].Value)
+	require.Equal(t, "text/html", got.Content[1].Type)
+}
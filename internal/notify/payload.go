@@ -0,0 +1,247 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+// Oversize policies decide what happens to an event whose body exceeds the
+// endpoint's payload limit. OversizeTruncate drops the data and sends a signed
+// link to fetch it; OversizeSplit sends the data in numbered chunks.
+const (
+	OversizeTruncate = "truncate"
+	OversizeSplit    = "split"
+)
+
+// OversizePolicies lists the accepted endpoint oversize policies.
+var OversizePolicies = []string{OversizeTruncate, OversizeSplit}
+
+// MinPayloadBytes is the smallest per-endpoint payload limit accepted. Below
+// it even a truncated envelope may not fit.
+const MinPayloadBytes = 1024
+
+const (
+	defaultPayloadURLTTL = 7 * 24 * time.Hour
+	// maxPayloadParts bounds how many requests one event may be split into;
+	// larger events are truncated instead.
+	maxPayloadParts = 32
+	// minChunkBytes is the least room a part must leave for data before
+	// splitting is considered worthwhile.
+	minChunkBytes = 256
+)
+
+// NormalizeOversizePolicy validates an endpoint oversize policy, defaulting
+// empty to truncate.
+func NormalizeOversizePolicy(policy string) (string, error) {
+	policy = strings.ToLower(strings.TrimSpace(policy))
+	switch policy {
+	case "":
+		return OversizeTruncate, nil
+	case OversizeTruncate, OversizeSplit:
+		return policy, nil
+	}
+	return "", fmt.Errorf("oversize_policy must be one of %s", strings.Join(OversizePolicies, ", "))
+}
+
+// ValidateMaxPayloadBytes checks a per-endpoint payload limit. Zero means the
+// dispatcher default applies.
+func ValidateMaxPayloadBytes(limit int) error {
+	if limit == 0 || (limit >= MinPayloadBytes && limit <= math.MaxInt32) {
+		return nil
+	}
+	return fmt.Errorf("max_payload_bytes must be 0 or between %d and %d", MinPayloadBytes, math.MaxInt32)
+}
+
+func (d *Dispatcher) payloadLimit(ep dbgen.WebhookEndpoint) int {
+	if ep.MaxPayloadBytes > 0 {
+		return int(ep.MaxPayloadBytes)
+	}
+	return d.MaxPayloadBytes
+}
+
+// deliveryRequests builds the signed requests delivering ev to ep. Events
+// within the payload limit go out as a single request; larger ones follow the
+// endpoint's oversize policy.
+func (d *Dispatcher) deliveryRequests(ctx context.Context, ep dbgen.WebhookEndpoint, ev dbgen.DomainEvent, del dbgen.WebhookDelivery) ([]*http.Request, error) {
+	if err := validateURL(ep.Url); err != nil {
+		return nil, err
+	}
+	env := newEnvelope(ev, del)
+	body, contentType, err := encodeEnvelope(ep.Format, env)
+	if err != nil {
+		return nil, err
+	}
+	limit := d.payloadLimit(ep)
+	if limit <= 0 || len(body) <= limit {
+		req, err := newSignedRequest(ctx, ep, ev, del, body, contentType, uuidFrom(del.ID))
+		if err != nil {
+			return nil, err
+		}
+		return []*http.Request{req}, nil
+	}
+	if ep.OversizePolicy == OversizeSplit {
+		reqs, err := d.splitRequests(ctx, ep, ev, del, env, limit)
+		if err != nil || reqs != nil {
+			return reqs, err
+		}
+	}
+	env.Data = nil
+	env.Truncated = true
+	env.Size = len(ev.Payload)
+	env.ResourceURL = d.payloadURL(ep, ev, time.Now())
+	body, contentType, err = encodeEnvelope(ep.Format, env)
+	if err != nil {
+		return nil, err
+	}
+	req, err := newSignedRequest(ctx, ep, ev, del, body, contentType, uuidFrom(del.ID))
+	if err != nil {
+		return nil, err
+	}
+	return []*http.Request{req}, nil
+}
+
+// splitRequests spreads the event data over several requests, each carrying a
+// slice of its base64url encoding. Consumers join the chunks in part order and
+// decode the result. It returns nil requests when the data cannot be split
+// within the limit so the caller falls back to truncation.
+func (d *Dispatcher) splitRequests(ctx context.Context, ep dbgen.WebhookEndpoint, ev dbgen.DomainEvent, del dbgen.WebhookDelivery, env webhookEnvelope, limit int) ([]*http.Request, error) {
+	encoded := base64.RawURLEncoding.EncodeToString(ev.Payload)
+	env.Data = nil
+	env.Size = len(ev.Payload)
+	// Size the envelope with the widest part numbers and a one byte chunk so
+	// every part fits; base64url needs no escaping in any format.
+	probe := env
+	probe.Part, probe.Parts = maxPayloadParts, maxPayloadParts
+	probe.Chunk = "A"
+	overhead, _, err := encodeEnvelope(ep.Format, probe)
+	if err != nil {
+		return nil, err
+	}
+	room := limit - (len(overhead) - 1)
+	if room < minChunkBytes {
+		return nil, nil
+	}
+	parts := (len(encoded) + room - 1) / room
+	if parts > maxPayloadParts {
+		return nil, nil
+	}
+	reqs := make([]*http.Request, 0, parts)
+	for i := 0; i < parts; i++ {
+		end := min((i+1)*room, len(encoded))
+		part := env
+		part.Part, part.Parts = i+1, parts
+		part.Chunk = encoded[i*room : end]
+		body, contentType, err := encodeEnvelope(ep.Format, part)
+		if err != nil {
+			return nil, err
+		}
+		req, err := newSignedRequest(ctx, ep, ev, del, body, contentType, fmt.Sprintf("%s.%d", uuidFrom(del.ID), i+1))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-Payload-Part", fmt.Sprintf("%d/%d", i+1, parts))
+		reqs = append(reqs, req)
+	}
+	return reqs, nil
+}
+
+// payloadURL returns a link to fetch the full event, signed with the endpoint
+// secret so only that endpoint can use it.
+func (d *Dispatcher) payloadURL(ep dbgen.WebhookEndpoint, ev dbgen.DomainEvent, now time.Time) string {
+	ttl := d.PayloadURLTTL
+	if ttl <= 0 {
+		ttl = defaultPayloadURLTTL
+	}
+	endpointID, eventID := uuidFrom(ep.ID), uuidFrom(ev.ID)
+	expires := now.Add(ttl).Unix()
+	query := url.Values{}
+	query.Set("endpoint", endpointID)
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("signature", payloadURLSignature(ep.Secret, endpointID, eventID, expires))
+	return strings.TrimRight(d.PublicBaseURL, "/") + "/api/v1/webhooks/events/" + eventID + "?" + query.Encode()
+}
+
+func payloadURLSignature(secret, endpointID, eventID string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = fmt.Fprintf(mac, "payload.%s.%s.%d", endpointID, eventID, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// PayloadHandler serves the full event for truncated deliveries.
+type PayloadHandler struct {
+	Store Store
+	Now   func() time.Time
+}
+
+// Fetch returns the complete JSON envelope of an event when the request
+// carries a valid, unexpired link signature.
+func (h *PayloadHandler) Fetch(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Store == nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "webhook store unavailable", nil)
+		return
+	}
+	eventID, err := parseUUID(chi.URLParam(r, "id"))
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid id", nil)
+		return
+	}
+	query := r.URL.Query()
+	endpointID, err := parseUUID(query.Get("endpoint"))
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid endpoint", nil)
+		return
+	}
+	expires, err := strconv.ParseInt(query.Get("expires"), 10, 64)
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid expires", nil)
+		return
+	}
+	now := time.Now
+	if h.Now != nil {
+		now = h.Now
+	}
+	if now().Unix() > expires {
+		common.JSONError(w, http.StatusGone, common.CodeLinkExpired, "payload link expired", nil)
+		return
+	}
+	endpoint, err := h.Store.GetWebhookEndpoint(r.Context(), endpointID)
+	if err != nil {
+		common.JSONError(w, http.StatusForbidden, "FORBIDDEN", "invalid signature", nil)
+		return
+	}
+	expected := payloadURLSignature(endpoint.Secret, uuidFrom(endpointID), uuidFrom(eventID), expires)
+	if !hmac.Equal([]byte(expected), []byte(query.Get("signature"))) {
+		common.JSONError(w, http.StatusForbidden, "FORBIDDEN", "invalid signature", nil)
+		return
+	}
+	event, err := h.Store.GetDomainEvent(r.Context(), eventID)
+	if err != nil {
+		common.JSONError(w, http.StatusNotFound, "NOT_FOUND", "event not found", nil)
+		return
+	}
+	common.JSON(w, http.StatusOK, newEnvelope(event, dbgen.WebhookDelivery{}))
+}
+
+// capText shortens s to at most limit bytes for storage. Invalid UTF-8,
+// including a sequence cut at the limit, is dropped since Postgres rejects it.
+func capText(s string, limit int) string {
+	if limit > 0 && len(s) > limit {
+		s = s[:limit]
+	}
+	return strings.ToValidUTF8(s, "")
+}
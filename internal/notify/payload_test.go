@@ -0,0 +1,199 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/notify"
+	"github.com/noah-isme/backend-toko/internal/resilience"
+)
+
+type payloadRequest struct {
+	header http.Header
+	body   []byte
+}
+
+func payloadServer(t *testing.T) (*httptest.Server, chan payloadRequest) {
+	t.Helper()
+	received := make(chan payloadRequest, 64)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- payloadRequest{header: r.Header.Clone(), body: body}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, received
+}
+
+func largeEvent() dbgen.DomainEvent {
+	items := make([]map[string]any, 200)
+	for i := range items {
+		items[i] = map[string]any{"sku": "SKU-" + strconv.Itoa(i), "qty": i, "note": strings.Repeat("x", 20)}
+	}
+	payload, _ := json.Marshal(map[string]any{"orderId": "A-1", "items": items})
+	return dbgen.DomainEvent{
+		ID:         toUUID(uuid.New()),
+		Topic:      "order.created",
+		Payload:    payload,
+		OccurredAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}
+}
+
+func TestOversizedPayloadIsTruncatedWithFetchURL(t *testing.T) {
+	srv, received := payloadServer(t)
+	event := largeEvent()
+	endpoint := dbgen.WebhookEndpoint{ID: toUUID(uuid.New()), Url: srv.URL, Secret: "secret", OversizePolicy: notify.OversizeTruncate}
+	dispatcher := &notify.Dispatcher{
+		HTTP:            &resilience.HTTPClient{Client: srv.Client(), MaxAttempts: 1, Timeout: time.Second},
+		MaxPayloadBytes: 2048,
+		PublicBaseURL:   "https://api.example.com",
+	}
+
+	status, _, err := dispatcher.Deliver(context.Background(), endpoint, event, dbgen.WebhookDelivery{ID: toUUID(uuid.New())})
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+
+	got := <-received
+	require.LessOrEqual(t, len(got.body), 2048)
+	var env map[string]any
+	require.NoError(t, json.Unmarshal(got.body, &env))
+	require.Equal(t, true, env["truncated"])
+	require.Equal(t, float64(len(event.Payload)), env["size"])
+	require.NotContains(t, env, "data")
+	resource, err := url.Parse(env["resourceUrl"].(string))
+	require.NoError(t, err)
+	require.Equal(t, "api.example.com", resource.Host)
+	require.Equal(t, "/api/v1/webhooks/events/"+uuidString(event.ID), resource.Path)
+
+	store := &retryStore{endpoint: endpoint, event: event}
+	router := chi.NewRouter()
+	router.Get("/api/v1/webhooks/events/{id}", (&notify.PayloadHandler{Store: store}).Fetch)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, resource.RequestURI(), nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var full struct {
+		EventID string          `json:"eventId"`
+		Data    json.RawMessage `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &full))
+	require.Equal(t, uuidString(event.ID), full.EventID)
+	require.JSONEq(t, string(event.Payload), string(full.Data))
+
+	tampered := resource.Query()
+	tampered.Set("expires", strconv.FormatInt(time.Now().Add(365*24*time.Hour).Unix(), 10))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, resource.Path+"?"+tampered.Encode(), nil))
+	require.Equal(t, http.StatusForbidden, rec.Code)
+
+	expired := &notify.PayloadHandler{Store: store, Now: func() time.Time { return time.Now().Add(8 * 24 * time.Hour) }}
+	router = chi.NewRouter()
+	router.Get("/api/v1/webhooks/events/{id}", expired.Fetch)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, resource.RequestURI(), nil))
+	require.Equal(t, http.StatusGone, rec.Code)
+}
+
+func TestOversizedPayloadIsSplitIntoParts(t *testing.T) {
+	srv, received := payloadServer(t)
+	event := largeEvent()
+	delivery := dbgen.WebhookDelivery{ID: toUUID(uuid.New())}
+	endpoint := dbgen.WebhookEndpoint{
+		ID:              toUUID(uuid.New()),
+		Url:             srv.URL,
+		Secret:          "secret",
+		MaxPayloadBytes: 2048,
+		OversizePolicy:  notify.OversizeSplit,
+	}
+	dispatcher := &notify.Dispatcher{HTTP: &resilience.HTTPClient{Client: srv.Client(), MaxAttempts: 1, Timeout: time.Second}}
+
+	status, _, err := dispatcher.Deliver(context.Background(), endpoint, event, delivery)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, status)
+	close(received)
+
+	var joined strings.Builder
+	parts := 0
+	for got := range received {
+		parts++
+		require.LessOrEqual(t, len(got.body), 2048)
+		ts, _ := strconv.ParseInt(got.header.Get("X-Timestamp"), 10, 64)
+		require.Equal(t, notify.ComputeSignature("secret", ts, got.header.Get("X-Event-ID"), got.body), got.header.Get("X-Signature"))
+		require.Equal(t, uuidString(delivery.ID)+"."+strconv.Itoa(parts), got.header.Get("X-Idempotency-Key"))
+
+		var env struct {
+			Part  int    `json:"part"`
+			Parts int    `json:"parts"`
+			Size  int    `json:"size"`
+			Chunk string `json:"chunk"`
+		}
+		require.NoError(t, json.Unmarshal(got.body, &env))
+		require.Equal(t, parts, env.Part)
+		require.Equal(t, strconv.Itoa(env.Part)+"/"+strconv.Itoa(env.Parts), got.header.Get("X-Payload-Part"))
+		require.Equal(t, len(event.Payload), env.Size)
+		joined.WriteString(env.Chunk)
+	}
+	require.Greater(t, parts, 1)
+	data, err := base64.RawURLEncoding.DecodeString(joined.String())
+	require.NoError(t, err)
+	require.Equal(t, event.Payload, data)
+}
+
+func TestOversizePolicyValidation(t *testing.T) {
+	policy, err := notify.NormalizeOversizePolicy("")
+	require.NoError(t, err)
+	require.Equal(t, notify.OversizeTruncate, policy)
+	policy, err = notify.NormalizeOversizePolicy(" Split ")
+	require.NoError(t, err)
+	require.Equal(t, notify.OversizeSplit, policy)
+	_, err = notify.NormalizeOversizePolicy("drop")
+	require.Error(t, err)
+
+	require.NoError(t, notify.ValidateMaxPayloadBytes(0))
+	require.NoError(t, notify.ValidateMaxPayloadBytes(notify.MinPayloadBytes))
+	require.Error(t, notify.ValidateMaxPayloadBytes(100))
+	require.Error(t, notify.ValidateMaxPayloadBytes(-1))
+}
+
+func TestStoredResponseAndReasonAreCapped(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(strings.Repeat("é", 4096)))
+	}))
+	t.Cleanup(srv.Close)
+
+	store := &retryStore{
+		endpoint: dbgen.WebhookEndpoint{ID: toUUID(uuid.New()), Url: srv.URL, Secret: "secret"},
+		event:    dbgen.DomainEvent{ID: toUUID(uuid.New()), Topic: "order.paid", Payload: []byte(`{"id":1}`)},
+	}
+	dispatcher := &notify.Dispatcher{
+		Store:             store,
+		HTTP:              &resilience.HTTPClient{Client: srv.Client(), MaxAttempts: 1, Timeout: time.Second},
+		AttemptBodyLimit:  101,
+		Enabled:           true,
+		RetryClientErrors: true,
+	}
+
+	require.NoError(t, dispatcher.WorkOnce(context.Background(), 1))
+	require.NoError(t, dispatcher.WorkOnce(context.Background(), 1))
+	require.Len(t, store.attempts, 2)
+	body := store.attempts[0].ResponseBody.String
+	require.Len(t, body, 100, "the cut drops the partial character at the limit")
+	require.Equal(t, strings.Repeat("é", 50), body)
+	require.Len(t, store.dlq, 1)
+	require.LessOrEqual(t, len(store.dlq[0].LastError.String), 101)
+}
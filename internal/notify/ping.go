@@ -0,0 +1,123 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/events"
+)
+
+// maxPingBody caps how much of the endpoint response a test delivery returns.
+const maxPingBody = 4096
+
+// ErrTopicNotSubscribed is returned when a test delivery names a topic the
+// endpoint does not subscribe to.
+var ErrTopicNotSubscribed = errors.New("endpoint is not subscribed to topic")
+
+// PingResult reports the outcome of a test delivery.
+type PingResult struct {
+	EventID    string `json:"eventId"`
+	DeliveryID string `json:"deliveryId"`
+	Topic      string `json:"topic"`
+	Success    bool   `json:"success"`
+	Status     int    `json:"status"`
+	Body       string `json:"body"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// Ping sends a synthetic, signed event to ep exactly once. Nothing is
+// persisted and the request bypasses retries, the shared circuit breaker, and
+// replay protection, so a misconfigured endpoint cannot affect real traffic
+// and error responses are returned as is.
+// An empty topic picks the first topic the endpoint's first subscription
+// covers.
+func (d *Dispatcher) Ping(ctx context.Context, ep dbgen.WebhookEndpoint, topic string) (PingResult, error) {
+	if d == nil {
+		return PingResult{}, errors.New("dispatcher not configured")
+	}
+	topic = strings.ToLower(strings.TrimSpace(topic))
+	switch {
+	case topic == "":
+		topic = events.TopicOrderCreated
+		// Pick the first known topic the endpoint's first pattern covers.
+		if len(ep.Topics) > 0 {
+			if i := slices.IndexFunc(events.SubscribableTopics(), func(t string) bool { return events.MatchTopic(ep.Topics[0], t) }); i >= 0 {
+				topic = events.SubscribableTopics()[i]
+			}
+		}
+	case !subscribed(ep.Topics, topic):
+		return PingResult{}, fmt.Errorf("%w: %s", ErrTopicNotSubscribed, topic)
+	}
+	payload, err := json.Marshal(samplePayload(topic))
+	if err != nil {
+		return PingResult{}, err
+	}
+	event := dbgen.DomainEvent{
+		ID:         pgtype.UUID{Bytes: uuid.New(), Valid: true},
+		Topic:      topic,
+		Payload:    payload,
+		OccurredAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}
+	delivery := dbgen.WebhookDelivery{ID: pgtype.UUID{Bytes: uuid.New(), Valid: true}, EndpointID: ep.ID, EventID: event.ID}
+
+	result := PingResult{EventID: uuidFrom(event.ID), DeliveryID: uuidFrom(delivery.ID), Topic: topic}
+	req, err := signedRequest(ctx, ep, event, delivery)
+	if err == nil {
+		err = d.Headers.setCustomHeaders(ep.CustomHeaders, req)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	if reqID := common.RequestID(ctx); reqID != "" {
+		req.Header.Set(common.RequestIDHeader, reqID)
+	}
+	start := time.Now()
+	resp, err := d.httpClient().Client.Do(req)
+	result.DurationMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxPingBody))
+	if err != nil {
+		result.Error = err.Error()
+	}
+	result.Status = resp.StatusCode
+	result.Body = string(body)
+	result.Success = err == nil && resp.StatusCode >= 200 && resp.StatusCode < 300
+	return result, nil
+}
+
+func samplePayload(topic string) map[string]any {
+	data := map[string]any{"test": true, "orderId": uuid.NewString()}
+	switch {
+	case strings.HasPrefix(topic, "order."):
+		data["status"] = strings.ToUpper(strings.TrimPrefix(topic, "order."))
+		data["total"] = 150000
+		data["currency"] = "IDR"
+	case strings.HasPrefix(topic, "payment."):
+		data["provider"] = "midtrans"
+		data["status"] = strings.ToUpper(strings.TrimPrefix(topic, "payment."))
+	case strings.HasPrefix(topic, "shipment."):
+		data["courier"] = "jne"
+		data["trackingNumber"] = "TEST0000000000"
+		data["status"] = strings.ToUpper(strings.TrimPrefix(topic, "shipment."))
+	}
+	return data
+}
@@ -0,0 +1,263 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/events"
+)
+
+// Email preference categories. Topics no routing rule covers fall under
+// EmailCategoryMarketing.
+const (
+	EmailCategoryTransactional = "transactional"
+	EmailCategoryShipping      = "shipping"
+	EmailCategoryMarketing     = "marketing"
+)
+
+// DefaultEmailCategories routes order and payment emails to transactional and
+// shipment emails to shipping.
+func DefaultEmailCategories() map[string]string {
+	return map[string]string{
+		"order.*":    EmailCategoryTransactional,
+		"payment.*":  EmailCategoryTransactional,
+		"shipment.*": EmailCategoryShipping,
+	}
+}
+
+// DefaultRequiredEmailCategories cannot be opted out of.
+var DefaultRequiredEmailCategories = []string{EmailCategoryTransactional}
+
+// ErrUnknownEmailCategory and ErrRequiredEmailCategory reject preference
+// updates.
+var (
+	ErrUnknownEmailCategory  = errors.New("unknown email category")
+	ErrRequiredEmailCategory = errors.New("email category cannot be disabled")
+)
+
+// EmailRouting assigns topics to preference categories.
+type EmailRouting struct {
+	// Categories maps a topic pattern, as matched by events.MatchTopic, to a
+	// category. The exact topic wins over the longest matching prefix. Nil
+	// uses DefaultEmailCategories.
+	Categories map[string]string
+	// Required lists the categories that are always sent. Nil uses
+	// DefaultRequiredEmailCategories.
+	Required []string
+}
+
+func (r EmailRouting) categories() map[string]string {
+	if r.Categories == nil {
+		return DefaultEmailCategories()
+	}
+	return r.Categories
+}
+
+func (r EmailRouting) required() []string {
+	if r.Required == nil {
+		return DefaultRequiredEmailCategories
+	}
+	return r.Required
+}
+
+// Category returns the preference category of topic.
+func (r EmailRouting) Category(topic string) string {
+	best, category := "", EmailCategoryMarketing
+	for pattern, c := range r.categories() {
+		if !events.MatchTopic(pattern, topic) {
+			continue
+		}
+		if pattern == topic {
+			return c
+		}
+		if len(pattern) > len(best) || len(pattern) == len(best) && pattern < best {
+			best, category = pattern, c
+		}
+	}
+	return category
+}
+
+// IsRequired reports whether category is always sent.
+func (r EmailRouting) IsRequired(category string) bool {
+	return slices.Contains(r.required(), category)
+}
+
+// List returns every category: the routed ones, the required ones, and
+// EmailCategoryMarketing, sorted.
+func (r EmailRouting) List() []string {
+	out := []string{EmailCategoryMarketing}
+	for _, c := range r.categories() {
+		out = append(out, c)
+	}
+	out = append(out, r.required()...)
+	sort.Strings(out)
+	return slices.Compact(out)
+}
+
+// PreferenceStore persists per-user email preferences.
+type PreferenceStore interface {
+	ListUserEmailPreferences(ctx context.Context, userID pgtype.UUID) ([]dbgen.UserEmailPreference, error)
+	UpsertUserEmailPreference(ctx context.Context, arg dbgen.UpsertUserEmailPreferenceParams) error
+	GetUserByID(ctx context.Context, id pgtype.UUID) (dbgen.GetUserByIDRow, error)
+}
+
+// EmailPreferences applies per-user opt-outs on top of EmailRouting.
+type EmailPreferences struct {
+	Store   PreferenceStore
+	Routing EmailRouting
+}
+
+// CategoryPreference is a user's setting for one category.
+type CategoryPreference struct {
+	Category string `json:"category"`
+	Enabled  bool   `json:"enabled"`
+	// Required categories are always sent and cannot be disabled.
+	Required bool `json:"required"`
+}
+
+// Get returns the user's setting for every category. Categories the user
+// never changed are enabled.
+func (p EmailPreferences) Get(ctx context.Context, userID pgtype.UUID) ([]CategoryPreference, error) {
+	rows, err := p.Store.ListUserEmailPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		stored[row.Category] = row.Enabled
+	}
+	categories := p.Routing.List()
+	out := make([]CategoryPreference, 0, len(categories))
+	for _, category := range categories {
+		required := p.Routing.IsRequired(category)
+		enabled, ok := stored[category]
+		out = append(out, CategoryPreference{Category: category, Enabled: required || !ok || enabled, Required: required})
+	}
+	return out, nil
+}
+
+// Set stores the given settings, leaving other categories as they are. It
+// rejects unknown categories and disabling a required one before storing
+// anything.
+func (p EmailPreferences) Set(ctx context.Context, userID pgtype.UUID, settings map[string]bool) error {
+	categories := p.Routing.List()
+	for category, enabled := range settings {
+		if !slices.Contains(categories, category) {
+			return fmt.Errorf("%w: %s", ErrUnknownEmailCategory, category)
+		}
+		if !enabled && p.Routing.IsRequired(category) {
+			return fmt.Errorf("%w: %s", ErrRequiredEmailCategory, category)
+		}
+	}
+	for category, enabled := range settings {
+		err := p.Store.UpsertUserEmailPreference(ctx, dbgen.UpsertUserEmailPreferenceParams{UserID: userID, Category: category, Enabled: enabled})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Allowed reports whether the user wants emails about topic.
+func (p EmailPreferences) Allowed(ctx context.Context, userID pgtype.UUID, topic string) (bool, error) {
+	category := p.Routing.Category(topic)
+	if p.Store == nil || p.Routing.IsRequired(category) {
+		return true, nil
+	}
+	rows, err := p.Store.ListUserEmailPreferences(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	for _, row := range rows {
+		if row.Category == category {
+			return row.Enabled, nil
+		}
+	}
+	return true, nil
+}
+
+// PreferenceHandler lets users view and change their email preferences.
+type PreferenceHandler struct {
+	Prefs EmailPreferences
+}
+
+// Get handles GET /api/v1/users/me/email-preferences.
+func (h PreferenceHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userID(w, r)
+	if !ok {
+		return
+	}
+	prefs, err := h.Prefs.Get(r.Context(), userID)
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "failed to load email preferences", nil)
+		return
+	}
+	common.JSON(w, http.StatusOK, map[string]any{"data": prefs})
+}
+
+// Update handles PUT /api/v1/users/me/email-preferences with a body such as
+// {"categories": {"marketing": false}}.
+func (h PreferenceHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := h.userID(w, r)
+	if !ok {
+		return
+	}
+	var req struct {
+		Categories map[string]bool `json:"categories"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.JSONError(w, http.StatusBadRequest, "INVALID_BODY", "invalid request payload", nil)
+		return
+	}
+	err := h.Prefs.Set(r.Context(), userID, req.Categories)
+	switch {
+	case errors.Is(err, ErrUnknownEmailCategory):
+		common.JSONError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error(), map[string]any{"field": "categories", "allowed": h.Prefs.Routing.List()})
+		return
+	case errors.Is(err, ErrRequiredEmailCategory):
+		common.JSONError(w, http.StatusBadRequest, "VALIDATION_ERROR", err.Error(), map[string]any{"field": "categories"})
+		return
+	case err != nil:
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "failed to save email preferences", nil)
+		return
+	}
+	h.Get(w, r)
+}
+
+func (h PreferenceHandler) userID(w http.ResponseWriter, r *http.Request) (pgtype.UUID, bool) {
+	if h.Prefs.Store == nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "email preferences not configured", nil)
+		return pgtype.UUID{}, false
+	}
+	raw, ok := common.UserID(r.Context())
+	if !ok {
+		common.JSONError(w, http.StatusUnauthorized, "UNAUTHORIZED", "missing or invalid token", nil)
+		return pgtype.UUID{}, false
+	}
+	userID, err := parseUUID(raw)
+	if err != nil {
+		common.JSONError(w, http.StatusUnauthorized, "UNAUTHORIZED", "missing or invalid token", nil)
+		return pgtype.UUID{}, false
+	}
+	return userID, true
+}
+
+// recipientUser returns the user an event payload is about, if any.
+func recipientUser(payload map[string]any) (pgtype.UUID, bool) {
+	raw, _ := payload["userId"].(string)
+	if strings.TrimSpace(raw) == "" {
+		return pgtype.UUID{}, false
+	}
+	id, err := parseUUID(raw)
+	return id, err == nil
+}
@@ -0,0 +1,96 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/events"
+	"github.com/noah-isme/backend-toko/internal/notify"
+)
+
+type prefStore struct {
+	prefs map[string]bool
+	email string
+}
+
+func (s *prefStore) ListUserEmailPreferences(ctx context.Context, userID pgtype.UUID) ([]dbgen.UserEmailPreference, error) {
+	var rows []dbgen.UserEmailPreference
+	for category, enabled := range s.prefs {
+		rows = append(rows, dbgen.UserEmailPreference{UserID: userID, Category: category, Enabled: enabled})
+	}
+	return rows, nil
+}
+
+func (s *prefStore) UpsertUserEmailPreference(ctx context.Context, arg dbgen.UpsertUserEmailPreferenceParams) error {
+	if s.prefs == nil {
+		s.prefs = map[string]bool{}
+	}
+	s.prefs[arg.Category] = arg.Enabled
+	return nil
+}
+
+func (s *prefStore) GetUserByID(ctx context.Context, id pgtype.UUID) (dbgen.GetUserByIDRow, error) {
+	return dbgen.GetUserByIDRow{ID: id, Email: s.email}, nil
+}
+
+type sentMail struct{ to []string }
+
+func (m *sentMail) Send(to, subject, html string) error {
+	m.to = append(m.to, to)
+	return nil
+}
+
+func TestEmailNotifierHonoursUserOptOut(t *testing.T) {
+	userID := uuid.New()
+	store := &prefStore{email: "buyer@example.com"}
+	prefs := notify.EmailPreferences{Store: store}
+	mail := &sentMail{}
+	notifier := notify.EmailNotifier{Mail: mail, Enabled: true, Preferences: prefs}
+	ctx := context.Background()
+	id := pgtype.UUID{Bytes: userID, Valid: true}
+
+	require.NoError(t, prefs.Set(ctx, id, map[string]bool{notify.EmailCategoryShipping: false}))
+
+	event := func(topic string, payload any) dbgen.DomainEvent {
+		data, err := json.Marshal(payload)
+		require.NoError(t, err)
+		return dbgen.DomainEvent{Topic: topic, Payload: data}
+	}
+	shipped := event(events.TopicShipmentShipped, map[string]string{"orderId": "o-1", "userId": userID.String(), "email": "buyer@example.com"})
+	require.NoError(t, notifier.Notify(ctx, shipped))
+	require.Empty(t, mail.to, "opted-out user must not be emailed")
+
+	// Transactional emails are required and only name the user.
+	paid := event(events.TopicOrderPaid, map[string]string{"orderId": "o-1", "userId": userID.String()})
+	require.NoError(t, notifier.Notify(ctx, paid))
+	require.Equal(t, []string{"buyer@example.com"}, mail.to)
+
+	require.ErrorIs(t, prefs.Set(ctx, id, map[string]bool{notify.EmailCategoryTransactional: false}), notify.ErrRequiredEmailCategory)
+	require.ErrorIs(t, prefs.Set(ctx, id, map[string]bool{"newsletters": true}), notify.ErrUnknownEmailCategory)
+
+	got, err := prefs.Get(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, []notify.CategoryPreference{
+		{Category: notify.EmailCategoryMarketing, Enabled: true},
+		{Category: notify.EmailCategoryShipping, Enabled: false},
+		{Category: notify.EmailCategoryTransactional, Enabled: true, Required: true},
+	}, got)
+}
+
+func TestEmailRoutingPrefersMostSpecificPattern(t *testing.T) {
+	routing := notify.EmailRouting{Categories: map[string]string{
+		"*":                  "marketing",
+		"shipment.*":         "shipping",
+		"shipment.delivered": "transactional",
+	}}
+	require.Equal(t, "shipping", routing.Category(events.TopicShipmentShipped))
+	require.Equal(t, "transactional", routing.Category(events.TopicShipmentDelivered))
+	require.Equal(t, "marketing", routing.Category(events.TopicOrderPaid))
+	require.True(t, routing.IsRequired(notify.EmailCategoryTransactional))
+}
@@ -0,0 +1,152 @@
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/obs"
+)
+
+// DefaultPurgeBatchSize bounds how many rows one purge statement deletes so
+// no single statement holds row locks for long.
+const DefaultPurgeBatchSize = 1000
+
+// PurgeStore is the subset of queries the retention purge needs.
+type PurgeStore interface {
+	PurgeWebhookDeliveries(ctx context.Context, arg dbgen.PurgeWebhookDeliveriesParams) (int64, error)
+	PurgeDeliveryAttempts(ctx context.Context, arg dbgen.PurgeDeliveryAttemptsParams) (int64, error)
+	PurgeDomainEvents(ctx context.Context, arg dbgen.PurgeDomainEventsParams) (int64, error)
+	PurgeInboundWebhookEvents(ctx context.Context, arg dbgen.PurgeInboundWebhookEventsParams) (int64, error)
+}
+
+// Retention holds how long each kind of row is kept. A zero period keeps
+// those rows forever.
+type Retention struct {
+	Delivered  time.Duration
+	DeadLetter time.Duration
+	Attempts   time.Duration
+	Events     time.Duration
+	// Inbound is how long processed provider callbacks are remembered for
+	// deduplication.
+	Inbound time.Duration
+}
+
+// PurgeResult reports rows deleted per target by one purge pass. Attempts and
+// DLQ rows removed by cascade when their delivery is deleted are not counted.
+type PurgeResult map[string]int64
+
+// Purger deletes delivered and dead-lettered webhook deliveries, their
+// attempts, domain events, and processed inbound callbacks once they age past
+// the retention periods.
+// Only terminal deliveries are deleted, and events are kept for as long as any
+// delivery references them, so pending work is never lost.
+type Purger struct {
+	Store     PurgeStore
+	Retention Retention
+	BatchSize int
+	// Interval between purge passes; zero runs hourly.
+	Interval time.Duration
+	Logger   zerolog.Logger
+	// Now overrides the clock in tests.
+	Now func() time.Time
+}
+
+// Run purges once immediately and then every Interval until ctx is done.
+func (p *Purger) Run(ctx context.Context) error {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		result, err := p.PurgeOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			p.Logger.Error().Err(err).Msg("retention purge failed")
+		} else if len(result) > 0 {
+			event := p.Logger.Info()
+			for target, rows := range result {
+				event = event.Int64(target, rows)
+			}
+			event.Msg("retention purge complete")
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeOnce runs one pass over every target with a retention period. Deliveries
+// are purged before events so events they released can go in the same pass.
+func (p *Purger) PurgeOnce(ctx context.Context) (PurgeResult, error) {
+	if p == nil || p.Store == nil {
+		return nil, nil
+	}
+	now := time.Now
+	if p.Now != nil {
+		now = p.Now
+	}
+	batch := p.BatchSize
+	if batch <= 0 {
+		batch = DefaultPurgeBatchSize
+	}
+	size := int32(batch)
+	cutoff := func(keep time.Duration) pgtype.Timestamptz {
+		return pgtype.Timestamptz{Time: now().Add(-keep), Valid: true}
+	}
+
+	steps := []struct {
+		target string
+		keep   time.Duration
+		purge  func(pgtype.Timestamptz) (int64, error)
+	}{
+		{"webhook_delivery_attempts", p.Retention.Attempts, func(before pgtype.Timestamptz) (int64, error) {
+			return p.Store.PurgeDeliveryAttempts(ctx, dbgen.PurgeDeliveryAttemptsParams{Before: before, BatchSize: size})
+		}},
+		{"webhook_deliveries_delivered", p.Retention.Delivered, func(before pgtype.Timestamptz) (int64, error) {
+			return p.Store.PurgeWebhookDeliveries(ctx, dbgen.PurgeWebhookDeliveriesParams{Status: dbgen.DeliveryStatusDELIVERED, Before: before, BatchSize: size})
+		}},
+		{"webhook_deliveries_dlq", p.Retention.DeadLetter, func(before pgtype.Timestamptz) (int64, error) {
+			return p.Store.PurgeWebhookDeliveries(ctx, dbgen.PurgeWebhookDeliveriesParams{Status: dbgen.DeliveryStatusDLQ, Before: before, BatchSize: size})
+		}},
+		{"domain_events", p.Retention.Events, func(before pgtype.Timestamptz) (int64, error) {
+			return p.Store.PurgeDomainEvents(ctx, dbgen.PurgeDomainEventsParams{Before: before, BatchSize: size})
+		}},
+		{"inbound_webhook_events", p.Retention.Inbound, func(before pgtype.Timestamptz) (int64, error) {
+			return p.Store.PurgeInboundWebhookEvents(ctx, dbgen.PurgeInboundWebhookEventsParams{Before: before, BatchSize: size})
+		}},
+	}
+
+	result := PurgeResult{}
+	for _, step := range steps {
+		if step.keep <= 0 {
+			continue
+		}
+		before := cutoff(step.keep)
+		for {
+			if err := ctx.Err(); err != nil {
+				return result, err
+			}
+			rows, err := step.purge(before)
+			if err != nil {
+				return result, err
+			}
+			if rows > 0 {
+				result[step.target] += rows
+				if obs.RetentionRowsPurgedTotal != nil {
+					obs.RetentionRowsPurgedTotal.WithLabelValues(step.target).Add(float64(rows))
+				}
+			}
+			if rows < int64(batch) {
+				break
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,105 @@
+package notify_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/notify"
+)
+
+// purgeStore pretends each purge target holds a fixed number of old rows and
+// records the cutoffs and batch sizes it was asked for.
+type purgeStore struct {
+	rows    map[string]int64
+	cutoffs map[string]time.Time
+	calls   map[string]int
+	batches []int32
+}
+
+func (s *purgeStore) take(target string, before time.Time, batch int32) int64 {
+	s.cutoffs[target] = before
+	s.calls[target]++
+	s.batches = append(s.batches, batch)
+	n := s.rows[target]
+	if n > int64(batch) {
+		n = int64(batch)
+	}
+	s.rows[target] -= n
+	return n
+}
+
+func (s *purgeStore) PurgeWebhookDeliveries(_ context.Context, arg dbgen.PurgeWebhookDeliveriesParams) (int64, error) {
+	return s.take(string(arg.Status), arg.Before.Time, arg.BatchSize), nil
+}
+
+func (s *purgeStore) PurgeDeliveryAttempts(_ context.Context, arg dbgen.PurgeDeliveryAttemptsParams) (int64, error) {
+	return s.take("attempts", arg.Before.Time, arg.BatchSize), nil
+}
+
+func (s *purgeStore) PurgeDomainEvents(_ context.Context, arg dbgen.PurgeDomainEventsParams) (int64, error) {
+	return s.take("events", arg.Before.Time, arg.BatchSize), nil
+}
+
+func (s *purgeStore) PurgeInboundWebhookEvents(_ context.Context, arg dbgen.PurgeInboundWebhookEventsParams) (int64, error) {
+	return s.take("inbound", arg.Before.Time, arg.BatchSize), nil
+}
+
+func TestPurgerDeletesInBatchesPerRetention(t *testing.T) {
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	store := &purgeStore{
+		rows:    map[string]int64{"DELIVERED": 25, "DLQ": 3, "attempts": 10, "events": 7, "inbound": 4},
+		cutoffs: map[string]time.Time{},
+		calls:   map[string]int{},
+	}
+	purger := &notify.Purger{
+		Store: store,
+		Retention: notify.Retention{
+			Delivered:  14 * 24 * time.Hour,
+			DeadLetter: 90 * 24 * time.Hour,
+			Events:     30 * 24 * time.Hour,
+			Inbound:    60 * 24 * time.Hour,
+		},
+		BatchSize: 10,
+		Now:       func() time.Time { return now },
+	}
+
+	result, err := purger.PurgeOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, notify.PurgeResult{
+		"webhook_deliveries_delivered": 25,
+		"webhook_deliveries_dlq":       3,
+		"domain_events":                7,
+		"inbound_webhook_events":       4,
+	}, result)
+
+	// 25 delivered rows take three batches; the last short batch ends the loop.
+	require.Equal(t, 3, store.calls["DELIVERED"])
+	require.Equal(t, 1, store.calls["DLQ"])
+	require.NotContains(t, store.calls, "attempts", "a zero retention keeps rows forever")
+	for _, batch := range store.batches {
+		require.EqualValues(t, 10, batch)
+	}
+
+	require.Equal(t, now.AddDate(0, 0, -14), store.cutoffs["DELIVERED"])
+	require.Equal(t, now.AddDate(0, 0, -90), store.cutoffs["DLQ"])
+	require.Equal(t, now.AddDate(0, 0, -30), store.cutoffs["events"])
+	require.Equal(t, now.AddDate(0, 0, -60), store.cutoffs["inbound"])
+}
+
+func TestPurgerStopsWhenContextCancelled(t *testing.T) {
+	store := &purgeStore{
+		rows:    map[string]int64{"DELIVERED": 100},
+		cutoffs: map[string]time.Time{},
+		calls:   map[string]int{},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	purger := &notify.Purger{Store: store, Retention: notify.Retention{Delivered: time.Hour}}
+	_, err := purger.PurgeOnce(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Zero(t, store.calls["DELIVERED"])
+}
@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"fmt"
+	"slices"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+// Failed attempts are retryable or permanent. A permanent failure moves the
+// delivery to the DLQ at once instead of spending its remaining attempts.
+const (
+	FailureRetryable = "retryable"
+	FailurePermanent = "permanent"
+)
+
+// ClassifyFailure decides whether a failed attempt that got status (zero for
+// timeouts and connection errors) is worth retrying. 4xx responses other than
+// 408 and 429 are permanent, as the receiver rejected the request itself;
+// everything else is retryable. The endpoint's retry and permanent status
+// lists override that default, and retryClientErrors makes every status
+// retryable unless the endpoint lists it as permanent.
+func ClassifyFailure(endpoint dbgen.WebhookEndpoint, status int, retryClientErrors bool) string {
+	code := int32(status)
+	switch {
+	case status <= 0:
+		return FailureRetryable
+	case slices.Contains(endpoint.PermanentStatuses, code):
+		return FailurePermanent
+	case slices.Contains(endpoint.RetryStatuses, code):
+		return FailureRetryable
+	case retryClientErrors:
+		return FailureRetryable
+	case status == 408 || status == 429:
+		return FailureRetryable
+	case status >= 400 && status < 500:
+		return FailurePermanent
+	}
+	return FailureRetryable
+}
+
+// NormalizeRetryStatuses validates an endpoint's retry and permanent status
+// overrides: each must be an HTTP status from 300 to 599 and none may appear
+// in both lists. The lists come back sorted without duplicates; nil becomes
+// empty.
+func NormalizeRetryStatuses(retry, permanent []int) ([]int32, []int32, error) {
+	retryOut, err := normalizeStatuses("retry_statuses", retry)
+	if err != nil {
+		return nil, nil, err
+	}
+	permanentOut, err := normalizeStatuses("permanent_statuses", permanent)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, code := range retryOut {
+		if slices.Contains(permanentOut, code) {
+			return nil, nil, fmt.Errorf("status %d cannot be both retryable and permanent", code)
+		}
+	}
+	return retryOut, permanentOut, nil
+}
+
+func normalizeStatuses(field string, statuses []int) ([]int32, error) {
+	out := make([]int32, 0, len(statuses))
+	for _, status := range statuses {
+		if status < 300 || status > 599 {
+			return nil, fmt.Errorf("%s must hold HTTP statuses from 300 to 599, got %d", field, status)
+		}
+		out = append(out, int32(status))
+	}
+	slices.Sort(out)
+	return slices.Compact(out), nil
+}
@@ -0,0 +1,103 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+
+	"github.com/noah-isme/backend-toko/internal/resilience"
+)
+
+const sendGridURL = "https://api.sendgrid.com/v3/mail/send"
+
+// SendGridEmailSender delivers email through the SendGrid v3 mail send API.
+type SendGridEmailSender struct {
+	HTTP   *resilience.HTTPClient
+	APIKey string
+	From   string
+	// URL overrides the API endpoint, e.g. for the EU region or tests.
+	URL string
+}
+
+type sendGridAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sendGridPersonalization struct {
+	To []sendGridAddress `json:"to"`
+}
+
+type sendGridRequest struct {
+	Personalizations []sendGridPersonalization `json:"personalizations"`
+	From             sendGridAddress           `json:"from"`
+	Subject          string                    `json:"subject"`
+	Content          []sendGridContent         `json:"content"`
+}
+
+// Send implements common.EmailSender.
+func (s SendGridEmailSender) Send(to, subject, html string) error {
+	return s.SendContext(context.Background(), to, subject, html)
+}
+
+// SendContext implements ContextEmailSender.
+func (s SendGridEmailSender) SendContext(ctx context.Context, to, subject, html string) error {
+	if s.HTTP == nil {
+		return errors.New("sendgrid: http client not configured")
+	}
+	from, err := mail.ParseAddress(s.From)
+	if err != nil {
+		return fmt.Errorf("sendgrid: invalid from address: %w", err)
+	}
+	rcpt, err := mail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("sendgrid: invalid recipient: %w", err)
+	}
+	payload := sendGridRequest{
+		Personalizations: []sendGridPersonalization{{
+			To: []sendGridAddress{{Email: rcpt.Address, Name: rcpt.Name}},
+		}},
+		From:    sendGridAddress{Email: from.Address, Name: from.Name},
+		Subject: subject,
+		// SendGrid requires text/plain to precede text/html.
+		Content: []sendGridContent{
+			{Type: "text/plain", Value: htmlToText(html)},
+			{Type: "text/html", Value: html},
+		},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	url := s.URL
+	if url == "" {
+		url = sendGridURL
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+s.APIKey)
+	resp, err := s.HTTP.Do(ctx, req)
+	if err != nil {
+		return fmt.Errorf("sendgrid: %w", err)
+	}
+	defer resp.Body.Close()
+	detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("sendgrid: unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SMTP transport security modes.
+const (
+	SMTPStartTLS = "starttls"
+	SMTPTLS      = "tls"
+	SMTPNoTLS    = "none"
+)
+
+// SMTPEmailSender delivers email through an SMTP relay.
+type SMTPEmailSender struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+	// TLS is SMTPStartTLS (the default), SMTPTLS for implicit TLS (usually
+	// port 465), or SMTPNoTLS for local relays.
+	TLS string
+	// Timeout bounds the whole conversation when the context has no deadline.
+	Timeout time.Duration
+	// TLSConfig overrides the TLS settings; ServerName defaults to Host.
+	TLSConfig *tls.Config
+}
+
+// Send implements common.EmailSender.
+func (s SMTPEmailSender) Send(to, subject, html string) error {
+	return s.SendContext(context.Background(), to, subject, html)
+}
+
+// SendContext implements ContextEmailSender.
+func (s SMTPEmailSender) SendContext(ctx context.Context, to, subject, htmlBody string) error {
+	from, err := mail.ParseAddress(s.From)
+	if err != nil {
+		return fmt.Errorf("smtp: invalid from address: %w", err)
+	}
+	rcpt, err := mail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("smtp: invalid recipient: %w", err)
+	}
+	msg, err := buildMIMEMessage(from, rcpt, subject, htmlBody, time.Now())
+	if err != nil {
+		return err
+	}
+
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = defaultEmailSendTimeout
+	}
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	port := s.Port
+	if port <= 0 {
+		port = 587
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.Host, strconv.Itoa(port)))
+	if err != nil {
+		return fmt.Errorf("smtp: dial: %w", err)
+	}
+	deadline, _ := ctx.Deadline()
+	_ = conn.SetDeadline(deadline)
+	// Closing the connection unblocks any pending read once ctx ends.
+	stop := context.AfterFunc(ctx, func() { _ = conn.Close() })
+	defer stop()
+
+	if s.TLS == SMTPTLS {
+		conn = tls.Client(conn, s.tlsConfig())
+	}
+	client, err := smtp.NewClient(conn, s.Host)
+	if err != nil {
+		_ = conn.Close()
+		return fmt.Errorf("smtp: handshake: %w", err)
+	}
+	defer client.Close()
+
+	if s.TLS == "" || s.TLS == SMTPStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return errors.New("smtp: server does not support STARTTLS")
+		}
+		if err := client.StartTLS(s.tlsConfig()); err != nil {
+			return fmt.Errorf("smtp: starttls: %w", err)
+		}
+	}
+	if s.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", s.Username, s.Password, s.Host)); err != nil {
+			return fmt.Errorf("smtp: auth: %w", err)
+		}
+	}
+	if err := client.Mail(from.Address); err != nil {
+		return fmt.Errorf("smtp: mail from: %w", err)
+	}
+	if err := client.Rcpt(rcpt.Address); err != nil {
+		return fmt.Errorf("smtp: rcpt to: %w", err)
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp: data: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("smtp: write message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp: send message: %w", err)
+	}
+	return client.Quit()
+}
+
+func (s SMTPEmailSender) tlsConfig() *tls.Config {
+	cfg := &tls.Config{}
+	if s.TLSConfig != nil {
+		cfg = s.TLSConfig.Clone()
+	}
+	if cfg.ServerName == "" {
+		cfg.ServerName = s.Host
+	}
+	return cfg
+}
+
+// buildMIMEMessage renders a multipart/alternative message carrying the body
+// as HTML and a plain text fallback for clients that do not render HTML.
+func buildMIMEMessage(from, to *mail.Address, subject, htmlBody string, now time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	domain := "localhost"
+	if at := strings.LastIndex(from.Address, "@"); at >= 0 {
+		domain = from.Address[at+1:]
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	header := []struct{ key, value string }{
+		{"From", from.String()},
+		{"To", to.String()},
+		{"Subject", mime.QEncoding.Encode("utf-8", subject)},
+		{"Date", now.Format(time.RFC1123Z)},
+		{"Message-ID", "<" + hex.EncodeToString(id) + "@" + domain + ">"},
+		{"MIME-Version", "1.0"},
+		{"Content-Type", "multipart/alternative; boundary=" + mw.Boundary()},
+	}
+	var head bytes.Buffer
+	for _, h := range header {
+		head.WriteString(h.key + ": " + h.value + "\r\n")
+	}
+	head.WriteString("\r\n")
+
+	parts := []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", htmlToText(htmlBody)},
+		{"text/html; charset=utf-8", htmlBody},
+	}
+	for _, part := range parts {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qw.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return append(head.Bytes(), buf.Bytes()...), nil
+}
+
+// htmlToText derives the plain text part from an HTML body by dropping tags,
+// turning block ends into line breaks, and decoding entities. Bodies without
+// markup come back unchanged.
+func htmlToText(body string) string {
+	if !strings.Contains(body, "<") {
+		return body
+	}
+	var b strings.Builder
+	for len(body) > 0 {
+		start := strings.IndexByte(body, '<')
+		if start < 0 {
+			b.WriteString(body)
+			break
+		}
+		b.WriteString(body[:start])
+		end := strings.IndexByte(body[start:], '>')
+		if end < 0 {
+			b.WriteString(body[start:])
+			break
+		}
+		tag := strings.ToLower(strings.Trim(body[start+1:start+end], "/ "))
+		if name, _, _ := strings.Cut(tag, " "); name == "br" || name == "p" || name == "div" || name == "li" || name == "tr" {
+			b.WriteByte('\n')
+		}
+		body = body[start+end+1:]
+	}
+	lines := strings.Split(html.UnescapeString(b.String()), "\n")
+	out := lines[:0]
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" && (len(out) == 0 || out[len(out)-1] == "") {
+			continue
+		}
+		out = append(out, line)
+	}
+	return strings.TrimSpace(strings.Join(out, "\n"))
+}
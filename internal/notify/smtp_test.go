@@ -0,0 +1,120 @@
+package notify_test
+
+import (
+	"bufio"
+	"context"
+	"mime"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/notify"
+)
+
+// fakeSMTPServer accepts one plaintext SMTP session and reports the envelope
+// and message it received.
+func fakeSMTPServer(t *testing.T) (string, int, <-chan []string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	got := make(chan []string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		tp := textproto.NewConn(conn)
+		var envelope []string
+		_ = tp.PrintfLine("220 localhost ESMTP")
+		for {
+			line, err := tp.ReadLine()
+			if err != nil {
+				return
+			}
+			verb := strings.ToUpper(strings.Fields(line)[0])
+			switch verb {
+			case "EHLO":
+				_ = tp.PrintfLine("250-localhost")
+				_ = tp.PrintfLine("250 8BITMIME")
+			case "MAIL", "RCPT":
+				envelope = append(envelope, line)
+				_ = tp.PrintfLine("250 OK")
+			case "DATA":
+				_ = tp.PrintfLine("354 go ahead")
+				data, err := tp.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				envelope = append(envelope, string(data))
+				_ = tp.PrintfLine("250 queued")
+			case "QUIT":
+				_ = tp.PrintfLine("221 bye")
+				got <- envelope
+				return
+			default:
+				_ = tp.PrintfLine("502 unsupported")
+			}
+		}
+	}()
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, got
+}
+
+func TestSMTPEmailSenderSendsMultipartMessage(t *testing.T) {
+	host, port, got := fakeSMTPServer(t)
+	sender := notify.SMTPEmailSender{
+		Host:    host,
+		Port:    port,
+		From:    "Toko <no-reply@toko.local>",
+		TLS:     notify.SMTPNoTLS,
+		Timeout: 2 * time.Second,
+	}
+	require.NoError(t, sender.Send("buyer@example.com", "Pesanan diterima ✓", "<p>Halo &amp; terima kasih</p><p>ID: 42</p>"))
+
+	envelope := <-got
+	require.Len(t, envelope, 3)
+	require.Equal(t, "MAIL FROM:<no-reply@toko.local> BODY=8BITMIME", envelope[0])
+	require.Equal(t, "RCPT TO:<buyer@example.com>", envelope[1])
+
+	msg, err := mail.ReadMessage(bufio.NewReader(strings.NewReader(envelope[2])))
+	require.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	require.NoError(t, err)
+	require.Equal(t, "Pesanan diterima ✓", subject)
+	require.Contains(t, msg.Header.Get("Content-Type"), "multipart/alternative")
+	require.Contains(t, envelope[2], "Content-Type: text/plain; charset=utf-8")
+	require.Contains(t, envelope[2], "Halo & terima kasih\n\nID: 42")
+	require.Contains(t, envelope[2], "Content-Type: text/html; charset=utf-8")
+}
+
+func TestSMTPEmailSenderRequiresStartTLS(t *testing.T) {
+	host, port, _ := fakeSMTPServer(t)
+	sender := notify.SMTPEmailSender{Host: host, Port: port, From: "no-reply@toko.local", Timeout: time.Second}
+	err := sender.SendContext(context.Background(), "buyer@example.com", "Hi", "body")
+	require.ErrorContains(t, err, "STARTTLS")
+}
+
+func TestSMTPEmailSenderRejectsHeaderInjection(t *testing.T) {
+	sender := notify.SMTPEmailSender{Host: "127.0.0.1", Port: 1, From: "no-reply@toko.local", TLS: notify.SMTPNoTLS}
+	err := sender.Send("buyer@example.com\r\nBcc: victim@example.com", "Hi", "body")
+	require.ErrorContains(t, err, "invalid recipient")
+}
+
+func TestNewEmailSenderRejectsUnknownProvider(t *testing.T) {
+	_, err := notify.NewEmailSender(notify.EmailSettings{Provider: "pigeon"})
+	require.Error(t, err)
+
+	for _, provider := range []string{"", "nop", "log", "http", "smtp", "sendgrid"} {
+		sender, err := notify.NewEmailSender(notify.EmailSettings{Provider: provider, From: "no-reply@toko.local"})
+		require.NoError(t, err, provider)
+		require.NotNil(t, sender, provider)
+	}
+}
@@ -14,7 +14,12 @@ type Store interface {
 	UpdateWebhookEndpoint(ctx context.Context, arg dbgen.UpdateWebhookEndpointParams) (dbgen.WebhookEndpoint, error)
 	GetWebhookEndpoint(ctx context.Context, id pgtype.UUID) (dbgen.WebhookEndpoint, error)
 	ListWebhookEndpoints(ctx context.Context, arg dbgen.ListWebhookEndpointsParams) ([]dbgen.WebhookEndpoint, error)
+	CountWebhookEndpoints(ctx context.Context) (int64, error)
 	DeleteWebhookEndpoint(ctx context.Context, id pgtype.UUID) error
+	RecordEndpointFailure(ctx context.Context, id pgtype.UUID) (int32, error)
+	ResetEndpointFailures(ctx context.Context, id pgtype.UUID) error
+	DisableWebhookEndpoint(ctx context.Context, arg dbgen.DisableWebhookEndpointParams) (dbgen.WebhookEndpoint, error)
+	EnableWebhookEndpoint(ctx context.Context, id pgtype.UUID) (dbgen.WebhookEndpoint, error)
 
 	ListActiveEndpointsForTopic(ctx context.Context, topic string) ([]dbgen.WebhookEndpoint, error)
 	EnqueueDelivery(ctx context.Context, arg dbgen.EnqueueDeliveryParams) (dbgen.WebhookDelivery, error)
@@ -22,6 +27,8 @@ type Store interface {
 	MarkDelivering(ctx context.Context, id pgtype.UUID) error
 	MarkDelivered(ctx context.Context, arg dbgen.MarkDeliveredParams) error
 	MarkFailedWithBackoff(ctx context.Context, arg dbgen.MarkFailedWithBackoffParams) error
+	GetPendingPredecessors(ctx context.Context, arg dbgen.GetPendingPredecessorsParams) (dbgen.GetPendingPredecessorsRow, error)
+	DeferDelivery(ctx context.Context, arg dbgen.DeferDeliveryParams) error
 	MoveToDLQ(ctx context.Context, arg dbgen.MoveToDLQParams) error
 	InsertWebhookDlq(ctx context.Context, arg dbgen.InsertWebhookDlqParams) (dbgen.WebhookDlq, error)
 	GetDeliveryByID(ctx context.Context, id pgtype.UUID) (dbgen.WebhookDelivery, error)
@@ -29,6 +36,9 @@ type Store interface {
 	DeleteDlqByDelivery(ctx context.Context, deliveryID pgtype.UUID) error
 	ListWebhookDeliveries(ctx context.Context, arg dbgen.ListWebhookDeliveriesParams) ([]dbgen.ListWebhookDeliveriesRow, error)
 	CountWebhookDeliveries(ctx context.Context, arg dbgen.CountWebhookDeliveriesParams) (int64, error)
+	InsertDeliveryAttempt(ctx context.Context, arg dbgen.InsertDeliveryAttemptParams) error
+	PruneDeliveryAttempts(ctx context.Context, arg dbgen.PruneDeliveryAttemptsParams) error
+	ListDeliveryAttempts(ctx context.Context, deliveryID pgtype.UUID) ([]dbgen.WebhookDeliveryAttempt, error)
 
 	GetDomainEvent(ctx context.Context, id pgtype.UUID) (dbgen.DomainEvent, error)
 }
@@ -64,10 +74,30 @@ func (s QueriesStore) ListWebhookEndpoints(ctx context.Context, arg dbgen.ListWe
 	return s.Queries.ListWebhookEndpoints(ctx, arg)
 }
 
+func (s QueriesStore) CountWebhookEndpoints(ctx context.Context) (int64, error) {
+	return s.Queries.CountWebhookEndpoints(ctx)
+}
+
 func (s QueriesStore) DeleteWebhookEndpoint(ctx context.Context, id pgtype.UUID) error {
 	return s.Queries.DeleteWebhookEndpoint(ctx, id)
 }
 
+func (s QueriesStore) RecordEndpointFailure(ctx context.Context, id pgtype.UUID) (int32, error) {
+	return s.Queries.RecordEndpointFailure(ctx, id)
+}
+
+func (s QueriesStore) ResetEndpointFailures(ctx context.Context, id pgtype.UUID) error {
+	return s.Queries.ResetEndpointFailures(ctx, id)
+}
+
+func (s QueriesStore) DisableWebhookEndpoint(ctx context.Context, arg dbgen.DisableWebhookEndpointParams) (dbgen.WebhookEndpoint, error) {
+	return s.Queries.DisableWebhookEndpoint(ctx, arg)
+}
+
+func (s QueriesStore) EnableWebhookEndpoint(ctx context.Context, id pgtype.UUID) (dbgen.WebhookEndpoint, error) {
+	return s.Queries.EnableWebhookEndpoint(ctx, id)
+}
+
 func (s QueriesStore) ListActiveEndpointsForTopic(ctx context.Context, topic string) ([]dbgen.WebhookEndpoint, error) {
 	return s.Queries.ListActiveEndpointsForTopic(ctx, topic)
 }
@@ -92,6 +122,14 @@ func (s QueriesStore) MarkFailedWithBackoff(ctx context.Context, arg dbgen.MarkF
 	return s.Queries.MarkFailedWithBackoff(ctx, arg)
 }
 
+func (s QueriesStore) GetPendingPredecessors(ctx context.Context, arg dbgen.GetPendingPredecessorsParams) (dbgen.GetPendingPredecessorsRow, error) {
+	return s.Queries.GetPendingPredecessors(ctx, arg)
+}
+
+func (s QueriesStore) DeferDelivery(ctx context.Context, arg dbgen.DeferDeliveryParams) error {
+	return s.Queries.DeferDelivery(ctx, arg)
+}
+
 func (s QueriesStore) MoveToDLQ(ctx context.Context, arg dbgen.MoveToDLQParams) error {
 	return s.Queries.MoveToDLQ(ctx, arg)
 }
@@ -120,6 +158,18 @@ func (s QueriesStore) CountWebhookDeliveries(ctx context.Context, arg dbgen.Coun
 	return s.Queries.CountWebhookDeliveries(ctx, arg)
 }
 
+func (s QueriesStore) InsertDeliveryAttempt(ctx context.Context, arg dbgen.InsertDeliveryAttemptParams) error {
+	return s.Queries.InsertDeliveryAttempt(ctx, arg)
+}
+
+func (s QueriesStore) PruneDeliveryAttempts(ctx context.Context, arg dbgen.PruneDeliveryAttemptsParams) error {
+	return s.Queries.PruneDeliveryAttempts(ctx, arg)
+}
+
+func (s QueriesStore) ListDeliveryAttempts(ctx context.Context, deliveryID pgtype.UUID) ([]dbgen.WebhookDeliveryAttempt, error) {
+	return s.Queries.ListDeliveryAttempts(ctx, deliveryID)
+}
+
 func (s QueriesStore) GetDomainEvent(ctx context.Context, id pgtype.UUID) (dbgen.DomainEvent, error) {
 	row, err := s.Queries.GetDomainEvent(ctx, id)
 	if err != nil {
@@ -0,0 +1,49 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+// Delivery modes an endpoint can request. DeliveryAsync hands every delivery
+// to the worker queue. DeliverySync attempts delivery inline when the event is
+// scheduled and only queues it when that attempt fails.
+const (
+	DeliveryAsync = "async"
+	DeliverySync  = "sync"
+)
+
+// DeliveryModes lists the accepted endpoint delivery modes.
+var DeliveryModes = []string{DeliveryAsync, DeliverySync}
+
+// NormalizeDeliveryMode validates an endpoint delivery mode, defaulting empty
+// to async.
+func NormalizeDeliveryMode(mode string) (string, error) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case "":
+		return DeliveryAsync, nil
+	case DeliveryAsync, DeliverySync:
+		return mode, nil
+	}
+	return "", fmt.Errorf("delivery_mode must be one of %s", strings.Join(DeliveryModes, ", "))
+}
+
+// deliverInline makes the first attempt of a sync delivery on the caller's
+// goroutine. The attempt is bounded by the resilience client's timeout and
+// retries, not by the caller's context, so a caller that goes away cannot
+// leave the delivery half recorded. A failed attempt is scheduled for retry
+// by processDelivery; if the attempt could not run at all, the delivery is
+// queued as if it were async.
+func (d *Dispatcher) deliverInline(ctx context.Context, del dbgen.WebhookDelivery) error {
+	ctx = context.WithoutCancel(ctx)
+	if err := d.processDelivery(ctx, del); err != nil {
+		if qErr := d.EnqueueDelivery(ctx, uuidFrom(del.ID), 0, int(del.MaxAttempt)); qErr != nil {
+			return fmt.Errorf("sync delivery %s: %w; queue fallback: %w", uuidFrom(del.ID), err, qErr)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package notify
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/noah-isme/backend-toko/internal/events"
+)
+
+// TopicPatterns lists what an endpoint may subscribe to: every subscribable
+// topic, the wildcard, and a "<prefix>.*" pattern for each topic prefix.
+func TopicPatterns() []string {
+	topics := events.SubscribableTopics()
+	patterns := append([]string{events.TopicWildcard}, topics...)
+	for _, topic := range topics {
+		for i := range len(topic) {
+			if topic[i] != '.' {
+				continue
+			}
+			if pattern := topic[:i] + ".*"; !slices.Contains(patterns, pattern) {
+				patterns = append(patterns, pattern)
+			}
+		}
+	}
+	return patterns
+}
+
+// NormalizeTopics lowercases, trims, and dedups endpoint topics, dropping
+// empty entries. It also returns the entries that are neither a known topic
+// nor a pattern covering one, so a typo such as "order.payed" is rejected
+// instead of never firing.
+func NormalizeTopics(topics []string) (normalized, unknown []string) {
+	known := events.SubscribableTopics()
+	normalized = make([]string, 0, len(topics))
+	for _, topic := range topics {
+		topic = strings.ToLower(strings.TrimSpace(topic))
+		if topic == "" || slices.Contains(normalized, topic) {
+			continue
+		}
+		if !slices.ContainsFunc(known, func(k string) bool { return events.MatchTopic(topic, k) }) {
+			unknown = append(unknown, topic)
+			continue
+		}
+		normalized = append(normalized, topic)
+	}
+	return normalized, unknown
+}
+
+// subscribed reports whether an endpoint subscribing to topics receives
+// topic. No topics means every topic.
+func subscribed(topics []string, topic string) bool {
+	return len(topics) == 0 || slices.ContainsFunc(topics, func(p string) bool { return events.MatchTopic(p, topic) })
+}
@@ -0,0 +1,96 @@
+package notify_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/events"
+)
+
+// TestListActiveEndpointsForTopicQuery runs the wildcard matching of
+// ListActiveEndpointsForTopic against a migrated database given by
+// TEST_DATABASE_URL, checking it agrees with events.MatchTopic and lists
+// every endpoint at most once. Writes are rolled back.
+func TestListActiveEndpointsForTopicQuery(t *testing.T) {
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+	ctx := context.Background()
+	pool, err := pgxpool.New(ctx, dsn)
+	require.NoError(t, err)
+	t.Cleanup(pool.Close)
+	tx, err := pool.Begin(ctx)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = tx.Rollback(ctx) })
+	q := dbgen.New(tx)
+
+	subscriptions := map[string][]string{
+		"all":         {},
+		"star":        {"*"},
+		"exact":       {events.TopicOrderPaid},
+		"prefix":      {"order.*"},
+		"overlapping": {events.TopicOrderPaid, "order.*", "*"},
+		"other":       {"shipment.*"},
+		"near-miss":   {"ord.*", "order"},
+	}
+	create := func(name string, topics []string, active bool) pgtype.UUID {
+		ep, err := q.CreateWebhookEndpoint(ctx, dbgen.CreateWebhookEndpointParams{
+			Name:              "topic-query-" + name,
+			Url:               "https://example.test/" + name,
+			Secret:            "secret",
+			Active:            active,
+			Topics:            topics,
+			Format:            "json",
+			OversizePolicy:    "truncate",
+			DeliveryMode:      "async",
+			RetryStatuses:     []int32{},
+			PermanentStatuses: []int32{},
+			CustomHeaderNames: []string{},
+		})
+		require.NoError(t, err)
+		return ep.ID
+	}
+	names := make(map[pgtype.UUID]string, len(subscriptions))
+	for name, topics := range subscriptions {
+		names[create(name, topics, true)] = name
+	}
+	inactive := create("inactive", []string{"*"}, false)
+
+	for _, topic := range []string{events.TopicOrderPaid, events.TopicShipmentDelivered, "order"} {
+		endpoints, err := q.ListActiveEndpointsForTopic(ctx, topic)
+		require.NoError(t, err)
+		var got []string
+		for _, ep := range endpoints {
+			require.NotEqual(t, inactive, ep.ID, "inactive endpoints are skipped")
+			if name, ok := names[ep.ID]; ok {
+				got = append(got, name)
+			}
+		}
+		var want []string
+		for name, topics := range subscriptions {
+			if matchesAny(topics, topic) {
+				want = append(want, name)
+			}
+		}
+		require.ElementsMatch(t, want, got, "topic %s", topic)
+	}
+}
+
+func matchesAny(patterns []string, topic string) bool {
+	if len(patterns) == 0 {
+		return true
+	}
+	for _, pattern := range patterns {
+		if events.MatchTopic(pattern, topic) {
+			return true
+		}
+	}
+	return false
+}
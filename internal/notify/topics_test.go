@@ -0,0 +1,34 @@
+package notify_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/events"
+	"github.com/noah-isme/backend-toko/internal/notify"
+)
+
+func TestNormalizeTopicsRejectsUnknown(t *testing.T) {
+	topics, unknown := notify.NormalizeTopics([]string{" Order.Paid ", "order.paid", "", "shipment.*", "order.payed", "ordr.*", "*"})
+	require.Equal(t, []string{"order.paid", "shipment.*", "*"}, topics)
+	require.Equal(t, []string{"order.payed", "ordr.*"}, unknown)
+
+	topics, unknown = notify.NormalizeTopics(nil)
+	require.Empty(t, unknown)
+	require.NotNil(t, topics)
+
+	patterns := notify.TopicPatterns()
+	require.Contains(t, patterns, "order.*")
+	require.Contains(t, patterns, "webhook.endpoint.*")
+	require.Contains(t, patterns, events.TopicShipmentOutForDelivery)
+}
+
+func TestMatchTopic(t *testing.T) {
+	require.True(t, events.MatchTopic("order.*", events.TopicOrderPaid))
+	require.True(t, events.MatchTopic("*", events.TopicShipmentDelivered))
+	require.True(t, events.MatchTopic("webhook.*", events.TopicWebhookEndpointDisabled))
+	require.False(t, events.MatchTopic("order.*", events.TopicPaymentFailed))
+	require.False(t, events.MatchTopic("order*", events.TopicOrderPaid))
+	require.False(t, events.MatchTopic("order.paid", events.TopicOrderCreated))
+}
@@ -5,7 +5,6 @@ import (
 	"context"
 	"crypto/hmac"
 	"crypto/sha256"
-	"crypto/tls"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -15,11 +14,13 @@ import (
 	"net/url"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
 
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
@@ -41,20 +42,60 @@ type Dispatcher struct {
 	Enabled            bool
 	Replay             ReplayProtector
 	ReplayTTL          time.Duration
+	// AttemptBodyLimit caps the response bytes kept per recorded attempt and
+	// AttemptHistoryLimit the attempts kept per delivery. Zero uses the
+	// defaults; a negative history limit disables attempt recording.
+	AttemptBodyLimit    int
+	AttemptHistoryLimit int
+	// MaxPayloadBytes caps request bodies for endpoints without their own
+	// limit; zero sends every payload in full. Oversized events are truncated
+	// to a link under PublicBaseURL, valid for PayloadURLTTL, or split,
+	// depending on the endpoint's policy.
+	MaxPayloadBytes int
+	PublicBaseURL   string
+	PayloadURLTTL   time.Duration
+	// AutoDisableAfter deactivates an endpoint after this many consecutive
+	// failed attempts; zero only counts them. Events receives a
+	// webhook.endpoint.disabled event for each endpoint disabled this way.
+	AutoDisableAfter int
+	Events           EventEmitter
+	// WorkConcurrency bounds how many endpoints WorkOnce delivers to at the
+	// same time; zero or one delivers the batch sequentially.
+	WorkConcurrency int
+	// RetryClientErrors retries 4xx responses like any other failure instead
+	// of moving them to the DLQ; see ClassifyFailure.
+	RetryClientErrors bool
+	// Headers seals and opens endpoint custom headers.
+	Headers HeaderCipher
 }
 
-// Schedule enqueues deliveries for active endpoints subscribed to the topic.
-func (d *Dispatcher) Schedule(ctx context.Context, event dbgen.DomainEvent) error {
+// Defaults for delivery attempt history.
+const (
+	defaultAttemptBodyLimit    = 2048
+	defaultAttemptHistoryLimit = 20
+)
+
+// orderedDeferDelay is the shortest wait before an ordered delivery held back
+// by an earlier one is looked at again.
+const orderedDeferDelay = 2 * time.Second
+
+// Schedule enqueues deliveries for active endpoints subscribed to the topic,
+// exactly or through a wildcard, and returns how many it enqueued. An endpoint
+// whose subscriptions overlap, such as "order.paid" and "order.*", still gets
+// one delivery per event. Deliveries to sync endpoints are attempted before
+// Schedule returns.
+func (d *Dispatcher) Schedule(ctx context.Context, event dbgen.DomainEvent) (int, error) {
 	if d == nil || !d.Enabled || d.Store == nil {
-		return nil
+		return 0, nil
 	}
 	if strings.TrimSpace(event.Topic) == "" {
-		return nil
+		return 0, nil
 	}
 	endpoints, err := d.Store.ListActiveEndpointsForTopic(ctx, event.Topic)
 	if err != nil {
-		return err
+		return 0, err
 	}
+	scheduled := 0
 	var joined error
 	for _, ep := range endpoints {
 		maxAttempt := d.DefaultMaxAttempts
@@ -62,9 +103,10 @@ func (d *Dispatcher) Schedule(ctx context.Context, event dbgen.DomainEvent) erro
 			maxAttempt = 6
 		}
 		delivery, err := d.Store.EnqueueDelivery(ctx, dbgen.EnqueueDeliveryParams{
-			EndpointID: ep.ID,
-			EventID:    event.ID,
-			MaxAttempt: int32(maxAttempt),
+			EndpointID:  ep.ID,
+			EventID:     event.ID,
+			MaxAttempt:  int32(maxAttempt),
+			AggregateID: event.AggregateID,
 		})
 		if err != nil {
 			var pgErr *pgconn.PgError
@@ -74,14 +116,24 @@ func (d *Dispatcher) Schedule(ctx context.Context, event dbgen.DomainEvent) erro
 			joined = errors.Join(joined, fmt.Errorf("enqueue delivery for %s: %w", uuidFrom(ep.ID), err))
 			continue
 		}
+		scheduled++
+		if ep.DeliveryMode == DeliverySync {
+			if err := d.deliverInline(ctx, delivery); err != nil {
+				joined = errors.Join(joined, err)
+			}
+			continue
+		}
 		if err := d.EnqueueDelivery(ctx, uuidFrom(delivery.ID), 0, int(delivery.MaxAttempt)); err != nil {
 			joined = errors.Join(joined, fmt.Errorf("queue delivery %s: %w", uuidFrom(delivery.ID), err))
 		}
 	}
-	return joined
+	return scheduled, joined
 }
 
-// WorkOnce dequeues eligible deliveries and attempts delivery.
+// WorkOnce dequeues eligible deliveries and attempts delivery. Deliveries
+// are grouped by endpoint: up to WorkConcurrency endpoints are served in
+// parallel, while each endpoint's deliveries are attempted one at a time in
+// dequeue order, so ordered endpoints keep their sequence.
 func (d *Dispatcher) WorkOnce(ctx context.Context, batch int32) error {
 	if d == nil || !d.Enabled || d.Store == nil {
 		return nil
@@ -98,11 +150,38 @@ func (d *Dispatcher) WorkOnce(ctx context.Context, batch int32) error {
 		span.RecordError(err)
 		return err
 	}
+	var order []pgtype.UUID
+	groups := make(map[pgtype.UUID][]dbgen.WebhookDelivery)
 	for _, del := range deliveries {
-		if err := d.processDelivery(ctx, del); err != nil {
-			span.RecordError(err)
+		if _, ok := groups[del.EndpointID]; !ok {
+			order = append(order, del.EndpointID)
 		}
+		groups[del.EndpointID] = append(groups[del.EndpointID], del)
 	}
+	// Build the shared client before workers race to create it lazily.
+	d.httpClient()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(d.WorkConcurrency, 1))
+	for _, endpointID := range order {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(group []dbgen.WebhookDelivery) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			for _, del := range group {
+				if err := d.processDelivery(ctx, del); err != nil {
+					mu.Lock()
+					span.RecordError(err)
+					mu.Unlock()
+				}
+			}
+		}(groups[endpointID])
+	}
+	wg.Wait()
 	return nil
 }
 
@@ -135,6 +214,19 @@ func (d *Dispatcher) processDelivery(ctx context.Context, del dbgen.WebhookDeliv
 	if d == nil || d.Store == nil {
 		return errors.New("dispatcher not configured")
 	}
+	endpoint, err := d.Store.GetWebhookEndpoint(ctx, del.EndpointID)
+	if err != nil {
+		return d.failDelivery(ctx, del, fmt.Errorf("load endpoint: %w", err))
+	}
+	if endpoint.Ordered {
+		wait, err := d.predecessorWait(ctx, del)
+		if err != nil {
+			return err
+		}
+		if wait > 0 {
+			return d.deferDelivery(ctx, del, wait)
+		}
+	}
 	if obs.WebhookDispatchAttempts != nil {
 		obs.WebhookDispatchAttempts.Inc()
 	}
@@ -142,15 +234,13 @@ func (d *Dispatcher) processDelivery(ctx context.Context, del dbgen.WebhookDeliv
 	if err := d.Store.MarkDelivering(ctx, del.ID); err != nil {
 		return err
 	}
-	endpoint, err := d.Store.GetWebhookEndpoint(ctx, del.EndpointID)
-	if err != nil {
-		return d.failDelivery(ctx, del, fmt.Errorf("load endpoint: %w", err))
-	}
 	event, err := d.Store.GetDomainEvent(ctx, del.EventID)
 	if err != nil {
 		return d.failDelivery(ctx, del, fmt.Errorf("load event: %w", err))
 	}
+	sendStart := time.Now()
 	status, respBody, deliverErr := d.deliver(ctx, endpoint, event, del)
+	d.recordAttempt(ctx, del, status, respBody, deliverErr, time.Since(sendStart))
 	if deliverErr == nil && status >= 200 && status < 300 {
 		if obs.WebhookDeliveriesTotal != nil {
 			obs.WebhookDeliveriesTotal.WithLabelValues("delivered").Inc()
@@ -164,17 +254,24 @@ func (d *Dispatcher) processDelivery(ctx context.Context, del dbgen.WebhookDeliv
 		}
 		bodyVal := pgtype.Text{}
 		if respBody != "" {
-			bodyVal = pgtype.Text{String: respBody, Valid: true}
+			bodyVal = pgtype.Text{String: capText(respBody, d.bodyLimit()), Valid: true}
 		}
+		d.endpointSucceeded(ctx, endpoint)
 		return d.Store.MarkDelivered(ctx, dbgen.MarkDeliveredParams{
 			ResponseStatus: statusVal,
 			ResponseBody:   bodyVal,
 			ID:             del.ID,
 		})
 	}
+	permanent := ClassifyFailure(endpoint, status, d.RetryClientErrors) == FailurePermanent
 	reason := fmt.Sprintf("status=%d err=%v", status, deliverErr)
+	if permanent {
+		reason = "permanent failure: " + reason
+	}
+	reason = capText(reason, d.bodyLimit())
 	reasonText := pgtype.Text{String: reason, Valid: true}
-	if int(del.Attempt+1) >= int(del.MaxAttempt) {
+	d.endpointFailed(ctx, endpoint, reason)
+	if permanent || int(del.Attempt+1) >= int(del.MaxAttempt) {
 		if obs.WebhookDeliveriesTotal != nil {
 			obs.WebhookDeliveriesTotal.WithLabelValues("dlq").Inc()
 		}
@@ -201,6 +298,93 @@ func (d *Dispatcher) processDelivery(ctx context.Context, del dbgen.WebhookDeliv
 	return d.EnqueueDelivery(ctx, uuidFrom(del.ID), time.Duration(delay)*time.Second, int(del.MaxAttempt))
 }
 
+// recordAttempt stores what an attempt returned for the delivery's history and
+// prunes the oldest attempts beyond the limit. History is best effort and
+// never affects the delivery outcome.
+func (d *Dispatcher) recordAttempt(ctx context.Context, del dbgen.WebhookDelivery, status int, body string, deliverErr error, elapsed time.Duration) {
+	keep := d.AttemptHistoryLimit
+	if keep == 0 {
+		keep = defaultAttemptHistoryLimit
+	}
+	if keep < 0 {
+		return
+	}
+	limit := d.bodyLimit()
+	arg := dbgen.InsertDeliveryAttemptParams{
+		DeliveryID: del.ID,
+		Attempt:    del.Attempt + 1,
+		DurationMs: int32(elapsed.Milliseconds()),
+	}
+	if status > 0 {
+		arg.ResponseStatus = pgtype.Int4{Int32: int32(status), Valid: true}
+	}
+	if body != "" {
+		arg.ResponseBody = pgtype.Text{String: capText(body, limit), Valid: true}
+	}
+	if deliverErr != nil {
+		arg.Error = pgtype.Text{String: capText(deliverErr.Error(), limit), Valid: true}
+	}
+	if err := d.Store.InsertDeliveryAttempt(ctx, arg); err != nil {
+		if obs.WebhookAttemptRecordFailures != nil {
+			obs.WebhookAttemptRecordFailures.Inc()
+		}
+		zerolog.Ctx(ctx).Warn().Err(err).Str("delivery_id", uuidFrom(del.ID)).Int32("attempt", arg.Attempt).Msg("record webhook delivery attempt failed")
+		return
+	}
+	if err := d.Store.PruneDeliveryAttempts(ctx, dbgen.PruneDeliveryAttemptsParams{DeliveryID: del.ID, Keep: int32(keep)}); err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Str("delivery_id", uuidFrom(del.ID)).Msg("prune webhook delivery attempts failed")
+	}
+}
+
+// predecessorWait reports how long an ordered delivery must wait for earlier
+// deliveries of its aggregate, or zero when none are outstanding. Deliveries
+// created before sequencing existed have no sequence and are never held.
+func (d *Dispatcher) predecessorWait(ctx context.Context, del dbgen.WebhookDelivery) (time.Duration, error) {
+	if !del.AggregateID.Valid || !del.Sequence.Valid {
+		return 0, nil
+	}
+	pending, err := d.Store.GetPendingPredecessors(ctx, dbgen.GetPendingPredecessorsParams{
+		EndpointID:  del.EndpointID,
+		AggregateID: del.AggregateID,
+		Sequence:    del.Sequence,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("check predecessors: %w", err)
+	}
+	if pending.Pending == 0 {
+		return 0, nil
+	}
+	wait := orderedDeferDelay
+	if pending.NextAttemptAt.Valid {
+		// Waking before the predecessor's next attempt would only defer again.
+		if until := time.Until(pending.NextAttemptAt.Time) + orderedDeferDelay; until > wait {
+			wait = until
+		}
+	}
+	return wait, nil
+}
+
+// deferDelivery pushes the delivery back without spending an attempt.
+func (d *Dispatcher) deferDelivery(ctx context.Context, del dbgen.WebhookDelivery, wait time.Duration) error {
+	if obs.WebhookDeliveriesTotal != nil {
+		obs.WebhookDeliveriesTotal.WithLabelValues("deferred").Inc()
+	}
+	delaySec := int32((wait + time.Second - 1) / time.Second)
+	if err := d.Store.DeferDelivery(ctx, dbgen.DeferDeliveryParams{DelaySec: delaySec, ID: del.ID}); err != nil {
+		return err
+	}
+	return d.EnqueueDelivery(ctx, uuidFrom(del.ID), time.Duration(delaySec)*time.Second, int(del.MaxAttempt))
+}
+
+// bodyLimit is the most bytes of a response body or error kept in the
+// database for a delivery.
+func (d *Dispatcher) bodyLimit() int {
+	if d.AttemptBodyLimit > 0 {
+		return d.AttemptBodyLimit
+	}
+	return defaultAttemptBodyLimit
+}
+
 func (d *Dispatcher) nextDelay(attempt int32) int {
 	base := d.BackoffBaseSec
 	if base <= 0 {
@@ -214,7 +398,7 @@ func (d *Dispatcher) nextDelay(attempt int32) int {
 }
 
 func (d *Dispatcher) failDelivery(ctx context.Context, del dbgen.WebhookDelivery, err error) error {
-	reason := err.Error()
+	reason := capText(err.Error(), d.bodyLimit())
 	reasonText := pgtype.Text{String: reason, Valid: true}
 	if int(del.Attempt+1) >= int(del.MaxAttempt) {
 		if obs.WebhookDeliveriesTotal != nil {
@@ -240,7 +424,6 @@ func (d *Dispatcher) failDelivery(ctx context.Context, del dbgen.WebhookDelivery
 }
 
 func (d *Dispatcher) deliver(ctx context.Context, ep dbgen.WebhookEndpoint, ev dbgen.DomainEvent, del dbgen.WebhookDelivery) (int, string, error) {
-	httpClient := d.httpClient()
 	ctx, span := otel.Tracer("notify.Dispatcher").Start(ctx, "Dispatcher.deliver")
 	defer span.End()
 	span.SetAttributes(
@@ -248,33 +431,14 @@ func (d *Dispatcher) deliver(ctx context.Context, ep dbgen.WebhookEndpoint, ev d
 		attribute.String("webhook.delivery_id", uuidFrom(del.ID)),
 		attribute.String("webhook.topic", ev.Topic),
 	)
-	if err := validateURL(ep.Url); err != nil {
-		span.RecordError(err)
-		return 0, "", err
-	}
-	var occurred time.Time
-	if ev.OccurredAt.Valid {
-		occurred = ev.OccurredAt.Time
-	} else {
-		occurred = time.Now()
-	}
-	payload := struct {
-		EventID    string          `json:"eventId"`
-		Topic      string          `json:"topic"`
-		Data       json.RawMessage `json:"data"`
-		OccurredAt time.Time       `json:"occurredAt"`
-	}{
-		EventID:    uuidFrom(ev.ID),
-		Topic:      ev.Topic,
-		Data:       json.RawMessage(ev.Payload),
-		OccurredAt: occurred,
+	reqs, err := d.deliveryRequests(ctx, ep, ev, del)
+	if err == nil {
+		err = d.Headers.setCustomHeaders(ep.CustomHeaders, reqs...)
 	}
-	body, err := json.Marshal(payload)
 	if err != nil {
 		span.RecordError(err)
 		return 0, "", err
 	}
-	ts := time.Now().Unix()
 	if d.Replay != nil && d.ReplayTTL > 0 {
 		key := replayKey(ep.ID, ev.ID)
 		ok, err := d.Replay.Acquire(ctx, key, d.ReplayTTL)
@@ -287,41 +451,128 @@ func (d *Dispatcher) deliver(ctx context.Context, ep dbgen.WebhookEndpoint, ev d
 			return http.StatusOK, "replay-suppressed", nil
 		}
 	}
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.Url, bytes.NewReader(body))
+	span.SetAttributes(attribute.Int("webhook.parts", len(reqs)))
+	// Parts go out in order and a failed part fails the whole delivery; the
+	// retry resends every part under the same idempotency keys.
+	var (
+		status int
+		body   string
+	)
+	for _, req := range reqs {
+		status, body, err = d.send(ctx, req)
+		if err != nil || status < 200 || status >= 300 {
+			break
+		}
+	}
 	if err != nil {
 		span.RecordError(err)
-		return 0, "", err
 	}
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("User-Agent", "toko-api-webhooks/1.0")
-	eventID := uuidFrom(ev.ID)
-	deliveryID := uuidFrom(del.ID)
-	req.Header.Set("X-Event-ID", eventID)
-	req.Header.Set("X-Timestamp", fmt.Sprintf("%d", ts))
-	req.Header.Set("X-Idempotency-Key", deliveryID)
-	req.Header.Set("X-Signature", ComputeSignature(ep.Secret, ts, eventID, body))
-	resp, err := httpClient.Do(ctx, req)
+	span.SetAttributes(attribute.Int("http.status_code", status))
+	return status, body, err
+}
+
+// send performs one request and reads at most the stored body limit of the
+// response.
+func (d *Dispatcher) send(ctx context.Context, req *http.Request) (int, string, error) {
+	resp, err := d.httpClient().Do(ctx, req)
 	if err != nil {
-		span.RecordError(err)
 		return 0, "", err
 	}
 	defer func() {
 		_ = resp.Body.Close()
 	}()
-	responseBody, err := io.ReadAll(resp.Body)
+	responseBody, err := io.ReadAll(io.LimitReader(resp.Body, int64(d.bodyLimit())))
 	if err != nil {
-		span.RecordError(err)
 		return resp.StatusCode, "", err
 	}
-	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
 	return resp.StatusCode, string(responseBody), nil
 }
 
+// signedRequest builds the POST for delivering ev to ep, with the event
+// envelope encoded in the endpoint's format and the signature headers set.
+func signedRequest(ctx context.Context, ep dbgen.WebhookEndpoint, ev dbgen.DomainEvent, del dbgen.WebhookDelivery) (*http.Request, error) {
+	if err := validateURL(ep.Url); err != nil {
+		return nil, err
+	}
+	body, contentType, err := encodeEnvelope(ep.Format, newEnvelope(ev, del))
+	if err != nil {
+		return nil, err
+	}
+	return newSignedRequest(ctx, ep, ev, del, body, contentType, uuidFrom(del.ID))
+}
+
+// webhookEnvelope is the body sent for an event. Data is left out when the
+// payload was truncated or split into chunks to fit the endpoint's limit.
+type webhookEnvelope struct {
+	EventID     string          `json:"eventId"`
+	Topic       string          `json:"topic"`
+	Data        json.RawMessage `json:"data,omitempty"`
+	OccurredAt  time.Time       `json:"occurredAt"`
+	AggregateID string          `json:"aggregateId,omitempty"`
+	Sequence    int64           `json:"sequence,omitempty"`
+
+	Truncated   bool   `json:"truncated,omitempty"`
+	Size        int    `json:"size,omitempty"`
+	ResourceURL string `json:"resourceUrl,omitempty"`
+	Part        int    `json:"part,omitempty"`
+	Parts       int    `json:"parts,omitempty"`
+	Chunk       string `json:"chunk,omitempty"`
+}
+
+func newEnvelope(ev dbgen.DomainEvent, del dbgen.WebhookDelivery) webhookEnvelope {
+	occurred := time.Now()
+	if ev.OccurredAt.Valid {
+		occurred = ev.OccurredAt.Time
+	}
+	env := webhookEnvelope{
+		EventID:    uuidFrom(ev.ID),
+		Topic:      ev.Topic,
+		Data:       json.RawMessage(ev.Payload),
+		OccurredAt: occurred,
+	}
+	if del.Sequence.Valid {
+		// The sequence counts deliveries of one aggregate to this endpoint, so a
+		// jump tells the consumer it missed one.
+		env.AggregateID = uuidFrom(ev.AggregateID)
+		env.Sequence = del.Sequence.Int64
+	}
+	return env
+}
+
+func encodeEnvelope(format string, env webhookEnvelope) ([]byte, string, error) {
+	raw, err := json.Marshal(env)
+	if err != nil {
+		return nil, "", err
+	}
+	return encodePayload(format, raw)
+}
+
+// newSignedRequest wraps an encoded body in a POST carrying the event and
+// signature headers.
+func newSignedRequest(ctx context.Context, ep dbgen.WebhookEndpoint, ev dbgen.DomainEvent, del dbgen.WebhookDelivery, body []byte, contentType, idempotencyKey string) (*http.Request, error) {
+	ts := time.Now().Unix()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, ep.Url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("User-Agent", "toko-api-webhooks/1.0")
+	eventID := uuidFrom(ev.ID)
+	req.Header.Set("X-Event-ID", eventID)
+	req.Header.Set("X-Timestamp", fmt.Sprintf("%d", ts))
+	req.Header.Set("X-Idempotency-Key", idempotencyKey)
+	if del.Sequence.Valid {
+		req.Header.Set("X-Event-Sequence", strconv.FormatInt(del.Sequence.Int64, 10))
+	}
+	req.Header.Set("X-Signature", ComputeSignature(ep.Secret, ts, eventID, body))
+	return req, nil
+}
+
 func (d *Dispatcher) httpClient() *resilience.HTTPClient {
 	if d.HTTP != nil {
 		return d.HTTP
 	}
-	base := HttpClient(5000, false)
+	base := HttpClient(5000, nil)
 	d.HTTP = &resilience.HTTPClient{
 		Client:      base,
 		Breaker:     resilience.NewBreaker(5, 0.5, 30*time.Second),
@@ -371,14 +622,14 @@ func ComputeSignature(secret string, ts int64, eventID string, body []byte) stri
 	return hex.EncodeToString(mac.Sum(nil))
 }
 
-// HttpClient returns an HTTP client configured for webhook delivery.
-func HttpClient(timeoutMs int, insecure bool) *http.Client {
+// HttpClient returns an HTTP client configured for webhook delivery. A nil
+// transport uses resilience.NewTransport defaults, which honour HTTPS_PROXY.
+func HttpClient(timeoutMs int, transport *http.Transport) *http.Client {
 	if timeoutMs <= 0 {
 		timeoutMs = 5000
 	}
-	transport := &http.Transport{}
-	if insecure {
-		transport.TLSClientConfig = insecureTLSConfig
+	if transport == nil {
+		transport, _ = resilience.NewTransport(resilience.TransportConfig{})
 	}
 	return &http.Client{
 		Timeout:   time.Duration(timeoutMs) * time.Millisecond,
@@ -386,8 +637,6 @@ func HttpClient(timeoutMs int, insecure bool) *http.Client {
 	}
 }
 
-var insecureTLSConfig = &tls.Config{InsecureSkipVerify: true} //nolint:gosec
-
 // ReplayProtector guards against sending duplicate deliveries within a TTL.
 type ReplayProtector interface {
 	Acquire(ctx context.Context, key string, ttl time.Duration) (bool, error)
@@ -2,21 +2,31 @@ package notify_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strconv"
+	"sync"
 	"testing"
 	"time"
 
+	miniredis "github.com/alicebob/miniredis/v2"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/require"
 
 	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/events"
 	"github.com/noah-isme/backend-toko/internal/notify"
+	"github.com/noah-isme/backend-toko/internal/obs"
+	"github.com/noah-isme/backend-toko/internal/queue"
 	"github.com/noah-isme/backend-toko/internal/resilience"
 )
 
@@ -86,6 +96,19 @@ type retryStore struct {
 	event    dbgen.DomainEvent
 	failed   []dbgen.MarkFailedWithBackoffParams
 	dlq      []dbgen.MoveToDLQParams
+	attempts []dbgen.InsertDeliveryAttemptParams
+	pruned   []dbgen.PruneDeliveryAttemptsParams
+	// attemptErr fails InsertDeliveryAttempt.
+	attemptErr error
+
+	aggregateID  pgtype.UUID
+	sequence     pgtype.Int8
+	predecessors dbgen.GetPendingPredecessorsRow
+	deferred     []dbgen.DeferDeliveryParams
+	delivered    int
+
+	failures int32
+	disabled []dbgen.DisableWebhookEndpointParams
 }
 
 func (r *retryStore) CreateWebhookEndpoint(context.Context, dbgen.CreateWebhookEndpointParams) (dbgen.WebhookEndpoint, error) {
@@ -104,14 +127,38 @@ func (r *retryStore) ListWebhookEndpoints(context.Context, dbgen.ListWebhookEndp
 	return nil, nil
 }
 
+func (r *retryStore) CountWebhookEndpoints(context.Context) (int64, error) { return 0, nil }
+
 func (r *retryStore) DeleteWebhookEndpoint(context.Context, pgtype.UUID) error { return nil }
 
+func (r *retryStore) RecordEndpointFailure(context.Context, pgtype.UUID) (int32, error) {
+	r.failures++
+	return r.failures, nil
+}
+
+func (r *retryStore) ResetEndpointFailures(context.Context, pgtype.UUID) error {
+	r.failures = 0
+	return nil
+}
+
+func (r *retryStore) DisableWebhookEndpoint(_ context.Context, arg dbgen.DisableWebhookEndpointParams) (dbgen.WebhookEndpoint, error) {
+	r.disabled = append(r.disabled, arg)
+	ep := r.endpoint
+	ep.Active, ep.DisabledReason = false, arg.Reason
+	return ep, nil
+}
+
+func (r *retryStore) EnableWebhookEndpoint(context.Context, pgtype.UUID) (dbgen.WebhookEndpoint, error) {
+	r.failures = 0
+	return r.endpoint, nil
+}
+
 func (r *retryStore) ListActiveEndpointsForTopic(context.Context, string) ([]dbgen.WebhookEndpoint, error) {
-	return nil, nil
+	return []dbgen.WebhookEndpoint{r.endpoint}, nil
 }
 
-func (r *retryStore) EnqueueDelivery(context.Context, dbgen.EnqueueDeliveryParams) (dbgen.WebhookDelivery, error) {
-	return dbgen.WebhookDelivery{}, nil
+func (r *retryStore) EnqueueDelivery(_ context.Context, arg dbgen.EnqueueDeliveryParams) (dbgen.WebhookDelivery, error) {
+	return dbgen.WebhookDelivery{ID: toUUID(uuid.New()), EndpointID: arg.EndpointID, EventID: arg.EventID, MaxAttempt: arg.MaxAttempt}, nil
 }
 
 func (r *retryStore) DequeueDueDeliveries(context.Context, int32) ([]dbgen.WebhookDelivery, error) {
@@ -119,18 +166,23 @@ func (r *retryStore) DequeueDueDeliveries(context.Context, int32) ([]dbgen.Webho
 		return nil, nil
 	}
 	delivery := dbgen.WebhookDelivery{
-		ID:         toUUID(uuid.New()),
-		EndpointID: r.endpoint.ID,
-		EventID:    r.event.ID,
-		Attempt:    int32(r.attempt),
-		MaxAttempt: 2,
+		ID:          toUUID(uuid.New()),
+		EndpointID:  r.endpoint.ID,
+		EventID:     r.event.ID,
+		Attempt:     int32(r.attempt),
+		MaxAttempt:  2,
+		AggregateID: r.aggregateID,
+		Sequence:    r.sequence,
 	}
 	return []dbgen.WebhookDelivery{delivery}, nil
 }
 
 func (r *retryStore) MarkDelivering(context.Context, pgtype.UUID) error { return nil }
 
-func (r *retryStore) MarkDelivered(context.Context, dbgen.MarkDeliveredParams) error { return nil }
+func (r *retryStore) MarkDelivered(context.Context, dbgen.MarkDeliveredParams) error {
+	r.delivered++
+	return nil
+}
 
 func (r *retryStore) MarkFailedWithBackoff(_ context.Context, arg dbgen.MarkFailedWithBackoffParams) error {
 	r.failed = append(r.failed, arg)
@@ -138,6 +190,15 @@ func (r *retryStore) MarkFailedWithBackoff(_ context.Context, arg dbgen.MarkFail
 	return nil
 }
 
+func (r *retryStore) GetPendingPredecessors(context.Context, dbgen.GetPendingPredecessorsParams) (dbgen.GetPendingPredecessorsRow, error) {
+	return r.predecessors, nil
+}
+
+func (r *retryStore) DeferDelivery(_ context.Context, arg dbgen.DeferDeliveryParams) error {
+	r.deferred = append(r.deferred, arg)
+	return nil
+}
+
 func (r *retryStore) MoveToDLQ(_ context.Context, arg dbgen.MoveToDLQParams) error {
 	r.dlq = append(r.dlq, arg)
 	r.attempt++
@@ -166,6 +227,23 @@ func (r *retryStore) CountWebhookDeliveries(context.Context, dbgen.CountWebhookD
 	return 0, nil
 }
 
+func (r *retryStore) InsertDeliveryAttempt(_ context.Context, arg dbgen.InsertDeliveryAttemptParams) error {
+	if r.attemptErr != nil {
+		return r.attemptErr
+	}
+	r.attempts = append(r.attempts, arg)
+	return nil
+}
+
+func (r *retryStore) PruneDeliveryAttempts(_ context.Context, arg dbgen.PruneDeliveryAttemptsParams) error {
+	r.pruned = append(r.pruned, arg)
+	return nil
+}
+
+func (r *retryStore) ListDeliveryAttempts(context.Context, pgtype.UUID) ([]dbgen.WebhookDeliveryAttempt, error) {
+	return nil, nil
+}
+
 func (r *retryStore) GetDomainEvent(context.Context, pgtype.UUID) (dbgen.DomainEvent, error) {
 	return r.event, nil
 }
@@ -203,6 +281,359 @@ func TestRetryAndDLQ(t *testing.T) {
 	require.Len(t, store.dlq, 1)
 }
 
+func TestClientErrorGoesStraightToDLQ(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}))
+	t.Cleanup(srv.Close)
+
+	newDispatcher := func(store *retryStore) *notify.Dispatcher {
+		return &notify.Dispatcher{
+			Store: store,
+			HTTP: &resilience.HTTPClient{
+				Client:      srv.Client(),
+				Breaker:     resilience.NewBreaker(100, 1, time.Second),
+				MaxAttempts: 1,
+				Timeout:     time.Second,
+			},
+			DefaultMaxAttempts: 5,
+			Enabled:            true,
+		}
+	}
+	event := dbgen.DomainEvent{ID: toUUID(uuid.New()), Topic: "order.paid", Payload: []byte(`{"id":1}`), OccurredAt: pgtype.Timestamptz{Time: time.Now(), Valid: true}}
+
+	store := &retryStore{endpoint: dbgen.WebhookEndpoint{ID: toUUID(uuid.New()), Url: srv.URL, Secret: "secret"}, event: event}
+	require.NoError(t, newDispatcher(store).WorkOnce(context.Background(), 1))
+	require.Empty(t, store.failed)
+	require.Len(t, store.dlq, 1)
+	require.Contains(t, store.dlq[0].LastError.String, "permanent failure: status=422")
+
+	store = &retryStore{endpoint: dbgen.WebhookEndpoint{ID: toUUID(uuid.New()), Url: srv.URL, Secret: "secret", RetryStatuses: []int32{422}}, event: event}
+	require.NoError(t, newDispatcher(store).WorkOnce(context.Background(), 1))
+	require.Len(t, store.failed, 1, "the endpoint marks 422 retryable")
+	require.Empty(t, store.dlq)
+}
+
+func TestClassifyFailure(t *testing.T) {
+	plain := dbgen.WebhookEndpoint{}
+	custom := dbgen.WebhookEndpoint{RetryStatuses: []int32{409}, PermanentStatuses: []int32{501}}
+	cases := []struct {
+		endpoint    dbgen.WebhookEndpoint
+		status      int
+		retryClient bool
+		want        string
+	}{
+		{plain, 0, false, notify.FailureRetryable},
+		{plain, 400, false, notify.FailurePermanent},
+		{plain, 404, false, notify.FailurePermanent},
+		{plain, 408, false, notify.FailureRetryable},
+		{plain, 429, false, notify.FailureRetryable},
+		{plain, 503, false, notify.FailureRetryable},
+		{plain, 400, true, notify.FailureRetryable},
+		{custom, 409, false, notify.FailureRetryable},
+		{custom, 501, false, notify.FailurePermanent},
+		{custom, 501, true, notify.FailurePermanent},
+	}
+	for _, tc := range cases {
+		require.Equal(t, tc.want, notify.ClassifyFailure(tc.endpoint, tc.status, tc.retryClient), "status %d", tc.status)
+	}
+
+	_, _, err := notify.NormalizeRetryStatuses([]int{409}, []int{409})
+	require.Error(t, err)
+	_, _, err = notify.NormalizeRetryStatuses([]int{200}, nil)
+	require.Error(t, err)
+	retry, permanent, err := notify.NormalizeRetryStatuses([]int{503, 409, 409}, nil)
+	require.NoError(t, err)
+	require.Equal(t, []int32{409, 503}, retry)
+	require.Equal(t, []int32{}, permanent)
+}
+
+func TestDeliverySendsCustomHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Clone()
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	cipher, err := notify.NewHeaderCipher("", "jwt-secret")
+	require.NoError(t, err)
+	headers, names, err := notify.NormalizeCustomHeaders(map[string]string{"authorization": "Bearer partner", "x-api-key": " k1 "})
+	require.NoError(t, err)
+	require.Equal(t, []string{"Authorization", "X-Api-Key"}, names)
+	sealed, err := cipher.Seal(headers)
+	require.NoError(t, err)
+	require.NotContains(t, string(sealed), "partner")
+
+	store := &retryStore{
+		endpoint: dbgen.WebhookEndpoint{ID: toUUID(uuid.New()), Url: srv.URL, Secret: "secret", CustomHeaderNames: names, CustomHeaders: sealed},
+		event:    dbgen.DomainEvent{ID: toUUID(uuid.New()), Topic: "order.paid", Payload: []byte(`{"id":1}`)},
+	}
+	dispatcher := &notify.Dispatcher{
+		Store:   store,
+		HTTP:    &resilience.HTTPClient{Client: srv.Client(), MaxAttempts: 1, Timeout: time.Second},
+		Enabled: true,
+		Headers: cipher,
+	}
+	require.NoError(t, dispatcher.WorkOnce(context.Background(), 1))
+	require.Equal(t, 1, store.delivered)
+	require.Equal(t, "Bearer partner", got.Get("Authorization"))
+	require.Equal(t, "k1", got.Get("X-Api-Key"))
+	require.NotEmpty(t, got.Get("X-Signature"))
+
+	for _, bad := range []map[string]string{
+		{"X-Signature": "forged"},
+		{"Transfer-Encoding": "chunked"},
+		{"Bad Name": "v"},
+		{"X-Api-Key": "line\r\nbreak"},
+	} {
+		_, _, err := notify.NormalizeCustomHeaders(bad)
+		require.Error(t, err, "%v", bad)
+	}
+}
+
+type recordedEmit struct {
+	topic   string
+	payload any
+}
+
+type emitRecorder struct{ emitted []recordedEmit }
+
+func (e *emitRecorder) Emit(_ context.Context, topic string, _ pgtype.UUID, payload any) (dbgen.DomainEvent, error) {
+	e.emitted = append(e.emitted, recordedEmit{topic: topic, payload: payload})
+	return dbgen.DomainEvent{}, nil
+}
+
+func TestEndpointDisabledAfterConsecutiveFailures(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(srv.Close)
+
+	store := &retryStore{
+		endpoint: dbgen.WebhookEndpoint{ID: toUUID(uuid.New()), Name: "erp", Url: srv.URL, Secret: "secret", Active: true},
+		event:    dbgen.DomainEvent{ID: toUUID(uuid.New()), Topic: "order.paid", Payload: []byte(`{"id":1}`), OccurredAt: pgtype.Timestamptz{Time: time.Now(), Valid: true}},
+	}
+	emitter := &emitRecorder{}
+	dispatcher := &notify.Dispatcher{
+		Store: store,
+		HTTP: &resilience.HTTPClient{
+			Client:      srv.Client(),
+			Breaker:     resilience.NewBreaker(100, 1, time.Second),
+			MaxAttempts: 1,
+			Timeout:     time.Second,
+		},
+		DefaultMaxAttempts: 2,
+		Enabled:            true,
+		AutoDisableAfter:   2,
+		Events:             emitter,
+	}
+
+	require.NoError(t, dispatcher.WorkOnce(context.Background(), 1))
+	require.Equal(t, int32(1), store.failures)
+	require.Empty(t, store.disabled, "one failure is below the threshold")
+
+	require.NoError(t, dispatcher.WorkOnce(context.Background(), 1))
+	require.Len(t, store.disabled, 1)
+	require.Contains(t, store.disabled[0].Reason.String, "disabled after 2 consecutive failed deliveries")
+	require.Contains(t, store.disabled[0].Reason.String, "502 Bad Gateway")
+	require.Len(t, emitter.emitted, 1)
+	require.Equal(t, events.TopicWebhookEndpointDisabled, emitter.emitted[0].topic)
+	require.Equal(t, "erp", emitter.emitted[0].payload.(map[string]any)["name"])
+}
+
+func TestEndpointSuccessResetsFailureCount(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	store := &retryStore{
+		endpoint: dbgen.WebhookEndpoint{ID: toUUID(uuid.New()), Url: srv.URL, Secret: "secret", Active: true, ConsecutiveFailures: 3},
+		event:    dbgen.DomainEvent{ID: toUUID(uuid.New()), Topic: "order.paid", Payload: []byte(`{"id":1}`), OccurredAt: pgtype.Timestamptz{Time: time.Now(), Valid: true}},
+		failures: 3,
+	}
+	dispatcher := &notify.Dispatcher{
+		Store:            store,
+		HTTP:             &resilience.HTTPClient{Client: srv.Client(), MaxAttempts: 1, Timeout: time.Second},
+		Enabled:          true,
+		AutoDisableAfter: 5,
+	}
+
+	require.NoError(t, dispatcher.WorkOnce(context.Background(), 1))
+	require.Equal(t, 1, store.delivered)
+	require.Zero(t, store.failures)
+}
+
+func TestSyncEndpointDeliversInlineAndQueuesOnFailure(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	mr := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = rdb.Close() })
+
+	store := &retryStore{
+		endpoint: dbgen.WebhookEndpoint{ID: toUUID(uuid.New()), Url: srv.URL, Secret: "secret", DeliveryMode: notify.DeliverySync},
+		event:    dbgen.DomainEvent{ID: toUUID(uuid.New()), Topic: "order.paid", Payload: []byte(`{"id":1}`)},
+	}
+	dispatcher := &notify.Dispatcher{
+		Store:              store,
+		HTTP:               &resilience.HTTPClient{Client: srv.Client(), MaxAttempts: 1, Timeout: time.Second},
+		Queue:              queue.Enqueuer{R: rdb},
+		BackoffBaseSec:     3,
+		DefaultMaxAttempts: 3,
+		Enabled:            true,
+	}
+	queued := func() int64 {
+		n, err := rdb.ZCard(context.Background(), "queue:"+notify.WebhookDeliveryTask()).Result()
+		require.NoError(t, err)
+		return n
+	}
+
+	// A canceled caller does not abort the inline attempt.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	scheduled, err := dispatcher.Schedule(ctx, store.event)
+	require.NoError(t, err)
+	require.Equal(t, 1, scheduled)
+	require.Equal(t, 1, store.delivered)
+	require.Len(t, store.attempts, 1)
+	require.Zero(t, queued(), "a delivered sync attempt leaves nothing for the worker")
+
+	status = http.StatusServiceUnavailable
+	_, err = dispatcher.Schedule(context.Background(), store.event)
+	require.NoError(t, err)
+	require.Equal(t, 1, store.delivered)
+	require.Len(t, store.failed, 1)
+	require.Equal(t, int32(3), store.failed[0].DelaySec)
+	require.Equal(t, int64(1), queued(), "a failed sync attempt falls back to the queue")
+
+	store.endpoint.DeliveryMode = notify.DeliveryAsync
+	status = http.StatusOK
+	_, err = dispatcher.Schedule(context.Background(), store.event)
+	require.NoError(t, err)
+	require.Len(t, store.attempts, 2, "async endpoints are not attempted inline")
+	require.Equal(t, int64(2), queued())
+
+	mode, err := notify.NormalizeDeliveryMode("")
+	require.NoError(t, err)
+	require.Equal(t, notify.DeliveryAsync, mode)
+	_, err = notify.NormalizeDeliveryMode("inline")
+	require.Error(t, err)
+}
+
+func TestDeliveryAttemptsAreRecorded(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		_, _ = w.Write([]byte(`{"error":"unknown order"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	store := &retryStore{
+		endpoint: dbgen.WebhookEndpoint{ID: toUUID(uuid.New()), Url: srv.URL, Secret: "secret"},
+		event:    dbgen.DomainEvent{ID: toUUID(uuid.New()), Topic: "order.paid", Payload: []byte(`{"id":1}`), OccurredAt: pgtype.Timestamptz{Time: time.Now(), Valid: true}},
+	}
+	dispatcher := &notify.Dispatcher{
+		Store: store,
+		HTTP: &resilience.HTTPClient{
+			Client:      srv.Client(),
+			MaxAttempts: 1,
+			Timeout:     time.Second,
+			Target:      "webhook-delivery",
+		},
+		BackoffBaseSec:      3,
+		Enabled:             true,
+		AttemptBodyLimit:    9,
+		AttemptHistoryLimit: 5,
+	}
+
+	require.NoError(t, dispatcher.WorkOnce(context.Background(), 1))
+	require.NoError(t, dispatcher.WorkOnce(context.Background(), 1))
+	require.Len(t, store.attempts, 2)
+	for i, attempt := range store.attempts {
+		require.Equal(t, int32(i+1), attempt.Attempt)
+		require.Equal(t, pgtype.Int4{Int32: http.StatusUnprocessableEntity, Valid: true}, attempt.ResponseStatus)
+		require.Equal(t, `{"error":`, attempt.ResponseBody.String, "bodies are truncated to the limit")
+		require.False(t, attempt.Error.Valid)
+	}
+	require.Len(t, store.pruned, 2)
+	require.Equal(t, int32(5), store.pruned[0].Keep)
+}
+
+func TestFailedAttemptRecordIsCountedAndKeepsOutcome(t *testing.T) {
+	obs.MustRegisterDomainMetrics("test", prometheus.NewRegistry())
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	store := &retryStore{
+		endpoint:   dbgen.WebhookEndpoint{ID: toUUID(uuid.New()), Url: srv.URL, Secret: "secret"},
+		event:      dbgen.DomainEvent{ID: toUUID(uuid.New()), Topic: "order.paid", Payload: []byte(`{"id":1}`), OccurredAt: pgtype.Timestamptz{Time: time.Now(), Valid: true}},
+		attemptErr: errors.New("attempts table unavailable"),
+	}
+	dispatcher := &notify.Dispatcher{
+		Store:   store,
+		HTTP:    &resilience.HTTPClient{Client: srv.Client(), MaxAttempts: 1, Timeout: time.Second},
+		Enabled: true,
+	}
+	before := testutil.ToFloat64(obs.WebhookAttemptRecordFailures)
+
+	require.NoError(t, dispatcher.WorkOnce(context.Background(), 1))
+	require.Equal(t, 1, store.delivered, "history failures do not affect the delivery")
+	require.Empty(t, store.pruned)
+	require.Equal(t, before+1, testutil.ToFloat64(obs.WebhookAttemptRecordFailures))
+}
+
+func TestOrderedEndpointWaitsForPredecessor(t *testing.T) {
+	received := make(chan *http.Request, 1)
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		received <- r
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	aggregateID := toUUID(uuid.New())
+	store := &retryStore{
+		endpoint:     dbgen.WebhookEndpoint{ID: toUUID(uuid.New()), Url: srv.URL, Secret: "secret", Ordered: true},
+		event:        dbgen.DomainEvent{ID: toUUID(uuid.New()), Topic: "order.shipped", AggregateID: aggregateID, Payload: []byte(`{"id":1}`), OccurredAt: pgtype.Timestamptz{Time: time.Now(), Valid: true}},
+		aggregateID:  aggregateID,
+		sequence:     pgtype.Int8{Int64: 2, Valid: true},
+		predecessors: dbgen.GetPendingPredecessorsRow{Pending: 1, NextAttemptAt: pgtype.Timestamptz{Time: time.Now().Add(10 * time.Second), Valid: true}},
+	}
+	dispatcher := &notify.Dispatcher{
+		Store:   store,
+		HTTP:    &resilience.HTTPClient{Client: srv.Client(), MaxAttempts: 1, Timeout: time.Second},
+		Enabled: true,
+	}
+
+	// The earlier delivery is still retrying, so this one waits past its next
+	// attempt without using one of its own.
+	require.NoError(t, dispatcher.WorkOnce(context.Background(), 1))
+	require.Len(t, store.deferred, 1)
+	require.GreaterOrEqual(t, store.deferred[0].DelaySec, int32(10))
+	require.Empty(t, store.attempts)
+	require.Empty(t, store.failed)
+	require.Empty(t, received)
+
+	store.predecessors = dbgen.GetPendingPredecessorsRow{}
+	require.NoError(t, dispatcher.WorkOnce(context.Background(), 1))
+	req := <-received
+	require.Equal(t, "2", req.Header.Get("X-Event-Sequence"))
+	var envelope struct {
+		AggregateID string `json:"aggregateId"`
+		Sequence    int64  `json:"sequence"`
+	}
+	require.NoError(t, json.Unmarshal(body, &envelope))
+	require.Equal(t, uuidString(aggregateID), envelope.AggregateID)
+	require.Equal(t, int64(2), envelope.Sequence)
+}
+
 type scheduleStore struct {
 	endpoints []dbgen.WebhookEndpoint
 	enqueued  int
@@ -224,7 +655,19 @@ func (s *scheduleStore) ListWebhookEndpoints(context.Context, dbgen.ListWebhookE
 	return nil, nil
 }
 
+func (s *scheduleStore) CountWebhookEndpoints(context.Context) (int64, error) { return 0, nil }
+
 func (s *scheduleStore) DeleteWebhookEndpoint(context.Context, pgtype.UUID) error { return nil }
+func (s *scheduleStore) RecordEndpointFailure(context.Context, pgtype.UUID) (int32, error) {
+	return 0, nil
+}
+func (s *scheduleStore) ResetEndpointFailures(context.Context, pgtype.UUID) error { return nil }
+func (s *scheduleStore) DisableWebhookEndpoint(context.Context, dbgen.DisableWebhookEndpointParams) (dbgen.WebhookEndpoint, error) {
+	return dbgen.WebhookEndpoint{}, nil
+}
+func (s *scheduleStore) EnableWebhookEndpoint(context.Context, pgtype.UUID) (dbgen.WebhookEndpoint, error) {
+	return dbgen.WebhookEndpoint{}, nil
+}
 
 func (s *scheduleStore) ListActiveEndpointsForTopic(context.Context, string) ([]dbgen.WebhookEndpoint, error) {
 	return s.endpoints, nil
@@ -246,7 +689,11 @@ func (s *scheduleStore) MarkDelivered(context.Context, dbgen.MarkDeliveredParams
 func (s *scheduleStore) MarkFailedWithBackoff(context.Context, dbgen.MarkFailedWithBackoffParams) error {
 	return nil
 }
-func (s *scheduleStore) MoveToDLQ(context.Context, dbgen.MoveToDLQParams) error { return nil }
+func (s *scheduleStore) GetPendingPredecessors(context.Context, dbgen.GetPendingPredecessorsParams) (dbgen.GetPendingPredecessorsRow, error) {
+	return dbgen.GetPendingPredecessorsRow{}, nil
+}
+func (s *scheduleStore) DeferDelivery(context.Context, dbgen.DeferDeliveryParams) error { return nil }
+func (s *scheduleStore) MoveToDLQ(context.Context, dbgen.MoveToDLQParams) error         { return nil }
 func (s *scheduleStore) InsertWebhookDlq(context.Context, dbgen.InsertWebhookDlqParams) (dbgen.WebhookDlq, error) {
 	return dbgen.WebhookDlq{}, nil
 }
@@ -263,6 +710,15 @@ func (s *scheduleStore) ListWebhookDeliveries(context.Context, dbgen.ListWebhook
 func (s *scheduleStore) CountWebhookDeliveries(context.Context, dbgen.CountWebhookDeliveriesParams) (int64, error) {
 	return 0, nil
 }
+func (s *scheduleStore) InsertDeliveryAttempt(context.Context, dbgen.InsertDeliveryAttemptParams) error {
+	return nil
+}
+func (s *scheduleStore) PruneDeliveryAttempts(context.Context, dbgen.PruneDeliveryAttemptsParams) error {
+	return nil
+}
+func (s *scheduleStore) ListDeliveryAttempts(context.Context, pgtype.UUID) ([]dbgen.WebhookDeliveryAttempt, error) {
+	return nil, nil
+}
 func (s *scheduleStore) GetDomainEvent(context.Context, pgtype.UUID) (dbgen.DomainEvent, error) {
 	return dbgen.DomainEvent{}, nil
 }
@@ -282,7 +738,188 @@ func TestIdempotencyUniqueDelivery(t *testing.T) {
 	}
 	event := dbgen.DomainEvent{ID: toUUID(uuid.New()), Topic: "order.created"}
 
-	err := dispatcher.Schedule(context.Background(), event)
+	scheduled, err := dispatcher.Schedule(context.Background(), event)
 	require.NoError(t, err)
+	require.Equal(t, 1, scheduled, "the duplicate delivery is not counted")
 	require.Equal(t, 2, store.enqueued)
 }
+
+func TestPingSendsSignedSampleOnce(t *testing.T) {
+	hits := 0
+	var signatureOK bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get("X-Timestamp"), 10, 64)
+		signatureOK = notify.ComputeSignature("secret", ts, r.Header.Get("X-Event-ID"), body) == r.Header.Get("X-Signature")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		_, _ = w.Write([]byte("maintenance"))
+	}))
+	t.Cleanup(srv.Close)
+
+	dispatcher := &notify.Dispatcher{
+		HTTP: &resilience.HTTPClient{
+			Client:      srv.Client(),
+			Breaker:     resilience.NewBreaker(1, 1, time.Second),
+			MaxAttempts: 3,
+			BaseBackoff: time.Millisecond,
+			Timeout:     time.Second,
+			Target:      "webhook-delivery",
+		},
+		Enabled: true,
+	}
+	endpoint := dbgen.WebhookEndpoint{ID: toUUID(uuid.New()), Url: srv.URL, Secret: "secret", Topics: []string{"shipment.shipped", "order.paid"}}
+
+	result, err := dispatcher.Ping(context.Background(), endpoint, "")
+	require.NoError(t, err)
+	require.Equal(t, 1, hits, "test deliveries are not retried")
+	require.True(t, signatureOK)
+	require.Equal(t, "shipment.shipped", result.Topic)
+	require.Equal(t, http.StatusServiceUnavailable, result.Status)
+	require.Equal(t, "maintenance", result.Body)
+	require.False(t, result.Success)
+
+	_, err = dispatcher.Ping(context.Background(), endpoint, "payment.failed")
+	require.ErrorIs(t, err, notify.ErrTopicNotSubscribed)
+
+	endpoint.Topics = []string{"payment.*"}
+	result, err = dispatcher.Ping(context.Background(), endpoint, "")
+	require.NoError(t, err)
+	require.Equal(t, "payment.failed", result.Topic)
+	result, err = dispatcher.Ping(context.Background(), endpoint, "payment.expired")
+	require.NoError(t, err)
+	require.Equal(t, "payment.expired", result.Topic)
+}
+
+func TestDeliverEncodesEndpointFormat(t *testing.T) {
+	type captured struct {
+		contentType string
+		body        []byte
+		signatureOK bool
+	}
+	received := make(chan captured, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get("X-Timestamp"), 10, 64)
+		received <- captured{
+			contentType: r.Header.Get("Content-Type"),
+			body:        body,
+			signatureOK: notify.ComputeSignature("secret", ts, r.Header.Get("X-Event-ID"), body) == r.Header.Get("X-Signature"),
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	dispatcher := &notify.Dispatcher{HTTP: &resilience.HTTPClient{Client: srv.Client(), MaxAttempts: 1, Timeout: time.Second}}
+	event := dbgen.DomainEvent{
+		ID:         toUUID(uuid.New()),
+		Topic:      "order.paid",
+		Payload:    []byte(`{"orderId":"A-1","total":150000,"items":[{"sku":"K-M","qty":2}]}`),
+		OccurredAt: pgtype.Timestamptz{Time: time.Now(), Valid: true},
+	}
+	deliver := func(format string) captured {
+		endpoint := dbgen.WebhookEndpoint{ID: toUUID(uuid.New()), Url: srv.URL, Secret: "secret", Format: format}
+		_, _, err := dispatcher.Deliver(context.Background(), endpoint, event, dbgen.WebhookDelivery{ID: toUUID(uuid.New())})
+		require.NoError(t, err)
+		got := <-received
+		require.True(t, got.signatureOK, "signature covers the bytes sent for %q", format)
+		return got
+	}
+
+	got := deliver("")
+	require.Equal(t, "application/json", got.contentType)
+	var nested map[string]any
+	require.NoError(t, json.Unmarshal(got.body, &nested))
+	require.Equal(t, "A-1", nested["data"].(map[string]any)["orderId"], "the default keeps the nested envelope")
+
+	got = deliver(notify.FormatJSONFlat)
+	require.Equal(t, "application/json", got.contentType)
+	var flat map[string]any
+	require.NoError(t, json.Unmarshal(got.body, &flat))
+	require.Equal(t, "A-1", flat["data.orderId"])
+	require.Equal(t, "K-M", flat["data.items.0.sku"])
+	require.Equal(t, "order.paid", flat["topic"])
+
+	got = deliver(notify.FormatForm)
+	require.Equal(t, "application/x-www-form-urlencoded", got.contentType)
+	form, err := url.ParseQuery(string(got.body))
+	require.NoError(t, err)
+	require.Equal(t, "150000", form.Get("data.total"))
+	require.Equal(t, "2", form.Get("data.items.0.qty"))
+	require.Equal(t, uuidString(event.ID), form.Get("eventId"))
+
+	_, err = notify.NormalizeFormat("xml")
+	require.Error(t, err)
+}
+
+type batchStore struct {
+	*scheduleStore
+	endpoints  map[pgtype.UUID]dbgen.WebhookEndpoint
+	deliveries []dbgen.WebhookDelivery
+}
+
+func (s *batchStore) DequeueDueDeliveries(context.Context, int32) ([]dbgen.WebhookDelivery, error) {
+	return s.deliveries, nil
+}
+
+func (s *batchStore) GetWebhookEndpoint(_ context.Context, id pgtype.UUID) (dbgen.WebhookEndpoint, error) {
+	return s.endpoints[id], nil
+}
+
+func (s *batchStore) GetDomainEvent(_ context.Context, id pgtype.UUID) (dbgen.DomainEvent, error) {
+	return dbgen.DomainEvent{ID: id, Topic: "order.paid", Payload: []byte(`{}`)}, nil
+}
+
+func TestWorkOnceDeliversEndpointsInParallelAndInOrder(t *testing.T) {
+	var mu sync.Mutex
+	inFlight, maxInFlight := map[string]int{}, 0
+	received := map[string][]string{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight[r.URL.Path]++
+		require.Equal(t, 1, inFlight[r.URL.Path], "one delivery per endpoint at a time")
+		total := 0
+		for _, n := range inFlight {
+			total += n
+		}
+		maxInFlight = max(maxInFlight, total)
+		received[r.URL.Path] = append(received[r.URL.Path], r.Header.Get("X-Event-Sequence"))
+		mu.Unlock()
+		time.Sleep(50 * time.Millisecond)
+		mu.Lock()
+		inFlight[r.URL.Path]--
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	store := &batchStore{scheduleStore: &scheduleStore{}, endpoints: map[pgtype.UUID]dbgen.WebhookEndpoint{}}
+	for i := range 3 {
+		ep := dbgen.WebhookEndpoint{ID: toUUID(uuid.New()), Url: srv.URL + "/" + strconv.Itoa(i), Secret: "secret", Ordered: true}
+		store.endpoints[ep.ID] = ep
+		for seq := range 3 {
+			store.deliveries = append(store.deliveries, dbgen.WebhookDelivery{
+				ID: toUUID(uuid.New()), EndpointID: ep.ID, EventID: toUUID(uuid.New()), MaxAttempt: 3,
+				Sequence: pgtype.Int8{Int64: int64(seq + 1), Valid: true},
+			})
+		}
+	}
+	dispatcher := &notify.Dispatcher{
+		Store: store,
+		HTTP: &resilience.HTTPClient{
+			Client:      srv.Client(),
+			Breaker:     resilience.NewBreaker(100, 1, time.Second),
+			MaxAttempts: 1,
+			Timeout:     time.Second,
+			Target:      "webhook-delivery",
+		},
+		Enabled:         true,
+		WorkConcurrency: 2,
+	}
+
+	require.NoError(t, dispatcher.WorkOnce(context.Background(), 9))
+	require.Len(t, received, 3)
+	for path, seqs := range received {
+		require.Equal(t, []string{"1", "2", "3"}, seqs, path)
+	}
+	require.Equal(t, 2, maxInFlight, "endpoints are served in parallel up to the limit")
+}
@@ -19,6 +19,9 @@ type DeliveryWorker struct {
 	Dispatcher *Dispatcher
 	Locker     lock.Locker
 	LockTTL    time.Duration
+	// LockRenewEvery controls how often the delivery lock is extended while a
+	// delivery is running. Zero renews at a third of LockTTL.
+	LockRenewEvery time.Duration
 }
 
 // Handle executes the delivery identified by payload.
@@ -35,7 +38,7 @@ func (w DeliveryWorker) Handle(ctx context.Context, payload []byte) error {
 		ttl = 30 * time.Second
 	}
 	key := fmt.Sprintf("lock:delivery:%s", deliveryID)
-	return w.Locker.WithLock(ctx, key, ttl, func(ctx context.Context) error {
+	return w.Locker.WithRenewingLock(ctx, key, ttl, w.LockRenewEvery, func(ctx context.Context) error {
 		if w.Dispatcher.Store == nil {
 			return errors.New("webhook worker: dispatcher store unavailable")
 		}
@@ -51,6 +54,18 @@ func (w DeliveryWorker) Handle(ctx context.Context, payload []byte) error {
 		if delivery.Status == dbgen.DeliveryStatusDELIVERED || delivery.Status == dbgen.DeliveryStatusDLQ {
 			return nil
 		}
-		return w.Dispatcher.DeliverByID(ctx, deliveryID)
+		if !delivery.AggregateID.Valid {
+			return w.Dispatcher.DeliverByID(ctx, deliveryID)
+		}
+		endpoint, err := w.Dispatcher.Store.GetWebhookEndpoint(ctx, delivery.EndpointID)
+		if err != nil || !endpoint.Ordered {
+			return w.Dispatcher.DeliverByID(ctx, deliveryID)
+		}
+		// Ordered endpoints take one delivery per aggregate at a time, so two
+		// workers cannot both pass the predecessor check for the same aggregate.
+		aggregateKey := fmt.Sprintf("lock:delivery-aggregate:%s:%s", uuidFrom(delivery.EndpointID), uuidFrom(delivery.AggregateID))
+		return w.Locker.WithRenewingLock(ctx, aggregateKey, ttl, w.LockRenewEvery, func(ctx context.Context) error {
+			return w.Dispatcher.DeliverByID(ctx, deliveryID)
+		})
 	})
 }
@@ -1,6 +1,9 @@
 package obs
 
-import "context"
+import (
+	"context"
+	"sync"
+)
 
 // routePatternKey is the context key storing matched route pattern.
 type routePatternKey struct{}
@@ -23,3 +26,39 @@ func RoutePatternFromContext(ctx context.Context) string {
 	}
 	return ""
 }
+
+// accessFieldsKey is the context key for identifiers resolved after the
+// request logger runs.
+type accessFieldsKey struct{}
+
+type accessFields struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+func withAccessFields(ctx context.Context) (context.Context, *accessFields) {
+	fields := &accessFields{values: make(map[string]string, 2)}
+	return context.WithValue(ctx, accessFieldsKey{}, fields), fields
+}
+
+// SetAccessLogField records value under name on the request's access log
+// line. Middleware deeper in the chain uses it for identifiers such as the
+// user or tenant that the request logger cannot see on its own context.
+func SetAccessLogField(ctx context.Context, name, value string) {
+	if ctx == nil {
+		return
+	}
+	fields, ok := ctx.Value(accessFieldsKey{}).(*accessFields)
+	if !ok || value == "" {
+		return
+	}
+	fields.mu.Lock()
+	fields.values[name] = value
+	fields.mu.Unlock()
+}
+
+func (f *accessFields) get(name string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.values[name]
+}
@@ -24,6 +24,22 @@ var (
 	WebhookDispatchAttempts prometheus.Counter
 	// WebhookDispatchDLQ counts deliveries moved to dead-letter queue.
 	WebhookDispatchDLQ prometheus.Counter
+	// WebhookAttemptRecordFailures counts delivery attempts whose history
+	// row could not be stored.
+	WebhookAttemptRecordFailures prometheus.Counter
+	// MaintenanceActive is 1 for the maintenance mode currently enforced.
+	MaintenanceActive *prometheus.GaugeVec
+	// MaintenanceRejectedTotal counts requests rejected by maintenance mode.
+	MaintenanceRejectedTotal *prometheus.CounterVec
+	// DBSlowQueriesTotal counts queries slower than the configured threshold by query name.
+	DBSlowQueriesTotal *prometheus.CounterVec
+	// RetentionRowsPurgedTotal counts rows deleted by the retention purge by target.
+	RetentionRowsPurgedTotal *prometheus.CounterVec
+	// DomainEventsTotal counts emitted domain events by topic and outcome.
+	DomainEventsTotal *prometheus.CounterVec
+	// DomainEventDeliveriesTotal counts webhook deliveries scheduled for
+	// emitted domain events by topic.
+	DomainEventDeliveriesTotal *prometheus.CounterVec
 )
 
 // MustRegisterDomainMetrics initialises and registers domain-specific Prometheus collectors.
@@ -68,6 +84,41 @@ func MustRegisterDomainMetrics(namespace string, reg prometheus.Registerer) {
 			Name:      "webhook_dispatch_dlq_total",
 			Help:      "Number of webhook deliveries moved to the dead-letter queue.",
 		})
+		WebhookAttemptRecordFailures = prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "webhook_attempt_record_failures_total",
+			Help:      "Webhook delivery attempts whose history could not be recorded.",
+		})
+		MaintenanceActive = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "maintenance_active",
+			Help:      "Set to 1 for the maintenance mode currently enforced.",
+		}, []string{"mode"})
+		MaintenanceRejectedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "maintenance_rejected_total",
+			Help:      "Requests rejected while maintenance mode is active.",
+		}, []string{"mode"})
+		DBSlowQueriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "db_slow_queries_total",
+			Help:      "Queries that exceeded the slow query threshold.",
+		}, []string{"query"})
+		RetentionRowsPurgedTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "retention_rows_purged_total",
+			Help:      "Rows deleted by the data-retention purge.",
+		}, []string{"target"})
+		DomainEventsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "domain_events_total",
+			Help:      "Emitted domain events by topic and outcome.",
+		}, []string{"topic", "result"})
+		DomainEventDeliveriesTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "domain_event_deliveries_scheduled_total",
+			Help:      "Webhook deliveries scheduled for emitted domain events.",
+		}, []string{"topic"})
 
 		mustRegisterCollector(reg, PaymentIntentTotal, func(existing prometheus.Collector) {
 			if v, ok := existing.(*prometheus.CounterVec); ok {
@@ -104,6 +155,41 @@ func MustRegisterDomainMetrics(namespace string, reg prometheus.Registerer) {
 				WebhookDispatchDLQ = v
 			}
 		})
+		mustRegisterCollector(reg, WebhookAttemptRecordFailures, func(existing prometheus.Collector) {
+			if v, ok := existing.(prometheus.Counter); ok {
+				WebhookAttemptRecordFailures = v
+			}
+		})
+		mustRegisterCollector(reg, MaintenanceActive, func(existing prometheus.Collector) {
+			if v, ok := existing.(*prometheus.GaugeVec); ok {
+				MaintenanceActive = v
+			}
+		})
+		mustRegisterCollector(reg, MaintenanceRejectedTotal, func(existing prometheus.Collector) {
+			if v, ok := existing.(*prometheus.CounterVec); ok {
+				MaintenanceRejectedTotal = v
+			}
+		})
+		mustRegisterCollector(reg, DBSlowQueriesTotal, func(existing prometheus.Collector) {
+			if v, ok := existing.(*prometheus.CounterVec); ok {
+				DBSlowQueriesTotal = v
+			}
+		})
+		mustRegisterCollector(reg, RetentionRowsPurgedTotal, func(existing prometheus.Collector) {
+			if v, ok := existing.(*prometheus.CounterVec); ok {
+				RetentionRowsPurgedTotal = v
+			}
+		})
+		mustRegisterCollector(reg, DomainEventsTotal, func(existing prometheus.Collector) {
+			if v, ok := existing.(*prometheus.CounterVec); ok {
+				DomainEventsTotal = v
+			}
+		})
+		mustRegisterCollector(reg, DomainEventDeliveriesTotal, func(existing prometheus.Collector) {
+			if v, ok := existing.(*prometheus.CounterVec); ok {
+				DomainEventDeliveriesTotal = v
+			}
+		})
 	})
 }
 
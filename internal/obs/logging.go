@@ -2,12 +2,13 @@ package obs
 
 import (
 	"io"
+	"math/rand"
 	"net/http"
 	"os"
 	"strings"
 	"time"
 
-	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog"
 	"go.opentelemetry.io/otel/trace"
 
@@ -32,24 +33,76 @@ func NewLogger(format, level string) zerolog.Logger {
 	return logger
 }
 
+// Access log field names accepted by RequestLogger.Fields.
+const (
+	FieldMethod     = "method"
+	FieldRoute      = "route"
+	FieldPath       = "path"
+	FieldStatus     = "status"
+	FieldDuration   = "duration"
+	FieldBytes      = "bytes"
+	FieldUserID     = "user_id"
+	FieldRequestID  = "request_id"
+	FieldTrace      = "trace"
+	FieldTenant     = "tenant"
+	FieldHost       = "host"
+	FieldRemoteAddr = "remote_addr"
+	FieldUserAgent  = "user_agent"
+	// FieldQuery, FieldHeaders, and FieldBody are opt-in and always pass
+	// through RequestLogger.Redactor.
+	FieldQuery   = "query"
+	FieldHeaders = "headers"
+	FieldBody    = "body"
+)
+
+// DefaultAccessLogFields is logged when RequestLogger.Fields is empty.
+var DefaultAccessLogFields = []string{
+	FieldMethod, FieldRoute, FieldPath, FieldStatus, FieldDuration, FieldBytes,
+	FieldUserID, FieldRequestID, FieldTrace, FieldTenant, FieldHost,
+	FieldRemoteAddr, FieldUserAgent,
+}
+
+// OptionalAccessLogFields are only logged when named in RequestLogger.Fields.
+var OptionalAccessLogFields = []string{FieldQuery, FieldHeaders, FieldBody}
+
+// defaultBodyLogLimit caps the request bodies RequestLogger logs.
+const defaultBodyLogLimit = 4096
+
 // RequestLogger records structured HTTP request logs enriched with tracing metadata.
 type RequestLogger struct {
 	Logger zerolog.Logger
+	// Fields selects the access log fields; empty logs DefaultAccessLogFields.
+	Fields []string
+	// SampleRates maps a path prefix to the fraction of its requests that
+	// are logged; the longest matching prefix wins and unmatched paths are
+	// always logged. 5xx responses and slow requests bypass sampling.
+	SampleRates map[string]float64
+	// SlowThreshold marks requests at least this long as slow; zero
+	// disables the check.
+	SlowThreshold time.Duration
+	// Rand returns a number in [0, 1) for sampling; defaults to rand.Float64.
+	Rand func() float64
+	// Redactor masks sensitive query parameters, headers, and body fields.
+	Redactor common.Redactor
+	// BodyLimit skips logging bodies longer than this many bytes; zero means
+	// 4096.
+	BodyLimit int
 }
 
-// Middleware implements chi middleware for structured request logs.
+// Middleware implements chi middleware for structured request logs. A logger
+// carrying the request and trace identifiers is attached to the request context
+// so that handlers and downstream clients using zerolog.Ctx emit correlated logs.
 func (l RequestLogger) Middleware(next http.Handler) http.Handler {
+	names := l.Fields
+	if len(names) == 0 {
+		names = DefaultAccessLogFields
+	}
+	enabled := make(map[string]bool, len(names))
+	for _, name := range names {
+		enabled[name] = true
+	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		recorder := NewStatusRecorder(w)
-		start := time.Now()
-		next.ServeHTTP(recorder, r)
-
-		duration := time.Since(start)
-		route := RoutePatternFromContext(r.Context())
-		if route == "" {
-			route = r.URL.Path
-		}
-		reqID := middleware.GetReqID(r.Context())
+		reqID := common.RequestID(r.Context())
 		spanCtx := trace.SpanContextFromContext(r.Context())
 		traceID := ""
 		spanID := ""
@@ -57,30 +110,138 @@ func (l RequestLogger) Middleware(next http.Handler) http.Handler {
 			traceID = spanCtx.TraceID().String()
 			spanID = spanCtx.SpanID().String()
 		}
-		userID, _ := common.UserID(r.Context())
-
-		evt := l.Logger.Info().
-			Str("method", r.Method).
-			Str("route", route).
-			Str("path", r.URL.Path).
-			Int("status", recorder.Status()).
-			Int64("duration_ms", duration.Milliseconds()).
-			Int64("bytes", recorder.BytesWritten()).
-			Str("request_id", reqID).
-			Str("trace_id", traceID).
-			Str("span_id", spanID)
-		if user := strings.TrimSpace(userID); user != "" {
-			evt = evt.Str("user_id", user)
-		}
-		if host := strings.TrimSpace(r.Host); host != "" {
+		fields := l.Logger.With().Str("request_id", reqID)
+		if traceID != "" {
+			fields = fields.Str("trace_id", traceID)
+		}
+		reqLogger := fields.Logger()
+		ctx, access := withAccessFields(reqLogger.WithContext(r.Context()))
+		r = r.WithContext(ctx)
+
+		var body any
+		var hasBody bool
+		if enabled[FieldBody] {
+			limit := l.BodyLimit
+			if limit <= 0 {
+				limit = defaultBodyLogLimit
+			}
+			// Read before the handler consumes it.
+			body, hasBody = l.Redactor.Body(r, limit)
+		}
+
+		recorder := NewStatusRecorder(w)
+		start := time.Now()
+		next.ServeHTTP(recorder, r)
+
+		duration := time.Since(start)
+		status := recorder.Status()
+		slow := l.SlowThreshold > 0 && duration >= l.SlowThreshold
+		rate := l.sampleRate(r.URL.Path)
+		if status < http.StatusInternalServerError && !slow && !l.sampled(rate) {
+			return
+		}
+
+		evt := l.Logger.Info()
+		if enabled[FieldMethod] {
+			evt = evt.Str("method", r.Method)
+		}
+		if enabled[FieldRoute] {
+			evt = evt.Str("route", routePattern(r))
+		}
+		if enabled[FieldPath] {
+			evt = evt.Str("path", r.URL.Path)
+		}
+		if enabled[FieldStatus] {
+			evt = evt.Int("status", status)
+		}
+		if enabled[FieldDuration] {
+			evt = evt.Int64("duration_ms", duration.Milliseconds())
+		}
+		if enabled[FieldBytes] {
+			evt = evt.Int64("bytes", recorder.BytesWritten())
+		}
+		if enabled[FieldRequestID] {
+			evt = evt.Str("request_id", reqID)
+		}
+		if enabled[FieldTrace] {
+			evt = evt.Str("trace_id", traceID).Str("span_id", spanID)
+		}
+		if enabled[FieldUserID] {
+			userID, _ := common.UserID(r.Context())
+			if user := strings.TrimSpace(userID); user != "" {
+				evt = evt.Str("user_id", user)
+			} else if user := access.get(FieldUserID); user != "" {
+				evt = evt.Str("user_id", user)
+			}
+		}
+		if enabled[FieldTenant] {
+			if tenant := access.get(FieldTenant); tenant != "" {
+				evt = evt.Str("tenant", tenant)
+			}
+		}
+		if host := strings.TrimSpace(r.Host); host != "" && enabled[FieldHost] {
 			evt = evt.Str("host", host)
 		}
-		if ip := strings.TrimSpace(r.RemoteAddr); ip != "" {
+		if ip := strings.TrimSpace(r.RemoteAddr); ip != "" && enabled[FieldRemoteAddr] {
 			evt = evt.Str("remote_addr", ip)
 		}
-		if ua := strings.TrimSpace(r.UserAgent()); ua != "" {
+		if ua := strings.TrimSpace(r.UserAgent()); ua != "" && enabled[FieldUserAgent] {
 			evt = evt.Str("user_agent", ua)
 		}
+		if enabled[FieldQuery] && r.URL.RawQuery != "" {
+			evt = evt.Str("query", l.Redactor.Query(r.URL.RawQuery))
+		}
+		if enabled[FieldHeaders] {
+			evt = evt.Interface("headers", l.Redactor.HeaderValues(r.Header))
+		}
+		if hasBody {
+			evt = evt.Interface("body", body)
+		}
+		if slow {
+			evt = evt.Bool("slow", true)
+		}
+		if rate < 1 {
+			evt = evt.Float64("sample_rate", rate)
+		}
 		evt.Msg("http_request")
 	})
 }
+
+// sampleRate returns the fraction of requests to path that are logged.
+func (l RequestLogger) sampleRate(path string) float64 {
+	rate, longest := 1.0, -1
+	for prefix, r := range l.SampleRates {
+		if strings.HasPrefix(path, prefix) && len(prefix) > longest {
+			rate, longest = r, len(prefix)
+		}
+	}
+	return rate
+}
+
+func (l RequestLogger) sampled(rate float64) bool {
+	if rate >= 1 {
+		return true
+	}
+	if rate <= 0 {
+		return false
+	}
+	draw := rand.Float64
+	if l.Rand != nil {
+		draw = l.Rand
+	}
+	return draw() < rate
+}
+
+// routePattern prefers the pattern chi matched once routing has run.
+func routePattern(r *http.Request) string {
+	route := RoutePatternFromContext(r.Context())
+	if route == "" {
+		if rc := chi.RouteContext(r.Context()); rc != nil {
+			route = rc.RoutePattern()
+		}
+	}
+	if route == "" {
+		route = r.URL.Path
+	}
+	return route
+}
@@ -10,6 +10,8 @@ import (
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/codes"
+
+	"github.com/noah-isme/backend-toko/internal/tenant"
 )
 
 // StatusRecorder wraps ResponseWriter to capture status code and bytes written.
@@ -117,3 +119,14 @@ func TracingMiddleware(next http.Handler) http.Handler {
 		span.End()
 	})
 }
+
+// TenantAccessLog copies the resolved tenant onto the access log line; mount
+// it after the tenant resolver.
+func TenantAccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, ok := tenant.FromContext(r.Context()); ok {
+			SetAccessLogField(r.Context(), FieldTenant, id)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
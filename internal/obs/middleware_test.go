@@ -1,14 +1,21 @@
 package obs_test
 
 import (
+	"bytes"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/rs/zerolog"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 
 	"github.com/noah-isme/backend-toko/internal/obs"
+	"github.com/noah-isme/backend-toko/internal/tenant"
 )
 
 func TestHTTPMetricsLabels(t *testing.T) {
@@ -43,3 +50,125 @@ func TestHTTPMetricsLabels(t *testing.T) {
 		}
 	}
 }
+
+func TestRequestLoggerAttachesRequestID(t *testing.T) {
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+	handler := middleware.RequestID(obs.RequestLogger{Logger: logger}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zerolog.Ctx(r.Context()).Info().Msg("inside handler")
+		w.WriteHeader(http.StatusOK)
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set(middleware.RequestIDHeader, "req-abc")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected handler and access log lines, got %q", buf.String())
+	}
+	for _, line := range lines {
+		if !strings.Contains(line, `"request_id":"req-abc"`) {
+			t.Fatalf("log line missing request id: %s", line)
+		}
+	}
+}
+
+func TestRequestLoggerSamplesByPathButKeepsErrorsAndSlowRequests(t *testing.T) {
+	var buf bytes.Buffer
+	status := http.StatusOK
+	delay := time.Duration(0)
+	handler := obs.RequestLogger{
+		Logger:        zerolog.New(&buf),
+		SampleRates:   map[string]float64{"/health": 0.01, "/health/live": 0},
+		SlowThreshold: 20 * time.Millisecond,
+		Rand:          func() float64 { return 0.5 },
+	}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		w.WriteHeader(status)
+	}))
+	serve := func(path string) {
+		buf.Reset()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	serve("/health/ready")
+	if buf.Len() != 0 {
+		t.Fatalf("expected sampled-out health check, got %s", buf.String())
+	}
+	serve("/products")
+	if !strings.Contains(buf.String(), `"path":"/products"`) {
+		t.Fatalf("expected unsampled path to be logged, got %q", buf.String())
+	}
+
+	status = http.StatusServiceUnavailable
+	serve("/health/live")
+	if !strings.Contains(buf.String(), `"status":503`) || !strings.Contains(buf.String(), `"sample_rate":0`) {
+		t.Fatalf("expected 5xx to bypass sampling, got %q", buf.String())
+	}
+
+	status = http.StatusOK
+	delay = 25 * time.Millisecond
+	serve("/health/ready")
+	if !strings.Contains(buf.String(), `"slow":true`) {
+		t.Fatalf("expected slow request to bypass sampling, got %q", buf.String())
+	}
+}
+
+func TestRequestLoggerFieldSelectionAndLateIdentifiers(t *testing.T) {
+	var buf bytes.Buffer
+	inner := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		obs.SetAccessLogField(r.Context(), obs.FieldUserID, "user-1")
+		w.WriteHeader(http.StatusOK)
+	})
+	withTenant := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(tenant.WithTenant(r.Context(), "tenant-a")))
+		})
+	}
+	handler := obs.RequestLogger{
+		Logger: zerolog.New(&buf),
+		Fields: []string{obs.FieldMethod, obs.FieldStatus, obs.FieldUserID, obs.FieldTenant},
+	}.Middleware(withTenant(obs.TenantAccessLog(inner)))
+
+	req := httptest.NewRequest(http.MethodGet, "/cart", nil)
+	req.Header.Set("User-Agent", "k6")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := buf.String()
+	for _, want := range []string{`"method":"GET"`, `"status":200`, `"user_id":"user-1"`, `"tenant":"tenant-a"`} {
+		if !strings.Contains(line, want) {
+			t.Fatalf("expected %s in %s", want, line)
+		}
+	}
+	for _, unwanted := range []string{`"path"`, `"duration_ms"`, `"user_agent"`, `"trace_id"`} {
+		if strings.Contains(line, unwanted) {
+			t.Fatalf("unexpected %s in %s", unwanted, line)
+		}
+	}
+}
+
+func TestRequestLoggerRedactsBodyAndHeaders(t *testing.T) {
+	var buf bytes.Buffer
+	handler := obs.RequestLogger{
+		Logger: zerolog.New(&buf),
+		Fields: []string{obs.FieldPath, obs.FieldHeaders, obs.FieldBody},
+	}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/auth/login", strings.NewReader(`{"email":"a@example.com","password":"hunter2-secret"}`))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer tok-123")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	line := buf.String()
+	for _, leaked := range []string{"hunter2-secret", "tok-123"} {
+		if strings.Contains(line, leaked) {
+			t.Fatalf("access log leaked %q: %s", leaked, line)
+		}
+	}
+	if !strings.Contains(line, `"email":"a@example.com"`) || !strings.Contains(line, `"password":"[REDACTED]"`) {
+		t.Fatalf("expected redacted body in access log, got %s", line)
+	}
+}
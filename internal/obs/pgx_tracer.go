@@ -3,17 +3,30 @@ package obs
 import (
 	"context"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 )
 
-type ctxSpanKey struct{}
+type ctxQueryKey struct{}
 
-// PGXTracer implements pgx.QueryTracer to create spans for database interactions.
-type PGXTracer struct{}
+type queryTrace struct {
+	span  trace.Span
+	sql   string
+	start time.Time
+}
+
+// PGXTracer implements pgx.QueryTracer to create spans for database
+// interactions. Queries slower than SlowThreshold are logged at warn level
+// and counted in DBSlowQueriesTotal; a zero threshold disables this.
+type PGXTracer struct {
+	SlowThreshold time.Duration
+	Logger        zerolog.Logger
+}
 
 // TraceQueryStart starts a span for the SQL statement.
 func (PGXTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
@@ -25,17 +38,50 @@ func (PGXTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.Trac
 	if strings.TrimSpace(data.SQL) != "" {
 		span.SetAttributes(attribute.String("db.operation", strings.Fields(data.SQL)[0]))
 	}
-	return context.WithValue(ctx, ctxSpanKey{}, span)
+	return context.WithValue(ctx, ctxQueryKey{}, &queryTrace{span: span, sql: data.SQL, start: time.Now()})
+}
+
+// TraceQueryEnd ends the span, records any error, and reports slow queries.
+func (t PGXTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	qt, ok := ctx.Value(ctxQueryKey{}).(*queryTrace)
+	if !ok {
+		return
+	}
+	if data.Err != nil {
+		qt.span.RecordError(data.Err)
+	}
+	qt.span.End()
+
+	elapsed := time.Since(qt.start)
+	if t.SlowThreshold <= 0 || elapsed < t.SlowThreshold {
+		return
+	}
+	name := QueryName(qt.sql)
+	if DBSlowQueriesTotal != nil {
+		DBSlowQueriesTotal.WithLabelValues(name).Inc()
+	}
+	// Only the parameterized statement is logged; argument values may hold PII.
+	t.Logger.Warn().
+		Str("query", name).
+		Str("sql", truncateSQL(qt.sql)).
+		Dur("duration", elapsed).
+		Dur("threshold", t.SlowThreshold).
+		Msg("slow query")
 }
 
-// TraceQueryEnd ends the span and records any error.
-func (PGXTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
-	if span, ok := ctx.Value(ctxSpanKey{}).(trace.Span); ok {
-		if data.Err != nil {
-			span.RecordError(data.Err)
+// QueryName returns the sqlc query name from the "-- name: X :kind" header,
+// or the SQL operation for statements without one.
+func QueryName(sql string) string {
+	trimmed := strings.TrimSpace(sql)
+	if rest, ok := strings.CutPrefix(trimmed, "-- name:"); ok {
+		if fields := strings.Fields(rest); len(fields) > 0 {
+			return fields[0]
 		}
-		span.End()
 	}
+	if fields := strings.Fields(trimmed); len(fields) > 0 {
+		return strings.ToLower(fields[0])
+	}
+	return "unknown"
 }
 
 func truncateSQL(sql string) string {
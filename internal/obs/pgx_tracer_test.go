@@ -0,0 +1,66 @@
+package obs_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+
+	"github.com/noah-isme/backend-toko/internal/obs"
+)
+
+const listVariantsSQL = `-- name: ListVariantsByProduct :many
+SELECT id, sku FROM product_variants WHERE product_id = $1`
+
+func TestQueryName(t *testing.T) {
+	if got := obs.QueryName(listVariantsSQL); got != "ListVariantsByProduct" {
+		t.Fatalf("expected sqlc name, got %q", got)
+	}
+	if got := obs.QueryName("  SELECT 1"); got != "select" {
+		t.Fatalf("expected operation fallback, got %q", got)
+	}
+	if got := obs.QueryName(""); got != "unknown" {
+		t.Fatalf("expected unknown for empty SQL, got %q", got)
+	}
+}
+
+func TestPGXTracerReportsSlowQueries(t *testing.T) {
+	obs.MustRegisterDomainMetrics("test", prometheus.NewRegistry())
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	run := func(tracer obs.PGXTracer, delay time.Duration) {
+		ctx := tracer.TraceQueryStart(context.Background(), nil, pgx.TraceQueryStartData{
+			SQL:  listVariantsSQL,
+			Args: []any{"secret-product-id"},
+		})
+		time.Sleep(delay)
+		tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{})
+	}
+
+	before := testutil.ToFloat64(obs.DBSlowQueriesTotal.WithLabelValues("ListVariantsByProduct"))
+	run(obs.PGXTracer{SlowThreshold: time.Hour, Logger: logger}, 0)
+	run(obs.PGXTracer{Logger: logger}, 2*time.Millisecond)
+	if buf.Len() != 0 {
+		t.Fatalf("expected no logs below threshold or when disabled, got %s", buf.String())
+	}
+
+	run(obs.PGXTracer{SlowThreshold: time.Millisecond, Logger: logger}, 2*time.Millisecond)
+	out := buf.String()
+	if !strings.Contains(out, `"level":"warn"`) || !strings.Contains(out, `"query":"ListVariantsByProduct"`) {
+		t.Fatalf("expected warn log with query name, got %s", out)
+	}
+	if !strings.Contains(out, "product_id = $1") || strings.Contains(out, "secret-product-id") {
+		t.Fatalf("expected parameterized SQL without argument values, got %s", out)
+	}
+	after := testutil.ToFloat64(obs.DBSlowQueriesTotal.WithLabelValues("ListVariantsByProduct"))
+	if after-before != 1 {
+		t.Fatalf("expected slow query counter to increase by 1, got %v", after-before)
+	}
+}
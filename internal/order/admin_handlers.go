@@ -4,12 +4,14 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 
+	"github.com/noah-isme/backend-toko/internal/cart"
 	"github.com/noah-isme/backend-toko/internal/common"
 	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
 )
@@ -17,6 +19,54 @@ import (
 // AdminHandler provides administrative order management endpoints.
 type AdminHandler struct {
 	Q *dbgen.Queries
+	// ReleaseVoucherOnCancel gives a canceled order's voucher use back.
+	ReleaseVoucherOnCancel bool
+	// ExportFormat is the accounting export format used when a request
+	// names none.
+	ExportFormat string
+	// ExportStatuses are the order statuses exported when a request names
+	// none; empty means DefaultExportStatuses.
+	ExportStatuses []string
+}
+
+// List returns orders across all customers, newest first, optionally
+// filtered by status.
+func (h *AdminHandler) List(w http.ResponseWriter, r *http.Request) {
+	if h.Q == nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "order queries not configured", nil)
+		return
+	}
+	var status pgtype.Text
+	if raw := strings.ToUpper(strings.TrimSpace(r.URL.Query().Get("status"))); raw != "" {
+		if orderStatusRank(dbgen.OrderStatus(raw)) == -2 {
+			common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "unsupported status", nil)
+			return
+		}
+		status = pgtype.Text{String: raw, Valid: true}
+	}
+	limit, offset := common.ParseOffsetPagination(r, 20, 100)
+	total, err := h.Q.CountOrdersAdmin(r.Context(), status)
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "failed to count orders", nil)
+		return
+	}
+	orders, err := h.Q.ListOrdersAdmin(r.Context(), dbgen.ListOrdersAdminParams{Status: status, PageLimit: int32(limit), PageOffset: int32(offset)})
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "failed to list orders", nil)
+		return
+	}
+	response := make([]map[string]any, 0, len(orders))
+	for _, ord := range orders {
+		response = append(response, map[string]any{
+			"id":        cart.UUIDString(ord.ID),
+			"userId":    cart.UUIDString(ord.UserID),
+			"status":    ord.Status,
+			"total":     common.Int64(ord.PricingTotal),
+			"currency":  ord.Currency,
+			"createdAt": ord.CreatedAt,
+		})
+	}
+	common.WritePage(w, r, "orders", response, limit, offset, total, nil)
 }
 
 type patchStatusRequest struct {
@@ -70,6 +120,9 @@ func (h *AdminHandler) PatchStatus(w http.ResponseWriter, r *http.Request) {
 		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "failed to update order status", nil)
 		return
 	}
+	if target == dbgen.OrderStatusCANCELED && h.ReleaseVoucherOnCancel {
+		releaseVoucher(r.Context(), h.Q, oID)
+	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
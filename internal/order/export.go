@@ -0,0 +1,420 @@
+package order
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
+
+	"github.com/noah-isme/backend-toko/internal/cart"
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/pricing"
+	"github.com/noah-isme/backend-toko/internal/tenant"
+)
+
+// Accounting export formats. ExportCSV writes one row per order line with
+// accounting columns; ExportJSON writes one document per order in the shape
+// common ERP imports take.
+const (
+	ExportCSV  = "csv"
+	ExportJSON = "json"
+)
+
+// ExportFormats lists the accepted export formats.
+var ExportFormats = []string{ExportCSV, ExportJSON}
+
+// DefaultExportStatuses are the orders finance books: paid and beyond, but
+// not unpaid or canceled ones.
+var DefaultExportStatuses = []string{
+	string(dbgen.OrderStatusPAID),
+	string(dbgen.OrderStatusPACKED),
+	string(dbgen.OrderStatusSHIPPED),
+	string(dbgen.OrderStatusOUTFORDELIVERY),
+	string(dbgen.OrderStatusDELIVERED),
+}
+
+// Export line types.
+const (
+	LineItem     = "item"
+	LineShipping = "shipping"
+)
+
+// exportPageSize bounds how many orders an export holds in memory at once.
+const exportPageSize = 200
+
+// exportQueries is the subset of queries an accounting export reads.
+type exportQueries interface {
+	ListOrdersForExportAfter(ctx context.Context, arg dbgen.ListOrdersForExportAfterParams) ([]dbgen.Order, error)
+	ListOrderItemsByOrders(ctx context.Context, orderIds []pgtype.UUID) ([]dbgen.OrderItem, error)
+}
+
+// ExportLine is one booked line of an order: an item, or the shipping
+// charge. Net is Gross less the allocated discount, and Total adds the tax
+// on Net.
+type ExportLine struct {
+	Type        string       `json:"type"`
+	ItemCode    string       `json:"itemCode"`
+	Description string       `json:"description"`
+	Quantity    int32        `json:"quantity"`
+	UnitPrice   common.Int64 `json:"unitPrice"`
+	Gross       common.Int64 `json:"grossAmount"`
+	Discount    common.Int64 `json:"discountAmount"`
+	Net         common.Int64 `json:"netAmount"`
+	Tax         common.Int64 `json:"taxAmount"`
+	Total       common.Int64 `json:"lineTotal"`
+}
+
+// ExportTotals are the order's own totals, which the lines add up to.
+type ExportTotals struct {
+	Subtotal common.Int64 `json:"subtotal"`
+	Discount common.Int64 `json:"discount"`
+	Tax      common.Int64 `json:"tax"`
+	Shipping common.Int64 `json:"shipping"`
+	Total    common.Int64 `json:"total"`
+}
+
+// ExportDocument is one order in the JSON export.
+type ExportDocument struct {
+	DocumentNo   string       `json:"documentNo"`
+	DocumentDate time.Time    `json:"documentDate"`
+	Status       string       `json:"status"`
+	Currency     string       `json:"currency"`
+	CustomerID   string       `json:"customerId"`
+	VoucherCode  string       `json:"voucherCode,omitempty"`
+	Lines        []ExportLine `json:"lines"`
+	Totals       ExportTotals `json:"totals"`
+}
+
+// AccountingLines books an order's items and shipping. Each item carries its
+// stored discount share; orders placed before shares were stored split the
+// discount by item subtotal instead. The order's tax is spread over the items
+// by net amount, since tax is charged on the discounted subtotal and not on
+// shipping, so the lines always add up to the order totals.
+func AccountingLines(o dbgen.Order, items []dbgen.OrderItem) []ExportLine {
+	gross := make([]pricing.Money, len(items))
+	discounts := make([]pricing.Money, len(items))
+	var allocated pricing.Money
+	for i, it := range items {
+		gross[i] = it.Subtotal
+		discounts[i] = it.DiscountAllocated
+		allocated += it.DiscountAllocated
+	}
+	if allocated != o.PricingDiscount {
+		discounts = pricing.Allocate(o.PricingDiscount, gross)
+	}
+	net := make([]pricing.Money, len(items))
+	for i := range items {
+		net[i] = gross[i] - discounts[i]
+	}
+	taxes := pricing.Allocate(o.PricingTax, net)
+
+	lines := make([]ExportLine, 0, len(items)+1)
+	for i, it := range items {
+		code := cart.UUIDString(it.VariantID)
+		if !it.VariantID.Valid {
+			code = cart.UUIDString(it.ProductID)
+		}
+		lines = append(lines, ExportLine{
+			Type:        LineItem,
+			ItemCode:    code,
+			Description: it.Title,
+			Quantity:    it.Qty,
+			UnitPrice:   common.Int64(it.UnitPrice),
+			Gross:       common.Int64(gross[i]),
+			Discount:    common.Int64(discounts[i]),
+			Net:         common.Int64(net[i]),
+			Tax:         common.Int64(taxes[i]),
+			Total:       common.Int64(net[i] + taxes[i]),
+		})
+	}
+	if o.PricingShipping > 0 {
+		lines = append(lines, ExportLine{
+			Type:        LineShipping,
+			ItemCode:    "SHIPPING",
+			Description: "Shipping",
+			Quantity:    1,
+			UnitPrice:   common.Int64(o.PricingShipping),
+			Gross:       common.Int64(o.PricingShipping),
+			Net:         common.Int64(o.PricingShipping),
+			Total:       common.Int64(o.PricingShipping),
+		})
+	}
+	return lines
+}
+
+func exportDocument(o dbgen.Order, items []dbgen.OrderItem) ExportDocument {
+	voucher := ""
+	if o.AppliedVoucherCode.Valid {
+		voucher = o.AppliedVoucherCode.String
+	}
+	return ExportDocument{
+		DocumentNo:   cart.UUIDString(o.ID),
+		DocumentDate: o.CreatedAt.Time.UTC(),
+		Status:       string(o.Status),
+		Currency:     o.Currency,
+		CustomerID:   cart.UUIDString(o.UserID),
+		VoucherCode:  voucher,
+		Lines:        AccountingLines(o, items),
+		Totals: ExportTotals{
+			Subtotal: common.Int64(o.PricingSubtotal),
+			Discount: common.Int64(o.PricingDiscount),
+			Tax:      common.Int64(o.PricingTax),
+			Shipping: common.Int64(o.PricingShipping),
+			Total:    common.Int64(o.PricingTotal),
+		},
+	}
+}
+
+// exportColumns is the CSV header; amounts are in minor units.
+var exportColumns = []string{
+	"document_no", "document_date", "status", "currency", "customer_id", "voucher_code",
+	"line_no", "line_type", "item_code", "description", "quantity", "unit_price",
+	"gross_amount", "discount_amount", "net_amount", "tax_amount", "line_total",
+}
+
+// Export streams the orders of one tenant created in [From, To) with one of
+// Statuses.
+type Export struct {
+	q        exportQueries
+	From     time.Time
+	To       time.Time
+	Statuses []string
+	TenantID pgtype.UUID
+	Format   string
+	pageSize int
+}
+
+// Filename is the suggested name of the downloaded file.
+func (e *Export) Filename() string {
+	return fmt.Sprintf("orders-%s-%s.%s", e.From.UTC().Format("20060102"), e.To.UTC().Format("20060102"), e.Format)
+}
+
+// ContentType is the media type of the export.
+func (e *Export) ContentType() string {
+	if e.Format == ExportJSON {
+		return "application/json; charset=utf-8"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Stream writes the export to w a page of orders at a time, so any range can
+// be exported without holding it in memory. When Stream fails part way, w
+// holds an incomplete file.
+func (e *Export) Stream(ctx context.Context, w io.Writer) error {
+	buf := bufio.NewWriter(w)
+	var (
+		csvOut *csv.Writer
+		enc    *json.Encoder
+		first  = true
+	)
+	if e.Format == ExportJSON {
+		enc = json.NewEncoder(buf)
+		if _, err := fmt.Fprintf(buf, `{"from":%q,"to":%q,"documents":[`, e.From.UTC().Format(time.RFC3339), e.To.UTC().Format(time.RFC3339)); err != nil {
+			return err
+		}
+	} else {
+		csvOut = csv.NewWriter(buf)
+		if err := csvOut.Write(exportColumns); err != nil {
+			return err
+		}
+	}
+	pageSize := e.pageSize
+	if pageSize <= 0 {
+		pageSize = exportPageSize
+	}
+	params := dbgen.ListOrdersForExportAfterParams{
+		FromAt:    pgtype.Timestamptz{Time: e.From, Valid: true},
+		ToAt:      pgtype.Timestamptz{Time: e.To, Valid: true},
+		Statuses:  e.Statuses,
+		TenantID:  e.TenantID,
+		PageLimit: int32(pageSize),
+	}
+	for {
+		orders, err := e.q.ListOrdersForExportAfter(ctx, params)
+		if err != nil {
+			return err
+		}
+		if len(orders) > 0 {
+			ids := make([]pgtype.UUID, 0, len(orders))
+			for _, o := range orders {
+				ids = append(ids, o.ID)
+			}
+			items, err := e.q.ListOrderItemsByOrders(ctx, ids)
+			if err != nil {
+				return err
+			}
+			byOrder := make(map[pgtype.UUID][]dbgen.OrderItem, len(orders))
+			for _, it := range items {
+				byOrder[it.OrderID] = append(byOrder[it.OrderID], it)
+			}
+			for _, o := range orders {
+				doc := exportDocument(o, byOrder[o.ID])
+				if enc != nil {
+					if !first {
+						if err := buf.WriteByte(','); err != nil {
+							return err
+						}
+					}
+					first = false
+					if err := enc.Encode(doc); err != nil {
+						return err
+					}
+					continue
+				}
+				if err := writeCSVDocument(csvOut, doc); err != nil {
+					return err
+				}
+			}
+		}
+		if csvOut != nil {
+			csvOut.Flush()
+			if err := csvOut.Error(); err != nil {
+				return err
+			}
+		}
+		if err := flushExport(buf, w); err != nil {
+			return err
+		}
+		if len(orders) < pageSize {
+			break
+		}
+		last := orders[len(orders)-1]
+		params.AfterCreatedAt, params.AfterID = last.CreatedAt, last.ID
+	}
+	if enc != nil {
+		if _, err := buf.WriteString("]}\n"); err != nil {
+			return err
+		}
+	}
+	return flushExport(buf, w)
+}
+
+func writeCSVDocument(out *csv.Writer, doc ExportDocument) error {
+	date := doc.DocumentDate.Format(time.RFC3339)
+	for i, line := range doc.Lines {
+		err := out.Write([]string{
+			csvText(doc.DocumentNo), date, csvText(doc.Status), csvText(doc.Currency), csvText(doc.CustomerID), csvText(doc.VoucherCode),
+			strconv.Itoa(i + 1), csvText(line.Type), csvText(line.ItemCode), csvText(line.Description), strconv.Itoa(int(line.Quantity)),
+			strconv.FormatInt(int64(line.UnitPrice), 10),
+			strconv.FormatInt(int64(line.Gross), 10),
+			strconv.FormatInt(int64(line.Discount), 10),
+			strconv.FormatInt(int64(line.Net), 10),
+			strconv.FormatInt(int64(line.Tax), 10),
+			strconv.FormatInt(int64(line.Total), 10),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// csvText neutralises a free-text cell that a spreadsheet would evaluate as
+// a formula, such as a product titled "=HYPERLINK(...)", by prefixing a quote.
+// Numeric cells are written as is so negative amounts stay numbers.
+func csvText(v string) string {
+	if v != "" && strings.ContainsRune("=+-@\t\r", rune(v[0])) {
+		return "'" + v
+	}
+	return v
+}
+
+// flushExport hands buffered output to the client and pushes it out when the
+// destination supports flushing.
+func flushExport(buf *bufio.Writer, dst io.Writer) error {
+	if err := buf.Flush(); err != nil {
+		return err
+	}
+	if f, ok := dst.(interface{ Flush() }); ok {
+		f.Flush()
+	}
+	return nil
+}
+
+// Export handles GET /api/v1/admin/orders/export?from=&to=&format=&status=.
+// from and to are RFC 3339 times or dates, to exclusive; status is a comma
+// separated list overriding the configured statuses.
+func (h *AdminHandler) Export(w http.ResponseWriter, r *http.Request) {
+	if h.Q == nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "order queries not configured", nil)
+		return
+	}
+	query := r.URL.Query()
+	from, err := parseExportTime(query.Get("from"))
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "from must be an RFC 3339 time or a YYYY-MM-DD date", map[string]any{"field": "from"})
+		return
+	}
+	to, err := parseExportTime(query.Get("to"))
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "to must be an RFC 3339 time or a YYYY-MM-DD date", map[string]any{"field": "to"})
+		return
+	}
+	if !from.Before(to) {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "from must be before to", nil)
+		return
+	}
+	format := strings.ToLower(strings.TrimSpace(query.Get("format")))
+	if format == "" {
+		format = h.ExportFormat
+	}
+	if format == "" {
+		format = ExportCSV
+	}
+	if !slices.Contains(ExportFormats, format) {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "unsupported format", map[string]any{"field": "format", "allowed": ExportFormats})
+		return
+	}
+	statuses := h.ExportStatuses
+	if raw := strings.TrimSpace(query.Get("status")); raw != "" {
+		statuses = nil
+		for _, s := range strings.Split(raw, ",") {
+			s = strings.ToUpper(strings.TrimSpace(s))
+			if orderStatusRank(dbgen.OrderStatus(s)) == -2 {
+				common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "unsupported status", map[string]any{"field": "status", "value": s})
+				return
+			}
+			statuses = append(statuses, s)
+		}
+	}
+	if len(statuses) == 0 {
+		statuses = DefaultExportStatuses
+	}
+	export := &Export{q: h.Q, From: from, To: to, Statuses: statuses, Format: format}
+	if tenantID, ok := tenant.FromContext(r.Context()); ok {
+		id, err := parseUUID(tenantID)
+		if err != nil {
+			common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid tenant", nil)
+			return
+		}
+		export.TenantID = id
+	}
+	w.Header().Set("Content-Type", export.ContentType())
+	w.Header().Set("Content-Disposition", `attachment; filename="`+export.Filename()+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if err := export.Stream(r.Context(), w); err != nil {
+		// The status is already sent; the truncated body tells the client
+		// the download failed.
+		zerolog.Ctx(r.Context()).Error().Err(err).Msg("order export failed")
+	}
+}
+
+func parseExportTime(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if t, err := time.Parse(time.DateOnly, raw); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, raw)
+}
@@ -0,0 +1,167 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	db "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+type fakeExportQueries struct {
+	orders []db.Order
+	items  []db.OrderItem
+	pages  int
+}
+
+func (f *fakeExportQueries) ListOrdersForExportAfter(_ context.Context, arg db.ListOrdersForExportAfterParams) ([]db.Order, error) {
+	f.pages++
+	start := 0
+	if arg.AfterID.Valid {
+		for i, o := range f.orders {
+			if o.ID == arg.AfterID {
+				start = i + 1
+			}
+		}
+	}
+	end := min(start+int(arg.PageLimit), len(f.orders))
+	return f.orders[start:end], nil
+}
+
+func (f *fakeExportQueries) ListOrderItemsByOrders(_ context.Context, ids []pgtype.UUID) ([]db.OrderItem, error) {
+	var out []db.OrderItem
+	for _, it := range f.items {
+		for _, id := range ids {
+			if it.OrderID == id {
+				out = append(out, it)
+			}
+		}
+	}
+	return out, nil
+}
+
+func exportID(b byte) pgtype.UUID {
+	return pgtype.UUID{Bytes: [16]byte{b}, Valid: true}
+}
+
+func exportOrder(b byte) db.Order {
+	return db.Order{
+		ID:              exportID(b),
+		UserID:          exportID(0xaa),
+		Status:          db.OrderStatusPAID,
+		Currency:        "IDR",
+		PricingSubtotal: 300,
+		PricingDiscount: 100,
+		PricingTax:      20,
+		PricingShipping: 15,
+		PricingTotal:    235,
+		CreatedAt:       pgtype.Timestamptz{Time: time.Date(2025, 5, 1, 0, 0, int(b), 0, time.UTC), Valid: true},
+	}
+}
+
+func exportItems(o db.Order) []db.OrderItem {
+	return []db.OrderItem{
+		{OrderID: o.ID, ProductID: exportID(1), Title: "A", Qty: 1, UnitPrice: 100, Subtotal: 100},
+		{OrderID: o.ID, ProductID: exportID(2), Title: "B", Qty: 2, UnitPrice: 100, Subtotal: 200},
+	}
+}
+
+func TestAccountingLinesAddUpToOrderTotals(t *testing.T) {
+	o := exportOrder(1)
+	lines := AccountingLines(o, exportItems(o))
+	require.Len(t, lines, 3)
+
+	var discount, tax, total int64
+	for _, l := range lines {
+		discount += int64(l.Discount)
+		tax += int64(l.Tax)
+		total += int64(l.Total)
+		require.Equal(t, int64(l.Gross)-int64(l.Discount), int64(l.Net))
+	}
+	require.Equal(t, o.PricingDiscount, discount)
+	require.Equal(t, o.PricingTax, tax)
+	require.Equal(t, o.PricingTotal, total)
+	// Without stored shares the discount is split by subtotal.
+	require.EqualValues(t, 33, lines[0].Discount)
+	require.EqualValues(t, 67, lines[1].Discount)
+	require.Equal(t, LineShipping, lines[2].Type)
+	require.Zero(t, lines[2].Tax)
+
+	// Stored shares win when they account for the whole discount.
+	items := exportItems(o)
+	items[0].DiscountAllocated, items[1].DiscountAllocated = 100, 0
+	lines = AccountingLines(o, items)
+	require.EqualValues(t, 100, lines[0].Discount)
+	require.EqualValues(t, 0, lines[0].Net)
+	require.EqualValues(t, 0, lines[0].Tax)
+	require.EqualValues(t, 20, lines[1].Tax)
+}
+
+func TestExportStreamsPagesAsCSVAndJSON(t *testing.T) {
+	q := &fakeExportQueries{}
+	for b := byte(1); b <= 3; b++ {
+		o := exportOrder(b)
+		q.orders = append(q.orders, o)
+		q.items = append(q.items, exportItems(o)...)
+	}
+	export := &Export{
+		q:        q,
+		From:     time.Date(2025, 5, 1, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC),
+		Statuses: DefaultExportStatuses,
+		Format:   ExportCSV,
+		pageSize: 2,
+	}
+	require.Equal(t, "orders-20250501-20250601.csv", export.Filename())
+
+	var buf bytes.Buffer
+	require.NoError(t, export.Stream(context.Background(), &buf))
+	require.Equal(t, 2, q.pages)
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 1+3*3)
+	require.Equal(t, exportColumns, rows[0])
+	require.Equal(t, []string{"1", "item", "01000000-0000-0000-0000-000000000000", "A", "1", "100", "100", "33", "67", "7", "74"}, rows[1][6:])
+
+	q.pages = 0
+	buf.Reset()
+	export.Format = ExportJSON
+	require.NoError(t, export.Stream(context.Background(), &buf))
+	var out struct {
+		From      string           `json:"from"`
+		Documents []ExportDocument `json:"documents"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	require.Equal(t, "2025-05-01T00:00:00Z", out.From)
+	require.Len(t, out.Documents, 3)
+	require.Len(t, out.Documents[2].Lines, 3)
+	require.EqualValues(t, 235, out.Documents[2].Totals.Total)
+}
+
+func TestExportCSVNeutralisesFormulaCells(t *testing.T) {
+	var buf bytes.Buffer
+	out := csv.NewWriter(&buf)
+	doc := ExportDocument{DocumentNo: "ORD-1", Status: "PAID", Currency: "IDR", VoucherCode: "@SUM(A1)"}
+	for _, title := range []string{`=HYPERLINK("http://evil.test","x")`, "+1", "-1+2", "\tcmd", "\rcmd", "Kaos - Hitam"} {
+		doc.Lines = append(doc.Lines, ExportLine{Type: "item", Description: title, Discount: -100})
+	}
+	require.NoError(t, writeCSVDocument(out, doc))
+	out.Flush()
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 6)
+	require.Equal(t, "'@SUM(A1)", rows[0][5])
+	var titles []string
+	for _, row := range rows {
+		titles = append(titles, row[9])
+		require.Equal(t, "-100", row[13], "numeric cells stay numbers")
+	}
+	require.Equal(t, []string{`'=HYPERLINK("http://evil.test","x")`, "'+1", "'-1+2", "'\tcmd", "'\rcmd", "Kaos - Hitam"}, titles)
+}
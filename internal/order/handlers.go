@@ -1,6 +1,7 @@
 package order
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"net/http"
@@ -9,6 +10,7 @@ import (
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
 
 	"github.com/noah-isme/backend-toko/internal/cart"
 	"github.com/noah-isme/backend-toko/internal/common"
@@ -17,6 +19,8 @@ import (
 
 type Handler struct {
 	Q *dbgen.Queries
+	// ReleaseVoucherOnCancel gives a canceled order's voucher use back.
+	ReleaseVoucherOnCancel bool
 }
 
 func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
@@ -54,17 +58,18 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 		response = append(response, map[string]any{
 			"id":        cart.UUIDString(ord.ID),
 			"status":    ord.Status,
-			"total":     ord.PricingTotal,
-			"subtotal":  ord.PricingSubtotal,
-			"discount":  ord.PricingDiscount,
-			"tax":       ord.PricingTax,
-			"shipping":  ord.PricingShipping,
+			"total":     common.Int64(ord.PricingTotal),
+			"subtotal":  common.Int64(ord.PricingSubtotal),
+			"discount":  common.Int64(ord.PricingDiscount),
+			"tax":       common.Int64(ord.PricingTax),
+			"shipping":  common.Int64(ord.PricingShipping),
 			"currency":  ord.Currency,
 			"createdAt": ord.CreatedAt,
 		})
 	}
 	w.Header().Set("X-Total-Count", strconv.FormatInt(total, 10))
-	common.JSON(w, http.StatusOK, map[string]any{
+	listPage := common.ListPage{Type: "orders", Items: response, Page: page, PerPage: perPage, Total: total}
+	common.WriteList(w, r, listPage, map[string]any{
 		"data": response,
 		"pagination": common.Pagination{
 			Page:       page,
@@ -111,26 +116,32 @@ func (h *Handler) Get(w http.ResponseWriter, r *http.Request) {
 	}
 	responseItems := make([]map[string]any, 0, len(items))
 	for _, it := range items {
-		responseItems = append(responseItems, map[string]any{
-			"id":        cart.UUIDString(it.ID),
-			"productId": cart.UUIDString(it.ProductID),
-			"variantId": nullableUUID(it.VariantID),
-			"title":     it.Title,
-			"slug":      it.Slug,
-			"qty":       it.Qty,
-			"unitPrice": it.UnitPrice,
-			"subtotal":  it.Subtotal,
-		})
+		item := map[string]any{
+			"id":                cart.UUIDString(it.ID),
+			"productId":         cart.UUIDString(it.ProductID),
+			"variantId":         nullableUUID(it.VariantID),
+			"title":             it.Title,
+			"slug":              it.Slug,
+			"qty":               it.Qty,
+			"unitPrice":         common.Int64(it.UnitPrice),
+			"subtotal":          common.Int64(it.Subtotal),
+			"preorder":          it.Preorder,
+			"discountAllocated": common.Int64(it.DiscountAllocated),
+		}
+		if it.CatalogUnitPrice.Valid {
+			item["catalogUnitPrice"] = common.Int64(it.CatalogUnitPrice.Int64)
+		}
+		responseItems = append(responseItems, item)
 	}
 	common.JSON(w, http.StatusOK, map[string]any{
 		"data": map[string]any{
 			"id":              cart.UUIDString(ord.ID),
 			"status":          ord.Status,
-			"total":           ord.PricingTotal,
-			"subtotal":        ord.PricingSubtotal,
-			"discount":        ord.PricingDiscount,
-			"tax":             ord.PricingTax,
-			"shipping":        ord.PricingShipping,
+			"total":           common.Int64(ord.PricingTotal),
+			"subtotal":        common.Int64(ord.PricingSubtotal),
+			"discount":        common.Int64(ord.PricingDiscount),
+			"tax":             common.Int64(ord.PricingTax),
+			"shipping":        common.Int64(ord.PricingShipping),
 			"currency":        ord.Currency,
 			"createdAt":       ord.CreatedAt,
 			"items":           responseItems,
@@ -179,9 +190,22 @@ func (h *Handler) Cancel(w http.ResponseWriter, r *http.Request) {
 		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "failed to cancel order", nil)
 		return
 	}
+	if h.ReleaseVoucherOnCancel {
+		releaseVoucher(r.Context(), h.Q, ord.ID)
+	}
 	common.JSON(w, http.StatusOK, map[string]any{"data": map[string]any{"status": "CANCELED"}})
 }
 
+// releaseVoucher gives a canceled order's voucher use back. Failure does not
+// fail the request, since the order is already canceled and per-user limits
+// skip canceled orders anyway, but it is logged because the voucher's total
+// used count stays high until the usage is released by hand.
+func releaseVoucher(ctx context.Context, q *dbgen.Queries, orderID pgtype.UUID) {
+	if _, err := q.ReleaseVoucherUsageByOrder(ctx, orderID); err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Str("order_id", cart.UUIDString(orderID)).Msg("release voucher usage of canceled order failed")
+	}
+}
+
 func nullableText(t pgtype.Text) *string {
 	if !t.Valid {
 		return nil
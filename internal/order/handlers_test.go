@@ -0,0 +1,46 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	db "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+// failingDB fails every statement.
+type failingDB struct{}
+
+func (failingDB) Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, errors.New("voucher_usages unavailable")
+}
+
+func (failingDB) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	return nil, errors.New("voucher_usages unavailable")
+}
+
+func (failingDB) QueryRow(context.Context, string, ...interface{}) pgx.Row {
+	return nil
+}
+
+func (failingDB) SendBatch(context.Context, *pgx.Batch) pgx.BatchResults {
+	return nil
+}
+
+func TestReleaseVoucherLogsFailure(t *testing.T) {
+	var logs bytes.Buffer
+	ctx := zerolog.New(&logs).WithContext(context.Background())
+	orderID := pgtype.UUID{Bytes: [16]byte{1}, Valid: true}
+
+	releaseVoucher(ctx, db.New(failingDB{}), orderID)
+	require.Contains(t, logs.String(), "release voucher usage of canceled order failed")
+	require.Contains(t, logs.String(), "voucher_usages unavailable")
+	require.Contains(t, logs.String(), `"order_id":"01000000-0000-0000-0000-000000000000"`)
+}
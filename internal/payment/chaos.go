@@ -0,0 +1,43 @@
+package payment
+
+import (
+	"context"
+
+	"github.com/noah-isme/backend-toko/internal/resilience"
+)
+
+// ChaosTarget is the chaos testing target name of payment providers.
+const ChaosTarget = "payment"
+
+// WithChaos wraps provider so intents and refunds fail and slow down as
+// chaos dictates. Webhook verification is left alone. Without chaos for
+// payments provider is returned unchanged.
+func WithChaos(provider Provider, chaos *resilience.Chaos) Provider {
+	if provider == nil || !chaos.Applies(ChaosTarget) {
+		return provider
+	}
+	return chaosProvider{Provider: provider, chaos: chaos}
+}
+
+type chaosProvider struct {
+	Provider
+	chaos *resilience.Chaos
+}
+
+func (p chaosProvider) CreateIntent(ctx context.Context, req IntentRequest) (IntentResponse, error) {
+	if err := p.chaos.Inject(ctx, ChaosTarget); err != nil {
+		return IntentResponse{}, err
+	}
+	return p.Provider.CreateIntent(ctx, req)
+}
+
+func (p chaosProvider) Refund(ctx context.Context, req RefundRequest) (RefundResponse, error) {
+	refunder, ok := p.Provider.(Refunder)
+	if !ok {
+		return RefundResponse{}, ErrCapabilityUnsupported
+	}
+	if err := p.chaos.Inject(ctx, ChaosTarget); err != nil {
+		return RefundResponse{}, err
+	}
+	return refunder.Refund(ctx, req)
+}
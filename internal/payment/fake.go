@@ -0,0 +1,270 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/noah-isme/backend-toko/internal/queue"
+)
+
+// FakeProviderName selects the fake provider in PAYMENT_PROVIDER.
+const FakeProviderName = "fake"
+
+// FakeSignatureHeader carries the HMAC-SHA256 of a fake webhook body.
+const FakeSignatureHeader = "X-Fake-Signature"
+
+const (
+	fakeCallbackTask     = "payment-fake-callback"
+	fakeDefaultSecret    = "fake-secret"
+	fakeDefaultBaseURL   = "https://pay.fake.local"
+	fakeWebhookPathStart = "/api/v1/webhooks/payment/"
+)
+
+// Fake outcomes. The outcome of an intent is chosen by the last two digits of
+// its amount, or by a magic order ID for fixtures with a fixed amount:
+//
+//	amount ends in 01, order ID ends in -000000000001: FAILED
+//	amount ends in 02, order ID ends in -000000000002: EXPIRED
+//	amount ends in 03, order ID ends in -000000000003: PENDING (no callback)
+//	anything else: PAID
+const (
+	FakeOutcomePaid    = "PAID"
+	FakeOutcomeFailed  = "FAILED"
+	FakeOutcomeExpired = "EXPIRED"
+	FakeOutcomePending = "PENDING"
+)
+
+// TaskEnqueuer publishes queue tasks; queue.Enqueuer implements it.
+type TaskEnqueuer interface {
+	Enqueue(ctx context.Context, t queue.Task) error
+}
+
+// Fake is a provider for QA and demos that never leaves the process. Intents
+// resolve deterministically (see FakeOutcome) and, when Callbacks is set, the
+// provider schedules its own signed webhook so the full checkout, payment,
+// and webhook flow runs without a provider sandbox.
+type Fake struct {
+	SecretKey string
+	BaseURL   string
+	// Callbacks queues the self-triggered webhook; nil leaves settlement to
+	// manually posted webhooks.
+	Callbacks TaskEnqueuer
+	// CallbackDelay is how long after the intent the webhook fires.
+	CallbackDelay time.Duration
+	// CallbackBaseURL is the API base the webhook is posted to when the
+	// request carries none, as with refunds.
+	CallbackBaseURL string
+}
+
+// FakeOutcome returns the status the fake provider settles an intent with.
+func FakeOutcome(orderID string, amount int64) string {
+	switch {
+	case amount%100 == 1 || strings.HasSuffix(orderID, "-000000000001"):
+		return FakeOutcomeFailed
+	case amount%100 == 2 || strings.HasSuffix(orderID, "-000000000002"):
+		return FakeOutcomeExpired
+	case amount%100 == 3 || strings.HasSuffix(orderID, "-000000000003"):
+		return FakeOutcomePending
+	default:
+		return FakeOutcomePaid
+	}
+}
+
+// CreateIntent issues a fake intent and schedules its webhook.
+func (f *Fake) CreateIntent(ctx context.Context, req IntentRequest) (IntentResponse, error) {
+	if strings.TrimSpace(req.OrderID) == "" {
+		return IntentResponse{}, errors.New("order id is required")
+	}
+	token := fmt.Sprintf("FAKE-%s", req.OrderID)
+	outcome := FakeOutcome(req.OrderID, req.Amount)
+	resp := IntentResponse{
+		Provider:    FakeProviderName,
+		Token:       token,
+		RedirectURL: fmt.Sprintf("%s/pay/%s?outcome=%s", f.baseURL(), token, strings.ToLower(outcome)),
+		ExpiresAt:   time.Now().Add(time.Duration(req.ExpiresAtSec) * time.Second).Unix(),
+	}
+	if outcome != FakeOutcomePending {
+		if err := f.scheduleCallback(ctx, req.CallbackBaseURL, fakeNotification{
+			OrderID:     req.OrderID,
+			IntentToken: token,
+			Amount:      req.Amount,
+			Status:      outcome,
+		}); err != nil {
+			return IntentResponse{}, err
+		}
+	}
+	return resp, nil
+}
+
+// Capabilities reports that the fake provider accepts any method and currency.
+func (f *Fake) Capabilities() Capabilities {
+	return Capabilities{Name: FakeProviderName, Refunds: true}
+}
+
+// Refund accepts every refund and schedules a REFUNDED webhook.
+func (f *Fake) Refund(ctx context.Context, req RefundRequest) (RefundResponse, error) {
+	if strings.TrimSpace(req.OrderID) == "" {
+		return RefundResponse{}, errors.New("order id is required")
+	}
+	reference := fmt.Sprintf("FAKE-REFUND-%s", req.OrderID)
+	err := f.scheduleCallback(ctx, "", fakeNotification{
+		OrderID:     req.OrderID,
+		IntentToken: req.IntentToken,
+		// The amount is checked against the payment, so a partial refund
+		// travels separately.
+		RefundAmount: req.Amount,
+		Status:       "REFUNDED",
+		Reference:    reference,
+	})
+	if err != nil {
+		return RefundResponse{}, err
+	}
+	return RefundResponse{Provider: FakeProviderName, Reference: reference}, nil
+}
+
+// VerifyWebhook checks the X-Fake-Signature header and normalises the body.
+func (f *Fake) VerifyWebhook(r *http.Request, body []byte) (WebhookVerifyResult, error) {
+	provided := strings.TrimSpace(r.Header.Get(FakeSignatureHeader))
+	if provided == "" || !hmac.Equal([]byte(f.sign(body)), []byte(provided)) {
+		return WebhookVerifyResult{Valid: false, Err: errors.New("invalid signature")}, nil
+	}
+	var payload fakeNotification
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return WebhookVerifyResult{Valid: false, Err: err}, nil
+	}
+	return WebhookVerifyResult{
+		Valid:           true,
+		OrderID:         payload.OrderID,
+		Amount:          payload.Amount,
+		Status:          strings.ToUpper(strings.TrimSpace(payload.Status)),
+		SentAt:          payload.SentAt,
+		ProviderPayload: body,
+	}, nil
+}
+
+// Sign returns the signature the fake provider expects for body, for tests
+// and tools that post fake webhooks by hand.
+func (f *Fake) Sign(body []byte) string {
+	return f.sign(body)
+}
+
+type fakeNotification struct {
+	OrderID     string `json:"order_id"`
+	IntentToken string `json:"intent_token,omitempty"`
+	Amount      int64  `json:"amount"`
+	Status      string `json:"status"`
+	Reference   string `json:"reference,omitempty"`
+	// RefundAmount is set on REFUNDED notifications.
+	RefundAmount int64 `json:"refund_amount,omitempty"`
+	// SentAt is stamped when the callback is scheduled.
+	SentAt time.Time `json:"sent_at,omitzero"`
+}
+
+// fakeCallback is the queued webhook, signed when it is scheduled so the
+// worker needs no provider secret.
+type fakeCallback struct {
+	URL       string          `json:"url"`
+	Body      json.RawMessage `json:"body"`
+	Signature string          `json:"signature"`
+}
+
+func (f *Fake) scheduleCallback(ctx context.Context, callbackBase string, n fakeNotification) error {
+	if f.Callbacks == nil {
+		return nil
+	}
+	base := strings.TrimRight(strings.TrimSpace(callbackBase), "/")
+	if base == "" {
+		base = strings.TrimRight(strings.TrimSpace(f.CallbackBaseURL), "/")
+	}
+	if base == "" {
+		return nil
+	}
+	n.SentAt = time.Now().UTC().Add(f.CallbackDelay)
+	body, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(fakeCallback{
+		URL:       base + fakeWebhookPathStart + FakeProviderName,
+		Body:      body,
+		Signature: f.sign(body),
+	})
+	if err != nil {
+		return err
+	}
+	err = f.Callbacks.Enqueue(ctx, queue.Task{
+		Kind:    fakeCallbackTask,
+		Payload: payload,
+		Delay:   f.CallbackDelay,
+	})
+	if err != nil {
+		return fmt.Errorf("schedule fake payment callback: %w", err)
+	}
+	return nil
+}
+
+func (f *Fake) baseURL() string {
+	if base := strings.TrimRight(strings.TrimSpace(f.BaseURL), "/"); base != "" {
+		return base
+	}
+	return fakeDefaultBaseURL
+}
+
+func (f *Fake) sign(body []byte) string {
+	key := f.SecretKey
+	if strings.TrimSpace(key) == "" {
+		key = fakeDefaultSecret
+	}
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// FakeCallbackTask returns the queue kind used for fake provider webhooks.
+func FakeCallbackTask() string {
+	return fakeCallbackTask
+}
+
+// FakeCallbackWorker posts queued fake provider webhooks back to the API.
+type FakeCallbackWorker struct {
+	HTTP *http.Client
+}
+
+// Handle delivers one queued callback. A duplicate (409) counts as delivered;
+// any other non-2xx response is retried by the queue.
+func (w FakeCallbackWorker) Handle(ctx context.Context, payload []byte) error {
+	var cb fakeCallback
+	if err := json.Unmarshal(payload, &cb); err != nil {
+		return fmt.Errorf("fake callback: decode payload: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, cb.URL, bytes.NewReader(cb.Body))
+	if err != nil {
+		return fmt.Errorf("fake callback: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(FakeSignatureHeader, cb.Signature)
+	client := w.HTTP
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fake callback: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusConflict {
+		return fmt.Errorf("fake callback: %s returned %d", cb.URL, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package payment_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/payment"
+	"github.com/noah-isme/backend-toko/internal/queue"
+)
+
+type taskRecorder struct{ tasks []queue.Task }
+
+func (r *taskRecorder) Enqueue(_ context.Context, t queue.Task) error {
+	r.tasks = append(r.tasks, t)
+	return nil
+}
+
+func TestFakeOutcomeIsDeterministic(t *testing.T) {
+	orderID := "5d0c1b2a-3e4f-4a5b-8c6d-7e8f9a0b1c2d"
+	require.Equal(t, payment.FakeOutcomePaid, payment.FakeOutcome(orderID, 150000))
+	require.Equal(t, payment.FakeOutcomeFailed, payment.FakeOutcome(orderID, 150001))
+	require.Equal(t, payment.FakeOutcomeExpired, payment.FakeOutcome(orderID, 150002))
+	require.Equal(t, payment.FakeOutcomePending, payment.FakeOutcome(orderID, 150003))
+	require.Equal(t, payment.FakeOutcomeFailed, payment.FakeOutcome("00000000-0000-4000-8000-000000000001", 150000))
+}
+
+func TestFakeProviderCallsItsOwnWebhook(t *testing.T) {
+	provider, err := payment.Open(payment.FakeProviderName, payment.ProviderConfig{SecretKey: "qa"})
+	require.NoError(t, err)
+	fake := provider.(*payment.Fake)
+	recorder := &taskRecorder{}
+	fake.Callbacks = recorder
+	fake.CallbackDelay = 2 * time.Second
+
+	var received payment.WebhookVerifyResult
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/webhooks/payment/fake", r.URL.Path)
+		body, _ := io.ReadAll(r.Body)
+		result, verifyErr := fake.VerifyWebhook(r, body)
+		require.NoError(t, verifyErr)
+		received = result
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	orderID := "5d0c1b2a-3e4f-4a5b-8c6d-7e8f9a0b1c2d"
+	resp, err := fake.CreateIntent(context.Background(), payment.IntentRequest{
+		OrderID:         orderID,
+		Amount:          150001,
+		ExpiresAtSec:    900,
+		CallbackBaseURL: server.URL,
+	})
+	require.NoError(t, err)
+	require.Equal(t, "fake", resp.Provider)
+	require.Equal(t, "FAKE-"+orderID, resp.Token)
+	require.Contains(t, resp.RedirectURL, "outcome=failed")
+	require.Len(t, recorder.tasks, 1)
+	require.Equal(t, payment.FakeCallbackTask(), recorder.tasks[0].Kind)
+	require.Equal(t, 2*time.Second, recorder.tasks[0].Delay)
+
+	worker := payment.FakeCallbackWorker{HTTP: server.Client()}
+	require.NoError(t, worker.Handle(context.Background(), recorder.tasks[0].Payload))
+	require.True(t, received.Valid)
+	require.Equal(t, orderID, received.OrderID)
+	require.Equal(t, int64(150001), received.Amount)
+	require.Equal(t, "FAILED", received.Status)
+
+	// Pending intents never call back.
+	_, err = fake.CreateIntent(context.Background(), payment.IntentRequest{OrderID: orderID, Amount: 150003, CallbackBaseURL: server.URL})
+	require.NoError(t, err)
+	require.Len(t, recorder.tasks, 1)
+}
+
+func TestFakeWebhookRejectsBadSignature(t *testing.T) {
+	fake := &payment.Fake{SecretKey: "qa"}
+	body := []byte(`{"order_id":"x","amount":1,"status":"PAID"}`)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/payment/fake", nil)
+	req.Header.Set(payment.FakeSignatureHeader, (&payment.Fake{SecretKey: "other"}).Sign(body))
+	result, err := fake.VerifyWebhook(req, body)
+	require.NoError(t, err)
+	require.False(t, result.Valid)
+
+	req.Header.Set(payment.FakeSignatureHeader, fake.Sign(body))
+	result, err = fake.VerifyWebhook(req, body)
+	require.NoError(t, err)
+	require.True(t, result.Valid)
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/noah-isme/backend-toko/internal/cart"
 	"github.com/noah-isme/backend-toko/internal/common"
 	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/resilience"
 )
 
 // Handler exposes HTTP endpoints for payment intents and status polling.
@@ -71,8 +72,16 @@ func (h *Handler) Intent(w http.ResponseWriter, r *http.Request) {
 	}
 	payment, err := h.Svc.CreateIntent(r.Context(), req.OrderID, order.PricingTotal, req.Channel, h.Svc.CallbackBaseURL)
 	if err != nil {
+		if resilience.IsTimeout(err) {
+			common.JSONError(w, http.StatusGatewayTimeout, common.CodeProviderTimeout, "payment provider timed out", nil)
+			return
+		}
+		if errors.Is(err, ErrCapabilityUnsupported) {
+			common.JSONError(w, http.StatusUnprocessableEntity, common.CodeCapabilityUnsupported, err.Error(), nil)
+			return
+		}
 		status := http.StatusBadRequest
-		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		if errors.Is(err, context.Canceled) {
 			status = http.StatusGatewayTimeout
 		}
 		common.JSONError(w, status, "INTENT_FAILED", err.Error(), nil)
@@ -39,6 +39,25 @@ func (m Midtrans) CreateIntent(_ context.Context, req IntentRequest) (IntentResp
 	}, nil
 }
 
+// Capabilities reports the methods and currency Midtrans SNAP accepts.
+func (m Midtrans) Capabilities() Capabilities {
+	return Capabilities{
+		Name:       "midtrans",
+		Refunds:    true,
+		Methods:    []string{"credit_card", "bank_transfer", "echannel", "gopay", "shopeepay", "qris", "cstore"},
+		Currencies: []string{"IDR"},
+	}
+}
+
+// Refund synthesises a refund reference without a network call, like
+// CreateIntent; the settled refund arrives as a "refund" notification.
+func (m Midtrans) Refund(_ context.Context, req RefundRequest) (RefundResponse, error) {
+	if strings.TrimSpace(req.OrderID) == "" {
+		return RefundResponse{}, errors.New("order id is required")
+	}
+	return RefundResponse{Provider: "midtrans", Reference: fmt.Sprintf("REFUND-%s", req.OrderID)}, nil
+}
+
 func (m Midtrans) snapHost() string {
 	host := strings.TrimSpace(m.BaseURL)
 	if host == "" {
@@ -58,6 +77,7 @@ func (m Midtrans) VerifyWebhook(_ *http.Request, body []byte) (WebhookVerifyResu
 		GrossAmount       string `json:"gross_amount"`
 		SignatureKey      string `json:"signature_key"`
 		TransactionStatus string `json:"transaction_status"`
+		TransactionID     string `json:"transaction_id"`
 	}
 	if err := json.Unmarshal(body, &payload); err != nil {
 		return WebhookVerifyResult{Valid: false, Err: err}, nil
@@ -80,11 +100,19 @@ func (m Midtrans) VerifyWebhook(_ *http.Request, body []byte) (WebhookVerifyResu
 
 	status := normaliseMidtransStatus(payload.TransactionStatus)
 
+	// Midtrans notifies each status change of a transaction and retries each
+	// notification unchanged.
+	var eventID string
+	if id := strings.TrimSpace(payload.TransactionID); id != "" {
+		eventID = id + ":" + strings.TrimSpace(payload.TransactionStatus)
+	}
+
 	return WebhookVerifyResult{
 		Valid:           true,
 		OrderID:         payload.OrderID,
 		Amount:          amount,
 		Status:          status,
+		EventID:         eventID,
 		ProviderPayload: body,
 	}, nil
 }
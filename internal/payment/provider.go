@@ -3,6 +3,7 @@ package payment
 import (
 	"context"
 	"net/http"
+	"time"
 )
 
 // IntentRequest captures the information required to open a payment intent with a provider.
@@ -24,16 +25,45 @@ type IntentResponse struct {
 
 // WebhookVerifyResult contains the normalised data extracted from a webhook notification after signature verification.
 type WebhookVerifyResult struct {
-	Valid           bool
-	OrderID         string
-	Amount          int64
-	Status          string
+	Valid   bool
+	OrderID string
+	Amount  int64
+	Status  string
+	// EventID identifies the notification at the provider; retries of the
+	// same notification carry the same value. Empty when the provider sends
+	// none, in which case the body identifies it.
+	EventID string
+	// SentAt is when the provider produced the notification, checked against
+	// the replay window. Only set when the signature covers the timestamp;
+	// zero otherwise, leaving the callback to dedup.
+	SentAt          time.Time
 	ProviderPayload []byte
 	Err             error
 }
 
+// RefundRequest asks a provider to return Amount of an order's payment.
+type RefundRequest struct {
+	OrderID     string
+	IntentToken string
+	Amount      int64
+	Reason      string
+}
+
+// RefundResponse identifies a refund accepted by a provider. The final
+// outcome arrives later through the provider's webhook.
+type RefundResponse struct {
+	Provider  string
+	Reference string
+}
+
 // Provider abstracts the operations required from an upstream payment provider.
 type Provider interface {
 	CreateIntent(ctx context.Context, req IntentRequest) (IntentResponse, error)
 	VerifyWebhook(r *http.Request, body []byte) (WebhookVerifyResult, error)
+	Capabilities() Capabilities
+}
+
+// Refunder is implemented by providers whose Capabilities report Refunds.
+type Refunder interface {
+	Refund(ctx context.Context, req RefundRequest) (RefundResponse, error)
 }
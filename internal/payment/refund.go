@@ -0,0 +1,131 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+
+	"github.com/noah-isme/backend-toko/internal/cart"
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/resilience"
+)
+
+// ErrNotRefundable is returned when the order has no settled payment to refund.
+var ErrNotRefundable = errors.New("payment is not refundable")
+
+// Refund asks the provider that took an order's payment to return amount, or
+// the full payment when amount is zero. The payment moves to REFUNDED when
+// the provider's webhook confirms it.
+func (s *Service) Refund(ctx context.Context, orderID string, amount int64, reason string) (RefundResponse, error) {
+	var zero RefundResponse
+	if s == nil || s.Q == nil {
+		return zero, errors.New("payment service not configured")
+	}
+	orderUUID, err := cart.ToUUID(orderID)
+	if err != nil {
+		return zero, fmt.Errorf("invalid order id: %w", err)
+	}
+	payment, err := s.Q.GetLatestPaymentByOrder(ctx, orderUUID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return zero, ErrNotRefundable
+		}
+		return zero, err
+	}
+	if payment.Status != dbgen.PaymentStatusPAID {
+		return zero, fmt.Errorf("%w: payment is %s", ErrNotRefundable, payment.Status)
+	}
+	paid := payment.Amount.Int64
+	if amount <= 0 {
+		amount = paid
+	}
+	if payment.Amount.Valid && amount > paid {
+		return zero, fmt.Errorf("%w: refund %d exceeds payment %d", ErrNotRefundable, amount, paid)
+	}
+
+	provider := s.providerFor(payment.Provider.String)
+	if provider == nil {
+		return zero, fmt.Errorf("%w: %q", ErrUnknownProvider, payment.Provider.String)
+	}
+	refunder, ok := provider.(Refunder)
+	if caps := provider.Capabilities(); !caps.Refunds || !ok {
+		return zero, fmt.Errorf("%w: %s does not support refunds", ErrCapabilityUnsupported, normaliseLabel(caps.Name))
+	}
+	callCtx, cancel := withTimeout(ctx, s.Timeout)
+	defer cancel()
+	return refunder.Refund(callCtx, RefundRequest{
+		OrderID:     orderID,
+		IntentToken: payment.IntentToken.String,
+		Amount:      amount,
+		Reason:      reason,
+	})
+}
+
+// providerFor returns the provider registered under name, falling back to the
+// active provider for payments recorded without one.
+func (s *Service) providerFor(name string) Provider {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if p, ok := s.Providers[key]; ok {
+		return p
+	}
+	if s.Provider != nil && (key == "" || strings.EqualFold(s.Provider.Capabilities().Name, key)) {
+		return s.Provider
+	}
+	return nil
+}
+
+type refundReq struct {
+	Amount int64  `json:"amount"`
+	Reason string `json:"reason"`
+}
+
+// Refund requests a refund of an order's payment on behalf of an admin.
+func (h *Handler) Refund(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Svc == nil {
+		common.JSONError(w, http.StatusInternalServerError, "PAYMENT_NOT_CONFIGURED", "payment handler unavailable", nil)
+		return
+	}
+	var req refundReq
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid body", nil)
+			return
+		}
+	}
+	if req.Amount < 0 {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "amount must not be negative", nil)
+		return
+	}
+	orderID := chi.URLParam(r, "id")
+	if _, err := cart.ToUUID(orderID); err != nil {
+		common.JSONError(w, http.StatusBadRequest, "INVALID_ORDER_ID", "invalid order identifier", nil)
+		return
+	}
+	resp, err := h.Svc.Refund(r.Context(), orderID, req.Amount, strings.TrimSpace(req.Reason))
+	if err != nil {
+		switch {
+		case errors.Is(err, ErrCapabilityUnsupported):
+			common.JSONError(w, http.StatusUnprocessableEntity, common.CodeCapabilityUnsupported, err.Error(), nil)
+		case errors.Is(err, ErrNotRefundable):
+			common.JSONError(w, http.StatusConflict, "INVALID_STATE", err.Error(), nil)
+		case errors.Is(err, ErrUnknownProvider):
+			common.JSONError(w, http.StatusNotFound, "PROVIDER_NOT_SUPPORTED", err.Error(), nil)
+		case resilience.IsTimeout(err):
+			common.JSONError(w, http.StatusGatewayTimeout, common.CodeProviderTimeout, "payment provider timed out", nil)
+		default:
+			common.JSONError(w, http.StatusBadGateway, common.CodeRefundFailed, err.Error(), nil)
+		}
+		return
+	}
+	common.JSON(w, http.StatusAccepted, map[string]any{"data": map[string]any{
+		"provider":  resp.Provider,
+		"reference": resp.Reference,
+	}})
+}
@@ -0,0 +1,149 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownProvider is returned by Open for a name nobody registered.
+var ErrUnknownProvider = errors.New("unknown payment provider")
+
+// ErrCapabilityUnsupported is returned when a provider cannot serve a request,
+// such as a refund, payment method, or currency it does not support.
+var ErrCapabilityUnsupported = errors.New("payment provider does not support this operation")
+
+// Capabilities describes what a provider supports so callers can branch on
+// behaviour rather than concrete type. Empty Methods or Currencies accept any
+// value.
+type Capabilities struct {
+	Name       string
+	Refunds    bool
+	Methods    []string
+	Currencies []string
+}
+
+// SupportsMethod reports whether the provider accepts the payment method.
+// An empty method lets the provider choose.
+func (c Capabilities) SupportsMethod(method string) bool {
+	return method == "" || containsFold(c.Methods, method)
+}
+
+// SupportsCurrency reports whether the provider settles in currency.
+func (c Capabilities) SupportsCurrency(currency string) bool {
+	return currency == "" || containsFold(c.Currencies, currency)
+}
+
+func containsFold(values []string, v string) bool {
+	if len(values) == 0 {
+		return true
+	}
+	v = strings.TrimSpace(v)
+	for _, candidate := range values {
+		if strings.EqualFold(candidate, v) {
+			return true
+		}
+	}
+	return false
+}
+
+// ProviderConfig carries the settings a factory needs to build a provider.
+type ProviderConfig struct {
+	SecretKey string
+	BaseURL   string
+	Sandbox   bool
+}
+
+// Factory builds a provider from its configuration.
+type Factory func(cfg ProviderConfig) (Provider, error)
+
+// Registry maps provider names to factories. Names are case-insensitive.
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]Factory
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{factories: map[string]Factory{}}
+}
+
+// Register adds a factory under name, replacing any earlier one.
+func (r *Registry) Register(name string, factory Factory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[strings.ToLower(strings.TrimSpace(name))] = factory
+}
+
+// Names lists the registered provider names in order.
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Open builds the provider registered under name.
+func (r *Registry) Open(name string, cfg ProviderConfig) (Provider, error) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	r.mu.RLock()
+	factory, ok := r.factories[key]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, name)
+	}
+	provider, err := factory(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("open payment provider %s: %w", key, err)
+	}
+	return provider, nil
+}
+
+// OpenAll builds every configured provider, keyed by lower-case name.
+func (r *Registry) OpenAll(configs map[string]ProviderConfig) (map[string]Provider, error) {
+	providers := make(map[string]Provider, len(configs))
+	for name, cfg := range configs {
+		provider, err := r.Open(name, cfg)
+		if err != nil {
+			return nil, err
+		}
+		providers[strings.ToLower(strings.TrimSpace(name))] = provider
+	}
+	return providers, nil
+}
+
+var defaultRegistry = NewRegistry()
+
+func init() {
+	Register("midtrans", func(cfg ProviderConfig) (Provider, error) {
+		return Midtrans{ServerKey: cfg.SecretKey, BaseURL: cfg.BaseURL, Sandbox: cfg.Sandbox}, nil
+	})
+	Register("xendit", func(cfg ProviderConfig) (Provider, error) {
+		return Xendit{SecretKey: cfg.SecretKey, BaseURL: cfg.BaseURL}, nil
+	})
+	Register(FakeProviderName, func(cfg ProviderConfig) (Provider, error) {
+		return &Fake{SecretKey: cfg.SecretKey, BaseURL: cfg.BaseURL}, nil
+	})
+}
+
+// Register adds a factory to the default registry. Providers register
+// themselves from an init function so wiring only needs configuration.
+func Register(name string, factory Factory) {
+	defaultRegistry.Register(name, factory)
+}
+
+// Open builds a provider from the default registry.
+func Open(name string, cfg ProviderConfig) (Provider, error) {
+	return defaultRegistry.Open(name, cfg)
+}
+
+// OpenAll builds every configured provider from the default registry.
+func OpenAll(configs map[string]ProviderConfig) (map[string]Provider, error) {
+	return defaultRegistry.OpenAll(configs)
+}
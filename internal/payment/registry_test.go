@@ -0,0 +1,147 @@
+package payment_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/payment"
+)
+
+// cashOnly is a provider without refunds that only takes IDR bank transfers.
+type cashOnly struct{}
+
+func (cashOnly) CreateIntent(context.Context, payment.IntentRequest) (payment.IntentResponse, error) {
+	return payment.IntentResponse{Provider: "cash"}, nil
+}
+
+func (cashOnly) VerifyWebhook(*http.Request, []byte) (payment.WebhookVerifyResult, error) {
+	return payment.WebhookVerifyResult{}, nil
+}
+
+func (cashOnly) Capabilities() payment.Capabilities {
+	return payment.Capabilities{Name: "cash", Methods: []string{"bank_transfer"}, Currencies: []string{"IDR"}}
+}
+
+func TestRegistryOpensRegisteredProviders(t *testing.T) {
+	reg := payment.NewRegistry()
+	reg.Register("Cash", func(payment.ProviderConfig) (payment.Provider, error) { return cashOnly{}, nil })
+	reg.Register("broken", func(payment.ProviderConfig) (payment.Provider, error) { return nil, errors.New("missing key") })
+	require.Equal(t, []string{"broken", "cash"}, reg.Names())
+
+	providers, err := reg.OpenAll(map[string]payment.ProviderConfig{"CASH": {}})
+	require.NoError(t, err)
+	require.Equal(t, "cash", providers["cash"].Capabilities().Name)
+
+	_, err = reg.Open("doku", payment.ProviderConfig{})
+	require.ErrorIs(t, err, payment.ErrUnknownProvider)
+	_, err = reg.Open("broken", payment.ProviderConfig{})
+	require.ErrorContains(t, err, "missing key")
+}
+
+func TestDefaultRegistryBuildsBuiltins(t *testing.T) {
+	providers, err := payment.OpenAll(map[string]payment.ProviderConfig{
+		"midtrans": {SecretKey: "server-key", Sandbox: true},
+		"xendit":   {SecretKey: "secret"},
+	})
+	require.NoError(t, err)
+	require.Equal(t, payment.Midtrans{ServerKey: "server-key", Sandbox: true}, providers["midtrans"])
+	for name, p := range providers {
+		caps := p.Capabilities()
+		require.Equal(t, name, caps.Name)
+		require.True(t, caps.Refunds)
+		require.Implements(t, (*payment.Refunder)(nil), p)
+	}
+}
+
+func TestCapabilitiesSupport(t *testing.T) {
+	caps := cashOnly{}.Capabilities()
+	require.True(t, caps.SupportsMethod(""))
+	require.True(t, caps.SupportsMethod("BANK_TRANSFER"))
+	require.False(t, caps.SupportsMethod("qris"))
+	require.True(t, caps.SupportsCurrency("idr"))
+	require.False(t, caps.SupportsCurrency("USD"))
+	require.True(t, payment.Capabilities{}.SupportsCurrency("USD"), "empty lists accept anything")
+}
+
+func TestCreateIntentRejectsUnsupportedCapabilities(t *testing.T) {
+	svc := &payment.Service{Q: dbgen.New(&paymentDB{}), Provider: cashOnly{}, Currency: "IDR"}
+	orderID := "5d0c1b2a-3e4f-4a5b-8c6d-7e8f9a0b1c2d"
+
+	_, err := svc.CreateIntent(context.Background(), orderID, 0, "qris", "")
+	require.ErrorIs(t, err, payment.ErrCapabilityUnsupported)
+
+	svc.Currency = "USD"
+	_, err = svc.CreateIntent(context.Background(), orderID, 0, "bank_transfer", "")
+	require.ErrorIs(t, err, payment.ErrCapabilityUnsupported)
+}
+
+func TestRefundChecksProviderCapability(t *testing.T) {
+	orderID := "5d0c1b2a-3e4f-4a5b-8c6d-7e8f9a0b1c2d"
+	db := &paymentDB{provider: "cash", status: dbgen.PaymentStatusPAID, amount: 150000}
+	svc := &payment.Service{
+		Q:         dbgen.New(db),
+		Provider:  payment.Midtrans{},
+		Providers: map[string]payment.Provider{"cash": cashOnly{}, "midtrans": payment.Midtrans{}},
+	}
+
+	// The payment was taken by a provider without refunds.
+	_, err := svc.Refund(context.Background(), orderID, 0, "")
+	require.ErrorIs(t, err, payment.ErrCapabilityUnsupported)
+
+	db.provider = "midtrans"
+	resp, err := svc.Refund(context.Background(), orderID, 50000, "damaged")
+	require.NoError(t, err)
+	require.Equal(t, "midtrans", resp.Provider)
+
+	_, err = svc.Refund(context.Background(), orderID, 200000, "")
+	require.ErrorIs(t, err, payment.ErrNotRefundable)
+
+	db.status = dbgen.PaymentStatusPENDING
+	_, err = svc.Refund(context.Background(), orderID, 0, "")
+	require.ErrorIs(t, err, payment.ErrNotRefundable)
+}
+
+// paymentDB answers GetLatestPaymentByOrder with one payment and fails
+// everything else.
+type paymentDB struct {
+	provider string
+	status   dbgen.PaymentStatus
+	amount   int64
+}
+
+func (d *paymentDB) Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, errors.New("unexpected exec")
+}
+
+func (d *paymentDB) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	return nil, errors.New("unexpected query")
+}
+
+func (d *paymentDB) QueryRow(context.Context, string, ...interface{}) pgx.Row {
+	return paymentRow{d}
+}
+
+func (d *paymentDB) SendBatch(context.Context, *pgx.Batch) pgx.BatchResults {
+	return nil
+}
+
+type paymentRow struct{ db *paymentDB }
+
+// Scan fills the columns of GetLatestPaymentByOrder the refund path reads.
+func (r paymentRow) Scan(dest ...any) error {
+	if len(dest) != 12 {
+		return errors.New("unexpected row shape")
+	}
+	*dest[2].(*pgtype.Text) = pgtype.Text{String: r.db.provider, Valid: true}
+	*dest[3].(*dbgen.PaymentStatus) = r.db.status
+	*dest[10].(*pgtype.Int8) = pgtype.Int8{Int64: r.db.amount, Valid: true}
+	return nil
+}
@@ -0,0 +1,37 @@
+package payment_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/payment"
+)
+
+func TestMidtransIgnoresUnsignedTimestamps(t *testing.T) {
+	const key = "server-key"
+	mac := hmac.New(sha512.New, []byte(key))
+	mac.Write([]byte("order-1" + "200" + "15000.00" + key))
+	body, err := json.Marshal(map[string]string{
+		"order_id":           "order-1",
+		"status_code":        "200",
+		"gross_amount":       "15000.00",
+		"signature_key":      hex.EncodeToString(mac.Sum(nil)),
+		"transaction_status": "refund",
+		"transaction_id":     "trx-1",
+		"transaction_time":   "2026-03-01 09:00:00",
+		"settlement_time":    "2026-03-01 09:05:00",
+	})
+	require.NoError(t, err)
+
+	res, err := payment.Midtrans{ServerKey: key}.VerifyWebhook(nil, body)
+	require.NoError(t, err)
+	require.True(t, res.Valid)
+	// A refund arrives long after settlement; the unsigned times must not
+	// push it outside the replay window.
+	require.True(t, res.SentAt.IsZero(), res.SentAt)
+}
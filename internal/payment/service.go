@@ -25,6 +25,29 @@ type Service struct {
 	Provider        Provider
 	IntentTTL       time.Duration
 	CallbackBaseURL string
+	// Timeout bounds each provider call; zero leaves only the request's own
+	// deadline.
+	Timeout time.Duration
+	// Providers holds every opened provider by name so refunds go back
+	// through the provider that took the payment.
+	Providers map[string]Provider
+	// Currency is checked against the active provider's capabilities.
+	Currency string
+	// Now overrides the clock used to expire and reuse intents; nil means
+	// time.Now.
+	Now func() time.Time
+}
+
+func (s *Service) now() time.Time {
+	if s != nil && s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// intentLive reports whether a pending intent may still be handed out again.
+func (s *Service) intentLive(expiresAt pgtype.Timestamptz) bool {
+	return !expiresAt.Valid || expiresAt.Time.After(s.now())
 }
 
 // CreateIntent creates (or reuses) a payment intent for the provided order.
@@ -37,7 +60,8 @@ func (s *Service) CreateIntent(ctx context.Context, orderID string, amount int64
 	defer span.End()
 
 	start := time.Now()
-	providerName := inferProviderName(s.Provider)
+	caps := s.Provider.Capabilities()
+	providerName := normaliseLabel(caps.Name)
 	channelLabel := normaliseLabel(channel)
 	result := "error"
 	defer func() {
@@ -51,6 +75,12 @@ func (s *Service) CreateIntent(ctx context.Context, orderID string, amount int64
 			obs.PaymentIntentTotal.WithLabelValues(providerName, channelLabel, result).Inc()
 		}
 	}()
+	if !caps.SupportsMethod(channel) {
+		return zero, fmt.Errorf("%w: %s does not accept channel %q", ErrCapabilityUnsupported, providerName, channel)
+	}
+	if !caps.SupportsCurrency(s.Currency) {
+		return zero, fmt.Errorf("%w: %s does not settle %s", ErrCapabilityUnsupported, providerName, s.Currency)
+	}
 	if cbBase == "" {
 		cbBase = s.CallbackBaseURL
 	}
@@ -81,7 +111,7 @@ func (s *Service) CreateIntent(ctx context.Context, orderID string, amount int64
 			return zero, errors.New("order already paid")
 		}
 		if existing.Status == dbgen.PaymentStatusPENDING {
-			if !existing.ExpiresAt.Valid || existing.ExpiresAt.Time.After(time.Now()) {
+			if s.intentLive(existing.ExpiresAt) {
 				if existing.Provider.Valid {
 					providerName = normaliseLabel(existing.Provider.String)
 				}
@@ -114,16 +144,16 @@ func (s *Service) CreateIntent(ctx context.Context, orderID string, amount int64
 		ExpiresAtSec:    int(ttl.Seconds()),
 		CallbackBaseURL: cbBase,
 	}
-	resp, err := s.Provider.CreateIntent(ctx, req)
+	callCtx, cancel := withTimeout(ctx, s.Timeout)
+	resp, err := s.Provider.CreateIntent(callCtx, req)
+	cancel()
 	if err != nil {
 		span.RecordError(err)
 		return zero, err
 	}
-	providerName = resp.Provider
-	if providerName == "" {
-		providerName = inferProviderName(s.Provider)
+	if resp.Provider != "" {
+		providerName = normaliseLabel(resp.Provider)
 	}
-	providerName = normaliseLabel(providerName)
 	result = "success"
 	payload := toJSON(map[string]any{
 		"request":  req,
@@ -135,7 +165,7 @@ func (s *Service) CreateIntent(ctx context.Context, orderID string, amount int64
 		expiresAt.Time = time.Unix(resp.ExpiresAt, 0)
 	} else {
 		expiresAt.Valid = true
-		expiresAt.Time = time.Now().Add(ttl)
+		expiresAt.Time = s.now().Add(ttl)
 	}
 	payment, err := s.Q.CreatePayment(ctx, dbgen.CreatePaymentParams{
 		OrderID:         orderUUID,
@@ -204,17 +234,6 @@ func (s *Service) ConsolidatedStatus(ctx context.Context, orderID string) (strin
 	}
 }
 
-func inferProviderName(p Provider) string {
-	switch p.(type) {
-	case Midtrans:
-		return "midtrans"
-	case Xendit:
-		return "xendit"
-	default:
-		return ""
-	}
-}
-
 func normaliseLabel(value string) string {
 	trimmed := strings.TrimSpace(strings.ToLower(value))
 	if trimmed == "" {
@@ -227,3 +246,10 @@ func toJSON(v any) []byte {
 	b, _ := json.Marshal(v)
 	return b
 }
+
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
@@ -0,0 +1,22 @@
+package payment
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/clock"
+)
+
+func TestIntentLiveFollowsClock(t *testing.T) {
+	fake := clock.NewFake(time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC))
+	svc := &Service{Now: fake.Now}
+	expiresAt := pgtype.Timestamptz{Time: fake.Now().Add(15 * time.Minute), Valid: true}
+
+	require.True(t, svc.intentLive(expiresAt))
+	fake.Advance(15 * time.Minute)
+	require.False(t, svc.intentLive(expiresAt), "an intent is expired at its ExpiresAt")
+	require.True(t, svc.intentLive(pgtype.Timestamptz{}), "intents without expiry stay live")
+}
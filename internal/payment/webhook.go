@@ -4,17 +4,14 @@ import (
 	"bytes"
 	"context"
 	"errors"
-	"fmt"
 	"io"
 	"net/http"
 	"strings"
-	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
-	redis "github.com/redis/go-redis/v9"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
@@ -25,20 +22,25 @@ import (
 	"github.com/noah-isme/backend-toko/internal/common"
 	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
 	"github.com/noah-isme/backend-toko/internal/events"
+	"github.com/noah-isme/backend-toko/internal/inbound"
 	"github.com/noah-isme/backend-toko/internal/obs"
 )
 
 // Webhook handles payment provider callbacks, including signature verification and settlement.
 type Webhook struct {
-	Q            *dbgen.Queries
-	Pool         *pgxpool.Pool
-	Providers    map[string]Provider
-	Replay       *redis.Client
-	ReplayTTL    time.Duration
+	Q         *dbgen.Queries
+	Pool      *pgxpool.Pool
+	Providers map[string]Provider
+	// Inbound, when set, runs each provider event at most once; retries of
+	// a processed event are acknowledged with 200.
+	Inbound      *inbound.Consumer
 	Voucher      VoucherSettler
 	Events       *events.Bus
 	CatalogCache *catalog.Cache
 	Analytics    *analytics.Service
+	// ReleaseVoucherOnCancel gives an order's voucher use back when a failed
+	// or expired payment cancels it.
+	ReleaseVoucherOnCancel bool
 }
 
 // VoucherSettler records voucher usage as part of order settlement.
@@ -90,19 +92,43 @@ func (h Webhook) Handle(w http.ResponseWriter, r *http.Request) {
 		common.JSONError(w, http.StatusUnauthorized, "INVALID_SIGNATURE", "signature verification failed", nil)
 		return
 	}
-	if h.Replay != nil && h.ReplayTTL > 0 {
-		key := fmt.Sprintf("wh:%s:%s", providerKey, common.Sha256Hex(string(body)))
-		ok, err := h.Replay.SetNX(r.Context(), key, "1", h.ReplayTTL).Result()
-		if err != nil {
+	source := "payment:" + providerKey
+	var (
+		key      string
+		finished bool
+	)
+	if h.Inbound != nil {
+		if err := h.Inbound.CheckTimestamp(source, result.SentAt); err != nil {
 			span.RecordError(err)
-			common.JSONError(w, http.StatusInternalServerError, "REPLAY_STORE_ERROR", err.Error(), nil)
+			outcome = "expired"
+			common.JSONError(w, http.StatusBadRequest, common.CodeWebhookExpired, err.Error(), nil)
 			return
 		}
-		if !ok {
-			span.AddEvent("payment webhook replay prevented")
-			common.JSONError(w, http.StatusConflict, "REPLAY", "duplicate webhook", nil)
+		key = inbound.Key(result.EventID, body)
+		if err := h.Inbound.Claim(r.Context(), source, key); err != nil {
+			switch {
+			case errors.Is(err, inbound.ErrDuplicate):
+				span.AddEvent("payment webhook already processed")
+				outcome = "duplicate"
+				common.JSON(w, http.StatusOK, map[string]any{"data": map[string]any{"duplicate": true}})
+			case errors.Is(err, inbound.ErrInFlight):
+				span.AddEvent("payment webhook already in flight")
+				common.JSONError(w, http.StatusConflict, "REPLAY", "webhook is being processed; retry later", nil)
+			default:
+				span.RecordError(err)
+				common.JSONError(w, http.StatusInternalServerError, "REPLAY_STORE_ERROR", err.Error(), nil)
+			}
 			return
 		}
+		defer func() {
+			if finished {
+				return
+			}
+			// Failed deliveries are released so the provider's retry runs them.
+			if err := h.Inbound.Finish(context.WithoutCancel(r.Context()), source, key, false); err != nil {
+				span.RecordError(err)
+			}
+		}()
 	}
 	if result.ProviderPayload == nil {
 		result.ProviderPayload = body
@@ -184,13 +210,43 @@ func (h Webhook) Handle(w http.ResponseWriter, r *http.Request) {
 				common.JSONError(w, http.StatusInternalServerError, "ORDER_ITEMS_ERROR", err.Error(), nil)
 				return
 			}
-			productSlugs := make(map[string]struct{})
+			variantIDs := make([]pgtype.UUID, 0, len(items))
 			for _, it := range items {
 				if it.VariantID.Valid {
-					if err := q.DecrementVariantStock(ctx, dbgen.DecrementVariantStockParams{Qty: int32(it.Qty), ID: it.VariantID}); err != nil {
-						span.RecordError(err)
-						common.JSONError(w, http.StatusInternalServerError, "STOCK_UPDATE_ERROR", err.Error(), nil)
-						return
+					variantIDs = append(variantIDs, it.VariantID)
+				}
+			}
+			components := make(map[pgtype.UUID][]dbgen.ListBundleComponentsByVariantIDsRow)
+			if len(variantIDs) > 0 {
+				rows, err := q.ListBundleComponentsByVariantIDs(ctx, variantIDs)
+				if err != nil {
+					span.RecordError(err)
+					common.JSONError(w, http.StatusInternalServerError, "ORDER_ITEMS_ERROR", err.Error(), nil)
+					return
+				}
+				for _, row := range rows {
+					components[row.BundleVariantID] = append(components[row.BundleVariantID], row)
+				}
+			}
+			productSlugs := make(map[string]struct{})
+			for _, it := range items {
+				// Preorders are sold ahead of stock, so settlement leaves stock alone.
+				if it.VariantID.Valid && !it.Preorder {
+					decrements := []dbgen.DecrementVariantStockParams{{Qty: int32(it.Qty), ID: it.VariantID}}
+					// Bundles ship their components, so stock comes off each component.
+					if parts, ok := components[it.VariantID]; ok {
+						decrements = decrements[:0]
+						for _, c := range parts {
+							decrements = append(decrements, dbgen.DecrementVariantStockParams{Qty: int32(it.Qty) * c.Qty, ID: c.ComponentVariantID})
+							productSlugs[c.Slug] = struct{}{}
+						}
+					}
+					for _, arg := range decrements {
+						if err := q.DecrementVariantStock(ctx, arg); err != nil {
+							span.RecordError(err)
+							common.JSONError(w, http.StatusInternalServerError, "STOCK_UPDATE_ERROR", err.Error(), nil)
+							return
+						}
 					}
 				}
 				if slug := strings.TrimSpace(it.Slug); slug != "" {
@@ -223,10 +279,26 @@ func (h Webhook) Handle(w http.ResponseWriter, r *http.Request) {
 			if err := q.UpdateOrderStatus(ctx, dbgen.UpdateOrderStatusParams{ID: order.ID, Status: dbgen.OrderStatusCANCELED}); err == nil {
 				orderCanceled = true
 				order.Status = dbgen.OrderStatusCANCELED
+				if h.ReleaseVoucherOnCancel {
+					if _, err := q.ReleaseVoucherUsageByOrder(ctx, order.ID); err != nil {
+						span.RecordError(err)
+						common.JSONError(w, http.StatusInternalServerError, "VOUCHER_RELEASE_FAILED", err.Error(), nil)
+						return
+					}
+				}
 			}
 		}
 	}
 
+	if h.Inbound != nil {
+		// Completed in the settlement transaction, so a crash after commit
+		// cannot leave the event claimable and settle it a second time.
+		if err := h.Inbound.WithQueries(q).Finish(ctx, source, key, true); err != nil {
+			span.RecordError(err)
+			common.JSONError(w, http.StatusInternalServerError, "REPLAY_STORE_ERROR", err.Error(), nil)
+			return
+		}
+	}
 	if tx != nil {
 		if err := tx.Commit(ctx); err != nil {
 			span.RecordError(err)
@@ -234,17 +306,18 @@ func (h Webhook) Handle(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 	}
+	finished = true
 	if h.Events != nil {
-		payload := map[string]any{
-			"orderId":   cart.UUIDString(order.ID),
-			"paymentId": cart.UUIDString(payment.ID),
-			"status":    string(newStatus),
+		payload := &events.OrderPaymentPayload{
+			OrderID:   cart.UUIDString(order.ID),
+			PaymentID: cart.UUIDString(payment.ID),
+			Status:    string(newStatus),
 		}
 		if order.UserID.Valid {
-			payload["userId"] = cart.UUIDString(order.UserID)
+			payload.UserID = cart.UUIDString(order.UserID)
 		}
 		if user, err := h.Q.GetUserByID(ctx, order.UserID); err == nil && user.Email != "" {
-			payload["email"] = user.Email
+			payload.Email = user.Email
 		}
 		switch newStatus {
 		case dbgen.PaymentStatusPAID:
@@ -288,6 +361,8 @@ func normaliseWebhookStatus(status string) dbgen.PaymentStatus {
 		return dbgen.PaymentStatusFAILED
 	case "EXPIRED":
 		return dbgen.PaymentStatusEXPIRED
+	case "REFUNDED":
+		return dbgen.PaymentStatusREFUNDED
 	default:
 		return dbgen.PaymentStatusPENDING
 	}
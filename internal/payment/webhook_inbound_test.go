@@ -0,0 +1,119 @@
+package payment_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/inbound"
+	"github.com/noah-isme/backend-toko/internal/payment"
+)
+
+// webhookDB records the sqlc queries run against it and serves canned rows
+// keyed by query name.
+type webhookDB struct {
+	rows     map[string]any
+	execErrs map[string]error
+	calls    []string
+}
+
+func (d *webhookDB) record(sql string) string {
+	name := strings.Fields(strings.TrimPrefix(sql, "-- name:"))[0]
+	d.calls = append(d.calls, name)
+	return name
+}
+
+func (d *webhookDB) Exec(_ context.Context, sql string, _ ...interface{}) (pgconn.CommandTag, error) {
+	return pgconn.CommandTag{}, d.execErrs[d.record(sql)]
+}
+
+func (d *webhookDB) Query(context.Context, string, ...interface{}) (pgx.Rows, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (d *webhookDB) QueryRow(_ context.Context, sql string, _ ...interface{}) pgx.Row {
+	return cannedRow{item: d.rows[d.record(sql)]}
+}
+
+func (d *webhookDB) SendBatch(context.Context, *pgx.Batch) pgx.BatchResults {
+	return nil
+}
+
+func serveFakeWebhook(t *testing.T, db *webhookDB) *httptest.ResponseRecorder {
+	t.Helper()
+	provider, err := payment.Open(payment.FakeProviderName, payment.ProviderConfig{SecretKey: "qa"})
+	require.NoError(t, err)
+	q := dbgen.New(db)
+	h := payment.Webhook{
+		Q:         q,
+		Providers: map[string]payment.Provider{payment.FakeProviderName: provider},
+		Inbound:   &inbound.Consumer{Q: q},
+	}
+	router := chi.NewRouter()
+	router.Post("/webhooks/payment/{provider}", h.Handle)
+
+	body := `{"order_id":"01000000-0000-0000-0000-000000000000","status":"PENDING"}`
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/payment/fake", strings.NewReader(body))
+	req.Header.Set(payment.FakeSignatureHeader, provider.(*payment.Fake).Sign([]byte(body)))
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func pendingPaymentDB() *webhookDB {
+	orderID := pgtype.UUID{Bytes: [16]byte{1}, Valid: true}
+	return &webhookDB{rows: map[string]any{
+		"ClaimInboundWebhook":     pgtype.Timestamptz{},
+		"GetLatestPaymentByOrder": dbgen.GetLatestPaymentByOrderRow{ID: pgtype.UUID{Bytes: [16]byte{2}, Valid: true}, OrderID: orderID, Status: dbgen.PaymentStatusPENDING},
+		"GetOrderByID":            dbgen.Order{ID: orderID, Status: dbgen.OrderStatusPENDINGPAYMENT},
+	}}
+}
+
+// cannedRow scans a struct's fields in declaration order, or a single value.
+type cannedRow struct{ item any }
+
+func (r cannedRow) Scan(dest ...any) error {
+	if r.item == nil {
+		return pgx.ErrNoRows
+	}
+	src := reflect.ValueOf(r.item)
+	if src.Kind() != reflect.Struct || len(dest) == 1 {
+		reflect.ValueOf(dest[0]).Elem().Set(src)
+		return nil
+	}
+	for i, d := range dest {
+		reflect.ValueOf(d).Elem().Set(src.Field(i))
+	}
+	return nil
+}
+
+func TestWebhookCompletesInboundEventWithSettlement(t *testing.T) {
+	db := pendingPaymentDB()
+	rec := serveFakeWebhook(t, db)
+
+	require.Equal(t, http.StatusNoContent, rec.Code, rec.Body.String())
+	require.Contains(t, db.calls, "CompleteInboundWebhook")
+	require.NotContains(t, db.calls, "ReleaseInboundWebhook")
+}
+
+func TestWebhookFailsWhenInboundEventCannotBeCompleted(t *testing.T) {
+	db := pendingPaymentDB()
+	db.execErrs = map[string]error{"CompleteInboundWebhook": errors.New("connection reset")}
+	rec := serveFakeWebhook(t, db)
+
+	// The settlement rolls back with the completion, so the claim is
+	// released for the provider's retry instead of being acknowledged.
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	require.Equal(t, "ReleaseInboundWebhook", db.calls[len(db.calls)-1])
+}
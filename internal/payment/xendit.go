@@ -38,6 +38,24 @@ func (x Xendit) CreateIntent(_ context.Context, req IntentRequest) (IntentRespon
 	}, nil
 }
 
+// Capabilities reports the methods and currencies Xendit invoices accept.
+func (x Xendit) Capabilities() Capabilities {
+	return Capabilities{
+		Name:       "xendit",
+		Refunds:    true,
+		Methods:    []string{"credit_card", "bank_transfer", "ewallet", "qris", "retail_outlet"},
+		Currencies: []string{"IDR", "PHP"},
+	}
+}
+
+// Refund builds a deterministic refund reference for testing purposes.
+func (x Xendit) Refund(_ context.Context, req RefundRequest) (RefundResponse, error) {
+	if strings.TrimSpace(req.OrderID) == "" {
+		return RefundResponse{}, errors.New("order id is required")
+	}
+	return RefundResponse{Provider: "xendit", Reference: fmt.Sprintf("xendit-refund-%s", req.OrderID)}, nil
+}
+
 // VerifyWebhook validates the callback signature and normalises the payload.
 func (x Xendit) VerifyWebhook(r *http.Request, body []byte) (WebhookVerifyResult, error) {
 	expected := x.computeSignature(body)
@@ -47,6 +65,7 @@ func (x Xendit) VerifyWebhook(r *http.Request, body []byte) (WebhookVerifyResult
 	}
 
 	var payload struct {
+		ID         string      `json:"id"`
 		ExternalID string      `json:"external_id"`
 		Amount     json.Number `json:"amount"`
 		Status     string      `json:"status"`
@@ -69,11 +88,20 @@ func (x Xendit) VerifyWebhook(r *http.Request, body []byte) (WebhookVerifyResult
 
 	status := normaliseXenditStatus(payload.Status)
 
+	// Newer Xendit callbacks carry a webhook-id that stays the same across
+	// retries; invoice callbacks only identify the invoice, whose status
+	// changes over time.
+	eventID := strings.TrimSpace(r.Header.Get("webhook-id"))
+	if id := strings.TrimSpace(payload.ID); eventID == "" && id != "" {
+		eventID = id + ":" + strings.TrimSpace(payload.Status)
+	}
+
 	return WebhookVerifyResult{
 		Valid:           true,
 		OrderID:         orderID,
 		Amount:          amount,
 		Status:          status,
+		EventID:         eventID,
 		ProviderPayload: body,
 	}, nil
 }
@@ -0,0 +1,67 @@
+package pricing
+
+import (
+	"math/bits"
+	"sort"
+)
+
+// Allocate splits amount across weights in proportion to each weight using
+// the largest-remainder method: every share is first rounded down, then the
+// units lost to rounding go one each to the shares with the largest
+// remainders, earlier shares winning ties. The result sums exactly to amount
+// and no share exceeds its weight. An amount above the weights' sum is capped
+// at it; negative weights count as zero, and if every weight is zero the
+// result is all zeros.
+func Allocate(amount Money, weights []Money) []Money {
+	shares := make([]Money, len(weights))
+	if amount <= 0 {
+		return shares
+	}
+	var total uint64
+	for _, w := range weights {
+		if w > 0 {
+			total += uint64(w)
+		}
+	}
+	if total == 0 {
+		return shares
+	}
+	if uint64(amount) > total {
+		amount = Money(total)
+	}
+
+	remainders := make([]uint64, len(weights))
+	var allocated Money
+	for i, w := range weights {
+		if w <= 0 {
+			continue
+		}
+		// amount*w can overflow int64 for large carts, so divide the
+		// 128-bit product; amount <= total keeps the quotient in range.
+		hi, lo := bits.Mul64(uint64(amount), uint64(w))
+		q, r := bits.Div64(hi, lo, total)
+		shares[i] = Money(q)
+		remainders[i] = r
+		allocated += Money(q)
+	}
+
+	left := amount - allocated
+	if left <= 0 {
+		return shares
+	}
+	order := make([]int, 0, len(weights))
+	for i, w := range weights {
+		if w > 0 {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]] > remainders[order[b]]
+	})
+	// Fewer units are left than there are weighted shares.
+	for i := 0; left > 0; i++ {
+		shares[order[i]]++
+		left--
+	}
+	return shares
+}
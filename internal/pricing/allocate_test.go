@@ -0,0 +1,69 @@
+package pricing
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func sum(values []Money) Money {
+	var total Money
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func TestAllocateSumsExactlyToAmount(t *testing.T) {
+	cases := []struct {
+		name    string
+		amount  Money
+		weights []Money
+		want    []Money
+	}{
+		{"even split", 300, []Money{100, 100, 100}, []Money{100, 100, 100}},
+		{"thirds", 100, []Money{100, 100, 100}, []Money{34, 33, 33}},
+		{"largest remainder wins", 10, []Money{333, 333, 334}, []Money{3, 3, 4}},
+		{"proportional", 1000, []Money{5000, 3000, 2000}, []Money{500, 300, 200}},
+		{"excluded lines get nothing", 7, []Money{10, 0, 10}, []Money{4, 0, 3}},
+		{"capped at weights", 50, []Money{10, 20}, []Money{10, 20}},
+		{"zero amount", 0, []Money{10, 20}, []Money{0, 0}},
+		{"no weights", 10, []Money{0, 0}, []Money{0, 0}},
+		{"huge values do not overflow", math.MaxInt64 / 2, []Money{math.MaxInt64 / 4, math.MaxInt64 / 4}, []Money{math.MaxInt64 / 4, math.MaxInt64 / 4}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Allocate(tc.amount, tc.weights)
+			require.Equal(t, tc.want, got)
+			for i, share := range got {
+				require.LessOrEqual(t, share, max(tc.weights[i], 0))
+			}
+		})
+	}
+}
+
+func TestAllocateAwkwardRatios(t *testing.T) {
+	weights := []Money{9999, 1, 12345, 777, 31}
+	for amount := Money(0); amount <= sum(weights); amount += 97 {
+		require.Equal(t, amount, sum(Allocate(amount, weights)), "amount %d", amount)
+	}
+}
+
+func TestComputeAllocatesDiscountToEligibleItems(t *testing.T) {
+	items := []Item{
+		{Qty: 2, UnitPrice: 33333},
+		{Qty: 1, UnitPrice: 50000, Excluded: true},
+		{Qty: 3, UnitPrice: 11111},
+	}
+	s := Compute(items, 10001, 1100, 0, RoundHalfUp)
+	require.Len(t, s.Allocations, 3)
+	require.Equal(t, s.Discount, sum(s.Allocations))
+	require.Zero(t, s.Allocations[1])
+	require.Equal(t, []Money{6667, 0, 3334}, s.Allocations)
+
+	// A discount larger than the eligible lines spills over to every line.
+	s = Compute(items, 120000, 0, 0, RoundFloor)
+	require.Equal(t, s.Discount, sum(s.Allocations))
+	require.Positive(t, s.Allocations[1])
+}
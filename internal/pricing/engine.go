@@ -7,19 +7,28 @@ type Money = int64
 type Item struct {
 	Qty       int
 	UnitPrice Money
+	// Excluded lines fall outside the voucher's scope and absorb none of the
+	// discount.
+	Excluded bool
 }
 
-// Summary aggregates computed pricing components.
+// Summary aggregates computed pricing components. Each component is rounded
+// once and Total is summed from the rounded values, so the displayed
+// components always add up: Total == Subtotal - Discount + Tax + Shipping.
 type Summary struct {
 	Subtotal Money
 	Discount Money
 	Tax      Money
 	Shipping Money
 	Total    Money
+	// Allocations holds the share of Discount each item absorbed, in item
+	// order; the shares sum exactly to Discount.
+	Allocations []Money
 }
 
-// Compute calculates cart totals given the provided inputs.
-func Compute(items []Item, voucher Money, taxBps int, shipping Money) Summary {
+// Compute calculates cart totals given the provided inputs. Tax is rounded
+// with rounding; the voucher amount is already in minor units.
+func Compute(items []Item, voucher Money, taxBps int, shipping Money, rounding Rounding) Summary {
 	var subtotal Money
 	for _, it := range items {
 		if it.Qty <= 0 {
@@ -34,13 +43,37 @@ func Compute(items []Item, voucher Money, taxBps int, shipping Money) Summary {
 	if taxable < 0 {
 		taxable = 0
 	}
-	tax := (taxable * Money(taxBps)) / 10000
+	tax := rounding.ApplyBps(taxable, taxBps)
 	total := taxable + tax + shipping
 	return Summary{
-		Subtotal: subtotal,
-		Discount: voucher,
-		Tax:      tax,
-		Shipping: shipping,
-		Total:    total,
+		Subtotal:    subtotal,
+		Discount:    voucher,
+		Tax:         tax,
+		Shipping:    shipping,
+		Total:       total,
+		Allocations: allocateDiscount(items, voucher),
 	}
 }
+
+// allocateDiscount spreads discount over the eligible lines by line subtotal.
+// When the eligible lines cannot absorb the whole discount it is spread over
+// every line.
+func allocateDiscount(items []Item, discount Money) []Money {
+	weights := make([]Money, len(items))
+	all := make([]Money, len(items))
+	var eligible Money
+	for i, it := range items {
+		if it.Qty <= 0 {
+			continue
+		}
+		all[i] = Money(it.Qty) * it.UnitPrice
+		if !it.Excluded {
+			weights[i] = all[i]
+			eligible += weights[i]
+		}
+	}
+	if eligible < discount {
+		weights = all
+	}
+	return Allocate(discount, weights)
+}
@@ -0,0 +1,87 @@
+package pricing
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Rounding selects how a division that does not come out even in minor units
+// is rounded. Jurisdictions differ, so it is configured rather than fixed.
+type Rounding string
+
+const (
+	// RoundFloor rounds toward negative infinity. It is the zero value's
+	// behaviour and matches the original integer division.
+	RoundFloor Rounding = "floor"
+	// RoundCeil rounds toward positive infinity.
+	RoundCeil Rounding = "ceil"
+	// RoundHalfUp rounds to the nearest unit, ties away from zero.
+	RoundHalfUp Rounding = "half_up"
+	// RoundHalfEven rounds to the nearest unit, ties to the even neighbour
+	// (banker's rounding).
+	RoundHalfEven Rounding = "half_even"
+)
+
+// ParseRounding reads a rounding mode name; hyphens and case are ignored and
+// an empty value means RoundFloor.
+func ParseRounding(value string) (Rounding, error) {
+	mode := Rounding(strings.ReplaceAll(strings.ToLower(strings.TrimSpace(value)), "-", "_"))
+	switch mode {
+	case "":
+		return RoundFloor, nil
+	case RoundFloor, RoundCeil, RoundHalfUp, RoundHalfEven:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("unknown rounding mode %q, want floor, ceil, half_up, or half_even", value)
+	}
+}
+
+// Div returns num/den rounded with the mode. den must not be zero.
+func (r Rounding) Div(num, den int64) int64 {
+	q, rem := num/den, num%den
+	if rem == 0 {
+		return q
+	}
+	// Go truncates toward zero; away moves one unit away from zero.
+	negative := (num < 0) != (den < 0)
+	away := q + 1
+	if negative {
+		away = q - 1
+	}
+	twiceRem, absDen := abs(rem)*2, abs(den)
+	switch r {
+	case RoundCeil:
+		if !negative {
+			return away
+		}
+		return q
+	case RoundHalfUp:
+		if twiceRem >= absDen {
+			return away
+		}
+		return q
+	case RoundHalfEven:
+		if twiceRem > absDen || (twiceRem == absDen && q%2 != 0) {
+			return away
+		}
+		return q
+	default:
+		if negative {
+			return away
+		}
+		return q
+	}
+}
+
+// ApplyBps returns amount scaled by bps basis points, rounded with the mode.
+// Tax and percentage discounts both go through it.
+func (r Rounding) ApplyBps(amount Money, bps int) Money {
+	return r.Div(amount*Money(bps), 10000)
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
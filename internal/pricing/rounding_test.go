@@ -0,0 +1,75 @@
+package pricing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyBpsRoundingModes(t *testing.T) {
+	// 11% tax; each amount lands the exact result on a different fraction.
+	cases := []struct {
+		name   string
+		amount Money
+		want   map[Rounding]Money
+	}{
+		{"tie above odd", 1250, map[Rounding]Money{RoundFloor: 137, RoundCeil: 138, RoundHalfUp: 138, RoundHalfEven: 138}},    // 137.5
+		{"tie above even", 1150, map[Rounding]Money{RoundFloor: 126, RoundCeil: 127, RoundHalfUp: 127, RoundHalfEven: 126}},   // 126.5
+		{"just above whole", 1001, map[Rounding]Money{RoundFloor: 110, RoundCeil: 111, RoundHalfUp: 110, RoundHalfEven: 110}}, // 110.11
+		{"just below whole", 1009, map[Rounding]Money{RoundFloor: 110, RoundCeil: 111, RoundHalfUp: 111, RoundHalfEven: 111}}, // 110.99
+		{"exact", 1000, map[Rounding]Money{RoundFloor: 110, RoundCeil: 110, RoundHalfUp: 110, RoundHalfEven: 110}},
+		{"zero", 0, map[Rounding]Money{RoundFloor: 0, RoundCeil: 0, RoundHalfUp: 0, RoundHalfEven: 0}},
+	}
+	for _, tc := range cases {
+		for mode, want := range tc.want {
+			t.Run(tc.name+"/"+string(mode), func(t *testing.T) {
+				require.Equal(t, want, mode.ApplyBps(tc.amount, 1100))
+			})
+		}
+	}
+}
+
+func TestDivRoundsNegativeValues(t *testing.T) {
+	cases := []struct {
+		mode Rounding
+		want int64
+	}{
+		{RoundFloor, -3},
+		{RoundCeil, -2},
+		{RoundHalfUp, -3},
+		{RoundHalfEven, -2},
+		{"", -3},
+	}
+	for _, tc := range cases {
+		require.Equal(t, tc.want, tc.mode.Div(-5, 2), "mode %q", tc.mode)
+	}
+}
+
+func TestParseRounding(t *testing.T) {
+	cases := map[string]Rounding{
+		"":          RoundFloor,
+		"floor":     RoundFloor,
+		"CEIL":      RoundCeil,
+		"half-up":   RoundHalfUp,
+		"half_even": RoundHalfEven,
+	}
+	for in, want := range cases {
+		got, err := ParseRounding(in)
+		require.NoError(t, err, in)
+		require.Equal(t, want, got, in)
+	}
+	_, err := ParseRounding("bankers")
+	require.Error(t, err)
+}
+
+func TestComputeComponentsSumToTotal(t *testing.T) {
+	items := []Item{{Qty: 3, UnitPrice: 3333}, {Qty: 1, UnitPrice: 1251}}
+	for _, mode := range []Rounding{RoundFloor, RoundCeil, RoundHalfUp, RoundHalfEven} {
+		t.Run(string(mode), func(t *testing.T) {
+			s := Compute(items, 1000, 1100, 15000, mode)
+			require.Equal(t, Money(11250), s.Subtotal)
+			require.Equal(t, mode.ApplyBps(s.Subtotal-s.Discount, 1100), s.Tax)
+			require.Equal(t, s.Subtotal-s.Discount+s.Tax+s.Shipping, s.Total)
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package queue
+
+import (
+	"sync"
+	"time"
+)
+
+// AdaptiveConfig tunes the AIMD concurrency controller used by Worker when
+// adaptive mode is enabled. Successful jobs that finish under LatencyTarget
+// grow the limit additively; failures or slow jobs shrink it multiplicatively.
+type AdaptiveConfig struct {
+	Min            int
+	Max            int
+	LatencyTarget  time.Duration
+	DecreaseFactor float64
+}
+
+// AdaptiveLimiter bounds in-flight jobs using additive-increase /
+// multiplicative-decrease between the configured minimum and maximum.
+type AdaptiveLimiter struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	limit    float64
+	inFlight int
+	min      int
+	max      int
+	target   time.Duration
+	decrease float64
+}
+
+// NewAdaptiveLimiter builds a limiter starting at the configured minimum.
+func NewAdaptiveLimiter(cfg AdaptiveConfig) *AdaptiveLimiter {
+	min := cfg.Min
+	if min <= 0 {
+		min = 1
+	}
+	max := cfg.Max
+	if max < min {
+		max = min
+	}
+	target := cfg.LatencyTarget
+	if target <= 0 {
+		target = 2 * time.Second
+	}
+	decrease := cfg.DecreaseFactor
+	if decrease <= 0 || decrease >= 1 {
+		decrease = 0.5
+	}
+	l := &AdaptiveLimiter{limit: float64(min), min: min, max: max, target: target, decrease: decrease}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+// Limit reports the current effective concurrency.
+func (l *AdaptiveLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+// Acquire blocks until an in-flight slot is available under the current limit.
+func (l *AdaptiveLimiter) Acquire() {
+	l.mu.Lock()
+	for l.inFlight >= int(l.limit) {
+		l.cond.Wait()
+	}
+	l.inFlight++
+	l.mu.Unlock()
+}
+
+// Release frees a slot and feeds the job outcome back into the controller.
+func (l *AdaptiveLimiter) Release(latency time.Duration, err error) {
+	l.mu.Lock()
+	l.inFlight--
+	if err != nil || latency > l.target {
+		l.limit *= l.decrease
+		if l.limit < float64(l.min) {
+			l.limit = float64(l.min)
+		}
+	} else {
+		l.limit += 1 / l.limit
+		if l.limit > float64(l.max) {
+			l.limit = float64(l.max)
+		}
+	}
+	l.mu.Unlock()
+	l.cond.Broadcast()
+}
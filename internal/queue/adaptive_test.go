@@ -0,0 +1,69 @@
+package queue_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/queue"
+)
+
+func TestAdaptiveLimiterIncreasesOnFastSuccess(t *testing.T) {
+	l := queue.NewAdaptiveLimiter(queue.AdaptiveConfig{Min: 1, Max: 4, LatencyTarget: time.Second})
+	require.Equal(t, 1, l.Limit())
+
+	for i := 0; i < 20; i++ {
+		l.Acquire()
+		l.Release(10*time.Millisecond, nil)
+	}
+	require.Equal(t, 4, l.Limit(), "limit should grow to the configured max")
+}
+
+func TestAdaptiveLimiterBacksOffOnErrorsAndSlowJobs(t *testing.T) {
+	l := queue.NewAdaptiveLimiter(queue.AdaptiveConfig{Min: 2, Max: 16, LatencyTarget: 100 * time.Millisecond})
+	for i := 0; i < 200; i++ {
+		l.Acquire()
+		l.Release(time.Millisecond, nil)
+	}
+	require.Equal(t, 16, l.Limit())
+
+	l.Acquire()
+	l.Release(time.Millisecond, errors.New("boom"))
+	require.Equal(t, 8, l.Limit())
+
+	l.Acquire()
+	l.Release(time.Second, nil)
+	require.Equal(t, 4, l.Limit())
+
+	for i := 0; i < 5; i++ {
+		l.Acquire()
+		l.Release(time.Millisecond, errors.New("boom"))
+	}
+	require.Equal(t, 2, l.Limit(), "limit must not drop below the configured min")
+}
+
+func TestAdaptiveLimiterBlocksAtLimit(t *testing.T) {
+	l := queue.NewAdaptiveLimiter(queue.AdaptiveConfig{Min: 1, Max: 1})
+	l.Acquire()
+
+	acquired := make(chan struct{})
+	go func() {
+		l.Acquire()
+		close(acquired)
+	}()
+
+	select {
+	case <-acquired:
+		t.Fatal("second acquire should block while the only slot is held")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	l.Release(time.Millisecond, nil)
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("second acquire should proceed once the slot is released")
+	}
+}
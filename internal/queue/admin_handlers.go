@@ -5,7 +5,6 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
@@ -33,7 +32,7 @@ func (h *AdminHandler) ListDLQ(w http.ResponseWriter, r *http.Request) {
 	}
 	ctx := r.Context()
 	kind := strings.TrimSpace(r.URL.Query().Get("kind"))
-	limit, offset := parsePagination(r, h.pageSize())
+	limit, offset := common.ParseOffsetPagination(r, h.pageSize(), 200)
 
 	storeKind := kind
 	if storeKind != "" {
@@ -73,14 +72,11 @@ func (h *AdminHandler) ListDLQ(w http.ResponseWriter, r *http.Request) {
 		items = append(items, item)
 	}
 
-	resp := map[string]any{
-		"data":  items,
-		"total": total,
-	}
+	var extra map[string]any
 	if storeKind != "" {
-		resp["kind"] = storeKind
+		extra = map[string]any{"kind": storeKind}
 	}
-	common.JSON(w, http.StatusOK, resp)
+	common.WritePage(w, r, "dlq-entries", items, limit, offset, total, extra)
 }
 
 // ReplayDLQ re-enqueues DLQ entries either by ID list or batch by kind.
@@ -174,7 +170,7 @@ func (h *AdminHandler) Stats(w http.ResponseWriter, r *http.Request) {
 	}
 	ctx := r.Context()
 	queueKey := h.Queue.queueKey(storeKind)
-	worker := Worker{R: h.Queue.R, Prefix: h.Queue.Prefix}
+	worker := Worker{R: h.Queue.R, Prefix: h.Queue.Prefix, Now: h.Queue.Now}
 	processingKey := worker.processingKey(storeKind)
 
 	ready, err := h.Queue.R.ZCard(ctx, queueKey).Result()
@@ -197,11 +193,27 @@ func (h *AdminHandler) Stats(w http.ResponseWriter, r *http.Request) {
 	oldest, err := h.Queue.R.ZRangeWithScores(ctx, queueKey, 0, 0).Result()
 	if err == nil && len(oldest) > 0 {
 		ts := time.Unix(0, int64(oldest[0].Score))
-		if ts.Before(time.Now()) {
-			lagMillis = time.Since(ts).Milliseconds()
+		if now := h.Queue.now(); ts.Before(now) {
+			lagMillis = now.Sub(ts).Milliseconds()
 		}
 	}
 
+	window, err := growthWindow(r)
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+		return
+	}
+	now := time.Now()
+	summaries, err := h.Store.QueueDlqSummary(ctx, storeKind, now.Add(-window))
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", err.Error(), nil)
+		return
+	}
+	summary := newDLQKindSummary(DLQSummary{Kind: storeKind}, window, now)
+	if len(summaries) > 0 {
+		summary = newDLQKindSummary(summaries[0], window, now)
+	}
+
 	h.updateDepthMetric(ctx, storeKind)
 	h.updateDLQMetric(ctx, storeKind)
 
@@ -216,10 +228,101 @@ func (h *AdminHandler) Stats(w http.ResponseWriter, r *http.Request) {
 		"dlq":                dlq,
 		"oldest_lag_ms":      lagMillis,
 		"visibility_timeout": visibility.Seconds(),
+		"dlq_oldest_age_sec": summary.OldestAgeSec,
+		"dlq_growth":         summary.Growth,
 	}
 	common.JSON(w, http.StatusOK, resp)
 }
 
+// Bounds for the DLQ growth window accepted by Stats and DLQSummary.
+const (
+	defaultDLQGrowthWindow = time.Hour
+	minDLQGrowthWindow     = time.Minute
+	maxDLQGrowthWindow     = 24 * time.Hour
+)
+
+type dlqGrowth struct {
+	WindowSec int64   `json:"window_sec"`
+	Added     int64   `json:"added"`
+	PerMinute float64 `json:"per_minute"`
+}
+
+type dlqKindSummary struct {
+	Kind         string    `json:"kind"`
+	DLQ          int64     `json:"dlq"`
+	OldestAgeSec int64     `json:"oldest_age_sec"`
+	Growth       dlqGrowth `json:"growth"`
+}
+
+func newDLQKindSummary(summary DLQSummary, window time.Duration, now time.Time) dlqKindSummary {
+	out := dlqKindSummary{
+		Kind: summary.Kind,
+		DLQ:  summary.Count,
+		Growth: dlqGrowth{
+			WindowSec: int64(window.Seconds()),
+			Added:     summary.Recent,
+			PerMinute: float64(summary.Recent) / window.Minutes(),
+		},
+	}
+	if summary.Count > 0 && !summary.Oldest.IsZero() {
+		out.OldestAgeSec = int64(now.Sub(summary.Oldest).Seconds())
+	}
+	return out
+}
+
+// growthWindow reads the optional window query parameter, a Go duration such
+// as "15m", clamped to between one minute and a day.
+func growthWindow(r *http.Request) (time.Duration, error) {
+	raw := strings.TrimSpace(r.URL.Query().Get("window"))
+	if raw == "" {
+		return defaultDLQGrowthWindow, nil
+	}
+	window, err := time.ParseDuration(raw)
+	if err != nil || window <= 0 {
+		return 0, errors.New("window must be a positive duration such as 15m")
+	}
+	return min(max(window, minDLQGrowthWindow), maxDLQGrowthWindow), nil
+}
+
+// DLQSummary reports DLQ size, oldest entry age, and recent growth for every
+// kind with entries, plus totals across kinds.
+func (h *AdminHandler) DLQSummary(w http.ResponseWriter, r *http.Request) {
+	if h == nil || h.Store == nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "queue store unavailable", nil)
+		return
+	}
+	window, err := growthWindow(r)
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+		return
+	}
+	now := time.Now()
+	summaries, err := h.Store.QueueDlqSummary(r.Context(), "", now.Add(-window))
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", err.Error(), nil)
+		return
+	}
+	kinds := make([]dlqKindSummary, 0, len(summaries))
+	total := dlqKindSummary{Growth: dlqGrowth{WindowSec: int64(window.Seconds())}}
+	for _, summary := range summaries {
+		item := newDLQKindSummary(summary, window, now)
+		kinds = append(kinds, item)
+		total.DLQ += item.DLQ
+		total.Growth.Added += item.Growth.Added
+		total.OldestAgeSec = max(total.OldestAgeSec, item.OldestAgeSec)
+		if QueueDLQSize != nil {
+			QueueDLQSize.WithLabelValues(queueLabel(summary.Kind)).Set(float64(summary.Count))
+		}
+	}
+	total.Growth.PerMinute = float64(total.Growth.Added) / window.Minutes()
+	common.JSON(w, http.StatusOK, map[string]any{
+		"data":           kinds,
+		"total":          total.DLQ,
+		"oldest_age_sec": total.OldestAgeSec,
+		"growth":         total.Growth,
+	})
+}
+
 func (h *AdminHandler) requeueEntry(ctx context.Context, entry DLQEntry) error {
 	msg, err := decodeMessage(string(entry.Payload))
 	if err != nil {
@@ -277,25 +380,6 @@ func (h *AdminHandler) pageSize() int {
 	return h.PageSize
 }
 
-func parsePagination(r *http.Request, defaultLimit int) (limit, offset int) {
-	limit = defaultLimit
-	offset = 0
-	if limit <= 0 {
-		limit = 50
-	}
-	if v := strings.TrimSpace(r.URL.Query().Get("limit")); v != "" {
-		if parsed, err := strconv.Atoi(v); err == nil && parsed > 0 && parsed <= 200 {
-			limit = parsed
-		}
-	}
-	if v := strings.TrimSpace(r.URL.Query().Get("offset")); v != "" {
-		if parsed, err := strconv.Atoi(v); err == nil && parsed >= 0 {
-			offset = parsed
-		}
-	}
-	return
-}
-
 func uniqueStrings(values []string) []string {
 	if len(values) == 0 {
 		return nil
@@ -86,3 +86,76 @@ func TestDLQReplay(t *testing.T) {
 	_, err = store.GetQueueDlq(context.Background(), id)
 	require.ErrorIs(t, err, sql.ErrNoRows)
 }
+
+func TestDLQSummaryReportsAgeAndGrowth(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	store := newMemoryStore()
+	handler := queue.AdminHandler{Store: store, Queue: queue.Enqueuer{R: client}}
+	now := time.Now()
+	for _, entry := range []queue.DLQEntry{
+		{Kind: "webhook-delivery", CreatedAt: now.Add(-3 * time.Hour)},
+		{Kind: "webhook-delivery", CreatedAt: now.Add(-10 * time.Minute)},
+		{Kind: "webhook-delivery", CreatedAt: now.Add(-time.Minute)},
+		{Kind: "email-send", CreatedAt: now.Add(-2 * time.Minute)},
+	} {
+		_, err := store.InsertQueueDlq(context.Background(), entry)
+		require.NoError(t, err)
+	}
+
+	rr := httptest.NewRecorder()
+	handler.DLQSummary(rr, httptest.NewRequest(http.MethodGet, "/admin/queue/dlq/summary?window=30m", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var summary struct {
+		Data []struct {
+			Kind         string `json:"kind"`
+			DLQ          int64  `json:"dlq"`
+			OldestAgeSec int64  `json:"oldest_age_sec"`
+			Growth       struct {
+				WindowSec int64   `json:"window_sec"`
+				Added     int64   `json:"added"`
+				PerMinute float64 `json:"per_minute"`
+			} `json:"growth"`
+		} `json:"data"`
+		Total        int64 `json:"total"`
+		OldestAgeSec int64 `json:"oldest_age_sec"`
+		Growth       struct {
+			Added int64 `json:"added"`
+		} `json:"growth"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &summary))
+	require.Equal(t, int64(4), summary.Total)
+	require.Equal(t, int64(3), summary.Growth.Added)
+	require.InDelta(t, 3*time.Hour.Seconds(), summary.OldestAgeSec, 5)
+	require.Len(t, summary.Data, 2)
+	require.Equal(t, "email-send", summary.Data[0].Kind)
+	require.Equal(t, "webhook-delivery", summary.Data[1].Kind)
+	require.Equal(t, int64(3), summary.Data[1].DLQ)
+	require.Equal(t, int64(2), summary.Data[1].Growth.Added)
+	require.Equal(t, int64(1800), summary.Data[1].Growth.WindowSec)
+	require.InDelta(t, 2.0/30, summary.Data[1].Growth.PerMinute, 1e-9)
+
+	rr = httptest.NewRecorder()
+	handler.Stats(rr, httptest.NewRequest(http.MethodGet, "/admin/queue/stats?kind=webhook-delivery&window=30m", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+	var stats struct {
+		DLQ          int64 `json:"dlq"`
+		OldestAgeSec int64 `json:"dlq_oldest_age_sec"`
+		Growth       struct {
+			Added int64 `json:"added"`
+		} `json:"dlq_growth"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &stats))
+	require.Equal(t, int64(3), stats.DLQ)
+	require.Equal(t, int64(2), stats.Growth.Added)
+	require.InDelta(t, 3*time.Hour.Seconds(), stats.OldestAgeSec, 5)
+
+	rr = httptest.NewRecorder()
+	handler.DLQSummary(rr, httptest.NewRequest(http.MethodGet, "/admin/queue/dlq/summary?window=soon", nil))
+	require.Equal(t, http.StatusBadRequest, rr.Code)
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	miniredis "github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	redis "github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog"
 	"github.com/stretchr/testify/require"
@@ -49,11 +50,13 @@ func TestMoveToDLQAfterMaxAttempts(t *testing.T) {
 		close(done)
 	}()
 
+	inserts := queue.QueueDLQInsertsTotal.WithLabelValues("webhook")
+	before := testutil.ToFloat64(inserts)
 	require.NoError(t, enq.Enqueue(context.Background(), queue.Task{Kind: "webhook", Payload: []byte("body"), IdempotencyKey: "dlq1", MaxAttempts: 2}))
 
 	require.Eventually(t, func() bool {
 		count, err := store.CountQueueDlq(context.Background(), "webhook")
-		return err == nil && count == 1
+		return err == nil && count == 1 && testutil.ToFloat64(inserts) == before+1
 	}, 2*time.Second, 20*time.Millisecond)
 
 	snapshot := store.snapshot()
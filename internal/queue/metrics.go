@@ -24,8 +24,22 @@ var (
 		},
 		[]string{"kind"},
 	)
+	QueueDLQInsertsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "queue_dlq_inserts_total",
+			Help: "Total tasks written to the DLQ per kind",
+		},
+		[]string{"kind"},
+	)
+	QueueWorkerConcurrency = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "queue_worker_concurrency",
+			Help: "Effective number of concurrent jobs allowed per kind",
+		},
+		[]string{"kind"},
+	)
 )
 
 func init() {
-	prometheus.MustRegister(QueueDepth, QueueProcessedTotal, QueueDLQSize)
+	prometheus.MustRegister(QueueDepth, QueueProcessedTotal, QueueDLQSize, QueueDLQInsertsTotal, QueueWorkerConcurrency)
 }
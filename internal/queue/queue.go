@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -33,6 +34,9 @@ type Enqueuer struct {
 	Prefix      string
 	DedupTTL    time.Duration
 	MaxAttempts int
+	// Now overrides the clock used to schedule delayed tasks; nil means
+	// time.Now.
+	Now func() time.Time
 }
 
 // Enqueue inserts the task into the queue. If an idempotency key is supplied the
@@ -62,7 +66,7 @@ func (e Enqueuer) Enqueue(ctx context.Context, t Task) error {
 			msg.MaxAttempts = 10
 		}
 	}
-	availableAt := time.Now().Add(t.Delay)
+	availableAt := e.now().Add(t.Delay)
 	msg.AvailableAt = availableAt.UnixNano()
 
 	if msg.Key != "" {
@@ -97,6 +101,13 @@ func (e Enqueuer) Enqueue(ctx context.Context, t Task) error {
 	return nil
 }
 
+func (e Enqueuer) now() time.Time {
+	if e.Now != nil {
+		return e.Now()
+	}
+	return time.Now()
+}
+
 func (e Enqueuer) queueKey(kind string) string {
 	if e.Prefix == "" {
 		return fmt.Sprintf("queue:%s", kind)
@@ -142,10 +153,26 @@ type Worker struct {
 	HeartbeatInterval time.Duration
 	SoftDeadline      time.Duration
 	Logger            *zerolog.Logger
+	// Adaptive switches the worker from the static Concurrency to an AIMD
+	// controller. A zero Max inherits Concurrency as the upper bound.
+	Adaptive *AdaptiveConfig
+	// Now overrides the clock that decides when tasks are due, retried, and
+	// redelivered; nil means time.Now. It should match the Enqueuer's.
+	Now func() time.Time
+	// ShutdownGrace is how long in-flight tasks may keep running once the
+	// context is cancelled, capped at the visibility timeout. Zero cancels
+	// them immediately.
+	ShutdownGrace time.Duration
+	// Beat, when set, is called on every iteration of the dequeue loop so a
+	// process heartbeat can tell a hung loop from an idle one.
+	Beat func()
 }
 
 // Run starts processing tasks until the context is cancelled. Active tasks are
-// tracked in a processing set to enable redelivery when workers crash.
+// tracked in a processing set to enable redelivery when workers crash. On
+// cancellation it stops dequeuing and gives in-flight tasks ShutdownGrace to
+// finish before cancelling them; abandoned tasks are redelivered once their
+// visibility timeout passes.
 func (w Worker) Run(ctx context.Context) error {
 	if w.R == nil {
 		return errors.New("queue: worker redis client not configured")
@@ -185,7 +212,24 @@ func (w Worker) Run(ctx context.Context) error {
 	}
 
 	sem := make(chan struct{}, concurrency)
+	var adaptive *AdaptiveLimiter
+	if w.Adaptive != nil {
+		cfg := *w.Adaptive
+		if cfg.Max <= 0 {
+			cfg.Max = concurrency
+		}
+		adaptive = NewAdaptiveLimiter(cfg)
+		w.updateConcurrency(kind, adaptive.Limit())
+	} else {
+		w.updateConcurrency(kind, concurrency)
+	}
 	var wg sync.WaitGroup
+	var inFlight atomic.Int64
+	// Tasks run on their own context so shutdown can stop dequeuing without
+	// cutting the tasks already running.
+	jobsCtx, cancelJobs := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancelJobs()
+	grace := min(max(w.ShutdownGrace, 0), visibility)
 	processingKey := w.processingKey(kind)
 	queueKey := w.queueKey(kind)
 	retryBase := w.RetryBase
@@ -201,10 +245,14 @@ func (w Worker) Run(ctx context.Context) error {
 	logger := w.logger().With().Str("queue_kind", kind).Logger()
 
 	for {
+		if w.Beat != nil {
+			w.Beat()
+		}
 		select {
 		case <-ctx.Done():
-			logger.Info().Msg("worker shutdown initiated")
-			wg.Wait()
+			logger.Info().Int64("in_flight", inFlight.Load()).Dur("grace", grace).Msg("worker shutdown initiated")
+			completed, abandoned := drain(&wg, &inFlight, grace, cancelJobs)
+			logger.Info().Int64("completed", completed).Int64("abandoned", abandoned).Msg("worker drained")
 			_ = w.requeueExpired(context.Background(), processingKey, queueKey)
 			w.updateDepth(context.Background(), queueKey, kind)
 			w.updateDLQSize(context.Background(), kind)
@@ -227,7 +275,8 @@ func (w Worker) Run(ctx context.Context) error {
 		res, err := w.R.ZPopMin(ctx, queueKey, 1).Result()
 		if err != nil {
 			if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
-				return nil
+				// Shutting down: drain the in-flight tasks above.
+				continue
 			}
 			if err == redis.Nil {
 				time.Sleep(100 * time.Millisecond)
@@ -247,7 +296,7 @@ func (w Worker) Run(ctx context.Context) error {
 		if err != nil {
 			continue
 		}
-		now := time.Now().UnixNano()
+		now := w.now().UnixNano()
 		if msg.AvailableAt > now {
 			// not due yet, push back and wait
 			_ = w.R.ZAdd(ctx, queueKey, redis.Z{Score: float64(msg.AvailableAt), Member: member})
@@ -265,22 +314,42 @@ func (w Worker) Run(ctx context.Context) error {
 			continue
 		}
 		raw := string(rawBytes)
-		deadline := time.Now().Add(visibility).UnixNano()
+		deadline := w.now().Add(visibility).UnixNano()
 		if err := w.R.ZAdd(ctx, processingKey, redis.Z{Score: float64(deadline), Member: raw}).Err(); err != nil {
+			if ctx.Err() != nil {
+				// Shutdown interrupted the hand-off; put the task back.
+				_ = w.R.ZAdd(jobsCtx, queueKey, redis.Z{Score: float64(msg.AvailableAt), Member: member}).Err()
+				continue
+			}
 			return err
 		}
 
 		w.updateDepth(ctx, queueKey, kind)
 
-		sem <- struct{}{}
+		if adaptive != nil {
+			adaptive.Acquire()
+		} else {
+			sem <- struct{}{}
+		}
 		wg.Add(1)
+		inFlight.Add(1)
 		go func(raw string, m taskMessage) {
-			defer func() { <-sem }()
+			started := time.Now()
+			var err error
+			defer inFlight.Add(-1)
+			defer func() {
+				if adaptive != nil {
+					adaptive.Release(time.Since(started), err)
+					w.updateConcurrency(kind, adaptive.Limit())
+					return
+				}
+				<-sem
+			}()
 			defer wg.Done()
-			jobCtx, cancel := context.WithTimeout(ctx, softDeadline)
+			jobCtx, cancel := context.WithTimeout(jobsCtx, softDeadline)
 			defer cancel()
 			task := Task{Kind: kind, Payload: m.Payload, IdempotencyKey: m.Key, MaxAttempts: m.MaxAttempts, Attempt: m.Attempt}
-			err := w.Handler(jobCtx, task)
+			err = w.Handler(jobCtx, task)
 			if err != nil {
 				if err != context.Canceled && err != context.DeadlineExceeded {
 					logger.Warn().Err(err).Str("status", "retry").Msg("job failed")
@@ -293,6 +362,31 @@ func (w Worker) Run(ctx context.Context) error {
 	}
 }
 
+// drain waits up to grace for the in-flight tasks, then cancels the ones still
+// running and waits for them to return. It reports how many tasks finished
+// within the grace period and how many were abandoned.
+func drain(wg *sync.WaitGroup, inFlight *atomic.Int64, grace time.Duration, cancelJobs context.CancelFunc) (completed, abandoned int64) {
+	started := inFlight.Load()
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	if grace > 0 {
+		timer := time.NewTimer(grace)
+		defer timer.Stop()
+		select {
+		case <-done:
+			return started, 0
+		case <-timer.C:
+		}
+	}
+	abandoned = inFlight.Load()
+	cancelJobs()
+	<-done
+	return started - abandoned, abandoned
+}
+
 func (w Worker) handleFailure(ctx context.Context, queueKey, processingKey, raw string, msg taskMessage, base time.Duration, cause error) {
 	if raw != "" {
 		_ = w.R.ZRem(ctx, processingKey, raw)
@@ -313,7 +407,7 @@ func (w Worker) handleFailure(ctx context.Context, queueKey, processingKey, raw
 		return
 	}
 	delay := resilience.Backoff(base, msg.Attempt, w.RetryJitter)
-	msg.AvailableAt = time.Now().Add(delay).UnixNano()
+	msg.AvailableAt = w.now().Add(delay).UnixNano()
 	rawBytes, err := json.Marshal(msg)
 	if err != nil {
 		return
@@ -340,7 +434,7 @@ func (w Worker) ack(ctx context.Context, processingKey, raw string, msg taskMess
 }
 
 func (w Worker) requeueExpired(ctx context.Context, processingKey, queueKey string) error {
-	now := float64(time.Now().UnixNano())
+	now := float64(w.now().UnixNano())
 	due, err := w.R.ZRangeByScore(ctx, processingKey, &redis.ZRangeBy{Min: "-inf", Max: fmt.Sprintf("%f", now)}).Result()
 	if err != nil && err != redis.Nil {
 		return err
@@ -351,7 +445,7 @@ func (w Worker) requeueExpired(ctx context.Context, processingKey, queueKey stri
 			continue
 		}
 		_ = w.R.ZRem(ctx, processingKey, raw).Err()
-		msg.AvailableAt = time.Now().UnixNano()
+		msg.AvailableAt = w.now().UnixNano()
 		encoded, err := json.Marshal(msg)
 		if err != nil {
 			continue
@@ -361,6 +455,13 @@ func (w Worker) requeueExpired(ctx context.Context, processingKey, queueKey stri
 	return nil
 }
 
+func (w Worker) now() time.Time {
+	if w.Now != nil {
+		return w.Now()
+	}
+	return time.Now()
+}
+
 func (w Worker) queueKey(kind string) string {
 	if w.Prefix == "" {
 		return fmt.Sprintf("queue:%s", kind)
@@ -412,6 +513,13 @@ func (w Worker) updateDepth(ctx context.Context, queueKey, kind string) {
 	QueueDepth.WithLabelValues(queueLabel(kind)).Set(float64(depth))
 }
 
+func (w Worker) updateConcurrency(kind string, limit int) {
+	if QueueWorkerConcurrency == nil {
+		return
+	}
+	QueueWorkerConcurrency.WithLabelValues(queueLabel(kind)).Set(float64(limit))
+}
+
 func (w Worker) updateDLQSize(ctx context.Context, kind string) {
 	if QueueDLQSize == nil || w.Store == nil {
 		return
@@ -441,6 +549,10 @@ func (w Worker) pushToDLQ(ctx context.Context, raw string, msg taskMessage) {
 		if !errors.Is(err, ErrStoreUnavailable) {
 			w.logger().Error().Err(err).Str("queue_kind", queueLabel(msg.Kind)).Msg("insert dlq entry failed")
 		}
+		return
+	}
+	if QueueDLQInsertsTotal != nil {
+		QueueDLQInsertsTotal.WithLabelValues(queueLabel(msg.Kind)).Inc()
 	}
 }
 
@@ -11,6 +11,7 @@ import (
 	redis "github.com/redis/go-redis/v9"
 	"github.com/stretchr/testify/require"
 
+	"github.com/noah-isme/backend-toko/internal/clock"
 	"github.com/noah-isme/backend-toko/internal/queue"
 )
 
@@ -98,3 +99,40 @@ func TestWorkerRetries(t *testing.T) {
 
 	require.GreaterOrEqual(t, attempts.Load(), int32(2))
 }
+
+func TestDelayedTaskWaitsForClock(t *testing.T) {
+	mr, err := miniredis.Run()
+	require.NoError(t, err)
+	t.Cleanup(mr.Close)
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	fake := clock.NewFake(time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC))
+	enq := queue.Enqueuer{R: client, Prefix: "delay", Now: fake.Now}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.NoError(t, enq.Enqueue(ctx, queue.Task{Kind: "demo", Payload: []byte("later"), IdempotencyKey: "d1", Delay: time.Hour}))
+
+	var runs atomic.Int32
+	worker := queue.Worker{
+		R:                 client,
+		Prefix:            "delay",
+		Kind:              "demo",
+		Concurrency:       1,
+		VisibilityTimeout: time.Second,
+		Now:               fake.Now,
+		Handler: func(ctx context.Context, task queue.Task) error {
+			runs.Add(1)
+			return nil
+		},
+	}
+	go func() { _ = worker.Run(ctx) }()
+
+	fake.Advance(59 * time.Minute)
+	require.Never(t, func() bool { return runs.Load() > 0 }, 100*time.Millisecond, 5*time.Millisecond)
+
+	fake.Advance(time.Minute)
+	require.Eventually(t, func() bool { return runs.Load() == 1 }, time.Second, 5*time.Millisecond)
+}
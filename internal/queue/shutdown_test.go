@@ -0,0 +1,83 @@
+package queue_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	miniredis "github.com/alicebob/miniredis/v2"
+	redis "github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/queue"
+)
+
+// runUntilShutdown starts a worker whose handler holds each task for hold,
+// cancels the worker once the task is running, and reports whether the task
+// finished without its context being cancelled.
+func runUntilShutdown(t *testing.T, grace, hold time.Duration) (finished bool, processing int64) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	enq := queue.Enqueuer{R: client, Prefix: "drain", MaxAttempts: 3}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	started := make(chan struct{})
+	result := make(chan bool, 1)
+	log := zerolog.New(io.Discard)
+	worker := queue.Worker{
+		R:                 client,
+		Prefix:            "drain",
+		Kind:              "webhook",
+		Concurrency:       1,
+		VisibilityTimeout: time.Second,
+		Store:             newMemoryStore(),
+		Logger:            &log,
+		ShutdownGrace:     grace,
+		Handler: func(jobCtx context.Context, task queue.Task) error {
+			close(started)
+			select {
+			case <-time.After(hold):
+				result <- true
+				return nil
+			case <-jobCtx.Done():
+				result <- false
+				return jobCtx.Err()
+			}
+		},
+	}
+	done := make(chan struct{})
+	go func() {
+		_ = worker.Run(ctx)
+		close(done)
+	}()
+
+	require.NoError(t, enq.Enqueue(context.Background(), queue.Task{Kind: "webhook", Payload: []byte("payload")}))
+	select {
+	case <-started:
+	case <-time.After(2 * time.Second):
+		t.Fatal("task never started")
+	}
+	cancel()
+	<-done
+
+	processing, err := client.ZCard(context.Background(), "drain:webhook:processing").Result()
+	require.NoError(t, err)
+	return <-result, processing
+}
+
+func TestShutdownLetsInFlightTasksFinishWithinGrace(t *testing.T) {
+	finished, processing := runUntilShutdown(t, 500*time.Millisecond, 50*time.Millisecond)
+	require.True(t, finished, "the task should finish during the grace period")
+	require.Zero(t, processing, "the finished task should be acknowledged")
+}
+
+func TestShutdownWithoutGraceCancelsInFlightTasks(t *testing.T) {
+	finished, _ := runUntilShutdown(t, 0, time.Second)
+	require.False(t, finished, "without a grace period the task is cancelled")
+}
@@ -23,6 +23,7 @@ type Store interface {
 	ListQueueDlq(ctx context.Context, kind string, limit, offset int) ([]DLQEntry, error)
 	CountQueueDlq(ctx context.Context, kind string) (int64, error)
 	QueueDlqSizeByKind(ctx context.Context) (map[string]int64, error)
+	QueueDlqSummary(ctx context.Context, kind string, since time.Time) ([]DLQSummary, error)
 }
 
 // DLQEntry represents an item stored in the DLQ table.
@@ -36,6 +37,17 @@ type DLQEntry struct {
 	CreatedAt      time.Time
 }
 
+// DLQSummary aggregates the DLQ entries of one kind.
+type DLQSummary struct {
+	Kind   string
+	Count  int64
+	Oldest time.Time
+	// Recent counts the entries created at or after the since time passed to
+	// QueueDlqSummary. Replayed entries are deleted, so it can undercount
+	// inserts; queue_dlq_inserts_total is the exact signal.
+	Recent int64
+}
+
 // NewStore constructs a Store backed by a pgx connection pool.
 func NewStore(pool *pgxpool.Pool) Store {
 	return &pgStore{pool: pool}
@@ -173,6 +185,30 @@ func (s *pgStore) QueueDlqSizeByKind(ctx context.Context) (map[string]int64, err
 	return result, rows.Err()
 }
 
+// QueueDlqSummary returns per kind DLQ counts, oldest entry, and entries
+// created since the given time. An empty kind summarises every kind.
+func (s *pgStore) QueueDlqSummary(ctx context.Context, kind string, since time.Time) ([]DLQSummary, error) {
+	if s == nil || s.pool == nil {
+		return nil, ErrStoreUnavailable
+	}
+	rows, err := s.pool.Query(ctx, `SELECT kind, COUNT(*), MIN(created_at), COUNT(*) FILTER (WHERE created_at >= $1)
+FROM queue_dlq WHERE $2 = '' OR kind = $2 GROUP BY kind ORDER BY kind`, since, strings.TrimSpace(kind))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []DLQSummary
+	for rows.Next() {
+		var summary DLQSummary
+		if err := rows.Scan(&summary.Kind, &summary.Count, &summary.Oldest, &summary.Recent); err != nil {
+			return nil, err
+		}
+		result = append(result, summary)
+	}
+	return result, rows.Err()
+}
+
 func clampPositive(value, min, max int) int {
 	if value < min {
 		return min
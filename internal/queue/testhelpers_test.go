@@ -102,6 +102,35 @@ func (m *memoryStore) QueueDlqSizeByKind(_ context.Context) (map[string]int64, e
 	return result, nil
 }
 
+func (m *memoryStore) QueueDlqSummary(_ context.Context, kind string, since time.Time) ([]queue.DLQSummary, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	byKind := make(map[string]*queue.DLQSummary)
+	for _, entry := range m.entries {
+		if kind != "" && entry.Kind != kind {
+			continue
+		}
+		summary, ok := byKind[entry.Kind]
+		if !ok {
+			summary = &queue.DLQSummary{Kind: entry.Kind, Oldest: entry.CreatedAt}
+			byKind[entry.Kind] = summary
+		}
+		summary.Count++
+		if entry.CreatedAt.Before(summary.Oldest) {
+			summary.Oldest = entry.CreatedAt
+		}
+		if !entry.CreatedAt.Before(since) {
+			summary.Recent++
+		}
+	}
+	out := make([]queue.DLQSummary, 0, len(byKind))
+	for _, summary := range byKind {
+		out = append(out, *summary)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Kind < out[j].Kind })
+	return out, nil
+}
+
 func (m *memoryStore) snapshot() map[uuid.UUID]queue.DLQEntry {
 	m.mu.Lock()
 	defer m.mu.Unlock()
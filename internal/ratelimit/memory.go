@@ -0,0 +1,74 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryLimiter is a process-local sliding window limiter intended for
+// development and tests. It is safe for concurrent use but is not shared
+// between instances, so limits apply per process.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	events    map[string][]time.Time
+	expires   map[string]time.Time
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryLimiter builds an empty in-memory limiter.
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{
+		events:  make(map[string][]time.Time),
+		expires: make(map[string]time.Time),
+		now:     time.Now,
+	}
+}
+
+// Allow implements Store with the same semantics as the Redis limiter.
+func (m *MemoryLimiter) Allow(_ context.Context, key string, window time.Duration, max int) (bool, int, time.Time, error) {
+	now := m.now()
+	until := now.Add(window)
+	if max <= 0 || window <= 0 {
+		return true, max, until, nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sweep(now)
+
+	cutoff := now.Add(-window)
+	events := m.events[key]
+	kept := events[:0]
+	for _, at := range events {
+		if at.After(cutoff) {
+			kept = append(kept, at)
+		}
+	}
+	kept = append(kept, now)
+	m.events[key] = kept
+	m.expires[key] = until
+
+	current := len(kept)
+	remaining := max - current
+	if remaining < 0 {
+		remaining = 0
+	}
+	return current <= max, remaining, until, nil
+}
+
+// sweep drops keys whose window has fully elapsed so idle clients do not
+// accumulate. It runs at most once per minute.
+func (m *MemoryLimiter) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < time.Minute {
+		return
+	}
+	m.lastSweep = now
+	for key, exp := range m.expires {
+		if now.After(exp) {
+			delete(m.expires, key)
+			delete(m.events, key)
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMemoryLimiterSlidingWindow(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+
+	ctx := context.Background()
+	window := 2 * time.Second
+	for i := 0; i < 2; i++ {
+		allowed, remaining, _, err := limiter.Allow(ctx, "key", window, 2)
+		if err != nil || !allowed {
+			t.Fatalf("expected request %d to be allowed (err=%v)", i, err)
+		}
+		if remaining != 1-i {
+			t.Fatalf("unexpected remaining: %d", remaining)
+		}
+	}
+	if allowed, _, _, _ := limiter.Allow(ctx, "key", window, 2); allowed {
+		t.Fatal("expected third request to be rejected")
+	}
+
+	now = now.Add(window + time.Millisecond)
+	if allowed, remaining, _, _ := limiter.Allow(ctx, "key", window, 2); !allowed || remaining != 1 {
+		t.Fatalf("expected window to slide, allowed=%v remaining=%d", allowed, remaining)
+	}
+}
+
+func TestMemoryLimiterSweepsIdleKeys(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+
+	_, _, _, _ = limiter.Allow(context.Background(), "idle", time.Second, 5)
+	now = now.Add(2 * time.Minute)
+	_, _, _, _ = limiter.Allow(context.Background(), "active", time.Second, 5)
+
+	if _, ok := limiter.events["idle"]; ok {
+		t.Fatal("expected idle key to be swept")
+	}
+}
+
+func TestMemoryLimiterConcurrentMiddleware(t *testing.T) {
+	handler := Handler{
+		Limiter: NewMemoryLimiter(),
+		Config: Config{
+			Key:    func(*http.Request) string { return "shared" },
+			Window: time.Minute,
+			Max:    10,
+		},
+	}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	var mu sync.Mutex
+	codes := map[int]int{}
+	var wg sync.WaitGroup
+	for i := 0; i < 25; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/", nil))
+			mu.Lock()
+			codes[rr.Code]++
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	if codes[http.StatusOK] != 10 || codes[http.StatusTooManyRequests] != 15 {
+		t.Fatalf("unexpected status distribution: %v", codes)
+	}
+}
@@ -1,21 +1,34 @@
 package ratelimit
 
 import (
+	"context"
 	"net/http"
 	"strconv"
 	"time"
+
+	"github.com/noah-isme/backend-toko/internal/common"
 )
 
+// Store records an event for key within a sliding window and reports whether
+// the caller is still within max. Limiter (Redis) and MemoryLimiter implement it.
+type Store interface {
+	Allow(ctx context.Context, key string, window time.Duration, max int) (allowed bool, remaining int, reset time.Time, err error)
+}
+
 // Config describes how to derive a rate limit key and thresholds.
 type Config struct {
 	Key    func(*http.Request) string
 	Window time.Duration
 	Max    int
+	// WarnAt is the fraction of Max (e.g. 0.8) from which allowed responses
+	// carry X-RateLimit-Warning with the percentage used, so clients can back
+	// off before they are blocked. Zero disables the warning.
+	WarnAt float64
 }
 
 // Handler enforces rate limits before delegating to the next handler.
 type Handler struct {
-	Limiter Limiter
+	Limiter Store
 	Config  Config
 	OnError func(error)
 }
@@ -23,7 +36,7 @@ type Handler struct {
 // Middleware implements the http.Handler middleware interface.
 func (h Handler) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if h.Config.Key == nil {
+		if h.Config.Key == nil || h.Limiter == nil {
 			next.ServeHTTP(w, r)
 			return
 		}
@@ -52,9 +65,15 @@ func (h Handler) Middleware(next http.Handler) http.Handler {
 				retryAfter = 0
 			}
 			headers.Set("Retry-After", strconv.Itoa(retryAfter))
-			http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+			common.JSONError(w, http.StatusTooManyRequests, common.CodeRateLimited, "rate limit exceeded", map[string]any{"retryAfter": retryAfter})
 			return
 		}
+		if h.Config.WarnAt > 0 && limitValue > 0 {
+			used := float64(limitValue-remaining) / float64(limitValue)
+			if used >= h.Config.WarnAt {
+				headers.Set("X-RateLimit-Warning", strconv.Itoa(int(used*100)))
+			}
+		}
 
 		next.ServeHTTP(w, r)
 	})
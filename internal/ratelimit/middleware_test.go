@@ -8,6 +8,8 @@ import (
 
 	miniredis "github.com/alicebob/miniredis/v2"
 	"github.com/redis/go-redis/v9"
+
+	"github.com/noah-isme/backend-toko/internal/tenant"
 )
 
 func TestHandlerMiddlewareEnforcesLimit(t *testing.T) {
@@ -79,3 +81,67 @@ func TestHandlerMiddlewareOnError(t *testing.T) {
 	}
 	_ = client.Close()
 }
+
+func TestHandlerMiddlewareWarnsNearLimit(t *testing.T) {
+	handler := Handler{
+		Limiter: NewMemoryLimiter(),
+		Config: Config{
+			Key:    func(*http.Request) string { return "warn" },
+			Window: time.Minute,
+			Max:    5,
+			WarnAt: 0.8,
+		},
+	}
+	counted := handler.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	want := []string{"", "", "", "80", "100"}
+	for i, expected := range want {
+		rr := httptest.NewRecorder()
+		counted.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/test", nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("request %d: expected 200, got %d", i+1, rr.Code)
+		}
+		if got := rr.Header().Get("X-RateLimit-Warning"); got != expected {
+			t.Fatalf("request %d: warning header %q, want %q", i+1, got, expected)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	counted.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/test", nil))
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 once the limit is exceeded, got %d", rr.Code)
+	}
+}
+
+func TestHandlerSharesBucketsAcrossTenants(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer func() { _ = client.Close() }()
+
+	handler := Handler{
+		Limiter: Limiter{Client: client, Prefix: "rl:"},
+		Config: Config{
+			Key:    func(*http.Request) string { return "global" },
+			Window: time.Minute,
+			Max:    1,
+		},
+	}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	serve := func(tenantID string) int {
+		req := httptest.NewRequest(http.MethodGet, "/test", nil)
+		req = req.WithContext(tenant.WithTenant(req.Context(), tenantID))
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr.Code
+	}
+	if code := serve("acme"); code != http.StatusOK {
+		t.Fatalf("expected acme allowed, got %d", code)
+	}
+	if code := serve("globex"); code != http.StatusTooManyRequests {
+		t.Fatalf("switching tenants must not reset the limit, got %d", code)
+	}
+}
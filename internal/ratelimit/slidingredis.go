@@ -10,6 +10,8 @@ import (
 )
 
 // Limiter implements a sliding window rate limiter backed by Redis sorted sets.
+// Buckets are shared across tenants: the tenant comes from client-supplied
+// headers, so scoping by it would let clients reset their limits.
 type Limiter struct {
 	Client *redis.Client
 	Prefix string
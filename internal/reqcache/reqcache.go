@@ -0,0 +1,82 @@
+// Package reqcache memoizes read queries for the lifetime of one request, so
+// services that need the same row several times while serving it query the
+// database once.
+package reqcache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+type ctxKey struct{}
+
+// cache holds the results loaded during one request. Batch requests share it
+// between goroutines, hence the lock.
+type cache struct {
+	mu      sync.Mutex
+	entries map[string]any
+}
+
+// WithCache returns ctx carrying an empty request cache.
+func WithCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &cache{entries: map[string]any{}})
+}
+
+// Middleware gives every request its own cache.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(WithCache(r.Context())))
+	})
+}
+
+// Key builds a cache key from a query name and its arguments.
+func Key(query string, args ...any) string {
+	var b strings.Builder
+	b.WriteString(query)
+	for _, arg := range args {
+		fmt.Fprintf(&b, ":%v", arg)
+	}
+	return b.String()
+}
+
+// Load returns the result cached under key, or calls load and caches what it
+// returns. It only memoizes when enabled and ctx carries a cache; errors are
+// never cached. Callers share the cached value and must not modify it, and
+// should only memoize reads the request does not change afterwards.
+func Load[T any](ctx context.Context, enabled bool, key string, load func(context.Context) (T, error)) (T, error) {
+	c, _ := ctx.Value(ctxKey{}).(*cache)
+	if !enabled || c == nil {
+		return load(ctx)
+	}
+	c.mu.Lock()
+	cached, ok := c.entries[key].(T)
+	c.mu.Unlock()
+	if ok {
+		return cached, nil
+	}
+	value, err := load(ctx)
+	if err != nil {
+		return value, err
+	}
+	c.mu.Lock()
+	c.entries[key] = value
+	c.mu.Unlock()
+	return value, nil
+}
+
+// Forget drops the results cached under keys, for a request that changes a
+// row it already loaded.
+func Forget(ctx context.Context, keys ...string) {
+	c, _ := ctx.Value(ctxKey{}).(*cache)
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	for _, key := range keys {
+		delete(c.entries, key)
+	}
+	c.mu.Unlock()
+}
@@ -0,0 +1,73 @@
+package reqcache_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/reqcache"
+)
+
+func TestLoadMemoizesWithinRequest(t *testing.T) {
+	calls := 0
+	load := func(context.Context) (string, error) {
+		calls++
+		return "row", nil
+	}
+	ctx := reqcache.WithCache(context.Background())
+	key := reqcache.Key("GetCartByID", "c-1")
+
+	for range 3 {
+		value, err := reqcache.Load(ctx, true, key, load)
+		require.NoError(t, err)
+		require.Equal(t, "row", value)
+	}
+	require.Equal(t, 1, calls)
+
+	reqcache.Forget(ctx, key)
+	_, err := reqcache.Load(ctx, true, key, load)
+	require.NoError(t, err)
+	require.Equal(t, 2, calls)
+}
+
+func TestLoadBypassesCache(t *testing.T) {
+	calls := 0
+	load := func(context.Context) (int, error) {
+		calls++
+		return calls, nil
+	}
+	withCache := reqcache.WithCache(context.Background())
+	_, _ = reqcache.Load(withCache, false, "k", load)
+	_, _ = reqcache.Load(withCache, false, "k", load)
+	_, _ = reqcache.Load(context.Background(), true, "k", load)
+	require.Equal(t, 3, calls, "disabled services and contexts without a cache always load")
+
+	failing := func(context.Context) (int, error) {
+		calls++
+		return 0, errors.New("boom")
+	}
+	_, err := reqcache.Load(withCache, true, "err", failing)
+	require.Error(t, err)
+	_, err = reqcache.Load(withCache, true, "err", failing)
+	require.Error(t, err)
+	require.Equal(t, 5, calls, "errors are not cached")
+}
+
+func TestMiddlewareScopesCacheToRequest(t *testing.T) {
+	calls := 0
+	handler := reqcache.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for range 2 {
+			_, _ = reqcache.Load(r.Context(), true, "k", func(context.Context) (int, error) {
+				calls++
+				return calls, nil
+			})
+		}
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	require.Equal(t, 2, calls)
+}
@@ -0,0 +1,15 @@
+package resilience
+
+import (
+	"net/http"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+)
+
+// AdminHandler lists the circuit breakers of the serving process.
+type AdminHandler struct{}
+
+// Breakers returns the state of every targeted breaker in this process.
+func (AdminHandler) Breakers(w http.ResponseWriter, r *http.Request) {
+	common.JSON(w, http.StatusOK, map[string]any{"data": Breakers()})
+}
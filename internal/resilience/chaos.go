@@ -0,0 +1,134 @@
+package resilience
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// ErrChaos is the failure injected by chaos testing.
+var ErrChaos = errors.New("resilience: chaos fault injected")
+
+// Chaos injects faults into outbound calls so game-days can confirm breakers
+// open, retries back off, and jobs dead-letter. A nil Chaos injects nothing;
+// it must never be configured in production.
+type Chaos struct {
+	// FailureRate is the share of calls that fail with ErrChaos.
+	FailureRate float64
+	// LatencyRate is the share of calls delayed by Latency before they run
+	// (or fail).
+	LatencyRate float64
+	Latency     time.Duration
+	// Targets limits injection to these targets; empty means all of them.
+	Targets []string
+}
+
+// NewChaos returns a Chaos injecting into targets, or nil when neither rate
+// is positive. Rates are clamped to [0, 1].
+func NewChaos(failureRate, latencyRate float64, latency time.Duration, targets []string) *Chaos {
+	failureRate = min(max(failureRate, 0), 1)
+	latencyRate = min(max(latencyRate, 0), 1)
+	if latency <= 0 {
+		latencyRate = 0
+	}
+	if failureRate == 0 && latencyRate == 0 {
+		return nil
+	}
+	return &Chaos{FailureRate: failureRate, LatencyRate: latencyRate, Latency: latency, Targets: targets}
+}
+
+// Applies reports whether faults are injected into target.
+func (c *Chaos) Applies(target string) bool {
+	if c == nil {
+		return false
+	}
+	return len(c.Targets) == 0 || slices.ContainsFunc(c.Targets, func(t string) bool {
+		return strings.EqualFold(strings.TrimSpace(t), target)
+	})
+}
+
+// Inject delays and fails a call to target at the configured rates. The
+// delay ends early, returning the context error, when ctx is done.
+func (c *Chaos) Inject(ctx context.Context, target string) error {
+	if !c.Applies(target) {
+		return nil
+	}
+	if c.LatencyRate > 0 && rand.Float64() < c.LatencyRate {
+		c.count(target, "latency")
+		timer := time.NewTimer(c.Latency)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if c.FailureRate > 0 && rand.Float64() < c.FailureRate {
+		c.count(target, "failure")
+		return ErrChaos
+	}
+	return nil
+}
+
+func (c *Chaos) count(target, kind string) {
+	if ChaosInjected != nil {
+		ChaosInjected.WithLabelValues(target, kind).Inc()
+	}
+}
+
+// ChaosTargetDB is the target name of database queries.
+const ChaosTargetDB = "db"
+
+// DBTX is the query interface generated queries run on.
+type DBTX interface {
+	Exec(context.Context, string, ...interface{}) (pgconn.CommandTag, error)
+	Query(context.Context, string, ...interface{}) (pgx.Rows, error)
+	QueryRow(context.Context, string, ...interface{}) pgx.Row
+	SendBatch(context.Context, *pgx.Batch) pgx.BatchResults
+}
+
+// DB wraps db so its queries are delayed and failed under the "db" target.
+// Without chaos for that target db is returned unchanged. Batches, and
+// transactions begun on the underlying pool, are not affected.
+func (c *Chaos) DB(db DBTX) DBTX {
+	if !c.Applies(ChaosTargetDB) {
+		return db
+	}
+	return chaosDB{DBTX: db, chaos: c}
+}
+
+type chaosDB struct {
+	DBTX
+	chaos *Chaos
+}
+
+func (d chaosDB) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	if err := d.chaos.Inject(ctx, ChaosTargetDB); err != nil {
+		return pgconn.CommandTag{}, err
+	}
+	return d.DBTX.Exec(ctx, sql, args...)
+}
+
+func (d chaosDB) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	if err := d.chaos.Inject(ctx, ChaosTargetDB); err != nil {
+		return nil, err
+	}
+	return d.DBTX.Query(ctx, sql, args...)
+}
+
+func (d chaosDB) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	if err := d.chaos.Inject(ctx, ChaosTargetDB); err != nil {
+		return errRow{err: err}
+	}
+	return d.DBTX.QueryRow(ctx, sql, args...)
+}
+
+type errRow struct{ err error }
+
+func (r errRow) Scan(...any) error { return r.err }
@@ -0,0 +1,78 @@
+package resilience_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/resilience"
+)
+
+func TestChaosFailsAttemptsAndOpensBreaker(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	breaker := resilience.NewBreaker(2, 0.5, time.Minute)
+	cl := resilience.HTTPClient{
+		Client:      srv.Client(),
+		Breaker:     breaker,
+		MaxAttempts: 3,
+		BaseBackoff: time.Millisecond,
+		Target:      "webhook-delivery",
+		Chaos:       resilience.NewChaos(1, 0, 0, []string{"webhook-delivery"}),
+	}
+	req, err := http.NewRequest(http.MethodPost, srv.URL, nil)
+	require.NoError(t, err)
+	_, err = cl.Do(context.Background(), req)
+	require.ErrorIs(t, err, resilience.ErrOpenCircuit)
+	require.Zero(t, hits.Load(), "injected failures must not reach the upstream")
+
+	// Other targets are left alone.
+	cl.Target, cl.Breaker = "email-provider", nil
+	resp, err := cl.Do(context.Background(), req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.EqualValues(t, 1, hits.Load())
+}
+
+func TestChaosLatencyTimesOutAttempt(t *testing.T) {
+	chaos := resilience.NewChaos(0, 1, time.Second, nil)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	cl := resilience.HTTPClient{Client: srv.Client(), Timeout: 20 * time.Millisecond, Chaos: chaos}
+	_, err = cl.Do(context.Background(), req)
+	require.True(t, errors.Is(err, resilience.ErrTimeout), "got %v", err)
+}
+
+func TestNewChaosDisabledWithoutRates(t *testing.T) {
+	require.Nil(t, resilience.NewChaos(0, 0, time.Second, nil))
+	require.Nil(t, resilience.NewChaos(0, 1, 0, nil))
+
+	var chaos *resilience.Chaos
+	require.False(t, chaos.Applies("db"))
+	require.NoError(t, chaos.Inject(context.Background(), "db"))
+}
+
+func TestChaosDBFailsQueries(t *testing.T) {
+	chaos := resilience.NewChaos(1, 0, 0, []string{resilience.ChaosTargetDB})
+	db := chaos.DB(nil)
+	_, err := db.Exec(context.Background(), "SELECT 1")
+	require.ErrorIs(t, err, resilience.ErrChaos)
+	var n int
+	require.ErrorIs(t, db.QueryRow(context.Background(), "SELECT 1").Scan(&n), resilience.ErrChaos)
+
+	require.Nil(t, resilience.NewChaos(1, 0, 0, []string{"payment"}).DB(nil))
+}
@@ -26,7 +26,8 @@ const (
 	Closed State = iota
 	// Open rejects requests until the cool-off period expires.
 	Open
-	// HalfOpen allows a limited number of probes to determine recovery.
+	// HalfOpen allows a limited number of probes to determine recovery; it
+	// closes once enough of them succeed and reopens otherwise.
 	HalfOpen
 )
 
@@ -55,6 +56,14 @@ type Breaker struct {
 	openFor      time.Duration
 	target       string
 	logger       *zerolog.Logger
+	// Half-open probing: up to probes requests are admitted after the cool-off
+	// and at least probeSuccessRatio of them must succeed to close.
+	probes            int
+	probeSuccessRatio float64
+	probesAdmitted    int
+	halfOpenAt        time.Time
+	// trips counts transitions into open since the breaker was created.
+	trips int64
 }
 
 // NewBreaker constructs a breaker that opens when the rolling failure ratio
@@ -74,27 +83,61 @@ func NewBreaker(minRequests int, failureRatio float64, openFor time.Duration) *B
 		openFor = 30 * time.Second
 	}
 	return &Breaker{
-		state:        Closed,
-		minRequests:  minRequests,
-		failureRatio: failureRatio,
-		openFor:      openFor,
+		state:             Closed,
+		minRequests:       minRequests,
+		failureRatio:      failureRatio,
+		openFor:           openFor,
+		probes:            1,
+		probeSuccessRatio: 1,
 	}
 }
 
+// WithHalfOpenProbes sets how many probe requests the breaker admits once the
+// cool-off expires and the share of them that must succeed before it closes.
+// The breaker reopens as soon as that share can no longer be reached. The
+// default is a single probe that must succeed.
+func (b *Breaker) WithHalfOpenProbes(probes int, successRatio float64) *Breaker {
+	if probes <= 0 {
+		probes = 1
+	}
+	if successRatio <= 0 || successRatio > 1 {
+		successRatio = 1
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probes = probes
+	b.probeSuccessRatio = successRatio
+	return b
+}
+
 // Allow reports whether a request is permitted in the current state. When the
 // breaker is open it only permits a request after the cool-off period and moves
-// into half-open to sample the downstream dependency.
+// into half-open, where it admits up to the configured number of probes to
+// sample the downstream dependency.
 func (b *Breaker) Allow(ctx context.Context) bool {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
 	switch b.state {
 	case Open:
-		if time.Since(b.openedAt) >= b.openFor {
-			b.changeStateLocked(ctx, HalfOpen)
-			return true
+		if time.Since(b.openedAt) < b.openFor {
+			return false
+		}
+		b.changeStateLocked(ctx, HalfOpen)
+		b.probesAdmitted = 1
+		return true
+	case HalfOpen:
+		if b.probesAdmitted >= b.probes && time.Since(b.halfOpenAt) >= b.openFor {
+			// Probes that never reported (e.g. canceled by the caller) must
+			// not keep the breaker half-open forever; admit replacements.
+			b.probesAdmitted = b.successes + b.failures
+			b.halfOpenAt = time.Now()
+		}
+		if b.probesAdmitted >= b.probes {
+			return false
 		}
-		return false
+		b.probesAdmitted++
+		return true
 	default:
 		return true
 	}
@@ -111,11 +154,7 @@ func (b *Breaker) Report(ctx context.Context, success bool) {
 		// Ignore reports while open.
 		return
 	case HalfOpen:
-		if success {
-			b.changeStateLocked(ctx, Closed)
-			return
-		}
-		b.changeStateLocked(ctx, Open)
+		b.reportProbeLocked(ctx, success)
 		return
 	}
 
@@ -124,6 +163,7 @@ func (b *Breaker) Report(ctx context.Context, success bool) {
 	} else {
 		b.failures++
 	}
+	b.recordFailureRatioLocked()
 
 	total := b.failures + b.successes
 	if total < b.minRequests {
@@ -139,6 +179,28 @@ func (b *Breaker) Report(ctx context.Context, success bool) {
 	}
 }
 
+func (b *Breaker) reportProbeLocked(ctx context.Context, success bool) {
+	result := "failure"
+	if success {
+		b.successes++
+		result = "success"
+	} else {
+		b.failures++
+	}
+	if BreakerProbes != nil {
+		BreakerProbes.WithLabelValues(b.targetLabel(), result).Inc()
+	}
+	probes := float64(b.probes)
+	// Best case: every probe still outstanding succeeds.
+	if float64(b.probes-b.failures)/probes < b.probeSuccessRatio {
+		b.changeStateLocked(ctx, Open)
+		return
+	}
+	if b.successes+b.failures >= b.probes && float64(b.successes)/probes >= b.probeSuccessRatio {
+		b.changeStateLocked(ctx, Closed)
+	}
+}
+
 // Backoff returns an exponential backoff duration for the provided attempt.
 // Jitter is expressed as a fraction (e.g. 0.2 == 20%).
 func Backoff(base time.Duration, attempt int, jitterPct float64) time.Duration {
@@ -157,12 +219,15 @@ func Backoff(base time.Duration, attempt int, jitterPct float64) time.Duration {
 	return d + time.Duration(delta)
 }
 
-// WithTarget sets the logical dependency identifier used for telemetry labels.
+// WithTarget sets the logical dependency identifier used for telemetry labels
+// and lists the breaker in Breakers.
 func (b *Breaker) WithTarget(target string) *Breaker {
 	b.mu.Lock()
-	defer b.mu.Unlock()
 	b.target = strings.TrimSpace(target)
 	b.recordStateLocked()
+	b.recordFailureRatioLocked()
+	b.mu.Unlock()
+	register(b)
 	return b
 }
 
@@ -187,10 +252,18 @@ func (b *Breaker) changeStateLocked(ctx context.Context, next State) {
 	if next == Closed {
 		b.openedAt = time.Time{}
 	}
-	b.failures = 0
-	b.successes = 0
+	if next == HalfOpen {
+		b.halfOpenAt = time.Now()
+	}
+	if next == Open {
+		b.trips++
+	}
 	b.recordStateLocked()
 	b.recordTransition(ctx, prev, next)
+	b.failures = 0
+	b.successes = 0
+	b.probesAdmitted = 0
+	b.recordFailureRatioLocked()
 }
 
 func (b *Breaker) recordStateLocked() {
@@ -200,6 +273,24 @@ func (b *Breaker) recordStateLocked() {
 	BreakerState.WithLabelValues(b.targetLabel()).Set(stateGaugeValue(b.state))
 }
 
+func (b *Breaker) recordFailureRatioLocked() {
+	if BreakerFailureRatio == nil {
+		return
+	}
+	BreakerFailureRatio.WithLabelValues(b.targetLabel()).Set(b.failureRatioLocked())
+}
+
+// failureRatioLocked is the share of failures among the outcomes counted in
+// the current state; closed breakers halve their counts as they go, so it
+// tracks recent requests.
+func (b *Breaker) failureRatioLocked() float64 {
+	total := b.failures + b.successes
+	if total == 0 {
+		return 0
+	}
+	return float64(b.failures) / float64(total)
+}
+
 func (b *Breaker) recordTransition(ctx context.Context, from, to State) {
 	label := b.targetLabel()
 	if BreakerTransitions != nil {
@@ -211,6 +302,9 @@ func (b *Breaker) recordTransition(ctx context.Context, from, to State) {
 	logger := b.loggerFor(ctx)
 	traceID := traceIDFromContext(ctx)
 	evt := logger.Info().Str("target", label).Str("from_state", from.String()).Str("to_state", to.String())
+	if from == HalfOpen {
+		evt = evt.Int("probe_successes", b.successes).Int("probe_failures", b.failures).Int("probes", b.probes)
+	}
 	if traceID != "" {
 		evt = evt.Str("trace_id", traceID)
 	}
@@ -0,0 +1,117 @@
+package resilience_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/resilience"
+)
+
+// tripBreaker opens a breaker built with minRequests 1 and waits for the
+// cool-off so the next Allow moves it to half-open.
+func tripBreaker(t *testing.T, b *resilience.Breaker) {
+	t.Helper()
+	ctx := context.Background()
+	require.True(t, b.Allow(ctx))
+	b.Report(ctx, false)
+	require.False(t, b.Allow(ctx))
+	time.Sleep(25 * time.Millisecond)
+}
+
+func TestBreakerHalfOpenProbes(t *testing.T) {
+	cases := []struct {
+		name    string
+		probes  int
+		ratio   float64
+		results []bool
+		want    resilience.State
+	}{
+		{name: "single probe closes", probes: 1, ratio: 1, results: []bool{true}, want: resilience.Closed},
+		{name: "single probe reopens", probes: 1, ratio: 1, results: []bool{false}, want: resilience.Open},
+		{name: "all probes succeed", probes: 3, ratio: 1, results: []bool{true, true, true}, want: resilience.Closed},
+		{name: "ratio met despite a failure", probes: 4, ratio: 0.75, results: []bool{true, false, true, true}, want: resilience.Closed},
+		{name: "ratio missed", probes: 4, ratio: 0.75, results: []bool{true, false, true, false}, want: resilience.Open},
+		{name: "lucky first success", probes: 3, ratio: 1, results: []bool{true, false}, want: resilience.Open},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			target := "probe-" + tc.name
+			breaker := resilience.NewBreaker(1, 0.5, 20*time.Millisecond).WithTarget(target).WithHalfOpenProbes(tc.probes, tc.ratio)
+			ctx := context.Background()
+			tripBreaker(t, breaker)
+
+			// Exactly the configured number of probes is admitted.
+			for i := 0; i < tc.probes; i++ {
+				require.True(t, breaker.Allow(ctx), "probe %d should be admitted", i+1)
+			}
+			require.False(t, breaker.Allow(ctx), "no more than %d probes", tc.probes)
+
+			for i, ok := range tc.results {
+				if i < len(tc.results)-1 {
+					require.Equal(t, 2.0, testutil.ToFloat64(resilience.BreakerState.WithLabelValues(target)), "still half-open after %d reports", i+1)
+				}
+				breaker.Report(ctx, ok)
+			}
+			require.Equal(t, stateValue(tc.want), testutil.ToFloat64(resilience.BreakerState.WithLabelValues(target)))
+			require.Equal(t, 1.0, testutil.ToFloat64(resilience.BreakerTransitions.WithLabelValues(target, "half_open", tc.want.String())))
+			if tc.want == resilience.Closed {
+				require.True(t, breaker.Allow(ctx))
+				require.True(t, breaker.Allow(ctx), "a closed breaker admits everything")
+			} else {
+				require.False(t, breaker.Allow(ctx))
+			}
+		})
+	}
+}
+
+func TestBreakerCyclesThroughHalfOpen(t *testing.T) {
+	breaker := resilience.NewBreaker(1, 0.5, 20*time.Millisecond).WithHalfOpenProbes(2, 1)
+	ctx := context.Background()
+
+	// open -> half-open -> open
+	tripBreaker(t, breaker)
+	require.True(t, breaker.Allow(ctx))
+	require.True(t, breaker.Allow(ctx))
+	breaker.Report(ctx, true)
+	breaker.Report(ctx, false)
+	require.False(t, breaker.Allow(ctx), "a failed probe round reopens the breaker")
+
+	// open -> half-open -> closed
+	time.Sleep(25 * time.Millisecond)
+	require.True(t, breaker.Allow(ctx))
+	require.True(t, breaker.Allow(ctx))
+	breaker.Report(ctx, true)
+	breaker.Report(ctx, true)
+	require.True(t, breaker.Allow(ctx))
+	breaker.Report(ctx, true)
+	require.True(t, breaker.Allow(ctx))
+}
+
+func TestBreakerReadmitsProbesThatNeverReported(t *testing.T) {
+	breaker := resilience.NewBreaker(1, 0.5, 20*time.Millisecond)
+	ctx := context.Background()
+	tripBreaker(t, breaker)
+
+	require.True(t, breaker.Allow(ctx))
+	require.False(t, breaker.Allow(ctx))
+	// The probe is abandoned; after another cool-off a replacement goes out.
+	time.Sleep(25 * time.Millisecond)
+	require.True(t, breaker.Allow(ctx))
+	breaker.Report(ctx, true)
+	require.True(t, breaker.Allow(ctx))
+}
+
+func stateValue(s resilience.State) float64 {
+	switch s {
+	case resilience.Open:
+		return 1
+	case resilience.HalfOpen:
+		return 2
+	default:
+		return 0
+	}
+}
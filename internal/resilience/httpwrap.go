@@ -4,14 +4,40 @@ import (
 	"bytes"
 	"context"
 	"errors"
+	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/rs/zerolog"
+
+	"github.com/noah-isme/backend-toko/internal/common"
 )
 
+// DefaultTimeout bounds each attempt when neither HTTPClient.Timeout nor the
+// wrapped client's Timeout is set, so a hung upstream cannot block forever.
+const DefaultTimeout = 30 * time.Second
+
+// ErrTimeout marks an attempt that ran past its timeout or the caller's
+// deadline. It wraps the underlying error, so context.DeadlineExceeded still
+// matches.
+var ErrTimeout = errors.New("resilience: upstream timed out")
+
+// IsTimeout reports whether err is an upstream timeout: ErrTimeout, a context
+// deadline, or a network timeout.
+func IsTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrTimeout) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
 // HTTPClient wraps an http.Client with retry, timeout and circuit-breaker logic.
 type HTTPClient struct {
 	Client      *http.Client
@@ -23,6 +49,12 @@ type HTTPClient struct {
 	Fallback    func(context.Context, *http.Request, error) (*http.Response, error)
 	Target      string
 	Logger      *zerolog.Logger
+	// RetryBudget, when set, must have a token for every retry; once it is
+	// empty a failed attempt ends the request. Share it per target.
+	RetryBudget *RetryBudget
+	// Chaos, when set, fails and delays attempts for chaos testing as if
+	// the upstream had.
+	Chaos *Chaos
 }
 
 // Do executes the request applying retry semantics. The provided request body is
@@ -33,11 +65,13 @@ func (cl HTTPClient) Do(ctx context.Context, req *http.Request) (*http.Response,
 		return nil, errors.New("resilience: http client not configured")
 	}
 	breaker := cl.Breaker
-	if breaker == nil {
-		// default to closed breaker that never trips
+	switch {
+	case breaker == nil:
+		// default to closed breaker that never trips; it lives for this call
+		// only, so it stays out of the Breakers registry.
 		breaker = NewBreaker(1, 1, time.Second)
-	}
-	if cl.Target != "" {
+		breaker.target = strings.TrimSpace(cl.Target)
+	case cl.Target != "":
 		breaker = breaker.WithTarget(cl.Target)
 	}
 	if cl.Logger != nil {
@@ -76,12 +110,20 @@ func (cl HTTPClient) Do(ctx context.Context, req *http.Request) (*http.Response,
 			breaker.Report(ctx, false)
 			return nil, err
 		}
+		if reqID := common.RequestID(ctx); reqID != "" && attemptReq.Header.Get(common.RequestIDHeader) == "" {
+			attemptReq.Header.Set(common.RequestIDHeader, reqID)
+		}
 		evt := logger.Info().Str("target", target).Int("attempt", attempt)
 		if traceID != "" {
 			evt = evt.Str("trace_id", traceID)
 		}
 		evt.Msg("http attempt start")
 		resp, err := cl.doOnce(ctx, attemptReq)
+		if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+			// The caller gave up; retrying or tripping the breaker would only
+			// blame the upstream for it.
+			return nil, ctx.Err()
+		}
 		if err == nil && resp.StatusCode < 500 {
 			breaker.Report(ctx, true)
 			successEvt := logger.Info().Str("target", target).Int("attempt", attempt).Int("status", resp.StatusCode)
@@ -106,6 +148,10 @@ func (cl HTTPClient) Do(ctx context.Context, req *http.Request) (*http.Response,
 			failureEvt.Msg("http attempts exhausted")
 			break
 		}
+		if !cl.withdrawRetry(target) {
+			failureEvt.Msg("retry budget exhausted; not retrying")
+			break
+		}
 		sleepFor := Backoff(baseBackoff, attempt, cl.Jitter)
 		failureEvt.Dur("backoff", sleepFor).Msg("http attempt failed; backing off")
 		timer := time.NewTimer(sleepFor)
@@ -135,16 +181,37 @@ func (cl HTTPClient) doOnce(ctx context.Context, req *http.Request) (*http.Respo
 	if timeout <= 0 {
 		timeout = cl.Client.Timeout
 	}
-	var callCtx context.Context
-	var cancel context.CancelFunc
-	if timeout > 0 {
-		callCtx, cancel = context.WithTimeout(ctx, timeout)
-	} else {
-		callCtx, cancel = context.WithCancel(ctx)
+	if timeout <= 0 {
+		timeout = DefaultTimeout
 	}
-	defer cancel()
+	callCtx, cancel := context.WithTimeout(ctx, timeout)
 	req = req.WithContext(callCtx)
-	return cl.Client.Do(req)
+	err := cl.Chaos.Inject(callCtx, cl.targetLabel())
+	var resp *http.Response
+	if err == nil {
+		resp, err = cl.Client.Do(req)
+	}
+	if err != nil {
+		cancel()
+		if IsTimeout(err) {
+			return nil, fmt.Errorf("%w: %w", ErrTimeout, err)
+		}
+		return nil, err
+	}
+	// The timeout also covers reading the body; it is released on Close.
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
 }
 
 func ensureReplayableBody(req *http.Request) ([]byte, error) {
@@ -190,6 +257,22 @@ func cloneRequestWithContext(ctx context.Context, req *http.Request, body []byte
 	return clone, nil
 }
 
+// withdrawRetry spends a retry from the budget and reports whether the retry
+// may go ahead.
+func (cl HTTPClient) withdrawRetry(target string) bool {
+	if cl.RetryBudget == nil {
+		return true
+	}
+	ok := cl.RetryBudget.Withdraw()
+	if RetryBudgetTokens != nil {
+		RetryBudgetTokens.WithLabelValues(target).Set(cl.RetryBudget.Tokens())
+	}
+	if !ok && RetryBudgetExhausted != nil {
+		RetryBudgetExhausted.WithLabelValues(target).Inc()
+	}
+	return ok
+}
+
 func (cl HTTPClient) targetLabel() string {
 	trimmed := strings.TrimSpace(cl.Target)
 	if trimmed == "" {
@@ -199,7 +282,7 @@ func (cl HTTPClient) targetLabel() string {
 }
 
 func (cl HTTPClient) logger(ctx context.Context) *zerolog.Logger {
-	if ctxLogger := zerolog.Ctx(ctx); ctxLogger != nil {
+	if ctxLogger := zerolog.Ctx(ctx); ctxLogger != nil && ctxLogger.GetLevel() != zerolog.Disabled {
 		logger := ctxLogger.With().Logger()
 		return &logger
 	}
@@ -0,0 +1,55 @@
+package resilience_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/resilience"
+)
+
+func TestHTTPClientForwardsRequestID(t *testing.T) {
+	var got string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("X-Request-ID")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "req-42")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, srv.URL, nil)
+	require.NoError(t, err)
+	cl := resilience.HTTPClient{Client: srv.Client()}
+	resp, err := cl.Do(ctx, req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	require.Equal(t, "req-42", got)
+}
+
+func TestHTTPClientTimesOutHungUpstream(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+	require.NoError(t, err)
+	cl := resilience.HTTPClient{Client: srv.Client(), Timeout: 50 * time.Millisecond, MaxAttempts: 1}
+	start := time.Now()
+	_, err = cl.Do(context.Background(), req)
+	require.Error(t, err)
+	require.True(t, errors.Is(err, resilience.ErrTimeout), "got %v", err)
+	require.True(t, resilience.IsTimeout(err))
+	require.Less(t, time.Since(start), time.Second)
+}
@@ -24,8 +24,43 @@ var (
 		},
 		[]string{"target"},
 	)
+	BreakerFailureRatio = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "breaker_failure_ratio",
+			Help: "Share of failed requests among the recent outcomes a breaker has counted",
+		},
+		[]string{"target"},
+	)
+	BreakerProbes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "breaker_half_open_probe_total",
+			Help: "Outcomes of requests probed while a breaker was half-open",
+		},
+		[]string{"target", "result"},
+	)
+	RetryBudgetTokens = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "retry_budget_tokens",
+			Help: "Retries left in the shared retry budget of an outbound target",
+		},
+		[]string{"target"},
+	)
+	RetryBudgetExhausted = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "retry_budget_exhausted_total",
+			Help: "Retries skipped because the target's retry budget was empty",
+		},
+		[]string{"target"},
+	)
+	ChaosInjected = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "chaos_injected_total",
+			Help: "Faults injected by chaos testing, by target and kind (failure or latency)",
+		},
+		[]string{"target", "kind"},
+	)
 )
 
 func init() {
-	prometheus.MustRegister(BreakerState, BreakerTransitions, BreakerOpenedTotal)
+	prometheus.MustRegister(BreakerState, BreakerTransitions, BreakerOpenedTotal, BreakerFailureRatio, BreakerProbes, RetryBudgetTokens, RetryBudgetExhausted, ChaosInjected)
 }
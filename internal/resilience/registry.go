@@ -0,0 +1,85 @@
+package resilience
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// breakers holds every breaker given a target so operators can list them.
+// Only long-lived breakers configured on clients are registered, one per
+// target, so entries are never removed.
+var breakers = struct {
+	sync.Mutex
+	set map[*Breaker]struct{}
+}{set: map[*Breaker]struct{}{}}
+
+func register(b *Breaker) {
+	breakers.Lock()
+	breakers.set[b] = struct{}{}
+	breakers.Unlock()
+}
+
+// BreakerStatus is a point-in-time view of one breaker.
+type BreakerStatus struct {
+	Target       string  `json:"target"`
+	State        string  `json:"state"`
+	FailureRatio float64 `json:"failureRatio"`
+	Failures     int     `json:"failures"`
+	Successes    int     `json:"successes"`
+	Trips        int64   `json:"trips"`
+	// OpenedAt and RetryAt are set while the breaker is open.
+	OpenedAt *time.Time `json:"openedAt,omitempty"`
+	RetryAt  *time.Time `json:"retryAt,omitempty"`
+}
+
+// Status reports the breaker's current state.
+func (b *Breaker) Status() BreakerStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	status := BreakerStatus{
+		Target:       b.targetLabel(),
+		State:        b.state.String(),
+		FailureRatio: b.failureRatioLocked(),
+		Failures:     b.failures,
+		Successes:    b.successes,
+		Trips:        b.trips,
+	}
+	if b.state == Open {
+		opened := b.openedAt.UTC()
+		retry := opened.Add(b.openFor)
+		status.OpenedAt = &opened
+		status.RetryAt = &retry
+	}
+	return status
+}
+
+var stateRank = map[string]int{Open.String(): 2, HalfOpen.String(): 1}
+
+// Breakers reports every breaker that was given a target in this process,
+// ordered by target, most degraded first. A target served by several breakers is listed once per
+// breaker.
+func Breakers() []BreakerStatus {
+	breakers.Lock()
+	list := make([]*Breaker, 0, len(breakers.set))
+	for b := range breakers.set {
+		list = append(list, b)
+	}
+	breakers.Unlock()
+
+	out := make([]BreakerStatus, 0, len(list))
+	for _, b := range list {
+		out = append(out, b.Status())
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Target != out[j].Target {
+			return out[i].Target < out[j].Target
+		}
+		// Worst first within a target: open, half-open, then by failure ratio.
+		if out[i].State != out[j].State {
+			return stateRank[out[i].State] > stateRank[out[j].State]
+		}
+		return out[i].FailureRatio > out[j].FailureRatio
+	})
+	return out
+}
@@ -0,0 +1,100 @@
+package resilience_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/resilience"
+)
+
+func findBreaker(t *testing.T, target string) []resilience.BreakerStatus {
+	t.Helper()
+	var found []resilience.BreakerStatus
+	for _, status := range resilience.Breakers() {
+		if status.Target == target {
+			found = append(found, status)
+		}
+	}
+	return found
+}
+
+func TestBreakersListsTargetedBreakers(t *testing.T) {
+	ctx := context.Background()
+	// The registry is process-wide, so targets are unique per run.
+	target := fmt.Sprintf("registry-payments-%d", time.Now().UnixNano())
+	healthy := resilience.NewBreaker(4, 0.5, time.Minute).WithTarget(target)
+	sick := resilience.NewBreaker(2, 0.5, time.Minute).WithTarget(target)
+	// Registering again, as HTTPClient does on every call, must not duplicate.
+	sick.WithTarget(target)
+
+	healthy.Report(ctx, true)
+	healthy.Report(ctx, false)
+	require.InDelta(t, 0.5, testutil.ToFloat64(resilience.BreakerFailureRatio.WithLabelValues(target)), 1e-9)
+
+	sick.Report(ctx, false)
+	sick.Report(ctx, false)
+
+	found := findBreaker(t, target)
+	require.Len(t, found, 2)
+	open := found[0]
+	require.Equal(t, "open", open.State)
+	require.EqualValues(t, 1, open.Trips)
+	require.NotNil(t, open.OpenedAt)
+	require.Equal(t, open.OpenedAt.Add(time.Minute), *open.RetryAt)
+
+	closed := found[1]
+	require.Equal(t, "closed", closed.State)
+	require.InDelta(t, 0.5, closed.FailureRatio, 1e-9)
+	require.Equal(t, 1, closed.Failures)
+	require.Equal(t, 1, closed.Successes)
+	require.Nil(t, closed.OpenedAt)
+
+	require.Empty(t, findBreaker(t, "default"), "untargeted breakers are not listed")
+}
+
+func TestHTTPClientWithoutBreakerIsNotRegistered(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+	target := fmt.Sprintf("registry-unbounded-%d", time.Now().UnixNano())
+	client := resilience.HTTPClient{Client: srv.Client(), Target: target}
+
+	for range 3 {
+		req, err := http.NewRequest(http.MethodGet, srv.URL, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(context.Background(), req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	}
+	require.Empty(t, findBreaker(t, target), "per-call default breakers must not accumulate")
+}
+
+func TestAdminHandlerBreakers(t *testing.T) {
+	target := fmt.Sprintf("registry-admin-%d", time.Now().UnixNano())
+	resilience.NewBreaker(1, 0.5, time.Minute).WithTarget(target)
+
+	rr := httptest.NewRecorder()
+	resilience.AdminHandler{}.Breakers(rr, httptest.NewRequest(http.MethodGet, "/api/v1/admin/breakers", nil))
+	require.Equal(t, http.StatusOK, rr.Code)
+
+	var body struct {
+		Data []resilience.BreakerStatus `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	var states []string
+	for _, status := range body.Data {
+		if status.Target == target {
+			states = append(states, status.State)
+		}
+	}
+	require.Equal(t, []string{"closed"}, states)
+}
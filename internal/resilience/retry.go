@@ -0,0 +1,62 @@
+package resilience
+
+import (
+	"context"
+	"time"
+)
+
+// Retry calls fn until it succeeds, the attempts are used up, or ctx is done.
+// The wait between attempts starts at BaseBackoff and doubles up to MaxWait.
+type Retry struct {
+	Attempts    int
+	BaseBackoff time.Duration
+	MaxWait     time.Duration
+	// OnRetry is called after a failed attempt that will be retried.
+	OnRetry func(attempt int, wait time.Duration, err error)
+}
+
+// Do runs fn with retries and returns its last error. When ctx ends first the
+// last error from fn is returned, or ctx.Err() if fn never ran.
+func (r Retry) Do(ctx context.Context, fn func(context.Context) error) error {
+	attempts := r.Attempts
+	if attempts <= 0 {
+		attempts = 1
+	}
+	wait := r.BaseBackoff
+	if wait <= 0 {
+		wait = 500 * time.Millisecond
+	}
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			if err == nil {
+				err = ctxErr
+			}
+			return err
+		}
+		if err = fn(ctx); err == nil {
+			return nil
+		}
+		if attempt == attempts {
+			break
+		}
+		if r.MaxWait > 0 && wait > r.MaxWait {
+			wait = r.MaxWait
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			return err
+		}
+		if r.OnRetry != nil {
+			r.OnRetry(attempt, wait, err)
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		wait *= 2
+	}
+	return err
+}
@@ -0,0 +1,61 @@
+package resilience_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/resilience"
+)
+
+func TestRetrySucceedsAfterTransientFailures(t *testing.T) {
+	var waits []time.Duration
+	calls := 0
+	retry := resilience.Retry{
+		Attempts:    5,
+		BaseBackoff: time.Millisecond,
+		MaxWait:     3 * time.Millisecond,
+		OnRetry: func(attempt int, wait time.Duration, err error) {
+			waits = append(waits, wait)
+		},
+	}
+	err := retry.Do(context.Background(), func(context.Context) error {
+		calls++
+		if calls < 4 {
+			return errors.New("connection refused")
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 4, calls)
+	require.Equal(t, []time.Duration{time.Millisecond, 2 * time.Millisecond, 3 * time.Millisecond}, waits)
+}
+
+func TestRetryGivesUpAfterAttempts(t *testing.T) {
+	calls := 0
+	boom := errors.New("connection refused")
+	err := resilience.Retry{Attempts: 3, BaseBackoff: time.Millisecond}.Do(context.Background(), func(context.Context) error {
+		calls++
+		return boom
+	})
+	require.ErrorIs(t, err, boom)
+	require.Equal(t, 3, calls)
+}
+
+func TestRetryRespectsContextDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	calls := 0
+	boom := errors.New("connection refused")
+	start := time.Now()
+	err := resilience.Retry{Attempts: 10, BaseBackoff: time.Second}.Do(ctx, func(context.Context) error {
+		calls++
+		return boom
+	})
+	require.ErrorIs(t, err, boom)
+	require.Equal(t, 1, calls, "no retry is scheduled past the deadline")
+	require.Less(t, time.Since(start), time.Second)
+}
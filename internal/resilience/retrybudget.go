@@ -0,0 +1,74 @@
+package resilience
+
+import (
+	"sync"
+	"time"
+)
+
+// RetryBudget is a token bucket that bounds how often the clients sharing it
+// may retry. Each retry spends a token; tokens refill at a steady rate up to
+// the bucket size. During a broad upstream outage the bucket drains and
+// requests fail after their first attempt instead of multiplying the load.
+// Share one budget between every client calling the same target.
+type RetryBudget struct {
+	mu           sync.Mutex
+	size         float64
+	refillPerSec float64
+	tokens       float64
+	last         time.Time
+	now          func() time.Time
+}
+
+// NewRetryBudget returns a full budget of size retries that refills at
+// refillPerSec. A size of zero or less returns nil, which allows every retry.
+func NewRetryBudget(size int, refillPerSec float64) *RetryBudget {
+	if size <= 0 {
+		return nil
+	}
+	if refillPerSec < 0 {
+		refillPerSec = 0
+	}
+	return &RetryBudget{
+		size:         float64(size),
+		refillPerSec: refillPerSec,
+		tokens:       float64(size),
+		now:          time.Now,
+	}
+}
+
+// Withdraw spends a token for one retry and reports whether one was left.
+func (b *RetryBudget) Withdraw() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refillLocked()
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// Tokens reports the retries currently available.
+func (b *RetryBudget) Tokens() float64 {
+	if b == nil {
+		return 0
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refillLocked()
+	return b.tokens
+}
+
+func (b *RetryBudget) refillLocked() {
+	now := b.now()
+	if !b.last.IsZero() {
+		b.tokens += now.Sub(b.last).Seconds() * b.refillPerSec
+		if b.tokens > b.size {
+			b.tokens = b.size
+		}
+	}
+	b.last = now
+}
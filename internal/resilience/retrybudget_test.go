@@ -0,0 +1,69 @@
+package resilience_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/resilience"
+)
+
+func TestRetryBudgetRefillsUpToSize(t *testing.T) {
+	budget := resilience.NewRetryBudget(2, 100)
+	require.True(t, budget.Withdraw())
+	require.True(t, budget.Withdraw())
+	require.False(t, budget.Withdraw(), "an empty budget refuses retries")
+
+	require.Eventually(t, budget.Withdraw, time.Second, 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	require.LessOrEqual(t, budget.Tokens(), 2.0, "refills stop at the budget size")
+
+	var unlimited *resilience.RetryBudget
+	require.Nil(t, resilience.NewRetryBudget(0, 1))
+	require.True(t, unlimited.Withdraw(), "a nil budget allows every retry")
+}
+
+func TestHTTPClientStopsRetryingWhenBudgetIsSpent(t *testing.T) {
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	target := "budget-" + t.Name()
+	resilience.RetryBudgetExhausted.DeleteLabelValues(target)
+	budget := resilience.NewRetryBudget(2, 0)
+	newClient := func() resilience.HTTPClient {
+		return resilience.HTTPClient{
+			Client:      srv.Client(),
+			Breaker:     resilience.NewBreaker(100, 1, time.Second),
+			BaseBackoff: time.Millisecond,
+			MaxAttempts: 3,
+			Target:      target,
+			RetryBudget: budget,
+		}
+	}
+	send := func(cl resilience.HTTPClient) {
+		req, err := http.NewRequest(http.MethodPost, srv.URL, nil)
+		require.NoError(t, err)
+		_, err = cl.Do(context.Background(), req)
+		require.Error(t, err)
+	}
+
+	// The first request retries twice and drains the shared budget.
+	send(newClient())
+	require.EqualValues(t, 3, hits.Load())
+	// Another client on the same target fails after its first attempt.
+	send(newClient())
+	require.EqualValues(t, 4, hits.Load())
+
+	require.Equal(t, 0.0, testutil.ToFloat64(resilience.RetryBudgetTokens.WithLabelValues(target)))
+	require.Equal(t, 1.0, testutil.ToFloat64(resilience.RetryBudgetExhausted.WithLabelValues(target)))
+}
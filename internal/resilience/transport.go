@@ -0,0 +1,71 @@
+package resilience
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// TransportConfig controls how outbound clients reach webhook receivers and
+// providers.
+type TransportConfig struct {
+	// ProxyURL routes every request through this proxy. Empty honours
+	// HTTPS_PROXY, HTTP_PROXY, and NO_PROXY from the environment.
+	ProxyURL string
+	// CAFile is a PEM bundle trusted in addition to the system roots, e.g. a
+	// corporate CA that re-signs traffic at the proxy.
+	CAFile string
+	// InsecureSkipVerify disables certificate checks; development only.
+	InsecureSkipVerify bool
+}
+
+// NewTransport builds an HTTP transport from cfg on top of the defaults of
+// http.DefaultTransport. It fails when the proxy URL is malformed or the CA
+// bundle cannot be read or holds no certificates.
+func NewTransport(cfg TransportConfig) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if raw := strings.TrimSpace(cfg.ProxyURL); raw != "" {
+		proxy, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("proxy url: %w", err)
+		}
+		switch proxy.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return nil, fmt.Errorf("proxy url: unsupported scheme %q", proxy.Scheme)
+		}
+		if proxy.Host == "" {
+			return nil, errors.New("proxy url: missing host")
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	if path := strings.TrimSpace(cfg.CAFile); path != "" {
+		pem, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("ca file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("ca file %s: no PEM certificates found", path)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	if cfg.InsecureSkipVerify {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.InsecureSkipVerify = true //nolint:gosec
+	}
+	return transport, nil
+}
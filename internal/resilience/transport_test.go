@@ -0,0 +1,61 @@
+package resilience_test
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/resilience"
+)
+
+func TestNewTransportTrustsCustomCA(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	untrusted, err := resilience.NewTransport(resilience.TransportConfig{})
+	require.NoError(t, err)
+	_, err = (&http.Client{Transport: untrusted}).Get(srv.URL)
+	require.Error(t, err)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	block := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, block, 0o600))
+
+	trusted, err := resilience.NewTransport(resilience.TransportConfig{CAFile: caFile})
+	require.NoError(t, err)
+	resp, err := (&http.Client{Transport: trusted}).Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusNoContent, resp.StatusCode)
+}
+
+func TestNewTransportRejectsBadSettings(t *testing.T) {
+	_, err := resilience.NewTransport(resilience.TransportConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")})
+	require.ErrorContains(t, err, "ca file")
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	require.NoError(t, os.WriteFile(empty, []byte("not a certificate"), 0o600))
+	_, err = resilience.NewTransport(resilience.TransportConfig{CAFile: empty})
+	require.ErrorContains(t, err, "no PEM certificates")
+
+	_, err = resilience.NewTransport(resilience.TransportConfig{ProxyURL: "ftp://proxy.internal"})
+	require.ErrorContains(t, err, "unsupported scheme")
+}
+
+func TestNewTransportUsesConfiguredProxy(t *testing.T) {
+	transport, err := resilience.NewTransport(resilience.TransportConfig{ProxyURL: "http://proxy.internal:3128"})
+	require.NoError(t, err)
+
+	target, _ := url.Parse("https://partner.example.com/hook")
+	proxy, err := transport.Proxy(&http.Request{URL: target})
+	require.NoError(t, err)
+	require.Equal(t, "proxy.internal:3128", proxy.Host)
+}
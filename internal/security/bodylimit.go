@@ -5,11 +5,16 @@ import (
 	"errors"
 	"io"
 	"net/http"
+
+	"github.com/noah-isme/backend-toko/internal/common"
 )
 
 // BodyLimit enforces a maximum request payload size.
 type BodyLimit struct {
 	Max int64
+	// Skip lists request paths that enforce their own, larger limit (e.g.
+	// multipart uploads) and must not be buffered here.
+	Skip []string
 }
 
 // Middleware rejects requests exceeding the configured limit with HTTP 413.
@@ -19,20 +24,26 @@ func (b BodyLimit) Middleware(next http.Handler) http.Handler {
 			next.ServeHTTP(w, r)
 			return
 		}
+		for _, path := range b.Skip {
+			if r.URL.Path == path {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
 
 		if r.ContentLength > b.Max && r.ContentLength != -1 {
-			http.Error(w, "request entity too large", http.StatusRequestEntityTooLarge)
+			common.JSONError(w, http.StatusRequestEntityTooLarge, common.CodePayloadTooLarge, "request entity too large", map[string]any{"maxBytes": b.Max})
 			return
 		}
 
 		limited := io.LimitReader(r.Body, b.Max+1)
 		buf, err := io.ReadAll(limited)
 		if err != nil && !errors.Is(err, io.EOF) {
-			http.Error(w, "invalid request body", http.StatusBadRequest)
+			common.JSONError(w, http.StatusBadRequest, common.CodeInvalidBody, "invalid request body", nil)
 			return
 		}
 		if int64(len(buf)) > b.Max {
-			http.Error(w, "request entity too large", http.StatusRequestEntityTooLarge)
+			common.JSONError(w, http.StatusRequestEntityTooLarge, common.CodePayloadTooLarge, "request entity too large", map[string]any{"maxBytes": b.Max})
 			return
 		}
 
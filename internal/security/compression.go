@@ -0,0 +1,244 @@
+package security
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+)
+
+// Decompress inflates request bodies sent with Content-Encoding: gzip. It must
+// run before BodyLimit so the limit applies to the decompressed payload.
+type Decompress struct {
+	// Max caps the decompressed size so a small compressed body cannot expand
+	// without bound (a zip bomb). Zero disables decompression.
+	Max int64
+	// Skip lists request paths that read their own body and must not be
+	// buffered here.
+	Skip []string
+}
+
+// Middleware replaces gzip request bodies with their decompressed bytes.
+func (d Decompress) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		encoding := strings.TrimSpace(r.Header.Get("Content-Encoding"))
+		if d.Max <= 0 || r.Body == nil || encoding == "" || strings.EqualFold(encoding, "identity") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		for _, path := range d.Skip {
+			if r.URL.Path == path {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+		if !strings.EqualFold(encoding, "gzip") && !strings.EqualFold(encoding, "x-gzip") {
+			common.JSONError(w, http.StatusUnsupportedMediaType, common.CodeUnsupportedMediaType, "unsupported content encoding", map[string]any{"encoding": encoding})
+			return
+		}
+
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			common.JSONError(w, http.StatusBadRequest, common.CodeInvalidBody, "invalid gzip body", nil)
+			return
+		}
+		buf, err := io.ReadAll(io.LimitReader(zr, d.Max+1))
+		_ = zr.Close()
+		if err != nil && !errors.Is(err, io.EOF) {
+			common.JSONError(w, http.StatusBadRequest, common.CodeInvalidBody, "invalid gzip body", nil)
+			return
+		}
+		if int64(len(buf)) > d.Max {
+			common.JSONError(w, http.StatusRequestEntityTooLarge, common.CodePayloadTooLarge, "request entity too large", map[string]any{"maxBytes": d.Max})
+			return
+		}
+		_ = r.Body.Close()
+
+		r.Header.Del("Content-Encoding")
+		r.Header.Set("Content-Length", strconv.Itoa(len(buf)))
+		r.Body = io.NopCloser(bytes.NewReader(buf))
+		r.ContentLength = int64(len(buf))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// incompressibleTypes are content type prefixes that are already compressed,
+// where gzip costs CPU without shrinking the payload.
+var incompressibleTypes = []string{
+	"image/", "video/", "audio/", "font/woff",
+	"application/zip", "application/gzip", "application/x-gzip",
+	"application/pdf", "application/octet-stream", "text/event-stream",
+}
+
+// Compress gzips responses for clients that accept it once the body reaches
+// MinSize bytes. Smaller responses are sent as is since the gzip framing
+// would outweigh the savings.
+type Compress struct {
+	MinSize int
+	// Level is a compress/gzip level; zero uses gzip.DefaultCompression.
+	Level int
+}
+
+// Middleware wraps the response writer when the request accepts gzip.
+func (c Compress) Middleware(next http.Handler) http.Handler {
+	level := c.Level
+	if level == 0 {
+		level = gzip.DefaultCompression
+	}
+	pool := &sync.Pool{New: func() any {
+		zw, err := gzip.NewWriterLevel(io.Discard, level)
+		if err != nil {
+			zw = gzip.NewWriter(io.Discard)
+		}
+		return zw
+	}}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if r.Method == http.MethodHead || !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		gw := &gzipResponseWriter{ResponseWriter: w, minSize: c.MinSize, pool: pool}
+		defer gw.finish()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), "gzip") && strings.TrimSpace(name) != "*" {
+			continue
+		}
+		if q, ok := strings.CutPrefix(strings.ReplaceAll(params, " ", ""), "q="); ok {
+			if v, err := strconv.ParseFloat(q, 64); err == nil && v == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// gzipResponseWriter buffers the start of a response until it knows whether
+// the body is large enough and of a type worth compressing.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minSize int
+	pool    *sync.Pool
+
+	status  int
+	buf     []byte
+	decided bool
+	zw      *gzip.Writer
+}
+
+func (g *gzipResponseWriter) WriteHeader(status int) {
+	if g.decided || g.status != 0 {
+		return
+	}
+	g.status = status
+}
+
+func (g *gzipResponseWriter) Write(p []byte) (int, error) {
+	if g.status == 0 {
+		g.status = http.StatusOK
+	}
+	if g.decided {
+		if g.zw != nil {
+			return g.zw.Write(p)
+		}
+		return g.ResponseWriter.Write(p)
+	}
+	g.buf = append(g.buf, p...)
+	if len(g.buf) >= g.minSize {
+		if err := g.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// decide commits the headers, compressing when allowed and the response is
+// eligible, then flushes whatever was buffered.
+func (g *gzipResponseWriter) decide(allowed bool) error {
+	g.decided = true
+	header := g.Header()
+	if allowed && g.compressible(header) {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", "gzip")
+		g.ResponseWriter.WriteHeader(g.status)
+		g.zw = g.pool.Get().(*gzip.Writer)
+		g.zw.Reset(g.ResponseWriter)
+		_, err := g.zw.Write(g.buf)
+		g.buf = nil
+		return err
+	}
+	if g.status != 0 {
+		g.ResponseWriter.WriteHeader(g.status)
+	}
+	if len(g.buf) == 0 {
+		return nil
+	}
+	_, err := g.ResponseWriter.Write(g.buf)
+	g.buf = nil
+	return err
+}
+
+func (g *gzipResponseWriter) compressible(header http.Header) bool {
+	if header.Get("Content-Encoding") != "" {
+		return false
+	}
+	if g.status < http.StatusOK || g.status == http.StatusNoContent || g.status == http.StatusNotModified {
+		return false
+	}
+	contentType := strings.ToLower(header.Get("Content-Type"))
+	if contentType == "" {
+		contentType = strings.ToLower(http.DetectContentType(g.buf))
+	}
+	for _, prefix := range incompressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return false
+		}
+	}
+	return true
+}
+
+// Flush sends buffered bytes so streaming handlers keep working; a response
+// flushed before reaching MinSize is sent uncompressed.
+func (g *gzipResponseWriter) Flush() {
+	if !g.decided {
+		_ = g.decide(len(g.buf) >= g.minSize)
+	}
+	if g.zw != nil {
+		_ = g.zw.Flush()
+	}
+	if f, ok := g.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (g *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
+
+func (g *gzipResponseWriter) finish() {
+	if !g.decided {
+		if g.status == 0 && len(g.buf) == 0 {
+			return
+		}
+		_ = g.decide(false)
+	}
+	if g.zw != nil {
+		_ = g.zw.Close()
+		g.zw.Reset(io.Discard)
+		g.pool.Put(g.zw)
+		g.zw = nil
+	}
+}
@@ -0,0 +1,145 @@
+package security
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func gzipBytes(t *testing.T, data []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		t.Fatalf("gzip write: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip close: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestGzipProductImportRoundTrip(t *testing.T) {
+	type product struct {
+		Title string `json:"title"`
+		Slug  string `json:"slug"`
+		Price int64  `json:"price"`
+	}
+	products := make([]product, 200)
+	for i := range products {
+		products[i] = product{Title: fmt.Sprintf("Kaos %d", i), Slug: fmt.Sprintf("kaos-%d", i), Price: 99000}
+	}
+	payload, err := json.Marshal(map[string]any{"products": products})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	chain := Compress{MinSize: 1024}.Middleware(
+		Decompress{Max: 1 << 20}.Middleware(
+			BodyLimit{Max: 1 << 20}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Content-Encoding") != "" {
+					t.Errorf("handler should see a decoded body")
+				}
+				var decoded map[string][]product
+				if err := json.NewDecoder(r.Body).Decode(&decoded); err != nil {
+					t.Errorf("decode import body: %v", err)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				_ = json.NewEncoder(w).Encode(decoded)
+			})),
+		),
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/products/import", bytes.NewReader(gzipBytes(t, payload)))
+	req.Header.Set("Content-Encoding", "gzip")
+	req.Header.Set("Accept-Encoding", "br, gzip")
+	rr := httptest.NewRecorder()
+	chain.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rr.Code, rr.Body.String())
+	}
+	if rr.Header().Get("Content-Encoding") != "gzip" {
+		t.Fatalf("expected gzip response, headers %v", rr.Header())
+	}
+	zr, err := gzip.NewReader(rr.Body)
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+	body, err := io.ReadAll(zr)
+	if err != nil {
+		t.Fatalf("read gzip response: %v", err)
+	}
+	if strings.TrimSpace(string(body)) != string(payload) {
+		t.Fatal("response did not round-trip the import payload")
+	}
+}
+
+func TestDecompressRejectsBombs(t *testing.T) {
+	handler := Decompress{Max: 1024}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("oversized body should not reach the handler")
+	}))
+	compressed := gzipBytes(t, bytes.Repeat([]byte("a"), 1<<20))
+	req := httptest.NewRequest(http.MethodPost, "/payload", bytes.NewReader(compressed))
+	req.Header.Set("Content-Encoding", "gzip")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("expected 413, got %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/payload", strings.NewReader("not gzip"))
+	req.Header.Set("Content-Encoding", "gzip")
+	rr = httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for corrupt gzip, got %d", rr.Code)
+	}
+}
+
+func TestCompressSkipsSmallAndCompressedResponses(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		size        int
+		accept      string
+		wantGzip    bool
+	}{
+		{"large json", "application/json", 4096, "gzip", true},
+		{"below threshold", "application/json", 100, "gzip", false},
+		{"image", "image/png", 4096, "gzip", false},
+		{"client without gzip", "application/json", 4096, "", false},
+		{"gzip refused", "application/json", 4096, "gzip;q=0", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			body := strings.Repeat("x", tc.size)
+			handler := Compress{MinSize: 1024}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tc.contentType)
+				w.WriteHeader(http.StatusCreated)
+				_, _ = io.WriteString(w, body)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tc.accept != "" {
+				req.Header.Set("Accept-Encoding", tc.accept)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+			if rr.Code != http.StatusCreated {
+				t.Fatalf("status %d", rr.Code)
+			}
+			if got := rr.Header().Get("Content-Encoding") == "gzip"; got != tc.wantGzip {
+				t.Fatalf("gzip = %v, want %v", got, tc.wantGzip)
+			}
+			if !tc.wantGzip && rr.Body.String() != body {
+				t.Fatal("uncompressed body altered")
+			}
+		})
+	}
+}
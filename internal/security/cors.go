@@ -0,0 +1,213 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+)
+
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"}
+	defaultCORSHeaders = []string{"Authorization", "Content-Type", "Accept", "X-CSRF-Token", "X-Request-ID", "Idempotency-Key", "X-Maintenance-Bypass"}
+	defaultCORSExposed = []string{"Link", "X-Request-ID", "Retry-After", "X-Maintenance-Mode", "X-RateLimit-Warning"}
+)
+
+// CORSPolicy describes the cross-origin rules for one route group.
+type CORSPolicy struct {
+	// AllowedOrigins lists exact origins (scheme://host[:port]). "*" allows any
+	// origin and cannot be combined with AllowCredentials.
+	AllowedOrigins []string
+	// AllowedMethods, AllowedHeaders, and ExposedHeaders default to the
+	// methods and headers the API uses when empty.
+	AllowedMethods []string
+	AllowedHeaders []string
+	ExposedHeaders []string
+	// MaxAge lets browsers cache preflight results. Zero omits the header.
+	MaxAge           time.Duration
+	AllowCredentials bool
+	// Except lists path prefixes whose group applies its own policy.
+	Except []string
+}
+
+// CORS enforces a validated CORSPolicy. Build it with NewCORS and mount
+// Middleware on the chi router or route group the policy belongs to.
+type CORS struct {
+	policy   CORSPolicy
+	origins  map[string]struct{}
+	wildcard bool
+	methods  map[string]struct{}
+	headers  map[string]struct{}
+
+	allowMethods string
+	allowHeaders string
+	exposed      string
+	maxAge       string
+}
+
+// NewCORS validates policy and prepares it for request matching.
+func NewCORS(policy CORSPolicy) (*CORS, error) {
+	c := &CORS{policy: policy, origins: map[string]struct{}{}, methods: map[string]struct{}{}, headers: map[string]struct{}{}}
+	for _, raw := range policy.AllowedOrigins {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		if raw == "*" {
+			c.wildcard = true
+			continue
+		}
+		origin, err := normalizeOrigin(raw)
+		if err != nil {
+			return nil, err
+		}
+		c.origins[origin] = struct{}{}
+	}
+	if c.wildcard && policy.AllowCredentials {
+		return nil, errors.New("cors: credentials cannot be allowed for a wildcard origin")
+	}
+
+	configured := policy.AllowedMethods
+	if len(configured) == 0 {
+		configured = defaultCORSMethods
+	}
+	methods := make([]string, 0, len(configured))
+	for _, m := range configured {
+		m = strings.ToUpper(strings.TrimSpace(m))
+		methods = append(methods, m)
+		c.methods[m] = struct{}{}
+	}
+	headers := policy.AllowedHeaders
+	if len(headers) == 0 {
+		headers = defaultCORSHeaders
+	}
+	for _, h := range headers {
+		c.headers[strings.ToLower(strings.TrimSpace(h))] = struct{}{}
+	}
+	exposed := policy.ExposedHeaders
+	if len(exposed) == 0 {
+		exposed = defaultCORSExposed
+	}
+
+	c.allowMethods = strings.Join(methods, ", ")
+	c.allowHeaders = strings.Join(headers, ", ")
+	c.exposed = strings.Join(exposed, ", ")
+	if policy.MaxAge > 0 {
+		c.maxAge = strconv.Itoa(int(policy.MaxAge.Seconds()))
+	}
+	return c, nil
+}
+
+// Middleware applies the policy. Preflight requests are answered directly;
+// other requests get CORS headers only when their origin is allowed.
+func (c *CORS) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, prefix := range c.policy.Except {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
+		origin := strings.TrimSpace(r.Header.Get("Origin"))
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		headers := w.Header()
+		headers.Add("Vary", "Origin")
+		allowOrigin, ok := c.matchOrigin(origin)
+		preflight := r.Method == http.MethodOptions
+		if !ok {
+			if preflight {
+				common.JSONError(w, http.StatusForbidden, common.CodeForbidden, "cors origin not allowed", nil)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		headers.Set("Access-Control-Allow-Origin", allowOrigin)
+		if c.policy.AllowCredentials {
+			headers.Set("Access-Control-Allow-Credentials", "true")
+		}
+		if !preflight {
+			headers.Set("Access-Control-Expose-Headers", c.exposed)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		headers.Add("Vary", "Access-Control-Request-Method")
+		headers.Add("Vary", "Access-Control-Request-Headers")
+		if method := r.Header.Get("Access-Control-Request-Method"); method != "" {
+			if _, ok := c.methods[strings.ToUpper(method)]; !ok {
+				common.JSONError(w, http.StatusForbidden, common.CodeForbidden, "cors method not allowed", map[string]any{"method": method})
+				return
+			}
+		}
+		for _, h := range strings.Split(r.Header.Get("Access-Control-Request-Headers"), ",") {
+			h = strings.ToLower(strings.TrimSpace(h))
+			if h == "" {
+				continue
+			}
+			if _, ok := c.headers[h]; !ok {
+				common.JSONError(w, http.StatusForbidden, common.CodeForbidden, "cors header not allowed", map[string]any{"header": h})
+				return
+			}
+		}
+		headers.Set("Access-Control-Allow-Methods", c.allowMethods)
+		headers.Set("Access-Control-Allow-Headers", c.allowHeaders)
+		if c.maxAge != "" {
+			headers.Set("Access-Control-Max-Age", c.maxAge)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+// matchOrigin returns the Access-Control-Allow-Origin value for origin.
+// Listed origins are reflected in their canonical form; only a policy with a
+// wildcard and no credentials answers "*".
+func (c *CORS) matchOrigin(origin string) (string, bool) {
+	if normalized, err := normalizeOrigin(origin); err == nil {
+		if _, ok := c.origins[normalized]; ok {
+			return normalized, true
+		}
+	}
+	if c.wildcard {
+		return "*", true
+	}
+	return "", false
+}
+
+func normalizeOrigin(raw string) (string, error) {
+	u, err := url.Parse(strings.TrimRight(strings.TrimSpace(raw), "/"))
+	if err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" || u.RawQuery != "" || u.User != nil {
+		return "", fmt.Errorf("cors: invalid origin %q", raw)
+	}
+	return strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host), nil
+}
+
+// AllowCORS returns middleware enforcing an allowlist of origins. Listed
+// origins may send credentials; a "*" entry allows any origin without them.
+// Malformed entries are ignored. Use NewCORS for anything more specific.
+func AllowCORS(originsCSV string) func(http.Handler) http.Handler {
+	var origins []string
+	wildcard := false
+	for _, o := range strings.Split(originsCSV, ",") {
+		o = strings.TrimSpace(o)
+		if o == "*" {
+			wildcard = true
+		}
+		if _, err := normalizeOrigin(o); err == nil || o == "*" {
+			origins = append(origins, o)
+		}
+	}
+	cors, err := NewCORS(CORSPolicy{AllowedOrigins: origins, AllowCredentials: !wildcard})
+	if err != nil {
+		panic(err)
+	}
+	return cors.Middleware
+}
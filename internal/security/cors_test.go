@@ -0,0 +1,140 @@
+package security
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func TestNewCORSRejectsWildcardWithCredentials(t *testing.T) {
+	if _, err := NewCORS(CORSPolicy{AllowedOrigins: []string{"*"}, AllowCredentials: true}); err == nil {
+		t.Fatal("expected wildcard origin with credentials to be rejected")
+	}
+	if _, err := NewCORS(CORSPolicy{AllowedOrigins: []string{"example.com"}}); err == nil {
+		t.Fatal("expected origin without scheme to be rejected")
+	}
+	if _, err := NewCORS(CORSPolicy{AllowedOrigins: []string{"*"}}); err != nil {
+		t.Fatalf("expected wildcard without credentials to be accepted: %v", err)
+	}
+}
+
+func newGroupedCORSRouter(t *testing.T) http.Handler {
+	t.Helper()
+	public, err := NewCORS(CORSPolicy{
+		AllowedOrigins: []string{"https://shop.example", "https://dashboard.example"},
+		AllowedMethods: []string{"GET", "POST"},
+		MaxAge:         10 * time.Minute,
+		Except:         []string{"/admin"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err := NewCORS(CORSPolicy{
+		AllowedOrigins:   []string{"https://Dashboard.example/"},
+		AllowedHeaders:   []string{"Authorization", "Content-Type"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	r := chi.NewRouter()
+	r.Use(public.Middleware)
+	r.Get("/products", ok)
+	r.Route("/admin", func(a chi.Router) {
+		a.Use(admin.Middleware)
+		a.Put("/products/{id}", ok)
+	})
+	return r
+}
+
+func preflight(h http.Handler, path, origin, method, headers string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodOptions, path, nil)
+	req.Header.Set("Origin", origin)
+	req.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		req.Header.Set("Access-Control-Request-Headers", headers)
+	}
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestCORSPreflightPerRouteGroup(t *testing.T) {
+	h := newGroupedCORSRouter(t)
+
+	rr := preflight(h, "/products", "https://shop.example", "GET", "Content-Type")
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 for public preflight, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "https://shop.example" {
+		t.Fatalf("expected matched origin to be reflected, got %q", got)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Credentials"); got != "" {
+		t.Fatalf("expected no credentials header on public group, got %q", got)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Methods"); got != "GET, POST" {
+		t.Fatalf("unexpected allowed methods %q", got)
+	}
+	if got := rr.Header().Get("Access-Control-Max-Age"); got != "600" {
+		t.Fatalf("expected max-age 600, got %q", got)
+	}
+	if rr := preflight(h, "/products", "https://shop.example", "DELETE", ""); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for disallowed method, got %d", rr.Code)
+	}
+
+	rr = preflight(h, "/admin/products/1", "https://shop.example", "PUT", "Authorization")
+	if rr.Code != http.StatusForbidden {
+		t.Fatalf("expected admin group to reject public-only origin, got %d", rr.Code)
+	}
+
+	rr = preflight(h, "/admin/products/1", "https://dashboard.example", "PUT", "authorization, content-type")
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 for admin preflight, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "https://dashboard.example" {
+		t.Fatalf("expected matched origin to be reflected, got %q", got)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Credentials"); got != "true" {
+		t.Fatalf("expected credentials on admin group, got %q", got)
+	}
+	if got := rr.Header().Get("Access-Control-Max-Age"); got != "" {
+		t.Fatalf("expected no max-age on admin group, got %q", got)
+	}
+	if rr := preflight(h, "/admin/products/1", "https://dashboard.example", "PUT", "X-Custom"); rr.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for disallowed header, got %d", rr.Code)
+	}
+}
+
+func TestCORSSimpleRequestHeaders(t *testing.T) {
+	h := newGroupedCORSRouter(t)
+
+	req := httptest.NewRequest(http.MethodPut, "/admin/products/1", nil)
+	req.Header.Set("Origin", "https://dashboard.example")
+	rr := httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Access-Control-Expose-Headers"); got != "X-Request-ID" {
+		t.Fatalf("unexpected exposed headers %q", got)
+	}
+	if got := rr.Header().Values("Vary"); len(got) != 1 || got[0] != "Origin" {
+		t.Fatalf("expected Vary: Origin once, got %v", got)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/products", nil)
+	req.Header.Set("Origin", "https://evil.example")
+	rr = httptest.NewRecorder()
+	h.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected disallowed simple request to reach handler, got %d", rr.Code)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Fatalf("expected no allow-origin for disallowed origin, got %q", got)
+	}
+}
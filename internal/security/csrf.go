@@ -4,11 +4,16 @@ import (
 	"crypto/subtle"
 	"net/http"
 	"strings"
+
+	"github.com/noah-isme/backend-toko/internal/common"
 )
 
 // CSRF protects cookie-based flows using the double-submit technique.
 type CSRF struct {
 	Header string
+	// Exempt lists request paths that only read data despite using POST (for
+	// example the storefront batch endpoint) and therefore skip the check.
+	Exempt []string
 }
 
 // Middleware enforces that non-idempotent requests include a CSRF token header matching a cookie.
@@ -24,6 +29,12 @@ func (c CSRF) Middleware(next http.Handler) http.Handler {
 			next.ServeHTTP(w, r)
 			return
 		}
+		for _, path := range c.Exempt {
+			if r.URL.Path == path {
+				next.ServeHTTP(w, r)
+				return
+			}
+		}
 
 		auth := strings.TrimSpace(r.Header.Get("Authorization"))
 		if strings.HasPrefix(strings.ToLower(auth), "bearer ") {
@@ -33,18 +44,18 @@ func (c CSRF) Middleware(next http.Handler) http.Handler {
 
 		token := strings.TrimSpace(r.Header.Get(headerName))
 		if token == "" {
-			http.Error(w, "missing csrf token", http.StatusForbidden)
+			common.JSONError(w, http.StatusForbidden, common.CodeCSRFInvalid, "missing csrf token", nil)
 			return
 		}
 
 		cookie, err := r.Cookie(headerName)
 		if err != nil || strings.TrimSpace(cookie.Value) == "" {
-			http.Error(w, "missing csrf cookie", http.StatusForbidden)
+			common.JSONError(w, http.StatusForbidden, common.CodeCSRFInvalid, "missing csrf cookie", nil)
 			return
 		}
 
 		if subtleConstantTimeCompare(token, cookie.Value) != 1 {
-			http.Error(w, "invalid csrf token", http.StatusForbidden)
+			common.JSONError(w, http.StatusForbidden, common.CodeCSRFInvalid, "invalid csrf token", nil)
 			return
 		}
 
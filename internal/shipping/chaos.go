@@ -0,0 +1,31 @@
+package shipping
+
+import (
+	"context"
+
+	"github.com/noah-isme/backend-toko/internal/resilience"
+)
+
+// ChaosTarget is the chaos testing target name of shipping providers.
+const ChaosTarget = "shipping"
+
+// WithChaos wraps provider so tracking lookups fail and slow down as chaos
+// dictates. Without chaos for shipping provider is returned unchanged.
+func WithChaos(provider Provider, chaos *resilience.Chaos) Provider {
+	if provider == nil || !chaos.Applies(ChaosTarget) {
+		return provider
+	}
+	return chaosProvider{Provider: provider, chaos: chaos}
+}
+
+type chaosProvider struct {
+	Provider
+	chaos *resilience.Chaos
+}
+
+func (p chaosProvider) Track(ctx context.Context, req TrackReq) ([]TrackEvent, error) {
+	if err := p.chaos.Inject(ctx, ChaosTarget); err != nil {
+		return nil, err
+	}
+	return p.Provider.Track(ctx, req)
+}
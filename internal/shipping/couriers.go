@@ -0,0 +1,230 @@
+package shipping
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+// TenantCourierPolicyKey is the tenant_settings key holding a tenant's
+// CourierPolicy as JSON. When present it replaces the default policy.
+const TenantCourierPolicyKey = "shipping.couriers"
+
+// CourierPolicy filters and orders the rates a provider returns. The first
+// rule whose regions match the destination applies; Prefer orders the
+// remaining options when that rule sets no preference of its own.
+type CourierPolicy struct {
+	Prefer []string      `json:"prefer,omitempty"`
+	Rules  []CourierRule `json:"rules,omitempty"`
+}
+
+// CourierRule limits the couriers offered in Regions, matched like Rule
+// regions. Entries name a courier ("jne") or one of its services
+// ("jne:YES"), case-insensitively. A non-empty Allow keeps only the listed
+// options, Deny drops options, and Prefer lists options to show first.
+type CourierRule struct {
+	Name    string   `json:"name,omitempty"`
+	Regions []string `json:"regions,omitempty"`
+	Allow   []string `json:"allow,omitempty"`
+	Deny    []string `json:"deny,omitempty"`
+	Prefer  []string `json:"prefer,omitempty"`
+}
+
+// Validate rejects a policy with empty entries or a rule that both allows
+// and denies the same option.
+func (p CourierPolicy) Validate() error {
+	lists := [][]string{p.Prefer}
+	for _, rule := range p.Rules {
+		lists = append(lists, rule.Allow, rule.Deny, rule.Prefer)
+		for _, entry := range rule.Allow {
+			if slices.ContainsFunc(rule.Deny, func(d string) bool { return strings.EqualFold(strings.TrimSpace(d), strings.TrimSpace(entry)) }) {
+				return fmt.Errorf("rule %q both allows and denies %q", rule.Name, entry)
+			}
+		}
+	}
+	for _, list := range lists {
+		for _, entry := range list {
+			courier, service, _ := strings.Cut(strings.TrimSpace(entry), ":")
+			if strings.TrimSpace(courier) == "" || (strings.Contains(entry, ":") && strings.TrimSpace(service) == "") {
+				return fmt.Errorf("invalid courier entry %q", entry)
+			}
+		}
+	}
+	return nil
+}
+
+// Apply returns the rates the policy offers for destinations, preferred
+// options first and otherwise in provider order. Rates without a courier
+// take courier, the one that was quoted.
+func (p CourierPolicy) Apply(rates []Rate, courier string, destinations ...string) []Rate {
+	prefer := p.Prefer
+	var rule *CourierRule
+	for i := range p.Rules {
+		if (Rule{Regions: p.Rules[i].Regions}).matches(destinations) {
+			rule = &p.Rules[i]
+			break
+		}
+	}
+	if rule != nil && len(rule.Prefer) > 0 {
+		prefer = rule.Prefer
+	}
+	out := make([]Rate, 0, len(rates))
+	for _, rate := range rates {
+		if rate.Courier == "" {
+			rate.Courier = courier
+		}
+		if rule != nil {
+			if len(rule.Allow) > 0 && rank(rule.Allow, rate) < 0 {
+				continue
+			}
+			if rank(rule.Deny, rate) >= 0 {
+				continue
+			}
+		}
+		out = append(out, rate)
+	}
+	if len(prefer) > 0 {
+		slices.SortStableFunc(out, func(a, b Rate) int {
+			return preferRank(prefer, a) - preferRank(prefer, b)
+		})
+	}
+	return out
+}
+
+// Offers reports whether the policy offers service from courier at
+// destinations.
+func (p CourierPolicy) Offers(courier, service string, destinations ...string) bool {
+	return len(p.Apply([]Rate{{Courier: courier, Service: service}}, courier, destinations...)) > 0
+}
+
+// rank is the index of the first entry naming rate, or -1.
+func rank(entries []string, rate Rate) int {
+	for i, entry := range entries {
+		c, s, hasService := strings.Cut(strings.TrimSpace(entry), ":")
+		if !strings.EqualFold(strings.TrimSpace(c), strings.TrimSpace(rate.Courier)) {
+			continue
+		}
+		if !hasService || strings.EqualFold(strings.TrimSpace(s), strings.TrimSpace(rate.Service)) {
+			return i
+		}
+	}
+	return -1
+}
+
+func preferRank(prefer []string, rate Rate) int {
+	if i := rank(prefer, rate); i >= 0 {
+		return i
+	}
+	return len(prefer)
+}
+
+// TenantCourierPolicy returns the courier policy for tenantID, falling back
+// to defaults when the tenant has none or its setting is malformed.
+func TenantCourierPolicy(ctx context.Context, q tenantSettings, tenantID string, defaults CourierPolicy) (CourierPolicy, error) {
+	raw, err := q.GetTenantSetting(ctx, dbgen.GetTenantSettingParams{Tenant: tenantID, Key: TenantCourierPolicyKey})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return defaults, nil
+		}
+		return CourierPolicy{}, fmt.Errorf("load courier policy: %w", err)
+	}
+	var policy CourierPolicy
+	if err := json.Unmarshal(raw, &policy); err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Str("tenant", tenantID).Msg("malformed tenant courier policy; using defaults")
+		return defaults, nil
+	}
+	if err := policy.Validate(); err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Str("tenant", tenantID).Msg("invalid tenant courier policy; using defaults")
+		return defaults, nil
+	}
+	return policy, nil
+}
+
+// CourierAdmin exposes a tenant's courier policy to admins.
+type CourierAdmin struct {
+	Q *dbgen.Queries
+	// Defaults is the policy of tenants without their own.
+	Defaults CourierPolicy
+}
+
+// Get handles GET /api/v1/admin/tenants/{tenant}/shipping/couriers. Source
+// tells whether the tenant has its own policy or uses the default.
+func (h *CourierAdmin) Get(w http.ResponseWriter, r *http.Request) {
+	if h.Q == nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "shipping queries not configured", nil)
+		return
+	}
+	raw, err := h.Q.GetTenantSetting(r.Context(), dbgen.GetTenantSettingParams{Tenant: chi.URLParam(r, "tenant"), Key: TenantCourierPolicyKey})
+	source, policy := "tenant", h.Defaults
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		source = "default"
+	case err != nil:
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to load courier policy", nil)
+		return
+	default:
+		policy = CourierPolicy{}
+		if err := json.Unmarshal(raw, &policy); err != nil {
+			common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "stored courier policy is malformed", nil)
+			return
+		}
+	}
+	common.JSON(w, http.StatusOK, map[string]any{"data": map[string]any{"source": source, "policy": policy}})
+}
+
+// Put handles PUT /api/v1/admin/tenants/{tenant}/shipping/couriers,
+// replacing the tenant's policy.
+func (h *CourierAdmin) Put(w http.ResponseWriter, r *http.Request) {
+	if h.Q == nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "shipping queries not configured", nil)
+		return
+	}
+	var policy CourierPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		common.JSONError(w, http.StatusBadRequest, common.CodeInvalidBody, "invalid payload", nil)
+		return
+	}
+	if err := policy.Validate(); err != nil {
+		common.JSONError(w, http.StatusBadRequest, common.CodeValidation, err.Error(), nil)
+		return
+	}
+	raw, err := json.Marshal(policy)
+	if err != nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to encode courier policy", nil)
+		return
+	}
+	if _, err := h.Q.UpsertTenantSetting(r.Context(), dbgen.UpsertTenantSettingParams{Tenant: chi.URLParam(r, "tenant"), Key: TenantCourierPolicyKey, Value: raw}); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			common.JSONError(w, http.StatusNotFound, common.CodeNotFound, "tenant not found", nil)
+			return
+		}
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to save courier policy", nil)
+		return
+	}
+	common.JSON(w, http.StatusOK, map[string]any{"data": map[string]any{"source": "tenant", "policy": policy}})
+}
+
+// Delete handles DELETE /api/v1/admin/tenants/{tenant}/shipping/couriers,
+// returning the tenant to the default policy.
+func (h *CourierAdmin) Delete(w http.ResponseWriter, r *http.Request) {
+	if h.Q == nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "shipping queries not configured", nil)
+		return
+	}
+	if _, err := h.Q.DeleteTenantSetting(r.Context(), dbgen.DeleteTenantSettingParams{Tenant: chi.URLParam(r, "tenant"), Key: TenantCourierPolicyKey}); err != nil {
+		common.JSONError(w, http.StatusInternalServerError, common.CodeInternal, "failed to delete courier policy", nil)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
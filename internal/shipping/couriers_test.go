@@ -0,0 +1,60 @@
+package shipping_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/shipping"
+)
+
+func services(rates []shipping.Rate) []string {
+	out := make([]string, 0, len(rates))
+	for _, r := range rates {
+		out = append(out, r.Courier+":"+r.Service)
+	}
+	return out
+}
+
+func TestCourierPolicyFiltersAndOrdersByRegion(t *testing.T) {
+	t.Parallel()
+
+	policy := shipping.CourierPolicy{
+		Prefer: []string{"sicepat"},
+		Rules: []shipping.CourierRule{
+			{Name: "papua", Regions: []string{"99*"}, Allow: []string{"pos"}},
+			{Name: "jakarta", Regions: []string{"Jakarta"}, Deny: []string{"jne:YES"}, Prefer: []string{"jne:REG", "anteraja"}},
+		},
+	}
+	rates := []shipping.Rate{
+		{Courier: "anteraja", Service: "REG"},
+		{Courier: "jne", Service: "YES"},
+		{Courier: "sicepat", Service: "BEST"},
+		{Courier: "jne", Service: "REG"},
+	}
+
+	require.Equal(t, []string{"jne:REG", "anteraja:REG", "sicepat:BEST"}, services(policy.Apply(rates, "", "jakarta")))
+	require.Equal(t, []string{"sicepat:BEST", "anteraja:REG", "jne:YES", "jne:REG"}, services(policy.Apply(rates, "", "Bandung")))
+	require.Empty(t, policy.Apply(rates, "", "99111"))
+	require.False(t, policy.Offers("JNE", "yes", "", "10110", "Jakarta"))
+	require.True(t, policy.Offers("jne", "REG", "", "10110", "Jakarta"))
+}
+
+func TestCourierPolicyFillsQuotedCourier(t *testing.T) {
+	t.Parallel()
+
+	policy := shipping.CourierPolicy{Rules: []shipping.CourierRule{{Deny: []string{"tiki"}}}}
+	require.Empty(t, policy.Apply([]shipping.Rate{{Service: "REG"}}, "tiki", "Bandung"))
+
+	got := shipping.CourierPolicy{}.Apply([]shipping.Rate{{Service: "REG"}}, "jne", "Bandung")
+	require.Equal(t, []string{"jne:REG"}, services(got))
+}
+
+func TestCourierPolicyValidate(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, shipping.CourierPolicy{Prefer: []string{"jne:REG", "pos"}}.Validate())
+	require.Error(t, shipping.CourierPolicy{Prefer: []string{" "}}.Validate())
+	require.Error(t, shipping.CourierPolicy{Prefer: []string{"jne:"}}.Validate())
+	require.Error(t, shipping.CourierPolicy{Rules: []shipping.CourierRule{{Allow: []string{"jne"}, Deny: []string{"JNE"}}}}.Validate())
+}
@@ -2,12 +2,15 @@ package shipping_test
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 
 	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/events"
+	"github.com/noah-isme/backend-toko/internal/reqcache"
 	"github.com/noah-isme/backend-toko/internal/shipping"
 )
 
@@ -37,3 +40,50 @@ func TestNotifyOnDelivered(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, []string{"Terkirim"}, mailer.Subjects())
 }
+
+type eventRecorder struct {
+	payloads [][]byte
+}
+
+func (r *eventRecorder) InsertDomainEvent(_ context.Context, arg dbgen.InsertDomainEventParams) (dbgen.InsertDomainEventRow, error) {
+	r.payloads = append(r.payloads, arg.Payload)
+	return dbgen.InsertDomainEventRow{ID: toPGUUID(uuid.New()), Topic: arg.Topic, AggregateID: arg.AggregateID, Payload: arg.Payload}, nil
+}
+
+func TestTrackingUpdateLoadsRecipientOncePerRequest(t *testing.T) {
+	t.Parallel()
+
+	for _, cached := range []bool{false, true} {
+		orderID := uuid.New()
+		queries := newMockQueries()
+		queries.addOrder(dbgen.Order{ID: toPGUUID(orderID), UserID: toPGUUID(uuid.New()), Status: dbgen.OrderStatusPAID}, "buyer@example.com")
+		mailer := &recordingMailer{}
+		recorder := &eventRecorder{}
+		svc := &shipping.Service{
+			Q:               queries,
+			Mail:            mailer,
+			NotifyOnShipped: true,
+			Events:          &events.Bus{Store: recorder},
+			RequestCache:    cached,
+		}
+		_, err := svc.Create(context.Background(), toPGUUID(orderID), "jne", "TRACK123")
+		require.NoError(t, err)
+		queries.orderReads, queries.userReads = 0, 0
+
+		ctx := reqcache.WithCache(context.Background())
+		_, _, err = svc.AppendEvent(ctx, toPGUUID(orderID), dbgen.ShipmentStatusSHIPPED, nil, nil, nil, []byte(`{}`))
+		require.NoError(t, err)
+
+		want := 2
+		if cached {
+			want = 1
+		}
+		require.Equal(t, want, queries.orderReads, "cached=%v", cached)
+		require.Equal(t, want, queries.userReads, "cached=%v", cached)
+		require.Equal(t, []string{"Pesanan dikirim"}, mailer.Subjects())
+		require.Len(t, recorder.payloads, 1)
+		var payload events.ShipmentPayload
+		require.NoError(t, json.Unmarshal(recorder.payloads[0], &payload))
+		require.Equal(t, "buyer@example.com", payload.Email)
+	}
+}
@@ -0,0 +1,86 @@
+package shipping
+
+// DefaultItemWeightGram is the weight assumed for a unit whose variant has no
+// weight, when ParcelConfig does not set one.
+const DefaultItemWeightGram = 500
+
+// DefaultVolumetricDivisor is the common courier divisor: cubic centimetres
+// per kilogram of volumetric weight.
+const DefaultVolumetricDivisor = 6000
+
+// Parcel is what a cart weighs and measures once packed.
+type Parcel struct {
+	// WeightGram is the actual weight of every unit in the cart.
+	WeightGram int
+	// VolumetricWeightGram is the summed unit volume divided by the
+	// volumetric divisor; zero when no unit has dimensions or the divisor is
+	// disabled.
+	VolumetricWeightGram int
+	// LengthCm, WidthCm, and HeightCm estimate the box: the longest and widest
+	// unit, with every unit stacked on top of each other.
+	LengthCm int
+	WidthCm  int
+	HeightCm int
+}
+
+// ChargeableWeightGram is the weight couriers bill: the actual or the
+// volumetric weight, whichever is larger.
+func (p Parcel) ChargeableWeightGram() int {
+	return max(p.WeightGram, p.VolumetricWeightGram)
+}
+
+// ParcelItem is one cart line as far as shipping is concerned. Zero weight or
+// dimensions mean the variant has none recorded.
+type ParcelItem struct {
+	Qty        int
+	WeightGram int
+	LengthCm   int
+	WidthCm    int
+	HeightCm   int
+}
+
+// ParcelConfig turns cart lines into a Parcel.
+type ParcelConfig struct {
+	// DefaultItemWeightGram is used per unit when its variant has no weight;
+	// zero means DefaultItemWeightGram.
+	DefaultItemWeightGram int
+	// VolumetricDivisor is in cubic centimetres per kilogram; zero disables
+	// volumetric weight.
+	VolumetricDivisor int
+}
+
+// Pack sums the weight and volume of items. An empty cart weighs one default
+// unit so providers are never asked to quote nothing.
+func (c ParcelConfig) Pack(items []ParcelItem) Parcel {
+	defaultWeight := c.DefaultItemWeightGram
+	if defaultWeight <= 0 {
+		defaultWeight = DefaultItemWeightGram
+	}
+	var p Parcel
+	var volume int64
+	for _, it := range items {
+		if it.Qty <= 0 {
+			continue
+		}
+		weight := it.WeightGram
+		if weight <= 0 {
+			weight = defaultWeight
+		}
+		p.WeightGram += weight * it.Qty
+		if it.LengthCm <= 0 || it.WidthCm <= 0 || it.HeightCm <= 0 {
+			continue
+		}
+		volume += int64(it.LengthCm) * int64(it.WidthCm) * int64(it.HeightCm) * int64(it.Qty)
+		p.LengthCm = max(p.LengthCm, it.LengthCm)
+		p.WidthCm = max(p.WidthCm, it.WidthCm)
+		p.HeightCm += it.HeightCm * it.Qty
+	}
+	if p.WeightGram == 0 {
+		p.WeightGram = defaultWeight
+	}
+	if c.VolumetricDivisor > 0 && volume > 0 {
+		// Round up to the next gram, as couriers never round volume down.
+		p.VolumetricWeightGram = int((volume*1000 + int64(c.VolumetricDivisor) - 1) / int64(c.VolumetricDivisor))
+	}
+	return p
+}
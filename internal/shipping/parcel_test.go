@@ -0,0 +1,51 @@
+package shipping_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/shipping"
+)
+
+func TestPackHeavyMultiItemCart(t *testing.T) {
+	t.Parallel()
+
+	cfg := shipping.ParcelConfig{DefaultItemWeightGram: 300, VolumetricDivisor: 6000}
+	parcel := cfg.Pack([]shipping.ParcelItem{
+		{Qty: 2, WeightGram: 4500, LengthCm: 40, WidthCm: 30, HeightCm: 20},
+		{Qty: 3, WeightGram: 1200},
+		{Qty: 1},
+	})
+
+	// 2×4.5kg + 3×1.2kg + one unmeasured unit at the default.
+	require.Equal(t, 12900, parcel.WeightGram)
+	// 2×24000cm³ / 6000 = 8kg, lighter than the actual weight.
+	require.Equal(t, 8000, parcel.VolumetricWeightGram)
+	require.Equal(t, 12900, parcel.ChargeableWeightGram())
+	require.Equal(t, 40, parcel.LengthCm)
+	require.Equal(t, 30, parcel.WidthCm)
+	require.Equal(t, 40, parcel.HeightCm)
+}
+
+func TestPackBulkyLightCartChargesVolume(t *testing.T) {
+	t.Parallel()
+
+	parcel := shipping.ParcelConfig{VolumetricDivisor: 5000}.Pack([]shipping.ParcelItem{
+		{Qty: 1, WeightGram: 800, LengthCm: 60, WidthCm: 50, HeightCm: 41},
+	})
+	require.Equal(t, 800, parcel.WeightGram)
+	require.Equal(t, 24600, parcel.VolumetricWeightGram)
+	require.Equal(t, 24600, parcel.ChargeableWeightGram())
+
+	parcel = shipping.ParcelConfig{}.Pack([]shipping.ParcelItem{
+		{Qty: 1, WeightGram: 800, LengthCm: 60, WidthCm: 50, HeightCm: 41},
+	})
+	require.Zero(t, parcel.VolumetricWeightGram, "a zero divisor disables volumetric weight")
+}
+
+func TestPackEmptyCartWeighsOneDefaultUnit(t *testing.T) {
+	t.Parallel()
+
+	require.Equal(t, shipping.DefaultItemWeightGram, shipping.ParcelConfig{}.Pack(nil).WeightGram)
+}
@@ -2,12 +2,14 @@ package shipping
 
 import "context"
 
-// RateReq describes a shipping rate request.
+// RateReq describes a shipping rate request. The embedded Parcel carries the
+// cart's actual weight and, for providers that bill by volume, its volumetric
+// weight and box dimensions.
 type RateReq struct {
 	Origin      string
 	Destination string
-	WeightGram  int
-	Courier     string
+	Parcel
+	Courier string
 }
 
 // Rate describes a returned shipping rate option.
@@ -0,0 +1,137 @@
+package shipping
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+// TenantRulesKey is the tenant_settings key holding a tenant's shipping rules
+// as a JSON array, e.g. [{"name": "gratis-ongkir", "kind": "free_over",
+// "threshold": 200000}]. When present it replaces the default rules.
+const TenantRulesKey = "shipping.rules"
+
+// Rule kinds. free_over ships free once the cart value reaches Threshold,
+// flat charges Amount, and quoted defers to the rate provider.
+const (
+	RuleFreeOver = "free_over"
+	RuleFlat     = "flat"
+	RuleQuoted   = "quoted"
+)
+
+// Rule prices shipping for carts delivered to Regions. Regions match the
+// destination city or postal code case-insensitively; a trailing "*" matches
+// a prefix, and an empty list matches every destination.
+type Rule struct {
+	Name      string   `json:"name"`
+	Kind      string   `json:"kind"`
+	Regions   []string `json:"regions,omitempty"`
+	Threshold int64    `json:"threshold,omitempty"`
+	Amount    int64    `json:"amount,omitempty"`
+}
+
+// Decision is the outcome of evaluating shipping rules for a cart. Rule is
+// empty when no rule matched and the provider quote applies. FreeThreshold
+// and Remaining describe the nearest free-shipping rule the cart has not yet
+// reached.
+type Decision struct {
+	Rule          string       `json:"rule,omitempty"`
+	Kind          string       `json:"kind"`
+	Price         common.Int64 `json:"price"`
+	FreeThreshold common.Int64 `json:"freeThreshold,omitempty"`
+	Remaining     common.Int64 `json:"remaining,omitempty"`
+}
+
+// Quoted reports whether the shipping price comes from the rate provider.
+func (d Decision) Quoted() bool {
+	return d.Kind == RuleQuoted
+}
+
+// DefaultRules returns the rules used when a tenant has none: free shipping
+// from freeThreshold, or none when freeThreshold is zero.
+func DefaultRules(freeThreshold int64) []Rule {
+	if freeThreshold <= 0 {
+		return nil
+	}
+	return []Rule{{Name: "free_shipping", Kind: RuleFreeOver, Threshold: freeThreshold}}
+}
+
+// Evaluate applies the first matching rule to a cart worth value (item
+// subtotal after discounts) shipped to any of destinations. A free_over rule
+// the cart has not reached is skipped, so later rules can still price it.
+func Evaluate(rules []Rule, value int64, destinations ...string) Decision {
+	out := Decision{Kind: RuleQuoted}
+	for _, rule := range rules {
+		if !rule.matches(destinations) {
+			continue
+		}
+		switch rule.Kind {
+		case RuleFreeOver:
+			if value >= rule.Threshold {
+				out.Rule, out.Kind, out.Price = rule.Name, RuleFreeOver, 0
+				out.FreeThreshold, out.Remaining = 0, 0
+				return out
+			}
+			if remaining := rule.Threshold - value; out.Remaining == 0 || remaining < int64(out.Remaining) {
+				out.FreeThreshold, out.Remaining = common.Int64(rule.Threshold), common.Int64(remaining)
+			}
+		case RuleFlat:
+			out.Rule, out.Kind, out.Price = rule.Name, RuleFlat, common.Int64(rule.Amount)
+			return out
+		case RuleQuoted:
+			out.Rule = rule.Name
+			return out
+		}
+	}
+	return out
+}
+
+func (r Rule) matches(destinations []string) bool {
+	if len(r.Regions) == 0 {
+		return true
+	}
+	for _, region := range r.Regions {
+		region = strings.ToLower(strings.TrimSpace(region))
+		prefix, isPrefix := strings.CutSuffix(region, "*")
+		for _, dest := range destinations {
+			dest = strings.ToLower(strings.TrimSpace(dest))
+			if dest == "" {
+				continue
+			}
+			if dest == region || (isPrefix && strings.HasPrefix(dest, prefix)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type tenantSettings interface {
+	GetTenantSetting(ctx context.Context, arg dbgen.GetTenantSettingParams) ([]byte, error)
+}
+
+// TenantRules returns the shipping rules for tenantID, falling back to
+// defaults when the tenant has none or its setting is malformed.
+func TenantRules(ctx context.Context, q tenantSettings, tenantID string, defaults []Rule) ([]Rule, error) {
+	raw, err := q.GetTenantSetting(ctx, dbgen.GetTenantSettingParams{Tenant: tenantID, Key: TenantRulesKey})
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return defaults, nil
+		}
+		return nil, fmt.Errorf("load shipping rules: %w", err)
+	}
+	var rules []Rule
+	if err := json.Unmarshal(raw, &rules); err != nil {
+		zerolog.Ctx(ctx).Warn().Err(err).Str("tenant", tenantID).Msg("malformed tenant shipping rules; using defaults")
+		return defaults, nil
+	}
+	return rules, nil
+}
@@ -0,0 +1,62 @@
+package shipping_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/shipping"
+)
+
+func TestEvaluateRules(t *testing.T) {
+	t.Parallel()
+
+	rules := []shipping.Rule{
+		{Name: "jabodetabek", Kind: shipping.RuleFreeOver, Regions: []string{"Jakarta", "16*"}, Threshold: 100000},
+		{Name: "gratis", Kind: shipping.RuleFreeOver, Threshold: 300000},
+		{Name: "jawa-flat", Kind: shipping.RuleFlat, Regions: []string{"Kediri"}, Amount: 9000},
+	}
+
+	got := shipping.Evaluate(rules, 120000, "", "16111", "Bogor")
+	require.Equal(t, shipping.Decision{Rule: "jabodetabek", Kind: shipping.RuleFreeOver}, got)
+
+	got = shipping.Evaluate(rules, 80000, "jakarta")
+	require.True(t, got.Quoted())
+	require.EqualValues(t, 100000, got.FreeThreshold)
+	require.EqualValues(t, 20000, got.Remaining)
+
+	got = shipping.Evaluate(rules, 80000, "Kediri")
+	require.Equal(t, "jawa-flat", got.Rule)
+	require.EqualValues(t, 9000, got.Price)
+	require.EqualValues(t, 220000, got.Remaining, "the free threshold still shows how far the cart is")
+
+	got = shipping.Evaluate(rules, 300000, "Kediri")
+	require.Equal(t, "gratis", got.Rule)
+	require.EqualValues(t, 0, got.Price)
+
+	require.True(t, shipping.Evaluate(nil, 500000).Quoted())
+	require.Equal(t, []shipping.Rule{{Name: "free_shipping", Kind: shipping.RuleFreeOver, Threshold: 250000}}, shipping.DefaultRules(250000))
+	require.Nil(t, shipping.DefaultRules(0))
+}
+
+type rawSetting []byte
+
+func (r rawSetting) GetTenantSetting(context.Context, dbgen.GetTenantSettingParams) ([]byte, error) {
+	return r, nil
+}
+
+func TestTenantRulesLogsMalformedSetting(t *testing.T) {
+	var logs bytes.Buffer
+	ctx := zerolog.New(&logs).WithContext(context.Background())
+	defaults := shipping.DefaultRules(200000)
+
+	rules, err := shipping.TenantRules(ctx, rawSetting(`{"kind":`), "acme", defaults)
+	require.NoError(t, err)
+	require.Equal(t, defaults, rules)
+	require.Contains(t, logs.String(), "malformed tenant shipping rules")
+	require.Contains(t, logs.String(), `"tenant":"acme"`)
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/noah-isme/backend-toko/internal/common"
 	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
 	"github.com/noah-isme/backend-toko/internal/events"
+	"github.com/noah-isme/backend-toko/internal/reqcache"
 )
 
 var (
@@ -42,7 +43,27 @@ type Service struct {
 	NotifyOnShipped        bool
 	NotifyOnOutForDelivery bool
 	NotifyOnDelivered      bool
-	Events                 *events.Bus
+	// EmailAllowed, when set, is asked before each notification whether the
+	// customer wants emails about the shipment topic.
+	EmailAllowed func(ctx context.Context, userID pgtype.UUID, topic string) (bool, error)
+	Events       *events.Bus
+	// RequestCache memoizes the order and customer lookups that the
+	// notification and the domain event of one tracking update both need.
+	RequestCache bool
+}
+
+// recipient loads the order and its customer, memoized for the request when
+// RequestCache is on.
+func (s *Service) recipient(ctx context.Context, orderID pgtype.UUID) (dbgen.GetUserByIDRow, error) {
+	order, err := reqcache.Load(ctx, s.RequestCache, reqcache.Key("GetOrderByID", uuidString(orderID)), func(ctx context.Context) (dbgen.Order, error) {
+		return s.Q.GetOrderByID(ctx, orderID)
+	})
+	if err != nil {
+		return dbgen.GetUserByIDRow{}, err
+	}
+	return reqcache.Load(ctx, s.RequestCache, reqcache.Key("GetUserByID", uuidString(order.UserID)), func(ctx context.Context) (dbgen.GetUserByIDRow, error) {
+		return s.Q.GetUserByID(ctx, order.UserID)
+	})
 }
 
 // Create initialises a shipment for the provided order and records courier metadata.
@@ -186,13 +207,14 @@ func (s *Service) notify(ctx context.Context, orderID pgtype.UUID, status dbgen.
 	default:
 		return
 	}
-	order, err := s.Q.GetOrderByID(ctx, orderID)
+	user, err := s.recipient(ctx, orderID)
 	if err != nil {
 		return
 	}
-	user, err := s.Q.GetUserByID(ctx, order.UserID)
-	if err != nil {
-		return
+	if topic, ok := shipmentTopic(status); ok && s.EmailAllowed != nil {
+		if allowed, err := s.EmailAllowed(ctx, user.ID, topic); err != nil || !allowed {
+			return
+		}
 	}
 	subject, body := notificationContent(status)
 	_ = s.Mail.Send(user.Email, subject, body)
@@ -206,21 +228,17 @@ func (s *Service) emit(ctx context.Context, orderID, shipmentID pgtype.UUID, sta
 	if !ok {
 		return
 	}
-	data := map[string]any{
-		"orderId":    uuidString(orderID),
-		"shipmentId": uuidString(shipmentID),
-		"status":     string(status),
+	data := &events.ShipmentPayload{
+		OrderID:    uuidString(orderID),
+		ShipmentID: uuidString(shipmentID),
+		Status:     string(status),
 	}
-	if len(raw) > 0 {
-		var parsed any
-		if err := json.Unmarshal(raw, &parsed); err == nil {
-			data["payload"] = parsed
-		}
+	if len(raw) > 0 && json.Valid(raw) {
+		data.Payload = json.RawMessage(raw)
 	}
-	if order, err := s.Q.GetOrderByID(ctx, orderID); err == nil {
-		if user, err := s.Q.GetUserByID(ctx, order.UserID); err == nil && user.Email != "" {
-			data["email"] = user.Email
-		}
+	if user, err := s.recipient(ctx, orderID); err == nil {
+		data.UserID = uuidString(user.ID)
+		data.Email = user.Email
 	}
 	_, _ = s.Events.Emit(ctx, topic, shipmentID, data)
 }
@@ -20,6 +20,9 @@ type mockQueries struct {
 	shipmentsByID map[string]*dbgen.Shipment
 	users         map[string]dbgen.GetUserByIDRow
 	events        []dbgen.ShipmentEvent
+	// orderReads and userReads count GetOrderByID and GetUserByID calls.
+	orderReads int
+	userReads  int
 }
 
 func newMockQueries() *mockQueries {
@@ -53,6 +56,7 @@ func (m *mockQueries) storeShipment(shipment dbgen.Shipment) {
 func (m *mockQueries) GetOrderByID(ctx context.Context, id pgtype.UUID) (dbgen.Order, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.orderReads++
 	if order, ok := m.orders[uuidFromPG(id).String()]; ok {
 		copyOrder := *order
 		return copyOrder, nil
@@ -60,22 +64,30 @@ func (m *mockQueries) GetOrderByID(ctx context.Context, id pgtype.UUID) (dbgen.O
 	return dbgen.Order{}, pgx.ErrNoRows
 }
 
-func (m *mockQueries) GetShipmentByOrder(ctx context.Context, orderID pgtype.UUID) (dbgen.Shipment, error) {
+func (m *mockQueries) GetShipmentByOrder(ctx context.Context, orderID pgtype.UUID) (dbgen.GetShipmentByOrderRow, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	if shipment, ok := m.shipments[uuidFromPG(orderID).String()]; ok {
-		copyShipment := *shipment
-		return copyShipment, nil
+		return dbgen.GetShipmentByOrderRow{
+			ID:             shipment.ID,
+			OrderID:        shipment.OrderID,
+			Status:         shipment.Status,
+			Courier:        shipment.Courier,
+			TrackingNumber: shipment.TrackingNumber,
+			History:        shipment.History,
+			LastStatus:     shipment.LastStatus,
+			LastEventAt:    shipment.LastEventAt,
+		}, nil
 	}
-	return dbgen.Shipment{}, pgx.ErrNoRows
+	return dbgen.GetShipmentByOrderRow{}, pgx.ErrNoRows
 }
 
-func (m *mockQueries) CreateShipment(ctx context.Context, arg dbgen.CreateShipmentParams) (dbgen.Shipment, error) {
+func (m *mockQueries) CreateShipment(ctx context.Context, arg dbgen.CreateShipmentParams) (dbgen.CreateShipmentRow, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	key := uuidFromPG(arg.OrderID).String()
 	if _, exists := m.shipments[key]; exists {
-		return dbgen.Shipment{}, errors.New("shipment exists")
+		return dbgen.CreateShipmentRow{}, errors.New("shipment exists")
 	}
 	shipment := dbgen.Shipment{
 		ID:             toPGUUID(uuid.New()),
@@ -88,7 +100,16 @@ func (m *mockQueries) CreateShipment(ctx context.Context, arg dbgen.CreateShipme
 		LastEventAt:    pgtype.Timestamptz{Time: time.Now(), Valid: true},
 	}
 	m.storeShipment(shipment)
-	return shipment, nil
+	return dbgen.CreateShipmentRow{
+		ID:             shipment.ID,
+		OrderID:        shipment.OrderID,
+		Status:         shipment.Status,
+		Courier:        shipment.Courier,
+		TrackingNumber: shipment.TrackingNumber,
+		History:        shipment.History,
+		LastStatus:     shipment.LastStatus,
+		LastEventAt:    shipment.LastEventAt,
+	}, nil
 }
 
 func (m *mockQueries) UpdateOrderStatusIfAllowed(ctx context.Context, arg dbgen.UpdateOrderStatusIfAllowedParams) (pgtype.UUID, error) {
@@ -159,6 +180,7 @@ func (m *mockQueries) GetOrderStatus(ctx context.Context, id pgtype.UUID) (dbgen
 func (m *mockQueries) GetUserByID(ctx context.Context, id pgtype.UUID) (dbgen.GetUserByIDRow, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.userReads++
 	if user, ok := m.users[uuidFromPG(id).String()]; ok {
 		return user, nil
 	}
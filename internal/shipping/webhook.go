@@ -4,7 +4,6 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
-	"fmt"
 	"io"
 	"net/http"
 	"strings"
@@ -12,28 +11,28 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jackc/pgx/v5"
-	"github.com/redis/go-redis/v9"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/noah-isme/backend-toko/internal/common"
 	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/inbound"
 	"github.com/noah-isme/backend-toko/internal/obs"
 )
 
-type replayStore interface {
-	SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd
-}
-
 // Webhook handles courier callbacks and synchronises shipment state.
 type Webhook struct {
-	Svc       *Service
-	Replay    replayStore
-	ReplayTTL time.Duration
+	Svc *Service
+	// Inbound runs each courier event at most once; retries of a processed
+	// event are acknowledged with 200.
+	Inbound *inbound.Consumer
 }
 
 type webhookPayload struct {
+	// EventID is the courier's identifier for the event, also accepted as
+	// the X-Event-ID header. Without it the body identifies the event.
+	EventID        string     `json:"eventId"`
 	OrderID        string     `json:"orderId"`
 	TrackingNumber string     `json:"trackingNumber"`
 	ExternalStatus string     `json:"externalStatus"`
@@ -48,7 +47,7 @@ func (h Webhook) Handle(w http.ResponseWriter, r *http.Request) {
 		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "shipment service not configured", nil)
 		return
 	}
-	if h.Replay == nil {
+	if h.Inbound == nil {
 		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "replay protection not configured", nil)
 		return
 	}
@@ -71,24 +70,35 @@ func (h Webhook) Handle(w http.ResponseWriter, r *http.Request) {
 			obs.ShippingWebhookTotal.WithLabelValues(courierLabel, outcome).Inc()
 		}
 	}()
-	key := fmt.Sprintf("shwh:%s:%s", courier, common.Sha256Hex(string(body)))
-	ok, err := h.Replay.SetNX(r.Context(), key, "1", h.ReplayTTL).Result()
-	if err != nil {
-		span.RecordError(err)
-		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "replay protection failed", nil)
-		return
-	}
-	if !ok {
-		span.AddEvent("shipping webhook replay prevented")
-		common.JSONError(w, http.StatusConflict, "REPLAY", "duplicate webhook payload", nil)
-		return
-	}
 	payload, err := decodeWebhookPayload(body, r)
 	if err != nil {
 		span.RecordError(err)
 		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
 		return
 	}
+	source := "shipping:" + courierLabel
+	key := inbound.Key(payload.EventID, body)
+	if err := h.Inbound.Claim(r.Context(), source, key); err != nil {
+		switch {
+		case errors.Is(err, inbound.ErrDuplicate):
+			span.AddEvent("shipping webhook already processed")
+			outcome = "duplicate"
+			common.JSON(w, http.StatusOK, map[string]any{"data": map[string]any{"duplicate": true}})
+		case errors.Is(err, inbound.ErrInFlight):
+			span.AddEvent("shipping webhook already in flight")
+			common.JSONError(w, http.StatusConflict, "REPLAY", "webhook is being processed; retry later", nil)
+		default:
+			span.RecordError(err)
+			common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "replay protection failed", nil)
+		}
+		return
+	}
+	defer func() {
+		// Failed deliveries are released so the courier's retry runs them.
+		if err := h.Inbound.Finish(context.WithoutCancel(r.Context()), source, key, outcome == "success"); err != nil {
+			span.RecordError(err)
+		}
+	}()
 	orderID, err := parseUUID(payload.OrderID)
 	if err != nil {
 		span.RecordError(err)
@@ -130,6 +140,9 @@ func decodeWebhookPayload(body []byte, r *http.Request) (webhookPayload, error)
 			payload = webhookPayload{}
 		}
 	}
+	if payload.EventID == "" {
+		payload.EventID = strings.TrimSpace(r.Header.Get("X-Event-ID"))
+	}
 	if payload.OrderID == "" {
 		payload.OrderID = r.URL.Query().Get("orderId")
 	}
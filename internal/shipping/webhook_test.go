@@ -11,25 +11,46 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
-	"github.com/redis/go-redis/v9"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/stretchr/testify/require"
 
 	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/inbound"
 	"github.com/noah-isme/backend-toko/internal/shipping"
 )
 
-type fakeReplayStore struct {
-	results []bool
-	err     error
+// memoryInbound keeps inbound_webhook_events in memory.
+type memoryInbound struct {
+	claimed   map[string]bool
+	processed map[string]bool
 }
 
-func (f *fakeReplayStore) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.BoolCmd {
-	if len(f.results) == 0 {
-		return redis.NewBoolResult(true, f.err)
+func newMemoryInbound() *memoryInbound {
+	return &memoryInbound{claimed: map[string]bool{}, processed: map[string]bool{}}
+}
+
+func (m *memoryInbound) ClaimInboundWebhook(_ context.Context, arg dbgen.ClaimInboundWebhookParams) (pgtype.Timestamptz, error) {
+	id := arg.Source + "|" + arg.DedupKey
+	if m.claimed[id] {
+		return pgtype.Timestamptz{}, pgx.ErrNoRows
 	}
-	res := f.results[0]
-	f.results = f.results[1:]
-	return redis.NewBoolResult(res, f.err)
+	m.claimed[id] = true
+	return pgtype.Timestamptz{Time: time.Now(), Valid: true}, nil
+}
+
+func (m *memoryInbound) GetInboundWebhookProcessedAt(_ context.Context, arg dbgen.GetInboundWebhookProcessedAtParams) (pgtype.Timestamptz, error) {
+	return pgtype.Timestamptz{Time: time.Now(), Valid: m.processed[arg.Source+"|"+arg.DedupKey]}, nil
+}
+
+func (m *memoryInbound) CompleteInboundWebhook(_ context.Context, arg dbgen.CompleteInboundWebhookParams) error {
+	m.processed[arg.Source+"|"+arg.DedupKey] = true
+	return nil
+}
+
+func (m *memoryInbound) ReleaseInboundWebhook(_ context.Context, arg dbgen.ReleaseInboundWebhookParams) error {
+	delete(m.claimed, arg.Source+"|"+arg.DedupKey)
+	return nil
 }
 
 func TestWebhookReplayProtection(t *testing.T) {
@@ -53,8 +74,7 @@ func TestWebhookReplayProtection(t *testing.T) {
 	_, err := svc.Create(ctx, toPGUUID(orderID), "jne", "TRACK123")
 	require.NoError(t, err)
 
-	replay := &fakeReplayStore{results: []bool{true, false}}
-	wh := shipping.Webhook{Svc: svc, Replay: replay, ReplayTTL: time.Minute}
+	wh := shipping.Webhook{Svc: svc, Inbound: &inbound.Consumer{Q: newMemoryInbound()}}
 
 	payload := map[string]any{"orderId": orderID.String(), "externalStatus": "shipped"}
 	body, err := json.Marshal(payload)
@@ -77,6 +97,7 @@ func TestWebhookReplayProtection(t *testing.T) {
 
 	rr2 := httptest.NewRecorder()
 	wh.Handle(rr2, req2)
-	require.Equal(t, http.StatusConflict, rr2.Code)
+	// The courier's retry is acknowledged without a second transition.
+	require.Equal(t, http.StatusOK, rr2.Code)
 	require.Len(t, queries.events, 1)
 }
@@ -0,0 +1,214 @@
+// Package storefront exposes composition endpoints that let the storefront
+// fetch several read-only resources in a single round-trip.
+package storefront
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
+
+	"github.com/noah-isme/backend-toko/internal/catalog"
+	"github.com/noah-isme/backend-toko/internal/common"
+	"github.com/noah-isme/backend-toko/internal/reviews"
+	"github.com/noah-isme/backend-toko/internal/tenant"
+)
+
+const defaultMaxOperations = 10
+
+// Supported batch operations.
+const (
+	OpProductDetail  = "product.detail"
+	OpProductRelated = "product.related"
+	OpProductList    = "products.list"
+	OpBrands         = "brands.list"
+	OpCategories     = "categories.list"
+	OpReviews        = "reviews.list"
+	OpReviewStats    = "reviews.stats"
+)
+
+// Operation is a single read request inside a batch.
+type Operation struct {
+	ID     string            `json:"id"`
+	Op     string            `json:"op"`
+	Params map[string]string `json:"params"`
+}
+
+// Result carries the outcome of one operation. Exactly one of Data or Error is set.
+type Result struct {
+	ID     string            `json:"id"`
+	Status int               `json:"status"`
+	Data   any               `json:"data,omitempty"`
+	Error  *common.ErrorBody `json:"error,omitempty"`
+	Meta   map[string]any    `json:"meta,omitempty"`
+}
+
+// Handler serves POST /api/v1/batch. Operations run concurrently and are
+// isolated from each other: a failing operation only affects its own result.
+type Handler struct {
+	Catalog *catalog.Service
+	Reviews *reviews.Service
+	// MaxOperations caps the number of operations per request. Zero uses 10.
+	MaxOperations int
+}
+
+// Batch handles POST /api/v1/batch.
+func (h *Handler) Batch(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Operations []Operation `json:"operations"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		common.JSONError(w, http.StatusBadRequest, common.CodeInvalidBody, "invalid request body", nil)
+		return
+	}
+	limit := h.MaxOperations
+	if limit <= 0 {
+		limit = defaultMaxOperations
+	}
+	if len(req.Operations) == 0 {
+		common.JSONError(w, http.StatusBadRequest, common.CodeValidation, "operations must not be empty", nil)
+		return
+	}
+	if len(req.Operations) > limit {
+		common.JSONError(w, http.StatusBadRequest, common.CodeValidation, "too many operations", map[string]any{"max": limit})
+		return
+	}
+
+	ctx := r.Context()
+	if h.Catalog != nil {
+		ctx = h.Catalog.WithRequestLocale(r)
+	}
+	results := make([]Result, len(req.Operations))
+	var wg sync.WaitGroup
+	for i, op := range req.Operations {
+		id := strings.TrimSpace(op.ID)
+		if id == "" {
+			id = strconv.Itoa(i)
+		}
+		wg.Add(1)
+		go func(i int, id string, op Operation) {
+			defer wg.Done()
+			results[i] = h.run(ctx, id, op)
+		}(i, id, op)
+	}
+	wg.Wait()
+
+	common.JSON(w, http.StatusOK, map[string]any{"data": results})
+}
+
+func (h *Handler) run(ctx context.Context, id string, op Operation) (res Result) {
+	defer func() {
+		if rec := recover(); rec != nil {
+			zerolog.Ctx(ctx).Error().Str("op", op.Op).Interface("panic", rec).Msg("batch operation panicked")
+			res = errorResult(id, fmt.Errorf("panic: %v", rec))
+		}
+	}()
+	data, meta, err := h.dispatch(ctx, op)
+	if err != nil {
+		return errorResult(id, err)
+	}
+	return Result{ID: id, Status: http.StatusOK, Data: data, Meta: meta}
+}
+
+func (h *Handler) dispatch(ctx context.Context, op Operation) (any, map[string]any, error) {
+	params := op.Params
+	switch op.Op {
+	case OpProductDetail, OpProductRelated, OpProductList, OpBrands, OpCategories:
+		if h.Catalog == nil {
+			return nil, nil, common.NewAppError(common.CodeInternal, "catalog service not configured", http.StatusInternalServerError, nil)
+		}
+	case OpReviews, OpReviewStats:
+		if h.Reviews == nil {
+			return nil, nil, common.NewAppError(common.CodeInternal, "reviews service not configured", http.StatusInternalServerError, nil)
+		}
+	}
+
+	switch op.Op {
+	case OpProductDetail:
+		detail, err := h.Catalog.GetProductDetail(ctx, params["slug"])
+		return detail, nil, err
+	case OpProductRelated:
+		items, err := h.Catalog.ListRelatedProducts(ctx, strings.TrimSpace(params["slug"]))
+		return items, nil, err
+	case OpProductList:
+		values := url.Values{}
+		for k, v := range params {
+			values.Set(k, v)
+		}
+		listParams, err := h.Catalog.ParseListParams(values)
+		if err != nil {
+			return nil, nil, err
+		}
+		result, err := h.Catalog.ListProducts(ctx, listParams)
+		if err != nil {
+			return nil, nil, err
+		}
+		meta := map[string]any{"pagination": common.Pagination{Page: result.Page, PerPage: result.Limit, TotalItems: int(result.Total)}}
+		return result.Items, meta, nil
+	case OpBrands:
+		brands, err := h.Catalog.ListBrands(ctx)
+		return brands, nil, err
+	case OpCategories:
+		categories, err := h.Catalog.ListCategories(ctx)
+		return categories, nil, err
+	case OpReviews:
+		productID, tenantID, err := reviewScope(ctx, params)
+		if err != nil {
+			return nil, nil, err
+		}
+		page, _ := strconv.Atoi(params["page"])
+		limit, _ := strconv.Atoi(params["limit"])
+		list, err := h.Reviews.List(ctx, productID, tenantID, int32(page), int32(limit))
+		return list, nil, err
+	case OpReviewStats:
+		productID, tenantID, err := reviewScope(ctx, params)
+		if err != nil {
+			return nil, nil, err
+		}
+		stats, err := h.Reviews.Stats(ctx, productID, tenantID)
+		return stats, nil, err
+	default:
+		return nil, nil, &common.AppError{Code: common.CodeBadRequest, Message: "unsupported operation", HTTPStatus: http.StatusBadRequest, Details: map[string]any{"op": op.Op}}
+	}
+}
+
+func reviewScope(ctx context.Context, params map[string]string) (pgtype.UUID, pgtype.UUID, error) {
+	productID, err := uuid.Parse(strings.TrimSpace(params["productId"]))
+	if err != nil {
+		return pgtype.UUID{}, pgtype.UUID{}, &common.AppError{Code: common.CodeBadRequest, Message: "invalid product id", HTTPStatus: http.StatusBadRequest, Err: err, Details: map[string]any{"field": "productId"}}
+	}
+	tenantStr, ok := tenant.FromContext(ctx)
+	if !ok {
+		return pgtype.UUID{}, pgtype.UUID{}, common.NewAppError(common.CodeTenantRequired, "missing tenant context", http.StatusBadRequest, nil)
+	}
+	tenantID, err := uuid.Parse(tenantStr)
+	if err != nil {
+		return pgtype.UUID{}, pgtype.UUID{}, common.NewAppError(common.CodeInternal, "invalid tenant id", http.StatusInternalServerError, err)
+	}
+	return pgtype.UUID{Bytes: productID, Valid: true}, pgtype.UUID{Bytes: tenantID, Valid: true}, nil
+}
+
+func errorResult(id string, err error) Result {
+	var appErr *common.AppError
+	if errors.As(err, &appErr) {
+		status := appErr.HTTPStatus
+		if status == 0 {
+			status = http.StatusInternalServerError
+		}
+		code := appErr.Code
+		if code == "" {
+			code = common.CodeInternal
+		}
+		return Result{ID: id, Status: status, Error: &common.ErrorBody{Code: code, Message: appErr.Message, Details: appErr.Details}}
+	}
+	return Result{ID: id, Status: http.StatusInternalServerError, Error: &common.ErrorBody{Code: common.CodeInternal, Message: "internal error"}}
+}
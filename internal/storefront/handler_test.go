@@ -0,0 +1,162 @@
+package storefront_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/catalog"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/storefront"
+)
+
+type fakeQueries struct {
+	product dbgen.GetProductBySlugRow
+}
+
+func (f *fakeQueries) ListBrands(context.Context) ([]dbgen.ListBrandsRow, error) {
+	return nil, errors.New("brands unavailable")
+}
+
+func (f *fakeQueries) GetBrandByID(context.Context, pgtype.UUID) (dbgen.GetBrandByIDRow, error) {
+	return dbgen.GetBrandByIDRow{}, pgx.ErrNoRows
+}
+
+func (f *fakeQueries) ListCategories(context.Context) ([]dbgen.ListCategoriesRow, error) {
+	return nil, nil
+}
+
+func (f *fakeQueries) GetCategoryByID(context.Context, pgtype.UUID) (dbgen.GetCategoryByIDRow, error) {
+	return dbgen.GetCategoryByIDRow{}, pgx.ErrNoRows
+}
+
+func (f *fakeQueries) CountProductsPublic(context.Context, dbgen.CountProductsPublicParams) (int64, error) {
+	return 0, nil
+}
+
+func (f *fakeQueries) ListProductsPublic(context.Context, dbgen.ListProductsPublicParams) ([]dbgen.ListProductsPublicRow, error) {
+	return nil, nil
+}
+
+func (f *fakeQueries) GetProductBySlug(_ context.Context, slug string) (dbgen.GetProductBySlugRow, error) {
+	if slug != f.product.Slug {
+		return dbgen.GetProductBySlugRow{}, pgx.ErrNoRows
+	}
+	return f.product, nil
+}
+
+func (f *fakeQueries) ListVariantsByProduct(context.Context, pgtype.UUID) ([]dbgen.ProductVariant, error) {
+	return nil, nil
+}
+
+func (f *fakeQueries) ListTopProductSlugs(context.Context, int32) ([]string, error) {
+	return nil, nil
+}
+
+func (f *fakeQueries) ListPreviousProductPrices(context.Context, dbgen.ListPreviousProductPricesParams) ([]dbgen.ListPreviousProductPricesRow, error) {
+	return nil, nil
+}
+
+func (f *fakeQueries) ListProductPriceTrend(context.Context, dbgen.ListProductPriceTrendParams) ([]dbgen.ListProductPriceTrendRow, error) {
+	return nil, nil
+}
+
+func (f *fakeQueries) ListBundleComponentsByVariantIDs(context.Context, []pgtype.UUID) ([]dbgen.ListBundleComponentsByVariantIDsRow, error) {
+	return nil, nil
+}
+
+func (f *fakeQueries) ListImagesByProduct(context.Context, pgtype.UUID) ([]dbgen.ProductImage, error) {
+	return nil, nil
+}
+
+func (f *fakeQueries) ListSpecsByProduct(context.Context, pgtype.UUID) ([]dbgen.ProductSpec, error) {
+	return nil, nil
+}
+
+func (f *fakeQueries) ListRelatedByCategory(context.Context, dbgen.ListRelatedByCategoryParams) ([]dbgen.ListRelatedByCategoryRow, error) {
+	return nil, nil
+}
+
+func (f *fakeQueries) ListRecommendationCandidates(context.Context, dbgen.ListRecommendationCandidatesParams) ([]dbgen.ListRecommendationCandidatesRow, error) {
+	return nil, nil
+}
+
+func (f *fakeQueries) ListProductTranslations(context.Context, dbgen.ListProductTranslationsParams) ([]dbgen.ListProductTranslationsRow, error) {
+	return nil, nil
+}
+
+func (f *fakeQueries) GetCategoryDefaultSort(context.Context, string) (string, error) {
+	return "", pgx.ErrNoRows
+}
+
+func (f *fakeQueries) GetTenantSetting(context.Context, dbgen.GetTenantSettingParams) ([]byte, error) {
+	return nil, pgx.ErrNoRows
+}
+
+func TestBatchIsolatesOperationErrors(t *testing.T) {
+	queries := &fakeQueries{product: dbgen.GetProductBySlugRow{
+		ID:    pgtype.UUID{Bytes: [16]byte{1}, Valid: true},
+		Title: "Kaos",
+		Slug:  "kaos",
+		Price: 10000,
+	}}
+	svc, err := catalog.NewService(catalog.ServiceConfig{Queries: queries})
+	require.NoError(t, err)
+	h := &storefront.Handler{Catalog: svc}
+
+	body := `{"operations":[
+		{"id":"detail","op":"product.detail","params":{"slug":"kaos"}},
+		{"id":"missing","op":"product.detail","params":{"slug":"nope"}},
+		{"id":"brands","op":"brands.list"},
+		{"op":"unknown.op"}
+	]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/batch", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.Batch(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var resp struct {
+		Data []struct {
+			ID     string          `json:"id"`
+			Status int             `json:"status"`
+			Data   json.RawMessage `json:"data"`
+			Error  *struct {
+				Code string `json:"code"`
+			} `json:"error"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Data, 4)
+
+	require.Equal(t, "detail", resp.Data[0].ID)
+	require.Equal(t, http.StatusOK, resp.Data[0].Status)
+	var detail catalog.ProductDetail
+	require.NoError(t, json.Unmarshal(resp.Data[0].Data, &detail))
+	require.Equal(t, "Kaos", detail.Title)
+
+	require.Equal(t, http.StatusNotFound, resp.Data[1].Status)
+	require.Equal(t, "NOT_FOUND", resp.Data[1].Error.Code)
+
+	require.Equal(t, http.StatusInternalServerError, resp.Data[2].Status)
+	require.Equal(t, "INTERNAL", resp.Data[2].Error.Code)
+
+	require.Equal(t, "3", resp.Data[3].ID)
+	require.Equal(t, http.StatusBadRequest, resp.Data[3].Status)
+}
+
+func TestBatchRejectsTooManyOperations(t *testing.T) {
+	h := &storefront.Handler{MaxOperations: 1}
+	body := `{"operations":[{"op":"brands.list"},{"op":"categories.list"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/batch", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.Batch(rec, req)
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+}
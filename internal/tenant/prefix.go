@@ -1,6 +1,9 @@
 package tenant
 
-import "context"
+import (
+	"context"
+	"strings"
+)
 
 // From exposes the tenant identifier retrieval helper.
 func From(ctx context.Context) (string, bool) {
@@ -14,3 +17,59 @@ func PrefixKey(tenantSlugOrID, key string) string {
 	}
 	return tenantSlugOrID + ":" + key
 }
+
+// keyScopePrefix marks tenant-scoped Redis keys, so one tenant's keys can be
+// matched as "t:<tenant>:*" without colliding with flat keys.
+const keyScopePrefix = "t:"
+
+// KeyScope namespaces Redis keys by the tenant in the context. It is opt-in:
+// the zero value leaves keys flat, as single-tenant deployments expect.
+type KeyScope struct {
+	Enabled bool
+}
+
+// Key returns key scoped to the tenant in ctx, or key unchanged when scoping
+// is disabled or no tenant was resolved.
+func (s KeyScope) Key(ctx context.Context, key string) string {
+	if !s.Enabled {
+		return key
+	}
+	id, ok := FromContext(ctx)
+	if !ok {
+		return key
+	}
+	return s.For(id, key)
+}
+
+// For returns key scoped to tenantID, or key unchanged when scoping is
+// disabled.
+func (s KeyScope) For(tenantID, key string) string {
+	tenantID = strings.TrimSpace(tenantID)
+	if !s.Enabled || tenantID == "" {
+		return key
+	}
+	return keyScopePrefix + tenantID + ":" + key
+}
+
+// Pattern returns the glob matching every key scoped to tenantID, for
+// invalidating a tenant with SCAN. It is empty when scoping is disabled.
+func (s KeyScope) Pattern(tenantID string) string {
+	tenantID = strings.TrimSpace(tenantID)
+	if !s.Enabled || tenantID == "" {
+		return ""
+	}
+	return keyScopePrefix + escapeGlob(tenantID) + ":*"
+}
+
+// escapeGlob quotes the characters Redis MATCH treats specially.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,30 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+)
+
+func TestKeyScopeIsOptIn(t *testing.T) {
+	ctx := WithTenant(context.Background(), "acme")
+	if got := (KeyScope{}).Key(ctx, "idem:abc"); got != "idem:abc" {
+		t.Fatalf("disabled scope changed key: %q", got)
+	}
+	if got := (KeyScope{}).Pattern("acme"); got != "" {
+		t.Fatalf("disabled scope returned pattern %q", got)
+	}
+}
+
+func TestKeyScopeNamespacesByTenant(t *testing.T) {
+	scope := KeyScope{Enabled: true}
+	ctx := WithTenant(context.Background(), "acme")
+	if got := scope.Key(ctx, "idem:abc"); got != "t:acme:idem:abc" {
+		t.Fatalf("unexpected scoped key %q", got)
+	}
+	if got := scope.Key(context.Background(), "idem:abc"); got != "idem:abc" {
+		t.Fatalf("key without tenant should stay flat, got %q", got)
+	}
+	if got := scope.Pattern("ac*me"); got != `t:ac\*me:*` {
+		t.Fatalf("unexpected pattern %q", got)
+	}
+}
@@ -0,0 +1,367 @@
+package user
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	db "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+// exportPageSize bounds how many orders, addresses, or audit events an export
+// holds in memory at once.
+const exportPageSize = 200
+
+// exportQueries is the subset of queries an export reads.
+type exportQueries interface {
+	GetUserByID(ctx context.Context, id pgtype.UUID) (db.GetUserByIDRow, error)
+	ListAddressesByUser(ctx context.Context, arg db.ListAddressesByUserParams) ([]db.Address, error)
+	ListOrdersForUserAfter(ctx context.Context, arg db.ListOrdersForUserAfterParams) ([]db.Order, error)
+	ListOrderItemsByOrders(ctx context.Context, orderIds []pgtype.UUID) ([]db.OrderItem, error)
+	ListReviewsByUser(ctx context.Context, userID pgtype.UUID) ([]db.ListReviewsByUserRow, error)
+	ListAuditLogsByActorAfter(ctx context.Context, arg db.ListAuditLogsByActorAfterParams) ([]db.ListAuditLogsByActorAfterRow, error)
+}
+
+// Export is a copy of everything stored about one user, written by Stream.
+type Export struct {
+	q        exportQueries
+	profile  db.GetUserByIDRow
+	at       time.Time
+	pageSize int
+}
+
+// Export prepares the data export of userID. It only checks that the user
+// exists, so errors can still be answered as JSON before Stream starts
+// writing.
+func (s *Service) Export(ctx context.Context, userID string) (*Export, error) {
+	return newExport(ctx, s.queries, userID, time.Now())
+}
+
+func newExport(ctx context.Context, q exportQueries, userID string, now time.Time) (*Export, error) {
+	uid, err := toUUID(userID)
+	if err != nil {
+		return nil, common.NewAppError("UNAUTHORIZED", "unauthorized", httpStatusUnauthorized, nil)
+	}
+	profile, err := q.GetUserByID(ctx, uid)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, common.NewAppError("NOT_FOUND", "user not found", httpStatusNotFound, nil)
+		}
+		return nil, err
+	}
+	return &Export{q: q, profile: profile, at: now.UTC(), pageSize: exportPageSize}, nil
+}
+
+// Filename is the suggested name of the downloaded file.
+func (e *Export) Filename() string {
+	return "toko-export-" + e.at.Format("20060102") + ".json"
+}
+
+type exportProfile struct {
+	ID            string     `json:"id"`
+	Name          string     `json:"name"`
+	Email         string     `json:"email"`
+	Roles         []string   `json:"roles"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+	DeactivatedAt *time.Time `json:"deactivatedAt,omitempty"`
+}
+
+type exportOrder struct {
+	ID              string            `json:"id"`
+	Status          db.OrderStatus    `json:"status"`
+	Currency        string            `json:"currency"`
+	Subtotal        common.Int64      `json:"subtotal"`
+	Discount        common.Int64      `json:"discount"`
+	Tax             common.Int64      `json:"tax"`
+	Shipping        common.Int64      `json:"shipping"`
+	Total           common.Int64      `json:"total"`
+	VoucherCode     string            `json:"voucherCode,omitempty"`
+	ShippingAddress json.RawMessage   `json:"shippingAddress,omitempty"`
+	ShippingOption  json.RawMessage   `json:"shippingOption,omitempty"`
+	Notes           string            `json:"notes,omitempty"`
+	CreatedAt       time.Time         `json:"createdAt"`
+	UpdatedAt       time.Time         `json:"updatedAt"`
+	Items           []exportOrderItem `json:"items"`
+}
+
+type exportOrderItem struct {
+	ProductID string       `json:"productId"`
+	VariantID string       `json:"variantId,omitempty"`
+	Title     string       `json:"title"`
+	Slug      string       `json:"slug"`
+	Qty       int32        `json:"qty"`
+	UnitPrice common.Int64 `json:"unitPrice"`
+	Subtotal  common.Int64 `json:"subtotal"`
+	Preorder  bool         `json:"preorder"`
+}
+
+type exportReview struct {
+	ID          string    `json:"id"`
+	ProductID   string    `json:"productId"`
+	ProductSlug string    `json:"productSlug,omitempty"`
+	Rating      int32     `json:"rating"`
+	Comment     string    `json:"comment,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+type exportActivity struct {
+	Action       string    `json:"action"`
+	ResourceType string    `json:"resourceType"`
+	ResourceID   string    `json:"resourceId,omitempty"`
+	Method       string    `json:"method"`
+	Path         string    `json:"path"`
+	Status       int32     `json:"status"`
+	IP           string    `json:"ip,omitempty"`
+	UserAgent    string    `json:"userAgent,omitempty"`
+	At           time.Time `json:"at"`
+}
+
+// Stream writes the export to w as a single JSON object with the profile,
+// addresses, orders with their items, reviews, and audited activity of the
+// user. Rows are read and written a page at a time, so long order histories
+// never sit in memory. Password hashes, sessions, two-factor secrets, and
+// audit metadata are left out. When Stream fails part way, w holds an
+// incomplete document.
+func (e *Export) Stream(ctx context.Context, w io.Writer) error {
+	out := &jsonStream{w: bufio.NewWriter(w), dst: w}
+	out.enc = json.NewEncoder(out.w)
+
+	out.raw(`{"exportedAt":`)
+	out.value(e.at)
+	out.raw(`,"profile":`)
+	out.value(e.exportProfile())
+	out.raw(`,"addresses":[`)
+	if err := e.streamAddresses(ctx, out); err != nil {
+		return err
+	}
+	out.raw(`],"orders":[`)
+	if err := e.streamOrders(ctx, out); err != nil {
+		return err
+	}
+	out.raw(`],"reviews":[`)
+	if err := e.streamReviews(ctx, out); err != nil {
+		return err
+	}
+	out.raw(`],"activity":[`)
+	if err := e.streamActivity(ctx, out); err != nil {
+		return err
+	}
+	out.raw("]}\n")
+	return out.flush()
+}
+
+func (e *Export) exportProfile() exportProfile {
+	p := exportProfile{
+		ID:        uuidString(e.profile.ID),
+		Name:      e.profile.Name,
+		Email:     e.profile.Email,
+		Roles:     e.profile.Roles,
+		CreatedAt: timeFromPG(e.profile.CreatedAt),
+		UpdatedAt: timeFromPG(e.profile.UpdatedAt),
+	}
+	if e.profile.DeactivatedAt.Valid {
+		at := e.profile.DeactivatedAt.Time
+		p.DeactivatedAt = &at
+	}
+	return p
+}
+
+func (e *Export) streamAddresses(ctx context.Context, out *jsonStream) error {
+	for offset := 0; ; offset += e.pageSize {
+		rows, err := e.q.ListAddressesByUser(ctx, db.ListAddressesByUserParams{
+			UserID: e.profile.ID,
+			Limit:  int32(e.pageSize),
+			Offset: int32(offset),
+		})
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			out.element(convertAddress(row))
+		}
+		if err := out.flush(); err != nil {
+			return err
+		}
+		if len(rows) < e.pageSize {
+			return nil
+		}
+	}
+}
+
+func (e *Export) streamOrders(ctx context.Context, out *jsonStream) error {
+	params := db.ListOrdersForUserAfterParams{UserID: e.profile.ID, PageLimit: int32(e.pageSize)}
+	for {
+		orders, err := e.q.ListOrdersForUserAfter(ctx, params)
+		if err != nil {
+			return err
+		}
+		if len(orders) == 0 {
+			return nil
+		}
+		ids := make([]pgtype.UUID, 0, len(orders))
+		for _, o := range orders {
+			ids = append(ids, o.ID)
+		}
+		items, err := e.q.ListOrderItemsByOrders(ctx, ids)
+		if err != nil {
+			return err
+		}
+		byOrder := make(map[pgtype.UUID][]exportOrderItem, len(orders))
+		for _, it := range items {
+			byOrder[it.OrderID] = append(byOrder[it.OrderID], exportOrderItem{
+				ProductID: uuidString(it.ProductID),
+				VariantID: uuidString(it.VariantID),
+				Title:     it.Title,
+				Slug:      it.Slug,
+				Qty:       it.Qty,
+				UnitPrice: common.Int64(it.UnitPrice),
+				Subtotal:  common.Int64(it.Subtotal),
+				Preorder:  it.Preorder,
+			})
+		}
+		for _, o := range orders {
+			orderItems := byOrder[o.ID]
+			if orderItems == nil {
+				orderItems = []exportOrderItem{}
+			}
+			out.element(exportOrder{
+				ID:              uuidString(o.ID),
+				Status:          o.Status,
+				Currency:        o.Currency,
+				Subtotal:        common.Int64(o.PricingSubtotal),
+				Discount:        common.Int64(o.PricingDiscount),
+				Tax:             common.Int64(o.PricingTax),
+				Shipping:        common.Int64(o.PricingShipping),
+				Total:           common.Int64(o.PricingTotal),
+				VoucherCode:     textToString(o.AppliedVoucherCode),
+				ShippingAddress: rawJSON(o.ShippingAddress),
+				ShippingOption:  rawJSON(o.ShippingOption),
+				Notes:           textToString(o.Notes),
+				CreatedAt:       timeFromPG(o.CreatedAt),
+				UpdatedAt:       timeFromPG(o.UpdatedAt),
+				Items:           orderItems,
+			})
+		}
+		if err := out.flush(); err != nil {
+			return err
+		}
+		if len(orders) < e.pageSize {
+			return nil
+		}
+		last := orders[len(orders)-1]
+		params.AfterCreatedAt, params.AfterID = last.CreatedAt, last.ID
+	}
+}
+
+func (e *Export) streamReviews(ctx context.Context, out *jsonStream) error {
+	rows, err := e.q.ListReviewsByUser(ctx, e.profile.ID)
+	if err != nil {
+		return err
+	}
+	for _, r := range rows {
+		out.element(exportReview{
+			ID:          uuidString(r.ID),
+			ProductID:   uuidString(r.ProductID),
+			ProductSlug: textToString(r.ProductSlug),
+			Rating:      r.Rating,
+			Comment:     textToString(r.Comment),
+			CreatedAt:   timeFromPG(r.CreatedAt),
+			UpdatedAt:   timeFromPG(r.UpdatedAt),
+		})
+	}
+	return out.flush()
+}
+
+func (e *Export) streamActivity(ctx context.Context, out *jsonStream) error {
+	params := db.ListAuditLogsByActorAfterParams{ActorUserID: e.profile.ID, PageLimit: int32(e.pageSize)}
+	for {
+		rows, err := e.q.ListAuditLogsByActorAfter(ctx, params)
+		if err != nil {
+			return err
+		}
+		for _, row := range rows {
+			out.element(exportActivity{
+				Action:       row.Action,
+				ResourceType: row.ResourceType,
+				ResourceID:   textToString(row.ResourceID),
+				Method:       row.Method,
+				Path:         row.Path,
+				Status:       row.Status,
+				IP:           textToString(row.Ip),
+				UserAgent:    textToString(row.UserAgent),
+				At:           timeFromPG(row.CreatedAt),
+			})
+		}
+		if err := out.flush(); err != nil {
+			return err
+		}
+		if len(rows) < e.pageSize {
+			return nil
+		}
+		last := rows[len(rows)-1]
+		params.AfterCreatedAt, params.AfterID = last.CreatedAt, last.ID
+	}
+}
+
+func rawJSON(b []byte) json.RawMessage {
+	if len(b) == 0 {
+		return nil
+	}
+	return json.RawMessage(b)
+}
+
+// jsonStream writes a JSON document piece by piece and keeps the first
+// error, so callers only check it when flushing.
+type jsonStream struct {
+	w     *bufio.Writer
+	dst   io.Writer
+	enc   *json.Encoder
+	first bool
+	err   error
+}
+
+func (s *jsonStream) raw(text string) {
+	if s.err == nil {
+		_, s.err = s.w.WriteString(text)
+	}
+	// Every raw write opens or closes an array in this document.
+	s.first = true
+}
+
+func (s *jsonStream) value(v any) {
+	if s.err == nil {
+		s.err = s.enc.Encode(v)
+	}
+}
+
+// element writes v as the next element of the open array.
+func (s *jsonStream) element(v any) {
+	if !s.first && s.err == nil {
+		s.err = s.w.WriteByte(',')
+	}
+	s.first = false
+	s.value(v)
+}
+
+// flush hands buffered output to the destination and pushes it to the
+// client when the destination supports flushing.
+func (s *jsonStream) flush() error {
+	if s.err == nil {
+		s.err = s.w.Flush()
+	}
+	if s.err == nil {
+		if f, ok := s.dst.(interface{ Flush() }); ok {
+			f.Flush()
+		}
+	}
+	return s.err
+}
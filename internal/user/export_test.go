@@ -0,0 +1,147 @@
+package user
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	db "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+type fakeExportQueries struct {
+	user       db.GetUserByIDRow
+	addresses  []db.Address
+	orders     []db.Order
+	items      []db.OrderItem
+	reviews    []db.ListReviewsByUserRow
+	audit      []db.ListAuditLogsByActorAfterRow
+	orderPages int
+}
+
+func (f *fakeExportQueries) GetUserByID(_ context.Context, id pgtype.UUID) (db.GetUserByIDRow, error) {
+	if id != f.user.ID {
+		return db.GetUserByIDRow{}, pgx.ErrNoRows
+	}
+	return f.user, nil
+}
+
+func (f *fakeExportQueries) ListAddressesByUser(_ context.Context, arg db.ListAddressesByUserParams) ([]db.Address, error) {
+	return page(f.addresses, int(arg.Offset), int(arg.Limit)), nil
+}
+
+func (f *fakeExportQueries) ListOrdersForUserAfter(_ context.Context, arg db.ListOrdersForUserAfterParams) ([]db.Order, error) {
+	f.orderPages++
+	start := 0
+	if arg.AfterID.Valid {
+		for i, o := range f.orders {
+			if o.ID == arg.AfterID {
+				start = i + 1
+			}
+		}
+	}
+	return page(f.orders, start, int(arg.PageLimit)), nil
+}
+
+func (f *fakeExportQueries) ListOrderItemsByOrders(_ context.Context, ids []pgtype.UUID) ([]db.OrderItem, error) {
+	var out []db.OrderItem
+	for _, it := range f.items {
+		for _, id := range ids {
+			if it.OrderID == id {
+				out = append(out, it)
+			}
+		}
+	}
+	return out, nil
+}
+
+func (f *fakeExportQueries) ListReviewsByUser(context.Context, pgtype.UUID) ([]db.ListReviewsByUserRow, error) {
+	return f.reviews, nil
+}
+
+func (f *fakeExportQueries) ListAuditLogsByActorAfter(_ context.Context, arg db.ListAuditLogsByActorAfterParams) ([]db.ListAuditLogsByActorAfterRow, error) {
+	if arg.AfterID.Valid {
+		return nil, nil
+	}
+	return page(f.audit, 0, int(arg.PageLimit)), nil
+}
+
+func page[T any](rows []T, offset, limit int) []T {
+	if offset >= len(rows) {
+		return nil
+	}
+	return rows[offset:min(offset+limit, len(rows))]
+}
+
+func testUUID(b byte) pgtype.UUID {
+	return pgtype.UUID{Bytes: [16]byte{15: b}, Valid: true}
+}
+
+func TestExportStreamsAllSections(t *testing.T) {
+	created := pgtype.Timestamptz{Time: time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC), Valid: true}
+	q := &fakeExportQueries{
+		user: db.GetUserByIDRow{ID: testUUID(1), Name: "Ana", Email: "ana@example.com", Roles: []string{"customer"}, CreatedAt: created},
+		addresses: []db.Address{
+			{ID: testUUID(20), AddressLine1: pgtype.Text{String: "Jl. Merdeka 1", Valid: true}},
+		},
+		orders: []db.Order{
+			{ID: testUUID(30), Status: "DELIVERED", Currency: "IDR", PricingTotal: 1000, ShippingAddress: []byte(`{"city":"Bandung"}`), CreatedAt: created},
+			{ID: testUUID(31), Status: "PAID", Currency: "IDR", PricingTotal: 2000, CreatedAt: created},
+			{ID: testUUID(32), Status: "CANCELED", Currency: "IDR", PricingTotal: 3000, CreatedAt: created},
+		},
+		items: []db.OrderItem{
+			{OrderID: testUUID(30), ProductID: testUUID(40), Title: "Kopi", Qty: 2, UnitPrice: 500, Subtotal: 1000},
+			{OrderID: testUUID(32), ProductID: testUUID(41), Title: "Teh", Qty: 1, UnitPrice: 3000, Subtotal: 3000},
+		},
+		reviews: []db.ListReviewsByUserRow{{ID: testUUID(50), ProductID: testUUID(40), Rating: 5}},
+		audit:   []db.ListAuditLogsByActorAfterRow{{Action: "deactivate", ResourceType: "user", Method: "DELETE", Path: "/api/v1/users/me", Status: 200}},
+	}
+	export, err := newExport(context.Background(), q, uuidString(testUUID(1)), time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	export.pageSize = 2
+	require.Equal(t, "toko-export-20250301.json", export.Filename())
+
+	var buf bytes.Buffer
+	require.NoError(t, export.Stream(context.Background(), &buf))
+	require.Equal(t, 2, q.orderPages)
+
+	var doc struct {
+		Profile   map[string]any   `json:"profile"`
+		Addresses []map[string]any `json:"addresses"`
+		Orders    []struct {
+			ID              string           `json:"id"`
+			ShippingAddress map[string]any   `json:"shippingAddress"`
+			Items           []map[string]any `json:"items"`
+		} `json:"orders"`
+		Reviews  []map[string]any `json:"reviews"`
+		Activity []map[string]any `json:"activity"`
+	}
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &doc), buf.String())
+	require.Equal(t, "ana@example.com", doc.Profile["email"])
+	require.NotContains(t, doc.Profile, "deactivatedAt")
+	require.Len(t, doc.Addresses, 1)
+	require.Len(t, doc.Orders, 3)
+	require.Equal(t, "Bandung", doc.Orders[0].ShippingAddress["city"])
+	require.Len(t, doc.Orders[0].Items, 1)
+	require.Empty(t, doc.Orders[1].Items)
+	require.NotNil(t, doc.Orders[1].Items)
+	require.Equal(t, "Teh", doc.Orders[2].Items[0]["title"])
+	require.Len(t, doc.Reviews, 1)
+	require.Len(t, doc.Activity, 1)
+	require.Equal(t, "deactivate", doc.Activity[0]["action"])
+}
+
+func TestExportUnknownUser(t *testing.T) {
+	q := &fakeExportQueries{user: db.GetUserByIDRow{ID: testUUID(1)}}
+	_, err := newExport(context.Background(), q, uuidString(testUUID(2)), time.Now())
+	var appErr *common.AppError
+	require.True(t, errors.As(err, &appErr))
+	require.Equal(t, httpStatusNotFound, appErr.HTTPStatus)
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog"
 
 	"github.com/noah-isme/backend-toko/internal/common"
 )
@@ -43,7 +44,7 @@ func (h *Handler) List(w http.ResponseWriter, r *http.Request) {
 	page, limit := common.ParsePagination(r, 20)
 	addresses, total, err := h.Service.List(r.Context(), userID, page, limit)
 	if err != nil {
-		h.writeError(w, err)
+		writeError(w, err)
 		return
 	}
 	common.JSON(w, http.StatusOK, map[string]any{
@@ -70,7 +71,7 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 	address, err := h.Service.Create(r.Context(), userID, toInput(req))
 	if err != nil {
-		h.writeError(w, err)
+		writeError(w, err)
 		return
 	}
 	common.JSON(w, http.StatusCreated, map[string]any{"data": address})
@@ -99,7 +100,7 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 	address, err := h.Service.Update(r.Context(), userID, addressID, toInput(req))
 	if err != nil {
-		h.writeError(w, err)
+		writeError(w, err)
 		return
 	}
 	common.JSON(w, http.StatusOK, map[string]any{"data": address})
@@ -122,13 +123,41 @@ func (h *Handler) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if err := h.Service.Delete(r.Context(), userID, addressID); err != nil {
-		h.writeError(w, err)
+		writeError(w, err)
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func (h *Handler) writeError(w http.ResponseWriter, err error) {
+// Export handles GET /api/v1/users/me/export, streaming the caller's data as
+// a downloadable JSON file.
+func (h *Handler) Export(w http.ResponseWriter, r *http.Request) {
+	if h.Service == nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "user service not configured", nil)
+		return
+	}
+	userID, ok := common.UserID(r.Context())
+	if !ok {
+		common.JSONError(w, http.StatusUnauthorized, "UNAUTHORIZED", "missing or invalid token", nil)
+		return
+	}
+	export, err := h.Service.Export(r.Context(), userID)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+export.Filename()+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	if err := export.Stream(r.Context(), w); err != nil {
+		// The status is already sent; the truncated body tells the client
+		// the download failed.
+		zerolog.Ctx(r.Context()).Error().Err(err).Msg("user export failed")
+	}
+}
+
+func writeError(w http.ResponseWriter, err error) {
 	var appErr *common.AppError
 	if errors.As(err, &appErr) {
 		status := appErr.HTTPStatus
@@ -152,3 +181,23 @@ func (h *Handler) writeError(w http.ResponseWriter, err error) {
 func toInput(req addressRequest) AddressInput {
 	return AddressInput(req)
 }
+
+// AdminHandler exposes account administration endpoints.
+type AdminHandler struct {
+	Service *Service
+}
+
+// Purge handles POST /api/v1/admin/users/{id}/purge, erasing an account
+// right away instead of waiting for the recovery window.
+func (h *AdminHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	if h.Service == nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "user service not configured", nil)
+		return
+	}
+	result, err := h.Service.Purge(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	common.JSON(w, http.StatusOK, map[string]any{"data": result})
+}
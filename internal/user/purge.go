@@ -0,0 +1,148 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/rs/zerolog"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	db "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+// AnonymizedUserID replaces the user on the orders and voucher usages of a
+// purged account, so order history and voucher counts survive the account.
+var AnonymizedUserID = pgtype.UUID{Valid: true}
+
+// PurgeResult reports what one account purge changed.
+type PurgeResult struct {
+	UserID string `json:"userId"`
+	Orders int64  `json:"ordersAnonymized"`
+}
+
+// Purge erases the account of userID: its orders and voucher usages are
+// moved to AnonymizedUserID with the recipient's name, phone, street address,
+// and notes scrubbed from each order, its carts are deleted, and the user row
+// goes with its sessions, addresses, reviews, favorites, and two-factor
+// secrets. Accounts with orders that are not yet delivered or canceled are
+// refused, since fulfilling them still needs the address.
+func (s *Service) Purge(ctx context.Context, userID string) (PurgeResult, error) {
+	uid, err := toUUID(userID)
+	if err != nil {
+		return PurgeResult{}, common.NewAppError("NOT_FOUND", "user not found", httpStatusNotFound, nil)
+	}
+	tx, err := s.pool.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return PurgeResult{}, err
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	qtx := s.queries.WithTx(tx)
+	if _, err := qtx.LockUserForPurge(ctx, uid); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return PurgeResult{}, common.NewAppError("NOT_FOUND", "user not found", httpStatusNotFound, nil)
+		}
+		return PurgeResult{}, err
+	}
+	open, err := qtx.CountOpenOrdersByUser(ctx, uid)
+	if err != nil {
+		return PurgeResult{}, err
+	}
+	if open > 0 {
+		return PurgeResult{}, common.NewAppError(common.CodeAccountHasOpenOrders, "account has orders in progress", httpStatusConflict, nil)
+	}
+	orders, err := qtx.AnonymizeOrdersByUser(ctx, db.AnonymizeOrdersByUserParams{Placeholder: AnonymizedUserID, UserID: uid})
+	if err != nil {
+		return PurgeResult{}, err
+	}
+	if err := qtx.AnonymizeVoucherUsagesByUser(ctx, db.AnonymizeVoucherUsagesByUserParams{Placeholder: AnonymizedUserID, UserID: uid}); err != nil {
+		return PurgeResult{}, err
+	}
+	if err := qtx.DeleteCartsByUser(ctx, uid); err != nil {
+		return PurgeResult{}, err
+	}
+	if err := qtx.DeleteUser(ctx, uid); err != nil {
+		return PurgeResult{}, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return PurgeResult{}, err
+	}
+	return PurgeResult{UserID: uuidString(uid), Orders: orders}, nil
+}
+
+// Purger erases accounts whose owners closed them longer ago than the
+// recovery window. Accounts with orders in progress wait for a later pass.
+type Purger struct {
+	Svc *Service
+	// After is the recovery window; accounts closed for longer are purged.
+	After time.Duration
+	// Interval between passes; zero runs hourly.
+	Interval  time.Duration
+	BatchSize int
+	Logger    zerolog.Logger
+	// Now overrides the clock in tests.
+	Now func() time.Time
+}
+
+// Run purges once immediately and then every Interval until ctx is done.
+func (p *Purger) Run(ctx context.Context) error {
+	interval := p.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		purged, err := p.PurgeOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			p.Logger.Error().Err(err).Int("purged", purged).Msg("account purge failed")
+		} else if purged > 0 {
+			p.Logger.Info().Int("purged", purged).Msg("account purge complete")
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeOnce purges up to BatchSize due accounts and returns how many went.
+// An account that gained an open order since it was listed is skipped.
+func (p *Purger) PurgeOnce(ctx context.Context) (int, error) {
+	if p == nil || p.Svc == nil {
+		return 0, nil
+	}
+	now := time.Now
+	if p.Now != nil {
+		now = p.Now
+	}
+	batch := p.BatchSize
+	if batch <= 0 {
+		batch = 100
+	}
+	ids, err := p.Svc.queries.ListUsersDueForPurge(ctx, db.ListUsersDueForPurgeParams{
+		Before:    pgtype.Timestamptz{Time: now().Add(-p.After), Valid: true},
+		BatchSize: int32(batch),
+	})
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, id := range ids {
+		if _, err := p.Svc.Purge(ctx, uuidString(id)); err != nil {
+			var appErr *common.AppError
+			if errors.As(err, &appErr) && appErr.Code == common.CodeAccountHasOpenOrders {
+				continue
+			}
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
@@ -19,6 +19,7 @@ const (
 	httpStatusBadRequest   = 400
 	httpStatusUnauthorized = 401
 	httpStatusNotFound     = 404
+	httpStatusConflict     = 409
 )
 
 // Address represents a user address in API-friendly format.
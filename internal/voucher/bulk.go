@@ -0,0 +1,192 @@
+package voucher
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/noah-isme/backend-toko/internal/common"
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+// Bulk generation limits. MaxBulkCodes guards against runaway requests; the
+// random part of each code defaults to DefaultBulkCodeLength characters.
+const (
+	MaxBulkCodes          = 10000
+	DefaultBulkCodeLength = 8
+	minBulkCodeLength     = 6
+	maxBulkCodeLength     = 32
+	bulkAttempts          = 5
+)
+
+// codeAlphabet leaves out characters that are easy to misread (0/O, 1/I/L).
+const codeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// ErrCodesExhausted is returned when unique codes could not be found within
+// the retry budget, usually because the random part is too short.
+var ErrCodesExhausted = errors.New("could not generate enough unique voucher codes")
+
+type batchCreator interface {
+	CreateVoucherBatch(ctx context.Context, arg dbgen.CreateVoucherBatchParams) ([]string, error)
+}
+
+type bulkPayload struct {
+	voucherPayload
+	Prefix string `json:"prefix"`
+	Count  int    `json:"count"`
+	Length int    `json:"length"`
+	Format string `json:"format"`
+}
+
+// GenerateBatch creates count single-use vouchers from template, each coded
+// prefix plus length random characters. Codes that collide with existing
+// vouchers are skipped by the insert and regenerated, so the returned codes
+// are unique both in the batch and across the table.
+func GenerateBatch(ctx context.Context, q batchCreator, template dbgen.CreateVoucherBatchParams, prefix string, count, length int) ([]string, error) {
+	created := make([]string, 0, count)
+	tried := make(map[string]struct{}, count)
+	for attempt := 0; attempt < bulkAttempts && len(created) < count; attempt++ {
+		codes := make([]string, 0, count-len(created))
+		for len(codes) < count-len(created) {
+			code, err := randomCode(prefix, length)
+			if err != nil {
+				return nil, err
+			}
+			if _, dup := tried[code]; dup {
+				continue
+			}
+			tried[code] = struct{}{}
+			codes = append(codes, code)
+		}
+		params := template
+		params.Codes = codes
+		inserted, err := q.CreateVoucherBatch(ctx, params)
+		if err != nil {
+			return nil, fmt.Errorf("create vouchers: %w", err)
+		}
+		created = append(created, inserted...)
+	}
+	if len(created) < count {
+		return nil, ErrCodesExhausted
+	}
+	return created, nil
+}
+
+func randomCode(prefix string, length int) (string, error) {
+	var b strings.Builder
+	b.Grow(len(prefix) + length)
+	b.WriteString(prefix)
+	size := big.NewInt(int64(len(codeAlphabet)))
+	for i := 0; i < length; i++ {
+		n, err := rand.Int(rand.Reader, size)
+		if err != nil {
+			return "", fmt.Errorf("generate voucher code: %w", err)
+		}
+		b.WriteByte(codeAlphabet[n.Int64()])
+	}
+	return b.String(), nil
+}
+
+// Bulk generates many unique single-use voucher codes sharing one template.
+// Codes are returned as JSON, or as a CSV download when format is "csv" or
+// the client accepts text/csv.
+func (h *Handler) Bulk(w http.ResponseWriter, r *http.Request) {
+	if h.Q == nil {
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "voucher queries not configured", nil)
+		return
+	}
+	var payload bulkPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", "invalid payload", nil)
+		return
+	}
+	if payload.Count <= 0 || payload.Count > MaxBulkCodes {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", fmt.Sprintf("count must be between 1 and %d", MaxBulkCodes), nil)
+		return
+	}
+	length := payload.Length
+	if length == 0 {
+		length = DefaultBulkCodeLength
+	}
+	if length < minBulkCodeLength || length > maxBulkCodeLength {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", fmt.Sprintf("length must be between %d and %d", minBulkCodeLength, maxBulkCodeLength), nil)
+		return
+	}
+	prefix := strings.ToUpper(strings.TrimSpace(payload.Prefix))
+	one := int32(1)
+	template := payload.voucherPayload
+	// Codes are generated later; buildCreateParams only needs one to validate.
+	template.Code = prefix + "BULK"
+	template.UsageLimit, template.PerUserLimit = &one, &one
+	params, err := buildCreateParams(template, h.DefaultPriority)
+	if err != nil {
+		common.JSONError(w, http.StatusBadRequest, "BAD_REQUEST", err.Error(), nil)
+		return
+	}
+	batch := dbgen.CreateVoucherBatchParams{
+		Value:        params.Value,
+		Kind:         params.Kind,
+		PercentBps:   params.PercentBps,
+		MinSpend:     params.MinSpend,
+		UsageLimit:   params.UsageLimit,
+		ValidFrom:    params.ValidFrom,
+		ValidTo:      params.ValidTo,
+		ProductIds:   params.ProductIds,
+		CategoryIds:  params.CategoryIds,
+		BrandIds:     params.BrandIds,
+		Combinable:   params.Combinable,
+		Priority:     params.Priority,
+		PerUserLimit: params.PerUserLimit,
+	}
+
+	ctx := r.Context()
+	var q batchCreator = h.Q
+	var tx pgx.Tx
+	if h.Pool != nil {
+		tx, err = h.Pool.BeginTx(ctx, pgx.TxOptions{})
+		if err != nil {
+			common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "failed to create vouchers", nil)
+			return
+		}
+		defer func() { _ = tx.Rollback(ctx) }()
+		q = dbgen.New(tx)
+	}
+	codes, err := GenerateBatch(ctx, q, batch, prefix, payload.Count, length)
+	if err != nil {
+		if errors.Is(err, ErrCodesExhausted) {
+			common.JSONError(w, http.StatusConflict, "CONFLICT", "could not generate unique codes; use a longer length", nil)
+			return
+		}
+		common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "failed to create vouchers", nil)
+		return
+	}
+	if tx != nil {
+		if err := tx.Commit(ctx); err != nil {
+			common.JSONError(w, http.StatusInternalServerError, "INTERNAL", "failed to create vouchers", nil)
+			return
+		}
+	}
+	h.invalidateCaches(ctx)
+
+	if strings.EqualFold(payload.Format, "csv") || strings.Contains(r.Header.Get("Accept"), "text/csv") {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="vouchers.csv"`)
+		w.WriteHeader(http.StatusCreated)
+		out := csv.NewWriter(w)
+		_ = out.Write([]string{"code"})
+		for _, code := range codes {
+			_ = out.Write([]string{code})
+		}
+		out.Flush()
+		return
+	}
+	common.JSON(w, http.StatusCreated, map[string]any{"data": map[string]any{"count": len(codes), "codes": codes}})
+}
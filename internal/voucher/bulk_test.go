@@ -0,0 +1,65 @@
+package voucher
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+)
+
+// batchStore accepts codes it has not seen and pretends the first taken
+// codes of the first call already exist; a negative taken rejects every code.
+type batchStore struct {
+	codes map[string]struct{}
+	taken int
+	calls int
+}
+
+func (s *batchStore) CreateVoucherBatch(_ context.Context, arg dbgen.CreateVoucherBatchParams) ([]string, error) {
+	s.calls++
+	var inserted []string
+	for i, code := range arg.Codes {
+		if _, exists := s.codes[code]; exists || (s.calls == 1 && i < s.taken) || s.taken < 0 {
+			continue
+		}
+		s.codes[code] = struct{}{}
+		inserted = append(inserted, code)
+	}
+	return inserted, nil
+}
+
+func TestGenerateBatchRegeneratesCollisions(t *testing.T) {
+	store := &batchStore{codes: map[string]struct{}{}, taken: 3}
+	codes, err := GenerateBatch(context.Background(), store, dbgen.CreateVoucherBatchParams{Value: 10000}, "INFL", 50, DefaultBulkCodeLength)
+	if err != nil {
+		t.Fatalf("generate: %v", err)
+	}
+	if len(codes) != 50 {
+		t.Fatalf("expected 50 codes, got %d", len(codes))
+	}
+	seen := map[string]struct{}{}
+	for _, code := range codes {
+		if !strings.HasPrefix(code, "INFL") || len(code) != len("INFL")+DefaultBulkCodeLength {
+			t.Fatalf("unexpected code %q", code)
+		}
+		if _, dup := seen[code]; dup {
+			t.Fatalf("duplicate code %q", code)
+		}
+		seen[code] = struct{}{}
+	}
+	if store.calls < 2 {
+		t.Fatalf("expected colliding codes to be regenerated, got %d calls", store.calls)
+	}
+}
+
+func TestGenerateBatchGivesUp(t *testing.T) {
+	store := &batchStore{codes: map[string]struct{}{}, taken: -1}
+	if _, err := GenerateBatch(context.Background(), store, dbgen.CreateVoucherBatchParams{}, "X", 5, DefaultBulkCodeLength); !errors.Is(err, ErrCodesExhausted) {
+		t.Fatalf("expected ErrCodesExhausted, got %v", err)
+	}
+	if store.calls != bulkAttempts {
+		t.Fatalf("expected %d attempts, got %d", bulkAttempts, store.calls)
+	}
+}
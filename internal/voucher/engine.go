@@ -6,6 +6,8 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"github.com/noah-isme/backend-toko/internal/pricing"
 )
 
 var (
@@ -124,8 +126,9 @@ func ruleMatchesItem(r Rule, it Item) bool {
 	return len(r.ProductIDs) == 0 && len(r.CategoryIDs) == 0 && len(r.BrandIDs) == 0
 }
 
-// Compute determines the discount amount based on the rule and eligible subtotal.
-func Compute(eligible int64, r Rule) int64 {
+// Compute determines the discount amount based on the rule and eligible
+// subtotal. Percentage discounts are rounded with rounding.
+func Compute(eligible int64, r Rule, rounding pricing.Rounding) int64 {
 	if eligible <= 0 {
 		return 0
 	}
@@ -134,7 +137,7 @@ func Compute(eligible int64, r Rule) int64 {
 		if r.PercentBps == nil || *r.PercentBps <= 0 {
 			return 0
 		}
-		discount = (eligible * int64(*r.PercentBps)) / 10000
+		discount = rounding.ApplyBps(eligible, int(*r.PercentBps))
 	}
 	if discount > eligible {
 		discount = eligible
@@ -4,12 +4,14 @@ import (
 	"testing"
 
 	"github.com/google/uuid"
+
+	"github.com/noah-isme/backend-toko/internal/pricing"
 )
 
 func TestComputePercent(t *testing.T) {
 	percent := int32(2000)
 	rule := Rule{Kind: "percent", PercentBps: &percent}
-	discount := Compute(100_000, rule)
+	discount := Compute(100_000, rule, pricing.RoundFloor)
 	if discount != 20_000 {
 		t.Fatalf("expected 20000 discount, got %d", discount)
 	}
@@ -13,6 +13,7 @@ import (
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/noah-isme/backend-toko/internal/analytics"
 	"github.com/noah-isme/backend-toko/internal/catalog"
@@ -23,6 +24,7 @@ import (
 // Handler exposes administrative voucher management endpoints.
 type Handler struct {
 	Q               dbgen.Querier
+	Pool            *pgxpool.Pool
 	Svc             *Service
 	DefaultPriority int
 	CatalogCache    *catalog.Cache
@@ -12,6 +12,7 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 
 	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
+	"github.com/noah-isme/backend-toko/internal/pricing"
 )
 
 // Querier captures the database methods required by the voucher service.
@@ -35,6 +36,11 @@ type Service struct {
 	Q                   Querier
 	Now                 func() time.Time
 	DefaultPerUserLimit int
+	// ReleaseOnCancel stops usages of canceled or refunded orders from
+	// counting against the per-user limit.
+	ReleaseOnCancel bool
+	// Rounding rounds percentage discounts; the zero value floors.
+	Rounding pricing.Rounding
 }
 
 // Preview performs a dry-run evaluation for the given cart context.
@@ -63,7 +69,7 @@ func (s *Service) Preview(ctx context.Context, code string, userID *string, cart
 		if err != nil {
 			return PreviewResult{}, fmt.Errorf("invalid user id: %w", err)
 		}
-		used, err := s.Q.CountVoucherUsageByUser(ctx, dbgen.CountVoucherUsageByUserParams{VoucherID: voucher.ID, UserID: userUUID})
+		used, err := s.Q.CountVoucherUsageByUser(ctx, dbgen.CountVoucherUsageByUserParams{VoucherID: voucher.ID, UserID: userUUID, ExcludeReleased: s.ReleaseOnCancel})
 		if err != nil {
 			return PreviewResult{}, err
 		}
@@ -80,7 +86,7 @@ func (s *Service) Preview(ctx context.Context, code string, userID *string, cart
 	if eligible <= 0 {
 		return PreviewResult{}, ErrNotEligible
 	}
-	discount := Compute(eligible, rule)
+	discount := Compute(eligible, rule, s.Rounding)
 	if discount <= 0 {
 		return PreviewResult{}, ErrNotEligible
 	}
@@ -89,6 +95,18 @@ func (s *Service) Preview(ctx context.Context, code string, userID *string, cart
 
 // Settle records voucher usage at order payment time ensuring idempotency.
 func (s *Service) Settle(ctx context.Context, code string, orderID pgtype.UUID, userID pgtype.UUID, amount int64) error {
+	return s.redeem(ctx, code, orderID, userID, amount, false)
+}
+
+// Redeem records voucher usage when an order is placed. It behaves like
+// Settle but also rejects the voucher once its usage limit is reached. Called
+// with a transaction-bound Querier, the row lock serializes concurrent
+// redemptions and the usage row and count commit or roll back with the order.
+func (s *Service) Redeem(ctx context.Context, code string, orderID pgtype.UUID, userID pgtype.UUID, amount int64) error {
+	return s.redeem(ctx, code, orderID, userID, amount, true)
+}
+
+func (s *Service) redeem(ctx context.Context, code string, orderID pgtype.UUID, userID pgtype.UUID, amount int64, enforceLimit bool) error {
 	if s == nil || s.Q == nil {
 		return errors.New("voucher service not configured")
 	}
@@ -112,6 +130,9 @@ func (s *Service) Settle(ctx context.Context, code string, orderID pgtype.UUID,
 	if !errors.Is(err, pgx.ErrNoRows) {
 		return err
 	}
+	if enforceLimit && voucher.UsageLimit.Valid && voucher.UsedCount >= voucher.UsageLimit.Int32 {
+		return ErrUsageLimitReached
+	}
 	params := dbgen.InsertVoucherUsageParams{VoucherID: voucher.ID, OrderID: orderID, Amount: amount}
 	if userID.Valid {
 		params.UserID = userID
@@ -119,8 +140,7 @@ func (s *Service) Settle(ctx context.Context, code string, orderID pgtype.UUID,
 	if err := s.Q.InsertVoucherUsage(ctx, params); err != nil {
 		return err
 	}
-	_ = s.Q.IncreaseVoucherUsedCount(ctx, voucher.ID)
-	return nil
+	return s.Q.IncreaseVoucherUsedCount(ctx, voucher.ID)
 }
 
 func (s *Service) now() time.Time {
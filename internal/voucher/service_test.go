@@ -10,13 +10,16 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 
+	"github.com/noah-isme/backend-toko/internal/clock"
 	dbgen "github.com/noah-isme/backend-toko/internal/db/gen"
 )
 
 type stubQueries struct {
 	voucher    dbgen.Voucher
 	usageCount int64
-	usageErr   error
+	// canceledUsage is how much of usageCount belongs to canceled orders.
+	canceledUsage int64
+	usageErr      error
 }
 
 func (s *stubQueries) GetVoucherByCodeForUpdate(ctx context.Context, code string) (dbgen.Voucher, error) {
@@ -30,6 +33,9 @@ func (s *stubQueries) CountVoucherUsageByUser(ctx context.Context, arg dbgen.Cou
 	if s.usageErr != nil {
 		return 0, s.usageErr
 	}
+	if arg.ExcludeReleased {
+		return s.usageCount - s.canceledUsage, nil
+	}
 	return s.usageCount, nil
 }
 
@@ -62,6 +68,22 @@ func TestPerUserLimit(t *testing.T) {
 	}
 }
 
+func TestPerUserLimitReleasedByCanceledOrder(t *testing.T) {
+	v := newVoucher(1000, 1, 0)
+	v.PerUserLimit = pgtype.Int4{Int32: 1, Valid: true}
+	userID := uuid.New().String()
+	stub := &stubQueries{voucher: v, usageCount: 1, canceledUsage: 1}
+
+	svc := &Service{Q: stub}
+	if _, err := svc.Preview(context.Background(), "PROMO", &userID, 10_000, []Item{{Subtotal: 10_000}}); !errors.Is(err, ErrPerUserLimitReached) {
+		t.Fatalf("expected the canceled order to keep counting, got %v", err)
+	}
+	svc.ReleaseOnCancel = true
+	if _, err := svc.Preview(context.Background(), "PROMO", &userID, 10_000, []Item{{Subtotal: 10_000}}); err != nil {
+		t.Fatalf("expected the canceled order to free the slot, got %v", err)
+	}
+}
+
 func newVoucher(value int64, usedCount int32, percent int32) dbgen.Voucher {
 	return dbgen.Voucher{
 		ID:        uuidToPg(uuid.New()),
@@ -78,3 +100,28 @@ func newVoucher(value int64, usedCount int32, percent int32) dbgen.Voucher {
 func uuidToPg(id uuid.UUID) pgtype.UUID {
 	return pgtype.UUID{Bytes: id, Valid: true}
 }
+
+func TestPreviewValidityWindow(t *testing.T) {
+	start := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(start.Add(-time.Minute))
+	v := newVoucher(1000, 0, 0)
+	v.ValidFrom = pgtype.Timestamptz{Time: start, Valid: true}
+	v.ValidTo = pgtype.Timestamptz{Time: start.Add(24 * time.Hour), Valid: true}
+	svc := &Service{Q: &stubQueries{voucher: v}, Now: fake.Now}
+	preview := func() error {
+		_, err := svc.Preview(context.Background(), "PROMO", nil, 10_000, []Item{{Subtotal: 10_000}})
+		return err
+	}
+
+	if err := preview(); !errors.Is(err, ErrVoucherInactive) {
+		t.Fatalf("expected ErrVoucherInactive before the window, got %v", err)
+	}
+	fake.Advance(time.Minute)
+	if err := preview(); err != nil {
+		t.Fatalf("expected the voucher to apply at ValidFrom, got %v", err)
+	}
+	fake.Set(start.Add(24*time.Hour + time.Second))
+	if err := preview(); !errors.Is(err, ErrVoucherExpired) {
+		t.Fatalf("expected ErrVoucherExpired after the window, got %v", err)
+	}
+}
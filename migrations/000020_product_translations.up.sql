@@ -0,0 +1,13 @@
+CREATE TABLE product_translations (
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    locale TEXT NOT NULL,
+    title TEXT NOT NULL,
+    description TEXT,
+    badges TEXT[],
+    specs JSONB,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (product_id, locale)
+);
+
+CREATE INDEX idx_product_translations_locale ON product_translations(locale);
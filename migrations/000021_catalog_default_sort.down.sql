@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_mv_top_products_product;
+
+ALTER TABLE categories DROP COLUMN IF EXISTS default_sort;
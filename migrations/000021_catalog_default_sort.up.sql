@@ -0,0 +1,3 @@
+ALTER TABLE categories ADD COLUMN IF NOT EXISTS default_sort TEXT;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mv_top_products_product ON mv_top_products(product_id);
@@ -0,0 +1,2 @@
+-- Variant attributes canonicalised by the up migration are left as they are.
+ALTER TABLE products DROP COLUMN IF EXISTS option_schema;
@@ -0,0 +1,62 @@
+ALTER TABLE products ADD COLUMN IF NOT EXISTS option_schema JSONB NOT NULL DEFAULT '[]'::jsonb;
+
+-- Backfill schemas from existing variant attributes. A product is only
+-- backfilled when every variant carries the same scalar keys once folded to
+-- trimmed lower case and no value differs from another only by case; other
+-- products keep an empty schema and must be fixed by an admin.
+CREATE TEMP TABLE option_backfill_attrs AS
+SELECT v.id AS variant_id,
+       v.product_id,
+       lower(btrim(a.key)) AS name,
+       btrim(a.value #>> '{}') AS value,
+       jsonb_typeof(a.value) AS kind
+FROM product_variants v
+CROSS JOIN LATERAL jsonb_each(CASE WHEN jsonb_typeof(v.attributes) = 'object' THEN v.attributes ELSE '{}'::jsonb END) a;
+
+CREATE TEMP TABLE option_backfill_products AS
+SELECT DISTINCT product_id FROM option_backfill_attrs
+EXCEPT (
+    SELECT product_id FROM product_variants WHERE jsonb_typeof(attributes) <> 'object'
+    UNION
+    SELECT product_id FROM option_backfill_attrs
+    WHERE kind NOT IN ('string', 'number', 'boolean') OR name = '' OR value = ''
+    UNION
+    SELECT product_id FROM option_backfill_attrs GROUP BY product_id, variant_id, name HAVING count(*) > 1
+    UNION
+    SELECT product_id FROM option_backfill_attrs GROUP BY product_id, name
+    HAVING count(DISTINCT value) <> count(DISTINCT lower(value))
+    UNION
+    SELECT v.product_id
+    FROM product_variants v
+    LEFT JOIN option_backfill_attrs a ON a.variant_id = v.id
+    GROUP BY v.product_id, v.id
+    HAVING count(a.name) <> (SELECT count(DISTINCT x.name) FROM option_backfill_attrs x WHERE x.product_id = v.product_id)
+);
+
+UPDATE products p
+SET option_schema = s.schema
+FROM (
+    SELECT product_id, jsonb_agg(jsonb_build_object('name', name, 'values', to_jsonb(vals)) ORDER BY name) AS schema
+    FROM (
+        SELECT a.product_id, a.name, array_agg(DISTINCT a.value ORDER BY a.value) AS vals
+        FROM option_backfill_attrs a
+        JOIN option_backfill_products USING (product_id)
+        GROUP BY a.product_id, a.name
+    ) options
+    GROUP BY product_id
+) s
+WHERE p.id = s.product_id;
+
+-- Rewrite the backfilled variants to the canonical keys and string values.
+UPDATE product_variants v
+SET attributes = c.attrs
+FROM (
+    SELECT a.variant_id, jsonb_object_agg(a.name, a.value) AS attrs
+    FROM option_backfill_attrs a
+    JOIN option_backfill_products USING (product_id)
+    GROUP BY a.variant_id
+) c
+WHERE v.id = c.variant_id;
+
+DROP TABLE option_backfill_products;
+DROP TABLE option_backfill_attrs;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS variant_bundle_components;
+DROP TABLE IF EXISTS variant_bundles;
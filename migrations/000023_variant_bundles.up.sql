@@ -0,0 +1,18 @@
+-- A bundle is a variant sold as one SKU that ships several component
+-- variants. Its own stock column is ignored; availability is derived from the
+-- components and payment settlement decrements each component instead.
+CREATE TABLE IF NOT EXISTS variant_bundles (
+    variant_id UUID PRIMARY KEY REFERENCES product_variants(id) ON DELETE CASCADE,
+    pricing TEXT NOT NULL DEFAULT 'fixed' CHECK (pricing IN ('fixed', 'sum')),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS variant_bundle_components (
+    bundle_variant_id UUID NOT NULL REFERENCES variant_bundles(variant_id) ON DELETE CASCADE,
+    component_variant_id UUID NOT NULL REFERENCES product_variants(id) ON DELETE RESTRICT,
+    qty INT NOT NULL CHECK (qty > 0),
+    PRIMARY KEY (bundle_variant_id, component_variant_id),
+    CHECK (bundle_variant_id <> component_variant_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_variant_bundle_components_component ON variant_bundle_components(component_variant_id);
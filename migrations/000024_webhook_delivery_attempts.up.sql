@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS webhook_delivery_attempts (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  delivery_id UUID NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+  attempt INT NOT NULL,
+  response_status INT,
+  response_body TEXT,
+  error TEXT,
+  duration_ms INT NOT NULL DEFAULT 0,
+  created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+CREATE INDEX IF NOT EXISTS idx_webhook_delivery_attempts_delivery ON webhook_delivery_attempts(delivery_id, created_at);
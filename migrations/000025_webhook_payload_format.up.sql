@@ -0,0 +1,5 @@
+-- json keeps the nested envelope sent today; json_flat and form flatten it to
+-- dot-separated keys for consumers that cannot read nested bodies.
+ALTER TABLE webhook_endpoints
+  ADD COLUMN IF NOT EXISTS format TEXT NOT NULL DEFAULT 'json'
+  CHECK (format IN ('json', 'json_flat', 'form'));
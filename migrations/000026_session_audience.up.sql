@@ -0,0 +1,3 @@
+-- audience records which client a session was issued for so refreshed access
+-- tokens keep the same aud claim. NULL means the default audience.
+ALTER TABLE sessions ADD COLUMN IF NOT EXISTS audience TEXT;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_webhook_deliveries_aggregate_seq;
+DROP TABLE IF EXISTS webhook_sequences;
+ALTER TABLE webhook_deliveries
+  DROP COLUMN IF EXISTS sequence,
+  DROP COLUMN IF EXISTS aggregate_id;
+ALTER TABLE webhook_endpoints DROP COLUMN IF EXISTS ordered;
@@ -0,0 +1,20 @@
+-- Ordered endpoints receive deliveries for the same aggregate one at a time,
+-- in sequence order. Every delivery carries a per endpoint and aggregate
+-- sequence so consumers can spot gaps either way.
+ALTER TABLE webhook_endpoints
+  ADD COLUMN IF NOT EXISTS ordered BOOLEAN NOT NULL DEFAULT false;
+
+ALTER TABLE webhook_deliveries
+  ADD COLUMN IF NOT EXISTS aggregate_id UUID,
+  ADD COLUMN IF NOT EXISTS sequence BIGINT;
+
+CREATE TABLE IF NOT EXISTS webhook_sequences (
+  endpoint_id UUID NOT NULL REFERENCES webhook_endpoints(id) ON DELETE CASCADE,
+  aggregate_id UUID NOT NULL,
+  last_sequence BIGINT NOT NULL,
+  PRIMARY KEY (endpoint_id, aggregate_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_aggregate_seq
+  ON webhook_deliveries(endpoint_id, aggregate_id, sequence)
+  WHERE aggregate_id IS NOT NULL;
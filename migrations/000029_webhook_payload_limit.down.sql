@@ -0,0 +1,3 @@
+ALTER TABLE webhook_endpoints
+  DROP COLUMN IF EXISTS oversize_policy,
+  DROP COLUMN IF EXISTS max_payload_bytes;
@@ -0,0 +1,7 @@
+-- max_payload_bytes caps the body sent to an endpoint (0 uses the server
+-- default). Oversized events are either truncated to a link for fetching the
+-- full payload or split into chunks the consumer reassembles.
+ALTER TABLE webhook_endpoints
+  ADD COLUMN IF NOT EXISTS max_payload_bytes INT NOT NULL DEFAULT 0 CHECK (max_payload_bytes >= 0),
+  ADD COLUMN IF NOT EXISTS oversize_policy TEXT NOT NULL DEFAULT 'truncate'
+  CHECK (oversize_policy IN ('truncate', 'split'));
@@ -0,0 +1,5 @@
+-- delivery_mode 'sync' attempts delivery inline when the event is scheduled
+-- and falls back to the async queue only when that attempt fails.
+ALTER TABLE webhook_endpoints
+  ADD COLUMN IF NOT EXISTS delivery_mode TEXT NOT NULL DEFAULT 'async'
+  CHECK (delivery_mode IN ('async', 'sync'));
@@ -0,0 +1,18 @@
+-- default_variant_id is the variant a cart line resolves to when the client
+-- names only the product. Products with variants but no default reject such
+-- lines with VARIANT_REQUIRED.
+ALTER TABLE products
+  ADD COLUMN IF NOT EXISTS default_variant_id UUID REFERENCES product_variants(id) ON DELETE SET NULL;
+
+-- A product with a single variant defaults to it; products with several are
+-- left for an admin to choose.
+UPDATE products p
+SET default_variant_id = v.id
+FROM (
+    SELECT product_id, min(id::text)::uuid AS id
+    FROM product_variants
+    GROUP BY product_id
+    HAVING count(*) = 1
+) v
+WHERE p.id = v.product_id
+  AND p.default_variant_id IS NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE cart_items
+  DROP COLUMN IF EXISTS version;
+ALTER TABLE carts
+  DROP COLUMN IF EXISTS version;
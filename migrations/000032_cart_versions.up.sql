@@ -0,0 +1,7 @@
+-- version columns back optimistic locking: writers state the version they
+-- read and the update only applies when it still matches. A cart's version
+-- moves whenever its items or voucher change.
+ALTER TABLE carts
+  ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
+ALTER TABLE cart_items
+  ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 1;
@@ -0,0 +1,9 @@
+ALTER TABLE order_items
+  DROP COLUMN IF EXISTS preorder;
+ALTER TABLE products
+  DROP CONSTRAINT IF EXISTS products_availability_window;
+ALTER TABLE products
+  DROP COLUMN IF EXISTS preorder_ships_at,
+  DROP COLUMN IF EXISTS preorder,
+  DROP COLUMN IF EXISTS available_to,
+  DROP COLUMN IF EXISTS available_from;
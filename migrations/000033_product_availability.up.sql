@@ -0,0 +1,17 @@
+-- available_from/available_to bound when a product can be bought. Products
+-- past available_to drop out of listings; before available_from they are
+-- listed but only sellable when preorder is set, in which case
+-- preorder_ships_at is the expected ship date shown to shoppers.
+ALTER TABLE products
+  ADD COLUMN IF NOT EXISTS available_from TIMESTAMPTZ,
+  ADD COLUMN IF NOT EXISTS available_to TIMESTAMPTZ,
+  ADD COLUMN IF NOT EXISTS preorder BOOLEAN NOT NULL DEFAULT false,
+  ADD COLUMN IF NOT EXISTS preorder_ships_at TIMESTAMPTZ;
+
+ALTER TABLE products
+  ADD CONSTRAINT products_availability_window CHECK (available_from IS NULL OR available_to IS NULL OR available_from < available_to);
+
+-- Preorder lines are ordered ahead of stock, so settlement does not take
+-- stock for them.
+ALTER TABLE order_items
+  ADD COLUMN IF NOT EXISTS preorder BOOLEAN NOT NULL DEFAULT false;
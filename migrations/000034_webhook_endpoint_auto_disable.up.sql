@@ -0,0 +1,7 @@
+-- consecutive_failures counts failed delivery attempts since the endpoint's
+-- last success; the dispatcher deactivates the endpoint once it passes the
+-- configured threshold and records why in disabled_reason.
+ALTER TABLE webhook_endpoints
+  ADD COLUMN IF NOT EXISTS consecutive_failures INT NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS disabled_reason TEXT,
+  ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMPTZ;
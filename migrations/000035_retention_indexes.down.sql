@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_domain_events_occurred;
+DROP INDEX IF EXISTS idx_webhook_deliveries_event;
+DROP INDEX IF EXISTS idx_webhook_deliveries_status_updated;
@@ -0,0 +1,5 @@
+-- Support the retention purge: finding terminal deliveries by age and
+-- checking whether an event is still referenced by any delivery.
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_status_updated ON webhook_deliveries(status, updated_at);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event ON webhook_deliveries(event_id);
+CREATE INDEX IF NOT EXISTS idx_domain_events_occurred ON domain_events(occurred_at);
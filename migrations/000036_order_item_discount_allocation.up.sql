@@ -0,0 +1,4 @@
+-- Share of the order's voucher discount each line absorbed, so partial
+-- refunds and per-item revenue can be computed exactly.
+ALTER TABLE order_items
+  ADD COLUMN IF NOT EXISTS discount_allocated BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,7 @@
+-- When each analytics materialized view was last refreshed through the API,
+-- so reports can tell consumers how fresh their data is.
+CREATE TABLE IF NOT EXISTS analytics_view_refreshes (
+  view_name TEXT PRIMARY KEY,
+  refreshed_at TIMESTAMPTZ NOT NULL,
+  duration_ms BIGINT NOT NULL DEFAULT 0
+);
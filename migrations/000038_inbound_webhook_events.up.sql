@@ -0,0 +1,9 @@
+-- Provider callbacks that were claimed or processed, so a retry of the same
+-- event is acknowledged without running it again, even days later.
+CREATE TABLE IF NOT EXISTS inbound_webhook_events (
+  source TEXT NOT NULL,
+  dedup_key TEXT NOT NULL,
+  claimed_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+  processed_at TIMESTAMPTZ,
+  PRIMARY KEY (source, dedup_key)
+);
@@ -0,0 +1 @@
+DROP MATERIALIZED VIEW IF EXISTS mv_product_copurchase;
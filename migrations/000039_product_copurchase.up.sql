@@ -0,0 +1,14 @@
+-- Pairs of products bought in the same paid order, for "frequently bought
+-- together" recommendations. Refreshed with the other analytics views.
+CREATE MATERIALIZED VIEW IF NOT EXISTS mv_product_copurchase AS
+SELECT a.product_id,
+       b.product_id AS other_product_id,
+       COUNT(DISTINCT a.order_id) AS orders
+FROM order_items a
+JOIN order_items b ON b.order_id = a.order_id AND b.product_id <> a.product_id
+JOIN orders o ON o.id = a.order_id AND o.status = 'PAID'
+GROUP BY a.product_id, b.product_id;
+
+-- REFRESH ... CONCURRENTLY needs a unique index.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_mv_product_copurchase_pair ON mv_product_copurchase(product_id, other_product_id);
+CREATE INDEX IF NOT EXISTS idx_mv_product_copurchase_orders ON mv_product_copurchase(product_id, orders DESC);
@@ -0,0 +1,4 @@
+-- Catalog unit price of each line when the order was placed, next to the
+-- unit price charged, so price disputes can be settled from the order alone.
+ALTER TABLE order_items
+  ADD COLUMN IF NOT EXISTS catalog_unit_price BIGINT;
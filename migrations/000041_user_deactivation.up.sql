@@ -0,0 +1,7 @@
+-- Accounts closed by their owner are soft-deleted first and can be recovered
+-- until the worker purges them.
+ALTER TABLE users
+  ADD COLUMN IF NOT EXISTS deactivated_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_users_deactivated ON users (deactivated_at)
+  WHERE deactivated_at IS NOT NULL;
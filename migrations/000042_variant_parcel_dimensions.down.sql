@@ -0,0 +1,5 @@
+ALTER TABLE product_variants
+  DROP COLUMN IF EXISTS height_cm,
+  DROP COLUMN IF EXISTS width_cm,
+  DROP COLUMN IF EXISTS length_cm,
+  DROP COLUMN IF EXISTS weight_gram;
@@ -0,0 +1,7 @@
+-- Shipping quotes weigh the cart from its variants; a NULL weight falls back
+-- to the configured default and NULL dimensions skip volumetric weight.
+ALTER TABLE product_variants
+  ADD COLUMN IF NOT EXISTS weight_gram INTEGER CHECK (weight_gram > 0),
+  ADD COLUMN IF NOT EXISTS length_cm INTEGER CHECK (length_cm > 0),
+  ADD COLUMN IF NOT EXISTS width_cm INTEGER CHECK (width_cm > 0),
+  ADD COLUMN IF NOT EXISTS height_cm INTEGER CHECK (height_cm > 0);
@@ -0,0 +1,9 @@
+-- Per-user email opt-outs by preference category. A missing row means the
+-- category's default, which is to send.
+CREATE TABLE IF NOT EXISTS user_email_preferences (
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    category TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (user_id, category)
+);
@@ -0,0 +1,3 @@
+ALTER TABLE webhook_endpoints
+  DROP COLUMN IF EXISTS permanent_statuses,
+  DROP COLUMN IF EXISTS retry_statuses;
@@ -0,0 +1,6 @@
+-- Per-endpoint overrides of how failed responses are classified: statuses in
+-- retry_statuses are retried even when they would be permanent, and statuses
+-- in permanent_statuses go straight to the DLQ.
+ALTER TABLE webhook_endpoints
+  ADD COLUMN IF NOT EXISTS retry_statuses INT[] NOT NULL DEFAULT '{}',
+  ADD COLUMN IF NOT EXISTS permanent_statuses INT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,3 @@
+ALTER TABLE webhook_endpoints
+  DROP COLUMN IF EXISTS custom_headers,
+  DROP COLUMN IF EXISTS custom_header_names;
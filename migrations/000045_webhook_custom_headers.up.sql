@@ -0,0 +1,6 @@
+-- Extra headers sent with every delivery to an endpoint. The names are kept
+-- in the clear for display; the name/value map is sealed with AES-GCM since
+-- values are usually credentials.
+ALTER TABLE webhook_endpoints
+  ADD COLUMN IF NOT EXISTS custom_header_names TEXT[] NOT NULL DEFAULT '{}',
+  ADD COLUMN IF NOT EXISTS custom_headers BYTEA;
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS trg_product_variants_price_history ON product_variants;
+DROP TRIGGER IF EXISTS trg_products_price_history ON products;
+DROP FUNCTION IF EXISTS record_price_change();
+DROP TABLE IF EXISTS price_history;
@@ -0,0 +1,44 @@
+-- Every product and variant price change, for pricing audits, price-drop
+-- badges, and price trends. A trigger records the changes, so prices edited
+-- outside the API (seeds, imports, manual fixes) are captured too.
+CREATE TABLE IF NOT EXISTS price_history (
+  id BIGSERIAL PRIMARY KEY,
+  tenant_id UUID REFERENCES tenants(id),
+  product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+  -- NULL for a change of the product's own price.
+  variant_id UUID REFERENCES product_variants(id) ON DELETE CASCADE,
+  old_price BIGINT NOT NULL,
+  new_price BIGINT NOT NULL,
+  changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_history_product ON price_history(product_id, changed_at DESC, id DESC);
+
+CREATE OR REPLACE FUNCTION record_price_change() RETURNS trigger AS $$
+BEGIN
+  IF TG_TABLE_NAME = 'products' THEN
+    INSERT INTO price_history (tenant_id, product_id, old_price, new_price)
+    VALUES (NEW.tenant_id, NEW.id, OLD.price, NEW.price);
+  ELSE
+    INSERT INTO price_history (tenant_id, product_id, variant_id, old_price, new_price)
+    SELECT p.tenant_id, NEW.product_id, NEW.id, OLD.price, NEW.price
+    FROM products p
+    WHERE p.id = NEW.product_id;
+  END IF;
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_products_price_history ON products;
+CREATE TRIGGER trg_products_price_history
+  AFTER UPDATE OF price ON products
+  FOR EACH ROW
+  WHEN (OLD.price IS DISTINCT FROM NEW.price)
+  EXECUTE FUNCTION record_price_change();
+
+DROP TRIGGER IF EXISTS trg_product_variants_price_history ON product_variants;
+CREATE TRIGGER trg_product_variants_price_history
+  AFTER UPDATE OF price ON product_variants
+  FOR EACH ROW
+  WHEN (OLD.price IS DISTINCT FROM NEW.price)
+  EXECUTE FUNCTION record_price_change();
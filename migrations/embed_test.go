@@ -0,0 +1,23 @@
+package migrations_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/noah-isme/backend-toko/migrations"
+)
+
+func TestLatestMatchesNewestFile(t *testing.T) {
+	names, err := filepath.Glob("*.up.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, names)
+	sort.Strings(names)
+
+	latest, err := migrations.Latest()
+	require.NoError(t, err)
+	require.Regexp(t, fmt.Sprintf(`^0*%d_`, latest), names[len(names)-1])
+}